@@ -0,0 +1,140 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/satori/go.uuid"
+)
+
+// TYPE DEFINITIONS
+
+// AuditEntry records a mutation made through the API, with the resource state before and after it.
+// Before is empty when the resource is created, and After is empty when the resource is removed.
+type AuditEntry struct {
+	ID        string          `json:"id,omitempty"`
+	Actor     string          `json:"actor,omitempty"`
+	RequestID string          `json:"requestId,omitempty"`
+	Action    string          `json:"action,omitempty"`
+	Urn       string          `json:"urn,omitempty"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	CreateAt  time.Time       `json:"createAt,omitempty"`
+}
+
+func (ae AuditEntry) GetUrn() string {
+	return ae.Urn
+}
+
+func (ae AuditEntry) String() string {
+	return fmt.Sprintf("[id: %v, actor: %v, requestId: %v, action: %v, urn: %v, createAt: %v]",
+		ae.ID, ae.Actor, ae.RequestID, ae.Action, ae.Urn, ae.CreateAt.Format("2006-01-02 15:04:05 MST"))
+}
+
+// auditRelation is the state recorded for relation mutations, like memberships or policy attachments.
+// Urn identifies the related resource.
+type auditRelation struct {
+	Urn       string     `json:"urn"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// AUDIT API IMPLEMENTATION
+
+func (api WorkerAPI) ListAuditEntries(requestInfo RequestInfo, filter *Filter) ([]AuditEntry, int, error) {
+	// Validate fields
+	var total int
+	orderByValidColumns := api.AuditRepo.OrderByValidColumns(AUDIT_ACTION_LIST_ENTRIES)
+	err := validateFilter(filter, orderByValidColumns)
+	if err != nil {
+		return nil, total, err
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, total, &Error{
+			Code: INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: From %v must be before To %v",
+				filter.From.Format(time.RFC3339), filter.To.Format(time.RFC3339)),
+		}
+	}
+
+	// Call repo to retrieve the audit entries
+	entries, total, err := api.AuditRepo.GetAuditEntriesFiltered(filter)
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, total, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	// Check restrictions, users only get the entries of the resources allowed by their policies
+	resourcesToAuthorize := []Resource{}
+	for _, entry := range entries {
+		resourcesToAuthorize = append(resourcesToAuthorize, entry)
+	}
+	authorizedResources, err := api.getAuthorizedResources(requestInfo, "*", AUDIT_ACTION_LIST_ENTRIES, resourcesToAuthorize)
+	if err != nil {
+		return nil, total, err
+	}
+	entriesFiltered := []AuditEntry{}
+	for _, r := range authorizedResources {
+		entriesFiltered = append(entriesFiltered, r.(AuditEntry))
+	}
+
+	return entriesFiltered, total, nil
+}
+
+// PRIVATE HELPER METHODS
+
+// audit stores an entry for a mutation made by requestInfo on the resource with the urn. Before and after
+// are the resource states, and they are skipped when nil. It must be called with the API bound to the
+// transaction of the mutation, so the mutation is rolled back if the entry can't be stored. The change event
+// of the mutation is emitted once the entry is stored.
+func (api WorkerAPI) audit(requestInfo RequestInfo, action string, urn string, before interface{}, after interface{}) error {
+	entry := AuditEntry{
+		ID:        uuid.NewV4().String(),
+		Actor:     requestInfo.Identifier,
+		RequestID: requestInfo.RequestID,
+		Action:    action,
+		Urn:       urn,
+		CreateAt:  time.Now().UTC(),
+	}
+
+	var err error
+	if entry.Before, err = auditState(before); err != nil {
+		return err
+	}
+	if entry.After, err = auditState(after); err != nil {
+		return err
+	}
+
+	if err := api.AuditRepo.AddAuditEntry(entry); err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+	api.emitEvent(entry)
+	return nil
+}
+
+// auditState encodes a resource state, nil if there isn't state
+func auditState(state interface{}) (json.RawMessage, error) {
+	if state == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: err.Error(),
+		}
+	}
+	return data, nil
+}
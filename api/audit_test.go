@@ -0,0 +1,286 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerAPI_ListAuditEntries(t *testing.T) {
+	now := time.Now().UTC()
+	before := now.Add(-time.Hour)
+	org1Entry := AuditEntry{
+		ID:       "ID1",
+		Actor:    "admin",
+		Action:   GROUP_ACTION_CREATE_GROUP,
+		Urn:      CreateUrn("org1", RESOURCE_GROUP, "/path/", "group1"),
+		CreateAt: now,
+	}
+	org2Entry := AuditEntry{
+		ID:       "ID2",
+		Actor:    "admin",
+		Action:   GROUP_ACTION_CREATE_GROUP,
+		Urn:      CreateUrn("org2", RESOURCE_GROUP, "/path/", "group1"),
+		CreateAt: now,
+	}
+	user := &User{
+		ID:         "USER-ID",
+		ExternalID: "123456",
+		Path:       "/path/",
+		Urn:        CreateUrn("", RESOURCE_USER, "/path/", "123456"),
+	}
+	groups := []TestUserGroupRelation{
+		{
+			Group: &Group{
+				ID:   "GROUP-ID",
+				Name: "auditors",
+				Org:  "org1",
+				Path: "/path/",
+				Urn:  CreateUrn("org1", RESOURCE_GROUP, "/path/", "auditors"),
+			},
+		},
+	}
+	policyWithResources := func(resources ...string) []TestPolicyGroupRelation {
+		return []TestPolicyGroupRelation{
+			{
+				Policy: &Policy{
+					ID:   "POLICY-ID",
+					Name: "audit",
+					Org:  "org1",
+					Path: "/path/",
+					Urn:  CreateUrn("org1", RESOURCE_POLICY, "/path/", "audit"),
+					Statements: &[]Statement{
+						{
+							Effect:    "allow",
+							Actions:   []string{AUDIT_ACTION_LIST_ENTRIES},
+							Resources: resources,
+						},
+					},
+				},
+			},
+		}
+	}
+	testcases := map[string]struct {
+		// API Method args
+		requestInfo RequestInfo
+		filter      *Filter
+		// Expected result
+		expectedEntries []AuditEntry
+		totalResult     int
+		wantError       error
+		// Manager Results
+		getAuditEntriesFilteredResult []AuditEntry
+		getAuditEntriesFilteredTotal  int
+		getUserByExternalIDResult     *User
+		getGroupsByUserIDResult       []TestUserGroupRelation
+		getAttachedPoliciesResult     []TestPolicyGroupRelation
+		// Manager Errors
+		getAuditEntriesFilteredErr error
+	}{
+		"OkCase": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				Actor:   "admin",
+				Action:  USER_ACTION_CREATE_USER,
+				From:    &before,
+				To:      &now,
+				OrderBy: "create_at-desc",
+			},
+			expectedEntries: []AuditEntry{
+				{
+					ID:       "ID",
+					Actor:    "admin",
+					Action:   USER_ACTION_CREATE_USER,
+					Urn:      CreateUrn("", RESOURCE_USER, "/path/", "1234"),
+					After:    json.RawMessage(`{"externalId":"1234"}`),
+					CreateAt: now,
+				},
+			},
+			totalResult: 1,
+			getAuditEntriesFilteredResult: []AuditEntry{
+				{
+					ID:       "ID",
+					Actor:    "admin",
+					Action:   USER_ACTION_CREATE_USER,
+					Urn:      CreateUrn("", RESOURCE_USER, "/path/", "1234"),
+					After:    json.RawMessage(`{"externalId":"1234"}`),
+					CreateAt: now,
+				},
+			},
+			getAuditEntriesFilteredTotal: 1,
+		},
+		"ErrorCaseInvalidLimit": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				Limit: 10000,
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: limit 10000, max limit allowed: 1000",
+			},
+		},
+		"ErrorCaseInvalidTimeRange": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				From: &now,
+				To:   &before,
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: From " + now.Format(time.RFC3339) + " must be before To " + before.Format(time.RFC3339),
+			},
+		},
+		"OkCaseFilteredByPolicy": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			filter: &Filter{
+				OrderBy: "create_at-desc",
+			},
+			expectedEntries:               []AuditEntry{org1Entry},
+			totalResult:                   2,
+			getAuditEntriesFilteredResult: []AuditEntry{org1Entry, org2Entry},
+			getAuditEntriesFilteredTotal:  2,
+			getUserByExternalIDResult:     user,
+			getGroupsByUserIDResult:       groups,
+			getAttachedPoliciesResult:     policyWithResources(GetUrnPrefix("org1", RESOURCE_GROUP, "/")),
+		},
+		"ErrorCaseNoPermissions": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			filter: &Filter{},
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId 123456 is not allowed to access to resource *",
+			},
+			totalResult:                   2,
+			getAuditEntriesFilteredResult: []AuditEntry{org1Entry, org2Entry},
+			getAuditEntriesFilteredTotal:  2,
+			getUserByExternalIDResult:     user,
+			getGroupsByUserIDResult:       groups,
+			getAttachedPoliciesResult:     policyWithResources(),
+		},
+		"ErrorCaseInternalError": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+			getAuditEntriesFilteredErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[OrderByValidColumnsMethod][0] = []string{"actor", "action", "urn", "create_at"}
+		testRepo.ArgsOut[GetAuditEntriesFilteredMethod][0] = testcase.getAuditEntriesFilteredResult
+		testRepo.ArgsOut[GetAuditEntriesFilteredMethod][1] = testcase.getAuditEntriesFilteredTotal
+		testRepo.ArgsOut[GetAuditEntriesFilteredMethod][2] = testcase.getAuditEntriesFilteredErr
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = testcase.getUserByExternalIDResult
+		testRepo.ArgsOut[GetGroupsByUserIDMethod][0] = testcase.getGroupsByUserIDResult
+		testRepo.ArgsOut[GetAttachedPoliciesMethod][0] = testcase.getAttachedPoliciesResult
+
+		entries, total, err := testAPI.ListAuditEntries(testcase.requestInfo, testcase.filter)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.expectedEntries, entries)
+		assert.Equal(t, testcase.totalResult, total, "Error in test case %v", x)
+		assert.Equal(t, AUDIT_ACTION_LIST_ENTRIES, testRepo.ArgsIn[OrderByValidColumnsMethod][0], "Error in test case %v", x)
+		if testcase.wantError == nil {
+			assert.Equal(t, "create_at desc", testcase.filter.OrderBy, "Error in test case %v", x)
+		}
+	}
+}
+
+func TestWorkerAPI_audit(t *testing.T) {
+	user := &User{
+		ID:         "543210",
+		ExternalID: "1234",
+		Path:       "/path/",
+		Urn:        CreateUrn("", RESOURCE_USER, "/path/", "1234"),
+	}
+	userJSON, _ := json.Marshal(user)
+	testcases := map[string]struct {
+		// API Method args
+		before interface{}
+		after  interface{}
+		// Expected result
+		expectedBefore json.RawMessage
+		expectedAfter  json.RawMessage
+		wantError      error
+		// Manager Errors
+		addAuditEntryErr error
+	}{
+		"OkCaseCreate": {
+			after:         user,
+			expectedAfter: userJSON,
+		},
+		"OkCaseRemove": {
+			before:         user,
+			expectedBefore: userJSON,
+		},
+		"OkCaseRelation": {
+			after:         auditRelation{Urn: "urn"},
+			expectedAfter: json.RawMessage(`{"urn":"urn"}`),
+		},
+		"ErrorCaseAddAuditEntryErr": {
+			after: user,
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+			addAuditEntryErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+		requestInfo := RequestInfo{
+			Identifier: "admin",
+			RequestID:  "RequestID",
+		}
+
+		testRepo.ArgsOut[AddAuditEntryMethod][0] = testcase.addAuditEntryErr
+
+		err := testAPI.audit(requestInfo, USER_ACTION_UPDATE_USER, user.Urn, testcase.before, testcase.after)
+		assert.Equal(t, testcase.wantError, err, "Error in test case %v", x)
+
+		entry := testRepo.ArgsIn[AddAuditEntryMethod][0].(AuditEntry)
+		assert.NotEmpty(t, entry.ID, "Error in test case %v", x)
+		assert.False(t, entry.CreateAt.IsZero(), "Error in test case %v", x)
+		assert.Equal(t, "admin", entry.Actor, "Error in test case %v", x)
+		assert.Equal(t, "RequestID", entry.RequestID, "Error in test case %v", x)
+		assert.Equal(t, USER_ACTION_UPDATE_USER, entry.Action, "Error in test case %v", x)
+		assert.Equal(t, user.Urn, entry.Urn, "Error in test case %v", x)
+		if testcase.wantError == nil {
+			assert.Equal(t, testcase.expectedBefore, entry.Before, "Error in test case %v", x)
+			assert.Equal(t, testcase.expectedAfter, entry.After, "Error in test case %v", x)
+		}
+	}
+}
@@ -44,6 +44,19 @@ func (op OidcProvider) GetUrn() string {
 // AUTHENTICATOR OIDC API IMPLEMENTATION
 
 func (api WorkerAPI) AddOidcProvider(requestInfo RequestInfo, name string, path string, issuerURL string, oidcClients []string) (*OidcProvider, error) {
+	var oidcProvider *OidcProvider
+	err := api.withTx(func(txAPI WorkerAPI) error {
+		var err error
+		oidcProvider, err = txAPI.addOidcProvider(requestInfo, name, path, issuerURL, oidcClients)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return oidcProvider, nil
+}
+
+func (api WorkerAPI) addOidcProvider(requestInfo RequestInfo, name string, path string, issuerURL string, oidcClients []string) (*OidcProvider, error) {
 	// Validate fields
 	if !IsValidName(name) {
 		return nil, &Error{
@@ -112,6 +125,9 @@ func (api WorkerAPI) AddOidcProvider(requestInfo RequestInfo, name string, path
 				}
 			}
 
+			if err := api.audit(requestInfo, AUTH_OIDC_ACTION_CREATE_PROVIDER, createdOidcProvider.Urn, nil, createdOidcProvider); err != nil {
+				return nil, err
+			}
 			LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("OIDC provider created %+v", createdOidcProvider))
 			return createdOidcProvider, nil
 		default: // Unexpected error
@@ -217,6 +233,20 @@ func (api WorkerAPI) ListOidcProviders(requestInfo RequestInfo, filter *Filter)
 }
 
 func (api WorkerAPI) UpdateOidcProvider(requestInfo RequestInfo, oidcProviderName string, newName string, newPath string, newIssuerUrl string,
+	newClients []string) (*OidcProvider, error) {
+	var oidcProvider *OidcProvider
+	err := api.withTx(func(txAPI WorkerAPI) error {
+		var err error
+		oidcProvider, err = txAPI.updateOidcProvider(requestInfo, oidcProviderName, newName, newPath, newIssuerUrl, newClients)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return oidcProvider, nil
+}
+
+func (api WorkerAPI) updateOidcProvider(requestInfo RequestInfo, oidcProviderName string, newName string, newPath string, newIssuerUrl string,
 	newClients []string) (*OidcProvider, error) {
 	// Validate fields
 	if !IsValidName(newName) {
@@ -268,6 +298,11 @@ func (api WorkerAPI) UpdateOidcProvider(requestInfo RequestInfo, oidcProviderNam
 		}
 	}
 
+	// Check the request applies to the current version
+	if err := checkIfMatch(requestInfo, oldOidcProvider.Urn, oldOidcProvider.UpdateAt); err != nil {
+		return nil, err
+	}
+
 	// Check if OIDC Provider with "newName" exists
 	targetOidcProvider, err := api.GetOidcProviderByName(requestInfo, newName)
 
@@ -321,24 +356,41 @@ func (api WorkerAPI) UpdateOidcProvider(requestInfo RequestInfo, oidcProviderNam
 	}
 
 	// Update OIDC Provider
-	updatedOidcProvider, err := api.AuthOidcRepo.UpdateOidcProvider(oidcProvider)
+	updatedOidcProvider, err := api.AuthOidcRepo.UpdateOidcProvider(oidcProvider, oldOidcProvider.UpdateAt)
 
-	// Check unexpected DB error
+	// Error handling
 	if err != nil {
 		//Transform to DB error
 		dbError := err.(*database.Error)
-		return nil, &Error{
-			Code:    UNKNOWN_API_ERROR,
-			Message: dbError.Message,
+		switch dbError.Code {
+		case database.VERSION_CONFLICT:
+			return nil, &Error{
+				Code:    PRECONDITION_FAILED,
+				Message: dbError.Message,
+			}
+		default: // Unexpected error
+			return nil, &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: dbError.Message,
+			}
 		}
 	}
 
+	if err := api.audit(requestInfo, AUTH_OIDC_ACTION_UPDATE_PROVIDER, oldOidcProvider.Urn, oldOidcProvider, updatedOidcProvider); err != nil {
+		return nil, err
+	}
 	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("OIDC Provider updated from %+v to %+v",
 		oldOidcProvider, updatedOidcProvider))
 	return updatedOidcProvider, nil
 }
 
 func (api WorkerAPI) RemoveOidcProvider(requestInfo RequestInfo, name string) error {
+	return api.withTx(func(txAPI WorkerAPI) error {
+		return txAPI.removeOidcProvider(requestInfo, name)
+	})
+}
+
+func (api WorkerAPI) removeOidcProvider(requestInfo RequestInfo, name string) error {
 	// Call repo to retrieve the OIDC provider
 	oidcProvider, err := api.GetOidcProviderByName(requestInfo, name)
 	if err != nil {
@@ -358,6 +410,11 @@ func (api WorkerAPI) RemoveOidcProvider(requestInfo RequestInfo, name string) er
 		}
 	}
 
+	// Check the request applies to the current version
+	if err := checkIfMatch(requestInfo, oidcProvider.Urn, oidcProvider.UpdateAt); err != nil {
+		return err
+	}
+
 	err = api.AuthOidcRepo.RemoveOidcProvider(oidcProvider.ID)
 
 	// Error handling
@@ -370,6 +427,9 @@ func (api WorkerAPI) RemoveOidcProvider(requestInfo RequestInfo, name string) er
 		}
 	}
 
+	if err := api.audit(requestInfo, AUTH_OIDC_ACTION_DELETE_PROVIDER, oidcProvider.Urn, oidcProvider, nil); err != nil {
+		return err
+	}
 	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("OIDC Provider deleted %v", oidcProvider))
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Tecsisa/foulkon/database"
 )
@@ -14,6 +15,10 @@ type RequestInfo struct {
 	Identifier string
 	Admin      bool
 	RequestID  string
+	Context    RequestContext
+	// Entity tags of the resource versions that the request can update or remove (If-Match header).
+	// Empty if the request isn't conditional
+	IfMatch string
 }
 
 type EffectRestriction struct {
@@ -28,6 +33,50 @@ type Restrictions struct {
 	DeniedFullUrns     []string `json:"deniedFullUrns,omitempty"`
 }
 
+// AuthorizationExplanation describes how the authorization decision for a user, action and resource was taken
+type AuthorizationExplanation struct {
+	ExternalID string              `json:"externalId,omitempty"`
+	Action     string              `json:"action,omitempty"`
+	Resource   string              `json:"resource,omitempty"`
+	Allowed    bool                `json:"allowed"`
+	Groups     []GroupIdentity     `json:"groups,omitempty"`
+	Policies   []PolicyIdentity    `json:"policies,omitempty"`
+	Statements []StatementSource   `json:"statements,omitempty"`
+	Overrides  []StatementOverride `json:"overrides,omitempty"`
+}
+
+// StatementSource is a statement that matches the requested action and resource, with the group and
+// policy where it comes from. Group is empty for policies attached directly to the user
+type StatementSource struct {
+	Group     *GroupIdentity `json:"group,omitempty"`
+	Policy    PolicyIdentity `json:"policy"`
+	Statement Statement      `json:"statement"`
+}
+
+// StatementOverride links a deny statement with the allow statement that it overrides
+type StatementOverride struct {
+	Deny  StatementSource `json:"deny"`
+	Allow StatementSource `json:"allow"`
+}
+
+// policySet groups policies attached to the same group, or directly to the user if group is nil
+type policySet struct {
+	group    *GroupIdentity
+	policies []Policy
+}
+
+// AuthorizationCheck is an action with the full resources to authorize in a batch
+type AuthorizationCheck struct {
+	Action    string   `json:"action,omitempty"`
+	Resources []string `json:"resources,omitempty"`
+}
+
+// AuthorizationCheckResult holds the resources allowed for an AuthorizationCheck
+type AuthorizationCheckResult struct {
+	Action           string   `json:"action,omitempty"`
+	ResourcesAllowed []string `json:"resourcesAllowed"`
+}
+
 type ExternalResource struct {
 	Urn string `json:"urn,omitempty"`
 }
@@ -106,6 +155,23 @@ func (api WorkerAPI) GetAuthorizedProxyResources(requestInfo RequestInfo, resour
 	return proxyResourcesFiltered, nil
 }
 
+// GetAuthorizedUpstreamPools returns authorized upstream pools for specified user combined with resource+action
+func (api WorkerAPI) GetAuthorizedUpstreamPools(requestInfo RequestInfo, resourceUrn string, action string, pools []UpstreamPool) ([]UpstreamPool, error) {
+	resourcesToAuthorize := []Resource{}
+	for _, pool := range pools {
+		resourcesToAuthorize = append(resourcesToAuthorize, pool)
+	}
+	resources, err := api.getAuthorizedResources(requestInfo, resourceUrn, action, resourcesToAuthorize)
+	if err != nil {
+		return nil, err
+	}
+	poolsFiltered := []UpstreamPool{}
+	for _, res := range resources {
+		poolsFiltered = append(poolsFiltered, res.(UpstreamPool))
+	}
+	return poolsFiltered, nil
+}
+
 // GetAuthorizedOidcProviders returns authorized OIDC providers for specified user combined with resource+action
 func (api WorkerAPI) GetAuthorizedOidcProviders(requestInfo RequestInfo, resourceUrn string, action string, oidcProviders []OidcProvider) ([]OidcProvider, error) {
 	resourcesToAuthorize := []Resource{}
@@ -123,9 +189,129 @@ func (api WorkerAPI) GetAuthorizedOidcProviders(requestInfo RequestInfo, resourc
 	return oidcProvidersFiltered, nil
 }
 
+// GetAuthorizedWebhooks returns authorized webhooks for specified user combined with resource+action
+func (api WorkerAPI) GetAuthorizedWebhooks(requestInfo RequestInfo, resourceUrn string, action string, webhooks []Webhook) ([]Webhook, error) {
+	resourcesToAuthorize := []Resource{}
+	for _, webhook := range webhooks {
+		resourcesToAuthorize = append(resourcesToAuthorize, webhook)
+	}
+	resources, err := api.getAuthorizedResources(requestInfo, resourceUrn, action, resourcesToAuthorize)
+	if err != nil {
+		return nil, err
+	}
+	webhooksFiltered := []Webhook{}
+	for _, res := range resources {
+		webhooksFiltered = append(webhooksFiltered, res.(Webhook))
+	}
+	return webhooksFiltered, nil
+}
+
 // GetAuthorizedExternalResources returns the resources where the specified user has the action granted
 func (api WorkerAPI) GetAuthorizedExternalResources(requestInfo RequestInfo, action string, resources []string) ([]string, error) {
+	start := time.Now()
+	// Validate parameters
+	externalResources, err := getExternalResources(action, resources)
+	if err != nil {
+		return nil, err
+	}
+
+	// Admin users are allowed to access to all resources
+	allowedUrns := externalResources
+	if !requestInfo.Admin {
+		policies, err := api.getEffectivePolicies(requestInfo.Identifier)
+		if err != nil {
+			// Unauthorized users are denied, other errors aren't decisions
+			if apiError, ok := err.(*Error); ok && apiError.Code == UNAUTHORIZED_RESOURCES_ERROR {
+				api.recordDecision(requestInfo, action, resources, nil, start)
+			}
+			return nil, err
+		}
+		allowedUrns = filterResourcesByPolicies(externalResources, policies, action, requestInfo.Context)
+	}
+
+	response := []string{}
+	for _, res := range allowedUrns {
+		response = append(response, res.GetUrn())
+	}
+	api.recordDecision(requestInfo, action, resources, response, start)
+
+	if len(response) < 1 {
+		return nil, &Error{
+			Code:    UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to any resource", requestInfo.Identifier),
+		}
+	}
+
+	return response, nil
+}
+
+// GetAuthorizedExternalResourcesBatch returns the resources where the specified user has the action granted for
+// every check, retrieving user policies only once. The decision of each check is recorded.
+func (api WorkerAPI) GetAuthorizedExternalResourcesBatch(requestInfo RequestInfo, checks []AuthorizationCheck) ([]AuthorizationCheckResult, error) {
+	start := time.Now()
+	// Validate parameters
+	if len(checks) < 1 || len(checks) > MAX_RESOURCE_NUMBER {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter Checks. Checks can't be empty or bigger than %v elements", MAX_RESOURCE_NUMBER),
+		}
+	}
+	externalResources := make([][]Resource, len(checks))
+	for i, check := range checks {
+		resources, err := getExternalResources(check.Action, check.Resources)
+		if err != nil {
+			return nil, err
+		}
+		externalResources[i] = resources
+	}
+
+	// Retrieve user policies, not needed if user is an admin
+	var policies []Policy
+	if !requestInfo.Admin {
+		var err error
+		policies, err = api.getEffectivePolicies(requestInfo.Identifier)
+		if err != nil {
+			// Unauthorized users are denied, other errors aren't decisions
+			if apiError, ok := err.(*Error); ok && apiError.Code == UNAUTHORIZED_RESOURCES_ERROR {
+				for _, check := range checks {
+					api.recordDecision(requestInfo, check.Action, check.Resources, nil, start)
+				}
+			}
+			return nil, err
+		}
+	}
+
+	results := []AuthorizationCheckResult{}
+	for i, check := range checks {
+		allowedUrns := externalResources[i]
+		if !requestInfo.Admin {
+			allowedUrns = filterResourcesByPolicies(allowedUrns, policies, check.Action, requestInfo.Context)
+		}
+
+		result := AuthorizationCheckResult{
+			Action:           check.Action,
+			ResourcesAllowed: []string{},
+		}
+		for _, res := range allowedUrns {
+			result.ResourcesAllowed = append(result.ResourcesAllowed, res.GetUrn())
+		}
+		api.recordDecision(requestInfo, check.Action, check.Resources, result.ResourcesAllowed, start)
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// ExplainAuthorization returns the authorization decision for a user, action and resource, with the groups,
+// policies and statements involved in it
+func (api WorkerAPI) ExplainAuthorization(requestInfo RequestInfo, externalID string, action string, resource string) (*AuthorizationExplanation, error) {
 	// Validate parameters
+	if !IsValidUserExternalID(externalID) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: externalId %v", externalID),
+		}
+	}
 	if err := AreValidActions([]string{action}); err != nil {
 		// Transform to API error
 		apiError := err.(*Error)
@@ -134,55 +320,136 @@ func (api WorkerAPI) GetAuthorizedExternalResources(requestInfo RequestInfo, act
 			Message: apiError.Message,
 		}
 	}
-	if len(resources) < 1 || len(resources) > MAX_RESOURCE_NUMBER {
+	if strings.Contains(action, "*") {
 		return nil, &Error{
 			Code:    INVALID_PARAMETER_ERROR,
-			Message: fmt.Sprintf("Invalid parameter Resources. Resources can't be empty or bigger than %v elements", MAX_RESOURCE_NUMBER),
+			Message: fmt.Sprintf("Invalid parameter action %v. Action parameter can't be a prefix", action),
 		}
 	}
-	externalResources := []Resource{}
-	for _, res := range resources {
-		if !isFullUrn(res) {
-			return nil, &Error{
-				Code:    INVALID_PARAMETER_ERROR,
-				Message: fmt.Sprintf("Invalid parameter resource %v. Urn prefixes are not allowed here", res),
-			}
+	if !isFullUrn(resource) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter resource %v. Urn prefixes are not allowed here", resource),
 		}
-		if err := AreValidResources([]string{res}, RESOURCE_EXTERNAL); err != nil {
-			// Transform to API error
-			apiError := err.(*Error)
+	}
+	if err := AreValidResources([]string{resource}, RESOURCE_IAM); err != nil {
+		// Transform to API error
+		apiError := err.(*Error)
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: apiError.Message,
+		}
+	}
+
+	// Retrieve user to explain
+	user, err := api.UserRepo.GetUserByExternalID(externalID)
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		// User doesn't exist in DB
+		if dbError.Code == database.USER_NOT_FOUND {
 			return nil, &Error{
-				Code:    INVALID_PARAMETER_ERROR,
-				Message: apiError.Message,
+				Code:    USER_BY_EXTERNAL_ID_NOT_FOUND,
+				Message: dbError.Message,
 			}
 		}
-		externalResources = append(externalResources, ExternalResource{Urn: res})
+		return nil, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
 	}
-	if strings.Contains(action, "*") {
+
+	// Check restrictions
+	filteredUsers, err := api.GetAuthorizedUsers(requestInfo, user.Urn, AUTHZ_ACTION_EXPLAIN_AUTHORIZATION, []User{*user})
+	if err != nil {
+		return nil, err
+	}
+	if len(filteredUsers) < 1 {
 		return nil, &Error{
-			Code:    INVALID_PARAMETER_ERROR,
-			Message: fmt.Sprintf("Invalid parameter action %v. Action parameter can't be a prefix", action),
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, user.Urn),
 		}
 	}
 
-	allowedUrns, err := api.getAuthorizedResources(requestInfo, "urn:*", action, externalResources)
+	userPolicies, err := api.getPoliciesAttachedToUser(user.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(allowedUrns) < 1 {
-		return nil, &Error{
-			Code:    UNAUTHORIZED_RESOURCES_ERROR,
-			Message: fmt.Sprintf("User with externalId %v is not allowed to access to any resource", requestInfo.Identifier),
+	groups, _, err := api.getGroupsByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Policies attached directly to the user come first, without group
+	policySets := []policySet{{policies: userPolicies}}
+	for _, group := range groups {
+		policies, _, err := api.getPoliciesByGroups([]Group{group})
+		if err != nil {
+			return nil, err
 		}
+		policySets = append(policySets, policySet{
+			group:    &GroupIdentity{Org: group.Org, Name: group.Name},
+			policies: policies,
+		})
 	}
 
-	response := []string{}
-	for _, res := range allowedUrns {
-		response = append(response, res.GetUrn())
+	explanation := &AuthorizationExplanation{
+		ExternalID: externalID,
+		Action:     action,
+		Resource:   resource,
+		Groups:     []GroupIdentity{},
+		Policies:   []PolicyIdentity{},
+		Statements: []StatementSource{},
+		Overrides:  []StatementOverride{},
 	}
 
-	return response, nil
+	// Track the group and policy of each statement that applies to the resource
+	statements := []Statement{}
+	allowSources := []StatementSource{}
+	denySources := []StatementSource{}
+	for _, set := range policySets {
+		for _, policy := range set.policies {
+			policyIdentity := PolicyIdentity{Org: policy.Org, Name: policy.Name}
+			for _, statement := range getStatementsByRequestedAction([]Policy{policy}, action, requestInfo.Context.withResource(resource)) {
+				// Skip statements that don't restrict the requested resource
+				if !hasRestrictions(getRestrictions([]Statement{statement}, resource, true)) {
+					continue
+				}
+				source := StatementSource{
+					Group:     set.group,
+					Policy:    policyIdentity,
+					Statement: statement,
+				}
+				if statement.Effect == "allow" {
+					allowSources = append(allowSources, source)
+				} else {
+					denySources = append(denySources, source)
+				}
+				explanation.Statements = append(explanation.Statements, source)
+				if set.group != nil {
+					explanation.Groups = appendGroupIdentity(explanation.Groups, *set.group)
+				}
+				explanation.Policies = appendPolicyIdentity(explanation.Policies, policyIdentity)
+				statements = append(statements, statement)
+			}
+		}
+	}
+
+	// Deny statements override every allow statement over the same full resource
+	for _, deny := range denySources {
+		for _, allow := range allowSources {
+			explanation.Overrides = append(explanation.Overrides, StatementOverride{
+				Deny:  deny,
+				Allow: allow,
+			})
+		}
+	}
+
+	explanation.Allowed = isAllowedResource(ExternalResource{Urn: resource}, *getRestrictions(statements, resource, true))
+
+	return explanation, nil
 }
 
 // PRIVATE HELPER METHODS
@@ -195,10 +462,17 @@ func (api WorkerAPI) getAuthorizedResources(requestInfo RequestInfo, resourceUrn
 	}
 
 	// Check authorization for this user
-	restrictions, err := api.getRestrictions(requestInfo.Identifier, action, resourceUrn)
+	policies, err := api.getEffectivePolicies(requestInfo.Identifier)
 	if err != nil {
 		return nil, err
 	}
+	var restrictions *Restrictions
+	if isFullUrn(resourceUrn) {
+		restrictions = getRestrictionsByPolicies(policies, action, resourceUrn, requestInfo.Context)
+	} else {
+		statements := getStatementsByUrnPrefix(policies, action, requestInfo.Context.withResource(resourceUrn))
+		restrictions = getRestrictions(statements, resourceUrn, false)
+	}
 
 	Log.Debugf("Restrictions: %v", *restrictions)
 
@@ -211,13 +485,29 @@ func (api WorkerAPI) getAuthorizedResources(requestInfo RequestInfo, resourceUrn
 	}
 
 	// Filter resources
-	resourcesFiltered := filterResources(resources, restrictions)
+	resourcesFiltered := filterResourcesByPolicies(resources, policies, action, requestInfo.Context)
 
 	return resourcesFiltered, nil
 }
 
-// Get restrictions for this action and full resource or prefix resource, attached to this authenticated user
-func (api WorkerAPI) getRestrictions(externalID string, action string, resource string) (*Restrictions, error) {
+// Retrieve the effective policies of an authenticated user from cache, or from repositories if they aren't cached
+func (api WorkerAPI) getEffectivePolicies(externalID string) ([]Policy, error) {
+	policies, generation, ok := api.AuthzCache.get(externalID)
+	if ok {
+		return policies, nil
+	}
+
+	policies, expiresAt, err := api.getPoliciesByExternalID(externalID)
+	if err != nil {
+		return nil, err
+	}
+	api.AuthzCache.set(externalID, policies, generation, expiresAt)
+
+	return policies, nil
+}
+
+// Retrieve the effective policies of an authenticated user, with the earliest expiration of the relations they come from
+func (api WorkerAPI) getPoliciesByExternalID(externalID string) ([]Policy, *time.Time, error) {
 	// Get user if exists
 	user, err := api.UserRepo.GetUserByExternalID(externalID)
 
@@ -227,66 +517,185 @@ func (api WorkerAPI) getRestrictions(externalID string, action string, resource
 		dbError := err.(*database.Error)
 		switch dbError.Code {
 		case database.USER_NOT_FOUND:
-			return nil, &Error{
+			return nil, nil, &Error{
 				Code:    UNAUTHORIZED_RESOURCES_ERROR,
 				Message: fmt.Sprintf("Authenticated user with externalId %v not found. Unable to retrieve permissions.", externalID),
 			}
 		default:
-			return nil, &Error{
+			return nil, nil, &Error{
 				Code:    UNKNOWN_API_ERROR,
 				Message: dbError.Message,
 			}
 		}
 	}
 
-	groups, err := api.getGroupsByUser(user.ID)
-	if err != nil {
-		return nil, err
-	}
-
-	policies, err := api.getPoliciesByGroups(groups)
-	if err != nil {
-		return nil, err
-	}
+	return api.getPoliciesByUser(user.ID)
+}
 
-	// Retrieve valid statements
-	statements := getStatementsByRequestedAction(policies, action)
+// Get restrictions for this action and full resource or prefix resource from a slice of policies
+func getRestrictionsByPolicies(policies []Policy, action string, resource string, context RequestContext) *Restrictions {
+	// Retrieve valid statements, evaluating their conditions over the resource
+	statements := getStatementsByRequestedAction(policies, action, context.withResource(resource))
 
 	// Retrieve restrictions
-	var authResources *Restrictions
-	authResources = getRestrictions(statements, resource, isFullUrn(resource))
+	return getRestrictions(statements, resource, isFullUrn(resource))
+}
 
-	return authResources, nil
+// Validate an action and the full resources to authorize, returning them as external resources
+func getExternalResources(action string, resources []string) ([]Resource, error) {
+	if err := AreValidActions([]string{action}); err != nil {
+		// Transform to API error
+		apiError := err.(*Error)
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: apiError.Message,
+		}
+	}
+	if len(resources) < 1 || len(resources) > MAX_RESOURCE_NUMBER {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter Resources. Resources can't be empty or bigger than %v elements", MAX_RESOURCE_NUMBER),
+		}
+	}
+	externalResources := []Resource{}
+	for _, res := range resources {
+		if !isFullUrn(res) {
+			return nil, &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter resource %v. Urn prefixes are not allowed here", res),
+			}
+		}
+		if err := AreValidResources([]string{res}, RESOURCE_EXTERNAL); err != nil {
+			// Transform to API error
+			apiError := err.(*Error)
+			return nil, &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: apiError.Message,
+			}
+		}
+		externalResources = append(externalResources, ExternalResource{Urn: res})
+	}
+	if strings.Contains(action, "*") {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter action %v. Action parameter can't be a prefix", action),
+		}
+	}
+
+	return externalResources, nil
 }
 
-func (api WorkerAPI) getGroupsByUser(userID string) ([]Group, error) {
+// Retrieve user groups, with the parent groups of these groups at any depth, and the earliest expiration of
+// the memberships. Expired memberships are ignored.
+func (api WorkerAPI) getGroupsByUser(userID string) ([]Group, *time.Time, error) {
 	userGroups, _, err := api.UserRepo.GetGroupsByUserID(userID, &Filter{})
 	if err != nil {
 		//Transform to DB error
 		dbError := err.(*database.Error)
-		return nil, &Error{
+		return nil, nil, &Error{
 			Code:    UNKNOWN_API_ERROR,
 			Message: dbError.Message,
 		}
 	}
 
 	// Transform to Groups
+	now := time.Now().UTC()
 	groups := []Group{}
+	groupIDs := []string{}
+	var expiresAt *time.Time
 	for _, g := range userGroups {
+		if isExpired(g.GetExpiresAt(), now) {
+			continue
+		}
+		expiresAt = earliestExpiration(expiresAt, g.GetExpiresAt())
 		groups = append(groups, *g.GetGroup())
+		groupIDs = append(groupIDs, g.GetGroup().ID)
+	}
+	if len(groupIDs) < 1 {
+		return groups, expiresAt, nil
 	}
 
-	return groups, nil
+	// Retrieve parent groups of user groups at any depth
+	ancestors, err := api.GroupRepo.GetAncestorGroups(groupIDs)
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, nil, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+	for _, ancestor := range ancestors {
+		if !containsGroup(groups, ancestor.ID) {
+			groups = append(groups, ancestor)
+		}
+	}
+
+	return groups, expiresAt, nil
+}
+
+// Returns true if the group ID is in the slice of groups
+func containsGroup(groups []Group, groupID string) bool {
+	for _, g := range groups {
+		if g.ID == groupID {
+			return true
+		}
+	}
+	return false
+}
+
+// Retrieve policies attached directly to the user, followed by the policies attached to its groups,
+// with the earliest expiration of the memberships and attachments they come from
+func (api WorkerAPI) getPoliciesByUser(userID string) ([]Policy, *time.Time, error) {
+	policies, err := api.getPoliciesAttachedToUser(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groups, groupsExpiresAt, err := api.getGroupsByUser(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groupPolicies, policiesExpiresAt, err := api.getPoliciesByGroups(groups)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return append(policies, groupPolicies...), earliestExpiration(groupsExpiresAt, policiesExpiresAt), nil
+}
+
+// Retrieve policies attached directly to the user
+func (api WorkerAPI) getPoliciesAttachedToUser(userID string) ([]Policy, error) {
+	userPolicies, _, err := api.UserRepo.GetAttachedUserPolicies(userID, &Filter{})
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	policies := []Policy{}
+	for _, policy := range userPolicies {
+		policies = append(policies, *policy.GetPolicy())
+	}
+
+	return policies, nil
 }
 
-// Retrieve policies attached to a slice of groups
-func (api WorkerAPI) getPoliciesByGroups(groups []Group) ([]Policy, error) {
+// Retrieve policies attached to a slice of groups, and the earliest expiration of the attachments.
+// Expired attachments are ignored.
+func (api WorkerAPI) getPoliciesByGroups(groups []Group) ([]Policy, *time.Time, error) {
 	if groups == nil || len(groups) < 1 {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	// Create an empty slice
+	now := time.Now().UTC()
 	policies := []Policy{}
+	var expiresAt *time.Time
 
 	// Retrieve per each group its attached policies
 	for _, group := range groups {
@@ -295,22 +704,27 @@ func (api WorkerAPI) getPoliciesByGroups(groups []Group) ([]Policy, error) {
 		if err != nil {
 			//Transform to DB error
 			dbError := err.(*database.Error)
-			return nil, &Error{
+			return nil, nil, &Error{
 				Code:    UNKNOWN_API_ERROR,
 				Message: dbError.Message,
 			}
 		}
 
 		for _, policy := range policiesAttached {
+			if isExpired(policy.GetExpiresAt(), now) {
+				continue
+			}
+			expiresAt = earliestExpiration(expiresAt, policy.GetExpiresAt())
 			policies = append(policies, *policy.GetPolicy())
 		}
 	}
 
-	return policies, nil
+	return policies, expiresAt, nil
 }
 
-// Filter a slice of statements for a specified action
-func getStatementsByRequestedAction(policies []Policy, requestedAction string) []Statement {
+// Filter a slice of statements for a specified action, discarding statements whose conditions
+// aren't satisfied by the request context
+func getStatementsByRequestedAction(policies []Policy, requestedAction string, context RequestContext) []Statement {
 	// Check received policies
 	if policies == nil || len(policies) < 1 {
 		return nil
@@ -319,7 +733,23 @@ func getStatementsByRequestedAction(policies []Policy, requestedAction string) [
 	statements := []Statement{}
 	for _, policy := range policies {
 		for _, statement := range *policy.Statements {
-			if isActionContained(requestedAction, statement.Actions) {
+			if isActionContained(requestedAction, statement.Actions) && areConditionsMet(statement.Conditions, context) {
+				statements = append(statements, statement)
+			}
+		}
+	}
+
+	return statements
+}
+
+// Get the statements for this action that may apply to the resources of a urn prefix. Conditions that evaluate
+// the resource can't be evaluated with the prefix, so their statements are kept until each resource is evaluated.
+func getStatementsByUrnPrefix(policies []Policy, requestedAction string, context RequestContext) []Statement {
+	statements := []Statement{}
+	for _, policy := range policies {
+		for _, statement := range *policy.Statements {
+			if isActionContained(requestedAction, statement.Actions) &&
+				(areConditionsOnResource(statement.Conditions) || areConditionsMet(statement.Conditions, context)) {
 				statements = append(statements, statement)
 			}
 		}
@@ -521,6 +951,32 @@ func getRestrictions(statements []Statement, resource string, resourceIsFullUrn
 	return restrictions
 }
 
+// Returns true if there is at least one allowed or denied restriction
+func hasRestrictions(restrictions *Restrictions) bool {
+	return len(restrictions.AllowedUrnPrefixes) > 0 || len(restrictions.AllowedFullUrns) > 0 ||
+		len(restrictions.DeniedUrnPrefixes) > 0 || len(restrictions.DeniedFullUrns) > 0
+}
+
+// Append a group identity to a slice if it isn't already contained
+func appendGroupIdentity(groups []GroupIdentity, group GroupIdentity) []GroupIdentity {
+	for _, g := range groups {
+		if g == group {
+			return groups
+		}
+	}
+	return append(groups, group)
+}
+
+// Append a policy identity to a slice if it isn't already contained
+func appendPolicyIdentity(policies []PolicyIdentity, policy PolicyIdentity) []PolicyIdentity {
+	for _, p := range policies {
+		if p == policy {
+			return policies
+		}
+	}
+	return append(policies, policy)
+}
+
 // Remove resources that are not allowed by the restrictions
 func filterResources(resources []Resource, restrictions *Restrictions) []Resource {
 	filteredResource := []Resource{}
@@ -533,6 +989,19 @@ func filterResources(resources []Resource, restrictions *Restrictions) []Resourc
 	return filteredResource
 }
 
+// Filter resources where the policies allow the action. Restrictions are retrieved for every resource,
+// so statement conditions are evaluated with its URN
+func filterResourcesByPolicies(resources []Resource, policies []Policy, action string, context RequestContext) []Resource {
+	filteredResource := []Resource{}
+	for _, r := range resources {
+		if isAllowedByPolicies(policies, action, r.GetUrn(), context) {
+			filteredResource = append(filteredResource, r)
+		}
+	}
+
+	return filteredResource
+}
+
 // Check if resource is allowed or not
 func isAllowedResource(resource Resource, restrictions Restrictions) bool {
 	allowed := false
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// AuthzCache is a size-bounded cache of the effective policies of each user, used to avoid retrieving
+// the user, its groups and their policies from the repositories on every authorization request.
+// Entries expire after a TTL, and the whole cache is invalidated when a user, group, policy or any
+// relation between them changes. A nil cache is a disabled cache.
+type AuthzCache struct {
+	ttl  time.Duration
+	size int
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+
+	// generation is increased on every invalidation to discard entries retrieved before it
+	generation uint64
+
+	hits   uint64
+	misses uint64
+}
+
+// AuthzCacheStats holds the cache configuration and its hit and miss counters
+type AuthzCacheStats struct {
+	TTL    time.Duration
+	Size   int
+	Hits   uint64
+	Misses uint64
+}
+
+type authzCacheEntry struct {
+	externalID string
+	policies   []Policy
+	expiresAt  time.Time
+}
+
+// NewAuthzCache returns a cache that holds up to size users during ttl, or nil if any of them isn't positive
+func NewAuthzCache(ttl time.Duration, size int) *AuthzCache {
+	if ttl <= 0 || size <= 0 {
+		return nil
+	}
+	return &AuthzCache{
+		ttl:     ttl,
+		size:    size,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Invalidate removes all entries from the cache
+func (c *AuthzCache) Invalidate() {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.generation++
+}
+
+// Stats returns the cache configuration and its hit and miss counters
+func (c *AuthzCache) Stats() AuthzCacheStats {
+	if c == nil {
+		return AuthzCacheStats{}
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return AuthzCacheStats{
+		TTL:    c.ttl,
+		Size:   c.size,
+		Hits:   c.hits,
+		Misses: c.misses,
+	}
+}
+
+// get returns the policies cached for the user, and the generation to use when storing them on a miss
+func (c *AuthzCache) get(externalID string) ([]Policy, uint64, bool) {
+	if c == nil {
+		return nil, 0, false
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[externalID]; ok {
+		entry := element.Value.(*authzCacheEntry)
+		if time.Now().UTC().Before(entry.expiresAt) {
+			c.lru.MoveToFront(element)
+			c.hits++
+			return entry.policies, c.generation, true
+		}
+		c.lru.Remove(element)
+		delete(c.entries, externalID)
+	}
+	c.misses++
+	return nil, c.generation, false
+}
+
+// set stores the policies of the user, unless the cache has been invalidated since the given generation.
+// The entry expires after the TTL, or before if any relation the policies come from expires earlier.
+// The least recently used entry is evicted when the cache is full.
+func (c *AuthzCache) set(externalID string, policies []Policy, generation uint64, relationsExpireAt *time.Time) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	expiresAt := time.Now().UTC().Add(c.ttl)
+	if relationsExpireAt != nil && relationsExpireAt.Before(expiresAt) {
+		expiresAt = *relationsExpireAt
+	}
+	entry := &authzCacheEntry{
+		externalID: externalID,
+		policies:   policies,
+		expiresAt:  expiresAt,
+	}
+	if element, ok := c.entries[externalID]; ok {
+		element.Value = entry
+		c.lru.MoveToFront(element)
+		return
+	}
+	if c.lru.Len() >= c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*authzCacheEntry).externalID)
+	}
+	c.entries[externalID] = c.lru.PushFront(entry)
+}
@@ -0,0 +1,296 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAuthzCache(t *testing.T) {
+	testcases := map[string]struct {
+		ttl  time.Duration
+		size int
+		// Expected result
+		expectedNil bool
+	}{
+		"OkCase": {
+			ttl:  time.Minute,
+			size: 10,
+		},
+		"OkCaseZeroTTL": {
+			ttl:         0,
+			size:        10,
+			expectedNil: true,
+		},
+		"OkCaseZeroSize": {
+			ttl:         time.Minute,
+			size:        0,
+			expectedNil: true,
+		},
+	}
+
+	for n, test := range testcases {
+		cache := NewAuthzCache(test.ttl, test.size)
+		assert.Equal(t, test.expectedNil, cache == nil, "Error in test case %v", n)
+	}
+}
+
+func TestAuthzCache(t *testing.T) {
+	policy1 := []Policy{{ID: "policy1"}}
+	policy2 := []Policy{{ID: "policy2"}}
+	policy3 := []Policy{{ID: "policy3"}}
+
+	testcases := map[string]struct {
+		ttl  time.Duration
+		size int
+		// Operations over the cache before checking it
+		prepare func(cache *AuthzCache)
+		// Users to look up
+		externalID string
+		// Expected result
+		expectedPolicies []Policy
+		expectedFound    bool
+		expectedStats    AuthzCacheStats
+	}{
+		"OkCaseHit": {
+			ttl:  time.Minute,
+			size: 2,
+			prepare: func(cache *AuthzCache) {
+				cache.set("user1", policy1, 0, nil)
+			},
+			externalID:       "user1",
+			expectedPolicies: policy1,
+			expectedFound:    true,
+			expectedStats: AuthzCacheStats{
+				TTL:  time.Minute,
+				Size: 2,
+				Hits: 1,
+			},
+		},
+		"OkCaseMiss": {
+			ttl:  time.Minute,
+			size: 2,
+			prepare: func(cache *AuthzCache) {
+				cache.set("user1", policy1, 0, nil)
+			},
+			externalID: "user2",
+			expectedStats: AuthzCacheStats{
+				TTL:    time.Minute,
+				Size:   2,
+				Misses: 1,
+			},
+		},
+		"OkCaseReplaced": {
+			ttl:  time.Minute,
+			size: 2,
+			prepare: func(cache *AuthzCache) {
+				cache.set("user1", policy1, 0, nil)
+				cache.set("user1", policy2, 0, nil)
+			},
+			externalID:       "user1",
+			expectedPolicies: policy2,
+			expectedFound:    true,
+			expectedStats: AuthzCacheStats{
+				TTL:  time.Minute,
+				Size: 2,
+				Hits: 1,
+			},
+		},
+		"OkCaseExpired": {
+			ttl:  time.Nanosecond,
+			size: 2,
+			prepare: func(cache *AuthzCache) {
+				cache.set("user1", policy1, 0, nil)
+				time.Sleep(time.Millisecond)
+			},
+			externalID: "user1",
+			expectedStats: AuthzCacheStats{
+				TTL:    time.Nanosecond,
+				Size:   2,
+				Misses: 1,
+			},
+		},
+		"OkCaseRelationExpired": {
+			ttl:  time.Minute,
+			size: 2,
+			prepare: func(cache *AuthzCache) {
+				relationExpiresAt := time.Now().UTC().Add(time.Millisecond)
+				cache.set("user1", policy1, 0, &relationExpiresAt)
+				time.Sleep(2 * time.Millisecond)
+			},
+			externalID: "user1",
+			expectedStats: AuthzCacheStats{
+				TTL:    time.Minute,
+				Size:   2,
+				Misses: 1,
+			},
+		},
+		"OkCaseRelationExpiresAfterTTL": {
+			ttl:  time.Minute,
+			size: 2,
+			prepare: func(cache *AuthzCache) {
+				relationExpiresAt := time.Now().UTC().Add(time.Hour)
+				cache.set("user1", policy1, 0, &relationExpiresAt)
+			},
+			externalID:       "user1",
+			expectedPolicies: policy1,
+			expectedFound:    true,
+			expectedStats: AuthzCacheStats{
+				TTL:  time.Minute,
+				Size: 2,
+				Hits: 1,
+			},
+		},
+		"OkCaseLeastRecentlyUsedEvicted": {
+			ttl:  time.Minute,
+			size: 2,
+			prepare: func(cache *AuthzCache) {
+				cache.set("user1", policy1, 0, nil)
+				cache.set("user2", policy2, 0, nil)
+				cache.get("user1")
+				cache.set("user3", policy3, 0, nil)
+			},
+			externalID: "user2",
+			expectedStats: AuthzCacheStats{
+				TTL:    time.Minute,
+				Size:   2,
+				Hits:   1,
+				Misses: 1,
+			},
+		},
+		"OkCaseRecentlyUsedKept": {
+			ttl:  time.Minute,
+			size: 2,
+			prepare: func(cache *AuthzCache) {
+				cache.set("user1", policy1, 0, nil)
+				cache.set("user2", policy2, 0, nil)
+				cache.get("user1")
+				cache.set("user3", policy3, 0, nil)
+			},
+			externalID:       "user1",
+			expectedPolicies: policy1,
+			expectedFound:    true,
+			expectedStats: AuthzCacheStats{
+				TTL:  time.Minute,
+				Size: 2,
+				Hits: 2,
+			},
+		},
+		"OkCaseInvalidated": {
+			ttl:  time.Minute,
+			size: 2,
+			prepare: func(cache *AuthzCache) {
+				cache.set("user1", policy1, 0, nil)
+				cache.Invalidate()
+			},
+			externalID: "user1",
+			expectedStats: AuthzCacheStats{
+				TTL:    time.Minute,
+				Size:   2,
+				Misses: 1,
+			},
+		},
+		"OkCaseRetrievedBeforeInvalidation": {
+			ttl:  time.Minute,
+			size: 2,
+			prepare: func(cache *AuthzCache) {
+				_, generation, _ := cache.get("user1")
+				cache.Invalidate()
+				cache.set("user1", policy1, generation, nil)
+			},
+			externalID: "user1",
+			expectedStats: AuthzCacheStats{
+				TTL:    time.Minute,
+				Size:   2,
+				Misses: 2,
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		cache := NewAuthzCache(test.ttl, test.size)
+		test.prepare(cache)
+
+		policies, _, found := cache.get(test.externalID)
+		assert.Equal(t, test.expectedFound, found, "Error in test case %v", n)
+		assert.Equal(t, test.expectedPolicies, policies, "Error in test case %v", n)
+		assert.Equal(t, test.expectedStats, cache.Stats(), "Error in test case %v", n)
+	}
+}
+
+func TestAuthzCacheDisabled(t *testing.T) {
+	var cache *AuthzCache
+
+	cache.set("user1", []Policy{{ID: "policy1"}}, 0, nil)
+	policies, _, found := cache.get("user1")
+	cache.Invalidate()
+
+	assert.False(t, found, "Error in disabled cache")
+	assert.Nil(t, policies, "Error in disabled cache")
+	assert.Equal(t, AuthzCacheStats{}, cache.Stats(), "Error in disabled cache")
+}
+
+func TestGetAuthorizedResourcesWithCache(t *testing.T) {
+	requestInfo := RequestInfo{Identifier: "user1"}
+	urn := CreateUrn("example", RESOURCE_USER, "/path/", "user1")
+	resources := []Resource{User{ID: "UserID", Urn: urn}}
+	testcases := map[string]struct {
+		// Invalidate cache between requests
+		invalidate bool
+		// Expected result of the second request
+		expectedResources []Resource
+		wantError         error
+	}{
+		"OkCaseCached": {
+			expectedResources: resources,
+		},
+		"ErrorCaseInvalidated": {
+			invalidate: true,
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+		testAPI.AuthzCache = NewAuthzCache(time.Minute, 10)
+
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = &User{
+			ID: "UserID",
+		}
+		testRepo.ArgsOut[GetAttachedUserPoliciesMethod][0] = []TestPolicyUserRelation{
+			{
+				Policy: &Policy{
+					ID: "PolicyID",
+					Statements: &[]Statement{
+						{
+							Effect:    "allow",
+							Actions:   []string{USER_ACTION_GET_USER},
+							Resources: []string{urn},
+						},
+					},
+				},
+			},
+		}
+
+		// First request populates the cache
+		_, err := testAPI.getAuthorizedResources(requestInfo, urn, USER_ACTION_GET_USER, resources)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// Further requests to repositories fail
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = nil
+		testRepo.ArgsOut[GetUserByExternalIDMethod][1] = &database.Error{
+			Code: database.INTERNAL_ERROR,
+		}
+		if test.invalidate {
+			testAPI.AuthzCache.Invalidate()
+		}
+
+		authorizedResources, err := testAPI.getAuthorizedResources(requestInfo, urn, USER_ACTION_GET_USER, resources)
+		checkMethodResponse(t, n, test.wantError, err, test.expectedResources, authorizedResources)
+	}
+}
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"net/http"
 	"testing"
+	"time"
 
 	"fmt"
 
@@ -557,6 +559,59 @@ func TestGetAuthorizedExternalResources(t *testing.T) {
 				},
 			},
 		},
+		"OktestCaseHeaderEqualsResourceOrg": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+				Context: RequestContext{
+					Headers: http.Header{"X-Tenant": {"example"}},
+				},
+			},
+			resourceUrns: []string{
+				CreateUrn("example", RESOURCE_POLICY, "/path/", "policy1"),
+				CreateUrn("example1", RESOURCE_POLICY, "/path/", "policy2"),
+			},
+			action: POLICY_ACTION_GET_POLICY,
+			expectedResources: []string{
+				CreateUrn("example", RESOURCE_POLICY, "/path/", "policy1"),
+			},
+			getUserByExternalIDResult: &User{
+				ID:  "123456",
+				Urn: CreateUrn("", RESOURCE_USER, "/path/", "user1"),
+			},
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID:  "GROUP-USER-ID",
+						Urn: CreateUrn("example", RESOURCE_GROUP, "/path/", "groupUser"),
+					},
+				},
+			},
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID:  "POLICY-USER-ID",
+						Urn: CreateUrn("example", RESOURCE_POLICY, "/path/", "policyUser"),
+						Statements: &[]Statement{
+							{
+								Effect: "allow",
+								Actions: []string{
+									POLICY_ACTION_GET_POLICY,
+								},
+								Resources: []string{
+									"urn:*",
+								},
+								Conditions: Conditions{
+									CONDITION_STRING_EQUALS: {
+										CONDITION_KEY_REQUEST_HEADER + "X-Tenant": {"${" + CONDITION_KEY_RESOURCE_ORG + "}"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		"OktestCaseFullUrnDeny": {
 			requestInfo: RequestInfo{
 				Identifier: "123456",
@@ -700,6 +755,264 @@ func TestGetAuthorizedExternalResources(t *testing.T) {
 	}
 }
 
+func TestWorkerAPI_GetAuthorizedExternalResourcesBatch(t *testing.T) {
+	testcases := map[string]struct {
+		// Authenticated user
+		requestInfo RequestInfo
+		// Checks to authorize
+		checks []AuthorizationCheck
+		// Expected allowed resources per check
+		expectedResults []AuthorizationCheckResult
+		// Error to compare when we expect an error
+		wantError error
+		// GetUserByExternalID Method Out Arguments
+		getUserByExternalIDResult *User
+		getUserByExternalIDError  error
+		// GetGroupsByUserID Method Out Arguments
+		getGroupsByUserIDResult []TestUserGroupRelation
+		// GetAttachedPolicies Method Out Arguments
+		getAttachedPoliciesResult []TestPolicyGroupRelation
+	}{
+		"OkCaseAdmin": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			checks: []AuthorizationCheck{
+				{
+					Action:    "product:DoSomething",
+					Resources: []string{"urn:ews:product:instance:resource/path/resource1"},
+				},
+				{
+					Action:    "product:DoOther",
+					Resources: []string{"urn:ews:product:instance:resource/path/resource2"},
+				},
+			},
+			expectedResults: []AuthorizationCheckResult{
+				{
+					Action:           "product:DoSomething",
+					ResourcesAllowed: []string{"urn:ews:product:instance:resource/path/resource1"},
+				},
+				{
+					Action:           "product:DoOther",
+					ResourcesAllowed: []string{"urn:ews:product:instance:resource/path/resource2"},
+				},
+			},
+		},
+		"OkCaseUser": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+			},
+			checks: []AuthorizationCheck{
+				{
+					Action: "product:DoSomething",
+					Resources: []string{
+						"urn:ews:product:instance:resource/path/resource1",
+						"urn:ews:product:instance:resource/other/resource2",
+					},
+				},
+				{
+					Action:    "product:DoOther",
+					Resources: []string{"urn:ews:product:instance:resource/path/resource1"},
+				},
+				{
+					Action:    "product:DoSomething",
+					Resources: []string{"urn:ews:product:instance:resource/path/denied"},
+				},
+			},
+			expectedResults: []AuthorizationCheckResult{
+				{
+					Action:           "product:DoSomething",
+					ResourcesAllowed: []string{"urn:ews:product:instance:resource/path/resource1"},
+				},
+				{
+					Action:           "product:DoOther",
+					ResourcesAllowed: []string{},
+				},
+				{
+					Action:           "product:DoSomething",
+					ResourcesAllowed: []string{},
+				},
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "123456",
+				ExternalID: "123456",
+			},
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID: "GROUP1",
+					},
+				},
+			},
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID: "POLICY1",
+						Statements: &[]Statement{
+							{
+								Effect:    "allow",
+								Actions:   []string{"product:DoSomething"},
+								Resources: []string{"urn:ews:product:instance:resource/path/*"},
+							},
+							{
+								Effect:    "deny",
+								Actions:   []string{"product:DoSomething"},
+								Resources: []string{"urn:ews:product:instance:resource/path/denied"},
+							},
+						},
+					},
+				},
+			},
+		},
+		"ErrorCaseEmptyChecks": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter Checks. Checks can't be empty or bigger than %v elements", MAX_RESOURCE_NUMBER),
+			},
+		},
+		"ErrorCaseMaxChecksExceed": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+			},
+			checks: make([]AuthorizationCheck, MAX_RESOURCE_NUMBER+1),
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter Checks. Checks can't be empty or bigger than %v elements", MAX_RESOURCE_NUMBER),
+			},
+		},
+		"ErrorCaseInvalidAction": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+			},
+			checks: []AuthorizationCheck{
+				{
+					Action:    "product:DoSomething",
+					Resources: []string{"urn:ews:product:instance:resource/path/resource1"},
+				},
+				{
+					Action:    "valid::Action",
+					Resources: []string{"urn:ews:product:instance:resource/path/resource1"},
+				},
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter action, value: valid::Action",
+			},
+		},
+		"ErrorCaseActionPrefix": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+			},
+			checks: []AuthorizationCheck{
+				{
+					Action:    "product:*",
+					Resources: []string{"urn:ews:product:instance:resource/path/resource1"},
+				},
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter action product:*. Action parameter can't be a prefix",
+			},
+		},
+		"ErrorCaseInvalidResourceWithPrefix": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+			},
+			checks: []AuthorizationCheck{
+				{
+					Action:    "product:DoSomething",
+					Resources: []string{"urn:*"},
+				},
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter resource urn:*. Urn prefixes are not allowed here",
+			},
+		},
+		"ErrorCaseEmptyResources": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+			},
+			checks: []AuthorizationCheck{
+				{
+					Action: "product:DoSomething",
+				},
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter Resources. Resources can't be empty or bigger than %v elements", MAX_RESOURCE_NUMBER),
+			},
+		},
+		"ErrorCaseUserNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+			},
+			checks: []AuthorizationCheck{
+				{
+					Action:    "product:DoSomething",
+					Resources: []string{"urn:ews:product:instance:resource/path/resource1"},
+				},
+			},
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Authenticated user with externalId 123456 not found. Unable to retrieve permissions.",
+			},
+			getUserByExternalIDError: &database.Error{
+				Code: database.USER_NOT_FOUND,
+			},
+		},
+		"ErrorCaseInternalError": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+			},
+			checks: []AuthorizationCheck{
+				{
+					Action:    "product:DoSomething",
+					Resources: []string{"urn:ews:product:instance:resource/path/resource1"},
+				},
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+			getUserByExternalIDError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		// Count user retrievals, policies must be loaded once for all checks
+		userRetrievals := 0
+		testRepo.SpecialFuncs[GetUserByExternalIDMethod] = func(id string) (*User, error) {
+			userRetrievals++
+			return test.getUserByExternalIDResult, test.getUserByExternalIDError
+		}
+
+		testRepo.ArgsOut[GetGroupsByUserIDMethod][0] = test.getGroupsByUserIDResult
+		testRepo.ArgsOut[GetAttachedPoliciesMethod][0] = test.getAttachedPoliciesResult
+
+		results, err := testAPI.GetAuthorizedExternalResourcesBatch(test.requestInfo, test.checks)
+		checkMethodResponse(t, n, test.wantError, err, test.expectedResults, results)
+		if test.wantError == nil {
+			expectedRetrievals := 1
+			if test.requestInfo.Admin {
+				expectedRetrievals = 0
+			}
+			assert.Equal(t, expectedRetrievals, userRetrievals, "Error in test case %v", n)
+		}
+	}
+}
+
 // Test for aux methods of Foulkon
 
 func TestGetAuthorizedResources(t *testing.T) {
@@ -857,6 +1170,68 @@ func TestGetAuthorizedResources(t *testing.T) {
 				},
 			},
 		},
+		"OKtestCaseResourceConditionWithUrnPrefix": {
+			// This test case checks that statements with conditions on the resource urn, that can't be
+			// evaluated with the urn prefix, are evaluated with each resource
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			resourceUrn: GetUrnPrefix("example", RESOURCE_GROUP, "/path"),
+			action:      GROUP_ACTION_GET_GROUP,
+			resourcesToAuthorize: []Resource{
+				Group{
+					ID:  "654321",
+					Urn: CreateUrn("example", RESOURCE_GROUP, "/path/", "group1"),
+				},
+				Group{
+					ID:  "654322",
+					Urn: CreateUrn("example", RESOURCE_GROUP, "/path/", "group2"),
+				},
+			},
+			resourcesAuthorized: []Resource{
+				Group{
+					ID:  "654321",
+					Urn: CreateUrn("example", RESOURCE_GROUP, "/path/", "group1"),
+				},
+			},
+			getUserByExternalIDResult: &User{
+				ID:  "123456",
+				Urn: CreateUrn("", RESOURCE_USER, "/path/", "user1"),
+			},
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID:  "GROUP-USER-ID",
+						Urn: CreateUrn("example", RESOURCE_GROUP, "/path/", "groupUser"),
+					},
+				},
+			},
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID:  "POLICY-USER-ID",
+						Urn: CreateUrn("example", RESOURCE_POLICY, "/path/", "policyUser"),
+						Statements: &[]Statement{
+							{
+								Effect: "allow",
+								Actions: []string{
+									GROUP_ACTION_GET_GROUP,
+								},
+								Resources: []string{
+									GetUrnPrefix("example", RESOURCE_GROUP, "/"),
+								},
+								Conditions: Conditions{
+									CONDITION_STRING_LIKE: {
+										CONDITION_KEY_RESOURCE_URN: {"*/group1"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		"OKtestCaseResourcesFilteredReturnEmpty": {
 			// This test case checks if user has access to groups in /path2/ prefix, but there are groups
 			// only in /path/, so we expect a empty slice of groups authorized
@@ -934,7 +1309,7 @@ func TestGetAuthorizedResources(t *testing.T) {
 	}
 }
 
-func TestGetRestrictions(t *testing.T) {
+func TestGetAuthorizedResourcesByEffectivePolicies(t *testing.T) {
 	testcases := map[string]struct {
 		// Authenticated user identifier
 		authUserID string
@@ -942,8 +1317,10 @@ func TestGetRestrictions(t *testing.T) {
 		resourceUrn string
 		// Action to do
 		action string
-		// Expected Restrictions
-		expectedRestrictions *Restrictions
+		// Resources that user wants to access
+		resourcesToAuthorize []Resource
+		// Expected resources authorized
+		resourcesAuthorized []Resource
 		// Error to compare when we expect an error
 		wantError error
 		// GetUserByExternalID Method Out Arguments
@@ -955,6 +1332,9 @@ func TestGetRestrictions(t *testing.T) {
 		// GetAttachedPolicies Method Out Arguments
 		getAttachedPoliciesResult []TestPolicyGroupRelation
 		getAttachedPoliciesError  error
+		// GetAttachedUserPolicies Method Out Arguments
+		getAttachedUserPoliciesResult []TestPolicyUserRelation
+		getAttachedUserPoliciesError  error
 	}{
 		"ErrortestCaseGetUserAuthenticatedNotFound": {
 			authUserID:  "NotFound",
@@ -1014,15 +1394,91 @@ func TestGetRestrictions(t *testing.T) {
 				Code: database.INTERNAL_ERROR,
 			},
 		},
+		"ErrortestCaseGetUserPoliciesError": {
+			authUserID:  "InternalError",
+			resourceUrn: "urn:resource",
+			action:      USER_ACTION_GET_USER,
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+			getUserByExternalIDResult: &User{
+				ID: "UserID",
+			},
+			getAttachedUserPoliciesError: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+		},
+		"OktestCaseUserAndGroupPolicies": {
+			authUserID:  "AuthUserID",
+			resourceUrn: GetUrnPrefix("example", RESOURCE_GROUP, "/path"),
+			action:      GROUP_ACTION_GET_GROUP,
+			resourcesToAuthorize: []Resource{
+				Group{Urn: CreateUrn("example", RESOURCE_GROUP, "/path1/", "groupAllow")},
+				Group{Urn: CreateUrn("example", RESOURCE_GROUP, "/path1/", "groupDeny")},
+				Group{Urn: CreateUrn("example", RESOURCE_GROUP, "/path2/", "group")},
+			},
+			resourcesAuthorized: []Resource{
+				Group{Urn: CreateUrn("example", RESOURCE_GROUP, "/path1/", "groupAllow")},
+			},
+			getUserByExternalIDResult: &User{
+				ID: "AuthUserID",
+			},
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID: "GROUP-USER-ID",
+					},
+				},
+			},
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID:  "POLICY-GROUP-ID",
+						Urn: CreateUrn("example", RESOURCE_POLICY, "/path/", "policyGroup"),
+						Statements: &[]Statement{
+							{
+								Effect: "deny",
+								Actions: []string{
+									GROUP_ACTION_GET_GROUP,
+								},
+								Resources: []string{
+									CreateUrn("example", RESOURCE_GROUP, "/path1/", "groupDeny"),
+								},
+							},
+						},
+					},
+				},
+			},
+			getAttachedUserPoliciesResult: []TestPolicyUserRelation{
+				{
+					Policy: &Policy{
+						ID:  "POLICY-USER-ID",
+						Urn: CreateUrn("example", RESOURCE_POLICY, "/path/", "policyUser"),
+						Statements: &[]Statement{
+							{
+								Effect: "allow",
+								Actions: []string{
+									GROUP_ACTION_GET_GROUP,
+								},
+								Resources: []string{
+									GetUrnPrefix("example", RESOURCE_GROUP, "/path1/"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		"OktestCaseEmptyRelationsFullUrn": {
 			authUserID:  "AuthUserID",
 			resourceUrn: CreateUrn("example", RESOURCE_GROUP, "/path/", "group"),
 			action:      USER_ACTION_GET_USER,
-			expectedRestrictions: &Restrictions{
-				AllowedUrnPrefixes: []string{},
-				AllowedFullUrns:    []string{},
-				DeniedUrnPrefixes:  []string{},
-				DeniedFullUrns:     []string{},
+			resourcesToAuthorize: []Resource{
+				Group{Urn: CreateUrn("example", RESOURCE_GROUP, "/path/", "group")},
+			},
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId AuthUserID is not allowed to access to resource urn:iws:iam:example:group/path/group",
 			},
 			getUserByExternalIDResult: &User{
 				ID: "AuthUserID",
@@ -1032,11 +1488,12 @@ func TestGetRestrictions(t *testing.T) {
 			authUserID:  "AuthUserID",
 			resourceUrn: GetUrnPrefix("example", RESOURCE_GROUP, "/path/"),
 			action:      USER_ACTION_GET_USER,
-			expectedRestrictions: &Restrictions{
-				AllowedUrnPrefixes: []string{},
-				AllowedFullUrns:    []string{},
-				DeniedUrnPrefixes:  []string{},
-				DeniedFullUrns:     []string{},
+			resourcesToAuthorize: []Resource{
+				Group{Urn: CreateUrn("example", RESOURCE_GROUP, "/path/", "group")},
+			},
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId AuthUserID is not allowed to access to resource urn:iws:iam:example:group/path/*",
 			},
 			getUserByExternalIDResult: &User{
 				ID: "AuthUserID",
@@ -1046,13 +1503,11 @@ func TestGetRestrictions(t *testing.T) {
 			authUserID:  "AuthUserID",
 			resourceUrn: CreateUrn("example", RESOURCE_GROUP, "/path1/", "groupAllow"),
 			action:      GROUP_ACTION_GET_GROUP,
-			expectedRestrictions: &Restrictions{
-				AllowedUrnPrefixes: []string{
-					GetUrnPrefix("example", RESOURCE_GROUP, "/path1/"),
-				},
-				AllowedFullUrns:   []string{},
-				DeniedFullUrns:    []string{},
-				DeniedUrnPrefixes: []string{},
+			resourcesToAuthorize: []Resource{
+				Group{Urn: CreateUrn("example", RESOURCE_GROUP, "/path1/", "groupAllow")},
+			},
+			resourcesAuthorized: []Resource{
+				Group{Urn: CreateUrn("example", RESOURCE_GROUP, "/path1/", "groupAllow")},
 			},
 			getUserByExternalIDResult: &User{
 				ID: "AuthUserID",
@@ -1103,20 +1558,15 @@ func TestGetRestrictions(t *testing.T) {
 			authUserID:  "AuthUserID",
 			resourceUrn: GetUrnPrefix("example", RESOURCE_GROUP, "/path"),
 			action:      GROUP_ACTION_GET_GROUP,
-			expectedRestrictions: &Restrictions{
-				AllowedUrnPrefixes: []string{
-					GetUrnPrefix("example", RESOURCE_GROUP, "/path1/"),
-					GetUrnPrefix("example", RESOURCE_GROUP, "/path2/"),
-				},
-				AllowedFullUrns: []string{},
-				DeniedFullUrns: []string{
-					CreateUrn("example", RESOURCE_GROUP, "/path1/", "groupDeny"),
-					CreateUrn("example", RESOURCE_GROUP, "/path2/", "groupDeny"),
-				},
-				DeniedUrnPrefixes: []string{
-					GetUrnPrefix("example", RESOURCE_GROUP, "/path3/"),
-					GetUrnPrefix("example", RESOURCE_GROUP, "/path4/"),
-				},
+			resourcesToAuthorize: []Resource{
+				Group{Urn: CreateUrn("example", RESOURCE_GROUP, "/path1/", "groupAllow")},
+				Group{Urn: CreateUrn("example", RESOURCE_GROUP, "/path1/", "groupDeny")},
+				Group{Urn: CreateUrn("example", RESOURCE_GROUP, "/path2/", "group")},
+				Group{Urn: CreateUrn("example", RESOURCE_GROUP, "/path3/", "group")},
+			},
+			resourcesAuthorized: []Resource{
+				Group{Urn: CreateUrn("example", RESOURCE_GROUP, "/path1/", "groupAllow")},
+				Group{Urn: CreateUrn("example", RESOURCE_GROUP, "/path2/", "group")},
 			},
 			getUserByExternalIDResult: &User{
 				ID: "AuthUserID",
@@ -1178,11 +1628,17 @@ func TestGetRestrictions(t *testing.T) {
 		testRepo.ArgsOut[GetAttachedPoliciesMethod][0] = test.getAttachedPoliciesResult
 		testRepo.ArgsOut[GetAttachedPoliciesMethod][2] = test.getAttachedPoliciesError
 
-		restrictions, err := testAPI.getRestrictions(test.authUserID, test.action, test.resourceUrn)
-		checkMethodResponse(t, n, test.wantError, err, test.expectedRestrictions, restrictions)
-		if test.wantError == nil {
+		testRepo.ArgsOut[GetAttachedUserPoliciesMethod][0] = test.getAttachedUserPoliciesResult
+		testRepo.ArgsOut[GetAttachedUserPoliciesMethod][2] = test.getAttachedUserPoliciesError
+
+		requestInfo := RequestInfo{Identifier: test.authUserID}
+		authorizedResources, err := testAPI.getAuthorizedResources(requestInfo, test.resourceUrn, test.action, test.resourcesToAuthorize)
+		checkMethodResponse(t, n, test.wantError, err, test.resourcesAuthorized, authorizedResources)
+		if test.getUserByExternalIDResult != nil && test.getGroupsByUserIDError == nil && test.getAttachedPoliciesError == nil &&
+			test.getAttachedUserPoliciesError == nil {
 			assert.Equal(t, test.authUserID, testRepo.ArgsIn[GetUserByExternalIDMethod][0], "Error in test case %v", n)
 			assert.Equal(t, test.authUserID, testRepo.ArgsIn[GetGroupsByUserIDMethod][0], "Error in test case %v", n)
+			assert.Equal(t, test.authUserID, testRepo.ArgsIn[GetAttachedUserPoliciesMethod][0], "Error in test case %v", n)
 			if test.getGroupsByUserIDResult != nil {
 				assert.Equal(t, test.getGroupsByUserIDResult[0].Group.ID, testRepo.ArgsIn[GetAttachedPoliciesMethod][0], "Error in test case %v", n)
 			}
@@ -1191,46 +1647,142 @@ func TestGetRestrictions(t *testing.T) {
 }
 
 func TestGetGroupsByUser(t *testing.T) {
+	expired := time.Now().UTC().Add(-time.Hour)
+	notExpired := time.Now().UTC().Add(time.Hour)
 	testcases := map[string]struct {
 		// User ID to retrieve its groups
 		userID string
 		// Expected Groups
 		expectedGroups []Group
+		// Expected earliest membership expiration
+		expectedExpiresAt *time.Time
 		// Error to compare when we expect an error
 		wantError error
 		// GetGroupsByUserID Method Out Arguments
 		getGroupsByUserIDResult []TestUserGroupRelation
 		getGroupsByUserIDError  error
+		// GetAncestorGroups Method In and Out Arguments
+		expectedAncestorGroupIDs []string
+		getAncestorGroupsResult  []Group
+		getAncestorGroupsError   error
 	}{
 		"OktestCase": {
 			userID: "UserID",
 			expectedGroups: []Group{
 				{
-					ID: "GROUP-USER-ID1",
+					ID: "GROUP-USER-ID1",
+				},
+				{
+					ID: "GROUP-USER-ID2",
+				},
+			},
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID: "GROUP-USER-ID1",
+					},
+				},
+				{
+					Group: &Group{
+						ID: "GROUP-USER-ID2",
+					},
+				},
+			},
+		},
+		"OktestCaseWithParentGroups": {
+			userID: "UserID",
+			expectedGroups: []Group{
+				{
+					ID: "GROUP-USER-ID1",
+				},
+				{
+					ID: "GROUP-USER-ID2",
+				},
+				{
+					ID: "GROUP-PARENT-ID1",
+				},
+				{
+					ID: "GROUP-PARENT-ID2",
+				},
+			},
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID: "GROUP-USER-ID1",
+					},
+				},
+				{
+					Group: &Group{
+						ID: "GROUP-USER-ID2",
+					},
+				},
+			},
+			getAncestorGroupsResult: []Group{
+				{
+					ID: "GROUP-PARENT-ID1",
+				},
+				{
+					ID: "GROUP-USER-ID2",
+				},
+				{
+					ID: "GROUP-PARENT-ID2",
+				},
+			},
+		},
+		"ErrortestCase": {
+			userID: "UserID",
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+			getGroupsByUserIDError: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+		},
+		"OktestCaseWithExpiredMemberships": {
+			userID: "UserID",
+			expectedGroups: []Group{
+				{
+					ID: "GROUP-USER-ID2",
 				},
 				{
-					ID: "GROUP-USER-ID2",
+					ID: "GROUP-PARENT-ID2",
 				},
 			},
+			expectedExpiresAt: &notExpired,
 			getGroupsByUserIDResult: []TestUserGroupRelation{
 				{
 					Group: &Group{
 						ID: "GROUP-USER-ID1",
 					},
+					ExpiresAt: &expired,
 				},
 				{
 					Group: &Group{
 						ID: "GROUP-USER-ID2",
 					},
+					ExpiresAt: &notExpired,
+				},
+			},
+			expectedAncestorGroupIDs: []string{"GROUP-USER-ID2"},
+			getAncestorGroupsResult: []Group{
+				{
+					ID: "GROUP-PARENT-ID2",
 				},
 			},
 		},
-		"ErrortestCase": {
+		"ErrortestCaseGetAncestorGroups": {
 			userID: "UserID",
 			wantError: &Error{
 				Code: UNKNOWN_API_ERROR,
 			},
-			getGroupsByUserIDError: &database.Error{
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID: "GROUP-USER-ID1",
+					},
+				},
+			},
+			getAncestorGroupsError: &database.Error{
 				Code: database.INTERNAL_ERROR,
 			},
 		},
@@ -1243,17 +1795,28 @@ func TestGetGroupsByUser(t *testing.T) {
 
 		testRepo.ArgsOut[GetGroupsByUserIDMethod][0] = test.getGroupsByUserIDResult
 		testRepo.ArgsOut[GetGroupsByUserIDMethod][2] = test.getGroupsByUserIDError
+		testRepo.ArgsOut[GetAncestorGroupsMethod][0] = test.getAncestorGroupsResult
+		testRepo.ArgsOut[GetAncestorGroupsMethod][1] = test.getAncestorGroupsError
 
-		groups, err := testAPI.getGroupsByUser(test.userID)
+		groups, expiresAt, err := testAPI.getGroupsByUser(test.userID)
 		checkMethodResponse(t, n, test.wantError, err, test.expectedGroups, groups)
+		assert.Equal(t, test.expectedExpiresAt, expiresAt, "Error in test case %v", n)
 		assert.Equal(t, test.userID, testRepo.ArgsIn[GetGroupsByUserIDMethod][0], "Error in test case %v", n)
+		if test.expectedAncestorGroupIDs != nil {
+			assert.Equal(t, test.expectedAncestorGroupIDs, testRepo.ArgsIn[GetAncestorGroupsMethod][0], "Error in test case %v", n)
+		}
 	}
 }
 
 func TestGetPoliciesByGroups(t *testing.T) {
+	expired := time.Now().UTC().Add(-time.Hour)
+	expiresSoon := time.Now().UTC().Add(time.Hour)
+	expiresLater := time.Now().UTC().Add(2 * time.Hour)
 	testcases := map[string]struct {
 		groups           []Group
 		expectedPolicies []Policy
+		// Expected earliest attachment expiration
+		expectedExpiresAt *time.Time
 		// Error to compare when we expect an error
 		wantError error
 		// GetAttachedPolicies Method Out Arguments
@@ -1302,6 +1865,69 @@ func TestGetPoliciesByGroups(t *testing.T) {
 				},
 			},
 		},
+		"OktestCaseWithExpiredAttachments": {
+			groups: []Group{
+				{
+					ID: "GroupID1",
+				},
+			},
+			expectedPolicies: []Policy{
+				{
+					ID: "PolicyID2",
+				},
+			},
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID: "PolicyID1",
+					},
+					ExpiresAt: &expired,
+				},
+				{
+					Policy: &Policy{
+						ID: "PolicyID2",
+					},
+				},
+			},
+		},
+		"OktestCaseWithExpiringAttachments": {
+			groups: []Group{
+				{
+					ID: "GroupID1",
+				},
+			},
+			expectedPolicies: []Policy{
+				{
+					ID: "PolicyID1",
+				},
+				{
+					ID: "PolicyID2",
+				},
+				{
+					ID: "PolicyID3",
+				},
+			},
+			expectedExpiresAt: &expiresSoon,
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID: "PolicyID1",
+					},
+					ExpiresAt: &expiresLater,
+				},
+				{
+					Policy: &Policy{
+						ID: "PolicyID2",
+					},
+					ExpiresAt: &expiresSoon,
+				},
+				{
+					Policy: &Policy{
+						ID: "PolicyID3",
+					},
+				},
+			},
+		},
 		"ErrortestCase": {
 			groups: []Group{
 				{
@@ -1328,8 +1954,9 @@ func TestGetPoliciesByGroups(t *testing.T) {
 		testRepo.ArgsOut[GetAttachedPoliciesMethod][0] = test.getAttachedPoliciesResult
 		testRepo.ArgsOut[GetAttachedPoliciesMethod][2] = test.getAttachedPoliciesError
 
-		policies, err := testAPI.getPoliciesByGroups(test.groups)
+		policies, expiresAt, err := testAPI.getPoliciesByGroups(test.groups)
 		checkMethodResponse(t, n, test.wantError, err, test.expectedPolicies, policies)
+		assert.Equal(t, test.expectedExpiresAt, expiresAt, "Error in test case %v", n)
 		if test.wantError == nil && len(test.groups) > 0 {
 			assert.Equal(t, testRepo.ArgsIn[GetAttachedPoliciesMethod][0], test.groups[len(test.groups)-1].ID, "Error in test case %v", n)
 		}
@@ -1341,6 +1968,7 @@ func TestGetStatementsByRequestedAction(t *testing.T) {
 		// Policies to retrieve its statements according to an action
 		policies []Policy
 		action   string
+		context  RequestContext
 		// Expected data
 		expectedStatements []Statement
 	}{
@@ -1431,10 +2059,73 @@ func TestGetStatementsByRequestedAction(t *testing.T) {
 				},
 			},
 		},
+		"OktestCaseFilteredStatementsByConditions": {
+			policies: []Policy{
+				{
+					ID: "PolicyID1",
+					Statements: &[]Statement{
+						{
+							Effect:  "allow",
+							Actions: []string{"action"},
+							Resources: []string{
+								GetUrnPrefix("example", RESOURCE_GROUP, "/path1/"),
+							},
+							Conditions: Conditions{
+								CONDITION_IP_ADDRESS: {
+									CONDITION_KEY_SOURCE_IP: {"10.0.0.0/8"},
+								},
+							},
+						},
+						{
+							Effect:  "allow",
+							Actions: []string{"action"},
+							Resources: []string{
+								GetUrnPrefix("example", RESOURCE_GROUP, "/path2/"),
+							},
+							Conditions: Conditions{
+								CONDITION_IP_ADDRESS: {
+									CONDITION_KEY_SOURCE_IP: {"192.168.0.0/16"},
+								},
+							},
+						},
+						{
+							Effect:  "deny",
+							Actions: []string{"action"},
+							Resources: []string{
+								GetUrnPrefix("example", RESOURCE_GROUP, "/path3/"),
+							},
+							Conditions: Conditions{
+								CONDITION_BOOL: {
+									CONDITION_KEY_SECURE_TRANSPORT: {"true"},
+								},
+							},
+						},
+					},
+				},
+			},
+			action: "action",
+			context: RequestContext{
+				SourceIP: "10.1.2.3",
+			},
+			expectedStatements: []Statement{
+				{
+					Effect:  "allow",
+					Actions: []string{"action"},
+					Resources: []string{
+						GetUrnPrefix("example", RESOURCE_GROUP, "/path1/"),
+					},
+					Conditions: Conditions{
+						CONDITION_IP_ADDRESS: {
+							CONDITION_KEY_SOURCE_IP: {"10.0.0.0/8"},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for n, test := range testcases {
-		statements := getStatementsByRequestedAction(test.policies, test.action)
+		statements := getStatementsByRequestedAction(test.policies, test.action, test.context)
 		checkMethodResponse(t, n, nil, nil, test.expectedStatements, statements)
 	}
 }
@@ -2406,3 +3097,309 @@ func TestIsAllowedResource(t *testing.T) {
 		checkMethodResponse(t, n, nil, nil, test.expectedData, response)
 	}
 }
+
+func TestWorkerAPI_ExplainAuthorization(t *testing.T) {
+	allowStatement := Statement{
+		Effect:    "allow",
+		Actions:   []string{"example:Read"},
+		Resources: []string{"urn:ews:example:instance1:resource/*"},
+	}
+	denyStatement := Statement{
+		Effect:    "deny",
+		Actions:   []string{"example:*"},
+		Resources: []string{"urn:ews:example:instance1:resource/private"},
+	}
+	otherStatement := Statement{
+		Effect:    "allow",
+		Actions:   []string{"example:Read"},
+		Resources: []string{"urn:ews:example:instance1:other/*"},
+	}
+	groupIdentity := GroupIdentity{Org: "example", Name: "group1"}
+	policyIdentity := PolicyIdentity{Org: "example", Name: "policy1"}
+	testcases := map[string]struct {
+		// Method args
+		requestInfo RequestInfo
+		externalID  string
+		action      string
+		resource    string
+		// Expected result
+		expectedResponse *AuthorizationExplanation
+		wantError        error
+		// Manager Results
+		getUserByExternalIDResult     *User
+		getGroupsByUserIDResult       []TestUserGroupRelation
+		getAttachedPoliciesResult     []TestPolicyGroupRelation
+		getAttachedUserPoliciesResult []TestPolicyUserRelation
+		// Manager Errors
+		getUserByExternalIDError error
+	}{
+		"OkCaseAllowedByUserPolicy": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			externalID: "user1",
+			action:     "example:Read",
+			resource:   "urn:ews:example:instance1:resource/public",
+			expectedResponse: &AuthorizationExplanation{
+				ExternalID: "user1",
+				Action:     "example:Read",
+				Resource:   "urn:ews:example:instance1:resource/public",
+				Allowed:    true,
+				Groups:     []GroupIdentity{},
+				Policies:   []PolicyIdentity{policyIdentity},
+				Statements: []StatementSource{
+					{
+						Policy:    policyIdentity,
+						Statement: allowStatement,
+					},
+				},
+				Overrides: []StatementOverride{},
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "UserID",
+				ExternalID: "user1",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "user1"),
+			},
+			getAttachedUserPoliciesResult: []TestPolicyUserRelation{
+				{
+					Policy: &Policy{
+						ID:         "PolicyID",
+						Org:        "example",
+						Name:       "policy1",
+						Statements: &[]Statement{allowStatement, otherStatement},
+					},
+				},
+			},
+		},
+		"OkCaseAllowed": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			externalID: "user1",
+			action:     "example:Read",
+			resource:   "urn:ews:example:instance1:resource/public",
+			expectedResponse: &AuthorizationExplanation{
+				ExternalID: "user1",
+				Action:     "example:Read",
+				Resource:   "urn:ews:example:instance1:resource/public",
+				Allowed:    true,
+				Groups:     []GroupIdentity{groupIdentity},
+				Policies:   []PolicyIdentity{policyIdentity},
+				Statements: []StatementSource{
+					{
+						Group:     &groupIdentity,
+						Policy:    policyIdentity,
+						Statement: allowStatement,
+					},
+				},
+				Overrides: []StatementOverride{},
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "UserID",
+				ExternalID: "user1",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "user1"),
+			},
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID:   "GroupID",
+						Org:  "example",
+						Name: "group1",
+					},
+				},
+			},
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID:         "PolicyID",
+						Org:        "example",
+						Name:       "policy1",
+						Statements: &[]Statement{allowStatement, denyStatement, otherStatement},
+					},
+				},
+			},
+		},
+		"OkCaseDeniedWithOverride": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			externalID: "user1",
+			action:     "example:Read",
+			resource:   "urn:ews:example:instance1:resource/private",
+			expectedResponse: &AuthorizationExplanation{
+				ExternalID: "user1",
+				Action:     "example:Read",
+				Resource:   "urn:ews:example:instance1:resource/private",
+				Allowed:    false,
+				Groups:     []GroupIdentity{groupIdentity},
+				Policies:   []PolicyIdentity{policyIdentity},
+				Statements: []StatementSource{
+					{
+						Group:     &groupIdentity,
+						Policy:    policyIdentity,
+						Statement: allowStatement,
+					},
+					{
+						Group:     &groupIdentity,
+						Policy:    policyIdentity,
+						Statement: denyStatement,
+					},
+				},
+				Overrides: []StatementOverride{
+					{
+						Deny: StatementSource{
+							Group:     &groupIdentity,
+							Policy:    policyIdentity,
+							Statement: denyStatement,
+						},
+						Allow: StatementSource{
+							Group:     &groupIdentity,
+							Policy:    policyIdentity,
+							Statement: allowStatement,
+						},
+					},
+				},
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "UserID",
+				ExternalID: "user1",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "user1"),
+			},
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID:   "GroupID",
+						Org:  "example",
+						Name: "group1",
+					},
+				},
+			},
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID:         "PolicyID",
+						Org:        "example",
+						Name:       "policy1",
+						Statements: &[]Statement{allowStatement, denyStatement, otherStatement},
+					},
+				},
+			},
+		},
+		"OkCaseNoGroups": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			externalID: "user1",
+			action:     "example:Read",
+			resource:   "urn:ews:example:instance1:resource/public",
+			expectedResponse: &AuthorizationExplanation{
+				ExternalID: "user1",
+				Action:     "example:Read",
+				Resource:   "urn:ews:example:instance1:resource/public",
+				Allowed:    false,
+				Groups:     []GroupIdentity{},
+				Policies:   []PolicyIdentity{},
+				Statements: []StatementSource{},
+				Overrides:  []StatementOverride{},
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "UserID",
+				ExternalID: "user1",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "user1"),
+			},
+		},
+		"ErrorCaseInvalidExternalID": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			externalID: "*%~#@|",
+			action:     "example:Read",
+			resource:   "urn:ews:example:instance1:resource/public",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: externalId *%~#@|",
+			},
+		},
+		"ErrorCaseActionPrefix": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			externalID: "user1",
+			action:     "example:*",
+			resource:   "urn:ews:example:instance1:resource/public",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter action example:*. Action parameter can't be a prefix",
+			},
+		},
+		"ErrorCaseResourcePrefix": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			externalID: "user1",
+			action:     "example:Read",
+			resource:   "urn:ews:example:instance1:resource/*",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter resource urn:ews:example:instance1:resource/*. Urn prefixes are not allowed here",
+			},
+		},
+		"ErrorCaseUserNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			externalID: "user1",
+			action:     "example:Read",
+			resource:   "urn:ews:example:instance1:resource/public",
+			wantError: &Error{
+				Code:    USER_BY_EXTERNAL_ID_NOT_FOUND,
+				Message: "Error",
+			},
+			getUserByExternalIDError: &database.Error{
+				Code:    database.USER_NOT_FOUND,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseUnauthorized": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			externalID: "user1",
+			action:     "example:Read",
+			resource:   "urn:ews:example:instance1:resource/public",
+			wantError: &Error{
+				Code: UNAUTHORIZED_RESOURCES_ERROR,
+				Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+					"123456", CreateUrn("", RESOURCE_USER, "/path/", "user1")),
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "UserID",
+				ExternalID: "user1",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "user1"),
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = test.getUserByExternalIDResult
+		testRepo.ArgsOut[GetUserByExternalIDMethod][1] = test.getUserByExternalIDError
+		testRepo.ArgsOut[GetGroupsByUserIDMethod][0] = test.getGroupsByUserIDResult
+		testRepo.ArgsOut[GetAttachedPoliciesMethod][0] = test.getAttachedPoliciesResult
+		testRepo.ArgsOut[GetAttachedUserPoliciesMethod][0] = test.getAttachedUserPoliciesResult
+
+		explanation, err := testAPI.ExplainAuthorization(test.requestInfo, test.externalID, test.action, test.resource)
+		checkMethodResponse(t, n, test.wantError, err, test.expectedResponse, explanation)
+	}
+}
@@ -0,0 +1,318 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Condition operators
+	CONDITION_STRING_EQUALS             = "StringEquals"
+	CONDITION_STRING_NOT_EQUALS         = "StringNotEquals"
+	CONDITION_STRING_EQUALS_IGNORE_CASE = "StringEqualsIgnoreCase"
+	CONDITION_STRING_LIKE               = "StringLike"
+	CONDITION_STRING_NOT_LIKE           = "StringNotLike"
+	CONDITION_DATE_EQUALS               = "DateEquals"
+	CONDITION_DATE_LESS_THAN            = "DateLessThan"
+	CONDITION_DATE_LESS_THAN_EQUALS     = "DateLessThanEquals"
+	CONDITION_DATE_GREATER_THAN         = "DateGreaterThan"
+	CONDITION_DATE_GREATER_THAN_EQUALS  = "DateGreaterThanEquals"
+	CONDITION_IP_ADDRESS                = "IpAddress"
+	CONDITION_NOT_IP_ADDRESS            = "NotIpAddress"
+	CONDITION_BOOL                      = "Bool"
+
+	// Condition keys retrieved from request context
+	CONDITION_KEY_SOURCE_IP        = "foulkon:SourceIp"
+	CONDITION_KEY_CURRENT_TIME     = "foulkon:CurrentTime"
+	CONDITION_KEY_SECURE_TRANSPORT = "foulkon:SecureTransport"
+	CONDITION_KEY_USER_AGENT       = "foulkon:UserAgent"
+	CONDITION_KEY_REFERER          = "foulkon:Referer"
+	CONDITION_KEY_RESOURCE_URN     = "foulkon:ResourceUrn"
+	CONDITION_KEY_RESOURCE_ORG     = "foulkon:ResourceOrg"
+	// Prefix of the condition keys of request headers, e.g. foulkon:RequestHeader/X-Tenant
+	CONDITION_KEY_REQUEST_HEADER = "foulkon:RequestHeader/"
+)
+
+// Condition values can hold variables with the value of a condition key, e.g. ${foulkon:ResourceOrg}
+var conditionVariable = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// Valid header names, as HTTP tokens
+var headerName = regexp.MustCompile("^[!#$%&'*+\\-.^_`|~0-9A-Za-z]+$")
+
+// TYPE DEFINITIONS
+
+// Conditions maps a condition operator to the request context keys evaluated by it
+// and their accepted values, e.g. {"IpAddress": {"foulkon:SourceIp": ["10.0.0.0/8"]}}
+type Conditions map[string]map[string][]string
+
+// RequestContext holds request information used to evaluate statement conditions
+type RequestContext struct {
+	SourceIP        string
+	CurrentTime     time.Time
+	SecureTransport bool
+	UserAgent       string
+	Referer         string
+	Headers         http.Header
+	// Full URN of the resource being authorized, or URN prefix if the resources are listed
+	Resource string
+}
+
+// getValue returns the context value for a condition key, and false if it isn't present in the request
+func (c RequestContext) getValue(key string) (string, bool) {
+	switch key {
+	case CONDITION_KEY_SOURCE_IP:
+		return c.SourceIP, len(c.SourceIP) > 0
+	case CONDITION_KEY_CURRENT_TIME:
+		return c.CurrentTime.UTC().Format(time.RFC3339Nano), !c.CurrentTime.IsZero()
+	case CONDITION_KEY_SECURE_TRANSPORT:
+		return strconv.FormatBool(c.SecureTransport), true
+	case CONDITION_KEY_USER_AGENT:
+		return c.UserAgent, len(c.UserAgent) > 0
+	case CONDITION_KEY_REFERER:
+		return c.Referer, len(c.Referer) > 0
+	case CONDITION_KEY_RESOURCE_URN:
+		return c.Resource, len(c.Resource) > 0 && isFullUrn(c.Resource)
+	case CONDITION_KEY_RESOURCE_ORG:
+		// Org is the fourth field of the URN, e.g. urn:iws:iam:org1:group/path
+		fields := strings.SplitN(c.Resource, ":", 5)
+		if len(fields) < 5 || len(fields[3]) < 1 || strings.Contains(fields[3], "*") {
+			return "", false
+		}
+		return fields[3], true
+	default:
+		if strings.HasPrefix(key, CONDITION_KEY_REQUEST_HEADER) {
+			value := c.Headers.Get(strings.TrimPrefix(key, CONDITION_KEY_REQUEST_HEADER))
+			return value, len(value) > 0
+		}
+		return "", false
+	}
+}
+
+// withResource returns a copy of the request context to evaluate the conditions of a resource
+func (c RequestContext) withResource(resource string) RequestContext {
+	c.Resource = resource
+	return c
+}
+
+// PRIVATE HELPER METHODS
+
+// Returns true if all conditions are satisfied by the request context. Every operator and key must be
+// satisfied, and it's enough that one of the values of a key matches. If the key isn't present
+// in the request context, the condition isn't satisfied.
+func areConditionsMet(conditions Conditions, context RequestContext) bool {
+	for operator, keys := range conditions {
+		for key, values := range keys {
+			contextValue, ok := context.getValue(key)
+			if !ok || !isConditionMet(operator, contextValue, values, context) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Returns true if some condition evaluates the resource being authorized, with a key or a variable of a value
+func areConditionsOnResource(conditions Conditions) bool {
+	isResourceKey := func(key string) bool {
+		return key == CONDITION_KEY_RESOURCE_URN || key == CONDITION_KEY_RESOURCE_ORG
+	}
+	for _, keys := range conditions {
+		for key, values := range keys {
+			if isResourceKey(key) {
+				return true
+			}
+			for _, value := range values {
+				for _, match := range conditionVariable.FindAllStringSubmatch(value, -1) {
+					if isResourceKey(match[1]) {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Evaluate an operator over a context value with the condition values
+func isConditionMet(operator string, contextValue string, values []string, context RequestContext) bool {
+	switch operator {
+	case CONDITION_STRING_NOT_EQUALS, CONDITION_STRING_NOT_LIKE, CONDITION_NOT_IP_ADDRESS:
+		// Negated operators are satisfied if no value matches. Values with variables that aren't
+		// present in the request can't be checked, so they aren't satisfied
+		for _, value := range values {
+			if _, ok := expandConditionVariables(value, context, false); !ok {
+				return false
+			}
+			if matchConditionValue(operator, contextValue, value, context) {
+				return false
+			}
+		}
+		return true
+	default:
+		for _, value := range values {
+			if matchConditionValue(operator, contextValue, value, context) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Returns true if a context value matches a condition value for an operator. Variables of the condition value
+// are replaced with their context values, and it doesn't match if any of them isn't present in the request
+func matchConditionValue(operator string, contextValue string, value string, context RequestContext) bool {
+	switch operator {
+	case CONDITION_STRING_LIKE, CONDITION_STRING_NOT_LIKE:
+		// Wildcards in variable values are matched literally
+		expr, ok := expandConditionVariables(value, context, true)
+		return ok && matchStringLike(contextValue, expr)
+	}
+	value, ok := expandConditionVariables(value, context, false)
+	if !ok {
+		return false
+	}
+	switch operator {
+	case CONDITION_STRING_EQUALS, CONDITION_STRING_NOT_EQUALS:
+		return contextValue == value
+	case CONDITION_STRING_EQUALS_IGNORE_CASE:
+		return strings.EqualFold(contextValue, value)
+	case CONDITION_DATE_EQUALS, CONDITION_DATE_LESS_THAN, CONDITION_DATE_LESS_THAN_EQUALS,
+		CONDITION_DATE_GREATER_THAN, CONDITION_DATE_GREATER_THAN_EQUALS:
+		contextDate, err := time.Parse(time.RFC3339Nano, contextValue)
+		if err != nil {
+			return false
+		}
+		date, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return false
+		}
+		switch operator {
+		case CONDITION_DATE_EQUALS:
+			return contextDate.Equal(date)
+		case CONDITION_DATE_LESS_THAN:
+			return contextDate.Before(date)
+		case CONDITION_DATE_LESS_THAN_EQUALS:
+			return !contextDate.After(date)
+		case CONDITION_DATE_GREATER_THAN:
+			return contextDate.After(date)
+		default:
+			return !contextDate.Before(date)
+		}
+	case CONDITION_IP_ADDRESS, CONDITION_NOT_IP_ADDRESS:
+		ip := net.ParseIP(contextValue)
+		if ip == nil {
+			return false
+		}
+		if _, network, err := net.ParseCIDR(value); err == nil {
+			return network.Contains(ip)
+		}
+		valueIP := net.ParseIP(value)
+		return valueIP != nil && valueIP.Equal(ip)
+	case CONDITION_BOOL:
+		b, err := strconv.ParseBool(value)
+		return err == nil && strconv.FormatBool(b) == contextValue
+	default:
+		return false
+	}
+}
+
+// Match a value against an expression from expandConditionVariables, with '*' (any sequence of characters)
+// and '?' (any single character) wildcards
+func matchStringLike(value string, expr string) bool {
+	match, err := regexp.MatchString("^"+expr+"$", value)
+	return err == nil && match
+}
+
+// expandConditionVariables replaces the variables of a condition value with their context values. If like is true,
+// the value is a StringLike pattern and it returns its regular expression, where the variable values are matched
+// literally. It returns false if any variable isn't present in the request
+func expandConditionVariables(value string, context RequestContext, like bool) (string, bool) {
+	literal, variable := noEscape, noEscape
+	if like {
+		literal, variable = likeExpression, regexp.QuoteMeta
+	}
+	expanded := ""
+	last := 0
+	for _, match := range conditionVariable.FindAllStringSubmatchIndex(value, -1) {
+		contextValue, ok := context.getValue(value[match[2]:match[3]])
+		if !ok {
+			return "", false
+		}
+		expanded += literal(value[last:match[0]]) + variable(contextValue)
+		last = match[1]
+	}
+	return expanded + literal(value[last:]), true
+}
+
+// likeExpression transforms a StringLike pattern to a regular expression
+func likeExpression(pattern string) string {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.Replace(expr, `\*`, ".*", -1)
+	return strings.Replace(expr, `\?`, ".", -1)
+}
+
+func noEscape(value string) string {
+	return value
+}
+
+// Check that a condition value is valid for its operator. Dates and IP addresses can't have variables
+func isValidConditionValue(operator string, value string) error {
+	for _, match := range conditionVariable.FindAllStringSubmatch(value, -1) {
+		if !isValidConditionKey(match[1]) {
+			return &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter: condition %v value %v, unknown variable %v", operator, value, match[0]),
+			}
+		}
+	}
+	switch operator {
+	case CONDITION_STRING_EQUALS, CONDITION_STRING_NOT_EQUALS, CONDITION_STRING_EQUALS_IGNORE_CASE,
+		CONDITION_STRING_LIKE, CONDITION_STRING_NOT_LIKE:
+		return nil
+	case CONDITION_DATE_EQUALS, CONDITION_DATE_LESS_THAN, CONDITION_DATE_LESS_THAN_EQUALS,
+		CONDITION_DATE_GREATER_THAN, CONDITION_DATE_GREATER_THAN_EQUALS:
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter: condition %v value %v, it must be a RFC3339 date", operator, value),
+			}
+		}
+	case CONDITION_IP_ADDRESS, CONDITION_NOT_IP_ADDRESS:
+		if _, _, err := net.ParseCIDR(value); err != nil && net.ParseIP(value) == nil {
+			return &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter: condition %v value %v, it must be an IP address or CIDR block", operator, value),
+			}
+		}
+	case CONDITION_BOOL:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter: condition %v value %v, it must be true or false", operator, value),
+			}
+		}
+	default:
+		return &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: condition operator %v", operator),
+		}
+	}
+	return nil
+}
+
+// Check that a condition key exists in request context
+func isValidConditionKey(key string) bool {
+	switch key {
+	case CONDITION_KEY_SOURCE_IP, CONDITION_KEY_CURRENT_TIME, CONDITION_KEY_SECURE_TRANSPORT,
+		CONDITION_KEY_USER_AGENT, CONDITION_KEY_REFERER, CONDITION_KEY_RESOURCE_URN, CONDITION_KEY_RESOURCE_ORG:
+		return true
+	default:
+		return strings.HasPrefix(key, CONDITION_KEY_REQUEST_HEADER) &&
+			headerName.MatchString(strings.TrimPrefix(key, CONDITION_KEY_REQUEST_HEADER))
+	}
+}
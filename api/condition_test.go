@@ -0,0 +1,349 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAreConditionsMet(t *testing.T) {
+	now := time.Date(2016, 10, 1, 12, 0, 0, 0, time.UTC)
+	context := RequestContext{
+		SourceIP:        "10.1.2.3",
+		CurrentTime:     now,
+		SecureTransport: true,
+		UserAgent:       "curl/7.50.1",
+	}
+	resourceContext := RequestContext{
+		Headers:  http.Header{"X-Tenant": {"org1"}},
+		Resource: "urn:iws:iam:org1:group/path/group1",
+	}
+	testcases := map[string]struct {
+		// Method args
+		conditions Conditions
+		context    RequestContext
+		// Expected result
+		expectedResult bool
+	}{
+		"OkCaseNoConditions": {
+			context:        context,
+			expectedResult: true,
+		},
+		"OkCaseIpAddressInRange": {
+			conditions: Conditions{
+				CONDITION_IP_ADDRESS: {
+					CONDITION_KEY_SOURCE_IP: {"192.168.0.0/16", "10.0.0.0/8"},
+				},
+			},
+			context:        context,
+			expectedResult: true,
+		},
+		"OkCaseIpAddressEqual": {
+			conditions: Conditions{
+				CONDITION_IP_ADDRESS: {
+					CONDITION_KEY_SOURCE_IP: {"10.1.2.3"},
+				},
+			},
+			context:        context,
+			expectedResult: true,
+		},
+		"OkCaseIpAddressNotInRange": {
+			conditions: Conditions{
+				CONDITION_IP_ADDRESS: {
+					CONDITION_KEY_SOURCE_IP: {"192.168.0.0/16"},
+				},
+			},
+			context:        context,
+			expectedResult: false,
+		},
+		"OkCaseNotIpAddress": {
+			conditions: Conditions{
+				CONDITION_NOT_IP_ADDRESS: {
+					CONDITION_KEY_SOURCE_IP: {"192.168.0.0/16", "172.16.0.0/12"},
+				},
+			},
+			context:        context,
+			expectedResult: true,
+		},
+		"OkCaseNotIpAddressInRange": {
+			conditions: Conditions{
+				CONDITION_NOT_IP_ADDRESS: {
+					CONDITION_KEY_SOURCE_IP: {"192.168.0.0/16", "10.0.0.0/8"},
+				},
+			},
+			context:        context,
+			expectedResult: false,
+		},
+		"OkCaseDateLessThan": {
+			conditions: Conditions{
+				CONDITION_DATE_LESS_THAN: {
+					CONDITION_KEY_CURRENT_TIME: {"2016-10-02T00:00:00Z"},
+				},
+			},
+			context:        context,
+			expectedResult: true,
+		},
+		"OkCaseDateGreaterThanExpired": {
+			conditions: Conditions{
+				CONDITION_DATE_GREATER_THAN: {
+					CONDITION_KEY_CURRENT_TIME: {"2016-10-02T00:00:00Z"},
+				},
+			},
+			context:        context,
+			expectedResult: false,
+		},
+		"OkCaseDateRange": {
+			conditions: Conditions{
+				CONDITION_DATE_GREATER_THAN_EQUALS: {
+					CONDITION_KEY_CURRENT_TIME: {"2016-10-01T12:00:00Z"},
+				},
+				CONDITION_DATE_LESS_THAN_EQUALS: {
+					CONDITION_KEY_CURRENT_TIME: {"2016-10-01T12:00:00Z"},
+				},
+			},
+			context:        context,
+			expectedResult: true,
+		},
+		"OkCaseStringEquals": {
+			conditions: Conditions{
+				CONDITION_STRING_EQUALS: {
+					CONDITION_KEY_USER_AGENT: {"curl/7.50.1"},
+				},
+			},
+			context:        context,
+			expectedResult: true,
+		},
+		"OkCaseStringNotEquals": {
+			conditions: Conditions{
+				CONDITION_STRING_NOT_EQUALS: {
+					CONDITION_KEY_USER_AGENT: {"curl/7.50.1"},
+				},
+			},
+			context:        context,
+			expectedResult: false,
+		},
+		"OkCaseStringEqualsIgnoreCase": {
+			conditions: Conditions{
+				CONDITION_STRING_EQUALS_IGNORE_CASE: {
+					CONDITION_KEY_USER_AGENT: {"CURL/7.50.1"},
+				},
+			},
+			context:        context,
+			expectedResult: true,
+		},
+		"OkCaseStringLike": {
+			conditions: Conditions{
+				CONDITION_STRING_LIKE: {
+					CONDITION_KEY_USER_AGENT: {"curl/7.??.*"},
+				},
+			},
+			context:        context,
+			expectedResult: true,
+		},
+		"OkCaseStringNotLike": {
+			conditions: Conditions{
+				CONDITION_STRING_NOT_LIKE: {
+					CONDITION_KEY_USER_AGENT: {"Mozilla/*"},
+				},
+			},
+			context:        context,
+			expectedResult: true,
+		},
+		"OkCaseBool": {
+			conditions: Conditions{
+				CONDITION_BOOL: {
+					CONDITION_KEY_SECURE_TRANSPORT: {"true"},
+				},
+			},
+			context:        context,
+			expectedResult: true,
+		},
+		"OkCaseBoolFalse": {
+			conditions: Conditions{
+				CONDITION_BOOL: {
+					CONDITION_KEY_SECURE_TRANSPORT: {"false"},
+				},
+			},
+			context:        context,
+			expectedResult: false,
+		},
+		"OkCaseAllConditionsMustBeMet": {
+			conditions: Conditions{
+				CONDITION_IP_ADDRESS: {
+					CONDITION_KEY_SOURCE_IP: {"10.0.0.0/8"},
+				},
+				CONDITION_BOOL: {
+					CONDITION_KEY_SECURE_TRANSPORT: {"false"},
+				},
+			},
+			context:        context,
+			expectedResult: false,
+		},
+		"OkCaseKeyNotInContext": {
+			conditions: Conditions{
+				CONDITION_STRING_EQUALS: {
+					CONDITION_KEY_REFERER: {"http://example.com"},
+				},
+			},
+			context:        context,
+			expectedResult: false,
+		},
+		"OkCaseRequestHeader": {
+			conditions: Conditions{
+				CONDITION_STRING_EQUALS: {
+					CONDITION_KEY_REQUEST_HEADER + "x-tenant": {"org1"},
+				},
+			},
+			context:        resourceContext,
+			expectedResult: true,
+		},
+		"OkCaseRequestHeaderNotPresent": {
+			conditions: Conditions{
+				CONDITION_STRING_EQUALS: {
+					CONDITION_KEY_REQUEST_HEADER + "X-Other": {"org1"},
+				},
+			},
+			context:        resourceContext,
+			expectedResult: false,
+		},
+		"OkCaseResourceUrn": {
+			conditions: Conditions{
+				CONDITION_STRING_LIKE: {
+					CONDITION_KEY_RESOURCE_URN: {"urn:iws:iam:org1:group/*"},
+				},
+			},
+			context:        resourceContext,
+			expectedResult: true,
+		},
+		"OkCaseResourceUrnPrefix": {
+			conditions: Conditions{
+				CONDITION_STRING_LIKE: {
+					CONDITION_KEY_RESOURCE_URN: {"urn:iws:iam:org1:group/*"},
+				},
+			},
+			context:        resourceContext.withResource("urn:iws:iam:org1:group/*"),
+			expectedResult: false,
+		},
+		"OkCaseResourceOrgPrefix": {
+			conditions: Conditions{
+				CONDITION_STRING_EQUALS: {
+					CONDITION_KEY_RESOURCE_ORG: {"org1"},
+				},
+			},
+			context:        resourceContext.withResource("urn:iws:iam:org1:group/*"),
+			expectedResult: true,
+		},
+		"OkCaseResourceOrgNotPresent": {
+			conditions: Conditions{
+				CONDITION_STRING_EQUALS: {
+					CONDITION_KEY_RESOURCE_ORG: {"org1"},
+				},
+			},
+			context:        resourceContext.withResource("urn:*"),
+			expectedResult: false,
+		},
+		"OkCaseHeaderEqualsResourceOrg": {
+			conditions: Conditions{
+				CONDITION_STRING_EQUALS: {
+					CONDITION_KEY_REQUEST_HEADER + "X-Tenant": {"${" + CONDITION_KEY_RESOURCE_ORG + "}"},
+				},
+			},
+			context:        resourceContext,
+			expectedResult: true,
+		},
+		"OkCaseHeaderNotEqualsResourceOrg": {
+			conditions: Conditions{
+				CONDITION_STRING_EQUALS: {
+					CONDITION_KEY_REQUEST_HEADER + "X-Tenant": {"${" + CONDITION_KEY_RESOURCE_ORG + "}"},
+				},
+			},
+			context:        resourceContext.withResource("urn:iws:iam:org2:group/path/group1"),
+			expectedResult: false,
+		},
+		"OkCaseResourceUrnLikeHeader": {
+			conditions: Conditions{
+				CONDITION_STRING_LIKE: {
+					CONDITION_KEY_RESOURCE_URN: {"urn:iws:iam:${" + CONDITION_KEY_REQUEST_HEADER + "X-Tenant}:*"},
+				},
+			},
+			context:        resourceContext,
+			expectedResult: true,
+		},
+		"OkCaseResourceUrnLikeHeaderWildcard": {
+			conditions: Conditions{
+				CONDITION_STRING_LIKE: {
+					CONDITION_KEY_RESOURCE_URN: {"urn:iws:iam:${" + CONDITION_KEY_REQUEST_HEADER + "X-Tenant}:*"},
+				},
+			},
+			context: RequestContext{
+				Headers:  http.Header{"X-Tenant": {"*"}},
+				Resource: "urn:iws:iam:org1:group/path/group1",
+			},
+			expectedResult: false,
+		},
+		"OkCaseVariableNotPresent": {
+			conditions: Conditions{
+				CONDITION_STRING_NOT_EQUALS: {
+					CONDITION_KEY_REQUEST_HEADER + "X-Tenant": {"${" + CONDITION_KEY_REQUEST_HEADER + "X-Other}"},
+				},
+			},
+			context:        resourceContext,
+			expectedResult: false,
+		},
+		"OkCaseEmptyContext": {
+			conditions: Conditions{
+				CONDITION_DATE_LESS_THAN: {
+					CONDITION_KEY_CURRENT_TIME: {"2016-10-02T00:00:00Z"},
+				},
+			},
+			expectedResult: false,
+		},
+	}
+
+	for n, test := range testcases {
+		result := areConditionsMet(test.conditions, test.context)
+		assert.Equal(t, test.expectedResult, result, "Error in test case %v", n)
+	}
+}
+
+func TestAreConditionsOnResource(t *testing.T) {
+	testcases := map[string]struct {
+		conditions Conditions
+		// Expected result
+		expectedResult bool
+	}{
+		"OkCaseNoConditions": {},
+		"OkCaseRequestConditions": {
+			conditions: Conditions{
+				CONDITION_IP_ADDRESS: {
+					CONDITION_KEY_SOURCE_IP: {"10.0.0.0/8"},
+				},
+				CONDITION_STRING_EQUALS: {
+					CONDITION_KEY_REQUEST_HEADER + "X-Tenant": {"org1"},
+				},
+			},
+		},
+		"OkCaseResourceUrnKey": {
+			conditions: Conditions{
+				CONDITION_STRING_LIKE: {
+					CONDITION_KEY_RESOURCE_URN: {"urn:iws:iam:org1:group/*"},
+				},
+			},
+			expectedResult: true,
+		},
+		"OkCaseResourceOrgVariable": {
+			conditions: Conditions{
+				CONDITION_STRING_EQUALS: {
+					CONDITION_KEY_REQUEST_HEADER + "X-Tenant": {"${foulkon:ResourceOrg}"},
+				},
+			},
+			expectedResult: true,
+		},
+	}
+
+	for n, test := range testcases {
+		assert.Equal(t, test.expectedResult, areConditionsOnResource(test.conditions), "Error in test case %v", n)
+	}
+}
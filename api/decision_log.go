@@ -0,0 +1,129 @@
+package api
+
+import (
+	"math/rand"
+	"time"
+)
+
+const (
+	// Decision sources
+	DECISION_SOURCE_WORKER = "worker"
+	DECISION_SOURCE_PROXY  = "proxy"
+)
+
+// TYPE DEFINITIONS
+
+// Decision is an authorization decision made for a user, an action and the requested resources.
+// It is allowed if any of the requested resources is allowed.
+type Decision struct {
+	Time   time.Time `json:"time"`
+	Source string    `json:"source"`
+	// Request identifier of the decision and, for proxy decisions, of the worker request that made it
+	RequestID       string   `json:"requestId,omitempty"`
+	WorkerRequestID string   `json:"workerRequestId,omitempty"`
+	User            string   `json:"user,omitempty"`
+	Action          string   `json:"action"`
+	RequestedUrns   []string `json:"requestedUrns"`
+	AllowedUrns     []string `json:"allowedUrns"`
+	Allowed         bool     `json:"allowed"`
+	// True if the proxy took the decision from its decision cache
+	Cached bool `json:"cached,omitempty"`
+	// Time spent to make the decision, in nanoseconds
+	Latency time.Duration `json:"latency"`
+}
+
+// DecisionSink is the destination of the decisions recorded in a decision log
+type DecisionSink interface {
+	// Write emits a decision. Throw error if it couldn't be emitted.
+	Write(decision Decision) error
+
+	// Close releases the sink resources, flushing pending decisions
+	Close() error
+}
+
+// DecisionLog records authorization decisions in a sink. Allowed and denied decisions are sampled with
+// their own rate, between 0 (none) and 1 (all). A nil decision log is a disabled decision log.
+type DecisionLog struct {
+	sink            DecisionSink
+	allowSampleRate float64
+	denySampleRate  float64
+
+	// random returns a number in [0, 1) to sample decisions
+	random func() float64
+}
+
+// NewDecisionLog returns a decision log that writes to sink, or nil if there is no sink or no decision is sampled
+func NewDecisionLog(sink DecisionSink, allowSampleRate float64, denySampleRate float64) *DecisionLog {
+	if sink == nil || (allowSampleRate <= 0 && denySampleRate <= 0) {
+		return nil
+	}
+	return &DecisionLog{
+		sink:            sink,
+		allowSampleRate: allowSampleRate,
+		denySampleRate:  denySampleRate,
+		random:          rand.Float64,
+	}
+}
+
+// Record writes a decision in the sink if it is sampled. Sink errors are logged, they never fail the request.
+func (l *DecisionLog) Record(decision Decision) {
+	if l == nil || !l.sampled(decision.Allowed) {
+		return
+	}
+	if decision.Time.IsZero() {
+		decision.Time = time.Now().UTC()
+	}
+	if decision.RequestedUrns == nil {
+		decision.RequestedUrns = []string{}
+	}
+	if decision.AllowedUrns == nil {
+		decision.AllowedUrns = []string{}
+	}
+	if err := l.sink.Write(decision); err != nil {
+		Log.WithField("requestID", decision.RequestID).Warnf("Couldn't record authorization decision: %v", err)
+	}
+}
+
+// Close closes the decision log sink
+func (l *DecisionLog) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.sink.Close()
+}
+
+// sampled returns true if a decision with this result has to be recorded
+func (l *DecisionLog) sampled(allowed bool) bool {
+	rate := l.denySampleRate
+	if allowed {
+		rate = l.allowSampleRate
+	}
+	switch {
+	case rate >= 1:
+		return true
+	case rate <= 0:
+		return false
+	default:
+		return l.random() < rate
+	}
+}
+
+// PRIVATE HELPER METHODS
+
+// recordDecision records a decision made by the worker for the authenticated user since start
+func (api WorkerAPI) recordDecision(requestInfo RequestInfo, action string, requestedUrns []string, allowedUrns []string, start time.Time) {
+	if api.DecisionLog == nil {
+		return
+	}
+	api.DecisionLog.Record(Decision{
+		Time:          start.UTC(),
+		Source:        DECISION_SOURCE_WORKER,
+		RequestID:     requestInfo.RequestID,
+		User:          requestInfo.Identifier,
+		Action:        action,
+		RequestedUrns: requestedUrns,
+		AllowedUrns:   allowedUrns,
+		Allowed:       len(allowedUrns) > 0,
+		Latency:       time.Since(start),
+	})
+}
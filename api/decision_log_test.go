@@ -0,0 +1,354 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+// testDecisionSink stores the decisions written in memory
+type testDecisionSink struct {
+	decisions []Decision
+	err       error
+}
+
+func (s *testDecisionSink) Write(decision Decision) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.decisions = append(s.decisions, decision)
+	return nil
+}
+
+func (s *testDecisionSink) Close() error {
+	return nil
+}
+
+func TestNewDecisionLog(t *testing.T) {
+	testcases := map[string]struct {
+		sink            DecisionSink
+		allowSampleRate float64
+		denySampleRate  float64
+		expectedNil     bool
+	}{
+		"OkCase": {
+			sink:            &testDecisionSink{},
+			allowSampleRate: 0.1,
+			denySampleRate:  1,
+		},
+		"OkCaseOnlyDenies": {
+			sink:           &testDecisionSink{},
+			denySampleRate: 1,
+		},
+		"OkCaseDisabledWithoutSink": {
+			allowSampleRate: 1,
+			denySampleRate:  1,
+			expectedNil:     true,
+		},
+		"OkCaseDisabledWithoutSampling": {
+			sink:        &testDecisionSink{},
+			expectedNil: true,
+		},
+	}
+
+	for n, test := range testcases {
+		decisionLog := NewDecisionLog(test.sink, test.allowSampleRate, test.denySampleRate)
+		assert.Equal(t, test.expectedNil, decisionLog == nil, "Error in test case %v", n)
+	}
+}
+
+func TestDecisionLog_Record(t *testing.T) {
+	testcases := map[string]struct {
+		allowSampleRate float64
+		denySampleRate  float64
+		random          float64
+		sinkErr         error
+		decision        Decision
+		// Expected result
+		expectedRecorded bool
+	}{
+		"OkCaseAllowed": {
+			allowSampleRate:  1,
+			random:           0.99,
+			decision:         Decision{Allowed: true, AllowedUrns: []string{"urn"}},
+			expectedRecorded: true,
+		},
+		"OkCaseAllowedSampled": {
+			allowSampleRate:  0.5,
+			random:           0.2,
+			decision:         Decision{Allowed: true, AllowedUrns: []string{"urn"}},
+			expectedRecorded: true,
+		},
+		"OkCaseAllowedNotSampled": {
+			allowSampleRate: 0.5,
+			denySampleRate:  1,
+			random:          0.7,
+			decision:        Decision{Allowed: true, AllowedUrns: []string{"urn"}},
+		},
+		"OkCaseDenied": {
+			denySampleRate:   1,
+			random:           0.99,
+			decision:         Decision{},
+			expectedRecorded: true,
+		},
+		"OkCaseDeniedNotSampled": {
+			allowSampleRate: 1,
+			decision:        Decision{},
+		},
+		"ErrorCaseSinkError": {
+			allowSampleRate: 1,
+			sinkErr:         errors.New("Error"),
+			decision:        Decision{Allowed: true, AllowedUrns: []string{"urn"}},
+		},
+	}
+
+	for n, test := range testcases {
+		makeTestAPI(makeTestRepo())
+		sink := &testDecisionSink{err: test.sinkErr}
+		decisionLog := NewDecisionLog(sink, test.allowSampleRate, test.denySampleRate)
+		random := test.random
+		decisionLog.random = func() float64 { return random }
+
+		decisionLog.Record(test.decision)
+		if test.expectedRecorded {
+			if assert.Len(t, sink.decisions, 1, "Error in test case %v", n) {
+				// Check empty fields are filled
+				decision := sink.decisions[0]
+				assert.False(t, decision.Time.IsZero(), "Error in test case %v", n)
+				assert.NotNil(t, decision.RequestedUrns, "Error in test case %v", n)
+				assert.NotNil(t, decision.AllowedUrns, "Error in test case %v", n)
+				assert.Equal(t, test.decision.Allowed, decision.Allowed, "Error in test case %v", n)
+			}
+		} else {
+			assert.Empty(t, sink.decisions, "Error in test case %v", n)
+		}
+	}
+
+	// A nil decision log is disabled
+	var decisionLog *DecisionLog
+	decisionLog.Record(Decision{})
+	assert.Nil(t, decisionLog.Close())
+}
+
+func TestWorkerAPI_GetAuthorizedExternalResourcesDecisionLog(t *testing.T) {
+	testcases := map[string]struct {
+		requestInfo RequestInfo
+		action      string
+		resources   []string
+		// Manager Errors
+		getUserByExternalIDError error
+		// Expected result
+		expectedDecision *Decision
+	}{
+		"OkCaseAllowed": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				RequestID:  "RequestID",
+				Admin:      true,
+			},
+			action:    "product:DoSomething",
+			resources: []string{"urn:ews:product:instance:resource/res1"},
+			expectedDecision: &Decision{
+				Source:        DECISION_SOURCE_WORKER,
+				RequestID:     "RequestID",
+				User:          "admin",
+				Action:        "product:DoSomething",
+				RequestedUrns: []string{"urn:ews:product:instance:resource/res1"},
+				AllowedUrns:   []string{"urn:ews:product:instance:resource/res1"},
+				Allowed:       true,
+			},
+		},
+		"OkCaseDenied": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				RequestID:  "RequestID",
+			},
+			action:    "product:DoSomething",
+			resources: []string{"urn:ews:product:instance:resource/res1"},
+			getUserByExternalIDError: &database.Error{
+				Code: database.USER_NOT_FOUND,
+			},
+			expectedDecision: &Decision{
+				Source:        DECISION_SOURCE_WORKER,
+				RequestID:     "RequestID",
+				User:          "123456",
+				Action:        "product:DoSomething",
+				RequestedUrns: []string{"urn:ews:product:instance:resource/res1"},
+				AllowedUrns:   []string{},
+			},
+		},
+		"OkCaseInvalidParameterNotRecorded": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			action:    "invalid::action",
+			resources: []string{"urn:ews:product:instance:resource/res1"},
+		},
+		"OkCaseInternalErrorNotRecorded": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+			},
+			action:    "product:DoSomething",
+			resources: []string{"urn:ews:product:instance:resource/res1"},
+			getUserByExternalIDError: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+		sink := &testDecisionSink{}
+		testAPI.DecisionLog = NewDecisionLog(sink, 1, 1)
+
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = &User{ID: "UserID"}
+		testRepo.ArgsOut[GetUserByExternalIDMethod][1] = test.getUserByExternalIDError
+
+		testAPI.GetAuthorizedExternalResources(test.requestInfo, test.action, test.resources)
+		if test.expectedDecision == nil {
+			assert.Empty(t, sink.decisions, "Error in test case %v", n)
+			continue
+		}
+		if assert.Len(t, sink.decisions, 1, "Error in test case %v", n) {
+			decision := sink.decisions[0]
+			assert.False(t, decision.Time.IsZero(), "Error in test case %v", n)
+			// Time and latency change in each execution
+			decision.Time = time.Time{}
+			decision.Latency = 0
+			assert.Equal(t, *test.expectedDecision, decision, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerAPI_GetAuthorizedExternalResourcesBatchDecisionLog(t *testing.T) {
+	checks := []AuthorizationCheck{
+		{
+			Action:    "product:DoSomething",
+			Resources: []string{"urn:ews:product:instance:resource/res1", "urn:ews:product:instance:resource/res2"},
+		},
+		{
+			Action:    "product:DoOtherThing",
+			Resources: []string{"urn:ews:product:instance:resource/res1"},
+		},
+	}
+	testcases := map[string]struct {
+		requestInfo RequestInfo
+		checks      []AuthorizationCheck
+		// Manager Results
+		getAttachedUserPoliciesResult []TestPolicyUserRelation
+		getUserByExternalIDError      error
+		// Expected result
+		expectedDecisions []Decision
+	}{
+		"OkCaseAllowedAndDenied": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				RequestID:  "RequestID",
+			},
+			checks: checks,
+			getAttachedUserPoliciesResult: []TestPolicyUserRelation{
+				{
+					Policy: &Policy{
+						ID: "PolicyID",
+						Statements: &[]Statement{
+							{
+								Effect:    "allow",
+								Actions:   []string{"product:DoSomething"},
+								Resources: []string{"urn:ews:product:instance:resource/res1"},
+							},
+						},
+					},
+				},
+			},
+			expectedDecisions: []Decision{
+				{
+					Source:        DECISION_SOURCE_WORKER,
+					RequestID:     "RequestID",
+					User:          "123456",
+					Action:        "product:DoSomething",
+					RequestedUrns: []string{"urn:ews:product:instance:resource/res1", "urn:ews:product:instance:resource/res2"},
+					AllowedUrns:   []string{"urn:ews:product:instance:resource/res1"},
+					Allowed:       true,
+				},
+				{
+					Source:        DECISION_SOURCE_WORKER,
+					RequestID:     "RequestID",
+					User:          "123456",
+					Action:        "product:DoOtherThing",
+					RequestedUrns: []string{"urn:ews:product:instance:resource/res1"},
+					AllowedUrns:   []string{},
+				},
+			},
+		},
+		"OkCaseDenied": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				RequestID:  "RequestID",
+			},
+			checks: checks[1:],
+			getUserByExternalIDError: &database.Error{
+				Code: database.USER_NOT_FOUND,
+			},
+			expectedDecisions: []Decision{
+				{
+					Source:        DECISION_SOURCE_WORKER,
+					RequestID:     "RequestID",
+					User:          "123456",
+					Action:        "product:DoOtherThing",
+					RequestedUrns: []string{"urn:ews:product:instance:resource/res1"},
+					AllowedUrns:   []string{},
+				},
+			},
+		},
+		"OkCaseInvalidParameterNotRecorded": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			checks: []AuthorizationCheck{
+				{
+					Action:    "invalid::action",
+					Resources: []string{"urn:ews:product:instance:resource/res1"},
+				},
+			},
+			expectedDecisions: []Decision{},
+		},
+		"OkCaseInternalErrorNotRecorded": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+			},
+			checks: checks,
+			getUserByExternalIDError: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			expectedDecisions: []Decision{},
+		},
+	}
+
+	for n, test := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+		sink := &testDecisionSink{}
+		testAPI.DecisionLog = NewDecisionLog(sink, 1, 1)
+
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = &User{ID: "UserID"}
+		testRepo.ArgsOut[GetUserByExternalIDMethod][1] = test.getUserByExternalIDError
+		testRepo.ArgsOut[GetAttachedUserPoliciesMethod][0] = test.getAttachedUserPoliciesResult
+
+		testAPI.GetAuthorizedExternalResourcesBatch(test.requestInfo, test.checks)
+		decisions := []Decision{}
+		for _, decision := range sink.decisions {
+			assert.False(t, decision.Time.IsZero(), "Error in test case %v", n)
+			// Time and latency change in each execution
+			decision.Time = time.Time{}
+			decision.Latency = 0
+			decisions = append(decisions, decision)
+		}
+		assert.Equal(t, test.expectedDecisions, decisions, "Error in test case %v", n)
+	}
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// WriterDecisionSink writes decisions to a writer, one JSON document per line
+type WriterDecisionSink struct {
+	mutex sync.Mutex
+	out   io.Writer
+}
+
+// NewWriterDecisionSink returns a sink that writes to out, like os.Stdout
+func NewWriterDecisionSink(out io.Writer) *WriterDecisionSink {
+	return &WriterDecisionSink{out: out}
+}
+
+func (s *WriterDecisionSink) Write(decision Decision) error {
+	line, err := json.Marshal(decision)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, err = s.out.Write(append(line, '\n'))
+	return err
+}
+
+// Close does nothing, the writer is owned by the caller
+func (s *WriterDecisionSink) Close() error {
+	return nil
+}
+
+// FileDecisionSink writes decisions to a file, one JSON document per line. When the file would exceed
+// maxSize bytes, it is rotated to path.1, path.1 to path.2 and so on, keeping up to maxBackups files.
+type FileDecisionSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mutex sync.Mutex
+	file  *os.File
+	size  int64
+}
+
+// NewFileDecisionSink opens the file in path to append decisions, creating it if it doesn't exist.
+// Files aren't rotated if maxSize isn't positive.
+func NewFileDecisionSink(path string, maxSize int64, maxBackups int) (*FileDecisionSink, error) {
+	s := &FileDecisionSink{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileDecisionSink) Write(decision Decision) error {
+	line, err := json.Marshal(decision)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.file == nil {
+		return errors.New("Decision log file is closed")
+	}
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	return err
+}
+
+func (s *FileDecisionSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// open opens the current file and retrieves its size
+func (s *FileDecisionSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+// rotate shifts the backup files, dropping the oldest one, and starts a new current file
+func (s *FileDecisionSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	s.file = nil
+
+	if s.maxBackups < 1 {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	} else {
+		for i := s.maxBackups - 1; i > 0; i-- {
+			err := os.Rename(fmt.Sprintf("%v.%v", s.path, i), fmt.Sprintf("%v.%v", s.path, i+1))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(s.path, s.path+".1"); err != nil {
+			return err
+		}
+	}
+	return s.open()
+}
+
+// WebhookDecisionSink posts each decision as a JSON document to an URL. Decisions are queued and sent
+// in background, so requests don't wait for the endpoint. Decisions are dropped if the queue is full.
+type WebhookDecisionSink struct {
+	url    string
+	client *http.Client
+
+	// mutex guards closed, so decisions aren't queued after the queue is closed
+	mutex  sync.RWMutex
+	closed bool
+	queue  chan Decision
+	done   chan struct{}
+}
+
+// NewWebhookDecisionSink returns a sink that posts decisions to url, with a queue of bufferSize decisions
+func NewWebhookDecisionSink(url string, timeout time.Duration, bufferSize int) *WebhookDecisionSink {
+	s := &WebhookDecisionSink{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+		queue:  make(chan Decision, bufferSize),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *WebhookDecisionSink) Write(decision Decision) error {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.closed {
+		return errors.New("Decision log webhook is closed")
+	}
+	select {
+	case s.queue <- decision:
+		return nil
+	default:
+		return errors.New("Decision log webhook queue is full, decision dropped")
+	}
+}
+
+// Close stops queueing decisions and waits until the queued ones are sent
+func (s *WebhookDecisionSink) Close() error {
+	s.mutex.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mutex.Unlock()
+
+	<-s.done
+	return nil
+}
+
+// run sends queued decisions until the queue is closed
+func (s *WebhookDecisionSink) run() {
+	defer close(s.done)
+	for decision := range s.queue {
+		if err := s.post(decision); err != nil {
+			Log.WithField("requestID", decision.RequestID).Warnf("Couldn't send authorization decision to webhook: %v", err)
+		}
+	}
+}
+
+func (s *WebhookDecisionSink) post(decision Decision) error {
+	body, err := json.Marshal(decision)
+	if err != nil {
+		return err
+	}
+	res, err := s.client.Post(s.url, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("Unexpected status code %v", res.StatusCode)
+	}
+	return nil
+}
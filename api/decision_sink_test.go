@@ -0,0 +1,143 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterDecisionSink(t *testing.T) {
+	out := bytes.NewBuffer([]byte{})
+	sink := NewWriterDecisionSink(out)
+
+	decisions := []Decision{
+		{Source: DECISION_SOURCE_WORKER, User: "user1", Action: "product:Read", Allowed: true},
+		{Source: DECISION_SOURCE_PROXY, User: "user2", Action: "product:Write"},
+	}
+	for _, decision := range decisions {
+		assert.Nil(t, sink.Write(decision))
+	}
+	assert.Nil(t, sink.Close())
+
+	// Check a JSON document is written per line
+	scanner := bufio.NewScanner(out)
+	for _, expected := range decisions {
+		if !assert.True(t, scanner.Scan()) {
+			return
+		}
+		decision := Decision{}
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &decision))
+		assert.Equal(t, expected, decision)
+	}
+	assert.False(t, scanner.Scan())
+}
+
+func TestFileDecisionSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "decisions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "decisions.log")
+
+	decision := Decision{Source: DECISION_SOURCE_WORKER, User: "user", Action: "product:Read"}
+	line, _ := json.Marshal(decision)
+	lineSize := int64(len(line) + 1)
+
+	// Two decisions fit in each file, and two backups are kept
+	sink, err := NewFileDecisionSink(path, 2*lineSize, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 7; i++ {
+		assert.Nil(t, sink.Write(decision))
+	}
+	assert.Nil(t, sink.Close())
+	assert.NotNil(t, sink.Write(decision), "Closed sink must fail")
+
+	testcases := map[string]struct {
+		path         string
+		expectedSize int64
+	}{
+		"OkCaseCurrentFile": {
+			path:         path,
+			expectedSize: lineSize,
+		},
+		"OkCaseFirstBackup": {
+			path:         path + ".1",
+			expectedSize: 2 * lineSize,
+		},
+		"OkCaseSecondBackup": {
+			path:         path + ".2",
+			expectedSize: 2 * lineSize,
+		},
+	}
+	for n, test := range testcases {
+		info, err := os.Stat(test.path)
+		if assert.Nil(t, err, "Error in test case %v", n) {
+			assert.Equal(t, test.expectedSize, info.Size(), "Error in test case %v", n)
+		}
+	}
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err), "Oldest backup must be removed")
+
+	// Reopened files keep their size to rotate
+	sink, err = NewFileDecisionSink(path, 2*lineSize, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, sink.Write(decision))
+	assert.Nil(t, sink.Write(decision))
+	assert.Nil(t, sink.Close())
+	info, err := os.Stat(path)
+	if assert.Nil(t, err) {
+		assert.Equal(t, lineSize, info.Size())
+	}
+}
+
+func TestWebhookDecisionSink(t *testing.T) {
+	makeTestAPI(makeTestRepo())
+	received := make(chan Decision, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		decision := Decision{}
+		if err := json.NewDecoder(r.Body).Decode(&decision); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- decision
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookDecisionSink(server.URL, time.Second, 10)
+	decision := Decision{Source: DECISION_SOURCE_PROXY, RequestID: "RequestID", Action: "product:Read", Allowed: true}
+	assert.Nil(t, sink.Write(decision))
+	// Close waits until queued decisions are sent
+	assert.Nil(t, sink.Close())
+	assert.NotNil(t, sink.Write(decision), "Closed sink must fail")
+
+	select {
+	case sent := <-received:
+		assert.Equal(t, decision, sent)
+	default:
+		t.Fatal("Decision wasn't sent to webhook")
+	}
+
+	// Decisions are dropped when the queue is full
+	sink = NewWebhookDecisionSink(server.URL, time.Second, 0)
+	var dropped bool
+	for i := 0; i < 10 && !dropped; i++ {
+		dropped = sink.Write(decision) != nil
+	}
+	assert.True(t, dropped)
+	assert.Nil(t, sink.Close())
+}
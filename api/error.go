@@ -7,6 +7,7 @@ const (
 	UNKNOWN_API_ERROR            = "UnknownApiError"
 	INVALID_PARAMETER_ERROR      = "InvalidParameterError"
 	UNAUTHORIZED_RESOURCES_ERROR = "UnauthorizedResourcesError"
+	PRECONDITION_FAILED          = "PreconditionFailed"
 
 	// Authentication API error code
 	AUTHENTICATION_API_ERROR = "AuthenticationApiError"
@@ -23,10 +24,19 @@ const (
 	USER_IS_ALREADY_A_MEMBER_OF_GROUP = "UserIsAlreadyAMemberOfGroup"
 	USER_IS_NOT_A_MEMBER_OF_GROUP     = "UserIsNotAMemberOfGroup"
 
+	// GroupChildren error codes
+	GROUP_IS_ALREADY_A_CHILD_OF_GROUP = "GroupIsAlreadyAChildOfGroup"
+	GROUP_IS_NOT_A_CHILD_OF_GROUP     = "GroupIsNotAChildOfGroup"
+	GROUP_HIERARCHY_CYCLE             = "GroupHierarchyCycle"
+
 	// GroupPolicies error codes
 	POLICY_IS_ALREADY_ATTACHED_TO_GROUP = "PolicyIsAlreadyAttachedToGroup"
 	POLICY_IS_NOT_ATTACHED_TO_GROUP     = "PolicyIsNotAttachedToGroup"
 
+	// UserPolicies error codes
+	POLICY_IS_ALREADY_ATTACHED_TO_USER = "PolicyIsAlreadyAttachedToUser"
+	POLICY_IS_NOT_ATTACHED_TO_USER     = "PolicyIsNotAttachedToUser"
+
 	// Policy API error codes
 	POLICY_ALREADY_EXIST             = "PolicyAlreadyExist"
 	POLICY_BY_ORG_AND_NAME_NOT_FOUND = "PolicyWithOrgAndNameNotFound"
@@ -36,10 +46,19 @@ const (
 	PROXY_RESOURCE_BY_ORG_AND_NAME_NOT_FOUND = "ProxyResourceWithOrgAndNameNotFound"
 	PROXY_RESOURCES_ROUTES_CONFLICT          = "ProxyResourcesRoutesConflict"
 
+	// Upstream pools API error codes
+	UPSTREAM_POOL_ALREADY_EXIST             = "UpstreamPoolAlreadyExist"
+	UPSTREAM_POOL_BY_ORG_AND_NAME_NOT_FOUND = "UpstreamPoolWithOrgAndNameNotFound"
+	UPSTREAM_POOL_IN_USE                    = "UpstreamPoolInUse"
+
 	// Auth OIDC Provider API error codes
 	AUTH_OIDC_PROVIDER_ALREADY_EXIST     = "AuthOidcProviderAlreadyExist"
 	AUTH_OIDC_PROVIDER_BY_NAME_NOT_FOUND = "AuthOidcProviderWithNameNotFound"
 
+	// Webhook API error codes
+	WEBHOOK_ALREADY_EXIST     = "WebhookAlreadyExist"
+	WEBHOOK_BY_NAME_NOT_FOUND = "WebhookWithNameNotFound"
+
 	// Regex error
 	REGEX_NO_MATCH = "RegexNoMatch"
 )
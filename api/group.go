@@ -37,18 +37,38 @@ type GroupIdentity struct {
 }
 
 type GroupMembers struct {
-	User     string    `json:"user,omitempty"`
-	CreateAt time.Time `json:"joined,omitempty"`
+	User      string     `json:"user,omitempty"`
+	CreateAt  time.Time  `json:"joined,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
 }
 
 type GroupPolicies struct {
-	Policy   string    `json:"policy,omitempty"`
-	CreateAt time.Time `json:"attached,omitempty"`
+	Policy    string     `json:"policy,omitempty"`
+	CreateAt  time.Time  `json:"attached,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+type GroupChildren struct {
+	Group    string    `json:"group,omitempty"`
+	CreateAt time.Time `json:"joined,omitempty"`
 }
 
 // GROUP API IMPLEMENTATION
 
 func (api WorkerAPI) AddGroup(requestInfo RequestInfo, org string, name string, path string) (*Group, error) {
+	var group *Group
+	err := api.withTx(func(txAPI WorkerAPI) error {
+		var err error
+		group, err = txAPI.addGroup(requestInfo, org, name, path)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (api WorkerAPI) addGroup(requestInfo RequestInfo, org string, name string, path string) (*Group, error) {
 	// Validate fields
 	if !IsValidName(name) {
 		return nil, &Error{
@@ -106,6 +126,9 @@ func (api WorkerAPI) AddGroup(requestInfo RequestInfo, org string, name string,
 					Message: dbError.Message,
 				}
 			}
+			if err := api.audit(requestInfo, GROUP_ACTION_CREATE_GROUP, createdGroup.Urn, nil, createdGroup); err != nil {
+				return nil, err
+			}
 			LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Group created %+v", createdGroup))
 			return createdGroup, nil
 		default: // Unexpected error
@@ -225,6 +248,19 @@ func (api WorkerAPI) ListGroups(requestInfo RequestInfo, filter *Filter) ([]Grou
 }
 
 func (api WorkerAPI) UpdateGroup(requestInfo RequestInfo, org string, name string, newName string, newPath string) (*Group, error) {
+	var group *Group
+	err := api.withTx(func(txAPI WorkerAPI) error {
+		var err error
+		group, err = txAPI.updateGroup(requestInfo, org, name, newName, newPath)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (api WorkerAPI) updateGroup(requestInfo RequestInfo, org string, name string, newName string, newPath string) (*Group, error) {
 	// Validate fields
 	if !IsValidName(newName) {
 		return nil, &Error{
@@ -258,6 +294,11 @@ func (api WorkerAPI) UpdateGroup(requestInfo RequestInfo, org string, name strin
 		}
 	}
 
+	// Check the request applies to the current version
+	if err := checkIfMatch(requestInfo, oldGroup.Urn, oldGroup.UpdateAt); err != nil {
+		return nil, err
+	}
+
 	// Check if a group with "newName" already exists
 	newGroup, err := api.GetGroupByName(requestInfo, org, newName)
 
@@ -303,24 +344,42 @@ func (api WorkerAPI) UpdateGroup(requestInfo RequestInfo, org string, name strin
 		UpdateAt: time.Now().UTC(),
 	}
 
-	updatedGroup, err := api.GroupRepo.UpdateGroup(group)
+	updatedGroup, err := api.GroupRepo.UpdateGroup(group, oldGroup.UpdateAt)
 
-	// Check unexpected DB error
+	// Error handling
 	if err != nil {
 		//Transform to DB error
 		dbError := err.(*database.Error)
-		return nil, &Error{
-			Code:    UNKNOWN_API_ERROR,
-			Message: dbError.Message,
+		switch dbError.Code {
+		case database.VERSION_CONFLICT:
+			return nil, &Error{
+				Code:    PRECONDITION_FAILED,
+				Message: dbError.Message,
+			}
+		default: // Unexpected error
+			return nil, &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: dbError.Message,
+			}
 		}
 	}
 
+	if err := api.audit(requestInfo, GROUP_ACTION_UPDATE_GROUP, oldGroup.Urn, oldGroup, updatedGroup); err != nil {
+		return nil, err
+	}
+	api.AuthzCache.Invalidate()
 	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Group updated from %+v to %+v", oldGroup, updatedGroup))
 	return updatedGroup, nil
 
 }
 
 func (api WorkerAPI) RemoveGroup(requestInfo RequestInfo, org string, name string) error {
+	return api.withTx(func(txAPI WorkerAPI) error {
+		return txAPI.removeGroup(requestInfo, org, name)
+	})
+}
+
+func (api WorkerAPI) removeGroup(requestInfo RequestInfo, org string, name string) error {
 	// Call repo to retrieve the group
 	group, err := api.GetGroupByName(requestInfo, org, name)
 	if err != nil {
@@ -340,6 +399,11 @@ func (api WorkerAPI) RemoveGroup(requestInfo RequestInfo, org string, name strin
 		}
 	}
 
+	// Check the request applies to the current version
+	if err := checkIfMatch(requestInfo, group.Urn, group.UpdateAt); err != nil {
+		return err
+	}
+
 	err = api.GroupRepo.RemoveGroup(group.ID)
 
 	// Error handling
@@ -352,11 +416,29 @@ func (api WorkerAPI) RemoveGroup(requestInfo RequestInfo, org string, name strin
 		}
 	}
 
+	if err := api.audit(requestInfo, GROUP_ACTION_DELETE_GROUP, group.Urn, group, nil); err != nil {
+		return err
+	}
+	api.AuthzCache.Invalidate()
 	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Group deleted %v", group))
 	return nil
 }
 
-func (api WorkerAPI) AddMember(requestInfo RequestInfo, externalId string, name string, org string) error {
+func (api WorkerAPI) AddMember(requestInfo RequestInfo, externalId string, name string, org string, expiresAt *time.Time) error {
+	return api.withTx(func(txAPI WorkerAPI) error {
+		return txAPI.addMember(requestInfo, externalId, name, org, expiresAt)
+	})
+}
+
+func (api WorkerAPI) addMember(requestInfo RequestInfo, externalId string, name string, org string, expiresAt *time.Time) error {
+	// Validate fields
+	if !IsValidExpiration(expiresAt) {
+		return &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: expiresAt %v", expiresAt.Format(time.RFC3339)),
+		}
+	}
+
 	// Call repo to retrieve the group
 	groupDB, err := api.GetGroupByName(requestInfo, org, name)
 	if err != nil {
@@ -402,7 +484,7 @@ func (api WorkerAPI) AddMember(requestInfo RequestInfo, externalId string, name
 	}
 
 	// Add Member
-	err = api.GroupRepo.AddMember(userDB.ID, groupDB.ID)
+	err = api.GroupRepo.AddMember(userDB.ID, groupDB.ID, expiresAt)
 
 	// Check if there is an unexpected error in DB
 	if err != nil {
@@ -413,11 +495,21 @@ func (api WorkerAPI) AddMember(requestInfo RequestInfo, externalId string, name
 			Message: dbError.Message,
 		}
 	}
+	if err := api.audit(requestInfo, GROUP_ACTION_ADD_MEMBER, groupDB.Urn, nil, auditRelation{Urn: userDB.Urn, ExpiresAt: expiresAt}); err != nil {
+		return err
+	}
+	api.AuthzCache.Invalidate()
 	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Member %+v added to group %+v", userDB, groupDB))
 	return nil
 }
 
 func (api WorkerAPI) RemoveMember(requestInfo RequestInfo, externalId string, name string, org string) error {
+	return api.withTx(func(txAPI WorkerAPI) error {
+		return txAPI.removeMember(requestInfo, externalId, name, org)
+	})
+}
+
+func (api WorkerAPI) removeMember(requestInfo RequestInfo, externalId string, name string, org string) error {
 	// Call repo to retrieve the group
 	groupDB, err := api.GetGroupByName(requestInfo, org, name)
 	if err != nil {
@@ -475,6 +567,10 @@ func (api WorkerAPI) RemoveMember(requestInfo RequestInfo, externalId string, na
 		}
 	}
 
+	if err := api.audit(requestInfo, GROUP_ACTION_REMOVE_MEMBER, groupDB.Urn, auditRelation{Urn: userDB.Urn}, nil); err != nil {
+		return err
+	}
+	api.AuthzCache.Invalidate()
 	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Member %+v removed from group %+v", userDB, groupDB))
 	return nil
 }
@@ -525,8 +621,9 @@ func (api WorkerAPI) ListMembers(requestInfo RequestInfo, filter *Filter) ([]Gro
 		members = make([]GroupMembers, len(users), cap(users))
 		for i, m := range users {
 			members[i] = GroupMembers{
-				User:     m.GetUser().ExternalID,
-				CreateAt: m.GetDate(),
+				User:      m.GetUser().ExternalID,
+				CreateAt:  m.GetDate(),
+				ExpiresAt: m.GetExpiresAt(),
 			}
 		}
 	}
@@ -534,7 +631,20 @@ func (api WorkerAPI) ListMembers(requestInfo RequestInfo, filter *Filter) ([]Gro
 	return members, total, nil
 }
 
-func (api WorkerAPI) AttachPolicyToGroup(requestInfo RequestInfo, org string, name string, policyName string) error {
+func (api WorkerAPI) AttachPolicyToGroup(requestInfo RequestInfo, org string, name string, policyName string, expiresAt *time.Time) error {
+	return api.withTx(func(txAPI WorkerAPI) error {
+		return txAPI.attachPolicyToGroup(requestInfo, org, name, policyName, expiresAt)
+	})
+}
+
+func (api WorkerAPI) attachPolicyToGroup(requestInfo RequestInfo, org string, name string, policyName string, expiresAt *time.Time) error {
+	// Validate fields
+	if !IsValidExpiration(expiresAt) {
+		return &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: expiresAt %v", expiresAt.Format(time.RFC3339)),
+		}
+	}
 
 	// Check if group exists
 	group, err := api.GetGroupByName(requestInfo, org, name)
@@ -580,7 +690,7 @@ func (api WorkerAPI) AttachPolicyToGroup(requestInfo RequestInfo, org string, na
 	}
 
 	// Attach Policy to Group
-	err = api.GroupRepo.AttachPolicy(group.ID, policy.ID)
+	err = api.GroupRepo.AttachPolicy(group.ID, policy.ID, expiresAt)
 
 	if err != nil {
 		dbError := err.(*database.Error)
@@ -590,11 +700,21 @@ func (api WorkerAPI) AttachPolicyToGroup(requestInfo RequestInfo, org string, na
 		}
 	}
 
+	if err := api.audit(requestInfo, GROUP_ACTION_ATTACH_GROUP_POLICY, group.Urn, nil, auditRelation{Urn: policy.Urn, ExpiresAt: expiresAt}); err != nil {
+		return err
+	}
+	api.AuthzCache.Invalidate()
 	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Policy %+v attached to group %+v", policy, group))
 	return nil
 }
 
 func (api WorkerAPI) DetachPolicyToGroup(requestInfo RequestInfo, org string, name string, policyName string) error {
+	return api.withTx(func(txAPI WorkerAPI) error {
+		return txAPI.detachPolicyToGroup(requestInfo, org, name, policyName)
+	})
+}
+
+func (api WorkerAPI) detachPolicyToGroup(requestInfo RequestInfo, org string, name string, policyName string) error {
 
 	// Check if group exists
 	group, err := api.GetGroupByName(requestInfo, org, name)
@@ -651,6 +771,10 @@ func (api WorkerAPI) DetachPolicyToGroup(requestInfo RequestInfo, org string, na
 		}
 	}
 
+	if err := api.audit(requestInfo, GROUP_ACTION_DETACH_GROUP_POLICY, group.Urn, auditRelation{Urn: policy.Urn}, nil); err != nil {
+		return err
+	}
+	api.AuthzCache.Invalidate()
 	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Policy %+v detached from group %+v", policy, group))
 	return nil
 }
@@ -701,8 +825,9 @@ func (api WorkerAPI) ListAttachedGroupPolicies(requestInfo RequestInfo, filter *
 		policies = make([]GroupPolicies, len(attachedPolicies), cap(attachedPolicies))
 		for i, m := range attachedPolicies {
 			policies[i] = GroupPolicies{
-				Policy:   m.GetPolicy().Name,
-				CreateAt: m.GetDate(),
+				Policy:    m.GetPolicy().Name,
+				CreateAt:  m.GetDate(),
+				ExpiresAt: m.GetExpiresAt(),
 			}
 		}
 	}
@@ -710,6 +835,247 @@ func (api WorkerAPI) ListAttachedGroupPolicies(requestInfo RequestInfo, filter *
 	return policies, total, nil
 }
 
+func (api WorkerAPI) AddChildGroup(requestInfo RequestInfo, org string, name string, childName string) error {
+	return api.withTx(func(txAPI WorkerAPI) error {
+		return txAPI.addChildGroup(requestInfo, org, name, childName)
+	})
+}
+
+func (api WorkerAPI) addChildGroup(requestInfo RequestInfo, org string, name string, childName string) error {
+	// Call repo to retrieve the group
+	groupDB, err := api.GetGroupByName(requestInfo, org, name)
+	if err != nil {
+		return err
+	}
+
+	// Check restrictions
+	groupsFiltered, err := api.GetAuthorizedGroups(requestInfo, groupDB.Urn, GROUP_ACTION_ADD_CHILD_GROUP, []Group{*groupDB})
+	if err != nil {
+		return err
+	}
+	if len(groupsFiltered) < 1 {
+		return &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, groupDB.Urn),
+		}
+	}
+
+	// Call repo to retrieve the child group
+	childDB, err := api.GetGroupByName(requestInfo, org, childName)
+	if err != nil {
+		return err
+	}
+
+	// Call repo to check if group is already a child of the group
+	isChild, err := api.GroupRepo.IsChildOfGroup(childDB.ID, groupDB.ID)
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	// Error handling
+	if isChild {
+		return &Error{
+			Code:    GROUP_IS_ALREADY_A_CHILD_OF_GROUP,
+			Message: fmt.Sprintf("Group: %v is already a child of Group: %v", childName, name),
+		}
+	}
+
+	// Check that the child group isn't the group itself or one of its ancestors
+	ancestors, err := api.GroupRepo.GetAncestorGroups([]string{groupDB.ID})
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+	ancestors = append(ancestors, *groupDB)
+	for _, ancestor := range ancestors {
+		if ancestor.ID == childDB.ID {
+			return &Error{
+				Code: GROUP_HIERARCHY_CYCLE,
+				Message: fmt.Sprintf("Group with org %v and name %v can't be a child of group with org %v and name %v, it would create a cycle",
+					childDB.Org, childDB.Name, groupDB.Org, groupDB.Name),
+			}
+		}
+	}
+
+	// Add child group
+	err = api.GroupRepo.AddChildGroup(childDB.ID, groupDB.ID)
+
+	// Check if there is an unexpected error in DB
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	if err := api.audit(requestInfo, GROUP_ACTION_ADD_CHILD_GROUP, groupDB.Urn, nil, auditRelation{Urn: childDB.Urn}); err != nil {
+		return err
+	}
+	api.AuthzCache.Invalidate()
+	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Child group %+v added to group %+v", childDB, groupDB))
+	return nil
+}
+
+func (api WorkerAPI) RemoveChildGroup(requestInfo RequestInfo, org string, name string, childName string) error {
+	return api.withTx(func(txAPI WorkerAPI) error {
+		return txAPI.removeChildGroup(requestInfo, org, name, childName)
+	})
+}
+
+func (api WorkerAPI) removeChildGroup(requestInfo RequestInfo, org string, name string, childName string) error {
+	// Call repo to retrieve the group
+	groupDB, err := api.GetGroupByName(requestInfo, org, name)
+	if err != nil {
+		return err
+	}
+
+	// Check restrictions
+	groupsFiltered, err := api.GetAuthorizedGroups(requestInfo, groupDB.Urn, GROUP_ACTION_REMOVE_CHILD_GROUP, []Group{*groupDB})
+	if err != nil {
+		return err
+	}
+	if len(groupsFiltered) < 1 {
+		return &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, groupDB.Urn),
+		}
+	}
+
+	// Call repo to retrieve the child group
+	childDB, err := api.GetGroupByName(requestInfo, org, childName)
+	if err != nil {
+		return err
+	}
+
+	// Call repo to check if group is a child of the group
+	isChild, err := api.GroupRepo.IsChildOfGroup(childDB.ID, groupDB.ID)
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	if !isChild {
+		return &Error{
+			Code: GROUP_IS_NOT_A_CHILD_OF_GROUP,
+			Message: fmt.Sprintf("Group with org %v and name %v is not a child of group with org %v and name %v",
+				childDB.Org, childDB.Name, groupDB.Org, groupDB.Name),
+		}
+	}
+
+	// Remove child group
+	err = api.GroupRepo.RemoveChildGroup(childDB.ID, groupDB.ID)
+
+	// Check if there is an unexpected error in DB
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	if err := api.audit(requestInfo, GROUP_ACTION_REMOVE_CHILD_GROUP, groupDB.Urn, auditRelation{Urn: childDB.Urn}, nil); err != nil {
+		return err
+	}
+	api.AuthzCache.Invalidate()
+	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Child group %+v removed from group %+v", childDB, groupDB))
+	return nil
+}
+
+func (api WorkerAPI) ListChildGroups(requestInfo RequestInfo, filter *Filter) ([]GroupChildren, int, error) {
+	// Validate fields
+	var total int
+	orderByValidColumns := api.GroupRepo.OrderByValidColumns(GROUP_ACTION_LIST_CHILD_GROUPS)
+	err := validateFilter(filter, orderByValidColumns)
+	if err != nil {
+		return nil, total, err
+	}
+
+	// Call repo to retrieve the group
+	group, err := api.GetGroupByName(requestInfo, filter.Org, filter.GroupName)
+	if err != nil {
+		return nil, total, err
+	}
+
+	// Check restrictions
+	groupsFiltered, err := api.GetAuthorizedGroups(requestInfo, group.Urn, GROUP_ACTION_LIST_CHILD_GROUPS, []Group{*group})
+	if err != nil {
+		return nil, total, err
+	}
+	if len(groupsFiltered) < 1 {
+		return nil, total, &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, group.Urn),
+		}
+	}
+
+	// Get child groups
+	childGroups, total, err := api.GroupRepo.GetChildGroups(group.ID, filter)
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, total, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	children := []GroupChildren{}
+	if childGroups != nil {
+		children = make([]GroupChildren, len(childGroups), cap(childGroups))
+		for i, c := range childGroups {
+			children[i] = GroupChildren{
+				Group:    c.GetChild().Name,
+				CreateAt: c.GetDate(),
+			}
+		}
+	}
+
+	return children, total, nil
+}
+
+func (api WorkerAPI) RemoveExpiredRelations() error {
+	// Call repo to remove relations expired until now
+	removed, err := api.GroupRepo.RemoveExpiredRelations(time.Now().UTC())
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	if removed > 0 {
+		api.AuthzCache.Invalidate()
+		Log.Infof("Removed %v expired group relations", removed)
+	}
+	return nil
+}
+
 // PRIVATE HELPER METHODS
 
 func createGroup(org string, name string, path string) Group {
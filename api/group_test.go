@@ -2,6 +2,7 @@ package api
 
 import (
 	"testing"
+	"time"
 
 	"github.com/Tecsisa/foulkon/database"
 	"github.com/stretchr/testify/assert"
@@ -1608,6 +1609,7 @@ func TestAuthAPI_RemoveGroup(t *testing.T) {
 		getGroupByNameMethodErr      error
 		removeGroupMethodErr         error
 		getGroupsByUserIDError       error
+		withTxMethodErr              error
 	}{
 		"OKCaseAdminUser": {
 			requestInfo: RequestInfo{
@@ -1932,6 +1934,26 @@ func TestAuthAPI_RemoveGroup(t *testing.T) {
 				Code: database.INTERNAL_ERROR,
 			},
 		},
+		"ErrorCaseCommitTxErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "group1",
+			org:  "org1",
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+			getGroupByNameMethodResult: &Group{
+				ID:   "543210",
+				Name: "group1",
+				Org:  "org1",
+				Path: "/example/",
+			},
+			withTxMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+		},
 	}
 
 	for x, testcase := range testcases {
@@ -1946,19 +1968,28 @@ func TestAuthAPI_RemoveGroup(t *testing.T) {
 		testRepo.ArgsOut[GetGroupsByUserIDMethod][1] = testcase.getGroupsByUserIDError
 		testRepo.ArgsOut[GetAttachedPoliciesMethod][0] = testcase.getAttachedPoliciesResult
 		testRepo.ArgsOut[RemoveGroupMethod][0] = testcase.removeGroupMethodErr
+		testRepo.ArgsOut[WithTxMethod][0] = testcase.withTxMethodErr
 
 		err := testAPI.RemoveGroup(testcase.requestInfo, testcase.org, testcase.name)
 		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
+
+		// Transaction must be rolled back when the operation fails before committing
+		if testcase.wantError != nil && testcase.withTxMethodErr == nil {
+			assert.NotNil(t, testRepo.ArgsIn[WithTxMethod][0], "Error in test case %v", x)
+		}
 	}
 }
 
 func TestAuthAPI_AddMember(t *testing.T) {
+	future := time.Now().UTC().Add(time.Hour)
+	past := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
 	testcases := map[string]struct {
 		// API Method args
 		requestInfo RequestInfo
 		userID      string
 		org         string
 		groupName   string
+		expiresAt   *time.Time
 		// Expected result
 		wantError error
 		// Manager Results
@@ -1994,6 +2025,42 @@ func TestAuthAPI_AddMember(t *testing.T) {
 			},
 			isMemberOfGroupResult: false,
 		},
+		"OkCaseWithExpiration": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			userID:    "12345",
+			org:       "org1",
+			groupName: "group1",
+			expiresAt: &future,
+			getUserByExternalIDResult: &User{
+				ID:         "543210",
+				ExternalID: "12345",
+				Path:       "/test/asd/",
+			},
+			getGroupByNameResult: &Group{
+				ID:   "543210",
+				Name: "group1",
+				Org:  "org1",
+				Path: "/test/asd/",
+			},
+			isMemberOfGroupResult: false,
+		},
+		"ErrorCaseInvalidExpiration": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			userID:    "12345",
+			org:       "org1",
+			groupName: "group1",
+			expiresAt: &past,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: expiresAt 2015-01-01T00:00:00Z",
+			},
+		},
 		"OKCase": {
 			requestInfo: RequestInfo{
 				Identifier: "123456",
@@ -2414,8 +2481,11 @@ func TestAuthAPI_AddMember(t *testing.T) {
 		testRepo.ArgsOut[IsMemberOfGroupMethod][0] = testcase.isMemberOfGroupResult
 		testRepo.ArgsOut[IsMemberOfGroupMethod][1] = testcase.isMemberOfGroupMethodErr
 
-		err := testAPI.AddMember(testcase.requestInfo, testcase.userID, testcase.groupName, testcase.org)
+		err := testAPI.AddMember(testcase.requestInfo, testcase.userID, testcase.groupName, testcase.org, testcase.expiresAt)
 		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
+		if testcase.wantError == nil {
+			assert.Equal(t, testcase.expiresAt, testRepo.ArgsIn[AddMemberMethod][2], "Error in test case %v", x)
+		}
 	}
 }
 
@@ -2967,6 +3037,7 @@ func TestAuthAPI_RemoveMember(t *testing.T) {
 }
 
 func TestAuthAPI_ListMembers(t *testing.T) {
+	expiresAt := time.Date(2050, 1, 1, 0, 0, 0, 0, time.UTC)
 	testcases := map[string]struct {
 		// API Method args
 		requestInfo RequestInfo
@@ -3001,7 +3072,8 @@ func TestAuthAPI_ListMembers(t *testing.T) {
 					User: "member1",
 				},
 				{
-					User: "member2",
+					User:      "member2",
+					ExpiresAt: &expiresAt,
 				},
 			},
 			totalResult: 2,
@@ -3025,6 +3097,7 @@ func TestAuthAPI_ListMembers(t *testing.T) {
 						ExternalID: "member2",
 						Path:       "/test/",
 					},
+					ExpiresAt: &expiresAt,
 				},
 			},
 		},
@@ -3401,11 +3474,14 @@ func TestAuthAPI_ListMembers(t *testing.T) {
 }
 
 func TestAuthAPI_AttachPolicyToGroup(t *testing.T) {
+	future := time.Now().UTC().Add(time.Hour)
+	past := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
 	testcases := map[string]struct {
 		requestInfo RequestInfo
 		org         string
 		groupName   string
 		policyName  string
+		expiresAt   *time.Time
 		// Expected result
 		wantError error
 		// Manager Results
@@ -3457,6 +3533,45 @@ func TestAuthAPI_AttachPolicyToGroup(t *testing.T) {
 			},
 			isAttachedToGroupResult: false,
 		},
+		"OkCaseWithExpiration": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:        "123",
+			groupName:  "group1",
+			policyName: "policy1",
+			expiresAt:  &future,
+			getGroupByNameResult: &Group{
+				ID:   "12345",
+				Name: "group1",
+				Org:  "123",
+				Path: "/path/",
+				Urn:  CreateUrn("123", RESOURCE_GROUP, "/path/", "test"),
+			},
+			getPolicyByNameResult: &Policy{
+				ID:   "test1",
+				Name: "test",
+				Org:  "123",
+				Path: "/path/",
+				Urn:  CreateUrn("123", RESOURCE_POLICY, "/path/", "test"),
+			},
+			isAttachedToGroupResult: false,
+		},
+		"ErrorCaseInvalidExpiration": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:        "123",
+			groupName:  "group1",
+			policyName: "policy1",
+			expiresAt:  &past,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: expiresAt 2015-01-01T00:00:00Z",
+			},
+		},
 		"OKCase": {
 			requestInfo: RequestInfo{
 				Identifier: "123456",
@@ -3951,8 +4066,11 @@ func TestAuthAPI_AttachPolicyToGroup(t *testing.T) {
 		testRepo.ArgsOut[IsAttachedToGroupMethod][1] = testcase.isAttachedToGroupMethodErr
 		testRepo.ArgsOut[AttachPolicyMethod][0] = testcase.attachPolicyMethodErr
 
-		err := testAPI.AttachPolicyToGroup(testcase.requestInfo, testcase.org, testcase.groupName, testcase.policyName)
+		err := testAPI.AttachPolicyToGroup(testcase.requestInfo, testcase.org, testcase.groupName, testcase.policyName, testcase.expiresAt)
 		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
+		if testcase.wantError == nil {
+			assert.Equal(t, testcase.expiresAt, testRepo.ArgsIn[AttachPolicyMethod][2], "Error in test case %v", x)
+		}
 	}
 }
 
@@ -4505,6 +4623,7 @@ func TestAuthAPI_DetachPolicyToGroup(t *testing.T) {
 }
 
 func TestAuthAPI_ListAttachedGroupPolicies(t *testing.T) {
+	expiresAt := time.Date(2050, 1, 1, 0, 0, 0, 0, time.UTC)
 	testcases := map[string]struct {
 		//API method args
 		requestInfo RequestInfo
@@ -4547,6 +4666,39 @@ func TestAuthAPI_ListAttachedGroupPolicies(t *testing.T) {
 			},
 			expectedPolicies: []GroupPolicies{},
 		},
+		"OKCaseWithExpiration": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				Org:       "org1",
+				GroupName: "group1",
+			},
+			getGroupByNameMethodResult: &Group{
+				ID:   "543210",
+				Name: "group1",
+				Org:  "org1",
+				Path: "/example/",
+			},
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID:   "POLICY-ID",
+						Name: "policy1",
+						Org:  "org1",
+						Path: "/example/",
+					},
+					ExpiresAt: &expiresAt,
+				},
+			},
+			expectedPolicies: []GroupPolicies{
+				{
+					Policy:    "policy1",
+					ExpiresAt: &expiresAt,
+				},
+			},
+		},
 		"OKCase": {
 			requestInfo: RequestInfo{
 				Identifier: "123456",
@@ -4917,3 +5069,688 @@ func TestAuthAPI_ListAttachedGroupPolicies(t *testing.T) {
 		assert.Equal(t, testcase.totalResult, total, "Error in test case %v", x)
 	}
 }
+
+func TestAuthAPI_AddChildGroup(t *testing.T) {
+	groups := map[string]*Group{
+		"group1": {
+			ID:   "GROUP1-ID",
+			Name: "group1",
+			Org:  "org1",
+			Path: "/path/",
+			Urn:  CreateUrn("org1", RESOURCE_GROUP, "/path/", "group1"),
+		},
+		"group2": {
+			ID:   "GROUP2-ID",
+			Name: "group2",
+			Org:  "org1",
+			Path: "/path/",
+			Urn:  CreateUrn("org1", RESOURCE_GROUP, "/path/", "group2"),
+		},
+	}
+	testcases := map[string]struct {
+		// API Method args
+		requestInfo    RequestInfo
+		org            string
+		groupName      string
+		childGroupName string
+		// Expected result
+		wantError error
+		// Manager Results
+		getGroupsByUserIDResult   []TestUserGroupRelation
+		getAttachedPoliciesResult []TestPolicyGroupRelation
+		getUserByExternalIDResult *User
+		isChildOfGroupResult      bool
+		getAncestorGroupsResult   []Group
+		// Manager Errors
+		isChildOfGroupMethodErr    error
+		getAncestorGroupsMethodErr error
+		addChildGroupMethodErr     error
+	}{
+		"OkCaseAdmin": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:            "org1",
+			groupName:      "group1",
+			childGroupName: "group2",
+		},
+		"OkCase": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			org:            "org1",
+			groupName:      "group1",
+			childGroupName: "group2",
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID:   "GROUP-USER-ID",
+						Name: "groupUser",
+						Org:  "org1",
+						Path: "/path/",
+						Urn:  CreateUrn("org1", RESOURCE_GROUP, "/path/", "groupUser"),
+					},
+				},
+			},
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID:   "POLICY-USER-ID",
+						Name: "policyUser",
+						Org:  "org1",
+						Path: "/path/",
+						Urn:  CreateUrn("org1", RESOURCE_POLICY, "/path/", "policyUser"),
+						Statements: &[]Statement{
+							{
+								Effect: "allow",
+								Actions: []string{
+									GROUP_ACTION_GET_GROUP,
+									GROUP_ACTION_ADD_CHILD_GROUP,
+								},
+								Resources: []string{
+									GetUrnPrefix("org1", RESOURCE_GROUP, ""),
+								},
+							},
+						},
+					},
+				},
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "543210",
+				ExternalID: "123456",
+				Path:       "/path/",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "123456"),
+			},
+		},
+		"ErrorCaseGroupNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:            "org1",
+			groupName:      "group3",
+			childGroupName: "group2",
+			wantError: &Error{
+				Code: GROUP_BY_ORG_AND_NAME_NOT_FOUND,
+			},
+		},
+		"ErrorCaseChildGroupNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:            "org1",
+			groupName:      "group1",
+			childGroupName: "group3",
+			wantError: &Error{
+				Code: GROUP_BY_ORG_AND_NAME_NOT_FOUND,
+			},
+		},
+		"ErrorCaseInvalidChildGroupName": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:            "org1",
+			groupName:      "group1",
+			childGroupName: "d*%$",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: name d*%$",
+			},
+		},
+		"ErrorCaseDenyAddChildGroup": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			org:            "org1",
+			groupName:      "group1",
+			childGroupName: "group2",
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId 123456 is not allowed to access to resource urn:iws:iam:org1:group/path/group1",
+			},
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID:   "GROUP-USER-ID",
+						Name: "groupUser",
+						Org:  "org1",
+						Path: "/path/",
+						Urn:  CreateUrn("org1", RESOURCE_GROUP, "/path/", "groupUser"),
+					},
+				},
+			},
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID:   "POLICY-USER-ID",
+						Name: "policyUser",
+						Org:  "org1",
+						Path: "/path/",
+						Urn:  CreateUrn("org1", RESOURCE_POLICY, "/path/", "policyUser"),
+						Statements: &[]Statement{
+							{
+								Effect: "deny",
+								Actions: []string{
+									GROUP_ACTION_ADD_CHILD_GROUP,
+								},
+								Resources: []string{
+									GetUrnPrefix("org1", RESOURCE_GROUP, "/path/"),
+								},
+							},
+							{
+								Effect: "allow",
+								Actions: []string{
+									"iam:*",
+								},
+								Resources: []string{
+									GetUrnPrefix("org1", RESOURCE_GROUP, ""),
+								},
+							},
+						},
+					},
+				},
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "543210",
+				ExternalID: "123456",
+				Path:       "/path/",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "123456"),
+			},
+		},
+		"ErrorCaseIsAlreadyChild": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:            "org1",
+			groupName:      "group1",
+			childGroupName: "group2",
+			wantError: &Error{
+				Code:    GROUP_IS_ALREADY_A_CHILD_OF_GROUP,
+				Message: "Group: group2 is already a child of Group: group1",
+			},
+			isChildOfGroupResult: true,
+		},
+		"ErrorCaseSameGroup": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:            "org1",
+			groupName:      "group1",
+			childGroupName: "group1",
+			wantError: &Error{
+				Code:    GROUP_HIERARCHY_CYCLE,
+				Message: "Group with org org1 and name group1 can't be a child of group with org org1 and name group1, it would create a cycle",
+			},
+		},
+		"ErrorCaseCycle": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:            "org1",
+			groupName:      "group1",
+			childGroupName: "group2",
+			wantError: &Error{
+				Code:    GROUP_HIERARCHY_CYCLE,
+				Message: "Group with org org1 and name group2 can't be a child of group with org org1 and name group1, it would create a cycle",
+			},
+			getAncestorGroupsResult: []Group{
+				{
+					ID:   "GROUP3-ID",
+					Name: "group3",
+					Org:  "org1",
+				},
+				*groups["group2"],
+			},
+		},
+		"ErrorCaseIsChildDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:            "org1",
+			groupName:      "group1",
+			childGroupName: "group2",
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+			isChildOfGroupMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+		},
+		"ErrorCaseGetAncestorGroupsDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:            "org1",
+			groupName:      "group1",
+			childGroupName: "group2",
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+			getAncestorGroupsMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+		},
+		"ErrorCaseAddChildGroupDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:            "org1",
+			groupName:      "group1",
+			childGroupName: "group2",
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+			addChildGroupMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.SpecialFuncs[GetGroupByNameMethod] = func(org string, name string) (*Group, error) {
+			if group, ok := groups[name]; ok {
+				return group, nil
+			}
+			return nil, &database.Error{
+				Code: database.GROUP_NOT_FOUND,
+			}
+		}
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = testcase.getUserByExternalIDResult
+		testRepo.ArgsOut[GetGroupsByUserIDMethod][0] = testcase.getGroupsByUserIDResult
+		testRepo.ArgsOut[GetAttachedPoliciesMethod][0] = testcase.getAttachedPoliciesResult
+		testRepo.ArgsOut[IsChildOfGroupMethod][0] = testcase.isChildOfGroupResult
+		testRepo.ArgsOut[IsChildOfGroupMethod][1] = testcase.isChildOfGroupMethodErr
+		testRepo.ArgsOut[GetAncestorGroupsMethod][0] = testcase.getAncestorGroupsResult
+		testRepo.ArgsOut[GetAncestorGroupsMethod][1] = testcase.getAncestorGroupsMethodErr
+		testRepo.ArgsOut[AddChildGroupMethod][0] = testcase.addChildGroupMethodErr
+
+		err := testAPI.AddChildGroup(testcase.requestInfo, testcase.org, testcase.groupName, testcase.childGroupName)
+		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
+		if testcase.wantError == nil {
+			assert.Equal(t, groups[testcase.childGroupName].ID, testRepo.ArgsIn[AddChildGroupMethod][0], "Error in test case %v", x)
+			assert.Equal(t, groups[testcase.groupName].ID, testRepo.ArgsIn[AddChildGroupMethod][1], "Error in test case %v", x)
+		}
+	}
+}
+
+func TestAuthAPI_RemoveChildGroup(t *testing.T) {
+	groups := map[string]*Group{
+		"group1": {
+			ID:   "GROUP1-ID",
+			Name: "group1",
+			Org:  "org1",
+			Path: "/path/",
+			Urn:  CreateUrn("org1", RESOURCE_GROUP, "/path/", "group1"),
+		},
+		"group2": {
+			ID:   "GROUP2-ID",
+			Name: "group2",
+			Org:  "org1",
+			Path: "/path/",
+			Urn:  CreateUrn("org1", RESOURCE_GROUP, "/path/", "group2"),
+		},
+	}
+	testcases := map[string]struct {
+		// API Method args
+		requestInfo    RequestInfo
+		org            string
+		groupName      string
+		childGroupName string
+		// Expected result
+		wantError error
+		// Manager Results
+		getGroupsByUserIDResult   []TestUserGroupRelation
+		getAttachedPoliciesResult []TestPolicyGroupRelation
+		getUserByExternalIDResult *User
+		isChildOfGroupResult      bool
+		// Manager Errors
+		isChildOfGroupMethodErr   error
+		removeChildGroupMethodErr error
+	}{
+		"OkCaseAdmin": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:                  "org1",
+			groupName:            "group1",
+			childGroupName:       "group2",
+			isChildOfGroupResult: true,
+		},
+		"ErrorCaseGroupNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:            "org1",
+			groupName:      "group3",
+			childGroupName: "group2",
+			wantError: &Error{
+				Code: GROUP_BY_ORG_AND_NAME_NOT_FOUND,
+			},
+		},
+		"ErrorCaseChildGroupNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:            "org1",
+			groupName:      "group1",
+			childGroupName: "group3",
+			wantError: &Error{
+				Code: GROUP_BY_ORG_AND_NAME_NOT_FOUND,
+			},
+		},
+		"ErrorCaseNoPermissions": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			org:            "org1",
+			groupName:      "group1",
+			childGroupName: "group2",
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId 123456 is not allowed to access to resource urn:iws:iam:org1:group/path/group1",
+			},
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID:   "GROUP-USER-ID",
+						Name: "groupUser",
+						Org:  "org1",
+						Path: "/path/",
+						Urn:  CreateUrn("org1", RESOURCE_GROUP, "/path/", "groupUser"),
+					},
+				},
+			},
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID:   "POLICY-USER-ID",
+						Name: "policyUser",
+						Org:  "org1",
+						Path: "/path/",
+						Urn:  CreateUrn("org1", RESOURCE_POLICY, "/path/", "policyUser"),
+						Statements: &[]Statement{
+							{
+								Effect: "allow",
+								Actions: []string{
+									GROUP_ACTION_GET_GROUP,
+								},
+								Resources: []string{
+									GetUrnPrefix("org1", RESOURCE_GROUP, ""),
+								},
+							},
+						},
+					},
+				},
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "543210",
+				ExternalID: "123456",
+				Path:       "/path/",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "123456"),
+			},
+		},
+		"ErrorCaseIsNotChild": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:            "org1",
+			groupName:      "group1",
+			childGroupName: "group2",
+			wantError: &Error{
+				Code:    GROUP_IS_NOT_A_CHILD_OF_GROUP,
+				Message: "Group with org org1 and name group2 is not a child of group with org org1 and name group1",
+			},
+			isChildOfGroupResult: false,
+		},
+		"ErrorCaseIsChildDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:            "org1",
+			groupName:      "group1",
+			childGroupName: "group2",
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+			isChildOfGroupMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+		},
+		"ErrorCaseRemoveChildGroupDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:                  "org1",
+			groupName:            "group1",
+			childGroupName:       "group2",
+			isChildOfGroupResult: true,
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+			removeChildGroupMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.SpecialFuncs[GetGroupByNameMethod] = func(org string, name string) (*Group, error) {
+			if group, ok := groups[name]; ok {
+				return group, nil
+			}
+			return nil, &database.Error{
+				Code: database.GROUP_NOT_FOUND,
+			}
+		}
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = testcase.getUserByExternalIDResult
+		testRepo.ArgsOut[GetGroupsByUserIDMethod][0] = testcase.getGroupsByUserIDResult
+		testRepo.ArgsOut[GetAttachedPoliciesMethod][0] = testcase.getAttachedPoliciesResult
+		testRepo.ArgsOut[IsChildOfGroupMethod][0] = testcase.isChildOfGroupResult
+		testRepo.ArgsOut[IsChildOfGroupMethod][1] = testcase.isChildOfGroupMethodErr
+		testRepo.ArgsOut[RemoveChildGroupMethod][0] = testcase.removeChildGroupMethodErr
+
+		err := testAPI.RemoveChildGroup(testcase.requestInfo, testcase.org, testcase.groupName, testcase.childGroupName)
+		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
+	}
+}
+
+func TestAuthAPI_ListChildGroups(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API Method args
+		requestInfo RequestInfo
+		filter      *Filter
+		// Expected result
+		expectedChildren []GroupChildren
+		totalResult      int
+		wantError        error
+		// Manager Results
+		getGroupByNameResult *Group
+		getChildGroupsResult []TestGroupGroupRelation
+		// API Errors
+		getGroupByNameMethodErr  error
+		getChildGroupsMethodErr  error
+		orderByValidColumnsValue []string
+	}{
+		"OkCaseAdmin": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				Org:       "org1",
+				GroupName: "group1",
+			},
+			expectedChildren: []GroupChildren{
+				{
+					Group:    "child1",
+					CreateAt: now,
+				},
+				{
+					Group:    "child2",
+					CreateAt: now,
+				},
+			},
+			totalResult: 2,
+			getGroupByNameResult: &Group{
+				ID:   "543210",
+				Name: "group1",
+				Org:  "org1",
+				Path: "/test/",
+			},
+			getChildGroupsResult: []TestGroupGroupRelation{
+				{
+					Child: &Group{
+						ID:   "CHILD1-ID",
+						Name: "child1",
+						Org:  "org1",
+					},
+					CreateAt: now,
+				},
+				{
+					Child: &Group{
+						ID:   "CHILD2-ID",
+						Name: "child2",
+						Org:  "org1",
+					},
+					CreateAt: now,
+				},
+			},
+		},
+		"ErrorCaseInvalidOrderBy": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				Org:       "org1",
+				GroupName: "group1",
+				OrderBy:   "name-desc",
+			},
+			orderByValidColumnsValue: []string{"create_at"},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: OrderBy column name",
+			},
+		},
+		"ErrorCaseGroupNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				Org:       "org1",
+				GroupName: "group1",
+			},
+			wantError: &Error{
+				Code: GROUP_BY_ORG_AND_NAME_NOT_FOUND,
+			},
+			getGroupByNameMethodErr: &database.Error{
+				Code: database.GROUP_NOT_FOUND,
+			},
+		},
+		"ErrorCaseGetChildGroupsDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				Org:       "org1",
+				GroupName: "group1",
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+			getGroupByNameResult: &Group{
+				ID:   "543210",
+				Name: "group1",
+				Org:  "org1",
+				Path: "/test/",
+			},
+			getChildGroupsMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[GetGroupByNameMethod][0] = testcase.getGroupByNameResult
+		testRepo.ArgsOut[GetGroupByNameMethod][1] = testcase.getGroupByNameMethodErr
+		testRepo.ArgsOut[GetChildGroupsMethod][0] = testcase.getChildGroupsResult
+		testRepo.ArgsOut[GetChildGroupsMethod][1] = testcase.totalResult
+		testRepo.ArgsOut[GetChildGroupsMethod][2] = testcase.getChildGroupsMethodErr
+		testRepo.ArgsOut[OrderByValidColumnsMethod][0] = testcase.orderByValidColumnsValue
+
+		children, total, err := testAPI.ListChildGroups(testcase.requestInfo, testcase.filter)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.expectedChildren, children)
+		assert.Equal(t, testcase.totalResult, total, "Error in test case %v", x)
+	}
+}
+
+func TestWorkerAPI_RemoveExpiredRelations(t *testing.T) {
+	testcases := map[string]struct {
+		// Expected result
+		wantError error
+		// Manager Results
+		removeExpiredRelationsResult int
+		// Manager Errors
+		removeExpiredRelationsErr error
+	}{
+		"OkCase": {
+			removeExpiredRelationsResult: 2,
+		},
+		"OkCaseNothingExpired": {},
+		"ErrorCaseInternalError": {
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+			removeExpiredRelationsErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[RemoveExpiredRelationsMethod][0] = testcase.removeExpiredRelationsResult
+		testRepo.ArgsOut[RemoveExpiredRelationsMethod][1] = testcase.removeExpiredRelationsErr
+
+		before := time.Now().UTC()
+		err := testAPI.RemoveExpiredRelations()
+		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
+		date, ok := testRepo.ArgsIn[RemoveExpiredRelationsMethod][0].(time.Time)
+		assert.True(t, ok, "Error in test case %v", x)
+		assert.False(t, date.Before(before), "Error in test case %v", x)
+	}
+}
@@ -17,6 +17,8 @@ type UserGroupRelation interface {
 	GetUser() *User
 	GetGroup() *Group
 	GetDate() time.Time
+	// Returns nil if the relation never expires
+	GetExpiresAt() *time.Time
 }
 
 // PolicyGroupRelation interface for Policy-Group relationships
@@ -24,6 +26,22 @@ type PolicyGroupRelation interface {
 	GetGroup() *Group
 	GetPolicy() *Policy
 	GetDate() time.Time
+	// Returns nil if the relation never expires
+	GetExpiresAt() *time.Time
+}
+
+// PolicyUserRelation interface for Policy-User relationships
+type PolicyUserRelation interface {
+	GetUser() *User
+	GetPolicy() *Policy
+	GetDate() time.Time
+}
+
+// GroupGroupRelation interface for Parent-Child Group relationships
+type GroupGroupRelation interface {
+	GetParent() *Group
+	GetChild() *Group
+	GetDate() time.Time
 }
 
 // WorkerAPI that implements API interfaces using repositories
@@ -33,6 +51,21 @@ type WorkerAPI struct {
 	PolicyRepo   PolicyRepo
 	ProxyRepo    ProxyRepo
 	AuthOidcRepo AuthOidcRepo
+	AuditRepo    AuditRepo
+	WebhookRepo  WebhookRepo
+
+	// Cache of effective policies per user, disabled if nil
+	AuthzCache *AuthzCache
+
+	// Log of authorization decisions, disabled if nil
+	DecisionLog *DecisionLog
+
+	// Dispatcher of change events to webhooks, disabled if nil
+	Webhooks *WebhookDispatcher
+
+	// Events of the mutations made in the current transaction, published once it is committed.
+	// It is nil outside transactions.
+	pendingEvents *[]WebhookEvent
 }
 
 // ProxyAPI that implements API interfaces using repositories
@@ -48,7 +81,16 @@ type Filter struct {
 	PolicyName        string
 	GroupName         string
 	ProxyResourceName string
+	UpstreamPoolName  string
 	AuthProviderName  string
+	WebhookName       string
+	// Audit entries
+	Actor       string
+	ResourceUrn string
+	Action      string
+	// Time range, unbounded if nil. From is inclusive and To exclusive
+	From *time.Time
+	To   *time.Time
 	// Pagination
 	Offset int
 	Limit  int
@@ -76,13 +118,25 @@ type UserAPI interface {
 	// are invalid, user doesn't exist or unexpected error happen.
 	UpdateUser(requestInfo RequestInfo, externalId string, newPath string) (*User, error)
 
-	// Remove user stored in database with its group relationships.
+	// Remove user stored in database with its group and policy relationships.
 	// Throw error if externalId parameter is invalid, user doesn't exist or unexpected error happen.
 	RemoveUser(requestInfo RequestInfo, externalId string) error
 
 	// Retrieve groups that belongs to the user. Throw error if externalId parameter is invalid, user
 	// doesn't exist or unexpected error happen.
 	ListGroupsByUser(requestInfo RequestInfo, filter *Filter) ([]UserGroups, int, error)
+
+	// Attach policy to user. Throw error if the input parameters are invalid, user doesn't exist,
+	// policy doesn't exist, policy is already attached to the user or unexpected error happen.
+	AttachPolicyToUser(requestInfo RequestInfo, externalId string, org string, policyName string) error
+
+	// Detach policy from user. Throw error if the input parameters are invalid, user doesn't exist,
+	// policy doesn't exist, policy isn't attached to the user or unexpected error happen.
+	DetachPolicyFromUser(requestInfo RequestInfo, externalId string, org string, policyName string) error
+
+	// Retrieve policies that are attached directly to the user. Throw error if the input parameters are invalid,
+	// user doesn't exist or unexpected error happen.
+	ListAttachedUserPolicies(requestInfo RequestInfo, filter *Filter) ([]UserPolicies, int, error)
 }
 
 // GroupAPI interface
@@ -108,9 +162,10 @@ type GroupAPI interface {
 	// Throw error if the input parameters are invalid, the group doesn't exist or unexpected error happen.
 	RemoveGroup(requestInfo RequestInfo, org string, name string) error
 
-	// Add new member to group. Throw error if the input parameters are invalid, user doesn't exist,
+	// Add new member to group. The membership expires at expiresAt, or never if it is nil.
+	// Throw error if the input parameters are invalid, user doesn't exist,
 	// group doesn't exist, user is already a member of the group or unexpected error happen.
-	AddMember(requestInfo RequestInfo, externalId string, groupName string, org string) error
+	AddMember(requestInfo RequestInfo, externalId string, groupName string, org string, expiresAt *time.Time) error
 
 	// Remove member from group. Throw error if the input parameters are invalid, user doesn't exist,
 	// group doesn't exist, user isn't a member of the group or unexpected error happen.
@@ -120,9 +175,10 @@ type GroupAPI interface {
 	// group doesn't exist or unexpected error happen.
 	ListMembers(requestInfo RequestInfo, filter *Filter) ([]GroupMembers, int, error)
 
-	// Attach policy to group. Throw error if the input parameters are invalid, policy doesn't exist,
+	// Attach policy to group. The attachment expires at expiresAt, or never if it is nil.
+	// Throw error if the input parameters are invalid, policy doesn't exist,
 	// group doesn't exist, policy is already attached to the group or unexpected error happen.
-	AttachPolicyToGroup(requestInfo RequestInfo, org string, groupName string, policyName string) error
+	AttachPolicyToGroup(requestInfo RequestInfo, org string, groupName string, policyName string, expiresAt *time.Time) error
 
 	// Detach policy from group. Throw error if the input parameters are invalid, policy doesn't exist,
 	// group doesn't exist, policy isn't attached to the group or unexpected error happen.
@@ -131,6 +187,19 @@ type GroupAPI interface {
 	// Retrieve policies that are attached to the group. Throw error if the input parameters are invalid,
 	// group doesn't exist or unexpected error happen.
 	ListAttachedGroupPolicies(requestInfo RequestInfo, filter *Filter) ([]GroupPolicies, int, error)
+
+	// Add child group to group, so policies attached to the group apply to child group members. Throw error if
+	// the input parameters are invalid, any group doesn't exist, child group is already a child of the group,
+	// the relation makes a cycle or unexpected error happen.
+	AddChildGroup(requestInfo RequestInfo, org string, groupName string, childGroupName string) error
+
+	// Remove child group from group. Throw error if the input parameters are invalid, any group doesn't exist,
+	// child group isn't a child of the group or unexpected error happen.
+	RemoveChildGroup(requestInfo RequestInfo, org string, groupName string, childGroupName string) error
+
+	// List group names that are direct children of the group. Throw error if the input parameters are invalid,
+	// group doesn't exist or unexpected error happen.
+	ListChildGroups(requestInfo RequestInfo, filter *Filter) ([]GroupChildren, int, error)
 }
 
 // PolicyAPI interface
@@ -153,7 +222,7 @@ type PolicyAPI interface {
 	UpdatePolicy(requestInfo RequestInfo, org string, name string, newName string, newPath string,
 		newStatements []Statement) (*Policy, error)
 
-	// Remove policy stored in database with its groups relationships.
+	// Remove policy stored in database with its groups and users relationships.
 	// Throw error if the input parameters are invalid, the policy doesn't exist or unexpected error happen.
 	RemovePolicy(requestInfo RequestInfo, org string, name string) error
 
@@ -183,12 +252,41 @@ type AuthzAPI interface {
 	// Retrieve list of authorized external resources filtered according to the input parameters. Throw error
 	// if requestInfo doesn't exist, requestInfo doesn't have access to any resources or unexpected error happen.
 	GetAuthorizedExternalResources(requestInfo RequestInfo, action string, resources []string) ([]string, error)
+
+	// Retrieve the authorized external resources for every action and resources check, loading user policies once.
+	// Checks without authorized resources return an empty list. Throw error if the input parameters are invalid,
+	// requestInfo doesn't exist or unexpected error happen.
+	GetAuthorizedExternalResourcesBatch(requestInfo RequestInfo, checks []AuthorizationCheck) ([]AuthorizationCheckResult, error)
+
+	// Retrieve the authorization decision for a user, action and full resource, with the groups, policies and
+	// statements that take part in it and the deny statements that override allow ones. Throw error if the input
+	// parameters are invalid, user doesn't exist, requestInfo doesn't have access to the user or unexpected error happen.
+	ExplainAuthorization(requestInfo RequestInfo, externalID string, action string, resource string) (*AuthorizationExplanation, error)
+
+	// Evaluate action and full resource pairs for a user before and after applying draft policies, without storing them.
+	// Throw error if the input parameters are invalid, user doesn't exist, requestInfo doesn't have access to the user
+	// or unexpected error happen.
+	SimulatePolicies(requestInfo RequestInfo, externalID string, drafts []DraftPolicy, checks []SimulationCheck) ([]SimulationResult, error)
+}
+
+// InternalGroupAPI interface to manage expiration of group relations
+type InternalGroupAPI interface {
+	// Remove group memberships and group policy attachments whose expiration date has passed.
+	// Throw error if there are problems with database.
+	RemoveExpiredRelations() error
 }
 
 // InternalProxyAPI interface to manage proxy resources
 type InternalProxyAPI interface {
 	// Retrieve list of proxy resources.
 	GetProxyResources() ([]ProxyResource, error)
+
+	// Retrieve list of upstream pools.
+	GetUpstreamPools() ([]UpstreamPool, error)
+
+	// Retrieve proxy resources and upstream pools with their revision, or nil if the revision is still the given one.
+	// Revision 0 always retrieves them.
+	GetProxyConfig(revision int64) (*ProxyConfig, error)
 }
 
 // WorkerProxyResourcesAPI interface to manage proxy resources
@@ -213,6 +311,34 @@ type ProxyResourcesAPI interface {
 	// Remove proxy resource stored in database.
 	// Throw error if the input parameters are invalid, the proxy resource doesn't exist or unexpected error happen.
 	RemoveProxyResource(requestInfo RequestInfo, org string, name string) error
+
+	// Retrieve proxy resources and upstream pools with their revision, or nil if the revision is still the given one.
+	// Throw error if the user isn't an admin or unexpected error happen.
+	ExportProxyConfig(requestInfo RequestInfo, revision int64) (*ProxyConfig, error)
+}
+
+// UpstreamPoolAPI interface to manage upstream pools
+type UpstreamPoolAPI interface {
+	// Store upstream pool in database. Throw error when the input parameters are invalid,
+	// the upstream pool already exist or unexpected error happen.
+	AddUpstreamPool(requestInfo RequestInfo, name string, org string, path string, config UpstreamPoolConfig) (*UpstreamPool, error)
+
+	// Retrieve upstream pool from database. Throw error when the input parameters are invalid,
+	// upstream pool doesn't exist or unexpected error happen.
+	GetUpstreamPoolByName(requestInfo RequestInfo, org string, name string) (*UpstreamPool, error)
+
+	// Retrieve list of upstream pools.
+	ListUpstreamPools(requestInfo RequestInfo, filter *Filter) ([]UpstreamPoolIdentity, int, error)
+
+	// Update upstream pool stored in database with new name, new path and new config. Throw error if the input
+	// parameters are invalid, upstream pool to update doesn't exist, target upstream pool already exist,
+	// the upstream pool is renamed while proxy resources use it or unexpected error happen.
+	UpdateUpstreamPool(requestInfo RequestInfo, org string, name string, newName string, newPath string,
+		newConfig UpstreamPoolConfig) (*UpstreamPool, error)
+
+	// Remove upstream pool stored in database. Throw error if the input parameters are invalid,
+	// the upstream pool doesn't exist, proxy resources use it or unexpected error happen.
+	RemoveUpstreamPool(requestInfo RequestInfo, org string, name string) error
 }
 
 // AuthOidcAPI interface
@@ -239,10 +365,79 @@ type AuthOidcAPI interface {
 	RemoveOidcProvider(requestInfo RequestInfo, name string) error
 }
 
+// AuditAPI interface
+type AuditAPI interface {
+	// Retrieve audit entries from database filtered by actor, resource urn, action and time range. These filter
+	// parameters are optional. Throw error if the input parameters are invalid, requestInfo isn't an admin
+	// or unexpected error happen.
+	ListAuditEntries(requestInfo RequestInfo, filter *Filter) ([]AuditEntry, int, error)
+}
+
+// WebhookAPI interface
+type WebhookAPI interface {
+	// Store a new webhook in database. Throw error when parameters are invalid,
+	// the webhook already exists or unexpected error happen.
+	AddWebhook(requestInfo RequestInfo, name string, path string, url string, secret string, events []string) (*Webhook, error)
+
+	// Retrieve webhook from database. Throw error when parameter is invalid,
+	// the webhook doesn't exist or unexpected error happen.
+	GetWebhookByName(requestInfo RequestInfo, name string) (*Webhook, error)
+
+	// Retrieve webhook names from database filtered by pathPrefix (optional parameter). Throw error
+	// if pathPrefix is invalid or unexpected error happen.
+	ListWebhooks(requestInfo RequestInfo, filter *Filter) ([]string, int, error)
+
+	// Update webhook stored in database with new parameters. The secret is kept if newSecret is empty.
+	// Throw error if the input parameters are invalid, the webhook doesn't exist,
+	// target webhook already exist or unexpected error happen.
+	UpdateWebhook(requestInfo RequestInfo, name string, newName string, newPath string, newURL string,
+		newSecret string, newEvents []string) (*Webhook, error)
+
+	// Remove webhook stored in database with its dead letters.
+	// Throw error if name parameter is invalid, webhook doesn't exist or unexpected error happen.
+	RemoveWebhook(requestInfo RequestInfo, name string) error
+
+	// Retrieve the events that couldn't be delivered to the webhook. Throw error if the input parameters
+	// are invalid, webhook doesn't exist or unexpected error happen.
+	ListWebhookDeadLetters(requestInfo RequestInfo, filter *Filter) ([]WebhookDeadLetter, int, error)
+}
+
+// StateAPI interface
+type StateAPI interface {
+	// Retrieve the IAM state with all entities and relations, or only the ones of the org if it isn't empty.
+	// Throw error if org is invalid, requestInfo isn't an admin or unexpected error happen.
+	ExportState(requestInfo RequestInfo, org string) (*State, error)
+
+	// Apply the IAM state in a single transaction with create, upsert or replace mode, or only retrieve the
+	// changes to apply if dryRun is true. Throw error if the state or mode are invalid, requestInfo isn't an admin,
+	// any change fails or unexpected error happen. Then no change is applied.
+	ImportState(requestInfo RequestInfo, state State, mode string, dryRun bool) (*ImportResult, error)
+}
+
 // REPOSITORY INTERFACES
 
+// TxRepos holds the repositories bound to a transaction
+type TxRepos struct {
+	UserRepo     UserRepo
+	GroupRepo    GroupRepo
+	PolicyRepo   PolicyRepo
+	ProxyRepo    ProxyRepo
+	AuthOidcRepo AuthOidcRepo
+	AuditRepo    AuditRepo
+	WebhookRepo  WebhookRepo
+}
+
+// TxRepo runs several database operations atomically
+type TxRepo interface {
+	// Call fn with repositories bound to a new transaction. The transaction is committed if fn returns nil,
+	// and rolled back otherwise. It returns fn error as is, or an error if the transaction can't be committed.
+	WithTx(fn func(repos TxRepos) error) error
+}
+
 // UserRepo contains all database operations
 type UserRepo interface {
+	TxRepo
+
 	// Store user in database if there aren't errors.
 	AddUser(user User) (*User, error)
 
@@ -253,11 +448,12 @@ type UserRepo interface {
 	// if there are problems with database.
 	GetUsersFiltered(filter *Filter) ([]User, int, error)
 
-	// Update user stored in database with new fields. Throw error if the database restrictions
+	// Update user stored in database with new fields, only if its update date is still oldUpdateAt.
+	// Throw a version conflict error otherwise, or error if the database restrictions
 	// are not satisfied or unexpected error happen.
-	UpdateUser(user User) (*User, error)
+	UpdateUser(user User, oldUpdateAt time.Time) (*User, error)
 
-	// Remove user stored in database with its group relationships.
+	// Remove user stored in database with its group and policy relationships.
 	// Throw error if there are problems during transactions.
 	RemoveUser(id string) error
 
@@ -265,12 +461,29 @@ type UserRepo interface {
 	// if there are problems with database.
 	GetGroupsByUserID(id string, filter *Filter) ([]UserGroupRelation, int, error)
 
+	// Attach policy to user. It doesn't check restrictions about existence of user or policy. It throws
+	// errors if there are problems with database.
+	AttachUserPolicy(userID string, policyID string) error
+
+	// Detach policy from user. It doesn't check restrictions about existence of user or policy. It throws
+	// errors if there are problems with database.
+	DetachUserPolicy(userID string, policyID string) error
+
+	// Check if policy is attached to user. It returns true if at least one relation exists. It throws
+	// errors if there are problems with database.
+	IsAttachedToUser(userID string, policyID string) (bool, error)
+
+	// Retrieve policies that are attached directly to the user. Throw error if there are problems with database.
+	GetAttachedUserPolicies(userID string, filter *Filter) ([]PolicyUserRelation, int, error)
+
 	// OrderByValidColumns returns valid columns that you can use in OrderBy
 	OrderByValidColumns(action string) []string
 }
 
 // GroupRepo contains all database operations
 type GroupRepo interface {
+	TxRepo
+
 	// Store group in database if there aren't errors.
 	AddGroup(group Group) (*Group, error)
 
@@ -281,17 +494,17 @@ type GroupRepo interface {
 	// if there are problems with database.
 	GetGroupsFiltered(filter *Filter) ([]Group, int, error)
 
-	// Update group stored in database with new fields.
-	// Throw error if there are problems with database.
-	UpdateGroup(group Group) (*Group, error)
+	// Update group stored in database with new fields, only if its update date is still oldUpdateAt.
+	// Throw a version conflict error otherwise, or error if there are problems with database.
+	UpdateGroup(group Group, oldUpdateAt time.Time) (*Group, error)
 
 	// Remove group stored in database with its user and policy relationships.
 	// Throw error if there are problems during transactions.
 	RemoveGroup(groupID string) error
 
-	// Add new member to group. It doesn't check restrictions about existence of group or user. It throws
-	// errors if there are problems with database.
-	AddMember(userID string, groupID string) error
+	// Add new member to group until expiresAt, or without expiration if it is nil. It doesn't check
+	// restrictions about existence of group or user. It throws errors if there are problems with database.
+	AddMember(userID string, groupID string, expiresAt *time.Time) error
 
 	// Remove member from group. It doesn't check restrictions about existence of group or user. It throws
 	// errors if there are problems with database.
@@ -304,9 +517,9 @@ type GroupRepo interface {
 	// Retrieve users that belong to the group. Throw error if there are problems with database.
 	GetGroupMembers(groupID string, filter *Filter) ([]UserGroupRelation, int, error)
 
-	// Attach policy to group. It doesn't check restrictions about existence of group or policy. It throws
-	// errors if there are problems with database.
-	AttachPolicy(groupID string, policyID string) error
+	// Attach policy to group until expiresAt, or without expiration if it is nil. It doesn't check
+	// restrictions about existence of group or policy. It throws errors if there are problems with database.
+	AttachPolicy(groupID string, policyID string, expiresAt *time.Time) error
 
 	// Detach policy from group. It doesn't check restrictions about existence of group or policy. It throws
 	// errors if there are problems with database.
@@ -319,12 +532,37 @@ type GroupRepo interface {
 	// Retrieve policies that are attached to the group. Throw error if there are problems with database.
 	GetAttachedPolicies(groupID string, filter *Filter) ([]PolicyGroupRelation, int, error)
 
+	// Add child group to group. It doesn't check restrictions about existence of groups or cycles. It throws
+	// errors if there are problems with database.
+	AddChildGroup(childID string, parentID string) error
+
+	// Remove child group from group. It doesn't check restrictions about existence of groups. It throws
+	// errors if there are problems with database.
+	RemoveChildGroup(childID string, parentID string) error
+
+	// Check if group is a direct child of parent group. It returns true if at least one relation exists. It throws
+	// errors if there are problems with database.
+	IsChildOfGroup(childID string, parentID string) (bool, error)
+
+	// Retrieve direct children of the group. Throw error if there are problems with database.
+	GetChildGroups(parentID string, filter *Filter) ([]GroupGroupRelation, int, error)
+
+	// Retrieve all groups that are direct or transitive parents of the groups. Throw error
+	// if there are problems with database.
+	GetAncestorGroups(groupIDs []string) ([]Group, error)
+
+	// Remove group memberships and group policy attachments that expired before the given date.
+	// It returns the number of removed relations. Throw error if there are problems during transaction.
+	RemoveExpiredRelations(date time.Time) (int, error)
+
 	// OrderByValidColumns returns valid columns that you can use in OrderBy
 	OrderByValidColumns(action string) []string
 }
 
 // PolicyRepo contains all database operations
 type PolicyRepo interface {
+	TxRepo
+
 	// Store policy in database if there aren't errors.
 	AddPolicy(policy Policy) (*Policy, error)
 
@@ -335,11 +573,12 @@ type PolicyRepo interface {
 	// if there are problems with database.
 	GetPoliciesFiltered(filter *Filter) ([]Policy, int, error)
 
-	// Update policy stored in database with new fields. Also it overrides statements if it has.
-	// Throw error if there are problems with database.
-	UpdatePolicy(policy Policy) (*Policy, error)
+	// Update policy stored in database with new fields, only if its update date is still oldUpdateAt.
+	// Also it overrides statements if it has.
+	// Throw a version conflict error otherwise, or error if there are problems with database.
+	UpdatePolicy(policy Policy, oldUpdateAt time.Time) (*Policy, error)
 
-	// Remove policy stored in database with its groups relationships.
+	// Remove policy stored in database with its groups and users relationships.
 	// Throw error if there are problems during transactions.
 	RemovePolicy(id string) error
 
@@ -361,14 +600,39 @@ type ProxyRepo interface {
 	// Store proxy resource in database if there aren't errors.
 	AddProxyResource(proxyResource ProxyResource) (*ProxyResource, error)
 
-	// Update proxy resource stored in database with new fields. Also it overrides statements if it has.
-	// Throw error if there are problems with database.
-	UpdateProxyResource(proxyResource ProxyResource) (*ProxyResource, error)
+	// Update proxy resource stored in database with new fields, only if its update date is still oldUpdateAt.
+	// Throw a version conflict error otherwise, or error if there are problems with database.
+	UpdateProxyResource(proxyResource ProxyResource, oldUpdateAt time.Time) (*ProxyResource, error)
 
 	// Remove proxy resource stored in database.
 	// Throw error if there are problems during transaction.
 	RemoveProxyResource(proxyResourceID string) error
 
+	// Retrieve upstream pools from database. Otherwise it throws an error.
+	GetUpstreamPools(filter *Filter) ([]UpstreamPool, int, error)
+
+	// Retrieve upstream pool from database if it exists. Otherwise it throws an error.
+	GetUpstreamPoolByName(org string, name string) (*UpstreamPool, error)
+
+	// Store upstream pool in database if there aren't errors.
+	AddUpstreamPool(pool UpstreamPool) (*UpstreamPool, error)
+
+	// Update upstream pool stored in database with new fields, only if its update date is still oldUpdateAt.
+	// Throw a version conflict error otherwise, or error if there are problems with database.
+	UpdateUpstreamPool(pool UpstreamPool, oldUpdateAt time.Time) (*UpstreamPool, error)
+
+	// Remove upstream pool stored in database.
+	// Throw error if there are problems during transaction.
+	RemoveUpstreamPool(id string) error
+
+	// Retrieve revision of the proxy config, the proxy resources and upstream pools. It starts at 1.
+	// Throw error if there are problems with database.
+	GetProxyRevision() (int64, error)
+
+	// Increase revision of the proxy config, in the transaction of its changes.
+	// Throw error if there are problems with database.
+	IncrementProxyRevision() error
+
 	// OrderByValidColumns returns valid columns that you can use in OrderBy
 	OrderByValidColumns(action string) []string
 }
@@ -385,9 +649,9 @@ type AuthOidcRepo interface {
 	// if there are problems with database.
 	GetOidcProvidersFiltered(filter *Filter) ([]OidcProvider, int, error)
 
-	// Update the OIDC provider stored in database with new fields.
-	// Throw error if there are problems with database.
-	UpdateOidcProvider(oidcProvider OidcProvider) (*OidcProvider, error)
+	// Update the OIDC provider stored in database with new fields, only if its update date is still oldUpdateAt.
+	// Throw a version conflict error otherwise, or error if there are problems with database.
+	UpdateOidcProvider(oidcProvider OidcProvider, oldUpdateAt time.Time) (*OidcProvider, error)
 
 	// Remove the OIDC provider stored in database with its OIDC Clients.
 	// Throw error if there are problems during transactions.
@@ -396,3 +660,47 @@ type AuthOidcRepo interface {
 	// OrderByValidColumns returns valid columns that you can use in OrderBy
 	OrderByValidColumns(action string) []string
 }
+
+// AuditRepo contains all database operations
+type AuditRepo interface {
+	// Store audit entry in database if there aren't errors.
+	AddAuditEntry(entry AuditEntry) error
+
+	// Retrieve audit entries from database filtered by actor, resource urn, action and time range optional
+	// parameters, ordered by creation date by default. Throw error if there are problems with database.
+	GetAuditEntriesFiltered(filter *Filter) ([]AuditEntry, int, error)
+
+	// OrderByValidColumns returns valid columns that you can use in OrderBy
+	OrderByValidColumns(action string) []string
+}
+
+// WebhookRepo contains all database operations
+type WebhookRepo interface {
+	// Store webhook in database if there aren't errors.
+	AddWebhook(webhook Webhook) (*Webhook, error)
+
+	// Retrieve webhook from database if it exists. Otherwise it throws an error.
+	GetWebhookByName(name string) (*Webhook, error)
+
+	// Retrieve webhooks from database filtered by pathPrefix optional parameter. Throw error
+	// if there are problems with database.
+	GetWebhooksFiltered(filter *Filter) ([]Webhook, int, error)
+
+	// Update webhook stored in database with new fields, only if its update date is still oldUpdateAt.
+	// Throw a version conflict error otherwise, or error if there are problems with database.
+	UpdateWebhook(webhook Webhook, oldUpdateAt time.Time) (*Webhook, error)
+
+	// Remove webhook stored in database with its dead letters.
+	// Throw error if there are problems during transactions.
+	RemoveWebhook(id string) error
+
+	// Store an event that couldn't be delivered to a webhook. Throw error if there are problems with database.
+	AddWebhookDeadLetter(deadLetter WebhookDeadLetter) error
+
+	// Retrieve the dead letters of a webhook, ordered by creation date by default. Throw error
+	// if there are problems with database.
+	GetWebhookDeadLettersFiltered(webhookID string, filter *Filter) ([]WebhookDeadLetter, int, error)
+
+	// OrderByValidColumns returns valid columns that you can use in OrderBy
+	OrderByValidColumns(action string) []string
+}
@@ -38,9 +38,10 @@ type PolicyIdentity struct {
 }
 
 type Statement struct {
-	Effect    string   `json:"effect,omitempty"`
-	Actions   []string `json:"actions,omitempty"`
-	Resources []string `json:"resources,omitempty"`
+	Effect     string     `json:"effect,omitempty"`
+	Actions    []string   `json:"actions,omitempty"`
+	Resources  []string   `json:"resources,omitempty"`
+	Conditions Conditions `json:"conditions,omitempty"`
 }
 
 type PolicyGroups struct {
@@ -49,12 +50,25 @@ type PolicyGroups struct {
 }
 
 func (s Statement) String() string {
-	return fmt.Sprintf("[effect: %v, actions: %v, resources: %v]", s.Effect, s.Actions, s.Resources)
+	return fmt.Sprintf("[effect: %v, actions: %v, resources: %v, conditions: %v]", s.Effect, s.Actions, s.Resources, s.Conditions)
 }
 
 // POLICY API IMPLEMENTATION
 
 func (api WorkerAPI) AddPolicy(requestInfo RequestInfo, name string, path string, org string, statements []Statement) (*Policy, error) {
+	var policy *Policy
+	err := api.withTx(func(txAPI WorkerAPI) error {
+		var err error
+		policy, err = txAPI.addPolicy(requestInfo, name, path, org, statements)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (api WorkerAPI) addPolicy(requestInfo RequestInfo, name string, path string, org string, statements []Statement) (*Policy, error) {
 	// Validate fields
 	if !IsValidName(name) {
 		return nil, &Error{
@@ -123,6 +137,9 @@ func (api WorkerAPI) AddPolicy(requestInfo RequestInfo, name string, path string
 				}
 			}
 
+			if err := api.audit(requestInfo, POLICY_ACTION_CREATE_POLICY, createdPolicy.Urn, nil, createdPolicy); err != nil {
+				return nil, err
+			}
 			LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Policy created %+v", createdPolicy))
 			return createdPolicy, nil
 		default: // Unexpected error
@@ -238,6 +255,20 @@ func (api WorkerAPI) ListPolicies(requestInfo RequestInfo, filter *Filter) ([]Po
 }
 
 func (api WorkerAPI) UpdatePolicy(requestInfo RequestInfo, org string, policyName string, newName string, newPath string,
+	newStatements []Statement) (*Policy, error) {
+	var policy *Policy
+	err := api.withTx(func(txAPI WorkerAPI) error {
+		var err error
+		policy, err = txAPI.updatePolicy(requestInfo, org, policyName, newName, newPath, newStatements)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (api WorkerAPI) updatePolicy(requestInfo RequestInfo, org string, policyName string, newName string, newPath string,
 	newStatements []Statement) (*Policy, error) {
 	// Validate fields
 	if !IsValidName(newName) {
@@ -282,6 +313,11 @@ func (api WorkerAPI) UpdatePolicy(requestInfo RequestInfo, org string, policyNam
 		}
 	}
 
+	// Check the request applies to the current version
+	if err := checkIfMatch(requestInfo, oldPolicy.Urn, oldPolicy.UpdateAt); err != nil {
+		return nil, err
+	}
+
 	// Check if policy with "newName" exists
 	targetPolicy, err := api.GetPolicyByName(requestInfo, org, newName)
 
@@ -328,23 +364,41 @@ func (api WorkerAPI) UpdatePolicy(requestInfo RequestInfo, org string, policyNam
 	}
 
 	// Update policy
-	updatedPolicy, err := api.PolicyRepo.UpdatePolicy(policy)
+	updatedPolicy, err := api.PolicyRepo.UpdatePolicy(policy, oldPolicy.UpdateAt)
 
-	// Check unexpected DB error
+	// Error handling
 	if err != nil {
 		//Transform to DB error
 		dbError := err.(*database.Error)
-		return nil, &Error{
-			Code:    UNKNOWN_API_ERROR,
-			Message: dbError.Message,
+		switch dbError.Code {
+		case database.VERSION_CONFLICT:
+			return nil, &Error{
+				Code:    PRECONDITION_FAILED,
+				Message: dbError.Message,
+			}
+		default: // Unexpected error
+			return nil, &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: dbError.Message,
+			}
 		}
 	}
 
+	if err := api.audit(requestInfo, POLICY_ACTION_UPDATE_POLICY, oldPolicy.Urn, oldPolicy, updatedPolicy); err != nil {
+		return nil, err
+	}
+	api.AuthzCache.Invalidate()
 	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Policy updated from %+v to %+v", oldPolicy, updatedPolicy))
 	return updatedPolicy, nil
 }
 
 func (api WorkerAPI) RemovePolicy(requestInfo RequestInfo, org string, name string) error {
+	return api.withTx(func(txAPI WorkerAPI) error {
+		return txAPI.removePolicy(requestInfo, org, name)
+	})
+}
+
+func (api WorkerAPI) removePolicy(requestInfo RequestInfo, org string, name string) error {
 
 	// Call repo to retrieve the policy
 	policy, err := api.GetPolicyByName(requestInfo, org, name)
@@ -365,6 +419,11 @@ func (api WorkerAPI) RemovePolicy(requestInfo RequestInfo, org string, name stri
 		}
 	}
 
+	// Check the request applies to the current version
+	if err := checkIfMatch(requestInfo, policy.Urn, policy.UpdateAt); err != nil {
+		return err
+	}
+
 	err = api.PolicyRepo.RemovePolicy(policy.ID)
 	if err != nil {
 		//Transform to DB error
@@ -375,6 +434,10 @@ func (api WorkerAPI) RemovePolicy(requestInfo RequestInfo, org string, name stri
 		}
 	}
 
+	if err := api.audit(requestInfo, POLICY_ACTION_DELETE_POLICY, policy.Urn, policy, nil); err != nil {
+		return err
+	}
+	api.AuthzCache.Invalidate()
 	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Policy deleted %+v", policy))
 	return nil
 }
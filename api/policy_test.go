@@ -1,7 +1,9 @@
 package api
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/Tecsisa/foulkon/database"
 	"github.com/stretchr/testify/assert"
@@ -1004,6 +1006,7 @@ func TestAuthAPI_ListPolicies(t *testing.T) {
 }
 
 func TestAuthAPI_UpdatePolicy(t *testing.T) {
+	updateAt := time.Now().UTC()
 	testcases := map[string]struct {
 		requestInfo   RequestInfo
 		org           string
@@ -2345,6 +2348,89 @@ func TestAuthAPI_UpdatePolicy(t *testing.T) {
 				Code: UNKNOWN_API_ERROR,
 			},
 		},
+		"OKCaseIfMatch": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+				IfMatch:    "\"1\", " + EntityTag(updateAt),
+			},
+			org:           "123",
+			policyName:    "test",
+			newPolicyName: "test",
+			newPath:       "/path/",
+			newStatements: []Statement{},
+			getPolicyByNameMethodResult: &Policy{
+				ID:         "test1",
+				Name:       "test",
+				Org:        "123",
+				Path:       "/path/",
+				UpdateAt:   updateAt,
+				Urn:        CreateUrn("123", RESOURCE_POLICY, "/path/", "test"),
+				Statements: &[]Statement{},
+			},
+			updatePolicyMethodResult: &Policy{
+				ID:         "test1",
+				Name:       "test",
+				Org:        "123",
+				Path:       "/path/",
+				Urn:        CreateUrn("123", RESOURCE_POLICY, "/path/", "test"),
+				Statements: &[]Statement{},
+			},
+		},
+		"ErrorCaseIfMatchMismatch": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+				IfMatch:    "\"1\"",
+			},
+			org:           "123",
+			policyName:    "test",
+			newPolicyName: "test",
+			newPath:       "/path/",
+			newStatements: []Statement{},
+			getPolicyByNameMethodResult: &Policy{
+				ID:         "test1",
+				Name:       "test",
+				Org:        "123",
+				Path:       "/path/",
+				UpdateAt:   updateAt,
+				Urn:        CreateUrn("123", RESOURCE_POLICY, "/path/", "test"),
+				Statements: &[]Statement{},
+			},
+			wantError: &Error{
+				Code: PRECONDITION_FAILED,
+				Message: fmt.Sprintf("Resource %v doesn't match any of the versions \"1\", current version is %v",
+					CreateUrn("123", RESOURCE_POLICY, "/path/", "test"), EntityTag(updateAt)),
+			},
+		},
+		"ErrorCaseVersionConflict": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:           "123",
+			policyName:    "test",
+			newPolicyName: "test",
+			newPath:       "/path/",
+			newStatements: []Statement{},
+			getPolicyByNameMethodResult: &Policy{
+				ID:         "test1",
+				Name:       "test",
+				Org:        "123",
+				Path:       "/path/",
+				UpdateAt:   updateAt,
+				Urn:        CreateUrn("123", RESOURCE_POLICY, "/path/", "test"),
+				Statements: &[]Statement{},
+			},
+			updatePolicyMethodErr: &database.Error{
+				Code:    database.VERSION_CONFLICT,
+				Message: "Policy with id test1 was modified or removed by another request",
+			},
+			wantError: &Error{
+				Code:    PRECONDITION_FAILED,
+				Message: "Policy with id test1 was modified or removed by another request",
+			},
+		},
 	}
 
 	testRepo := makeTestRepo()
@@ -3,6 +3,8 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/Tecsisa/foulkon/database"
@@ -10,6 +12,10 @@ import (
 	"github.com/satori/go.uuid"
 )
 
+// Path of the proxy endpoints served on the same listener as proxy resources, so requests to it never
+// reach them
+const PROXY_RESERVED_PATH = "/foulkon/proxy"
+
 // TYPE DEFINITIONS
 
 // ProxyResource domain
@@ -31,11 +37,23 @@ type ProxyResourceIdentity struct {
 }
 
 type ResourceEntity struct {
-	Host   string `json:"host,omitempty"`
-	Path   string `json:"path,omitempty"`
-	Method string `json:"method,omitempty"`
-	Urn    string `json:"urn,omitempty"`
-	Action string `json:"action,omitempty"`
+	Host string `json:"host,omitempty" yaml:"host,omitempty"`
+	// Upstream pool of the resource org that requests are sent to, instead of the host
+	Upstream string `json:"upstream,omitempty" yaml:"upstream,omitempty"`
+	Path     string `json:"path,omitempty" yaml:"path"`
+	Method   string `json:"method,omitempty" yaml:"method"`
+	Urn      string `json:"urn,omitempty" yaml:"urn"`
+	Action   string `json:"action,omitempty" yaml:"action"`
+	// Virtual host of the requests, without port. Requests to any host are matched if it is empty
+	MatchHost string `json:"matchHost,omitempty" yaml:"matchHost,omitempty"`
+	// Headers that the requests must have, all of them
+	MatchHeaders []HeaderMatcher `json:"matchHeaders,omitempty" yaml:"matchHeaders,omitempty"`
+}
+
+// HeaderMatcher matches the requests with a header value
+type HeaderMatcher struct {
+	Name  string `json:"name,omitempty" yaml:"name"`
+	Value string `json:"value,omitempty" yaml:"value"`
 }
 
 func (p ProxyResource) GetUrn() string {
@@ -60,6 +78,19 @@ func (api ProxyAPI) GetProxyResources() ([]ProxyResource, error) {
 }
 
 func (api WorkerAPI) AddProxyResource(requestInfo RequestInfo, name string, org string, path string, resource ResourceEntity) (*ProxyResource, error) {
+	var proxyResource *ProxyResource
+	err := api.withTx(func(txAPI WorkerAPI) error {
+		var err error
+		proxyResource, err = txAPI.addProxyResource(requestInfo, name, org, path, resource)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return proxyResource, nil
+}
+
+func (api WorkerAPI) addProxyResource(requestInfo RequestInfo, name string, org string, path string, resource ResourceEntity) (*ProxyResource, error) {
 	// Validate fields
 	if !IsValidName(name) {
 		return nil, &Error{
@@ -83,6 +114,7 @@ func (api WorkerAPI) AddProxyResource(requestInfo RequestInfo, name string, org
 	if err != nil {
 		return nil, err
 	}
+	resource = normalizeResourceEntity(resource)
 
 	proxyResource := createProxyResource(name, org, path, resource)
 
@@ -100,6 +132,13 @@ func (api WorkerAPI) AddProxyResource(requestInfo RequestInfo, name string, org
 		}
 	}
 
+	// Check upstream pool
+	if len(resource.Upstream) > 0 {
+		if err := api.checkUpstreamPoolExists(org, resource.Upstream); err != nil {
+			return nil, err
+		}
+	}
+
 	// Check if proxy resource already exists
 	_, err = api.ProxyRepo.GetProxyResourceByName(org, name)
 
@@ -110,7 +149,7 @@ func (api WorkerAPI) AddProxyResource(requestInfo RequestInfo, name string, org
 		switch dbError.Code {
 		case database.PROXY_RESOURCE_NOT_FOUND:
 			// Retrieve all routes to check if new proxy resource is consistent
-			storedProxyResources, total, err := api.ProxyRepo.GetProxyResources(&Filter{})
+			storedProxyResources, _, err := api.ProxyRepo.GetProxyResources(&Filter{})
 
 			// Check unexpected DB error
 			if err != nil {
@@ -123,17 +162,15 @@ func (api WorkerAPI) AddProxyResource(requestInfo RequestInfo, name string, org
 			}
 
 			// Validate routes
-			if total > 0 {
-				storedProxyResources := append(storedProxyResources, proxyResource)
-				err = validateProxyRoutes(storedProxyResources)
-				if err != nil {
-					convertedError := err.(*Error)
-					return nil, &Error{
-						Code: PROXY_RESOURCES_ROUTES_CONFLICT,
-						Message: fmt.Sprintf("Proxy resource with org %v and name %v, "+
-							"collides with other existent resource path: %v",
-							proxyResource.Org, proxyResource.Name, convertedError.Message),
-					}
+			storedProxyResources = append(storedProxyResources, proxyResource)
+			err = validateProxyRoutes(storedProxyResources)
+			if err != nil {
+				convertedError := err.(*Error)
+				return nil, &Error{
+					Code: PROXY_RESOURCES_ROUTES_CONFLICT,
+					Message: fmt.Sprintf("Proxy resource with org %v and name %v, "+
+						"collides with other existent resource path: %v",
+						proxyResource.Org, proxyResource.Name, convertedError.Message),
 				}
 			}
 
@@ -149,6 +186,12 @@ func (api WorkerAPI) AddProxyResource(requestInfo RequestInfo, name string, org
 					Message: dbError.Message,
 				}
 			}
+			if err := api.incrementProxyRevision(); err != nil {
+				return nil, err
+			}
+			if err := api.audit(requestInfo, PROXY_ACTION_CREATE_RESOURCE, created.Urn, nil, created); err != nil {
+				return nil, err
+			}
 			LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("proxy resource created %+v", created))
 			return created, nil
 		default: // Unexpected error
@@ -221,6 +264,19 @@ func (api WorkerAPI) GetProxyResourceByName(requestInfo RequestInfo, org string,
 }
 
 func (api WorkerAPI) UpdateProxyResource(requestInfo RequestInfo, org string, name string, newName string, newPath string, newResource ResourceEntity) (*ProxyResource, error) {
+	var proxyResource *ProxyResource
+	err := api.withTx(func(txAPI WorkerAPI) error {
+		var err error
+		proxyResource, err = txAPI.updateProxyResource(requestInfo, org, name, newName, newPath, newResource)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return proxyResource, nil
+}
+
+func (api WorkerAPI) updateProxyResource(requestInfo RequestInfo, org string, name string, newName string, newPath string, newResource ResourceEntity) (*ProxyResource, error) {
 	// Validate fields
 	if !IsValidName(newName) {
 		return nil, &Error{
@@ -238,6 +294,7 @@ func (api WorkerAPI) UpdateProxyResource(requestInfo RequestInfo, org string, na
 	if err != nil {
 		return nil, err
 	}
+	newResource = normalizeResourceEntity(newResource)
 
 	// Call repo to retrieve the old proxy resource
 	oldProxyResource, err := api.GetProxyResourceByName(requestInfo, org, name)
@@ -258,6 +315,11 @@ func (api WorkerAPI) UpdateProxyResource(requestInfo RequestInfo, org string, na
 		}
 	}
 
+	// Check the request applies to the current version
+	if err := checkIfMatch(requestInfo, oldProxyResource.Urn, oldProxyResource.UpdateAt); err != nil {
+		return nil, err
+	}
+
 	// Check if a proxy resource with "newName" already exists
 	newProxyResource, err := api.GetProxyResourceByName(requestInfo, org, newName)
 
@@ -292,6 +354,13 @@ func (api WorkerAPI) UpdateProxyResource(requestInfo RequestInfo, org string, na
 		}
 	}
 
+	// Check upstream pool
+	if len(newResource.Upstream) > 0 {
+		if err := api.checkUpstreamPoolExists(org, newResource.Upstream); err != nil {
+			return nil, err
+		}
+	}
+
 	// Update proxy resource
 	proxyResource := ProxyResource{
 		ID:       oldProxyResource.ID,
@@ -305,7 +374,7 @@ func (api WorkerAPI) UpdateProxyResource(requestInfo RequestInfo, org string, na
 	}
 
 	// Retrieve all routes to check if new proxy resource is consistent
-	storedProxyResources, total, err := api.ProxyRepo.GetProxyResources(&Filter{})
+	storedProxyResources, _, err := api.ProxyRepo.GetProxyResources(&Filter{})
 
 	// Check unexpected DB error
 	if err != nil {
@@ -318,44 +387,62 @@ func (api WorkerAPI) UpdateProxyResource(requestInfo RequestInfo, org string, na
 	}
 
 	// Validate routes
-	if total > 0 {
-		proxyResourcesToValidate := []ProxyResource{}
-		// Add all items except itself
-		for _, pr := range storedProxyResources {
-			if pr.ID != proxyResource.ID {
-				proxyResourcesToValidate = append(proxyResourcesToValidate, pr)
-			}
+	proxyResourcesToValidate := []ProxyResource{}
+	// Add all items except itself
+	for _, pr := range storedProxyResources {
+		if pr.ID != proxyResource.ID {
+			proxyResourcesToValidate = append(proxyResourcesToValidate, pr)
 		}
-		proxyResourcesToValidate = append(proxyResourcesToValidate, proxyResource)
-		err = validateProxyRoutes(proxyResourcesToValidate)
-		if err != nil {
-			convertedError := err.(*Error)
-			return nil, &Error{
-				Code: PROXY_RESOURCES_ROUTES_CONFLICT,
-				Message: fmt.Sprintf("Proxy resource with org %v and name %v, "+
-					"collides with other existent resource path: %v",
-					proxyResource.Org, proxyResource.Name, convertedError.Message),
-			}
+	}
+	proxyResourcesToValidate = append(proxyResourcesToValidate, proxyResource)
+	err = validateProxyRoutes(proxyResourcesToValidate)
+	if err != nil {
+		convertedError := err.(*Error)
+		return nil, &Error{
+			Code: PROXY_RESOURCES_ROUTES_CONFLICT,
+			Message: fmt.Sprintf("Proxy resource with org %v and name %v, "+
+				"collides with other existent resource path: %v",
+				proxyResource.Org, proxyResource.Name, convertedError.Message),
 		}
 	}
 
-	updatedProxyResource, err := api.ProxyRepo.UpdateProxyResource(proxyResource)
+	updatedProxyResource, err := api.ProxyRepo.UpdateProxyResource(proxyResource, oldProxyResource.UpdateAt)
 
-	// Check unexpected DB error
+	// Error handling
 	if err != nil {
 		//Transform to DB error
 		dbError := err.(*database.Error)
-		return nil, &Error{
-			Code:    UNKNOWN_API_ERROR,
-			Message: dbError.Message,
+		switch dbError.Code {
+		case database.VERSION_CONFLICT:
+			return nil, &Error{
+				Code:    PRECONDITION_FAILED,
+				Message: dbError.Message,
+			}
+		default: // Unexpected error
+			return nil, &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: dbError.Message,
+			}
 		}
 	}
 
+	if err := api.incrementProxyRevision(); err != nil {
+		return nil, err
+	}
+	if err := api.audit(requestInfo, PROXY_ACTION_UPDATE_RESOURCE, oldProxyResource.Urn, oldProxyResource, updatedProxyResource); err != nil {
+		return nil, err
+	}
 	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Proxy resource updated from %+v to %+v", oldProxyResource, updatedProxyResource))
 	return updatedProxyResource, nil
 }
 
 func (api WorkerAPI) RemoveProxyResource(requestInfo RequestInfo, org string, name string) error {
+	return api.withTx(func(txAPI WorkerAPI) error {
+		return txAPI.removeProxyResource(requestInfo, org, name)
+	})
+}
+
+func (api WorkerAPI) removeProxyResource(requestInfo RequestInfo, org string, name string) error {
 	// Call repo to retrieve the proxy resource
 	proxyResource, err := api.GetProxyResourceByName(requestInfo, org, name)
 	if err != nil {
@@ -375,6 +462,11 @@ func (api WorkerAPI) RemoveProxyResource(requestInfo RequestInfo, org string, na
 		}
 	}
 
+	// Check the request applies to the current version
+	if err := checkIfMatch(requestInfo, proxyResource.Urn, proxyResource.UpdateAt); err != nil {
+		return err
+	}
+
 	err = api.ProxyRepo.RemoveProxyResource(proxyResource.ID)
 
 	// Error handling
@@ -387,6 +479,12 @@ func (api WorkerAPI) RemoveProxyResource(requestInfo RequestInfo, org string, na
 		}
 	}
 
+	if err := api.incrementProxyRevision(); err != nil {
+		return err
+	}
+	if err := api.audit(requestInfo, PROXY_ACTION_DELETE_RESOURCE, proxyResource.Urn, proxyResource, nil); err != nil {
+		return err
+	}
 	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Proxy resource deleted %+v", proxyResource))
 	return nil
 }
@@ -438,12 +536,46 @@ func (api WorkerAPI) ListProxyResources(requestInfo RequestInfo, filter *Filter)
 
 // PRIVATE HELPER METHODS
 
-// This method validates proxy routes to avoid panics when they will be instantiated
+// This method validates proxy routes to avoid panics when they will be instantiated. Routes are
+// validated by virtual host, and resources with the same route must match different requests
+// with their headers, or be more specific than the others.
 func validateProxyRoutes(proxyResources []ProxyResource) error {
-	router := httprouter.New()
+	routers := map[string]*httprouter.Router{}
+	routes := map[string][]ProxyResource{}
 	for _, pr := range proxyResources {
+		host := strings.ToLower(pr.Resource.MatchHost)
+		router, ok := routers[host]
+		if !ok {
+			router = httprouter.New()
+			routers[host] = router
+		}
+		route := fmt.Sprintf("%v %v %v", host, pr.Resource.Method, pr.Resource.Path)
+
+		// Requests to the reserved path are served by the proxy itself
+		if pr.Resource.Path == PROXY_RESERVED_PATH || strings.HasPrefix(pr.Resource.Path, PROXY_RESERVED_PATH+"/") {
+			return &Error{
+				Code:    PROXY_RESOURCES_ROUTES_CONFLICT,
+				Message: fmt.Sprintf("Error in route handler: path '%v' is reserved by the proxy", pr.Resource.Path),
+			}
+		}
+
 		errorMessage := ""
-		safeRouterAdderHandler(router, pr, &errorMessage)
+		if len(routes[route]) == 0 {
+			safeRouterAdderHandler(router, pr, &errorMessage)
+		}
+		for _, other := range routes[route] {
+			overlap, equal := headerMatchersOverlap(other.Resource.MatchHeaders, pr.Resource.MatchHeaders)
+			if equal {
+				// Add the route again to get the error of the router
+				safeRouterAdderHandler(router, pr, &errorMessage)
+				break
+			}
+			if overlap {
+				errorMessage = fmt.Sprintf("Error in route handler: header matchers %v and %v match the same requests to path '%v'",
+					other.Resource.MatchHeaders, pr.Resource.MatchHeaders, pr.Resource.Path)
+				break
+			}
+		}
 		// If there was an error, exit with its info
 		if errorMessage != "" {
 			return &Error{
@@ -451,10 +583,35 @@ func validateProxyRoutes(proxyResources []ProxyResource) error {
 				Message: errorMessage,
 			}
 		}
+		routes[route] = append(routes[route], pr)
 	}
 	return nil
 }
 
+// headerMatchersOverlap checks if both sets of header matchers match some request without one of them
+// being more specific than the other, so the proxy couldn't choose a resource. It also checks if they are equal.
+func headerMatchersOverlap(a []HeaderMatcher, b []HeaderMatcher) (bool, bool) {
+	values := map[string]string{}
+	for _, h := range a {
+		values[h.Name] = h.Value
+	}
+	shared := 0
+	for _, h := range b {
+		value, ok := values[h.Name]
+		if !ok {
+			continue
+		}
+		// A request can't have both values, as the proxy only matches the first value of each header
+		if value != h.Value {
+			return false, false
+		}
+		shared++
+	}
+	// A set is more specific when it has all matchers of the other
+	equal := shared == len(a) && shared == len(b)
+	return equal || (shared < len(a) && shared < len(b)), equal
+}
+
 // This method adds routes to a handler using Proxy Resources avoiding panics, returning and error in third param if exist.
 func safeRouterAdderHandler(router *httprouter.Router, pr ProxyResource, err *string) {
 	defer func() {
@@ -481,3 +638,20 @@ func createProxyResource(name string, org string, path string, resource Resource
 
 	return pr
 }
+
+// normalizeResourceEntity returns the resource with the host to match in lower case, and the header matchers
+// with canonical names sorted by name, so the same routes are always stored in the same way
+func normalizeResourceEntity(resource ResourceEntity) ResourceEntity {
+	resource.MatchHost = strings.ToLower(resource.MatchHost)
+	if len(resource.MatchHeaders) == 0 {
+		resource.MatchHeaders = nil
+		return resource
+	}
+	headers := make([]HeaderMatcher, len(resource.MatchHeaders))
+	for i, h := range resource.MatchHeaders {
+		headers[i] = HeaderMatcher{Name: http.CanonicalHeaderKey(h.Name), Value: h.Value}
+	}
+	sort.Slice(headers, func(i, j int) bool { return headers[i].Name < headers[j].Name })
+	resource.MatchHeaders = headers
+	return resource
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/Tecsisa/foulkon/database"
+)
+
+// TYPE DEFINITIONS
+
+// ProxyConfig is a snapshot of all proxy resources and upstream pools. Its revision is increased on every change
+// of them, so proxies only reload the config when the revision they have is outdated.
+type ProxyConfig struct {
+	Revision      int64           `json:"revision"`
+	Resources     []ProxyResource `json:"resources"`
+	UpstreamPools []UpstreamPool  `json:"upstreamPools"`
+}
+
+// PROXY CONFIG API IMPLEMENTATION
+
+// GetProxyConfig returns the proxy config, or nil if its revision is still the given one
+func (api ProxyAPI) GetProxyConfig(revision int64) (*ProxyConfig, error) {
+	return getProxyConfig(api.ProxyRepo, revision)
+}
+
+// ExportProxyConfig returns the proxy config, or nil if its revision is still the given one. The user must be allowed
+// to get the proxy config for every proxy resource and upstream pool in it, because proxies can't serve a partial config
+func (api WorkerAPI) ExportProxyConfig(requestInfo RequestInfo, revision int64) (*ProxyConfig, error) {
+	// Check restrictions before reading the config, so unauthorized users can't even know its revision
+	if _, err := api.getAuthorizedResources(requestInfo, "*", PROXY_ACTION_GET_PROXY_CONFIG, []Resource{}); err != nil {
+		return nil, err
+	}
+
+	config, err := getProxyConfig(api.ProxyRepo, revision)
+	if err != nil || config == nil {
+		return nil, err
+	}
+
+	resourcesToAuthorize := []Resource{}
+	for _, proxyResource := range config.Resources {
+		resourcesToAuthorize = append(resourcesToAuthorize, proxyResource)
+	}
+	for _, pool := range config.UpstreamPools {
+		resourcesToAuthorize = append(resourcesToAuthorize, pool)
+	}
+	authorizedResources, err := api.getAuthorizedResources(requestInfo, "*", PROXY_ACTION_GET_PROXY_CONFIG, resourcesToAuthorize)
+	if err != nil {
+		return nil, err
+	}
+	if len(authorizedResources) != len(resourcesToAuthorize) {
+		return nil, &Error{
+			Code:    UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to export the proxy config", requestInfo.Identifier),
+		}
+	}
+
+	return config, nil
+}
+
+// PRIVATE HELPER METHODS
+
+// getProxyConfig reads the revision before the resources and pools, so the config returned is never older
+// than its revision
+func getProxyConfig(repo ProxyRepo, revision int64) (*ProxyConfig, error) {
+	currentRevision, err := repo.GetProxyRevision()
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+	if currentRevision == revision {
+		return nil, nil
+	}
+
+	resources, _, err := repo.GetProxyResources(&Filter{})
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	pools, _, err := repo.GetUpstreamPools(&Filter{})
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	return &ProxyConfig{
+		Revision:      currentRevision,
+		Resources:     resources,
+		UpstreamPools: pools,
+	}, nil
+}
+
+// incrementProxyRevision increases the revision of the proxy config after a change of a proxy resource
+// or upstream pool
+func (api WorkerAPI) incrementProxyRevision() error {
+	if err := api.ProxyRepo.IncrementProxyRevision(); err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/Tecsisa/foulkon/database"
+)
+
+func TestProxyAPI_GetProxyConfig(t *testing.T) {
+	resources := []ProxyResource{
+		{
+			Resource: ResourceEntity{
+				Host:   "host",
+				Path:   "/path",
+				Method: "Method",
+				Urn:    "urn",
+				Action: "action",
+			},
+		},
+	}
+	pools := []UpstreamPool{
+		{
+			Name: "pool1",
+			Config: UpstreamPoolConfig{
+				Targets: []string{"http://localhost:8080"},
+			},
+		},
+	}
+	testcases := map[string]struct {
+		// API Method args
+		revision int64
+		// Expected result
+		expectedResponse *ProxyConfig
+		wantError        error
+		// Manager Results
+		getProxyRevisionResult  int64
+		getProxyResourcesResult []ProxyResource
+		getUpstreamPoolsResult  []UpstreamPool
+		// Manager Errors
+		getProxyRevisionErr  error
+		getProxyResourcesErr error
+		getUpstreamPoolsErr  error
+	}{
+		"OkCase": {
+			revision:                1,
+			getProxyRevisionResult:  2,
+			getProxyResourcesResult: resources,
+			getUpstreamPoolsResult:  pools,
+			expectedResponse: &ProxyConfig{
+				Revision:      2,
+				Resources:     resources,
+				UpstreamPools: pools,
+			},
+		},
+		"OkCaseNotModified": {
+			revision:                2,
+			getProxyRevisionResult:  2,
+			getProxyResourcesResult: resources,
+			getUpstreamPoolsResult:  pools,
+		},
+		"ErrorCaseGetProxyRevision": {
+			getProxyRevisionErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseGetProxyResources": {
+			getProxyRevisionResult: 2,
+			getProxyResourcesErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseGetUpstreamPools": {
+			getProxyRevisionResult:  2,
+			getProxyResourcesResult: resources,
+			getUpstreamPoolsErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeProxyTestAPI(testRepo)
+
+		testRepo.ArgsOut[GetProxyRevisionMethod][0] = test.getProxyRevisionResult
+		testRepo.ArgsOut[GetProxyRevisionMethod][1] = test.getProxyRevisionErr
+		testRepo.ArgsOut[GetProxyResourcesMethod][0] = test.getProxyResourcesResult
+		testRepo.ArgsOut[GetProxyResourcesMethod][2] = test.getProxyResourcesErr
+		testRepo.ArgsOut[GetUpstreamPoolsMethod][0] = test.getUpstreamPoolsResult
+		testRepo.ArgsOut[GetUpstreamPoolsMethod][2] = test.getUpstreamPoolsErr
+
+		config, err := testAPI.GetProxyConfig(test.revision)
+		checkMethodResponse(t, n, test.wantError, err, test.expectedResponse, config)
+	}
+}
+
+func TestWorkerAPI_ExportProxyConfig(t *testing.T) {
+	proxyResource := ProxyResource{
+		ID:   "PR-ID",
+		Name: "pr",
+		Org:  "org1",
+		Path: "/path/",
+		Urn:  CreateUrn("org1", RESOURCE_PROXY, "/path/", "pr"),
+	}
+	pool := UpstreamPool{
+		ID:   "POOL-ID",
+		Name: "pool",
+		Org:  "org1",
+		Path: "/path/",
+		Urn:  CreateUrn("org1", RESOURCE_UPSTREAM_POOL, "/path/", "pool"),
+	}
+	user := &User{
+		ID:         "USER-ID",
+		ExternalID: "user",
+		Path:       "/path/",
+		Urn:        CreateUrn("", RESOURCE_USER, "/path/", "user"),
+	}
+	groups := []TestUserGroupRelation{
+		{
+			Group: &Group{
+				ID:   "GROUP-ID",
+				Name: "proxies",
+				Org:  "org1",
+				Path: "/path/",
+				Urn:  CreateUrn("org1", RESOURCE_GROUP, "/path/", "proxies"),
+			},
+		},
+	}
+	policyWithResources := func(resources ...string) []TestPolicyGroupRelation {
+		return []TestPolicyGroupRelation{
+			{
+				Policy: &Policy{
+					ID:   "POLICY-ID",
+					Name: "proxy",
+					Org:  "org1",
+					Path: "/path/",
+					Urn:  CreateUrn("org1", RESOURCE_POLICY, "/path/", "proxy"),
+					Statements: &[]Statement{
+						{
+							Effect:    "allow",
+							Actions:   []string{PROXY_ACTION_GET_PROXY_CONFIG},
+							Resources: resources,
+						},
+					},
+				},
+			},
+		}
+	}
+	testcases := map[string]struct {
+		// API Method args
+		requestInfo RequestInfo
+		revision    int64
+		// Expected result
+		expectedResponse *ProxyConfig
+		wantError        error
+		// Manager Results
+		getUserByExternalIDResult *User
+		getGroupsByUserIDResult   []TestUserGroupRelation
+		getAttachedPoliciesResult []TestPolicyGroupRelation
+	}{
+		"OkCase": {
+			requestInfo: RequestInfo{Identifier: "admin", Admin: true},
+			expectedResponse: &ProxyConfig{
+				Revision:      1,
+				Resources:     []ProxyResource{proxyResource},
+				UpstreamPools: []UpstreamPool{pool},
+			},
+		},
+		"OkCaseNotModified": {
+			requestInfo: RequestInfo{Identifier: "admin", Admin: true},
+			revision:    1,
+		},
+		"OkCaseAllowedByPolicy": {
+			requestInfo: RequestInfo{Identifier: "user"},
+			expectedResponse: &ProxyConfig{
+				Revision:      1,
+				Resources:     []ProxyResource{proxyResource},
+				UpstreamPools: []UpstreamPool{pool},
+			},
+			getUserByExternalIDResult: user,
+			getGroupsByUserIDResult:   groups,
+			getAttachedPoliciesResult: policyWithResources("urn:iws:iam:org1:*"),
+		},
+		"ErrorCaseNoPermissions": {
+			requestInfo: RequestInfo{Identifier: "user"},
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId user is not allowed to access to resource *",
+			},
+			getUserByExternalIDResult: user,
+			getGroupsByUserIDResult:   groups,
+			getAttachedPoliciesResult: policyWithResources(),
+		},
+		"ErrorCaseNotAllowedForEveryResource": {
+			requestInfo: RequestInfo{Identifier: "user"},
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId user is not allowed to export the proxy config",
+			},
+			getUserByExternalIDResult: user,
+			getGroupsByUserIDResult:   groups,
+			getAttachedPoliciesResult: policyWithResources(GetUrnPrefix("org1", RESOURCE_PROXY, "/")),
+		},
+	}
+
+	for n, test := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[GetProxyRevisionMethod][0] = int64(1)
+		testRepo.ArgsOut[GetProxyResourcesMethod][0] = []ProxyResource{proxyResource}
+		testRepo.ArgsOut[GetUpstreamPoolsMethod][0] = []UpstreamPool{pool}
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = test.getUserByExternalIDResult
+		testRepo.ArgsOut[GetGroupsByUserIDMethod][0] = test.getGroupsByUserIDResult
+		testRepo.ArgsOut[GetAttachedPoliciesMethod][0] = test.getAttachedPoliciesResult
+
+		config, err := testAPI.ExportProxyConfig(test.requestInfo, test.revision)
+		checkMethodResponse(t, n, test.wantError, err, test.expectedResponse, config)
+	}
+}
+
+func TestWorkerAPI_incrementProxyRevision(t *testing.T) {
+	testcases := map[string]struct {
+		// Expected result
+		wantError error
+		// Manager Errors
+		incrementProxyRevisionErr error
+	}{
+		"OkCase": {},
+		"ErrorCaseInternalError": {
+			incrementProxyRevisionErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[IncrementProxyRevisionMethod][0] = test.incrementProxyRevisionErr
+
+		err := testAPI.incrementProxyRevision()
+		checkMethodResponse(t, n, test.wantError, err, nil, nil)
+	}
+}
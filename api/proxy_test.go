@@ -2146,6 +2146,7 @@ func TestWorkerAPI_AddProxyResource(t *testing.T) {
 		resource    ResourceEntity
 		// Expected results
 		expectedProxyResource *ProxyResource
+		expectedAddedResource *ResourceEntity
 		wantError             error
 		// Manager Results
 		getUserByExternalIDResult          *User
@@ -2160,6 +2161,7 @@ func TestWorkerAPI_AddProxyResource(t *testing.T) {
 		getProxyResourcesMethodErr      error
 		getUserByExternalIDMethodErr    error
 		addProxyResourceMethodErr       error
+		getUpstreamPoolByNameMethodErr  error
 	}{
 		"OKCaseAdmin": {
 			requestInfo: RequestInfo{
@@ -2194,6 +2196,101 @@ func TestWorkerAPI_AddProxyResource(t *testing.T) {
 				Code: database.PROXY_RESOURCE_NOT_FOUND,
 			},
 		},
+		"OKCaseSamePathWithOtherHostAndHeaders": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "name",
+			org:  "org",
+			path: "/example/",
+			resource: ResourceEntity{
+				Host:         "http://host.com",
+				Path:         "/path",
+				Method:       "GET",
+				Urn:          "urn:ews:example:instance1:resource/get",
+				Action:       "action",
+				MatchHost:    "API.example.com",
+				MatchHeaders: []HeaderMatcher{{Name: "x-version", Value: "2"}, {Name: "accept", Value: "application/json"}},
+			},
+			expectedProxyResource: &ProxyResource{
+				ID:   "ID",
+				Name: "name",
+				Path: "/example/",
+				Org:  "org",
+				Resource: ResourceEntity{
+					Host:         "http://host.com",
+					Path:         "/path",
+					Method:       "GET",
+					Urn:          "urn:ews:example:instance1:resource/get",
+					Action:       "action",
+					MatchHost:    "api.example.com",
+					MatchHeaders: []HeaderMatcher{{Name: "Accept", Value: "application/json"}, {Name: "X-Version", Value: "2"}},
+				},
+				Urn: "urn",
+			},
+			expectedAddedResource: &ResourceEntity{
+				Host:         "http://host.com",
+				Path:         "/path",
+				Method:       "GET",
+				Urn:          "urn:ews:example:instance1:resource/get",
+				Action:       "action",
+				MatchHost:    "api.example.com",
+				MatchHeaders: []HeaderMatcher{{Name: "Accept", Value: "application/json"}, {Name: "X-Version", Value: "2"}},
+			},
+			getProxyResourceByNameMethodErr: &database.Error{
+				Code: database.PROXY_RESOURCE_NOT_FOUND,
+			},
+			getProxyResourcesMethodResult: []ProxyResource{
+				{
+					ID:   "ID1",
+					Name: "name1",
+					Path: "/example/",
+					Org:  "org",
+					Resource: ResourceEntity{
+						Host:   "http://host.com",
+						Path:   "/path",
+						Method: "GET",
+						Urn:    "urn:ews:example:instance1:resource/get",
+						Action: "action",
+					},
+					Urn: "urn1",
+				},
+				{
+					ID:   "ID2",
+					Name: "name2",
+					Path: "/example/",
+					Org:  "org",
+					Resource: ResourceEntity{
+						Host:         "http://host.com",
+						Path:         "/path",
+						Method:       "GET",
+						Urn:          "urn:ews:example:instance1:resource/get",
+						Action:       "action",
+						MatchHost:    "api.example.com",
+						MatchHeaders: []HeaderMatcher{{Name: "X-Version", Value: "1"}},
+					},
+					Urn: "urn2",
+				},
+				{
+					ID:   "ID3",
+					Name: "name3",
+					Path: "/example/",
+					Org:  "org",
+					Resource: ResourceEntity{
+						Host:         "http://host.com",
+						Path:         "/path",
+						Method:       "GET",
+						Urn:          "urn:ews:example:instance1:resource/get",
+						Action:       "action",
+						MatchHost:    "api.example.com",
+						MatchHeaders: []HeaderMatcher{{Name: "X-Version", Value: "2"}},
+					},
+					Urn: "urn3",
+				},
+			},
+			getProxyResourcesMethodTotal: 3,
+		},
 		"OKCase": {
 			requestInfo: RequestInfo{
 				Identifier: "123456",
@@ -2646,6 +2743,99 @@ func TestWorkerAPI_AddProxyResource(t *testing.T) {
 					"resource path: Error in route handler: a handle is already registered for path ''/path'",
 			},
 		},
+		"ErrorCaseProxyResourceReservedPath": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "name",
+			org:  "org",
+			path: "/example/",
+			resource: ResourceEntity{
+				Host:   "http://host.com",
+				Path:   "/foulkon/proxy/*path",
+				Method: "GET",
+				Urn:    "urn:ews:example:instance1:resource/get",
+				Action: "action",
+			},
+			getProxyResourceByNameMethodErr: &database.Error{
+				Code: database.PROXY_RESOURCE_NOT_FOUND,
+			},
+			wantError: &Error{
+				Code: PROXY_RESOURCES_ROUTES_CONFLICT,
+				Message: "Proxy resource with org org and name name, collides with other existent " +
+					"resource path: Error in route handler: path '/foulkon/proxy/*path' is reserved by the proxy",
+			},
+		},
+		"ErrorCaseProxyResourceRouteConflictHeaders": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "name",
+			org:  "org",
+			path: "/example/",
+			resource: ResourceEntity{
+				Host:         "http://host.com",
+				Path:         "/path",
+				Method:       "GET",
+				Urn:          "urn:ews:example:instance1:resource/get",
+				Action:       "action",
+				MatchHost:    "api.example.com",
+				MatchHeaders: []HeaderMatcher{{Name: "X-Version", Value: "2"}},
+			},
+			getProxyResourceByNameMethodErr: &database.Error{
+				Code: database.PROXY_RESOURCE_NOT_FOUND,
+			},
+			getProxyResourcesMethodResult: []ProxyResource{
+				{
+					ID:   "ID1",
+					Name: "name1",
+					Path: "/example/",
+					Org:  "org",
+					Resource: ResourceEntity{
+						Host:         "http://host.com",
+						Path:         "/path",
+						Method:       "GET",
+						Urn:          "urn:ews:example:instance1:resource/get",
+						Action:       "action",
+						MatchHost:    "api.example.com",
+						MatchHeaders: []HeaderMatcher{{Name: "Accept", Value: "application/json"}},
+					},
+					Urn: "urn1",
+				},
+			},
+			getProxyResourcesMethodTotal: 1,
+			wantError: &Error{
+				Code: PROXY_RESOURCES_ROUTES_CONFLICT,
+				Message: "Proxy resource with org org and name name, collides with other existent resource path: " +
+					"Error in route handler: header matchers [{Accept application/json}] and [{X-Version 2}] match the same requests to path '/path'",
+			},
+		},
+		"ErrorCaseUpstreamPoolNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "name",
+			org:  "org",
+			path: "/example/",
+			resource: ResourceEntity{
+				Upstream: "pool1",
+				Path:     "/path",
+				Method:   "GET",
+				Urn:      "urn:ews:example:instance1:resource/get",
+				Action:   "action",
+			},
+			getUpstreamPoolByNameMethodErr: &database.Error{
+				Code:    database.UPSTREAM_POOL_NOT_FOUND,
+				Message: "Upstream pool with organization org and name pool1 not found",
+			},
+			wantError: &Error{
+				Code:    UPSTREAM_POOL_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Upstream pool with organization org and name pool1 not found",
+			},
+		},
 	}
 
 	for x, testcase := range testcases {
@@ -2663,8 +2853,13 @@ func TestWorkerAPI_AddProxyResource(t *testing.T) {
 		testRepo.ArgsOut[GetAttachedPoliciesMethod][0] = testcase.getAttachedPoliciesResult
 		testRepo.ArgsOut[AddProxyResourceMethod][0] = testcase.expectedProxyResource
 		testRepo.ArgsOut[AddProxyResourceMethod][1] = testcase.addProxyResourceMethodErr
+		testRepo.ArgsOut[GetUpstreamPoolByNameMethod][1] = testcase.getUpstreamPoolByNameMethodErr
 
 		proxyResource, err := testAPI.AddProxyResource(testcase.requestInfo, testcase.name, testcase.org, testcase.path, testcase.resource)
 		checkMethodResponse(t, x, testcase.wantError, err, testcase.expectedProxyResource, proxyResource)
+		if testcase.expectedAddedResource != nil {
+			added := testRepo.ArgsIn[AddProxyResourceMethod][0].(ProxyResource)
+			assert.Equal(t, *testcase.expectedAddedResource, added.Resource, "Error in test case %v", x)
+		}
 	}
 }
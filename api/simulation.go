@@ -0,0 +1,205 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Tecsisa/foulkon/database"
+)
+
+// TYPE DEFINITIONS
+
+// DraftPolicy is a policy that isn't stored, used to simulate its effects. If Replace is true, its statements
+// replace the statements of the stored policy with the same org and name attached to the user groups,
+// else they are added to the user policies.
+type DraftPolicy struct {
+	Org        string      `json:"org,omitempty"`
+	Name       string      `json:"name,omitempty"`
+	Replace    bool        `json:"replace,omitempty"`
+	Statements []Statement `json:"statements,omitempty"`
+}
+
+// SimulationCheck is an action and full resource pair to evaluate in a simulation
+type SimulationCheck struct {
+	Action   string `json:"action,omitempty"`
+	Resource string `json:"resource,omitempty"`
+}
+
+// SimulationResult is the authorization decision for a SimulationCheck before and after applying draft policies
+type SimulationResult struct {
+	Action   string `json:"action,omitempty"`
+	Resource string `json:"resource,omitempty"`
+	Before   bool   `json:"before"`
+	After    bool   `json:"after"`
+}
+
+// SIMULATION API IMPLEMENTATION
+
+// SimulatePolicies returns the authorization decisions for a user before and after applying draft policies
+func (api WorkerAPI) SimulatePolicies(requestInfo RequestInfo, externalID string, drafts []DraftPolicy, checks []SimulationCheck) ([]SimulationResult, error) {
+	// Validate parameters
+	if !IsValidUserExternalID(externalID) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: externalId %v", externalID),
+		}
+	}
+	for _, draft := range drafts {
+		if draft.Replace {
+			if !IsValidOrg(draft.Org) {
+				return nil, &Error{
+					Code:    INVALID_PARAMETER_ERROR,
+					Message: fmt.Sprintf("Invalid parameter: org %v", draft.Org),
+				}
+			}
+			if !IsValidName(draft.Name) {
+				return nil, &Error{
+					Code:    INVALID_PARAMETER_ERROR,
+					Message: fmt.Sprintf("Invalid parameter: name %v", draft.Name),
+				}
+			}
+		}
+		if err := AreValidStatements(&draft.Statements); err != nil {
+			// Transform to API error
+			apiError := err.(*Error)
+			return nil, &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: apiError.Message,
+			}
+		}
+	}
+	if len(checks) < 1 || len(checks) > MAX_RESOURCE_NUMBER {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter Checks. Checks can't be empty or bigger than %v elements", MAX_RESOURCE_NUMBER),
+		}
+	}
+	for _, check := range checks {
+		if err := AreValidActions([]string{check.Action}); err != nil {
+			// Transform to API error
+			apiError := err.(*Error)
+			return nil, &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: apiError.Message,
+			}
+		}
+		if strings.Contains(check.Action, "*") {
+			return nil, &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter action %v. Action parameter can't be a prefix", check.Action),
+			}
+		}
+		if !isFullUrn(check.Resource) {
+			return nil, &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter resource %v. Urn prefixes are not allowed here", check.Resource),
+			}
+		}
+		if err := AreValidResources([]string{check.Resource}, RESOURCE_IAM); err != nil {
+			// Transform to API error
+			apiError := err.(*Error)
+			return nil, &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: apiError.Message,
+			}
+		}
+	}
+
+	// Retrieve user to simulate
+	user, err := api.UserRepo.GetUserByExternalID(externalID)
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		// User doesn't exist in DB
+		if dbError.Code == database.USER_NOT_FOUND {
+			return nil, &Error{
+				Code:    USER_BY_EXTERNAL_ID_NOT_FOUND,
+				Message: dbError.Message,
+			}
+		}
+		return nil, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	// Check restrictions
+	filteredUsers, err := api.GetAuthorizedUsers(requestInfo, user.Urn, AUTHZ_ACTION_SIMULATE_POLICIES, []User{*user})
+	if err != nil {
+		return nil, err
+	}
+	if len(filteredUsers) < 1 {
+		return nil, &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, user.Urn),
+		}
+	}
+
+	// Retrieve stored policies
+	policies, _, err := api.getPoliciesByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	draftPolicies, err := applyDraftPolicies(policies, drafts)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []SimulationResult{}
+	for _, check := range checks {
+		results = append(results, SimulationResult{
+			Action:   check.Action,
+			Resource: check.Resource,
+			Before:   isAllowedByPolicies(policies, check.Action, check.Resource, requestInfo.Context),
+			After:    isAllowedByPolicies(draftPolicies, check.Action, check.Resource, requestInfo.Context),
+		})
+	}
+
+	return results, nil
+}
+
+// PRIVATE HELPER METHODS
+
+// Returns a copy of the stored policies with the draft policies applied, or an error if a draft policy
+// to replace isn't one of the stored policies
+func applyDraftPolicies(policies []Policy, drafts []DraftPolicy) ([]Policy, error) {
+	draftPolicies := []Policy{}
+	replaced := make([]bool, len(drafts))
+	for _, policy := range policies {
+		for i, draft := range drafts {
+			if draft.Replace && draft.Org == policy.Org && draft.Name == policy.Name {
+				statements := draft.Statements
+				policy.Statements = &statements
+				replaced[i] = true
+			}
+		}
+		draftPolicies = append(draftPolicies, policy)
+	}
+	for i, draft := range drafts {
+		if draft.Replace && !replaced[i] {
+			return nil, &Error{
+				Code: INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter: policy with org %v and name %v to replace isn't attached to the user",
+					draft.Org, draft.Name),
+			}
+		}
+		if !draft.Replace {
+			statements := draft.Statements
+			draftPolicies = append(draftPolicies, Policy{
+				Org:        draft.Org,
+				Name:       draft.Name,
+				Statements: &statements,
+			})
+		}
+	}
+
+	return draftPolicies, nil
+}
+
+// Returns true if the policies allow the action over a full resource, in the same way that getAuthorizedResources does
+func isAllowedByPolicies(policies []Policy, action string, resource string, context RequestContext) bool {
+	restrictions := getRestrictionsByPolicies(policies, action, resource, context)
+	return len(filterResources([]Resource{ExternalResource{Urn: resource}}, restrictions)) > 0
+}
@@ -0,0 +1,409 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/database"
+)
+
+func TestWorkerAPI_SimulatePolicies(t *testing.T) {
+	readStatement := Statement{
+		Effect:    "allow",
+		Actions:   []string{"example:Read"},
+		Resources: []string{"urn:ews:example:instance1:resource/*"},
+	}
+	writeStatement := Statement{
+		Effect:    "allow",
+		Actions:   []string{"example:Write"},
+		Resources: []string{"urn:ews:example:instance1:resource/*"},
+	}
+	denyStatement := Statement{
+		Effect:    "deny",
+		Actions:   []string{"example:*"},
+		Resources: []string{"urn:ews:example:instance1:resource/private"},
+	}
+	checks := []SimulationCheck{
+		{
+			Action:   "example:Read",
+			Resource: "urn:ews:example:instance1:resource/public",
+		},
+		{
+			Action:   "example:Write",
+			Resource: "urn:ews:example:instance1:resource/private",
+		},
+	}
+	testcases := map[string]struct {
+		// Method args
+		requestInfo RequestInfo
+		externalID  string
+		drafts      []DraftPolicy
+		checks      []SimulationCheck
+		// Expected result
+		expectedResponse []SimulationResult
+		wantError        error
+		// Manager Results
+		getUserByExternalIDResult *User
+		getGroupsByUserIDResult   []TestUserGroupRelation
+		getAttachedPoliciesResult []TestPolicyGroupRelation
+		// Manager Errors
+		getUserByExternalIDError error
+	}{
+		"OkCaseAddDraft": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			externalID: "user1",
+			drafts: []DraftPolicy{
+				{
+					Org:        "example",
+					Name:       "draft",
+					Statements: []Statement{writeStatement},
+				},
+			},
+			checks: checks,
+			expectedResponse: []SimulationResult{
+				{
+					Action:   "example:Read",
+					Resource: "urn:ews:example:instance1:resource/public",
+					Before:   true,
+					After:    true,
+				},
+				{
+					Action:   "example:Write",
+					Resource: "urn:ews:example:instance1:resource/private",
+					Before:   false,
+					After:    true,
+				},
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "UserID",
+				ExternalID: "user1",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "user1"),
+			},
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID:   "GroupID",
+						Org:  "example",
+						Name: "group1",
+					},
+				},
+			},
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID:         "PolicyID",
+						Org:        "example",
+						Name:       "policy1",
+						Statements: &[]Statement{readStatement},
+					},
+				},
+			},
+		},
+		"OkCaseReplaceStoredPolicy": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			externalID: "user1",
+			drafts: []DraftPolicy{
+				{
+					Org:        "example",
+					Name:       "policy1",
+					Replace:    true,
+					Statements: []Statement{readStatement, writeStatement},
+				},
+			},
+			checks: checks,
+			expectedResponse: []SimulationResult{
+				{
+					Action:   "example:Read",
+					Resource: "urn:ews:example:instance1:resource/public",
+					Before:   true,
+					After:    true,
+				},
+				{
+					Action:   "example:Write",
+					Resource: "urn:ews:example:instance1:resource/private",
+					Before:   false,
+					After:    false,
+				},
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "UserID",
+				ExternalID: "user1",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "user1"),
+			},
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID:   "GroupID",
+						Org:  "example",
+						Name: "group1",
+					},
+				},
+			},
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID:         "PolicyID",
+						Org:        "example",
+						Name:       "policy1",
+						Statements: &[]Statement{readStatement},
+					},
+				},
+				{
+					Policy: &Policy{
+						ID:         "PolicyID2",
+						Org:        "example",
+						Name:       "policy2",
+						Statements: &[]Statement{denyStatement},
+					},
+				},
+			},
+		},
+		"OkCaseReplaceRemovesAccess": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			externalID: "user1",
+			drafts: []DraftPolicy{
+				{
+					Org:        "example",
+					Name:       "policy1",
+					Replace:    true,
+					Statements: []Statement{},
+				},
+			},
+			checks: checks[:1],
+			expectedResponse: []SimulationResult{
+				{
+					Action:   "example:Read",
+					Resource: "urn:ews:example:instance1:resource/public",
+					Before:   true,
+					After:    false,
+				},
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "UserID",
+				ExternalID: "user1",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "user1"),
+			},
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID:   "GroupID",
+						Org:  "example",
+						Name: "group1",
+					},
+				},
+			},
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID:         "PolicyID",
+						Org:        "example",
+						Name:       "policy1",
+						Statements: &[]Statement{readStatement},
+					},
+				},
+			},
+		},
+		"ErrorCaseReplaceNotAttachedPolicy": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			externalID: "user1",
+			drafts: []DraftPolicy{
+				{
+					Org:        "example",
+					Name:       "policy2",
+					Replace:    true,
+					Statements: []Statement{writeStatement},
+				},
+			},
+			checks: checks,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: policy with org example and name policy2 to replace isn't attached to the user",
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "UserID",
+				ExternalID: "user1",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "user1"),
+			},
+			getGroupsByUserIDResult: []TestUserGroupRelation{
+				{
+					Group: &Group{
+						ID:   "GroupID",
+						Org:  "example",
+						Name: "group1",
+					},
+				},
+			},
+			getAttachedPoliciesResult: []TestPolicyGroupRelation{
+				{
+					Policy: &Policy{
+						ID:         "PolicyID",
+						Org:        "example",
+						Name:       "policy1",
+						Statements: &[]Statement{readStatement},
+					},
+				},
+			},
+		},
+		"ErrorCaseInvalidExternalID": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			externalID: "*%~#@|",
+			checks:     checks,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: externalId *%~#@|",
+			},
+		},
+		"ErrorCaseInvalidDraftName": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			externalID: "user1",
+			drafts: []DraftPolicy{
+				{
+					Org:        "example",
+					Name:       "*%~#@|",
+					Replace:    true,
+					Statements: []Statement{readStatement},
+				},
+			},
+			checks: checks,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: name *%~#@|",
+			},
+		},
+		"ErrorCaseInvalidDraftStatement": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			externalID: "user1",
+			drafts: []DraftPolicy{
+				{
+					Org:  "example",
+					Name: "draft",
+					Statements: []Statement{
+						{
+							Effect:    "idontknow",
+							Actions:   []string{"example:Read"},
+							Resources: []string{"urn:ews:example:instance1:resource/*"},
+						},
+					},
+				},
+			},
+			checks: checks,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid effect: idontknow - Only 'allow' and 'deny' accepted",
+			},
+		},
+		"ErrorCaseEmptyChecks": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			externalID: "user1",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter Checks. Checks can't be empty or bigger than %v elements", MAX_RESOURCE_NUMBER),
+			},
+		},
+		"ErrorCaseActionPrefix": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			externalID: "user1",
+			checks: []SimulationCheck{
+				{
+					Action:   "example:*",
+					Resource: "urn:ews:example:instance1:resource/public",
+				},
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter action example:*. Action parameter can't be a prefix",
+			},
+		},
+		"ErrorCaseResourcePrefix": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			externalID: "user1",
+			checks: []SimulationCheck{
+				{
+					Action:   "example:Read",
+					Resource: "urn:ews:example:instance1:resource/*",
+				},
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter resource urn:ews:example:instance1:resource/*. Urn prefixes are not allowed here",
+			},
+		},
+		"ErrorCaseUserNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "admin",
+				Admin:      true,
+			},
+			externalID: "user1",
+			checks:     checks,
+			wantError: &Error{
+				Code:    USER_BY_EXTERNAL_ID_NOT_FOUND,
+				Message: "Error",
+			},
+			getUserByExternalIDError: &database.Error{
+				Code:    database.USER_NOT_FOUND,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseUnauthorized": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			externalID: "user1",
+			checks:     checks,
+			wantError: &Error{
+				Code: UNAUTHORIZED_RESOURCES_ERROR,
+				Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+					"123456", CreateUrn("", RESOURCE_USER, "/path/", "user1")),
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "UserID",
+				ExternalID: "user1",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "user1"),
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = test.getUserByExternalIDResult
+		testRepo.ArgsOut[GetUserByExternalIDMethod][1] = test.getUserByExternalIDError
+		testRepo.ArgsOut[GetGroupsByUserIDMethod][0] = test.getGroupsByUserIDResult
+		testRepo.ArgsOut[GetAttachedPoliciesMethod][0] = test.getAttachedPoliciesResult
+
+		results, err := testAPI.SimulatePolicies(test.requestInfo, test.externalID, test.drafts, test.checks)
+		checkMethodResponse(t, n, test.wantError, err, test.expectedResponse, results)
+	}
+}
@@ -0,0 +1,1160 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Tecsisa/foulkon/database"
+)
+
+const (
+	// Version of the state documents
+	STATE_VERSION = 1
+
+	// Import modes
+	IMPORT_MODE_CREATE  = "create"
+	IMPORT_MODE_UPSERT  = "upsert"
+	IMPORT_MODE_REPLACE = "replace"
+)
+
+// TYPE DEFINITIONS
+
+// State is a versioned document with the IAM entities and their relations. Entities are identified by their
+// external id or org and name, so the document can be imported in other workers. If Org is set, the document
+// only has the groups, policies, proxy resources and upstream pools of that org, the users related to them, and no
+// OIDC providers.
+type State struct {
+	Version        int                  `json:"version" yaml:"version"`
+	Org            string               `json:"org,omitempty" yaml:"org,omitempty"`
+	Users          []StateUser          `json:"users" yaml:"users"`
+	Groups         []StateGroup         `json:"groups" yaml:"groups"`
+	Policies       []StatePolicy        `json:"policies" yaml:"policies"`
+	ProxyResources []StateProxyResource `json:"proxyResources" yaml:"proxyResources"`
+	UpstreamPools  []StateUpstreamPool  `json:"upstreamPools" yaml:"upstreamPools"`
+	OidcProviders  []StateOidcProvider  `json:"oidcProviders" yaml:"oidcProviders"`
+}
+
+// StateUser is a user with the policies attached to it
+type StateUser struct {
+	ExternalID string           `json:"externalId" yaml:"externalId"`
+	Path       string           `json:"path" yaml:"path"`
+	Policies   []StatePolicyRef `json:"policies,omitempty" yaml:"policies,omitempty"`
+}
+
+// StatePolicyRef identifies a policy attached to a user
+type StatePolicyRef struct {
+	Org  string `json:"org" yaml:"org"`
+	Name string `json:"name" yaml:"name"`
+}
+
+// StateGroup is a group with its members, attached policies and child groups. Policies and
+// child groups belong to the group org.
+type StateGroup struct {
+	Org      string             `json:"org" yaml:"org"`
+	Name     string             `json:"name" yaml:"name"`
+	Path     string             `json:"path" yaml:"path"`
+	Members  []StateMember      `json:"members,omitempty" yaml:"members,omitempty"`
+	Policies []StateGroupPolicy `json:"policies,omitempty" yaml:"policies,omitempty"`
+	Groups   []string           `json:"groups,omitempty" yaml:"groups,omitempty"`
+}
+
+// StateMember is a group member, without expiration if ExpiresAt is nil
+type StateMember struct {
+	ExternalID string     `json:"externalId" yaml:"externalId"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty" yaml:"expiresAt,omitempty"`
+}
+
+// StateGroupPolicy is a policy attached to a group, without expiration if ExpiresAt is nil
+type StateGroupPolicy struct {
+	Name      string     `json:"name" yaml:"name"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty" yaml:"expiresAt,omitempty"`
+}
+
+// StatePolicy is a policy with its statements
+type StatePolicy struct {
+	Org        string      `json:"org" yaml:"org"`
+	Name       string      `json:"name" yaml:"name"`
+	Path       string      `json:"path" yaml:"path"`
+	Statements []Statement `json:"statements" yaml:"statements"`
+}
+
+// StateProxyResource is a proxy resource
+type StateProxyResource struct {
+	Org      string         `json:"org" yaml:"org"`
+	Name     string         `json:"name" yaml:"name"`
+	Path     string         `json:"path" yaml:"path"`
+	Resource ResourceEntity `json:"resource" yaml:"resource"`
+}
+
+// StateUpstreamPool is an upstream pool
+type StateUpstreamPool struct {
+	Org    string             `json:"org" yaml:"org"`
+	Name   string             `json:"name" yaml:"name"`
+	Path   string             `json:"path" yaml:"path"`
+	Config UpstreamPoolConfig `json:"config" yaml:"config"`
+}
+
+// StateOidcProvider is an OIDC provider with the names of its clients
+type StateOidcProvider struct {
+	Name      string   `json:"name" yaml:"name"`
+	Path      string   `json:"path" yaml:"path"`
+	IssuerURL string   `json:"issuerUrl" yaml:"issuerUrl"`
+	Clients   []string `json:"clients" yaml:"clients"`
+}
+
+// StateChange is a mutation made to import a state document, identified by the action and the urn of the
+// resource, like in the audit log. Related is the urn of the other resource of relation mutations.
+type StateChange struct {
+	Action  string `json:"action" yaml:"action"`
+	Urn     string `json:"urn" yaml:"urn"`
+	Related string `json:"related,omitempty" yaml:"related,omitempty"`
+}
+
+// ImportResult has the changes made to import a state document, or the changes that would be made in a dry run
+type ImportResult struct {
+	Mode    string        `json:"mode" yaml:"mode"`
+	DryRun  bool          `json:"dryRun" yaml:"dryRun"`
+	Changes []StateChange `json:"changes" yaml:"changes"`
+}
+
+// PlannedChange is a change planned to import a state document, with the resources of the document needed
+// to make it. Only the ones used by its action are set.
+type PlannedChange struct {
+	StateChange
+	User          *StateUser
+	UserPolicy    *StatePolicyRef
+	Group         *StateGroup
+	Member        *StateMember
+	GroupPolicy   *StateGroupPolicy
+	ChildGroup    string
+	Policy        *StatePolicy
+	ProxyResource *StateProxyResource
+	UpstreamPool  *StateUpstreamPool
+	OidcProvider  *StateOidcProvider
+}
+
+// STATE API IMPLEMENTATION
+
+func (api WorkerAPI) ExportState(requestInfo RequestInfo, org string) (*State, error) {
+	// Validate fields
+	if len(org) > 0 && !IsValidOrg(org) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: org %v", org),
+		}
+	}
+
+	// State is only available for admin users
+	if !requestInfo.Admin {
+		return nil, &Error{
+			Code:    UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to export the IAM state", requestInfo.Identifier),
+		}
+	}
+
+	state, err := api.exportState(org)
+	if err != nil {
+		return nil, err
+	}
+	if len(org) > 0 {
+		state.Users = relatedUsers(*state)
+	}
+
+	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("IAM state exported with org %v", org))
+	return state, nil
+}
+
+func (api WorkerAPI) ImportState(requestInfo RequestInfo, state State, mode string, dryRun bool) (*ImportResult, error) {
+	// Validate fields
+	if err := ValidateState(state, mode); err != nil {
+		return nil, err
+	}
+
+	// State is only available for admin users
+	if !requestInfo.Admin {
+		return nil, &Error{
+			Code:    UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to import the IAM state", requestInfo.Identifier),
+		}
+	}
+
+	// Changes are made to the current versions of the resources
+	requestInfo.IfMatch = ""
+
+	result := &ImportResult{
+		Mode:    mode,
+		DryRun:  dryRun,
+		Changes: []StateChange{},
+	}
+	err := api.withTx(func(txAPI WorkerAPI) error {
+		// Repositories may call this again if the transaction conflicts with a concurrent one, so only the changes
+		// of the last call are returned
+		result.Changes = result.Changes[:0]
+		current, err := txAPI.exportState(state.Org)
+		if err != nil {
+			return err
+		}
+		changes, err := PlanImport(*current, state, mode)
+		if err != nil {
+			return err
+		}
+		for _, change := range changes {
+			if !dryRun {
+				if err := txAPI.applyPlannedChange(requestInfo, change); err != nil {
+					return err
+				}
+			}
+			result.Changes = append(result.Changes, change.StateChange)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !dryRun {
+		LogOperation(requestInfo.RequestID, requestInfo.Identifier,
+			fmt.Sprintf("IAM state imported with mode %v and %v changes", mode, len(result.Changes)))
+	}
+	return result, nil
+}
+
+// PRIVATE HELPER METHODS
+
+// exportState retrieves the current state, only with the resources of the org and the policies of the org
+// attached to users if it isn't empty. All users are retrieved, even if they aren't related to the org.
+func (api WorkerAPI) exportState(org string) (*State, error) {
+	state := &State{
+		Version:        STATE_VERSION,
+		Org:            org,
+		Users:          []StateUser{},
+		Groups:         []StateGroup{},
+		Policies:       []StatePolicy{},
+		ProxyResources: []StateProxyResource{},
+		UpstreamPools:  []StateUpstreamPool{},
+		OidcProviders:  []StateOidcProvider{},
+	}
+
+	// Policies
+	policies, _, err := api.PolicyRepo.GetPoliciesFiltered(&Filter{Org: org})
+	if err != nil {
+		return nil, stateRepoError(err)
+	}
+	for _, p := range policies {
+		statements := []Statement{}
+		if p.Statements != nil {
+			statements = *p.Statements
+		}
+		state.Policies = append(state.Policies, StatePolicy{
+			Org:        p.Org,
+			Name:       p.Name,
+			Path:       p.Path,
+			Statements: statements,
+		})
+	}
+
+	// Groups with their relations
+	groups, _, err := api.GroupRepo.GetGroupsFiltered(&Filter{Org: org})
+	if err != nil {
+		return nil, stateRepoError(err)
+	}
+	for _, g := range groups {
+		group := StateGroup{
+			Org:  g.Org,
+			Name: g.Name,
+			Path: g.Path,
+		}
+		memberRelations, _, err := api.GroupRepo.GetGroupMembers(g.ID, &Filter{})
+		if err != nil {
+			return nil, stateRepoError(err)
+		}
+		for _, m := range memberRelations {
+			group.Members = append(group.Members, StateMember{
+				ExternalID: m.GetUser().ExternalID,
+				ExpiresAt:  m.GetExpiresAt(),
+			})
+		}
+		policyRelations, _, err := api.GroupRepo.GetAttachedPolicies(g.ID, &Filter{})
+		if err != nil {
+			return nil, stateRepoError(err)
+		}
+		for _, p := range policyRelations {
+			group.Policies = append(group.Policies, StateGroupPolicy{
+				Name:      p.GetPolicy().Name,
+				ExpiresAt: p.GetExpiresAt(),
+			})
+		}
+		childRelations, _, err := api.GroupRepo.GetChildGroups(g.ID, &Filter{})
+		if err != nil {
+			return nil, stateRepoError(err)
+		}
+		for _, c := range childRelations {
+			group.Groups = append(group.Groups, c.GetChild().Name)
+		}
+		sort.Slice(group.Members, func(i, j int) bool { return group.Members[i].ExternalID < group.Members[j].ExternalID })
+		sort.Slice(group.Policies, func(i, j int) bool { return group.Policies[i].Name < group.Policies[j].Name })
+		sort.Strings(group.Groups)
+		state.Groups = append(state.Groups, group)
+	}
+
+	// Users with their attached policies, only the org ones if org is set
+	users, _, err := api.UserRepo.GetUsersFiltered(&Filter{})
+	if err != nil {
+		return nil, stateRepoError(err)
+	}
+	for _, u := range users {
+		user := StateUser{
+			ExternalID: u.ExternalID,
+			Path:       u.Path,
+		}
+		policyRelations, _, err := api.UserRepo.GetAttachedUserPolicies(u.ID, &Filter{})
+		if err != nil {
+			return nil, stateRepoError(err)
+		}
+		for _, p := range policyRelations {
+			if len(org) > 0 && p.GetPolicy().Org != org {
+				continue
+			}
+			user.Policies = append(user.Policies, StatePolicyRef{
+				Org:  p.GetPolicy().Org,
+				Name: p.GetPolicy().Name,
+			})
+		}
+		sort.Slice(user.Policies, func(i, j int) bool {
+			return stateKey(user.Policies[i].Org, user.Policies[i].Name) < stateKey(user.Policies[j].Org, user.Policies[j].Name)
+		})
+		state.Users = append(state.Users, user)
+	}
+
+	// Proxy resources
+	proxyResources, _, err := api.ProxyRepo.GetProxyResources(&Filter{Org: org})
+	if err != nil {
+		return nil, stateRepoError(err)
+	}
+	for _, pr := range proxyResources {
+		state.ProxyResources = append(state.ProxyResources, StateProxyResource{
+			Org:      pr.Org,
+			Name:     pr.Name,
+			Path:     pr.Path,
+			Resource: pr.Resource,
+		})
+	}
+
+	// Upstream pools
+	pools, _, err := api.ProxyRepo.GetUpstreamPools(&Filter{Org: org})
+	if err != nil {
+		return nil, stateRepoError(err)
+	}
+	for _, p := range pools {
+		state.UpstreamPools = append(state.UpstreamPools, StateUpstreamPool{
+			Org:    p.Org,
+			Name:   p.Name,
+			Path:   p.Path,
+			Config: p.Config,
+		})
+	}
+
+	// OIDC providers don't belong to any org
+	if len(org) == 0 {
+		oidcProviders, _, err := api.AuthOidcRepo.GetOidcProvidersFiltered(&Filter{})
+		if err != nil {
+			return nil, stateRepoError(err)
+		}
+		for _, op := range oidcProviders {
+			clients := []string{}
+			for _, c := range op.OidcClients {
+				clients = append(clients, c.Name)
+			}
+			sort.Strings(clients)
+			state.OidcProviders = append(state.OidcProviders, StateOidcProvider{
+				Name:      op.Name,
+				Path:      op.Path,
+				IssuerURL: op.IssuerURL,
+				Clients:   clients,
+			})
+		}
+	}
+
+	sort.Slice(state.Users, func(i, j int) bool { return state.Users[i].ExternalID < state.Users[j].ExternalID })
+	sort.Slice(state.Groups, func(i, j int) bool {
+		return stateKey(state.Groups[i].Org, state.Groups[i].Name) < stateKey(state.Groups[j].Org, state.Groups[j].Name)
+	})
+	sort.Slice(state.Policies, func(i, j int) bool {
+		return stateKey(state.Policies[i].Org, state.Policies[i].Name) < stateKey(state.Policies[j].Org, state.Policies[j].Name)
+	})
+	sort.Slice(state.ProxyResources, func(i, j int) bool {
+		return stateKey(state.ProxyResources[i].Org, state.ProxyResources[i].Name) <
+			stateKey(state.ProxyResources[j].Org, state.ProxyResources[j].Name)
+	})
+	sort.Slice(state.UpstreamPools, func(i, j int) bool {
+		return stateKey(state.UpstreamPools[i].Org, state.UpstreamPools[i].Name) <
+			stateKey(state.UpstreamPools[j].Org, state.UpstreamPools[j].Name)
+	})
+	sort.Slice(state.OidcProviders, func(i, j int) bool { return state.OidcProviders[i].Name < state.OidcProviders[j].Name })
+
+	return state, nil
+}
+
+// ValidateState checks the import mode, and that the state document is valid and all its relations are
+// between resources of the document
+func ValidateState(state State, mode string) error {
+	switch mode {
+	case IMPORT_MODE_CREATE, IMPORT_MODE_UPSERT, IMPORT_MODE_REPLACE:
+	default:
+		return &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: mode %v", mode),
+		}
+	}
+	if state.Version != STATE_VERSION {
+		return &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: version %v", state.Version),
+		}
+	}
+	if len(state.Org) > 0 && !IsValidOrg(state.Org) {
+		return &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: org %v", state.Org),
+		}
+	}
+	// validOrg checks the org of a resource, that must be the document org if it is set
+	validOrg := func(org string) bool {
+		return IsValidOrg(org) && (len(state.Org) == 0 || org == state.Org)
+	}
+
+	policies := map[string]bool{}
+	for _, p := range state.Policies {
+		if !validOrg(p.Org) || !IsValidName(p.Name) || !IsValidPath(p.Path) {
+			return invalidStateResource(RESOURCE_POLICY, stateKey(p.Org, p.Name), p.Path)
+		}
+		if policies[stateKey(p.Org, p.Name)] {
+			return duplicatedStateResource(RESOURCE_POLICY, stateKey(p.Org, p.Name))
+		}
+		policies[stateKey(p.Org, p.Name)] = true
+		statements := p.Statements
+		if err := AreValidStatements(&statements); err != nil {
+			return err
+		}
+	}
+
+	users := map[string]bool{}
+	for _, u := range state.Users {
+		if !IsValidUserExternalID(u.ExternalID) || !IsValidPath(u.Path) {
+			return invalidStateResource(RESOURCE_USER, u.ExternalID, u.Path)
+		}
+		if users[u.ExternalID] {
+			return duplicatedStateResource(RESOURCE_USER, u.ExternalID)
+		}
+		users[u.ExternalID] = true
+		attached := map[string]bool{}
+		for _, p := range u.Policies {
+			if !policies[stateKey(p.Org, p.Name)] || attached[stateKey(p.Org, p.Name)] {
+				return invalidStateRelation(RESOURCE_USER, u.ExternalID, RESOURCE_POLICY, stateKey(p.Org, p.Name))
+			}
+			attached[stateKey(p.Org, p.Name)] = true
+		}
+	}
+
+	groups := map[string]bool{}
+	for _, g := range state.Groups {
+		if !validOrg(g.Org) || !IsValidName(g.Name) || !IsValidPath(g.Path) {
+			return invalidStateResource(RESOURCE_GROUP, stateKey(g.Org, g.Name), g.Path)
+		}
+		if groups[stateKey(g.Org, g.Name)] {
+			return duplicatedStateResource(RESOURCE_GROUP, stateKey(g.Org, g.Name))
+		}
+		groups[stateKey(g.Org, g.Name)] = true
+	}
+	for _, g := range state.Groups {
+		related := map[string]bool{}
+		for _, m := range g.Members {
+			if !users[m.ExternalID] || related[stateKey(RESOURCE_USER, m.ExternalID)] {
+				return invalidStateRelation(RESOURCE_GROUP, stateKey(g.Org, g.Name), RESOURCE_USER, m.ExternalID)
+			}
+			related[stateKey(RESOURCE_USER, m.ExternalID)] = true
+		}
+		for _, p := range g.Policies {
+			if !policies[stateKey(g.Org, p.Name)] || related[stateKey(RESOURCE_POLICY, p.Name)] {
+				return invalidStateRelation(RESOURCE_GROUP, stateKey(g.Org, g.Name), RESOURCE_POLICY, stateKey(g.Org, p.Name))
+			}
+			related[stateKey(RESOURCE_POLICY, p.Name)] = true
+		}
+		for _, child := range g.Groups {
+			if !groups[stateKey(g.Org, child)] || related[stateKey(RESOURCE_GROUP, child)] || child == g.Name {
+				return invalidStateRelation(RESOURCE_GROUP, stateKey(g.Org, g.Name), RESOURCE_GROUP, stateKey(g.Org, child))
+			}
+			related[stateKey(RESOURCE_GROUP, child)] = true
+		}
+	}
+
+	proxyResources := map[string]bool{}
+	for _, pr := range state.ProxyResources {
+		if !validOrg(pr.Org) || !IsValidName(pr.Name) || !IsValidPath(pr.Path) {
+			return invalidStateResource(RESOURCE_PROXY, stateKey(pr.Org, pr.Name), pr.Path)
+		}
+		if proxyResources[stateKey(pr.Org, pr.Name)] {
+			return duplicatedStateResource(RESOURCE_PROXY, stateKey(pr.Org, pr.Name))
+		}
+		proxyResources[stateKey(pr.Org, pr.Name)] = true
+		resource := pr.Resource
+		if err := IsValidProxyResource(&resource); err != nil {
+			return err
+		}
+	}
+
+	upstreamPools := map[string]bool{}
+	for _, p := range state.UpstreamPools {
+		if !validOrg(p.Org) || !IsValidName(p.Name) || !IsValidPath(p.Path) {
+			return invalidStateResource(RESOURCE_UPSTREAM_POOL, stateKey(p.Org, p.Name), p.Path)
+		}
+		if upstreamPools[stateKey(p.Org, p.Name)] {
+			return duplicatedStateResource(RESOURCE_UPSTREAM_POOL, stateKey(p.Org, p.Name))
+		}
+		upstreamPools[stateKey(p.Org, p.Name)] = true
+		config := p.Config
+		if err := IsValidUpstreamPoolConfig(&config); err != nil {
+			return err
+		}
+	}
+
+	oidcProviders := map[string]bool{}
+	for _, op := range state.OidcProviders {
+		if len(state.Org) > 0 || !IsValidName(op.Name) || !IsValidPath(op.Path) {
+			return invalidStateResource(RESOURCE_AUTH_OIDC_PROVIDER, op.Name, op.Path)
+		}
+		if oidcProviders[op.Name] {
+			return duplicatedStateResource(RESOURCE_AUTH_OIDC_PROVIDER, op.Name)
+		}
+		oidcProviders[op.Name] = true
+		if err := AreValidOidcClientNames(op.Clients); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PlanImport returns the changes needed to import the desired state over the current one. Removals are made
+// first, then resources are created or updated, and finally relations are added. Resources and relations
+// missing in the desired state are only removed in replace mode. Users are only removed if the state
+// isn't restricted to an org, otherwise their attachments to org policies are detached.
+func PlanImport(current State, desired State, mode string) ([]PlannedChange, error) {
+	replace := mode == IMPORT_MODE_REPLACE
+	removals := []PlannedChange{}
+	upserts := []PlannedChange{}
+	additions := []PlannedChange{}
+
+	policyPaths := map[string]string{}
+	for _, p := range current.Policies {
+		policyPaths[stateKey(p.Org, p.Name)] = p.Path
+	}
+	for _, p := range desired.Policies {
+		policyPaths[stateKey(p.Org, p.Name)] = p.Path
+	}
+	userPaths := map[string]string{}
+	for _, u := range current.Users {
+		userPaths[u.ExternalID] = u.Path
+	}
+	for _, u := range desired.Users {
+		userPaths[u.ExternalID] = u.Path
+	}
+	groupPaths := map[string]string{}
+	for _, g := range current.Groups {
+		groupPaths[stateKey(g.Org, g.Name)] = g.Path
+	}
+	for _, g := range desired.Groups {
+		groupPaths[stateKey(g.Org, g.Name)] = g.Path
+	}
+	policyUrn := func(org string, name string) string {
+		return CreateUrn(org, RESOURCE_POLICY, policyPaths[stateKey(org, name)], name)
+	}
+	userUrn := func(externalID string) string {
+		return CreateUrn("", RESOURCE_USER, userPaths[externalID], externalID)
+	}
+	groupUrn := func(org string, name string) string {
+		return CreateUrn(org, RESOURCE_GROUP, groupPaths[stateKey(org, name)], name)
+	}
+
+	// Policies
+	currentPolicies := map[string]StatePolicy{}
+	for _, p := range current.Policies {
+		currentPolicies[stateKey(p.Org, p.Name)] = p
+	}
+	desiredPolicies := map[string]bool{}
+	for _, p := range desired.Policies {
+		p := p
+		desiredPolicies[stateKey(p.Org, p.Name)] = true
+		old, ok := currentPolicies[stateKey(p.Org, p.Name)]
+		switch {
+		case !ok:
+			upserts = append(upserts, PlannedChange{
+				StateChange: StateChange{Action: POLICY_ACTION_CREATE_POLICY, Urn: policyUrn(p.Org, p.Name)},
+				Policy:      &p,
+			})
+		case mode == IMPORT_MODE_CREATE:
+			return nil, &Error{
+				Code:    POLICY_ALREADY_EXIST,
+				Message: fmt.Sprintf("Unable to create policy, policy with org %v and name %v already exist", p.Org, p.Name),
+			}
+		case old.Path != p.Path || !sameJSON(old.Statements, p.Statements):
+			upserts = append(upserts, PlannedChange{
+				StateChange: StateChange{Action: POLICY_ACTION_UPDATE_POLICY, Urn: CreateUrn(old.Org, RESOURCE_POLICY, old.Path, old.Name)},
+				Policy:      &p,
+			})
+		}
+	}
+	if replace {
+		for _, p := range current.Policies {
+			p := p
+			if desiredPolicies[stateKey(p.Org, p.Name)] {
+				continue
+			}
+			removals = append(removals, PlannedChange{
+				StateChange: StateChange{Action: POLICY_ACTION_DELETE_POLICY, Urn: policyUrn(p.Org, p.Name)},
+				Policy:      &p,
+			})
+		}
+	}
+
+	// Users with their attached policies
+	currentUsers := map[string]StateUser{}
+	for _, u := range current.Users {
+		currentUsers[u.ExternalID] = u
+	}
+	desiredUsers := map[string]bool{}
+	for _, u := range desired.Users {
+		u := u
+		desiredUsers[u.ExternalID] = true
+		old, ok := currentUsers[u.ExternalID]
+		switch {
+		case !ok:
+			upserts = append(upserts, PlannedChange{
+				StateChange: StateChange{Action: USER_ACTION_CREATE_USER, Urn: userUrn(u.ExternalID)},
+				User:        &u,
+			})
+		case mode == IMPORT_MODE_CREATE:
+			return nil, &Error{
+				Code:    USER_ALREADY_EXIST,
+				Message: fmt.Sprintf("Unable to create user, user with externalId %v already exist", u.ExternalID),
+			}
+		case old.Path != u.Path:
+			upserts = append(upserts, PlannedChange{
+				StateChange: StateChange{Action: USER_ACTION_UPDATE_USER, Urn: CreateUrn("", RESOURCE_USER, old.Path, old.ExternalID)},
+				User:        &u,
+			})
+		}
+	}
+	for _, u := range desired.Users {
+		u := u
+		oldPolicies := map[string]bool{}
+		for _, p := range currentUsers[u.ExternalID].Policies {
+			oldPolicies[stateKey(p.Org, p.Name)] = true
+		}
+		attached := map[string]bool{}
+		for _, p := range u.Policies {
+			p := p
+			attached[stateKey(p.Org, p.Name)] = true
+			if oldPolicies[stateKey(p.Org, p.Name)] {
+				continue
+			}
+			additions = append(additions, PlannedChange{
+				StateChange: StateChange{Action: USER_ACTION_ATTACH_USER_POLICY, Urn: userUrn(u.ExternalID), Related: policyUrn(p.Org, p.Name)},
+				User:        &u,
+				UserPolicy:  &p,
+			})
+		}
+		if replace {
+			for _, p := range currentUsers[u.ExternalID].Policies {
+				if attached[stateKey(p.Org, p.Name)] || !desiredPolicies[stateKey(p.Org, p.Name)] {
+					// Removed policies are detached with them
+					continue
+				}
+				removals = append(removals, detachUserPolicyChange(u, p, userUrn, policyUrn))
+			}
+		}
+	}
+	if replace {
+		for _, u := range current.Users {
+			u := u
+			if desiredUsers[u.ExternalID] {
+				continue
+			}
+			if len(desired.Org) > 0 {
+				// Users don't belong to the org, so they are kept without the org policies
+				for _, p := range u.Policies {
+					if desiredPolicies[stateKey(p.Org, p.Name)] {
+						removals = append(removals, detachUserPolicyChange(u, p, userUrn, policyUrn))
+					}
+				}
+				continue
+			}
+			removals = append(removals, PlannedChange{
+				StateChange: StateChange{Action: USER_ACTION_DELETE_USER, Urn: userUrn(u.ExternalID)},
+				User:        &u,
+			})
+		}
+	}
+
+	// Groups with their relations
+	currentGroups := map[string]StateGroup{}
+	for _, g := range current.Groups {
+		currentGroups[stateKey(g.Org, g.Name)] = g
+	}
+	desiredGroups := map[string]bool{}
+	for _, g := range desired.Groups {
+		desiredGroups[stateKey(g.Org, g.Name)] = true
+	}
+	for _, g := range desired.Groups {
+		g := g
+		old, ok := currentGroups[stateKey(g.Org, g.Name)]
+		switch {
+		case !ok:
+			upserts = append(upserts, PlannedChange{
+				StateChange: StateChange{Action: GROUP_ACTION_CREATE_GROUP, Urn: groupUrn(g.Org, g.Name)},
+				Group:       &g,
+			})
+		case mode == IMPORT_MODE_CREATE:
+			return nil, &Error{
+				Code:    GROUP_ALREADY_EXIST,
+				Message: fmt.Sprintf("Unable to create group, group with org %v and name %v already exists", g.Org, g.Name),
+			}
+		case old.Path != g.Path:
+			upserts = append(upserts, PlannedChange{
+				StateChange: StateChange{Action: GROUP_ACTION_UPDATE_GROUP, Urn: CreateUrn(old.Org, RESOURCE_GROUP, old.Path, old.Name)},
+				Group:       &g,
+			})
+		}
+
+		// Members
+		currentMembers := map[string]StateMember{}
+		for _, m := range old.Members {
+			currentMembers[m.ExternalID] = m
+		}
+		members := map[string]bool{}
+		for _, m := range g.Members {
+			m := m
+			members[m.ExternalID] = true
+			oldMember, ok := currentMembers[m.ExternalID]
+			if ok && sameExpiration(oldMember.ExpiresAt, m.ExpiresAt) {
+				continue
+			}
+			if ok {
+				// Expiration is changed adding the member again
+				removals = append(removals, removeMemberChange(g, oldMember, groupUrn, userUrn))
+			}
+			additions = append(additions, PlannedChange{
+				StateChange: StateChange{Action: GROUP_ACTION_ADD_MEMBER, Urn: groupUrn(g.Org, g.Name), Related: userUrn(m.ExternalID)},
+				Group:       &g,
+				Member:      &m,
+			})
+		}
+		if replace {
+			for _, m := range old.Members {
+				if members[m.ExternalID] || (!desiredUsers[m.ExternalID] && len(desired.Org) == 0) {
+					// Removed users leave their groups
+					continue
+				}
+				removals = append(removals, removeMemberChange(g, m, groupUrn, userUrn))
+			}
+		}
+
+		// Attached policies
+		currentGroupPolicies := map[string]StateGroupPolicy{}
+		for _, p := range old.Policies {
+			currentGroupPolicies[p.Name] = p
+		}
+		attached := map[string]bool{}
+		for _, p := range g.Policies {
+			p := p
+			attached[p.Name] = true
+			oldPolicy, ok := currentGroupPolicies[p.Name]
+			if ok && sameExpiration(oldPolicy.ExpiresAt, p.ExpiresAt) {
+				continue
+			}
+			if ok {
+				// Expiration is changed attaching the policy again
+				removals = append(removals, detachGroupPolicyChange(g, oldPolicy, groupUrn, policyUrn))
+			}
+			additions = append(additions, PlannedChange{
+				StateChange: StateChange{Action: GROUP_ACTION_ATTACH_GROUP_POLICY, Urn: groupUrn(g.Org, g.Name), Related: policyUrn(g.Org, p.Name)},
+				Group:       &g,
+				GroupPolicy: &p,
+			})
+		}
+		if replace {
+			for _, p := range old.Policies {
+				if attached[p.Name] || !desiredPolicies[stateKey(g.Org, p.Name)] {
+					// Removed policies are detached with them
+					continue
+				}
+				removals = append(removals, detachGroupPolicyChange(g, p, groupUrn, policyUrn))
+			}
+		}
+
+		// Child groups
+		currentChildren := map[string]bool{}
+		for _, child := range old.Groups {
+			currentChildren[child] = true
+		}
+		children := map[string]bool{}
+		for _, child := range g.Groups {
+			child := child
+			children[child] = true
+			if currentChildren[child] {
+				continue
+			}
+			additions = append(additions, PlannedChange{
+				StateChange: StateChange{Action: GROUP_ACTION_ADD_CHILD_GROUP, Urn: groupUrn(g.Org, g.Name), Related: groupUrn(g.Org, child)},
+				Group:       &g,
+				ChildGroup:  child,
+			})
+		}
+		if replace {
+			for _, child := range old.Groups {
+				child := child
+				if children[child] || !desiredGroups[stateKey(g.Org, child)] {
+					// Removed groups leave their parents
+					continue
+				}
+				removals = append(removals, PlannedChange{
+					StateChange: StateChange{Action: GROUP_ACTION_REMOVE_CHILD_GROUP, Urn: groupUrn(g.Org, g.Name), Related: groupUrn(g.Org, child)},
+					Group:       &g,
+					ChildGroup:  child,
+				})
+			}
+		}
+	}
+	if replace {
+		for _, g := range current.Groups {
+			g := g
+			if desiredGroups[stateKey(g.Org, g.Name)] {
+				continue
+			}
+			removals = append(removals, PlannedChange{
+				StateChange: StateChange{Action: GROUP_ACTION_DELETE_GROUP, Urn: groupUrn(g.Org, g.Name)},
+				Group:       &g,
+			})
+		}
+	}
+
+	// Upstream pools are created before the proxy resources that use them, and removed
+	// once proxy resources don't use them
+	upstreamRemovals := []PlannedChange{}
+	currentUpstreamPools := map[string]StateUpstreamPool{}
+	for _, p := range current.UpstreamPools {
+		currentUpstreamPools[stateKey(p.Org, p.Name)] = p
+	}
+	desiredUpstreamPools := map[string]bool{}
+	for _, p := range desired.UpstreamPools {
+		p := p
+		desiredUpstreamPools[stateKey(p.Org, p.Name)] = true
+		old, ok := currentUpstreamPools[stateKey(p.Org, p.Name)]
+		switch {
+		case !ok:
+			upserts = append(upserts, PlannedChange{
+				StateChange:  StateChange{Action: UPSTREAM_ACTION_CREATE_POOL, Urn: CreateUrn(p.Org, RESOURCE_UPSTREAM_POOL, p.Path, p.Name)},
+				UpstreamPool: &p,
+			})
+		case mode == IMPORT_MODE_CREATE:
+			return nil, &Error{
+				Code: UPSTREAM_POOL_ALREADY_EXIST,
+				Message: fmt.Sprintf("Unable to create upstream pool, upstream pool with org %v and name %v already exist",
+					p.Org, p.Name),
+			}
+		case old.Path != p.Path || !sameJSON(old.Config, normalizeUpstreamPoolConfig(p.Config)):
+			upserts = append(upserts, PlannedChange{
+				StateChange:  StateChange{Action: UPSTREAM_ACTION_UPDATE_POOL, Urn: CreateUrn(old.Org, RESOURCE_UPSTREAM_POOL, old.Path, old.Name)},
+				UpstreamPool: &p,
+			})
+		}
+	}
+	if replace {
+		for _, p := range current.UpstreamPools {
+			p := p
+			if desiredUpstreamPools[stateKey(p.Org, p.Name)] {
+				continue
+			}
+			upstreamRemovals = append(upstreamRemovals, PlannedChange{
+				StateChange:  StateChange{Action: UPSTREAM_ACTION_DELETE_POOL, Urn: CreateUrn(p.Org, RESOURCE_UPSTREAM_POOL, p.Path, p.Name)},
+				UpstreamPool: &p,
+			})
+		}
+	}
+
+	// Proxy resources
+	currentProxyResources := map[string]StateProxyResource{}
+	for _, pr := range current.ProxyResources {
+		currentProxyResources[stateKey(pr.Org, pr.Name)] = pr
+	}
+	desiredProxyResources := map[string]bool{}
+	for _, pr := range desired.ProxyResources {
+		pr := pr
+		desiredProxyResources[stateKey(pr.Org, pr.Name)] = true
+		old, ok := currentProxyResources[stateKey(pr.Org, pr.Name)]
+		switch {
+		case !ok:
+			upserts = append(upserts, PlannedChange{
+				StateChange:   StateChange{Action: PROXY_ACTION_CREATE_RESOURCE, Urn: CreateUrn(pr.Org, RESOURCE_PROXY, pr.Path, pr.Name)},
+				ProxyResource: &pr,
+			})
+		case mode == IMPORT_MODE_CREATE:
+			return nil, &Error{
+				Code: PROXY_RESOURCE_ALREADY_EXIST,
+				Message: fmt.Sprintf("Unable to create proxy resource, proxy resource with org %v and name %v already exist",
+					pr.Org, pr.Name),
+			}
+		case old.Path != pr.Path || !sameJSON(old.Resource, normalizeResourceEntity(pr.Resource)):
+			upserts = append(upserts, PlannedChange{
+				StateChange:   StateChange{Action: PROXY_ACTION_UPDATE_RESOURCE, Urn: CreateUrn(old.Org, RESOURCE_PROXY, old.Path, old.Name)},
+				ProxyResource: &pr,
+			})
+		}
+	}
+	if replace {
+		for _, pr := range current.ProxyResources {
+			pr := pr
+			if desiredProxyResources[stateKey(pr.Org, pr.Name)] {
+				continue
+			}
+			removals = append(removals, PlannedChange{
+				StateChange:   StateChange{Action: PROXY_ACTION_DELETE_RESOURCE, Urn: CreateUrn(pr.Org, RESOURCE_PROXY, pr.Path, pr.Name)},
+				ProxyResource: &pr,
+			})
+		}
+	}
+
+	// OIDC providers
+	currentOidcProviders := map[string]StateOidcProvider{}
+	for _, op := range current.OidcProviders {
+		currentOidcProviders[op.Name] = op
+	}
+	desiredOidcProviders := map[string]bool{}
+	for _, op := range desired.OidcProviders {
+		op := op
+		desiredOidcProviders[op.Name] = true
+		old, ok := currentOidcProviders[op.Name]
+		switch {
+		case !ok:
+			upserts = append(upserts, PlannedChange{
+				StateChange:  StateChange{Action: AUTH_OIDC_ACTION_CREATE_PROVIDER, Urn: CreateUrn("", RESOURCE_AUTH_OIDC_PROVIDER, op.Path, op.Name)},
+				OidcProvider: &op,
+			})
+		case mode == IMPORT_MODE_CREATE:
+			return nil, &Error{
+				Code:    AUTH_OIDC_PROVIDER_ALREADY_EXIST,
+				Message: fmt.Sprintf("Unable to create OIDC provider, OIDC provider with name %v already exist", op.Name),
+			}
+		case old.Path != op.Path || old.IssuerURL != op.IssuerURL || !sameNames(old.Clients, op.Clients):
+			upserts = append(upserts, PlannedChange{
+				StateChange:  StateChange{Action: AUTH_OIDC_ACTION_UPDATE_PROVIDER, Urn: CreateUrn("", RESOURCE_AUTH_OIDC_PROVIDER, old.Path, old.Name)},
+				OidcProvider: &op,
+			})
+		}
+	}
+	if replace {
+		for _, op := range current.OidcProviders {
+			op := op
+			if desiredOidcProviders[op.Name] {
+				continue
+			}
+			removals = append(removals, PlannedChange{
+				StateChange:  StateChange{Action: AUTH_OIDC_ACTION_DELETE_PROVIDER, Urn: CreateUrn("", RESOURCE_AUTH_OIDC_PROVIDER, op.Path, op.Name)},
+				OidcProvider: &op,
+			})
+		}
+	}
+
+	changes := append(removals, upserts...)
+	changes = append(changes, upstreamRemovals...)
+	return append(changes, additions...), nil
+}
+
+// applyPlannedChange makes a change planned to import a state document
+func (api WorkerAPI) applyPlannedChange(requestInfo RequestInfo, change PlannedChange) error {
+	var err error
+	switch change.Action {
+	case POLICY_ACTION_CREATE_POLICY:
+		p := change.Policy
+		_, err = api.addPolicy(requestInfo, p.Name, p.Path, p.Org, p.Statements)
+	case POLICY_ACTION_UPDATE_POLICY:
+		p := change.Policy
+		_, err = api.updatePolicy(requestInfo, p.Org, p.Name, p.Name, p.Path, p.Statements)
+	case POLICY_ACTION_DELETE_POLICY:
+		err = api.removePolicy(requestInfo, change.Policy.Org, change.Policy.Name)
+	case USER_ACTION_CREATE_USER:
+		_, err = api.addUser(requestInfo, change.User.ExternalID, change.User.Path)
+	case USER_ACTION_UPDATE_USER:
+		_, err = api.updateUser(requestInfo, change.User.ExternalID, change.User.Path)
+	case USER_ACTION_DELETE_USER:
+		err = api.removeUser(requestInfo, change.User.ExternalID)
+	case USER_ACTION_ATTACH_USER_POLICY:
+		err = api.attachPolicyToUser(requestInfo, change.User.ExternalID, change.UserPolicy.Org, change.UserPolicy.Name)
+	case USER_ACTION_DETACH_USER_POLICY:
+		err = api.detachPolicyFromUser(requestInfo, change.User.ExternalID, change.UserPolicy.Org, change.UserPolicy.Name)
+	case GROUP_ACTION_CREATE_GROUP:
+		g := change.Group
+		_, err = api.addGroup(requestInfo, g.Org, g.Name, g.Path)
+	case GROUP_ACTION_UPDATE_GROUP:
+		g := change.Group
+		_, err = api.updateGroup(requestInfo, g.Org, g.Name, g.Name, g.Path)
+	case GROUP_ACTION_DELETE_GROUP:
+		err = api.removeGroup(requestInfo, change.Group.Org, change.Group.Name)
+	case GROUP_ACTION_ADD_MEMBER:
+		g := change.Group
+		err = api.addMember(requestInfo, change.Member.ExternalID, g.Name, g.Org, change.Member.ExpiresAt)
+	case GROUP_ACTION_REMOVE_MEMBER:
+		g := change.Group
+		err = api.removeMember(requestInfo, change.Member.ExternalID, g.Name, g.Org)
+	case GROUP_ACTION_ATTACH_GROUP_POLICY:
+		g := change.Group
+		err = api.attachPolicyToGroup(requestInfo, g.Org, g.Name, change.GroupPolicy.Name, change.GroupPolicy.ExpiresAt)
+	case GROUP_ACTION_DETACH_GROUP_POLICY:
+		g := change.Group
+		err = api.detachPolicyToGroup(requestInfo, g.Org, g.Name, change.GroupPolicy.Name)
+	case GROUP_ACTION_ADD_CHILD_GROUP:
+		err = api.addChildGroup(requestInfo, change.Group.Org, change.Group.Name, change.ChildGroup)
+	case GROUP_ACTION_REMOVE_CHILD_GROUP:
+		err = api.removeChildGroup(requestInfo, change.Group.Org, change.Group.Name, change.ChildGroup)
+	case UPSTREAM_ACTION_CREATE_POOL:
+		p := change.UpstreamPool
+		_, err = api.addUpstreamPool(requestInfo, p.Name, p.Org, p.Path, p.Config)
+	case UPSTREAM_ACTION_UPDATE_POOL:
+		p := change.UpstreamPool
+		_, err = api.updateUpstreamPool(requestInfo, p.Org, p.Name, p.Name, p.Path, p.Config)
+	case UPSTREAM_ACTION_DELETE_POOL:
+		err = api.removeUpstreamPool(requestInfo, change.UpstreamPool.Org, change.UpstreamPool.Name)
+	case PROXY_ACTION_CREATE_RESOURCE:
+		pr := change.ProxyResource
+		_, err = api.addProxyResource(requestInfo, pr.Name, pr.Org, pr.Path, pr.Resource)
+	case PROXY_ACTION_UPDATE_RESOURCE:
+		pr := change.ProxyResource
+		_, err = api.updateProxyResource(requestInfo, pr.Org, pr.Name, pr.Name, pr.Path, pr.Resource)
+	case PROXY_ACTION_DELETE_RESOURCE:
+		err = api.removeProxyResource(requestInfo, change.ProxyResource.Org, change.ProxyResource.Name)
+	case AUTH_OIDC_ACTION_CREATE_PROVIDER:
+		op := change.OidcProvider
+		_, err = api.addOidcProvider(requestInfo, op.Name, op.Path, op.IssuerURL, op.Clients)
+	case AUTH_OIDC_ACTION_UPDATE_PROVIDER:
+		op := change.OidcProvider
+		_, err = api.updateOidcProvider(requestInfo, op.Name, op.Name, op.Path, op.IssuerURL, op.Clients)
+	case AUTH_OIDC_ACTION_DELETE_PROVIDER:
+		err = api.removeOidcProvider(requestInfo, change.OidcProvider.Name)
+	}
+	return err
+}
+
+// relatedUsers returns the users of the state that are members of its groups or have policies attached
+func relatedUsers(state State) []StateUser {
+	members := map[string]bool{}
+	for _, g := range state.Groups {
+		for _, m := range g.Members {
+			members[m.ExternalID] = true
+		}
+	}
+	users := []StateUser{}
+	for _, u := range state.Users {
+		if members[u.ExternalID] || len(u.Policies) > 0 {
+			users = append(users, u)
+		}
+	}
+	return users
+}
+
+func removeMemberChange(g StateGroup, m StateMember, groupUrn func(string, string) string,
+	userUrn func(string) string) PlannedChange {
+	return PlannedChange{
+		StateChange: StateChange{Action: GROUP_ACTION_REMOVE_MEMBER, Urn: groupUrn(g.Org, g.Name), Related: userUrn(m.ExternalID)},
+		Group:       &g,
+		Member:      &m,
+	}
+}
+
+func detachGroupPolicyChange(g StateGroup, p StateGroupPolicy, groupUrn func(string, string) string,
+	policyUrn func(string, string) string) PlannedChange {
+	return PlannedChange{
+		StateChange: StateChange{Action: GROUP_ACTION_DETACH_GROUP_POLICY, Urn: groupUrn(g.Org, g.Name), Related: policyUrn(g.Org, p.Name)},
+		Group:       &g,
+		GroupPolicy: &p,
+	}
+}
+
+func detachUserPolicyChange(u StateUser, p StatePolicyRef, userUrn func(string) string,
+	policyUrn func(string, string) string) PlannedChange {
+	return PlannedChange{
+		StateChange: StateChange{Action: USER_ACTION_DETACH_USER_POLICY, Urn: userUrn(u.ExternalID), Related: policyUrn(p.Org, p.Name)},
+		User:        &u,
+		UserPolicy:  &p,
+	}
+}
+
+// stateKey identifies a resource of an org
+func stateKey(org string, name string) string {
+	return org + "/" + name
+}
+
+func invalidStateResource(resource string, name string, path string) error {
+	return &Error{
+		Code:    INVALID_PARAMETER_ERROR,
+		Message: fmt.Sprintf("Invalid parameter: %v %v with path %v", resource, name, path),
+	}
+}
+
+func duplicatedStateResource(resource string, name string) error {
+	return &Error{
+		Code:    INVALID_PARAMETER_ERROR,
+		Message: fmt.Sprintf("Invalid parameter: duplicated %v %v", resource, name),
+	}
+}
+
+func invalidStateRelation(resource string, name string, relatedResource string, relatedName string) error {
+	return &Error{
+		Code: INVALID_PARAMETER_ERROR,
+		Message: fmt.Sprintf("Invalid parameter: %v %v relation with %v %v, that must be unique and in the document",
+			resource, name, relatedResource, relatedName),
+	}
+}
+
+// stateRepoError transforms a repository error
+func stateRepoError(err error) error {
+	//Transform to DB error
+	dbError := err.(*database.Error)
+	return &Error{
+		Code:    UNKNOWN_API_ERROR,
+		Message: dbError.Message,
+	}
+}
+
+// sameJSON checks if both values have the same JSON encoding
+func sameJSON(a interface{}, b interface{}) bool {
+	jsonA, errA := json.Marshal(a)
+	jsonB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(jsonA) == string(jsonB)
+}
+
+func sameExpiration(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+// sameNames checks if both lists have the same names, in any order
+func sameNames(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	names := map[string]int{}
+	for _, name := range a {
+		names[name]++
+	}
+	for _, name := range b {
+		if names[name] == 0 {
+			return false
+		}
+		names[name]--
+	}
+	return true
+}
@@ -0,0 +1,604 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerAPI_ExportState(t *testing.T) {
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	group := Group{ID: "GroupID", Name: "group1", Path: "/path/", Org: "org1"}
+	policy := Policy{
+		ID:   "PolicyID",
+		Name: "policy1",
+		Path: "/path/",
+		Org:  "org1",
+		Statements: &[]Statement{
+			{
+				Effect:    "allow",
+				Actions:   []string{USER_ACTION_GET_USER},
+				Resources: []string{GetUrnPrefix("", RESOURCE_USER, "/path/")},
+			},
+		},
+	}
+	otherPolicy := Policy{ID: "OtherPolicyID", Name: "policy2", Path: "/path/", Org: "org2"}
+	user1 := User{ID: "UserID1", ExternalID: "user1", Path: "/path/"}
+	user2 := User{ID: "UserID2", ExternalID: "user2", Path: "/path/"}
+	poolConfig := UpstreamPoolConfig{Targets: []string{"https://example.com"}, Balancer: UPSTREAM_BALANCER_ROUND_ROBIN}
+	testcases := map[string]struct {
+		// API method args
+		requestInfo RequestInfo
+		org         string
+		// Expected result
+		expectedResponse *State
+		wantError        error
+		// Manager Results
+		getAttachedUserPoliciesResult []TestPolicyUserRelation
+		// Manager Errors
+		getUsersFilteredErr error
+	}{
+		"OkCase": {
+			requestInfo: RequestInfo{Identifier: "admin", Admin: true},
+			expectedResponse: &State{
+				Version: STATE_VERSION,
+				Users: []StateUser{
+					{
+						ExternalID: "user1",
+						Path:       "/path/",
+						Policies:   []StatePolicyRef{{Org: "org1", Name: "policy1"}, {Org: "org2", Name: "policy2"}},
+					},
+					{
+						ExternalID: "user2",
+						Path:       "/path/",
+						Policies:   []StatePolicyRef{{Org: "org1", Name: "policy1"}, {Org: "org2", Name: "policy2"}},
+					},
+				},
+				Groups: []StateGroup{
+					{
+						Org:      "org1",
+						Name:     "group1",
+						Path:     "/path/",
+						Members:  []StateMember{{ExternalID: "user1", ExpiresAt: &expiresAt}},
+						Policies: []StateGroupPolicy{{Name: "policy1"}},
+						Groups:   []string{"group1"},
+					},
+				},
+				Policies:       []StatePolicy{{Org: "org1", Name: "policy1", Path: "/path/", Statements: *policy.Statements}},
+				ProxyResources: []StateProxyResource{{Org: "org1", Name: "proxy1", Path: "/path/", Resource: ResourceEntity{Host: "https://example.com"}}},
+				UpstreamPools:  []StateUpstreamPool{{Org: "org1", Name: "pool1", Path: "/path/", Config: poolConfig}},
+				OidcProviders:  []StateOidcProvider{{Name: "provider1", Path: "/path/", IssuerURL: "https://issuer.com", Clients: []string{"a", "b"}}},
+			},
+			getAttachedUserPoliciesResult: []TestPolicyUserRelation{{Policy: &otherPolicy}, {Policy: &policy}},
+		},
+		"OkCaseOrg": {
+			requestInfo: RequestInfo{Identifier: "admin", Admin: true},
+			org:         "org1",
+			expectedResponse: &State{
+				Version: STATE_VERSION,
+				Org:     "org1",
+				Users: []StateUser{
+					{
+						ExternalID: "user1",
+						Path:       "/path/",
+					},
+				},
+				Groups: []StateGroup{
+					{
+						Org:      "org1",
+						Name:     "group1",
+						Path:     "/path/",
+						Members:  []StateMember{{ExternalID: "user1", ExpiresAt: &expiresAt}},
+						Policies: []StateGroupPolicy{{Name: "policy1"}},
+						Groups:   []string{"group1"},
+					},
+				},
+				Policies:       []StatePolicy{{Org: "org1", Name: "policy1", Path: "/path/", Statements: *policy.Statements}},
+				ProxyResources: []StateProxyResource{{Org: "org1", Name: "proxy1", Path: "/path/", Resource: ResourceEntity{Host: "https://example.com"}}},
+				UpstreamPools:  []StateUpstreamPool{{Org: "org1", Name: "pool1", Path: "/path/", Config: poolConfig}},
+				OidcProviders:  []StateOidcProvider{},
+			},
+			getAttachedUserPoliciesResult: []TestPolicyUserRelation{{Policy: &otherPolicy}},
+		},
+		"ErrorCaseInvalidOrg": {
+			requestInfo: RequestInfo{Identifier: "admin", Admin: true},
+			org:         "!*^**~$%&/()",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: org !*^**~$%&/()",
+			},
+		},
+		"ErrorCaseNotAdmin": {
+			requestInfo: RequestInfo{Identifier: "user"},
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId user is not allowed to export the IAM state",
+			},
+		},
+		"ErrorCaseInternalError": {
+			requestInfo: RequestInfo{Identifier: "admin", Admin: true},
+			getUsersFilteredErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for x, test := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[GetPoliciesFilteredMethod][0] = []Policy{policy}
+		testRepo.ArgsOut[GetGroupsFilteredMethod][0] = []Group{group}
+		testRepo.ArgsOut[GetGroupMembersMethod][0] = []TestUserGroupRelation{{User: &user1, Group: &group, ExpiresAt: &expiresAt}}
+		testRepo.ArgsOut[GetAttachedPoliciesMethod][0] = []TestPolicyGroupRelation{{Policy: &policy, Group: &group}}
+		testRepo.ArgsOut[GetChildGroupsMethod][0] = []TestGroupGroupRelation{{Parent: &group, Child: &group}}
+		testRepo.ArgsOut[GetUsersFilteredMethod][0] = []User{user2, user1}
+		testRepo.ArgsOut[GetUsersFilteredMethod][2] = test.getUsersFilteredErr
+		testRepo.ArgsOut[GetAttachedUserPoliciesMethod][0] = test.getAttachedUserPoliciesResult
+		testRepo.ArgsOut[GetProxyResourcesMethod][0] = []ProxyResource{
+			{Org: "org1", Name: "proxy1", Path: "/path/", Resource: ResourceEntity{Host: "https://example.com"}},
+		}
+		testRepo.ArgsOut[GetUpstreamPoolsMethod][0] = []UpstreamPool{
+			{Org: "org1", Name: "pool1", Path: "/path/", Config: poolConfig},
+		}
+		testRepo.ArgsOut[GetOidcProvidersFilteredMethod][0] = []OidcProvider{
+			{Name: "provider1", Path: "/path/", IssuerURL: "https://issuer.com", OidcClients: []OidcClient{{Name: "b"}, {Name: "a"}}},
+		}
+
+		state, err := testAPI.ExportState(test.requestInfo, test.org)
+		checkMethodResponse(t, x, test.wantError, err, test.expectedResponse, state)
+	}
+}
+
+func TestWorkerAPI_ImportState(t *testing.T) {
+	state := State{
+		Version: STATE_VERSION,
+		Users:   []StateUser{{ExternalID: "user1", Path: "/path/"}},
+	}
+	testcases := map[string]struct {
+		// API method args
+		requestInfo RequestInfo
+		state       State
+		mode        string
+		dryRun      bool
+		// Expected result
+		expectedResponse *ImportResult
+		expectedUser     interface{}
+		wantError        error
+		// Manager Errors
+		addAuditEntryErr error
+		// Times the repository calls the transaction, as it does when it conflicts with a concurrent one
+		txAttempts int
+	}{
+		"OkCase": {
+			requestInfo: RequestInfo{Identifier: "admin", Admin: true},
+			state:       state,
+			mode:        IMPORT_MODE_UPSERT,
+			expectedResponse: &ImportResult{
+				Mode: IMPORT_MODE_UPSERT,
+				Changes: []StateChange{
+					{Action: USER_ACTION_CREATE_USER, Urn: CreateUrn("", RESOURCE_USER, "/path/", "user1")},
+				},
+			},
+			expectedUser: "user1",
+		},
+		"OkCaseDryRun": {
+			requestInfo: RequestInfo{Identifier: "admin", Admin: true},
+			state:       state,
+			mode:        IMPORT_MODE_CREATE,
+			dryRun:      true,
+			expectedResponse: &ImportResult{
+				Mode:   IMPORT_MODE_CREATE,
+				DryRun: true,
+				Changes: []StateChange{
+					{Action: USER_ACTION_CREATE_USER, Urn: CreateUrn("", RESOURCE_USER, "/path/", "user1")},
+				},
+			},
+		},
+		"OkCaseRetriedTransaction": {
+			requestInfo: RequestInfo{Identifier: "admin", Admin: true},
+			state:       state,
+			mode:        IMPORT_MODE_UPSERT,
+			txAttempts:  3,
+			expectedResponse: &ImportResult{
+				Mode: IMPORT_MODE_UPSERT,
+				Changes: []StateChange{
+					{Action: USER_ACTION_CREATE_USER, Urn: CreateUrn("", RESOURCE_USER, "/path/", "user1")},
+				},
+			},
+			expectedUser: "user1",
+		},
+		"ErrorCaseInvalidMode": {
+			requestInfo: RequestInfo{Identifier: "admin", Admin: true},
+			state:       state,
+			mode:        "merge",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: mode merge",
+			},
+		},
+		"ErrorCaseNotAdmin": {
+			requestInfo: RequestInfo{Identifier: "user"},
+			state:       state,
+			mode:        IMPORT_MODE_CREATE,
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId user is not allowed to import the IAM state",
+			},
+		},
+		"ErrorCaseChangeFailed": {
+			requestInfo: RequestInfo{Identifier: "admin", Admin: true},
+			state:       state,
+			mode:        IMPORT_MODE_CREATE,
+			// The user is created before the failure
+			expectedUser: "user1",
+			addAuditEntryErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for x, test := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[GetUserByExternalIDMethod][1] = &database.Error{
+			Code:    database.USER_NOT_FOUND,
+			Message: "User not found",
+		}
+		testRepo.ArgsOut[AddUserMethod][0] = &User{ExternalID: "user1", Path: "/path/"}
+		testRepo.ArgsOut[AddAuditEntryMethod][0] = test.addAuditEntryErr
+		if test.txAttempts > 0 {
+			testRepo.SpecialFuncs[WithTxMethod] = func(fn func(repos TxRepos) error, repos TxRepos) error {
+				var err error
+				for i := 0; i < test.txAttempts; i++ {
+					err = fn(repos)
+				}
+				return err
+			}
+		}
+
+		result, err := testAPI.ImportState(test.requestInfo, test.state, test.mode, test.dryRun)
+		checkMethodResponse(t, x, test.wantError, err, test.expectedResponse, result)
+
+		// Check the changes are applied unless it is a dry run, and rolled back if any fails
+		var addedUser interface{}
+		if user, ok := testRepo.ArgsIn[AddUserMethod][0].(User); ok {
+			addedUser = user.ExternalID
+		}
+		assert.Equal(t, test.expectedUser, addedUser, "Error in test case %v", x)
+		if test.wantError == nil {
+			assert.Nil(t, testRepo.ArgsIn[WithTxMethod][0], "Error in test case %v", x)
+		} else if test.expectedUser != nil {
+			assert.Equal(t, test.wantError, testRepo.ArgsIn[WithTxMethod][0], "Error in test case %v", x)
+		}
+	}
+}
+
+func TestValidateState(t *testing.T) {
+	testcases := map[string]struct {
+		state     State
+		mode      string
+		wantError error
+	}{
+		"OkCase": {
+			state: State{
+				Version: STATE_VERSION,
+				Org:     "org1",
+				Users: []StateUser{
+					{ExternalID: "user1", Path: "/", Policies: []StatePolicyRef{{Org: "org1", Name: "policy1"}}},
+				},
+				Groups: []StateGroup{
+					{
+						Org:      "org1",
+						Name:     "group1",
+						Path:     "/",
+						Members:  []StateMember{{ExternalID: "user1"}},
+						Policies: []StateGroupPolicy{{Name: "policy1"}},
+						Groups:   []string{"group2"},
+					},
+					{Org: "org1", Name: "group2", Path: "/"},
+				},
+				Policies: []StatePolicy{
+					{
+						Org:  "org1",
+						Name: "policy1",
+						Path: "/",
+						Statements: []Statement{
+							{Effect: "allow", Actions: []string{USER_ACTION_GET_USER}, Resources: []string{"urn:iws:iam::user/*"}},
+						},
+					},
+				},
+				ProxyResources: []StateProxyResource{
+					{
+						Org:  "org1",
+						Name: "proxy1",
+						Path: "/",
+						Resource: ResourceEntity{
+							Host:   "http://example.com",
+							Path:   "/resource",
+							Method: "GET",
+							Urn:    "urn:ews:example:instance1:resource/get",
+							Action: "example:get",
+						},
+					},
+				},
+			},
+			mode: IMPORT_MODE_REPLACE,
+		},
+		"ErrorCaseInvalidVersion": {
+			state: State{Version: 2},
+			mode:  IMPORT_MODE_CREATE,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: version 2",
+			},
+		},
+		"ErrorCaseOtherOrg": {
+			state: State{
+				Version: STATE_VERSION,
+				Org:     "org1",
+				Groups:  []StateGroup{{Org: "org2", Name: "group1", Path: "/"}},
+			},
+			mode: IMPORT_MODE_CREATE,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: group org2/group1 with path /",
+			},
+		},
+		"ErrorCaseDuplicatedUser": {
+			state: State{
+				Version: STATE_VERSION,
+				Users:   []StateUser{{ExternalID: "user1", Path: "/"}, {ExternalID: "user1", Path: "/other/"}},
+			},
+			mode: IMPORT_MODE_CREATE,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: duplicated user user1",
+			},
+		},
+		"ErrorCaseMemberNotInDocument": {
+			state: State{
+				Version: STATE_VERSION,
+				Groups: []StateGroup{
+					{Org: "org1", Name: "group1", Path: "/", Members: []StateMember{{ExternalID: "user1"}}},
+				},
+			},
+			mode: IMPORT_MODE_CREATE,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: group org1/group1 relation with user user1, that must be unique and in the document",
+			},
+		},
+		"ErrorCaseOidcProviderWithOrg": {
+			state: State{
+				Version:       STATE_VERSION,
+				Org:           "org1",
+				OidcProviders: []StateOidcProvider{{Name: "provider1", Path: "/"}},
+			},
+			mode: IMPORT_MODE_CREATE,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: oidc provider1 with path /",
+			},
+		},
+		"ErrorCaseInvalidStatements": {
+			state: State{
+				Version: STATE_VERSION,
+				Policies: []StatePolicy{
+					{
+						Org:        "org1",
+						Name:       "policy1",
+						Path:       "/",
+						Statements: []Statement{{Effect: "maybe", Actions: []string{USER_ACTION_GET_USER}, Resources: []string{"*"}}},
+					},
+				},
+			},
+			mode: IMPORT_MODE_CREATE,
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid effect: maybe - Only 'allow' and 'deny' accepted",
+			},
+		},
+	}
+
+	for x, test := range testcases {
+		err := ValidateState(test.state, test.mode)
+		checkMethodResponse(t, x, test.wantError, err, nil, nil)
+	}
+}
+
+func TestPlanImport(t *testing.T) {
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	statements := []Statement{
+		{Effect: "allow", Actions: []string{USER_ACTION_GET_USER}, Resources: []string{"urn:iws:iam::user/*"}},
+	}
+	current := State{
+		Version: STATE_VERSION,
+		Users: []StateUser{
+			{ExternalID: "user1", Path: "/old/", Policies: []StatePolicyRef{{Org: "org1", Name: "policy1"}}},
+			{ExternalID: "user2", Path: "/path/", Policies: []StatePolicyRef{{Org: "org1", Name: "policy1"}}},
+		},
+		Groups: []StateGroup{
+			{
+				Org:      "org1",
+				Name:     "group1",
+				Path:     "/path/",
+				Members:  []StateMember{{ExternalID: "user1"}, {ExternalID: "user2"}},
+				Policies: []StateGroupPolicy{{Name: "policy1"}},
+				Groups:   []string{"group2"},
+			},
+			{Org: "org1", Name: "group2", Path: "/path/"},
+		},
+		Policies: []StatePolicy{
+			{Org: "org1", Name: "policy1", Path: "/path/", Statements: statements},
+			{Org: "org1", Name: "policy2", Path: "/path/", Statements: statements},
+		},
+		ProxyResources: []StateProxyResource{
+			{Org: "org1", Name: "proxy1", Path: "/path/", Resource: ResourceEntity{Host: "http://example.com", Path: "/a"}},
+		},
+		OidcProviders: []StateOidcProvider{
+			{Name: "provider1", Path: "/path/", IssuerURL: "https://issuer.com", Clients: []string{"a", "b"}},
+		},
+	}
+	desired := State{
+		Version: STATE_VERSION,
+		Users: []StateUser{
+			{ExternalID: "user1", Path: "/path/"},
+			{ExternalID: "user3", Path: "/path/", Policies: []StatePolicyRef{{Org: "org1", Name: "policy1"}}},
+		},
+		Groups: []StateGroup{
+			{
+				Org:     "org1",
+				Name:    "group1",
+				Path:    "/path/",
+				Members: []StateMember{{ExternalID: "user1", ExpiresAt: &expiresAt}, {ExternalID: "user3"}},
+			},
+		},
+		Policies: []StatePolicy{
+			{Org: "org1", Name: "policy1", Path: "/path/", Statements: statements},
+		},
+		ProxyResources: []StateProxyResource{
+			{Org: "org1", Name: "proxy1", Path: "/path/", Resource: ResourceEntity{Host: "http://example.com", Path: "/b"}},
+		},
+		OidcProviders: []StateOidcProvider{
+			{Name: "provider1", Path: "/path/", IssuerURL: "https://issuer.com", Clients: []string{"b", "a"}},
+		},
+	}
+	orgDesired := desired
+	orgDesired.Org = "org1"
+	orgDesired.OidcProviders = nil
+
+	user1Urn := CreateUrn("", RESOURCE_USER, "/path/", "user1")
+	user2Urn := CreateUrn("", RESOURCE_USER, "/path/", "user2")
+	user3Urn := CreateUrn("", RESOURCE_USER, "/path/", "user3")
+	group1Urn := CreateUrn("org1", RESOURCE_GROUP, "/path/", "group1")
+	group2Urn := CreateUrn("org1", RESOURCE_GROUP, "/path/", "group2")
+	policy1Urn := CreateUrn("org1", RESOURCE_POLICY, "/path/", "policy1")
+	policy2Urn := CreateUrn("org1", RESOURCE_POLICY, "/path/", "policy2")
+	proxy1Urn := CreateUrn("org1", RESOURCE_PROXY, "/path/", "proxy1")
+	provider1Urn := CreateUrn("", RESOURCE_AUTH_OIDC_PROVIDER, "/path/", "provider1")
+
+	testcases := map[string]struct {
+		current         State
+		desired         State
+		mode            string
+		expectedChanges []StateChange
+		wantError       error
+	}{
+		"OkCaseUpsert": {
+			current: current,
+			desired: desired,
+			mode:    IMPORT_MODE_UPSERT,
+			expectedChanges: []StateChange{
+				{Action: GROUP_ACTION_REMOVE_MEMBER, Urn: group1Urn, Related: user1Urn},
+				{Action: USER_ACTION_UPDATE_USER, Urn: CreateUrn("", RESOURCE_USER, "/old/", "user1")},
+				{Action: USER_ACTION_CREATE_USER, Urn: user3Urn},
+				{Action: PROXY_ACTION_UPDATE_RESOURCE, Urn: proxy1Urn},
+				{Action: USER_ACTION_ATTACH_USER_POLICY, Urn: user3Urn, Related: policy1Urn},
+				{Action: GROUP_ACTION_ADD_MEMBER, Urn: group1Urn, Related: user1Urn},
+				{Action: GROUP_ACTION_ADD_MEMBER, Urn: group1Urn, Related: user3Urn},
+			},
+		},
+		"OkCaseReplace": {
+			current: current,
+			desired: desired,
+			mode:    IMPORT_MODE_REPLACE,
+			expectedChanges: []StateChange{
+				{Action: POLICY_ACTION_DELETE_POLICY, Urn: policy2Urn},
+				{Action: USER_ACTION_DETACH_USER_POLICY, Urn: user1Urn, Related: policy1Urn},
+				{Action: USER_ACTION_DELETE_USER, Urn: user2Urn},
+				{Action: GROUP_ACTION_REMOVE_MEMBER, Urn: group1Urn, Related: user1Urn},
+				{Action: GROUP_ACTION_DETACH_GROUP_POLICY, Urn: group1Urn, Related: policy1Urn},
+				{Action: GROUP_ACTION_DELETE_GROUP, Urn: group2Urn},
+				{Action: USER_ACTION_UPDATE_USER, Urn: CreateUrn("", RESOURCE_USER, "/old/", "user1")},
+				{Action: USER_ACTION_CREATE_USER, Urn: user3Urn},
+				{Action: PROXY_ACTION_UPDATE_RESOURCE, Urn: proxy1Urn},
+				{Action: USER_ACTION_ATTACH_USER_POLICY, Urn: user3Urn, Related: policy1Urn},
+				{Action: GROUP_ACTION_ADD_MEMBER, Urn: group1Urn, Related: user1Urn},
+				{Action: GROUP_ACTION_ADD_MEMBER, Urn: group1Urn, Related: user3Urn},
+			},
+		},
+		"OkCaseReplaceOrg": {
+			current: current,
+			desired: orgDesired,
+			mode:    IMPORT_MODE_REPLACE,
+			expectedChanges: []StateChange{
+				{Action: POLICY_ACTION_DELETE_POLICY, Urn: policy2Urn},
+				{Action: USER_ACTION_DETACH_USER_POLICY, Urn: user1Urn, Related: policy1Urn},
+				{Action: USER_ACTION_DETACH_USER_POLICY, Urn: user2Urn, Related: policy1Urn},
+				{Action: GROUP_ACTION_REMOVE_MEMBER, Urn: group1Urn, Related: user1Urn},
+				{Action: GROUP_ACTION_REMOVE_MEMBER, Urn: group1Urn, Related: user2Urn},
+				{Action: GROUP_ACTION_DETACH_GROUP_POLICY, Urn: group1Urn, Related: policy1Urn},
+				{Action: GROUP_ACTION_DELETE_GROUP, Urn: group2Urn},
+				{Action: AUTH_OIDC_ACTION_DELETE_PROVIDER, Urn: provider1Urn},
+				{Action: USER_ACTION_UPDATE_USER, Urn: CreateUrn("", RESOURCE_USER, "/old/", "user1")},
+				{Action: USER_ACTION_CREATE_USER, Urn: user3Urn},
+				{Action: PROXY_ACTION_UPDATE_RESOURCE, Urn: proxy1Urn},
+				{Action: USER_ACTION_ATTACH_USER_POLICY, Urn: user3Urn, Related: policy1Urn},
+				{Action: GROUP_ACTION_ADD_MEMBER, Urn: group1Urn, Related: user1Urn},
+				{Action: GROUP_ACTION_ADD_MEMBER, Urn: group1Urn, Related: user3Urn},
+			},
+		},
+		"OkCaseNoChanges": {
+			current:         current,
+			desired:         current,
+			mode:            IMPORT_MODE_REPLACE,
+			expectedChanges: []StateChange{},
+		},
+		"OkCaseCreate": {
+			current: State{Version: STATE_VERSION},
+			desired: current,
+			mode:    IMPORT_MODE_CREATE,
+			expectedChanges: []StateChange{
+				{Action: POLICY_ACTION_CREATE_POLICY, Urn: policy1Urn},
+				{Action: POLICY_ACTION_CREATE_POLICY, Urn: policy2Urn},
+				{Action: USER_ACTION_CREATE_USER, Urn: CreateUrn("", RESOURCE_USER, "/old/", "user1")},
+				{Action: USER_ACTION_CREATE_USER, Urn: user2Urn},
+				{Action: GROUP_ACTION_CREATE_GROUP, Urn: group1Urn},
+				{Action: GROUP_ACTION_CREATE_GROUP, Urn: group2Urn},
+				{Action: PROXY_ACTION_CREATE_RESOURCE, Urn: proxy1Urn},
+				{Action: AUTH_OIDC_ACTION_CREATE_PROVIDER, Urn: provider1Urn},
+				{Action: USER_ACTION_ATTACH_USER_POLICY, Urn: CreateUrn("", RESOURCE_USER, "/old/", "user1"), Related: policy1Urn},
+				{Action: USER_ACTION_ATTACH_USER_POLICY, Urn: user2Urn, Related: policy1Urn},
+				{Action: GROUP_ACTION_ADD_MEMBER, Urn: group1Urn, Related: CreateUrn("", RESOURCE_USER, "/old/", "user1")},
+				{Action: GROUP_ACTION_ADD_MEMBER, Urn: group1Urn, Related: user2Urn},
+				{Action: GROUP_ACTION_ATTACH_GROUP_POLICY, Urn: group1Urn, Related: policy1Urn},
+				{Action: GROUP_ACTION_ADD_CHILD_GROUP, Urn: group1Urn, Related: group2Urn},
+			},
+		},
+		"ErrorCaseCreateExistingPolicy": {
+			current: current,
+			desired: desired,
+			mode:    IMPORT_MODE_CREATE,
+			wantError: &Error{
+				Code:    POLICY_ALREADY_EXIST,
+				Message: "Unable to create policy, policy with org org1 and name policy1 already exist",
+			},
+		},
+	}
+
+	for x, test := range testcases {
+		changes, err := PlanImport(test.current, test.desired, test.mode)
+		var stateChanges []StateChange
+		if err == nil {
+			stateChanges = []StateChange{}
+			for _, change := range changes {
+				stateChanges = append(stateChanges, change.StateChange)
+			}
+		}
+		checkMethodResponse(t, x, test.wantError, err, test.expectedChanges, stateChanges)
+	}
+}
@@ -43,11 +43,38 @@ const (
 	AddProxyResourceMethod         = "AddProxyResource"
 	UpdateProxyResourceMethod      = "UpdateProxyResource"
 	GetProxyResourceByNameMethod   = "GetProxyResourceByName"
+	GetUpstreamPoolsMethod         = "GetUpstreamPools"
+	GetUpstreamPoolByNameMethod    = "GetUpstreamPoolByName"
+	AddUpstreamPoolMethod          = "AddUpstreamPool"
+	UpdateUpstreamPoolMethod       = "UpdateUpstreamPool"
+	RemoveUpstreamPoolMethod       = "RemoveUpstreamPool"
+	GetProxyRevisionMethod         = "GetProxyRevision"
+	IncrementProxyRevisionMethod   = "IncrementProxyRevision"
 	AddOidcProviderMethod          = "AddOidcProvider"
 	GetOidcProviderByNameMethod    = "GetOidcProviderByName"
 	GetOidcProvidersFilteredMethod = "GetOidcProvidersFiltered"
 	UpdateOidcProviderMethod       = "UpdateOidcProvider"
 	RemoveOidcProviderMethod       = "RemoveOidcProviderMethod"
+	AddChildGroupMethod            = "AddChildGroup"
+	RemoveChildGroupMethod         = "RemoveChildGroup"
+	IsChildOfGroupMethod           = "IsChildOfGroup"
+	GetChildGroupsMethod           = "GetChildGroups"
+	GetAncestorGroupsMethod        = "GetAncestorGroups"
+	RemoveExpiredRelationsMethod   = "RemoveExpiredRelations"
+	AttachUserPolicyMethod         = "AttachUserPolicy"
+	DetachUserPolicyMethod         = "DetachUserPolicy"
+	IsAttachedToUserMethod         = "IsAttachedToUser"
+	GetAttachedUserPoliciesMethod  = "GetAttachedUserPolicies"
+	WithTxMethod                   = "WithTx"
+	AddAuditEntryMethod            = "AddAuditEntry"
+	GetAuditEntriesFilteredMethod  = "GetAuditEntriesFiltered"
+	AddWebhookMethod               = "AddWebhook"
+	GetWebhookByNameMethod         = "GetWebhookByName"
+	GetWebhooksFilteredMethod      = "GetWebhooksFiltered"
+	UpdateWebhookMethod            = "UpdateWebhook"
+	RemoveWebhookMethod            = "RemoveWebhook"
+	AddWebhookDeadLetterMethod     = "AddWebhookDeadLetter"
+	GetWebhookDeadLettersMethod    = "GetWebhookDeadLettersFiltered"
 )
 
 // TestRepo that implements all repo manager interfaces
@@ -58,17 +85,31 @@ type TestRepo struct {
 }
 
 type TestUserGroupRelation struct {
+	User      *User
+	Group     *Group
+	CreateAt  time.Time
+	ExpiresAt *time.Time
+}
+
+type TestPolicyUserRelation struct {
 	User     *User
-	Group    *Group
+	Policy   *Policy
 	CreateAt time.Time
 }
 
-type TestPolicyGroupRelation struct {
-	Group    *Group
-	Policy   *Policy
+type TestGroupGroupRelation struct {
+	Parent   *Group
+	Child    *Group
 	CreateAt time.Time
 }
 
+type TestPolicyGroupRelation struct {
+	Group     *Group
+	Policy    *Policy
+	CreateAt  time.Time
+	ExpiresAt *time.Time
+}
+
 var testFilter = Filter{
 	PathPrefix: "",
 	Org:        "",
@@ -88,7 +129,7 @@ func makeTestRepo() *TestRepo {
 	}
 	testRepo.ArgsIn[GetUserByExternalIDMethod] = make([]interface{}, 1)
 	testRepo.ArgsIn[AddUserMethod] = make([]interface{}, 1)
-	testRepo.ArgsIn[UpdateUserMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[UpdateUserMethod] = make([]interface{}, 2)
 	testRepo.ArgsIn[GetUsersFilteredMethod] = make([]interface{}, 1)
 	testRepo.ArgsIn[GetGroupsByUserIDMethod] = make([]interface{}, 2)
 	testRepo.ArgsIn[RemoveUserMethod] = make([]interface{}, 1)
@@ -100,14 +141,14 @@ func makeTestRepo() *TestRepo {
 	testRepo.ArgsIn[GetGroupsFilteredMethod] = make([]interface{}, 1)
 	testRepo.ArgsIn[RemoveGroupMethod] = make([]interface{}, 1)
 	testRepo.ArgsIn[AddGroupMethod] = make([]interface{}, 1)
-	testRepo.ArgsIn[AddMemberMethod] = make([]interface{}, 2)
+	testRepo.ArgsIn[AddMemberMethod] = make([]interface{}, 3)
 	testRepo.ArgsIn[RemoveMemberMethod] = make([]interface{}, 2)
-	testRepo.ArgsIn[UpdateGroupMethod] = make([]interface{}, 1)
-	testRepo.ArgsIn[AttachPolicyMethod] = make([]interface{}, 2)
+	testRepo.ArgsIn[UpdateGroupMethod] = make([]interface{}, 2)
+	testRepo.ArgsIn[AttachPolicyMethod] = make([]interface{}, 3)
 	testRepo.ArgsIn[DetachPolicyMethod] = make([]interface{}, 2)
 	testRepo.ArgsIn[GetPolicyByNameMethod] = make([]interface{}, 2)
 	testRepo.ArgsIn[AddPolicyMethod] = make([]interface{}, 1)
-	testRepo.ArgsIn[UpdatePolicyMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[UpdatePolicyMethod] = make([]interface{}, 2)
 	testRepo.ArgsIn[RemovePolicyMethod] = make([]interface{}, 1)
 	testRepo.ArgsIn[GetPoliciesFilteredMethod] = make([]interface{}, 1)
 	testRepo.ArgsIn[GetAttachedGroupsMethod] = make([]interface{}, 2)
@@ -115,13 +156,38 @@ func makeTestRepo() *TestRepo {
 	testRepo.ArgsIn[GetProxyResourcesMethod] = make([]interface{}, 2)
 	testRepo.ArgsIn[RemoveProxyResourceMethod] = make([]interface{}, 2)
 	testRepo.ArgsIn[AddProxyResourceMethod] = make([]interface{}, 1)
-	testRepo.ArgsIn[UpdateProxyResourceMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[UpdateProxyResourceMethod] = make([]interface{}, 2)
 	testRepo.ArgsIn[GetProxyResourceByNameMethod] = make([]interface{}, 2)
+	testRepo.ArgsIn[GetUpstreamPoolsMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[GetUpstreamPoolByNameMethod] = make([]interface{}, 2)
+	testRepo.ArgsIn[AddUpstreamPoolMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[UpdateUpstreamPoolMethod] = make([]interface{}, 2)
+	testRepo.ArgsIn[RemoveUpstreamPoolMethod] = make([]interface{}, 1)
 	testRepo.ArgsIn[AddOidcProviderMethod] = make([]interface{}, 1)
 	testRepo.ArgsIn[GetOidcProviderByNameMethod] = make([]interface{}, 1)
 	testRepo.ArgsIn[GetOidcProvidersFilteredMethod] = make([]interface{}, 1)
-	testRepo.ArgsIn[UpdateOidcProviderMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[UpdateOidcProviderMethod] = make([]interface{}, 2)
 	testRepo.ArgsIn[RemoveOidcProviderMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[AddChildGroupMethod] = make([]interface{}, 2)
+	testRepo.ArgsIn[RemoveChildGroupMethod] = make([]interface{}, 2)
+	testRepo.ArgsIn[IsChildOfGroupMethod] = make([]interface{}, 2)
+	testRepo.ArgsIn[GetChildGroupsMethod] = make([]interface{}, 2)
+	testRepo.ArgsIn[GetAncestorGroupsMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[RemoveExpiredRelationsMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[AttachUserPolicyMethod] = make([]interface{}, 2)
+	testRepo.ArgsIn[DetachUserPolicyMethod] = make([]interface{}, 2)
+	testRepo.ArgsIn[IsAttachedToUserMethod] = make([]interface{}, 2)
+	testRepo.ArgsIn[GetAttachedUserPoliciesMethod] = make([]interface{}, 2)
+	testRepo.ArgsIn[WithTxMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[AddAuditEntryMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[GetAuditEntriesFilteredMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[AddWebhookMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[GetWebhookByNameMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[GetWebhooksFilteredMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[UpdateWebhookMethod] = make([]interface{}, 2)
+	testRepo.ArgsIn[RemoveWebhookMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[AddWebhookDeadLetterMethod] = make([]interface{}, 1)
+	testRepo.ArgsIn[GetWebhookDeadLettersMethod] = make([]interface{}, 2)
 
 	testRepo.ArgsOut[GetUserByExternalIDMethod] = make([]interface{}, 2)
 	testRepo.ArgsOut[AddUserMethod] = make([]interface{}, 2)
@@ -154,11 +220,38 @@ func makeTestRepo() *TestRepo {
 	testRepo.ArgsOut[AddProxyResourceMethod] = make([]interface{}, 2)
 	testRepo.ArgsOut[UpdateProxyResourceMethod] = make([]interface{}, 2)
 	testRepo.ArgsOut[GetProxyResourceByNameMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[GetUpstreamPoolsMethod] = make([]interface{}, 3)
+	testRepo.ArgsOut[GetUpstreamPoolByNameMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[AddUpstreamPoolMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[UpdateUpstreamPoolMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[RemoveUpstreamPoolMethod] = make([]interface{}, 1)
+	testRepo.ArgsOut[GetProxyRevisionMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[IncrementProxyRevisionMethod] = make([]interface{}, 1)
 	testRepo.ArgsOut[AddOidcProviderMethod] = make([]interface{}, 2)
 	testRepo.ArgsOut[GetOidcProviderByNameMethod] = make([]interface{}, 2)
 	testRepo.ArgsOut[GetOidcProvidersFilteredMethod] = make([]interface{}, 3)
 	testRepo.ArgsOut[UpdateOidcProviderMethod] = make([]interface{}, 2)
 	testRepo.ArgsOut[RemoveOidcProviderMethod] = make([]interface{}, 1)
+	testRepo.ArgsOut[AddChildGroupMethod] = make([]interface{}, 1)
+	testRepo.ArgsOut[RemoveChildGroupMethod] = make([]interface{}, 1)
+	testRepo.ArgsOut[IsChildOfGroupMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[GetChildGroupsMethod] = make([]interface{}, 3)
+	testRepo.ArgsOut[GetAncestorGroupsMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[RemoveExpiredRelationsMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[AttachUserPolicyMethod] = make([]interface{}, 1)
+	testRepo.ArgsOut[DetachUserPolicyMethod] = make([]interface{}, 1)
+	testRepo.ArgsOut[IsAttachedToUserMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[GetAttachedUserPoliciesMethod] = make([]interface{}, 3)
+	testRepo.ArgsOut[WithTxMethod] = make([]interface{}, 1)
+	testRepo.ArgsOut[AddAuditEntryMethod] = make([]interface{}, 1)
+	testRepo.ArgsOut[GetAuditEntriesFilteredMethod] = make([]interface{}, 3)
+	testRepo.ArgsOut[AddWebhookMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[GetWebhookByNameMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[GetWebhooksFilteredMethod] = make([]interface{}, 3)
+	testRepo.ArgsOut[UpdateWebhookMethod] = make([]interface{}, 2)
+	testRepo.ArgsOut[RemoveWebhookMethod] = make([]interface{}, 1)
+	testRepo.ArgsOut[AddWebhookDeadLetterMethod] = make([]interface{}, 1)
+	testRepo.ArgsOut[GetWebhookDeadLettersMethod] = make([]interface{}, 3)
 
 	return testRepo
 }
@@ -170,6 +263,8 @@ func makeTestAPI(testRepo *TestRepo) *WorkerAPI {
 		PolicyRepo:   testRepo,
 		ProxyRepo:    testRepo,
 		AuthOidcRepo: testRepo,
+		AuditRepo:    testRepo,
+		WebhookRepo:  testRepo,
 	}
 	Log = &log.Logger{
 		Out:       bytes.NewBuffer([]byte{}),
@@ -209,6 +304,42 @@ func (t TestUserGroupRelation) GetDate() time.Time {
 	return t.CreateAt
 }
 
+func (t TestUserGroupRelation) GetExpiresAt() *time.Time {
+	return t.ExpiresAt
+}
+
+//////////////////////
+// PolicyUserRelation
+//////////////////////
+
+func (t TestPolicyUserRelation) GetUser() *User {
+	return t.User
+}
+
+func (t TestPolicyUserRelation) GetPolicy() *Policy {
+	return t.Policy
+}
+
+func (t TestPolicyUserRelation) GetDate() time.Time {
+	return t.CreateAt
+}
+
+//////////////////////
+// GroupGroupRelation
+//////////////////////
+
+func (t TestGroupGroupRelation) GetParent() *Group {
+	return t.Parent
+}
+
+func (t TestGroupGroupRelation) GetChild() *Group {
+	return t.Child
+}
+
+func (t TestGroupGroupRelation) GetDate() time.Time {
+	return t.CreateAt
+}
+
 ///////////////////////
 // PolicyGroupRelation
 ///////////////////////
@@ -225,6 +356,32 @@ func (t TestPolicyGroupRelation) GetDate() time.Time {
 	return t.CreateAt
 }
 
+func (t TestPolicyGroupRelation) GetExpiresAt() *time.Time {
+	return t.ExpiresAt
+}
+
+//////////////////
+// Transactions
+//////////////////
+
+// WithTx stores fn error, that is nil if the transaction is committed, and returns the configured
+// commit error
+func (t TestRepo) WithTx(fn func(repos TxRepos) error) error {
+	repos := TxRepos{UserRepo: t, GroupRepo: t, PolicyRepo: t, ProxyRepo: t, AuthOidcRepo: t, AuditRepo: t, WebhookRepo: t}
+	if specialFunc, ok := t.SpecialFuncs[WithTxMethod].(func(fn func(repos TxRepos) error, repos TxRepos) error); ok && specialFunc != nil {
+		return specialFunc(fn, repos)
+	}
+	err := fn(repos)
+	t.ArgsIn[WithTxMethod][0] = err
+	if err != nil {
+		return err
+	}
+	if t.ArgsOut[WithTxMethod][0] != nil {
+		err = t.ArgsOut[WithTxMethod][0].(error)
+	}
+	return err
+}
+
 //////////////////
 // User repo
 //////////////////
@@ -258,8 +415,9 @@ func (t TestRepo) AddUser(user User) (*User, error) {
 	return created, err
 }
 
-func (t TestRepo) UpdateUser(user User) (*User, error) {
+func (t TestRepo) UpdateUser(user User, oldUpdateAt time.Time) (*User, error) {
 	t.ArgsIn[UpdateUserMethod][0] = user
+	t.ArgsIn[UpdateUserMethod][1] = oldUpdateAt
 	var updated *User
 	if t.ArgsOut[UpdateUserMethod][0] != nil {
 		updated = t.ArgsOut[UpdateUserMethod][0].(*User)
@@ -319,6 +477,61 @@ func (t TestRepo) RemoveUser(id string) error {
 	return err
 }
 
+func (t TestRepo) AttachUserPolicy(userID string, policyID string) error {
+	t.ArgsIn[AttachUserPolicyMethod][0] = userID
+	t.ArgsIn[AttachUserPolicyMethod][1] = policyID
+	var err error
+	if t.ArgsOut[AttachUserPolicyMethod][0] != nil {
+		err = t.ArgsOut[AttachUserPolicyMethod][0].(error)
+	}
+	return err
+}
+
+func (t TestRepo) DetachUserPolicy(userID string, policyID string) error {
+	t.ArgsIn[DetachUserPolicyMethod][0] = userID
+	t.ArgsIn[DetachUserPolicyMethod][1] = policyID
+	var err error
+	if t.ArgsOut[DetachUserPolicyMethod][0] != nil {
+		err = t.ArgsOut[DetachUserPolicyMethod][0].(error)
+	}
+	return err
+}
+
+func (t TestRepo) IsAttachedToUser(userID string, policyID string) (bool, error) {
+	t.ArgsIn[IsAttachedToUserMethod][0] = userID
+	t.ArgsIn[IsAttachedToUserMethod][1] = policyID
+	var isAttached bool
+	if t.ArgsOut[IsAttachedToUserMethod][0] != nil {
+		isAttached = t.ArgsOut[IsAttachedToUserMethod][0].(bool)
+	}
+	var err error
+	if t.ArgsOut[IsAttachedToUserMethod][1] != nil {
+		err = t.ArgsOut[IsAttachedToUserMethod][1].(error)
+	}
+	return isAttached, err
+}
+
+func (t TestRepo) GetAttachedUserPolicies(userID string, filter *Filter) ([]PolicyUserRelation, int, error) {
+	t.ArgsIn[GetAttachedUserPoliciesMethod][0] = userID
+	t.ArgsIn[GetAttachedUserPoliciesMethod][1] = filter
+	var policies []PolicyUserRelation
+	if t.ArgsOut[GetAttachedUserPoliciesMethod][0] != nil {
+		testPolicies := t.ArgsOut[GetAttachedUserPoliciesMethod][0].([]TestPolicyUserRelation)
+		for _, v := range testPolicies {
+			policies = append(policies, v)
+		}
+	}
+	var total int
+	if t.ArgsOut[GetAttachedUserPoliciesMethod][1] != nil {
+		total = t.ArgsOut[GetAttachedUserPoliciesMethod][1].(int)
+	}
+	var err error
+	if t.ArgsOut[GetAttachedUserPoliciesMethod][2] != nil {
+		err = t.ArgsOut[GetAttachedUserPoliciesMethod][2].(error)
+	}
+	return policies, total, err
+}
+
 //////////////////
 // Group repo
 //////////////////
@@ -449,9 +662,10 @@ func (t TestRepo) AddGroup(group Group) (*Group, error) {
 	return created, err
 }
 
-func (t TestRepo) AddMember(userID string, groupID string) error {
+func (t TestRepo) AddMember(userID string, groupID string, expiresAt *time.Time) error {
 	t.ArgsIn[AddMemberMethod][0] = userID
 	t.ArgsIn[AddMemberMethod][1] = groupID
+	t.ArgsIn[AddMemberMethod][2] = expiresAt
 	var err error
 	if t.ArgsOut[AddMemberMethod][0] != nil {
 		err = t.ArgsOut[AddMemberMethod][0].(error)
@@ -469,8 +683,9 @@ func (t TestRepo) RemoveMember(userID string, groupID string) error {
 	return err
 }
 
-func (t TestRepo) UpdateGroup(group Group) (*Group, error) {
+func (t TestRepo) UpdateGroup(group Group, oldUpdateAt time.Time) (*Group, error) {
 	t.ArgsIn[UpdateGroupMethod][0] = group
+	t.ArgsIn[UpdateGroupMethod][1] = oldUpdateAt
 
 	var updated *Group
 	if t.ArgsOut[UpdateGroupMethod][0] != nil {
@@ -483,9 +698,10 @@ func (t TestRepo) UpdateGroup(group Group) (*Group, error) {
 	return updated, err
 }
 
-func (t TestRepo) AttachPolicy(groupID string, policyID string) error {
+func (t TestRepo) AttachPolicy(groupID string, policyID string, expiresAt *time.Time) error {
 	t.ArgsIn[AttachPolicyMethod][0] = groupID
 	t.ArgsIn[AttachPolicyMethod][1] = policyID
+	t.ArgsIn[AttachPolicyMethod][2] = expiresAt
 	var err error
 	if t.ArgsOut[AttachPolicyMethod][0] != nil {
 		err = t.ArgsOut[AttachPolicyMethod][0].(error)
@@ -502,6 +718,87 @@ func (t TestRepo) DetachPolicy(groupID string, policyID string) error {
 	return err
 }
 
+func (t TestRepo) AddChildGroup(childID string, parentID string) error {
+	t.ArgsIn[AddChildGroupMethod][0] = childID
+	t.ArgsIn[AddChildGroupMethod][1] = parentID
+	var err error
+	if t.ArgsOut[AddChildGroupMethod][0] != nil {
+		err = t.ArgsOut[AddChildGroupMethod][0].(error)
+	}
+	return err
+}
+
+func (t TestRepo) RemoveChildGroup(childID string, parentID string) error {
+	t.ArgsIn[RemoveChildGroupMethod][0] = childID
+	t.ArgsIn[RemoveChildGroupMethod][1] = parentID
+	var err error
+	if t.ArgsOut[RemoveChildGroupMethod][0] != nil {
+		err = t.ArgsOut[RemoveChildGroupMethod][0].(error)
+	}
+	return err
+}
+
+func (t TestRepo) IsChildOfGroup(childID string, parentID string) (bool, error) {
+	t.ArgsIn[IsChildOfGroupMethod][0] = childID
+	t.ArgsIn[IsChildOfGroupMethod][1] = parentID
+	var isChild bool
+	if t.ArgsOut[IsChildOfGroupMethod][0] != nil {
+		isChild = t.ArgsOut[IsChildOfGroupMethod][0].(bool)
+	}
+	var err error
+	if t.ArgsOut[IsChildOfGroupMethod][1] != nil {
+		err = t.ArgsOut[IsChildOfGroupMethod][1].(error)
+	}
+	return isChild, err
+}
+
+func (t TestRepo) GetChildGroups(parentID string, filter *Filter) ([]GroupGroupRelation, int, error) {
+	t.ArgsIn[GetChildGroupsMethod][0] = parentID
+	t.ArgsIn[GetChildGroupsMethod][1] = filter
+	var children []GroupGroupRelation
+	if t.ArgsOut[GetChildGroupsMethod][0] != nil {
+		testChildren := t.ArgsOut[GetChildGroupsMethod][0].([]TestGroupGroupRelation)
+		for _, v := range testChildren {
+			children = append(children, v)
+		}
+	}
+	var total int
+	if t.ArgsOut[GetChildGroupsMethod][1] != nil {
+		total = t.ArgsOut[GetChildGroupsMethod][1].(int)
+	}
+	var err error
+	if t.ArgsOut[GetChildGroupsMethod][2] != nil {
+		err = t.ArgsOut[GetChildGroupsMethod][2].(error)
+	}
+	return children, total, err
+}
+
+func (t TestRepo) GetAncestorGroups(groupIDs []string) ([]Group, error) {
+	t.ArgsIn[GetAncestorGroupsMethod][0] = groupIDs
+	var groups []Group
+	if t.ArgsOut[GetAncestorGroupsMethod][0] != nil {
+		groups = t.ArgsOut[GetAncestorGroupsMethod][0].([]Group)
+	}
+	var err error
+	if t.ArgsOut[GetAncestorGroupsMethod][1] != nil {
+		err = t.ArgsOut[GetAncestorGroupsMethod][1].(error)
+	}
+	return groups, err
+}
+
+func (t TestRepo) RemoveExpiredRelations(date time.Time) (int, error) {
+	t.ArgsIn[RemoveExpiredRelationsMethod][0] = date
+	var removed int
+	if t.ArgsOut[RemoveExpiredRelationsMethod][0] != nil {
+		removed = t.ArgsOut[RemoveExpiredRelationsMethod][0].(int)
+	}
+	var err error
+	if t.ArgsOut[RemoveExpiredRelationsMethod][1] != nil {
+		err = t.ArgsOut[RemoveExpiredRelationsMethod][1].(error)
+	}
+	return removed, err
+}
+
 //////////////////
 // Policy repo
 //////////////////
@@ -536,8 +833,9 @@ func (t TestRepo) AddPolicy(policy Policy) (*Policy, error) {
 	return created, err
 }
 
-func (t TestRepo) UpdatePolicy(policy Policy) (*Policy, error) {
+func (t TestRepo) UpdatePolicy(policy Policy, oldUpdateAt time.Time) (*Policy, error) {
 	t.ArgsIn[UpdatePolicyMethod][0] = policy
+	t.ArgsIn[UpdatePolicyMethod][1] = oldUpdateAt
 
 	var updated *Policy
 	if t.ArgsOut[UpdatePolicyMethod][0] != nil {
@@ -624,8 +922,9 @@ func (t TestRepo) AddProxyResource(pr ProxyResource) (*ProxyResource, error) {
 	return created, err
 }
 
-func (t TestRepo) UpdateProxyResource(proxyResource ProxyResource) (*ProxyResource, error) {
+func (t TestRepo) UpdateProxyResource(proxyResource ProxyResource, oldUpdateAt time.Time) (*ProxyResource, error) {
 	t.ArgsIn[UpdateProxyResourceMethod][0] = proxyResource
+	t.ArgsIn[UpdateProxyResourceMethod][1] = oldUpdateAt
 
 	var updated *ProxyResource
 	if t.ArgsOut[UpdateProxyResourceMethod][0] != nil {
@@ -682,6 +981,98 @@ func (t TestRepo) RemoveProxyResource(id string) error {
 	return err
 }
 
+func (t TestRepo) GetUpstreamPools(filter *Filter) ([]UpstreamPool, int, error) {
+	t.ArgsIn[GetUpstreamPoolsMethod][0] = filter
+
+	var pools []UpstreamPool
+	if t.ArgsOut[GetUpstreamPoolsMethod][0] != nil {
+		pools = t.ArgsOut[GetUpstreamPoolsMethod][0].([]UpstreamPool)
+	}
+	var total int
+	if t.ArgsOut[GetUpstreamPoolsMethod][1] != nil {
+		total = t.ArgsOut[GetUpstreamPoolsMethod][1].(int)
+	}
+	var err error
+	if t.ArgsOut[GetUpstreamPoolsMethod][2] != nil {
+		err = t.ArgsOut[GetUpstreamPoolsMethod][2].(error)
+	}
+	return pools, total, err
+}
+
+func (t TestRepo) GetUpstreamPoolByName(org string, name string) (*UpstreamPool, error) {
+	t.ArgsIn[GetUpstreamPoolByNameMethod][0] = org
+	t.ArgsIn[GetUpstreamPoolByNameMethod][1] = name
+	if specialFunc, ok := t.SpecialFuncs[GetUpstreamPoolByNameMethod].(func(org string, name string) (*UpstreamPool, error)); ok && specialFunc != nil {
+		return specialFunc(org, name)
+	}
+	var pool *UpstreamPool
+	if t.ArgsOut[GetUpstreamPoolByNameMethod][0] != nil {
+		pool = t.ArgsOut[GetUpstreamPoolByNameMethod][0].(*UpstreamPool)
+	}
+	var err error
+	if t.ArgsOut[GetUpstreamPoolByNameMethod][1] != nil {
+		err = t.ArgsOut[GetUpstreamPoolByNameMethod][1].(error)
+	}
+	return pool, err
+}
+
+func (t TestRepo) AddUpstreamPool(pool UpstreamPool) (*UpstreamPool, error) {
+	t.ArgsIn[AddUpstreamPoolMethod][0] = pool
+	var created *UpstreamPool
+	if t.ArgsOut[AddUpstreamPoolMethod][0] != nil {
+		created = t.ArgsOut[AddUpstreamPoolMethod][0].(*UpstreamPool)
+	}
+	var err error
+	if t.ArgsOut[AddUpstreamPoolMethod][1] != nil {
+		err = t.ArgsOut[AddUpstreamPoolMethod][1].(error)
+	}
+	return created, err
+}
+
+func (t TestRepo) UpdateUpstreamPool(pool UpstreamPool, oldUpdateAt time.Time) (*UpstreamPool, error) {
+	t.ArgsIn[UpdateUpstreamPoolMethod][0] = pool
+	t.ArgsIn[UpdateUpstreamPoolMethod][1] = oldUpdateAt
+
+	var updated *UpstreamPool
+	if t.ArgsOut[UpdateUpstreamPoolMethod][0] != nil {
+		updated = t.ArgsOut[UpdateUpstreamPoolMethod][0].(*UpstreamPool)
+	}
+	var err error
+	if t.ArgsOut[UpdateUpstreamPoolMethod][1] != nil {
+		err = t.ArgsOut[UpdateUpstreamPoolMethod][1].(error)
+	}
+	return updated, err
+}
+
+func (t TestRepo) RemoveUpstreamPool(id string) error {
+	t.ArgsIn[RemoveUpstreamPoolMethod][0] = id
+	var err error
+	if t.ArgsOut[RemoveUpstreamPoolMethod][0] != nil {
+		err = t.ArgsOut[RemoveUpstreamPoolMethod][0].(error)
+	}
+	return err
+}
+
+func (t TestRepo) GetProxyRevision() (int64, error) {
+	var revision int64
+	if t.ArgsOut[GetProxyRevisionMethod][0] != nil {
+		revision = t.ArgsOut[GetProxyRevisionMethod][0].(int64)
+	}
+	var err error
+	if t.ArgsOut[GetProxyRevisionMethod][1] != nil {
+		err = t.ArgsOut[GetProxyRevisionMethod][1].(error)
+	}
+	return revision, err
+}
+
+func (t TestRepo) IncrementProxyRevision() error {
+	var err error
+	if t.ArgsOut[IncrementProxyRevisionMethod][0] != nil {
+		err = t.ArgsOut[IncrementProxyRevisionMethod][0].(error)
+	}
+	return err
+}
+
 ///////////////////////////
 // Auth OIDC provider repo
 //////////////////////////
@@ -733,8 +1124,9 @@ func (t TestRepo) GetOidcProvidersFiltered(filter *Filter) ([]OidcProvider, int,
 	return resources, total, err
 }
 
-func (t TestRepo) UpdateOidcProvider(oidcProvider OidcProvider) (*OidcProvider, error) {
+func (t TestRepo) UpdateOidcProvider(oidcProvider OidcProvider, oldUpdateAt time.Time) (*OidcProvider, error) {
 	t.ArgsIn[UpdateOidcProviderMethod][0] = oidcProvider
+	t.ArgsIn[UpdateOidcProviderMethod][1] = oldUpdateAt
 
 	var updated *OidcProvider
 	if t.ArgsOut[UpdateOidcProviderMethod][0] != nil {
@@ -756,6 +1148,140 @@ func (t TestRepo) RemoveOidcProvider(id string) error {
 	return err
 }
 
+//////////////////
+// Audit repo
+//////////////////
+
+func (t TestRepo) AddAuditEntry(entry AuditEntry) error {
+	t.ArgsIn[AddAuditEntryMethod][0] = entry
+	var err error
+	if t.ArgsOut[AddAuditEntryMethod][0] != nil {
+		err = t.ArgsOut[AddAuditEntryMethod][0].(error)
+	}
+	return err
+}
+
+func (t TestRepo) GetAuditEntriesFiltered(filter *Filter) ([]AuditEntry, int, error) {
+	t.ArgsIn[GetAuditEntriesFilteredMethod][0] = filter
+
+	var entries []AuditEntry
+	if t.ArgsOut[GetAuditEntriesFilteredMethod][0] != nil {
+		entries = t.ArgsOut[GetAuditEntriesFilteredMethod][0].([]AuditEntry)
+	}
+	var total int
+	if t.ArgsOut[GetAuditEntriesFilteredMethod][1] != nil {
+		total = t.ArgsOut[GetAuditEntriesFilteredMethod][1].(int)
+	}
+	var err error
+	if t.ArgsOut[GetAuditEntriesFilteredMethod][2] != nil {
+		err = t.ArgsOut[GetAuditEntriesFilteredMethod][2].(error)
+	}
+	return entries, total, err
+}
+
+//////////////////
+// Webhook repo
+//////////////////
+
+func (t TestRepo) AddWebhook(webhook Webhook) (*Webhook, error) {
+	t.ArgsIn[AddWebhookMethod][0] = webhook
+	var created *Webhook
+	if t.ArgsOut[AddWebhookMethod][0] != nil {
+		created = t.ArgsOut[AddWebhookMethod][0].(*Webhook)
+	}
+	var err error
+	if t.ArgsOut[AddWebhookMethod][1] != nil {
+		err = t.ArgsOut[AddWebhookMethod][1].(error)
+	}
+	return created, err
+}
+
+func (t TestRepo) GetWebhookByName(name string) (*Webhook, error) {
+	t.ArgsIn[GetWebhookByNameMethod][0] = name
+	if specialFunc, ok := t.SpecialFuncs[GetWebhookByNameMethod].(func(name string) (*Webhook, error)); ok && specialFunc != nil {
+		return specialFunc(name)
+	}
+	var webhook *Webhook
+	if t.ArgsOut[GetWebhookByNameMethod][0] != nil {
+		webhook = t.ArgsOut[GetWebhookByNameMethod][0].(*Webhook)
+	}
+	var err error
+	if t.ArgsOut[GetWebhookByNameMethod][1] != nil {
+		err = t.ArgsOut[GetWebhookByNameMethod][1].(error)
+	}
+	return webhook, err
+}
+
+func (t TestRepo) GetWebhooksFiltered(filter *Filter) ([]Webhook, int, error) {
+	t.ArgsIn[GetWebhooksFilteredMethod][0] = filter
+
+	var webhooks []Webhook
+	if t.ArgsOut[GetWebhooksFilteredMethod][0] != nil {
+		webhooks = t.ArgsOut[GetWebhooksFilteredMethod][0].([]Webhook)
+	}
+	var total int
+	if t.ArgsOut[GetWebhooksFilteredMethod][1] != nil {
+		total = t.ArgsOut[GetWebhooksFilteredMethod][1].(int)
+	}
+	var err error
+	if t.ArgsOut[GetWebhooksFilteredMethod][2] != nil {
+		err = t.ArgsOut[GetWebhooksFilteredMethod][2].(error)
+	}
+	return webhooks, total, err
+}
+
+func (t TestRepo) UpdateWebhook(webhook Webhook, oldUpdateAt time.Time) (*Webhook, error) {
+	t.ArgsIn[UpdateWebhookMethod][0] = webhook
+	t.ArgsIn[UpdateWebhookMethod][1] = oldUpdateAt
+
+	var updated *Webhook
+	if t.ArgsOut[UpdateWebhookMethod][0] != nil {
+		updated = t.ArgsOut[UpdateWebhookMethod][0].(*Webhook)
+	}
+	var err error
+	if t.ArgsOut[UpdateWebhookMethod][1] != nil {
+		err = t.ArgsOut[UpdateWebhookMethod][1].(error)
+	}
+	return updated, err
+}
+
+func (t TestRepo) RemoveWebhook(id string) error {
+	t.ArgsIn[RemoveWebhookMethod][0] = id
+	var err error
+	if t.ArgsOut[RemoveWebhookMethod][0] != nil {
+		err = t.ArgsOut[RemoveWebhookMethod][0].(error)
+	}
+	return err
+}
+
+func (t TestRepo) AddWebhookDeadLetter(deadLetter WebhookDeadLetter) error {
+	t.ArgsIn[AddWebhookDeadLetterMethod][0] = deadLetter
+	var err error
+	if t.ArgsOut[AddWebhookDeadLetterMethod][0] != nil {
+		err = t.ArgsOut[AddWebhookDeadLetterMethod][0].(error)
+	}
+	return err
+}
+
+func (t TestRepo) GetWebhookDeadLettersFiltered(webhookID string, filter *Filter) ([]WebhookDeadLetter, int, error) {
+	t.ArgsIn[GetWebhookDeadLettersMethod][0] = webhookID
+	t.ArgsIn[GetWebhookDeadLettersMethod][1] = filter
+
+	var deadLetters []WebhookDeadLetter
+	if t.ArgsOut[GetWebhookDeadLettersMethod][0] != nil {
+		deadLetters = t.ArgsOut[GetWebhookDeadLettersMethod][0].([]WebhookDeadLetter)
+	}
+	var total int
+	if t.ArgsOut[GetWebhookDeadLettersMethod][1] != nil {
+		total = t.ArgsOut[GetWebhookDeadLettersMethod][1].(int)
+	}
+	var err error
+	if t.ArgsOut[GetWebhookDeadLettersMethod][2] != nil {
+		err = t.ArgsOut[GetWebhookDeadLettersMethod][2].(error)
+	}
+	return deadLetters, total, err
+}
+
 // Private helper methods
 
 func getRandomString(runeValue []rune, n int) string {
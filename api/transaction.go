@@ -0,0 +1,52 @@
+package api
+
+import (
+	"github.com/Tecsisa/foulkon/database"
+)
+
+// withTx calls fn with a copy of the API whose repositories are bound to a single transaction, so the reads,
+// checks and writes made by fn, and their audit entries, are atomic. The transaction is rolled back if fn
+// returns an error. The authorization cache isn't used inside the transaction, and it is invalidated once
+// the transaction is committed. Change events emitted by fn are published to webhooks after the commit too.
+func (api WorkerAPI) withTx(fn func(txAPI WorkerAPI) error) error {
+	events := []WebhookEvent{}
+	var fnErr error
+	err := api.UserRepo.WithTx(func(repos TxRepos) error {
+		// Repositories may call fn again if the transaction conflicts with a concurrent one, so only the events
+		// of the last call are published
+		events = events[:0]
+		txAPI := api
+		txAPI.UserRepo = repos.UserRepo
+		txAPI.GroupRepo = repos.GroupRepo
+		txAPI.PolicyRepo = repos.PolicyRepo
+		txAPI.ProxyRepo = repos.ProxyRepo
+		txAPI.AuthOidcRepo = repos.AuthOidcRepo
+		txAPI.AuditRepo = repos.AuditRepo
+		txAPI.WebhookRepo = repos.WebhookRepo
+		txAPI.AuthzCache = nil
+		// Nested transactions publish their events with the outer one
+		if txAPI.pendingEvents == nil {
+			txAPI.pendingEvents = &events
+		}
+
+		fnErr = fn(txAPI)
+		return fnErr
+	})
+
+	if fnErr != nil {
+		return fnErr
+	}
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	api.AuthzCache.Invalidate()
+	api.Webhooks.Publish(events)
+	return nil
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerAPI_withTx(t *testing.T) {
+	testcases := map[string]struct {
+		// Error returned by the operation
+		fnErr error
+		// Repo errors
+		withTxMethodErr error
+		// Expected result
+		wantError       error
+		wantRollback    bool
+		wantInvalidated bool
+	}{
+		"OkCase": {
+			wantInvalidated: true,
+		},
+		"ErrorCaseOperationErr": {
+			fnErr:        errors.New("Failure"),
+			wantError:    errors.New("Failure"),
+			wantRollback: true,
+		},
+		"ErrorCaseCommitTxErr": {
+			withTxMethodErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Commit error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Commit error",
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+		testAPI.AuthzCache = NewAuthzCache(time.Minute, 10)
+
+		testRepo.ArgsOut[WithTxMethod][0] = testcase.withTxMethodErr
+		err := testAPI.withTx(func(txAPI WorkerAPI) error {
+			assert.Nil(t, txAPI.AuthzCache, "Error in test case %v", x)
+			return testcase.fnErr
+		})
+		assert.Equal(t, testcase.wantError, err, "Error in test case %v", x)
+		assert.Equal(t, testcase.wantRollback, testRepo.ArgsIn[WithTxMethod][0] != nil, "Error in test case %v", x)
+		assert.Equal(t, testcase.wantInvalidated, testAPI.AuthzCache.generation > 0, "Error in test case %v", x)
+	}
+}
@@ -0,0 +1,595 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/satori/go.uuid"
+)
+
+const (
+	// Upstream pool balancers
+	UPSTREAM_BALANCER_ROUND_ROBIN       = "round-robin"
+	UPSTREAM_BALANCER_LEAST_CONNECTIONS = "least-connections"
+
+	// Default settings of upstream pools, times in seconds
+	DEFAULT_HEALTH_CHECK_INTERVAL  = 10
+	DEFAULT_HEALTH_CHECK_TIMEOUT   = 2
+	DEFAULT_HEALTH_CHECK_THRESHOLD = 2
+	DEFAULT_EJECTION_MAX_FAILURES  = 5
+	DEFAULT_EJECTION_DURATION      = 30
+	DEFAULT_MAX_IDLE_CONNS         = 100
+	DEFAULT_IDLE_CONN_TIMEOUT      = 90
+)
+
+// TYPE DEFINITIONS
+
+// UpstreamPool is a named group of targets that proxy resources of its org send requests to
+type UpstreamPool struct {
+	ID       string             `json:"id,omitempty"`
+	Name     string             `json:"name,omitempty"`
+	Org      string             `json:"org,omitempty"`
+	Path     string             `json:"path,omitempty"`
+	Urn      string             `json:"urn,omitempty"`
+	Config   UpstreamPoolConfig `json:"config,omitempty"`
+	CreateAt time.Time          `json:"createAt,omitempty"`
+	UpdateAt time.Time          `json:"updateAt,omitempty"`
+}
+
+// Upstream pool identifier to retrieve them from DB
+type UpstreamPoolIdentity struct {
+	Org  string `json:"org,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// UpstreamPoolConfig has the targets of the pool, how a target is selected for each request and how the
+// health of the targets is checked. Times are in seconds.
+type UpstreamPoolConfig struct {
+	// Target URLs, like proxy resource hosts
+	Targets []string `json:"targets,omitempty" yaml:"targets"`
+	// Selection of targets, round-robin by default
+	Balancer string `json:"balancer,omitempty" yaml:"balancer,omitempty"`
+	// Active health checks, disabled if nil
+	HealthCheck *UpstreamHealthCheck `json:"healthCheck,omitempty" yaml:"healthCheck,omitempty"`
+	// Passive health checks of the requests proxied
+	Ejection UpstreamEjection `json:"ejection" yaml:"ejection"`
+	// Connections to the targets, shared by all resources of the pool
+	Transport UpstreamTransport `json:"transport" yaml:"transport"`
+}
+
+// UpstreamHealthCheck requests Path to every target each Interval. A target is unhealthy after Threshold
+// failed checks in a row, and healthy again after Threshold successful checks in a row.
+type UpstreamHealthCheck struct {
+	Path      string `json:"path,omitempty" yaml:"path"`
+	Interval  int    `json:"interval,omitempty" yaml:"interval,omitempty"`
+	Timeout   int    `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Threshold int    `json:"threshold,omitempty" yaml:"threshold,omitempty"`
+}
+
+// UpstreamEjection ejects a target for Duration after MaxFailures requests in a row failed with
+// a connection error or a 5xx status code
+type UpstreamEjection struct {
+	MaxFailures int `json:"maxFailures,omitempty" yaml:"maxFailures,omitempty"`
+	Duration    int `json:"duration,omitempty" yaml:"duration,omitempty"`
+}
+
+// UpstreamTransport tunes the connections to the targets. Responses are waited without limit if
+// ResponseTimeout is 0.
+type UpstreamTransport struct {
+	MaxIdleConns    int `json:"maxIdleConns,omitempty" yaml:"maxIdleConns,omitempty"`
+	IdleConnTimeout int `json:"idleConnTimeout,omitempty" yaml:"idleConnTimeout,omitempty"`
+	ResponseTimeout int `json:"responseTimeout,omitempty" yaml:"responseTimeout,omitempty"`
+}
+
+func (u UpstreamPool) GetUrn() string {
+	return u.Urn
+}
+
+// UPSTREAM POOL API IMPLEMENTATION
+
+// GetUpstreamPools return upstream pools
+func (api ProxyAPI) GetUpstreamPools() ([]UpstreamPool, error) {
+	pools, _, err := api.ProxyRepo.GetUpstreamPools(&Filter{})
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	return pools, nil
+}
+
+func (api WorkerAPI) AddUpstreamPool(requestInfo RequestInfo, name string, org string, path string, config UpstreamPoolConfig) (*UpstreamPool, error) {
+	var pool *UpstreamPool
+	err := api.withTx(func(txAPI WorkerAPI) error {
+		var err error
+		pool, err = txAPI.addUpstreamPool(requestInfo, name, org, path, config)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+func (api WorkerAPI) addUpstreamPool(requestInfo RequestInfo, name string, org string, path string, config UpstreamPoolConfig) (*UpstreamPool, error) {
+	// Validate fields
+	if !IsValidName(name) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: name %v", name),
+		}
+	}
+	if !IsValidOrg(org) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: org %v", org),
+		}
+	}
+	if !IsValidPath(path) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: path %v", path),
+		}
+	}
+	if err := IsValidUpstreamPoolConfig(&config); err != nil {
+		return nil, err
+	}
+	config = normalizeUpstreamPoolConfig(config)
+
+	pool := createUpstreamPool(name, org, path, config)
+
+	// Check restrictions
+	poolsFiltered, err := api.GetAuthorizedUpstreamPools(requestInfo, pool.Urn, UPSTREAM_ACTION_CREATE_POOL, []UpstreamPool{pool})
+	if err != nil {
+		return nil, err
+	}
+	if len(poolsFiltered) < 1 {
+		return nil, &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, pool.Urn),
+		}
+	}
+
+	// Check if upstream pool already exists
+	_, err = api.ProxyRepo.GetUpstreamPoolByName(org, name)
+
+	if err != nil {
+		// Transform to DB error
+		dbError := err.(*database.Error)
+		switch dbError.Code {
+		// Upstream pool doesn't exist in DB
+		case database.UPSTREAM_POOL_NOT_FOUND:
+			// Create upstream pool
+			created, err := api.ProxyRepo.AddUpstreamPool(pool)
+
+			// Check unexpected DB error
+			if err != nil {
+				//Transform to DB error
+				dbError := err.(*database.Error)
+				return nil, &Error{
+					Code:    UNKNOWN_API_ERROR,
+					Message: dbError.Message,
+				}
+			}
+			if err := api.incrementProxyRevision(); err != nil {
+				return nil, err
+			}
+			if err := api.audit(requestInfo, UPSTREAM_ACTION_CREATE_POOL, created.Urn, nil, created); err != nil {
+				return nil, err
+			}
+			LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Upstream pool created %+v", created))
+			return created, nil
+		default: // Unexpected error
+			return nil, &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: dbError.Message,
+			}
+		}
+	} else {
+		return nil, &Error{
+			Code:    UPSTREAM_POOL_ALREADY_EXIST,
+			Message: fmt.Sprintf("Unable to create upstream pool, upstream pool with org %v and name %v already exist", org, name),
+		}
+	}
+}
+
+func (api WorkerAPI) GetUpstreamPoolByName(requestInfo RequestInfo, org string, name string) (*UpstreamPool, error) {
+	// Validate fields
+	if !IsValidName(name) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: name %v", name),
+		}
+	}
+	if !IsValidOrg(org) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: org %v", org),
+		}
+	}
+
+	// Call repo to retrieve the upstream pool
+	pool, err := api.ProxyRepo.GetUpstreamPoolByName(org, name)
+
+	// Error handling
+	if err != nil {
+		// Transform to DB error
+		dbError := err.(*database.Error)
+		// Upstream pool doesn't exist in DB
+		switch dbError.Code {
+		case database.UPSTREAM_POOL_NOT_FOUND:
+			return nil, &Error{
+				Code:    UPSTREAM_POOL_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: dbError.Message,
+			}
+		default:
+			return nil, &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: dbError.Message,
+			}
+		}
+	}
+
+	// Check restrictions
+	poolsFiltered, err := api.GetAuthorizedUpstreamPools(requestInfo, pool.Urn, UPSTREAM_ACTION_GET_POOL, []UpstreamPool{*pool})
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if we have our user authorized
+	if len(poolsFiltered) > 0 {
+		poolFiltered := poolsFiltered[0]
+		return &poolFiltered, nil
+	}
+	return nil, &Error{
+		Code: UNAUTHORIZED_RESOURCES_ERROR,
+		Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+			requestInfo.Identifier, pool.Urn),
+	}
+}
+
+func (api WorkerAPI) ListUpstreamPools(requestInfo RequestInfo, filter *Filter) ([]UpstreamPoolIdentity, int, error) {
+	// Validate fields
+	var total int
+	orderByValidColumns := api.ProxyRepo.OrderByValidColumns(UPSTREAM_ACTION_LIST_POOLS)
+	err := validateFilter(filter, orderByValidColumns)
+	if err != nil {
+		return nil, total, err
+	}
+
+	// Call repo to retrieve the upstream pools
+	pools, total, err := api.ProxyRepo.GetUpstreamPools(filter)
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, total, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	// Check restrictions
+	var urnPrefix string
+	if len(filter.Org) == 0 {
+		urnPrefix = "*"
+	} else {
+		urnPrefix = GetUrnPrefix(filter.Org, RESOURCE_UPSTREAM_POOL, filter.PathPrefix)
+	}
+	poolsFiltered, err := api.GetAuthorizedUpstreamPools(requestInfo, urnPrefix, UPSTREAM_ACTION_LIST_POOLS, pools)
+	if err != nil {
+		return nil, total, err
+	}
+
+	poolIDs := []UpstreamPoolIdentity{}
+	for _, p := range poolsFiltered {
+		poolIDs = append(poolIDs, UpstreamPoolIdentity{
+			Org:  p.Org,
+			Name: p.Name,
+		})
+	}
+
+	return poolIDs, total, nil
+}
+
+func (api WorkerAPI) UpdateUpstreamPool(requestInfo RequestInfo, org string, name string, newName string, newPath string,
+	newConfig UpstreamPoolConfig) (*UpstreamPool, error) {
+	var pool *UpstreamPool
+	err := api.withTx(func(txAPI WorkerAPI) error {
+		var err error
+		pool, err = txAPI.updateUpstreamPool(requestInfo, org, name, newName, newPath, newConfig)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+func (api WorkerAPI) updateUpstreamPool(requestInfo RequestInfo, org string, name string, newName string, newPath string,
+	newConfig UpstreamPoolConfig) (*UpstreamPool, error) {
+	// Validate fields
+	if !IsValidName(newName) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: new name %v", newName),
+		}
+	}
+	if !IsValidPath(newPath) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: new path %v", newPath),
+		}
+	}
+	if err := IsValidUpstreamPoolConfig(&newConfig); err != nil {
+		return nil, err
+	}
+	newConfig = normalizeUpstreamPoolConfig(newConfig)
+
+	// Call repo to retrieve the old upstream pool
+	oldPool, err := api.GetUpstreamPoolByName(requestInfo, org, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check restrictions
+	poolsFiltered, err := api.GetAuthorizedUpstreamPools(requestInfo, oldPool.Urn, UPSTREAM_ACTION_UPDATE_POOL, []UpstreamPool{*oldPool})
+	if err != nil {
+		return nil, err
+	}
+	if len(poolsFiltered) < 1 {
+		return nil, &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, oldPool.Urn),
+		}
+	}
+
+	// Check the request applies to the current version
+	if err := checkIfMatch(requestInfo, oldPool.Urn, oldPool.UpdateAt); err != nil {
+		return nil, err
+	}
+
+	if newName != oldPool.Name {
+		// Check if an upstream pool with "newName" already exists
+		newPool, err := api.GetUpstreamPoolByName(requestInfo, org, newName)
+
+		if err == nil && oldPool.ID != newPool.ID {
+			// Upstream pool already exists
+			return nil, &Error{
+				Code:    UPSTREAM_POOL_ALREADY_EXIST,
+				Message: fmt.Sprintf("Upstream pool name: %v already exists", newName),
+			}
+		}
+
+		if err != nil {
+			if convertedError := err.(*Error); convertedError.Code != UPSTREAM_POOL_BY_ORG_AND_NAME_NOT_FOUND {
+				return nil, err
+			}
+		}
+
+		// Proxy resources reference the pool by name
+		if err := api.checkUpstreamPoolNotInUse(*oldPool); err != nil {
+			return nil, err
+		}
+	}
+
+	auxPool := UpstreamPool{
+		Urn: CreateUrn(org, RESOURCE_UPSTREAM_POOL, newPath, newName),
+	}
+
+	// Check restrictions
+	poolsFiltered, err = api.GetAuthorizedUpstreamPools(requestInfo, auxPool.Urn, UPSTREAM_ACTION_UPDATE_POOL, []UpstreamPool{auxPool})
+	if err != nil {
+		return nil, err
+	}
+	if len(poolsFiltered) < 1 {
+		return nil, &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, auxPool.Urn),
+		}
+	}
+
+	// Update upstream pool
+	pool := UpstreamPool{
+		ID:       oldPool.ID,
+		Name:     newName,
+		Org:      oldPool.Org,
+		Path:     newPath,
+		Urn:      auxPool.Urn,
+		Config:   newConfig,
+		CreateAt: oldPool.CreateAt,
+		UpdateAt: time.Now().UTC(),
+	}
+
+	updatedPool, err := api.ProxyRepo.UpdateUpstreamPool(pool, oldPool.UpdateAt)
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		switch dbError.Code {
+		case database.VERSION_CONFLICT:
+			return nil, &Error{
+				Code:    PRECONDITION_FAILED,
+				Message: dbError.Message,
+			}
+		default: // Unexpected error
+			return nil, &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: dbError.Message,
+			}
+		}
+	}
+
+	if err := api.incrementProxyRevision(); err != nil {
+		return nil, err
+	}
+	if err := api.audit(requestInfo, UPSTREAM_ACTION_UPDATE_POOL, oldPool.Urn, oldPool, updatedPool); err != nil {
+		return nil, err
+	}
+	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Upstream pool updated from %+v to %+v", oldPool, updatedPool))
+	return updatedPool, nil
+}
+
+func (api WorkerAPI) RemoveUpstreamPool(requestInfo RequestInfo, org string, name string) error {
+	return api.withTx(func(txAPI WorkerAPI) error {
+		return txAPI.removeUpstreamPool(requestInfo, org, name)
+	})
+}
+
+func (api WorkerAPI) removeUpstreamPool(requestInfo RequestInfo, org string, name string) error {
+	// Call repo to retrieve the upstream pool
+	pool, err := api.GetUpstreamPoolByName(requestInfo, org, name)
+	if err != nil {
+		return err
+	}
+
+	// Check restrictions
+	poolsFiltered, err := api.GetAuthorizedUpstreamPools(requestInfo, pool.Urn, UPSTREAM_ACTION_DELETE_POOL, []UpstreamPool{*pool})
+	if err != nil {
+		return err
+	}
+	if len(poolsFiltered) < 1 {
+		return &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, pool.Urn),
+		}
+	}
+
+	// Check the request applies to the current version
+	if err := checkIfMatch(requestInfo, pool.Urn, pool.UpdateAt); err != nil {
+		return err
+	}
+
+	if err := api.checkUpstreamPoolNotInUse(*pool); err != nil {
+		return err
+	}
+
+	err = api.ProxyRepo.RemoveUpstreamPool(pool.ID)
+
+	// Error handling
+	if err != nil {
+		// Transform to DB error
+		dbError := err.(*database.Error)
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	if err := api.incrementProxyRevision(); err != nil {
+		return err
+	}
+	if err := api.audit(requestInfo, UPSTREAM_ACTION_DELETE_POOL, pool.Urn, pool, nil); err != nil {
+		return err
+	}
+	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Upstream pool deleted %+v", pool))
+	return nil
+}
+
+// PRIVATE HELPER METHODS
+
+// checkUpstreamPoolNotInUse returns an UPSTREAM_POOL_IN_USE error if a proxy resource sends requests to the pool
+func (api WorkerAPI) checkUpstreamPoolNotInUse(pool UpstreamPool) error {
+	proxyResources, _, err := api.ProxyRepo.GetProxyResources(&Filter{Org: pool.Org})
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+	for _, pr := range proxyResources {
+		if pr.Resource.Upstream == pool.Name {
+			return &Error{
+				Code: UPSTREAM_POOL_IN_USE,
+				Message: fmt.Sprintf("Upstream pool with org %v and name %v is used by proxy resource %v",
+					pool.Org, pool.Name, pr.Name),
+			}
+		}
+	}
+	return nil
+}
+
+// checkUpstreamPoolExists returns an UPSTREAM_POOL_BY_ORG_AND_NAME_NOT_FOUND error if the upstream pool of a proxy
+// resource doesn't exist
+func (api WorkerAPI) checkUpstreamPoolExists(org string, name string) error {
+	_, err := api.ProxyRepo.GetUpstreamPoolByName(org, name)
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		if dbError.Code == database.UPSTREAM_POOL_NOT_FOUND {
+			return &Error{
+				Code:    UPSTREAM_POOL_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: dbError.Message,
+			}
+		}
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+	return nil
+}
+
+func createUpstreamPool(name string, org string, path string, config UpstreamPoolConfig) UpstreamPool {
+	pool := UpstreamPool{
+		ID:       uuid.NewV4().String(),
+		Name:     name,
+		Org:      org,
+		Path:     path,
+		Urn:      CreateUrn(org, RESOURCE_UPSTREAM_POOL, path, name),
+		Config:   config,
+		CreateAt: time.Now().UTC(),
+		UpdateAt: time.Now().UTC(),
+	}
+
+	return pool
+}
+
+// normalizeUpstreamPoolConfig returns the config with default values instead of zero values, so the settings
+// used by the proxy are stored
+func normalizeUpstreamPoolConfig(config UpstreamPoolConfig) UpstreamPoolConfig {
+	if config.Balancer == "" {
+		config.Balancer = UPSTREAM_BALANCER_ROUND_ROBIN
+	}
+	if config.HealthCheck != nil {
+		hc := *config.HealthCheck
+		if hc.Interval == 0 {
+			hc.Interval = DEFAULT_HEALTH_CHECK_INTERVAL
+		}
+		if hc.Timeout == 0 {
+			hc.Timeout = DEFAULT_HEALTH_CHECK_TIMEOUT
+		}
+		if hc.Threshold == 0 {
+			hc.Threshold = DEFAULT_HEALTH_CHECK_THRESHOLD
+		}
+		config.HealthCheck = &hc
+	}
+	if config.Ejection.MaxFailures == 0 {
+		config.Ejection.MaxFailures = DEFAULT_EJECTION_MAX_FAILURES
+	}
+	if config.Ejection.Duration == 0 {
+		config.Ejection.Duration = DEFAULT_EJECTION_DURATION
+	}
+	if config.Transport.MaxIdleConns == 0 {
+		config.Transport.MaxIdleConns = DEFAULT_MAX_IDLE_CONNS
+	}
+	if config.Transport.IdleConnTimeout == 0 {
+		config.Transport.IdleConnTimeout = DEFAULT_IDLE_CONN_TIMEOUT
+	}
+	return config
+}
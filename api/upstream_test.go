@@ -0,0 +1,960 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxyAPI_GetUpstreamPools(t *testing.T) {
+	testcases := map[string]struct {
+		wantError error
+
+		getUpstreamPoolsMethod []UpstreamPool
+		getUpstreamPoolsErr    error
+	}{
+		"OkCase": {
+			getUpstreamPoolsMethod: []UpstreamPool{
+				{
+					Name: "pool1",
+					Org:  "org1",
+					Config: UpstreamPoolConfig{
+						Targets: []string{"http://10.0.0.1:8080"},
+					},
+				},
+			},
+		},
+		"ErrorCaseInternalError": {
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+			getUpstreamPoolsErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+		},
+	}
+
+	for n, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeProxyTestAPI(testRepo)
+
+		testRepo.ArgsOut[GetUpstreamPoolsMethod][0] = testcase.getUpstreamPoolsMethod
+		testRepo.ArgsOut[GetUpstreamPoolsMethod][2] = testcase.getUpstreamPoolsErr
+
+		pools, err := testAPI.GetUpstreamPools()
+		checkMethodResponse(t, n, testcase.wantError, err, testcase.getUpstreamPoolsMethod, pools)
+	}
+}
+
+func TestWorkerAPI_AddUpstreamPool(t *testing.T) {
+	testcases := map[string]struct {
+		requestInfo RequestInfo
+		name        string
+		org         string
+		path        string
+		config      UpstreamPoolConfig
+
+		getUserByExternalIDResult *User
+
+		addUpstreamPoolMethodResult       *UpstreamPool
+		getUpstreamPoolByNameMethodResult *UpstreamPool
+		expectedConfig                    UpstreamPoolConfig
+		wantError                         error
+
+		getUpstreamPoolByNameMethodErr error
+		addUpstreamPoolMethodErr       error
+	}{
+		"OKCaseDefaultSettings": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "pool1",
+			org:  "org1",
+			path: "/path/",
+			config: UpstreamPoolConfig{
+				Targets:     []string{"http://10.0.0.1:8080", "http://10.0.0.2:8080"},
+				HealthCheck: &UpstreamHealthCheck{Path: "/health"},
+			},
+			getUpstreamPoolByNameMethodErr: &database.Error{
+				Code: database.UPSTREAM_POOL_NOT_FOUND,
+			},
+			addUpstreamPoolMethodResult: &UpstreamPool{
+				ID:   "test1",
+				Name: "pool1",
+				Org:  "org1",
+				Path: "/path/",
+				Urn:  CreateUrn("org1", RESOURCE_UPSTREAM_POOL, "/path/", "pool1"),
+			},
+			expectedConfig: UpstreamPoolConfig{
+				Targets:  []string{"http://10.0.0.1:8080", "http://10.0.0.2:8080"},
+				Balancer: UPSTREAM_BALANCER_ROUND_ROBIN,
+				HealthCheck: &UpstreamHealthCheck{
+					Path:      "/health",
+					Interval:  DEFAULT_HEALTH_CHECK_INTERVAL,
+					Timeout:   DEFAULT_HEALTH_CHECK_TIMEOUT,
+					Threshold: DEFAULT_HEALTH_CHECK_THRESHOLD,
+				},
+				Ejection: UpstreamEjection{
+					MaxFailures: DEFAULT_EJECTION_MAX_FAILURES,
+					Duration:    DEFAULT_EJECTION_DURATION,
+				},
+				Transport: UpstreamTransport{
+					MaxIdleConns:    DEFAULT_MAX_IDLE_CONNS,
+					IdleConnTimeout: DEFAULT_IDLE_CONN_TIMEOUT,
+				},
+			},
+		},
+		"OKCaseCustomSettings": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "pool1",
+			org:  "org1",
+			path: "/path/",
+			config: UpstreamPoolConfig{
+				Targets:   []string{"http://10.0.0.1:8080"},
+				Balancer:  UPSTREAM_BALANCER_LEAST_CONNECTIONS,
+				Ejection:  UpstreamEjection{MaxFailures: 3, Duration: 60},
+				Transport: UpstreamTransport{MaxIdleConns: 10, IdleConnTimeout: 30, ResponseTimeout: 5},
+			},
+			getUpstreamPoolByNameMethodErr: &database.Error{
+				Code: database.UPSTREAM_POOL_NOT_FOUND,
+			},
+			addUpstreamPoolMethodResult: &UpstreamPool{
+				ID:   "test1",
+				Name: "pool1",
+				Org:  "org1",
+				Path: "/path/",
+				Urn:  CreateUrn("org1", RESOURCE_UPSTREAM_POOL, "/path/", "pool1"),
+			},
+			expectedConfig: UpstreamPoolConfig{
+				Targets:   []string{"http://10.0.0.1:8080"},
+				Balancer:  UPSTREAM_BALANCER_LEAST_CONNECTIONS,
+				Ejection:  UpstreamEjection{MaxFailures: 3, Duration: 60},
+				Transport: UpstreamTransport{MaxIdleConns: 10, IdleConnTimeout: 30, ResponseTimeout: 5},
+			},
+		},
+		"ErrorCaseUpstreamPoolAlreadyExists": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "pool1",
+			org:  "org1",
+			path: "/path/",
+			config: UpstreamPoolConfig{
+				Targets: []string{"http://10.0.0.1:8080"},
+			},
+			getUpstreamPoolByNameMethodResult: &UpstreamPool{
+				ID:   "test1",
+				Name: "pool1",
+				Org:  "org1",
+				Path: "/path/",
+			},
+			wantError: &Error{
+				Code:    UPSTREAM_POOL_ALREADY_EXIST,
+				Message: "Unable to create upstream pool, upstream pool with org org1 and name pool1 already exist",
+			},
+		},
+		"ErrorCaseBadName": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "**!^#~",
+			org:  "org1",
+			path: "/path/",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: name **!^#~",
+			},
+		},
+		"ErrorCaseBadOrg": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "pool1",
+			org:  "**!^#~",
+			path: "/path/",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: org **!^#~",
+			},
+		},
+		"ErrorCaseBadPath": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "pool1",
+			org:  "org1",
+			path: "*/ /**!^#~path/",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: path */ /**!^#~path/",
+			},
+		},
+		"ErrorCaseBadConfig": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "pool1",
+			org:  "org1",
+			path: "/path/",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: targets must have between 1 and 50 items",
+			},
+		},
+		"ErrorCaseNoPermissions": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			name: "pool1",
+			org:  "org1",
+			path: "/path/",
+			config: UpstreamPoolConfig{
+				Targets: []string{"http://10.0.0.1:8080"},
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "543210",
+				ExternalID: "123456",
+				Path:       "/path/",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "123456"),
+			},
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId 123456 is not allowed to access to resource urn:iws:iam:org1:upstream/path/pool1",
+			},
+		},
+		"ErrorCaseAddUpstreamPoolErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "pool1",
+			org:  "org1",
+			path: "/path/",
+			config: UpstreamPoolConfig{
+				Targets: []string{"http://10.0.0.1:8080"},
+			},
+			getUpstreamPoolByNameMethodErr: &database.Error{
+				Code: database.UPSTREAM_POOL_NOT_FOUND,
+			},
+			addUpstreamPoolMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+		"ErrorCaseGetUpstreamPoolDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "pool1",
+			org:  "org1",
+			path: "/path/",
+			config: UpstreamPoolConfig{
+				Targets: []string{"http://10.0.0.1:8080"},
+			},
+			getUpstreamPoolByNameMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	testRepo := makeTestRepo()
+	testAPI := makeTestAPI(testRepo)
+
+	for x, testcase := range testcases {
+		testRepo.ArgsOut[AddUpstreamPoolMethod][0] = testcase.addUpstreamPoolMethodResult
+		testRepo.ArgsOut[AddUpstreamPoolMethod][1] = testcase.addUpstreamPoolMethodErr
+		testRepo.ArgsOut[GetUpstreamPoolByNameMethod][0] = testcase.getUpstreamPoolByNameMethodResult
+		testRepo.ArgsOut[GetUpstreamPoolByNameMethod][1] = testcase.getUpstreamPoolByNameMethodErr
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = testcase.getUserByExternalIDResult
+		pool, err := testAPI.AddUpstreamPool(testcase.requestInfo, testcase.name, testcase.org, testcase.path, testcase.config)
+		checkMethodResponse(t, x, testcase.wantError, err, pool, testcase.addUpstreamPoolMethodResult)
+		if testcase.wantError == nil {
+			// Check stored upstream pool
+			storedPool := testRepo.ArgsIn[AddUpstreamPoolMethod][0].(UpstreamPool)
+			assert.Equal(t, testcase.expectedConfig, storedPool.Config, "Error in test case %v", x)
+		}
+	}
+}
+
+func TestWorkerAPI_GetUpstreamPoolByName(t *testing.T) {
+	testcases := map[string]struct {
+		requestInfo RequestInfo
+		org         string
+		name        string
+
+		getUserByExternalIDResult *User
+
+		getUpstreamPoolByNameMethodResult *UpstreamPool
+		wantError                         error
+
+		getUpstreamPoolByNameMethodErr error
+	}{
+		"OKCase": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:  "org1",
+			name: "pool1",
+			getUpstreamPoolByNameMethodResult: &UpstreamPool{
+				ID:   "test1",
+				Name: "pool1",
+				Org:  "org1",
+				Path: "/path/",
+				Urn:  CreateUrn("org1", RESOURCE_UPSTREAM_POOL, "/path/", "pool1"),
+				Config: UpstreamPoolConfig{
+					Targets: []string{"http://10.0.0.1:8080"},
+				},
+			},
+		},
+		"ErrorCaseBadName": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:  "org1",
+			name: "**!^#~",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: name **!^#~",
+			},
+		},
+		"ErrorCaseBadOrg": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:  "**!^#~",
+			name: "pool1",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: org **!^#~",
+			},
+		},
+		"ErrorCaseUpstreamPoolNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:  "org1",
+			name: "pool1",
+			getUpstreamPoolByNameMethodErr: &database.Error{
+				Code:    database.UPSTREAM_POOL_NOT_FOUND,
+				Message: "Upstream pool with organization org1 and name pool1 not found",
+			},
+			wantError: &Error{
+				Code:    UPSTREAM_POOL_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Upstream pool with organization org1 and name pool1 not found",
+			},
+		},
+		"ErrorCaseInternalError": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:  "org1",
+			name: "pool1",
+			getUpstreamPoolByNameMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+		"ErrorCaseNoPermissions": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			org:  "org1",
+			name: "pool1",
+			getUpstreamPoolByNameMethodResult: &UpstreamPool{
+				ID:   "test1",
+				Name: "pool1",
+				Org:  "org1",
+				Path: "/path/",
+				Urn:  CreateUrn("org1", RESOURCE_UPSTREAM_POOL, "/path/", "pool1"),
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "543210",
+				ExternalID: "123456",
+				Path:       "/path/",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "123456"),
+			},
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId 123456 is not allowed to access to resource urn:iws:iam:org1:upstream/path/pool1",
+			},
+		},
+	}
+
+	testRepo := makeTestRepo()
+	testAPI := makeTestAPI(testRepo)
+
+	for x, testcase := range testcases {
+		testRepo.ArgsOut[GetUpstreamPoolByNameMethod][0] = testcase.getUpstreamPoolByNameMethodResult
+		testRepo.ArgsOut[GetUpstreamPoolByNameMethod][1] = testcase.getUpstreamPoolByNameMethodErr
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = testcase.getUserByExternalIDResult
+		pool, err := testAPI.GetUpstreamPoolByName(testcase.requestInfo, testcase.org, testcase.name)
+		checkMethodResponse(t, x, testcase.wantError, err, pool, testcase.getUpstreamPoolByNameMethodResult)
+	}
+}
+
+func TestWorkerAPI_ListUpstreamPools(t *testing.T) {
+	testcases := map[string]struct {
+		requestInfo RequestInfo
+		filter      *Filter
+
+		expectedPools []UpstreamPoolIdentity
+		wantError     error
+
+		getUpstreamPoolsMethodResult []UpstreamPool
+		getUpstreamPoolsMethodErr    error
+	}{
+		"OKCase": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				Org:        "org1",
+				PathPrefix: "/path/",
+			},
+			getUpstreamPoolsMethodResult: []UpstreamPool{
+				{
+					ID:   "test1",
+					Name: "pool1",
+					Org:  "org1",
+					Path: "/path/",
+					Urn:  CreateUrn("org1", RESOURCE_UPSTREAM_POOL, "/path/", "pool1"),
+				},
+				{
+					ID:   "test2",
+					Name: "pool2",
+					Org:  "org1",
+					Path: "/path/",
+					Urn:  CreateUrn("org1", RESOURCE_UPSTREAM_POOL, "/path/", "pool2"),
+				},
+			},
+			expectedPools: []UpstreamPoolIdentity{
+				{Org: "org1", Name: "pool1"},
+				{Org: "org1", Name: "pool2"},
+			},
+		},
+		"ErrorCaseInvalidPath": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				PathPrefix: "/path*/ /*",
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: pathPrefix /path*/ /*",
+			},
+		},
+		"ErrorCaseInternalError": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				PathPrefix: "/path/",
+			},
+			getUpstreamPoolsMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	testRepo := makeTestRepo()
+	testAPI := makeTestAPI(testRepo)
+
+	for x, testcase := range testcases {
+		testRepo.ArgsOut[GetUpstreamPoolsMethod][0] = testcase.getUpstreamPoolsMethodResult
+		testRepo.ArgsOut[GetUpstreamPoolsMethod][1] = len(testcase.getUpstreamPoolsMethodResult)
+		testRepo.ArgsOut[GetUpstreamPoolsMethod][2] = testcase.getUpstreamPoolsMethodErr
+		pools, total, err := testAPI.ListUpstreamPools(testcase.requestInfo, testcase.filter)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.expectedPools, pools)
+		if testcase.wantError == nil {
+			assert.Equal(t, len(testcase.expectedPools), total, "Error in test case %v", x)
+		}
+	}
+}
+
+func TestWorkerAPI_UpdateUpstreamPool(t *testing.T) {
+	now := time.Now().UTC()
+	config := UpstreamPoolConfig{
+		Targets: []string{"http://10.0.0.1:8080"},
+	}
+	testcases := map[string]struct {
+		// API Method args
+		requestInfo RequestInfo
+		org         string
+		name        string
+		newName     string
+		newPath     string
+		newConfig   UpstreamPoolConfig
+		// Expected result
+		expectedPool *UpstreamPool
+		wantError    error
+		// Manager Results
+		getUpstreamPoolByNameResult            *UpstreamPool
+		getUpstreamPoolByNameMethodSpecialFunc func(string, string) (*UpstreamPool, error)
+		getProxyResourcesResult                []ProxyResource
+		updateUpstreamPoolResult               *UpstreamPool
+		// Manager Errors
+		getUpstreamPoolByNameErr    error
+		updateUpstreamPoolMethodErr error
+	}{
+		"OKCase": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:       "org1",
+			name:      "pool1",
+			newName:   "pool1",
+			newPath:   "/new/",
+			newConfig: config,
+			getUpstreamPoolByNameResult: &UpstreamPool{
+				ID:       "12345",
+				Name:     "pool1",
+				Org:      "org1",
+				Path:     "/path/",
+				Urn:      CreateUrn("org1", RESOURCE_UPSTREAM_POOL, "/path/", "pool1"),
+				UpdateAt: now,
+			},
+			updateUpstreamPoolResult: &UpstreamPool{
+				ID:     "12345",
+				Name:   "pool1",
+				Org:    "org1",
+				Path:   "/new/",
+				Urn:    CreateUrn("org1", RESOURCE_UPSTREAM_POOL, "/new/", "pool1"),
+				Config: normalizeUpstreamPoolConfig(config),
+			},
+			expectedPool: &UpstreamPool{
+				ID:     "12345",
+				Name:   "pool1",
+				Org:    "org1",
+				Path:   "/new/",
+				Urn:    CreateUrn("org1", RESOURCE_UPSTREAM_POOL, "/new/", "pool1"),
+				Config: normalizeUpstreamPoolConfig(config),
+			},
+		},
+		"OKCaseRename": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:       "org1",
+			name:      "pool1",
+			newName:   "pool2",
+			newPath:   "/path/",
+			newConfig: config,
+			getUpstreamPoolByNameMethodSpecialFunc: func(org string, name string) (*UpstreamPool, error) {
+				if name == "pool1" {
+					return &UpstreamPool{
+						ID:   "12345",
+						Name: "pool1",
+						Org:  "org1",
+						Path: "/path/",
+						Urn:  CreateUrn("org1", RESOURCE_UPSTREAM_POOL, "/path/", "pool1"),
+					}, nil
+				}
+				return nil, &database.Error{
+					Code: database.UPSTREAM_POOL_NOT_FOUND,
+				}
+			},
+			getProxyResourcesResult: []ProxyResource{
+				{
+					Name: "pr1",
+					Org:  "org1",
+					Resource: ResourceEntity{
+						Host: "http://10.0.0.1:8080",
+					},
+				},
+			},
+			updateUpstreamPoolResult: &UpstreamPool{
+				ID:     "12345",
+				Name:   "pool2",
+				Org:    "org1",
+				Path:   "/path/",
+				Urn:    CreateUrn("org1", RESOURCE_UPSTREAM_POOL, "/path/", "pool2"),
+				Config: normalizeUpstreamPoolConfig(config),
+			},
+			expectedPool: &UpstreamPool{
+				ID:     "12345",
+				Name:   "pool2",
+				Org:    "org1",
+				Path:   "/path/",
+				Urn:    CreateUrn("org1", RESOURCE_UPSTREAM_POOL, "/path/", "pool2"),
+				Config: normalizeUpstreamPoolConfig(config),
+			},
+		},
+		"ErrorCaseRenameInUse": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:       "org1",
+			name:      "pool1",
+			newName:   "pool2",
+			newPath:   "/path/",
+			newConfig: config,
+			getUpstreamPoolByNameMethodSpecialFunc: func(org string, name string) (*UpstreamPool, error) {
+				if name == "pool1" {
+					return &UpstreamPool{
+						ID:   "12345",
+						Name: "pool1",
+						Org:  "org1",
+						Path: "/path/",
+						Urn:  CreateUrn("org1", RESOURCE_UPSTREAM_POOL, "/path/", "pool1"),
+					}, nil
+				}
+				return nil, &database.Error{
+					Code: database.UPSTREAM_POOL_NOT_FOUND,
+				}
+			},
+			getProxyResourcesResult: []ProxyResource{
+				{
+					Name: "pr1",
+					Org:  "org1",
+					Resource: ResourceEntity{
+						Upstream: "pool1",
+					},
+				},
+			},
+			wantError: &Error{
+				Code:    UPSTREAM_POOL_IN_USE,
+				Message: "Upstream pool with org org1 and name pool1 is used by proxy resource pr1",
+			},
+		},
+		"ErrorCaseRenameAlreadyExists": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:       "org1",
+			name:      "pool1",
+			newName:   "pool2",
+			newPath:   "/path/",
+			newConfig: config,
+			getUpstreamPoolByNameMethodSpecialFunc: func(org string, name string) (*UpstreamPool, error) {
+				return &UpstreamPool{
+					ID:   name,
+					Name: name,
+					Org:  "org1",
+					Path: "/path/",
+					Urn:  CreateUrn("org1", RESOURCE_UPSTREAM_POOL, "/path/", name),
+				}, nil
+			},
+			wantError: &Error{
+				Code:    UPSTREAM_POOL_ALREADY_EXIST,
+				Message: "Upstream pool name: pool2 already exists",
+			},
+		},
+		"ErrorCaseBadNewName": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:     "org1",
+			name:    "pool1",
+			newName: "**!^#~",
+			newPath: "/path/",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: new name **!^#~",
+			},
+		},
+		"ErrorCaseBadNewPath": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:     "org1",
+			name:    "pool1",
+			newName: "pool1",
+			newPath: "*/ /**!^#~path/",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: new path */ /**!^#~path/",
+			},
+		},
+		"ErrorCaseBadNewConfig": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:     "org1",
+			name:    "pool1",
+			newName: "pool1",
+			newPath: "/path/",
+			newConfig: UpstreamPoolConfig{
+				Targets:  []string{"http://10.0.0.1:8080"},
+				Balancer: "random",
+			},
+			wantError: &Error{
+				Code:    REGEX_NO_MATCH,
+				Message: "Invalid parameter balancer, value: random",
+			},
+		},
+		"ErrorCaseUpstreamPoolNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:       "org1",
+			name:      "pool1",
+			newName:   "pool1",
+			newPath:   "/path/",
+			newConfig: config,
+			getUpstreamPoolByNameErr: &database.Error{
+				Code:    database.UPSTREAM_POOL_NOT_FOUND,
+				Message: "Upstream pool with organization org1 and name pool1 not found",
+			},
+			wantError: &Error{
+				Code:    UPSTREAM_POOL_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Upstream pool with organization org1 and name pool1 not found",
+			},
+		},
+		"ErrorCaseVersionConflict": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:       "org1",
+			name:      "pool1",
+			newName:   "pool1",
+			newPath:   "/path/",
+			newConfig: config,
+			getUpstreamPoolByNameResult: &UpstreamPool{
+				ID:   "12345",
+				Name: "pool1",
+				Org:  "org1",
+				Path: "/path/",
+				Urn:  CreateUrn("org1", RESOURCE_UPSTREAM_POOL, "/path/", "pool1"),
+			},
+			updateUpstreamPoolMethodErr: &database.Error{
+				Code:    database.VERSION_CONFLICT,
+				Message: "Upstream pool with id 12345 was modified or removed by another request",
+			},
+			wantError: &Error{
+				Code:    PRECONDITION_FAILED,
+				Message: "Upstream pool with id 12345 was modified or removed by another request",
+			},
+		},
+		"ErrorCaseUpdateUpstreamPoolErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:       "org1",
+			name:      "pool1",
+			newName:   "pool1",
+			newPath:   "/path/",
+			newConfig: config,
+			getUpstreamPoolByNameResult: &UpstreamPool{
+				ID:   "12345",
+				Name: "pool1",
+				Org:  "org1",
+				Path: "/path/",
+				Urn:  CreateUrn("org1", RESOURCE_UPSTREAM_POOL, "/path/", "pool1"),
+			},
+			updateUpstreamPoolMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	testRepo := makeTestRepo()
+	testAPI := makeTestAPI(testRepo)
+
+	for x, testcase := range testcases {
+		testRepo.ArgsOut[UpdateUpstreamPoolMethod][0] = testcase.updateUpstreamPoolResult
+		testRepo.ArgsOut[UpdateUpstreamPoolMethod][1] = testcase.updateUpstreamPoolMethodErr
+		testRepo.ArgsOut[GetUpstreamPoolByNameMethod][0] = testcase.getUpstreamPoolByNameResult
+		testRepo.ArgsOut[GetUpstreamPoolByNameMethod][1] = testcase.getUpstreamPoolByNameErr
+		testRepo.ArgsOut[GetProxyResourcesMethod][0] = testcase.getProxyResourcesResult
+		testRepo.SpecialFuncs[GetUpstreamPoolByNameMethod] = testcase.getUpstreamPoolByNameMethodSpecialFunc
+
+		pool, err := testAPI.UpdateUpstreamPool(testcase.requestInfo, testcase.org, testcase.name, testcase.newName,
+			testcase.newPath, testcase.newConfig)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.expectedPool, pool)
+		if testcase.wantError == nil && testcase.getUpstreamPoolByNameResult != nil {
+			// Check the update applies to the retrieved version
+			assert.Equal(t, testcase.getUpstreamPoolByNameResult.UpdateAt, testRepo.ArgsIn[UpdateUpstreamPoolMethod][1], "Error in test case %v", x)
+		}
+	}
+}
+
+func TestWorkerAPI_RemoveUpstreamPool(t *testing.T) {
+	testcases := map[string]struct {
+		//API method args
+		requestInfo RequestInfo
+		org         string
+		name        string
+		// Expected result
+		wantError error
+		// Manager Results
+		getUpstreamPoolByNameResult *UpstreamPool
+		getProxyResourcesResult     []ProxyResource
+		// Manager Errors
+		getUpstreamPoolByNameErr error
+		getProxyResourcesErr     error
+		removeUpstreamPoolErr    error
+	}{
+		"OKCase": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:  "org1",
+			name: "pool1",
+			getUpstreamPoolByNameResult: &UpstreamPool{
+				ID:   "12345",
+				Name: "pool1",
+				Org:  "org1",
+				Path: "/path/",
+				Urn:  CreateUrn("org1", RESOURCE_UPSTREAM_POOL, "/path/", "pool1"),
+			},
+			getProxyResourcesResult: []ProxyResource{
+				{
+					Name: "pr1",
+					Org:  "org1",
+					Resource: ResourceEntity{
+						Upstream: "pool2",
+					},
+				},
+			},
+		},
+		"ErrorCaseUpstreamPoolNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:  "org1",
+			name: "pool1",
+			getUpstreamPoolByNameErr: &database.Error{
+				Code:    database.UPSTREAM_POOL_NOT_FOUND,
+				Message: "Upstream pool with organization org1 and name pool1 not found",
+			},
+			wantError: &Error{
+				Code:    UPSTREAM_POOL_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Upstream pool with organization org1 and name pool1 not found",
+			},
+		},
+		"ErrorCaseUpstreamPoolInUse": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:  "org1",
+			name: "pool1",
+			getUpstreamPoolByNameResult: &UpstreamPool{
+				ID:   "12345",
+				Name: "pool1",
+				Org:  "org1",
+				Path: "/path/",
+				Urn:  CreateUrn("org1", RESOURCE_UPSTREAM_POOL, "/path/", "pool1"),
+			},
+			getProxyResourcesResult: []ProxyResource{
+				{
+					Name: "pr1",
+					Org:  "org1",
+					Resource: ResourceEntity{
+						Upstream: "pool1",
+					},
+				},
+			},
+			wantError: &Error{
+				Code:    UPSTREAM_POOL_IN_USE,
+				Message: "Upstream pool with org org1 and name pool1 is used by proxy resource pr1",
+			},
+		},
+		"ErrorCaseGetProxyResourcesErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:  "org1",
+			name: "pool1",
+			getUpstreamPoolByNameResult: &UpstreamPool{
+				ID:   "12345",
+				Name: "pool1",
+				Org:  "org1",
+				Path: "/path/",
+				Urn:  CreateUrn("org1", RESOURCE_UPSTREAM_POOL, "/path/", "pool1"),
+			},
+			getProxyResourcesErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+		"ErrorCaseRemoveUpstreamPoolErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			org:  "org1",
+			name: "pool1",
+			getUpstreamPoolByNameResult: &UpstreamPool{
+				ID:   "12345",
+				Name: "pool1",
+				Org:  "org1",
+				Path: "/path/",
+				Urn:  CreateUrn("org1", RESOURCE_UPSTREAM_POOL, "/path/", "pool1"),
+			},
+			removeUpstreamPoolErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	testRepo := makeTestRepo()
+	testAPI := makeTestAPI(testRepo)
+
+	for x, testcase := range testcases {
+		testRepo.ArgsOut[GetUpstreamPoolByNameMethod][0] = testcase.getUpstreamPoolByNameResult
+		testRepo.ArgsOut[GetUpstreamPoolByNameMethod][1] = testcase.getUpstreamPoolByNameErr
+		testRepo.ArgsOut[GetProxyResourcesMethod][0] = testcase.getProxyResourcesResult
+		testRepo.ArgsOut[GetProxyResourcesMethod][2] = testcase.getProxyResourcesErr
+		testRepo.ArgsOut[RemoveUpstreamPoolMethod][0] = testcase.removeUpstreamPoolErr
+		err := testAPI.RemoveUpstreamPool(testcase.requestInfo, testcase.org, testcase.name)
+		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
+		if testcase.wantError == nil {
+			assert.Equal(t, testcase.getUpstreamPoolByNameResult.ID, testRepo.ArgsIn[RemoveUpstreamPoolMethod][0], "Error in test case %v", x)
+		}
+	}
+}
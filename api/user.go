@@ -26,6 +26,12 @@ type UserGroups struct {
 	CreateAt time.Time `json:"joined,omitempty"`
 }
 
+type UserPolicies struct {
+	Org      string    `json:"org,omitempty"`
+	Policy   string    `json:"policy,omitempty"`
+	CreateAt time.Time `json:"attached,omitempty"`
+}
+
 func (u User) String() string {
 	return fmt.Sprintf("[id: %v, externalId: %v, path: %v, urn: %v, createAt: %v]",
 		u.ID, u.ExternalID, u.Path, u.Urn, u.CreateAt.Format("2006-01-02 15:04:05 MST"))
@@ -38,6 +44,19 @@ func (u User) GetUrn() string {
 // USER API IMPLEMENTATION
 
 func (api WorkerAPI) AddUser(requestInfo RequestInfo, externalId string, path string) (*User, error) {
+	var user *User
+	err := api.withTx(func(txAPI WorkerAPI) error {
+		var err error
+		user, err = txAPI.addUser(requestInfo, externalId, path)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (api WorkerAPI) addUser(requestInfo RequestInfo, externalId string, path string) (*User, error) {
 	// Validate fields
 	if !IsValidUserExternalID(externalId) {
 		return nil, &Error{
@@ -88,6 +107,9 @@ func (api WorkerAPI) AddUser(requestInfo RequestInfo, externalId string, path st
 					Message: dbError.Message,
 				}
 			}
+			if err := api.audit(requestInfo, USER_ACTION_CREATE_USER, createdUser.Urn, nil, createdUser); err != nil {
+				return nil, err
+			}
 			LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("User created %+v", createdUser))
 			return createdUser, nil
 		default: // Unexpected error
@@ -187,6 +209,19 @@ func (api WorkerAPI) ListUsers(requestInfo RequestInfo, filter *Filter) ([]strin
 }
 
 func (api WorkerAPI) UpdateUser(requestInfo RequestInfo, externalId string, newPath string) (*User, error) {
+	var user *User
+	err := api.withTx(func(txAPI WorkerAPI) error {
+		var err error
+		user, err = txAPI.updateUser(requestInfo, externalId, newPath)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (api WorkerAPI) updateUser(requestInfo RequestInfo, externalId string, newPath string) (*User, error) {
 	if !IsValidPath(newPath) {
 		return nil, &Error{
 			Code:    INVALID_PARAMETER_ERROR,
@@ -213,6 +248,11 @@ func (api WorkerAPI) UpdateUser(requestInfo RequestInfo, externalId string, newP
 		}
 	}
 
+	// Check the request applies to the current version
+	if err := checkIfMatch(requestInfo, oldUser.Urn, oldUser.UpdateAt); err != nil {
+		return nil, err
+	}
+
 	auxUser := User{
 		Urn: CreateUrn("", RESOURCE_USER, newPath, externalId),
 	}
@@ -239,24 +279,42 @@ func (api WorkerAPI) UpdateUser(requestInfo RequestInfo, externalId string, newP
 		Urn:        auxUser.Urn,
 	}
 
-	updatedUser, err := api.UserRepo.UpdateUser(user)
+	updatedUser, err := api.UserRepo.UpdateUser(user, oldUser.UpdateAt)
 
-	// Check unexpected DB error
+	// Error handling
 	if err != nil {
 		//Transform to DB error
 		dbError := err.(*database.Error)
-		return nil, &Error{
-			Code:    UNKNOWN_API_ERROR,
-			Message: dbError.Message,
+		switch dbError.Code {
+		case database.VERSION_CONFLICT:
+			return nil, &Error{
+				Code:    PRECONDITION_FAILED,
+				Message: dbError.Message,
+			}
+		default: // Unexpected error
+			return nil, &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: dbError.Message,
+			}
 		}
 	}
 
+	if err := api.audit(requestInfo, USER_ACTION_UPDATE_USER, oldUser.Urn, oldUser, updatedUser); err != nil {
+		return nil, err
+	}
+	api.AuthzCache.Invalidate()
 	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("User updated from %+v to %+v", oldUser, updatedUser))
 	return updatedUser, nil
 
 }
 
 func (api WorkerAPI) RemoveUser(requestInfo RequestInfo, externalId string) error {
+	return api.withTx(func(txAPI WorkerAPI) error {
+		return txAPI.removeUser(requestInfo, externalId)
+	})
+}
+
+func (api WorkerAPI) removeUser(requestInfo RequestInfo, externalId string) error {
 	// Call repo to retrieve the user
 	user, err := api.GetUserByExternalID(requestInfo, externalId)
 	if err != nil {
@@ -276,6 +334,11 @@ func (api WorkerAPI) RemoveUser(requestInfo RequestInfo, externalId string) erro
 		}
 	}
 
+	// Check the request applies to the current version
+	if err := checkIfMatch(requestInfo, user.Urn, user.UpdateAt); err != nil {
+		return err
+	}
+
 	err = api.UserRepo.RemoveUser(user.ID)
 
 	// Error handling
@@ -287,6 +350,10 @@ func (api WorkerAPI) RemoveUser(requestInfo RequestInfo, externalId string) erro
 			Message: dbError.Message,
 		}
 	}
+	if err := api.audit(requestInfo, USER_ACTION_DELETE_USER, user.Urn, user, nil); err != nil {
+		return err
+	}
+	api.AuthzCache.Invalidate()
 	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("User deleted %+v", user))
 	return nil
 }
@@ -344,6 +411,196 @@ func (api WorkerAPI) ListGroupsByUser(requestInfo RequestInfo, filter *Filter) (
 	return groupIDs, total, nil
 }
 
+func (api WorkerAPI) AttachPolicyToUser(requestInfo RequestInfo, externalId string, org string, policyName string) error {
+	return api.withTx(func(txAPI WorkerAPI) error {
+		return txAPI.attachPolicyToUser(requestInfo, externalId, org, policyName)
+	})
+}
+
+func (api WorkerAPI) attachPolicyToUser(requestInfo RequestInfo, externalId string, org string, policyName string) error {
+	// Call repo to retrieve the user
+	user, err := api.GetUserByExternalID(requestInfo, externalId)
+	if err != nil {
+		return err
+	}
+
+	// Check restrictions
+	usersFiltered, err := api.GetAuthorizedUsers(requestInfo, user.Urn, USER_ACTION_ATTACH_USER_POLICY, []User{*user})
+	if err != nil {
+		return err
+	}
+	if len(usersFiltered) < 1 {
+		return &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, user.Urn),
+		}
+	}
+
+	// Check if policy exists
+	policy, err := api.GetPolicyByName(requestInfo, org, policyName)
+	if err != nil {
+		return err
+	}
+
+	// Check existing relationship
+	isAttached, err := api.UserRepo.IsAttachedToUser(user.ID, policy.ID)
+	if err != nil {
+		dbError := err.(*database.Error)
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	if isAttached {
+		return &Error{
+			Code:    POLICY_IS_ALREADY_ATTACHED_TO_USER,
+			Message: fmt.Sprintf("Policy: %v is already attached to User: %v", policy.Name, user.ExternalID),
+		}
+	}
+
+	// Attach Policy to User
+	err = api.UserRepo.AttachUserPolicy(user.ID, policy.ID)
+
+	if err != nil {
+		dbError := err.(*database.Error)
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	if err := api.audit(requestInfo, USER_ACTION_ATTACH_USER_POLICY, user.Urn, nil, auditRelation{Urn: policy.Urn}); err != nil {
+		return err
+	}
+	api.AuthzCache.Invalidate()
+	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Policy %+v attached to user %+v", policy, user))
+	return nil
+}
+
+func (api WorkerAPI) DetachPolicyFromUser(requestInfo RequestInfo, externalId string, org string, policyName string) error {
+	return api.withTx(func(txAPI WorkerAPI) error {
+		return txAPI.detachPolicyFromUser(requestInfo, externalId, org, policyName)
+	})
+}
+
+func (api WorkerAPI) detachPolicyFromUser(requestInfo RequestInfo, externalId string, org string, policyName string) error {
+	// Call repo to retrieve the user
+	user, err := api.GetUserByExternalID(requestInfo, externalId)
+	if err != nil {
+		return err
+	}
+
+	// Check restrictions
+	usersFiltered, err := api.GetAuthorizedUsers(requestInfo, user.Urn, USER_ACTION_DETACH_USER_POLICY, []User{*user})
+	if err != nil {
+		return err
+	}
+	if len(usersFiltered) < 1 {
+		return &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, user.Urn),
+		}
+	}
+
+	// Check if policy exists
+	policy, err := api.GetPolicyByName(requestInfo, org, policyName)
+	if err != nil {
+		return err
+	}
+
+	// Check existing relationship
+	isAttached, err := api.UserRepo.IsAttachedToUser(user.ID, policy.ID)
+	if err != nil {
+		dbError := err.(*database.Error)
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	if !isAttached {
+		return &Error{
+			Code: POLICY_IS_NOT_ATTACHED_TO_USER,
+			Message: fmt.Sprintf("Policy with org %v and name %v is not attached to user with externalId %v",
+				policy.Org, policy.Name, user.ExternalID),
+		}
+	}
+
+	// Detach Policy from User
+	err = api.UserRepo.DetachUserPolicy(user.ID, policy.ID)
+
+	if err != nil {
+		dbError := err.(*database.Error)
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	if err := api.audit(requestInfo, USER_ACTION_DETACH_USER_POLICY, user.Urn, auditRelation{Urn: policy.Urn}, nil); err != nil {
+		return err
+	}
+	api.AuthzCache.Invalidate()
+	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Policy %+v detached from user %+v", policy, user))
+	return nil
+}
+
+func (api WorkerAPI) ListAttachedUserPolicies(requestInfo RequestInfo, filter *Filter) ([]UserPolicies, int, error) {
+	// Check parameters
+	var total int
+	orderByValidColumns := api.UserRepo.OrderByValidColumns(USER_ACTION_LIST_ATTACHED_USER_POLICIES)
+	err := validateFilter(filter, orderByValidColumns)
+	if err != nil {
+		return nil, total, err
+	}
+
+	// Call repo to retrieve the user
+	user, err := api.GetUserByExternalID(requestInfo, filter.ExternalID)
+	if err != nil {
+		return nil, total, err
+	}
+
+	// Check restrictions
+	usersFiltered, err := api.GetAuthorizedUsers(requestInfo, user.Urn, USER_ACTION_LIST_ATTACHED_USER_POLICIES, []User{*user})
+	if err != nil {
+		return nil, total, err
+	}
+	if len(usersFiltered) < 1 {
+		return nil, total, &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, user.Urn),
+		}
+	}
+
+	// Call repo to retrieve the UserPolicyRelations
+	attachedPolicies, total, err := api.UserRepo.GetAttachedUserPolicies(user.ID, filter)
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, total, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	policies := []UserPolicies{}
+	for _, p := range attachedPolicies {
+		policies = append(policies, UserPolicies{
+			Org:      p.GetPolicy().Org,
+			Policy:   p.GetPolicy().Name,
+			CreateAt: p.GetDate(),
+		})
+	}
+
+	return policies, total, nil
+}
+
 // PRIVATE HELPER METHODS
 
 func createUser(externalId string, path string) User {
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/Tecsisa/foulkon/database"
 	"github.com/stretchr/testify/assert"
@@ -1431,6 +1433,8 @@ func TestAuthAPI_RemoveUser(t *testing.T) {
 		// API Errors
 		getUserByExternalIDMethodErr error
 		removeUserMethodErr          error
+		withTxMethodErr              error
+		addAuditEntryMethodErr       error
 	}{
 		"OKCaseAdmin": {
 			requestInfo: RequestInfo{
@@ -1677,6 +1681,42 @@ func TestAuthAPI_RemoveUser(t *testing.T) {
 				Code: database.INTERNAL_ERROR,
 			},
 		},
+		"ErrorCaseCommitTxErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			externalID: "123456",
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+			getUserByExternalIDMethodResult: &User{
+				ID:         "543210",
+				ExternalID: "123456",
+				Path:       "/example/",
+			},
+			withTxMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+		},
+		"ErrorCaseAddAuditEntryErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			externalID: "123456",
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+			getUserByExternalIDMethodResult: &User{
+				ID:         "543210",
+				ExternalID: "123456",
+				Path:       "/example/",
+			},
+			addAuditEntryMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+		},
 	}
 
 	for x, testcase := range testcases {
@@ -1688,8 +1728,24 @@ func TestAuthAPI_RemoveUser(t *testing.T) {
 		testRepo.ArgsOut[GetGroupsByUserIDMethod][0] = testcase.getGroupsByUserIDResult
 		testRepo.ArgsOut[GetAttachedPoliciesMethod][0] = testcase.getAttachedPoliciesResult
 		testRepo.ArgsOut[RemoveUserMethod][0] = testcase.removeUserMethodErr
+		testRepo.ArgsOut[WithTxMethod][0] = testcase.withTxMethodErr
+		testRepo.ArgsOut[AddAuditEntryMethod][0] = testcase.addAuditEntryMethodErr
 		err := testAPI.RemoveUser(testcase.requestInfo, testcase.externalID)
 		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
+
+		// Removed user must be audited
+		if testcase.wantError == nil {
+			entry := testRepo.ArgsIn[AddAuditEntryMethod][0].(AuditEntry)
+			assert.Equal(t, USER_ACTION_DELETE_USER, entry.Action, "Error in test case %v", x)
+			assert.Equal(t, testcase.getUserByExternalIDMethodResult.Urn, entry.Urn, "Error in test case %v", x)
+			assert.Equal(t, testcase.requestInfo.Identifier, entry.Actor, "Error in test case %v", x)
+			assert.Nil(t, entry.After, "Error in test case %v", x)
+		}
+
+		// Transaction must be rolled back when the operation fails before committing
+		if testcase.wantError != nil && testcase.withTxMethodErr == nil {
+			assert.NotNil(t, testRepo.ArgsIn[WithTxMethod][0], "Error in test case %v", x)
+		}
 	}
 }
 
@@ -2054,3 +2110,513 @@ func TestAuthAPI_ListGroupsByUser(t *testing.T) {
 	}
 
 }
+
+func TestAuthAPI_AttachPolicyToUser(t *testing.T) {
+	user := &User{
+		ID:         "543210",
+		ExternalID: "1234",
+		Path:       "/path/",
+		Urn:        CreateUrn("", RESOURCE_USER, "/path/", "1234"),
+	}
+	policy := &Policy{
+		ID:   "POLICY-ID",
+		Name: "policy1",
+		Org:  "org1",
+		Path: "/path/",
+		Urn:  CreateUrn("org1", RESOURCE_POLICY, "/path/", "policy1"),
+	}
+	testcases := map[string]struct {
+		// API Method args
+		requestInfo RequestInfo
+		externalID  string
+		org         string
+		policyName  string
+		// Expected result
+		wantError error
+		// Manager Results
+		getUserByExternalIDResult     *User
+		getPolicyByNameResult         *Policy
+		getAttachedUserPoliciesResult []TestPolicyUserRelation
+		isAttachedToUserResult        bool
+		// Manager Errors
+		getPolicyByNameMethodErr  error
+		isAttachedToUserMethodErr error
+		attachUserPolicyMethodErr error
+	}{
+		"OkCaseAdmin": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			externalID:                "1234",
+			org:                       "org1",
+			policyName:                "policy1",
+			getUserByExternalIDResult: user,
+			getPolicyByNameResult:     policy,
+		},
+		"OkCase": {
+			requestInfo: RequestInfo{
+				Identifier: "1234",
+				Admin:      false,
+			},
+			externalID:                "1234",
+			org:                       "org1",
+			policyName:                "policy1",
+			getUserByExternalIDResult: user,
+			getPolicyByNameResult:     policy,
+			getAttachedUserPoliciesResult: []TestPolicyUserRelation{
+				{
+					Policy: &Policy{
+						ID:   "POLICY-USER-ID",
+						Name: "policyUser",
+						Org:  "org1",
+						Path: "/path/",
+						Urn:  CreateUrn("org1", RESOURCE_POLICY, "/path/", "policyUser"),
+						Statements: &[]Statement{
+							{
+								Effect: "allow",
+								Actions: []string{
+									USER_ACTION_GET_USER,
+									USER_ACTION_ATTACH_USER_POLICY,
+								},
+								Resources: []string{
+									GetUrnPrefix("", RESOURCE_USER, "/path/"),
+								},
+							},
+							{
+								Effect: "allow",
+								Actions: []string{
+									POLICY_ACTION_GET_POLICY,
+								},
+								Resources: []string{
+									GetUrnPrefix("org1", RESOURCE_POLICY, "/path/"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"ErrorCaseUserNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			externalID: "1234",
+			org:        "org1",
+			policyName: "policy1",
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+		"ErrorCaseUnauthorized": {
+			requestInfo: RequestInfo{
+				Identifier: "1234",
+				Admin:      false,
+			},
+			externalID:                "1234",
+			org:                       "org1",
+			policyName:                "policy1",
+			getUserByExternalIDResult: user,
+			getPolicyByNameResult:     policy,
+			getAttachedUserPoliciesResult: []TestPolicyUserRelation{
+				{
+					Policy: &Policy{
+						ID:   "POLICY-USER-ID",
+						Name: "policyUser",
+						Org:  "org1",
+						Path: "/path/",
+						Urn:  CreateUrn("org1", RESOURCE_POLICY, "/path/", "policyUser"),
+						Statements: &[]Statement{
+							{
+								Effect: "allow",
+								Actions: []string{
+									USER_ACTION_GET_USER,
+								},
+								Resources: []string{
+									GetUrnPrefix("", RESOURCE_USER, "/path/"),
+								},
+							},
+						},
+					},
+				},
+			},
+			wantError: &Error{
+				Code: UNAUTHORIZED_RESOURCES_ERROR,
+				Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+					"1234", user.Urn),
+			},
+		},
+		"ErrorCasePolicyNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			externalID:                "1234",
+			org:                       "org1",
+			policyName:                "policy1",
+			getUserByExternalIDResult: user,
+			getPolicyByNameMethodErr: &database.Error{
+				Code:    database.POLICY_NOT_FOUND,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    POLICY_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseIsAttachedToUserDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			externalID:                "1234",
+			org:                       "org1",
+			policyName:                "policy1",
+			getUserByExternalIDResult: user,
+			getPolicyByNameResult:     policy,
+			isAttachedToUserMethodErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCasePolicyIsAlreadyAttached": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			externalID:                "1234",
+			org:                       "org1",
+			policyName:                "policy1",
+			getUserByExternalIDResult: user,
+			getPolicyByNameResult:     policy,
+			isAttachedToUserResult:    true,
+			wantError: &Error{
+				Code:    POLICY_IS_ALREADY_ATTACHED_TO_USER,
+				Message: "Policy: policy1 is already attached to User: 1234",
+			},
+		},
+		"ErrorCaseAttachUserPolicyDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			externalID:                "1234",
+			org:                       "org1",
+			policyName:                "policy1",
+			getUserByExternalIDResult: user,
+			getPolicyByNameResult:     policy,
+			attachUserPolicyMethodErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = testcase.getUserByExternalIDResult
+		if testcase.getUserByExternalIDResult == nil {
+			testRepo.ArgsOut[GetUserByExternalIDMethod][1] = &database.Error{
+				Code: database.INTERNAL_ERROR,
+			}
+		}
+		testRepo.ArgsOut[GetPolicyByNameMethod][0] = testcase.getPolicyByNameResult
+		testRepo.ArgsOut[GetPolicyByNameMethod][1] = testcase.getPolicyByNameMethodErr
+		testRepo.ArgsOut[GetAttachedUserPoliciesMethod][0] = testcase.getAttachedUserPoliciesResult
+		testRepo.ArgsOut[IsAttachedToUserMethod][0] = testcase.isAttachedToUserResult
+		testRepo.ArgsOut[IsAttachedToUserMethod][1] = testcase.isAttachedToUserMethodErr
+		testRepo.ArgsOut[AttachUserPolicyMethod][0] = testcase.attachUserPolicyMethodErr
+
+		err := testAPI.AttachPolicyToUser(testcase.requestInfo, testcase.externalID, testcase.org, testcase.policyName)
+		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
+		if testcase.wantError == nil {
+			assert.Equal(t, user.ID, testRepo.ArgsIn[AttachUserPolicyMethod][0], "Error in test case %v", x)
+			assert.Equal(t, policy.ID, testRepo.ArgsIn[AttachUserPolicyMethod][1], "Error in test case %v", x)
+		}
+	}
+}
+
+func TestAuthAPI_DetachPolicyFromUser(t *testing.T) {
+	user := &User{
+		ID:         "543210",
+		ExternalID: "1234",
+		Path:       "/path/",
+		Urn:        CreateUrn("", RESOURCE_USER, "/path/", "1234"),
+	}
+	policy := &Policy{
+		ID:   "POLICY-ID",
+		Name: "policy1",
+		Org:  "org1",
+		Path: "/path/",
+		Urn:  CreateUrn("org1", RESOURCE_POLICY, "/path/", "policy1"),
+	}
+	testcases := map[string]struct {
+		// API Method args
+		requestInfo RequestInfo
+		externalID  string
+		org         string
+		policyName  string
+		// Expected result
+		wantError error
+		// Manager Results
+		getUserByExternalIDResult *User
+		getPolicyByNameResult     *Policy
+		isAttachedToUserResult    bool
+		// Manager Errors
+		isAttachedToUserMethodErr error
+		detachUserPolicyMethodErr error
+	}{
+		"OkCaseAdmin": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			externalID:                "1234",
+			org:                       "org1",
+			policyName:                "policy1",
+			getUserByExternalIDResult: user,
+			getPolicyByNameResult:     policy,
+			isAttachedToUserResult:    true,
+		},
+		"ErrorCaseUnauthorized": {
+			requestInfo: RequestInfo{
+				Identifier: "1234",
+				Admin:      false,
+			},
+			externalID:                "1234",
+			org:                       "org1",
+			policyName:                "policy1",
+			getUserByExternalIDResult: user,
+			wantError: &Error{
+				Code: UNAUTHORIZED_RESOURCES_ERROR,
+				Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+					"1234", user.Urn),
+			},
+		},
+		"ErrorCaseIsAttachedToUserDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			externalID:                "1234",
+			org:                       "org1",
+			policyName:                "policy1",
+			getUserByExternalIDResult: user,
+			getPolicyByNameResult:     policy,
+			isAttachedToUserMethodErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCasePolicyIsNotAttached": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			externalID:                "1234",
+			org:                       "org1",
+			policyName:                "policy1",
+			getUserByExternalIDResult: user,
+			getPolicyByNameResult:     policy,
+			wantError: &Error{
+				Code:    POLICY_IS_NOT_ATTACHED_TO_USER,
+				Message: "Policy with org org1 and name policy1 is not attached to user with externalId 1234",
+			},
+		},
+		"ErrorCaseDetachUserPolicyDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			externalID:                "1234",
+			org:                       "org1",
+			policyName:                "policy1",
+			getUserByExternalIDResult: user,
+			getPolicyByNameResult:     policy,
+			isAttachedToUserResult:    true,
+			detachUserPolicyMethodErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = testcase.getUserByExternalIDResult
+		testRepo.ArgsOut[GetPolicyByNameMethod][0] = testcase.getPolicyByNameResult
+		testRepo.ArgsOut[IsAttachedToUserMethod][0] = testcase.isAttachedToUserResult
+		testRepo.ArgsOut[IsAttachedToUserMethod][1] = testcase.isAttachedToUserMethodErr
+		testRepo.ArgsOut[DetachUserPolicyMethod][0] = testcase.detachUserPolicyMethodErr
+
+		err := testAPI.DetachPolicyFromUser(testcase.requestInfo, testcase.externalID, testcase.org, testcase.policyName)
+		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
+		if testcase.wantError == nil {
+			assert.Equal(t, user.ID, testRepo.ArgsIn[DetachUserPolicyMethod][0], "Error in test case %v", x)
+			assert.Equal(t, policy.ID, testRepo.ArgsIn[DetachUserPolicyMethod][1], "Error in test case %v", x)
+		}
+	}
+}
+
+func TestAuthAPI_ListAttachedUserPolicies(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API Method args
+		requestInfo RequestInfo
+		filter      *Filter
+		// Expected result
+		expectedResponse []UserPolicies
+		totalResult      int
+		wantError        error
+		// Manager Results
+		getUserByExternalIDResult     *User
+		getAttachedUserPoliciesResult []TestPolicyUserRelation
+		// Manager Errors
+		getAttachedUserPoliciesMethodErr error
+	}{
+		"OkCaseAdmin": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				ExternalID: "1234",
+			},
+			expectedResponse: []UserPolicies{
+				{
+					Org:      "org1",
+					Policy:   "policy1",
+					CreateAt: now,
+				},
+			},
+			totalResult: 1,
+			getUserByExternalIDResult: &User{
+				ID:         "543210",
+				ExternalID: "1234",
+				Path:       "/path/",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "1234"),
+			},
+			getAttachedUserPoliciesResult: []TestPolicyUserRelation{
+				{
+					Policy: &Policy{
+						ID:   "POLICY-ID",
+						Name: "policy1",
+						Org:  "org1",
+					},
+					CreateAt: now,
+				},
+			},
+		},
+		"OkCaseNoPolicies": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				ExternalID: "1234",
+			},
+			expectedResponse: []UserPolicies{},
+			getUserByExternalIDResult: &User{
+				ID:         "543210",
+				ExternalID: "1234",
+				Path:       "/path/",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "1234"),
+			},
+		},
+		"ErrorCaseInvalidOrderBy": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				ExternalID: "1234",
+				OrderBy:    "name-desc",
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: OrderBy column name",
+			},
+		},
+		"ErrorCaseUnauthorized": {
+			requestInfo: RequestInfo{
+				Identifier: "1234",
+				Admin:      false,
+			},
+			filter: &Filter{
+				ExternalID: "1234",
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "543210",
+				ExternalID: "1234",
+				Path:       "/path/",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "1234"),
+			},
+			wantError: &Error{
+				Code: UNAUTHORIZED_RESOURCES_ERROR,
+				Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+					"1234", CreateUrn("", RESOURCE_USER, "/path/", "1234")),
+			},
+		},
+		"ErrorCaseGetAttachedUserPoliciesDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				ExternalID: "1234",
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "543210",
+				ExternalID: "1234",
+				Path:       "/path/",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "1234"),
+			},
+			getAttachedUserPoliciesMethodErr: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Error",
+			},
+			wantError: &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+
+		testRepo.ArgsOut[OrderByValidColumnsMethod][0] = []string{"create_at"}
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = testcase.getUserByExternalIDResult
+		testRepo.ArgsOut[GetAttachedUserPoliciesMethod][0] = testcase.getAttachedUserPoliciesResult
+		testRepo.ArgsOut[GetAttachedUserPoliciesMethod][1] = testcase.totalResult
+		testRepo.ArgsOut[GetAttachedUserPoliciesMethod][2] = testcase.getAttachedUserPoliciesMethodErr
+
+		policies, total, err := testAPI.ListAttachedUserPolicies(testcase.requestInfo, testcase.filter)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.expectedResponse, policies)
+		if testcase.wantError == nil {
+			assert.Equal(t, testcase.totalResult, total, "Error in test case %v", x)
+		}
+	}
+}
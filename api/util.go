@@ -2,8 +2,11 @@ package api
 
 import (
 	"fmt"
+	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
+	"time"
 )
 
 const (
@@ -13,6 +16,8 @@ const (
 	RESOURCE_POLICY             = "policy"
 	RESOURCE_PROXY              = "proxy"
 	RESOURCE_AUTH_OIDC_PROVIDER = "oidc"
+	RESOURCE_WEBHOOK            = "webhook"
+	RESOURCE_UPSTREAM_POOL      = "upstream"
 
 	// Resource validation
 	RESOURCE_EXTERNAL = "external"
@@ -23,19 +28,27 @@ const (
 	MAX_NAME_LENGTH        = 128
 	MAX_ACTION_LENGTH      = 128
 	MAX_PATH_LENGTH        = 512
+	MAX_HOST_LENGTH        = 253
+	MAX_HEADER_LENGTH      = 512
 	MAX_RESOURCE_NUMBER    = 50
+	MAX_UPSTREAM_TARGETS   = 50
+	MAX_UPSTREAM_SECONDS   = 3600
+	MAX_UPSTREAM_SETTING   = 1000
 	MAX_LIMIT_SIZE         = 1000
 	DEFAULT_LIMIT_SIZE     = 20
 
 	// Actions
 
 	// User actions
-	USER_ACTION_CREATE_USER          = "iam:CreateUser"
-	USER_ACTION_DELETE_USER          = "iam:DeleteUser"
-	USER_ACTION_GET_USER             = "iam:GetUser"
-	USER_ACTION_LIST_USERS           = "iam:ListUsers"
-	USER_ACTION_UPDATE_USER          = "iam:UpdateUser"
-	USER_ACTION_LIST_GROUPS_FOR_USER = "iam:ListGroupsForUser"
+	USER_ACTION_CREATE_USER                 = "iam:CreateUser"
+	USER_ACTION_DELETE_USER                 = "iam:DeleteUser"
+	USER_ACTION_GET_USER                    = "iam:GetUser"
+	USER_ACTION_LIST_USERS                  = "iam:ListUsers"
+	USER_ACTION_UPDATE_USER                 = "iam:UpdateUser"
+	USER_ACTION_LIST_GROUPS_FOR_USER        = "iam:ListGroupsForUser"
+	USER_ACTION_ATTACH_USER_POLICY          = "iam:AttachUserPolicy"
+	USER_ACTION_DETACH_USER_POLICY          = "iam:DetachUserPolicy"
+	USER_ACTION_LIST_ATTACHED_USER_POLICIES = "iam:ListAttachedUserPolicies"
 
 	// Group actions
 	GROUP_ACTION_CREATE_GROUP                 = "iam:CreateGroup"
@@ -49,6 +62,9 @@ const (
 	GROUP_ACTION_ATTACH_GROUP_POLICY          = "iam:AttachGroupPolicy"
 	GROUP_ACTION_DETACH_GROUP_POLICY          = "iam:DetachGroupPolicy"
 	GROUP_ACTION_LIST_ATTACHED_GROUP_POLICIES = "iam:ListAttachedGroupPolicies"
+	GROUP_ACTION_ADD_CHILD_GROUP              = "iam:AddChildGroup"
+	GROUP_ACTION_REMOVE_CHILD_GROUP           = "iam:RemoveChildGroup"
+	GROUP_ACTION_LIST_CHILD_GROUPS            = "iam:ListChildGroups"
 
 	// Policy actions
 	POLICY_ACTION_CREATE_POLICY        = "iam:CreatePolicy"
@@ -64,6 +80,14 @@ const (
 	PROXY_ACTION_UPDATE_RESOURCE    = "iam:UpdateProxyResource"
 	PROXY_ACTION_LIST_RESOURCES     = "iam:ListProxyResources"
 	PROXY_ACTION_GET_PROXY_RESOURCE = "iam:GetProxyResource"
+	PROXY_ACTION_GET_PROXY_CONFIG   = "iam:GetProxyConfig"
+
+	// Upstream pool actions
+	UPSTREAM_ACTION_CREATE_POOL = "iam:CreateUpstreamPool"
+	UPSTREAM_ACTION_DELETE_POOL = "iam:DeleteUpstreamPool"
+	UPSTREAM_ACTION_UPDATE_POOL = "iam:UpdateUpstreamPool"
+	UPSTREAM_ACTION_LIST_POOLS  = "iam:ListUpstreamPools"
+	UPSTREAM_ACTION_GET_POOL    = "iam:GetUpstreamPool"
 
 	// Auth OIDC provider actions
 	AUTH_OIDC_ACTION_CREATE_PROVIDER = "auth:CreateOidcProvider"
@@ -71,6 +95,21 @@ const (
 	AUTH_OIDC_ACTION_UPDATE_PROVIDER = "auth:UpdateOidcProvider"
 	AUTH_OIDC_ACTION_LIST_PROVIDERS  = "auth:ListOidcProviders"
 	AUTH_OIDC_ACTION_GET_PROVIDER    = "auth:GetOidcProvider"
+
+	// Authorization actions
+	AUTHZ_ACTION_EXPLAIN_AUTHORIZATION = "iam:ExplainAuthorization"
+	AUTHZ_ACTION_SIMULATE_POLICIES     = "iam:SimulatePolicies"
+
+	// Audit actions
+	AUDIT_ACTION_LIST_ENTRIES = "iam:ListAuditEntries"
+
+	// Webhook actions
+	WEBHOOK_ACTION_CREATE_WEBHOOK    = "iam:CreateWebhook"
+	WEBHOOK_ACTION_DELETE_WEBHOOK    = "iam:DeleteWebhook"
+	WEBHOOK_ACTION_GET_WEBHOOK       = "iam:GetWebhook"
+	WEBHOOK_ACTION_UPDATE_WEBHOOK    = "iam:UpdateWebhook"
+	WEBHOOK_ACTION_LIST_WEBHOOKS     = "iam:ListWebhooks"
+	WEBHOOK_ACTION_LIST_DEAD_LETTERS = "iam:ListWebhookDeadLetters"
 )
 
 var (
@@ -88,6 +127,10 @@ var (
 	rUrnExclude, _         = regexp.Compile(`[/]{2,}|[:]{2,}|[*]{2,}`)
 	rPathResource, _       = regexp.Compile(`^/$|^(/([\w*_-]+|:[\w_-]+))+$`)
 	rHost, _               = regexp.Compile(`^https?:/{2}[\w+\/\-_.]+(:\d{1,5})?$`)
+	rMatchHost, _          = regexp.Compile(`^([a-zA-Z0-9]([a-zA-Z0-9\-]*[a-zA-Z0-9])?\.)*[a-zA-Z0-9]([a-zA-Z0-9\-]*[a-zA-Z0-9])?$`)
+	rHeaderName, _         = regexp.Compile(`^[\w\-]+$`)
+	rHeaderValue, _        = regexp.Compile(`^[\x21-\x7e]([\x20-\x7e]*[\x21-\x7e])?$`)
+	rHealthCheckPath, _    = regexp.Compile(`^/[\x21-\x7e]*$`)
 	rUrnProxy, _           = regexp.Compile(`^\*$|^[\w+\-@.]+\*?$|^[\w+\-@.]+\*?$|^([\w+\-@.]|\{\w+\})+(/?(([\w+\-@.]|\{\w+\})+/)*([\w+\-@.]|\{\w+\})+)?$`)
 )
 
@@ -97,6 +140,8 @@ func CreateUrn(org string, resource string, path string, name string) string {
 		return fmt.Sprintf("urn:iws:iam::user%v%v", path, name)
 	case RESOURCE_AUTH_OIDC_PROVIDER:
 		return fmt.Sprintf("urn:iws:auth::%v%v%v", resource, path, name)
+	case RESOURCE_WEBHOOK:
+		return fmt.Sprintf("urn:iws:iam::%v%v%v", resource, path, name)
 	default:
 		return fmt.Sprintf("urn:iws:iam:%v:%v%v%v", org, resource, path, name)
 	}
@@ -108,6 +153,8 @@ func GetUrnPrefix(org string, resource string, path string) string {
 		return fmt.Sprintf("urn:iws:iam::user%v*", path)
 	case RESOURCE_AUTH_OIDC_PROVIDER:
 		return fmt.Sprintf("urn:iws:auth::%v%v*", resource, path)
+	case RESOURCE_WEBHOOK:
+		return fmt.Sprintf("urn:iws:iam::%v%v*", resource, path)
 	default:
 		return fmt.Sprintf("urn:iws:iam:%v:%v%v*", org, resource, path)
 	}
@@ -135,6 +182,24 @@ func IsValidPath(path string) bool {
 	return rPath.MatchString(path) && !rPathExclude.MatchString(path) && len(path) < MAX_PATH_LENGTH
 }
 
+// IsValidExpiration validates optional expiration dates, that must be in the future
+func IsValidExpiration(expiresAt *time.Time) bool {
+	return expiresAt == nil || expiresAt.After(time.Now().UTC())
+}
+
+// isExpired returns true if the expiration date has been reached at the given date
+func isExpired(expiresAt *time.Time, date time.Time) bool {
+	return expiresAt != nil && !expiresAt.After(date)
+}
+
+// earliestExpiration returns the earliest of two expiration dates, where nil means no expiration
+func earliestExpiration(a *time.Time, b *time.Time) *time.Time {
+	if a == nil || (b != nil && b.Before(*a)) {
+		return b
+	}
+	return a
+}
+
 func IsValidEffect(effect string) error {
 	if effect != "allow" && effect != "deny" {
 		return &Error{
@@ -146,7 +211,18 @@ func IsValidEffect(effect string) error {
 }
 
 func IsValidProxyResource(resource *ResourceEntity) error {
-	if !rHost.MatchString(resource.Host) {
+	// Requests are sent to the host, or to the targets of an upstream pool
+	if len(resource.Upstream) > 0 {
+		if len(resource.Host) > 0 {
+			return &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: host and upstream can't be both set",
+			}
+		}
+		if !IsValidName(resource.Upstream) {
+			return errFunc("upstream", resource.Upstream)
+		}
+	} else if !rHost.MatchString(resource.Host) {
 		return errFunc("host", resource.Host)
 	}
 
@@ -171,6 +247,92 @@ func IsValidProxyResource(resource *ResourceEntity) error {
 		return err
 	}
 
+	if resource.MatchHost != "" && (!rMatchHost.MatchString(resource.MatchHost) || len(resource.MatchHost) > MAX_HOST_LENGTH) {
+		return errFunc("match_host", resource.MatchHost)
+	}
+
+	headers := map[string]bool{}
+	for _, h := range resource.MatchHeaders {
+		// Host header isn't in request headers, it is matched with match_host
+		name := http.CanonicalHeaderKey(h.Name)
+		if !rHeaderName.MatchString(h.Name) || len(h.Name) > MAX_NAME_LENGTH || name == "Host" {
+			return errFunc("match_header_name", h.Name)
+		}
+		if !rHeaderValue.MatchString(h.Value) || len(h.Value) > MAX_HEADER_LENGTH {
+			return errFunc("match_header_value", h.Value)
+		}
+		if headers[name] {
+			return &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter: match header %v is repeated", h.Name),
+			}
+		}
+		headers[name] = true
+	}
+
+	return nil
+}
+
+// IsValidUpstreamPoolConfig validates the targets of an upstream pool, its balancer and its settings.
+// Times are in seconds, and zero values are replaced by default ones.
+func IsValidUpstreamPoolConfig(config *UpstreamPoolConfig) error {
+	if len(config.Targets) < 1 || len(config.Targets) > MAX_UPSTREAM_TARGETS {
+		return &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: targets must have between 1 and %v items", MAX_UPSTREAM_TARGETS),
+		}
+	}
+	targets := map[string]bool{}
+	for _, target := range config.Targets {
+		if !rHost.MatchString(target) {
+			return errFunc("target", target)
+		}
+		if targets[target] {
+			return &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter: target %v is repeated", target),
+			}
+		}
+		targets[target] = true
+	}
+
+	if len(config.Balancer) > 0 && config.Balancer != UPSTREAM_BALANCER_ROUND_ROBIN &&
+		config.Balancer != UPSTREAM_BALANCER_LEAST_CONNECTIONS {
+		return errFunc("balancer", config.Balancer)
+	}
+
+	if hc := config.HealthCheck; hc != nil {
+		if !rHealthCheckPath.MatchString(hc.Path) || len(hc.Path) > MAX_PATH_LENGTH {
+			return errFunc("health_check_path", hc.Path)
+		}
+		if err := isValidUpstreamSetting("health_check_interval", hc.Interval, MAX_UPSTREAM_SECONDS); err != nil {
+			return err
+		}
+		if err := isValidUpstreamSetting("health_check_timeout", hc.Timeout, MAX_UPSTREAM_SECONDS); err != nil {
+			return err
+		}
+		if err := isValidUpstreamSetting("health_check_threshold", hc.Threshold, MAX_UPSTREAM_SETTING); err != nil {
+			return err
+		}
+	}
+
+	if err := isValidUpstreamSetting("ejection_max_failures", config.Ejection.MaxFailures, MAX_UPSTREAM_SETTING); err != nil {
+		return err
+	}
+	if err := isValidUpstreamSetting("ejection_duration", config.Ejection.Duration, MAX_UPSTREAM_SECONDS); err != nil {
+		return err
+	}
+
+	if err := isValidUpstreamSetting("transport_max_idle_conns", config.Transport.MaxIdleConns, MAX_UPSTREAM_SETTING); err != nil {
+		return err
+	}
+	if err := isValidUpstreamSetting("transport_idle_conn_timeout", config.Transport.IdleConnTimeout, MAX_UPSTREAM_SECONDS); err != nil {
+		return err
+	}
+	if err := isValidUpstreamSetting("transport_response_timeout", config.Transport.ResponseTimeout, MAX_UPSTREAM_SECONDS); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -282,6 +444,43 @@ func AreValidStatements(statements *[]Statement) error {
 		if err != nil {
 			return err
 		}
+
+		// check conditions
+		err = AreValidConditions(statement.Conditions)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func AreValidConditions(conditions Conditions) error {
+	for operator, keys := range conditions {
+		if len(keys) < 1 {
+			return &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Empty keys for condition %v", operator),
+			}
+		}
+		for key, values := range keys {
+			if !isValidConditionKey(key) {
+				return &Error{
+					Code:    INVALID_PARAMETER_ERROR,
+					Message: fmt.Sprintf("Invalid parameter: condition key %v", key),
+				}
+			}
+			if len(values) < 1 {
+				return &Error{
+					Code:    INVALID_PARAMETER_ERROR,
+					Message: fmt.Sprintf("Empty values for condition %v and key %v", operator, key),
+				}
+			}
+			for _, value := range values {
+				if err := isValidConditionValue(operator, value); err != nil {
+					return err
+				}
+			}
+		}
 	}
 	return nil
 }
@@ -298,6 +497,35 @@ func AreValidOidcClientNames(oidcClients []string) error {
 	return nil
 }
 
+// IsValidWebhookURL validates webhook endpoints, that must be absolute http or https URLs
+func IsValidWebhookURL(webhookURL string) bool {
+	u, err := url.ParseRequestURI(webhookURL)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && len(u.Host) > 0 && len(webhookURL) < MAX_PATH_LENGTH
+}
+
+// AreValidWebhookEvents validates webhook event filters. A filter is an event type like "group.member_added",
+// all events of an entity like "group.*" or all events "*".
+func AreValidWebhookEvents(events []string) error {
+	if len(events) < 1 {
+		return &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: "Invalid parameter: events can't be empty",
+		}
+	}
+	for _, event := range events {
+		if !isValidWebhookEvent(event) {
+			return &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter: event %v", event),
+			}
+		}
+	}
+	return nil
+}
+
 func validateFilter(filter *Filter, validColumns []string) error {
 	if len(filter.Org) > 0 && !IsValidOrg(filter.Org) {
 		return &Error{
@@ -372,8 +600,45 @@ func validateFilter(filter *Filter, validColumns []string) error {
 	return nil
 }
 
+// EntityTag returns the strong entity tag of a resource version, derived from its update date
+func EntityTag(updateAt time.Time) string {
+	return fmt.Sprintf("\"%x\"", updateAt.UnixNano())
+}
+
+// checkIfMatch returns a PRECONDITION_FAILED error if the request is conditional, and none of its entity tags
+// matches the resource version. "*" matches any version.
+func checkIfMatch(requestInfo RequestInfo, resourceUrn string, updateAt time.Time) error {
+	if len(requestInfo.IfMatch) == 0 {
+		return nil
+	}
+
+	entityTag := EntityTag(updateAt)
+	for _, tag := range strings.Split(requestInfo.IfMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == entityTag {
+			return nil
+		}
+	}
+
+	return &Error{
+		Code:    PRECONDITION_FAILED,
+		Message: fmt.Sprintf("Resource %v doesn't match any of the versions %v, current version is %v", resourceUrn, requestInfo.IfMatch, entityTag),
+	}
+}
+
 // Private Methods
 
+// isValidUpstreamSetting validates a setting of an upstream pool, where zero is the default value
+func isValidUpstreamSetting(parameter string, value int, max int) error {
+	if value < 0 || value > max {
+		return &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: %v %v, must be between 0 and %v", parameter, value, max),
+		}
+	}
+	return nil
+}
+
 func errFunc(parameter string, value string) error {
 	return &Error{
 		Code:    REGEX_NO_MATCH,
@@ -3,6 +3,9 @@ package api
 import (
 	"fmt"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestCreateUrn(t *testing.T) {
@@ -33,6 +36,12 @@ func TestCreateUrn(t *testing.T) {
 			name:        "policy",
 			expectedUrn: "urn:iws:iam:org1:policy/policypath/policy",
 		},
+		"OkCaseWebhookResource": {
+			resource:    RESOURCE_WEBHOOK,
+			path:        "/webhookpath/",
+			name:        "webhook",
+			expectedUrn: "urn:iws:iam::webhook/webhookpath/webhook",
+		},
 	}
 
 	for x, testcase := range testcases {
@@ -65,6 +74,11 @@ func TestGetUrnPrefix(t *testing.T) {
 			path:        "/policypath/",
 			expectedUrn: "urn:iws:iam:org1:policy/policypath/*",
 		},
+		"OkCaseWebhookResourcePrefix": {
+			resource:    RESOURCE_WEBHOOK,
+			path:        "/webhookpath/",
+			expectedUrn: "urn:iws:iam::webhook/webhookpath/*",
+		},
 	}
 
 	for x, testcase := range testcases {
@@ -439,6 +453,28 @@ func TestAreValidStatements(t *testing.T) {
 				Message: "Invalid parameter urn, value: urn:iws:iam::user/path/****",
 			},
 		},
+		"ErrorCaseInvalidCondition": {
+			Statements: &[]Statement{
+				{
+					Effect: "allow",
+					Actions: []string{
+						USER_ACTION_GET_USER,
+					},
+					Resources: []string{
+						GetUrnPrefix("", RESOURCE_USER, "/path/"),
+					},
+					Conditions: Conditions{
+						CONDITION_IP_ADDRESS: {
+							CONDITION_KEY_SOURCE_IP: {"fail"},
+						},
+					},
+				},
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: condition IpAddress value fail, it must be an IP address or CIDR block",
+			},
+		},
 	}
 
 	for x, testcase := range testcases {
@@ -447,6 +483,134 @@ func TestAreValidStatements(t *testing.T) {
 	}
 }
 
+func TestAreValidConditions(t *testing.T) {
+	testcases := map[string]struct {
+		// Method args
+		conditions Conditions
+		// Expected results
+		wantError error
+	}{
+		"OKCase": {
+			conditions: Conditions{
+				CONDITION_IP_ADDRESS: {
+					CONDITION_KEY_SOURCE_IP: {"10.0.0.0/8", "127.0.0.1"},
+				},
+				CONDITION_DATE_LESS_THAN: {
+					CONDITION_KEY_CURRENT_TIME: {"2020-01-01T00:00:00Z"},
+				},
+				CONDITION_STRING_LIKE: {
+					CONDITION_KEY_USER_AGENT: {"curl/*"},
+				},
+				CONDITION_BOOL: {
+					CONDITION_KEY_SECURE_TRANSPORT: {"true"},
+				},
+			},
+		},
+		"OKCaseNilConditions": {},
+		"OKCaseResourceKeys": {
+			conditions: Conditions{
+				CONDITION_STRING_EQUALS: {
+					CONDITION_KEY_REQUEST_HEADER + "X-Tenant": {"${" + CONDITION_KEY_RESOURCE_ORG + "}"},
+				},
+				CONDITION_STRING_LIKE: {
+					CONDITION_KEY_RESOURCE_URN: {"urn:iws:iam:${" + CONDITION_KEY_REQUEST_HEADER + "X-Tenant}:*"},
+				},
+			},
+		},
+		"ErrorCaseInvalidHeaderKey": {
+			conditions: Conditions{
+				CONDITION_STRING_EQUALS: {
+					CONDITION_KEY_REQUEST_HEADER + "X Tenant": {"value"},
+				},
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: condition key foulkon:RequestHeader/X Tenant",
+			},
+		},
+		"ErrorCaseInvalidVariable": {
+			conditions: Conditions{
+				CONDITION_STRING_EQUALS: {
+					CONDITION_KEY_REQUEST_HEADER + "X-Tenant": {"${foulkon:Fail}"},
+				},
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: condition StringEquals value ${foulkon:Fail}, unknown variable ${foulkon:Fail}",
+			},
+		},
+		"ErrorCaseInvalidOperator": {
+			conditions: Conditions{
+				"Fail": {
+					CONDITION_KEY_SOURCE_IP: {"10.0.0.0/8"},
+				},
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: condition operator Fail",
+			},
+		},
+		"ErrorCaseInvalidKey": {
+			conditions: Conditions{
+				CONDITION_STRING_EQUALS: {
+					"foulkon:Fail": {"value"},
+				},
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: condition key foulkon:Fail",
+			},
+		},
+		"ErrorCaseEmptyKeys": {
+			conditions: Conditions{
+				CONDITION_STRING_EQUALS: {},
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Empty keys for condition StringEquals",
+			},
+		},
+		"ErrorCaseEmptyValues": {
+			conditions: Conditions{
+				CONDITION_STRING_EQUALS: {
+					CONDITION_KEY_USER_AGENT: {},
+				},
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Empty values for condition StringEquals and key foulkon:UserAgent",
+			},
+		},
+		"ErrorCaseInvalidDate": {
+			conditions: Conditions{
+				CONDITION_DATE_GREATER_THAN: {
+					CONDITION_KEY_CURRENT_TIME: {"2020-01-01"},
+				},
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: condition DateGreaterThan value 2020-01-01, it must be a RFC3339 date",
+			},
+		},
+		"ErrorCaseInvalidBool": {
+			conditions: Conditions{
+				CONDITION_BOOL: {
+					CONDITION_KEY_SECURE_TRANSPORT: {"yes"},
+				},
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: condition Bool value yes, it must be true or false",
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		err := AreValidConditions(testcase.conditions)
+		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
+	}
+}
+
 func TestAreValidResources(t *testing.T) {
 	testcases := map[string]struct {
 		// Method args
@@ -787,6 +951,34 @@ func TestIsValidProxyResource(t *testing.T) {
 				Message: "Invalid parameter host, value: ~32&",
 			},
 		},
+		"OKCaseUpstream": {
+			resource: &ResourceEntity{
+				Upstream: "pool1",
+				Path:     "/path",
+				Method:   "GET",
+				Urn:      "urn:ews:example:instance1:resource/get",
+				Action:   "action",
+			},
+		},
+		"ErrorCaseHostAndUpstream": {
+			resource: &ResourceEntity{
+				Host:     "http://host.com",
+				Upstream: "pool1",
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: host and upstream can't be both set",
+			},
+		},
+		"ErrorCaseInvalidUpstream": {
+			resource: &ResourceEntity{
+				Upstream: "~32&",
+			},
+			wantError: &Error{
+				Code:    REGEX_NO_MATCH,
+				Message: "Invalid parameter upstream, value: ~32&",
+			},
+		},
 		"ErrorCaseInvalidPath": {
 			resource: &ResourceEntity{
 				Host: "http://host.com",
@@ -846,6 +1038,87 @@ func TestIsValidProxyResource(t *testing.T) {
 				Message: "Invalid parameter urn, value: urn:ews:example:*",
 			},
 		},
+		"OKCaseWithHostAndHeaders": {
+			resource: &ResourceEntity{
+				Host:         "http://host.com",
+				Path:         "/path",
+				Method:       "GET",
+				Urn:          "urn:ews:example:instance1:resource/get",
+				Action:       "action",
+				MatchHost:    "api-v2.Example.com",
+				MatchHeaders: []HeaderMatcher{{Name: "X-Version", Value: "2"}, {Name: "accept", Value: "application/json; q=1"}},
+			},
+		},
+		"ErrorCaseInvalidMatchHost": {
+			resource: &ResourceEntity{
+				Host:      "http://host.com",
+				Path:      "/path",
+				Method:    "GET",
+				Urn:       "urn:ews:example:instance1:resource/get",
+				Action:    "action",
+				MatchHost: "api.example.com:8000",
+			},
+			wantError: &Error{
+				Code:    REGEX_NO_MATCH,
+				Message: "Invalid parameter match_host, value: api.example.com:8000",
+			},
+		},
+		"ErrorCaseInvalidMatchHeaderName": {
+			resource: &ResourceEntity{
+				Host:         "http://host.com",
+				Path:         "/path",
+				Method:       "GET",
+				Urn:          "urn:ews:example:instance1:resource/get",
+				Action:       "action",
+				MatchHeaders: []HeaderMatcher{{Name: "X Version", Value: "2"}},
+			},
+			wantError: &Error{
+				Code:    REGEX_NO_MATCH,
+				Message: "Invalid parameter match_header_name, value: X Version",
+			},
+		},
+		"ErrorCaseMatchHostHeader": {
+			resource: &ResourceEntity{
+				Host:         "http://host.com",
+				Path:         "/path",
+				Method:       "GET",
+				Urn:          "urn:ews:example:instance1:resource/get",
+				Action:       "action",
+				MatchHeaders: []HeaderMatcher{{Name: "host", Value: "api.example.com"}},
+			},
+			wantError: &Error{
+				Code:    REGEX_NO_MATCH,
+				Message: "Invalid parameter match_header_name, value: host",
+			},
+		},
+		"ErrorCaseInvalidMatchHeaderValue": {
+			resource: &ResourceEntity{
+				Host:         "http://host.com",
+				Path:         "/path",
+				Method:       "GET",
+				Urn:          "urn:ews:example:instance1:resource/get",
+				Action:       "action",
+				MatchHeaders: []HeaderMatcher{{Name: "X-Version", Value: ""}},
+			},
+			wantError: &Error{
+				Code:    REGEX_NO_MATCH,
+				Message: "Invalid parameter match_header_value, value: ",
+			},
+		},
+		"ErrorCaseRepeatedMatchHeader": {
+			resource: &ResourceEntity{
+				Host:         "http://host.com",
+				Path:         "/path",
+				Method:       "GET",
+				Urn:          "urn:ews:example:instance1:resource/get",
+				Action:       "action",
+				MatchHeaders: []HeaderMatcher{{Name: "X-Version", Value: "1"}, {Name: "x-version", Value: "2"}},
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: match header x-version is repeated",
+			},
+		},
 	}
 
 	for x, testcase := range testcases {
@@ -853,3 +1126,238 @@ func TestIsValidProxyResource(t *testing.T) {
 		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
 	}
 }
+
+func TestIsValidUpstreamPoolConfig(t *testing.T) {
+	testcases := map[string]struct {
+		// Method args
+		config *UpstreamPoolConfig
+		// Expected results
+		wantError error
+	}{
+		"OKCase": {
+			config: &UpstreamPoolConfig{
+				Targets: []string{"http://10.0.0.1:8080", "https://10.0.0.2"},
+			},
+		},
+		"OKCaseAllSettings": {
+			config: &UpstreamPoolConfig{
+				Targets:     []string{"http://10.0.0.1:8080"},
+				Balancer:    UPSTREAM_BALANCER_LEAST_CONNECTIONS,
+				HealthCheck: &UpstreamHealthCheck{Path: "/health?full=true", Interval: 5, Timeout: 1, Threshold: 3},
+				Ejection:    UpstreamEjection{MaxFailures: 3, Duration: 60},
+				Transport:   UpstreamTransport{MaxIdleConns: 10, IdleConnTimeout: 30, ResponseTimeout: 5},
+			},
+		},
+		"ErrorCaseNoTargets": {
+			config: &UpstreamPoolConfig{},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: targets must have between 1 and 50 items",
+			},
+		},
+		"ErrorCaseInvalidTarget": {
+			config: &UpstreamPoolConfig{
+				Targets: []string{"10.0.0.1:8080"},
+			},
+			wantError: &Error{
+				Code:    REGEX_NO_MATCH,
+				Message: "Invalid parameter target, value: 10.0.0.1:8080",
+			},
+		},
+		"ErrorCaseRepeatedTarget": {
+			config: &UpstreamPoolConfig{
+				Targets: []string{"http://10.0.0.1:8080", "http://10.0.0.1:8080"},
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: target http://10.0.0.1:8080 is repeated",
+			},
+		},
+		"ErrorCaseInvalidBalancer": {
+			config: &UpstreamPoolConfig{
+				Targets:  []string{"http://10.0.0.1:8080"},
+				Balancer: "random",
+			},
+			wantError: &Error{
+				Code:    REGEX_NO_MATCH,
+				Message: "Invalid parameter balancer, value: random",
+			},
+		},
+		"ErrorCaseInvalidHealthCheckPath": {
+			config: &UpstreamPoolConfig{
+				Targets:     []string{"http://10.0.0.1:8080"},
+				HealthCheck: &UpstreamHealthCheck{Path: "health"},
+			},
+			wantError: &Error{
+				Code:    REGEX_NO_MATCH,
+				Message: "Invalid parameter health_check_path, value: health",
+			},
+		},
+		"ErrorCaseInvalidHealthCheckInterval": {
+			config: &UpstreamPoolConfig{
+				Targets:     []string{"http://10.0.0.1:8080"},
+				HealthCheck: &UpstreamHealthCheck{Path: "/health", Interval: -1},
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: health_check_interval -1, must be between 0 and 3600",
+			},
+		},
+		"ErrorCaseInvalidEjectionMaxFailures": {
+			config: &UpstreamPoolConfig{
+				Targets:  []string{"http://10.0.0.1:8080"},
+				Ejection: UpstreamEjection{MaxFailures: 1001},
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: ejection_max_failures 1001, must be between 0 and 1000",
+			},
+		},
+		"ErrorCaseInvalidResponseTimeout": {
+			config: &UpstreamPoolConfig{
+				Targets:   []string{"http://10.0.0.1:8080"},
+				Transport: UpstreamTransport{ResponseTimeout: 3601},
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: transport_response_timeout 3601, must be between 0 and 3600",
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		err := IsValidUpstreamPoolConfig(testcase.config)
+		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
+	}
+}
+
+func TestEntityTag(t *testing.T) {
+	updateAt := time.Unix(0, 255).UTC()
+	assert.Equal(t, "\"ff\"", EntityTag(updateAt), "Unexpected entity tag")
+	assert.NotEqual(t, EntityTag(updateAt), EntityTag(updateAt.Add(time.Nanosecond)), "Entity tags must change on updates")
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	updateAt := time.Unix(0, 255).UTC()
+	testcases := map[string]struct {
+		ifMatch   string
+		wantError error
+	}{
+		"OkCaseNotConditional": {},
+		"OkCaseMatch": {
+			ifMatch: "\"ff\"",
+		},
+		"OkCaseMatchInList": {
+			ifMatch: "\"aa\", \"ff\"",
+		},
+		"OkCaseAnyVersion": {
+			ifMatch: "*",
+		},
+		"ErrorCaseMismatch": {
+			ifMatch: "\"aa\"",
+			wantError: &Error{
+				Code:    PRECONDITION_FAILED,
+				Message: "Resource urn doesn't match any of the versions \"aa\", current version is \"ff\"",
+			},
+		},
+		"ErrorCaseWeakTag": {
+			ifMatch: "W/\"ff\"",
+			wantError: &Error{
+				Code:    PRECONDITION_FAILED,
+				Message: "Resource urn doesn't match any of the versions W/\"ff\", current version is \"ff\"",
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		err := checkIfMatch(RequestInfo{IfMatch: testcase.ifMatch}, "urn", updateAt)
+		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
+	}
+}
+
+func TestIsValidWebhookURL(t *testing.T) {
+	testcases := map[string]struct {
+		url   string
+		valid bool
+	}{
+		"OkCaseHttp": {
+			url:   "http://hooks.example.com/foulkon",
+			valid: true,
+		},
+		"OkCaseHttps": {
+			url:   "https://hooks.example.com:8443/foulkon?token=1",
+			valid: true,
+		},
+		"OkCaseInvalidEmpty": {
+			url:   "",
+			valid: false,
+		},
+		"OkCaseInvalidRelative": {
+			url:   "/foulkon",
+			valid: false,
+		},
+		"OkCaseInvalidScheme": {
+			url:   "ftp://hooks.example.com/foulkon",
+			valid: false,
+		},
+		"OkCaseInvalidNoHost": {
+			url:   "http:///foulkon",
+			valid: false,
+		},
+		"OkCaseMaxLimitExceed": {
+			url:   "http://hooks.example.com/" + getRandomString([]rune("abcdefghijklmnopqrstuvwxyz"), MAX_PATH_LENGTH),
+			valid: false,
+		},
+	}
+
+	for x, testcase := range testcases {
+		valid := IsValidWebhookURL(testcase.url)
+		checkMethodResponse(t, x, nil, nil, testcase.valid, valid)
+	}
+}
+
+func TestAreValidWebhookEvents(t *testing.T) {
+	testcases := map[string]struct {
+		events    []string
+		wantError error
+	}{
+		"OkCaseEventTypes": {
+			events: []string{WEBHOOK_EVENT_USER_CREATED, WEBHOOK_EVENT_GROUP_MEMBER_ADDED},
+		},
+		"OkCaseEntityEvents": {
+			events: []string{"policy.*"},
+		},
+		"OkCaseProxyEvents": {
+			events: []string{"proxy_resource.*", WEBHOOK_EVENT_UPSTREAM_POOL_UPDATED},
+		},
+		"OkCaseAllEvents": {
+			events: []string{WEBHOOK_EVENT_ALL},
+		},
+		"ErrorCaseEmpty": {
+			events: []string{},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: events can't be empty",
+			},
+		},
+		"ErrorCaseUnknownEvent": {
+			events: []string{WEBHOOK_EVENT_USER_CREATED, "user.renamed"},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: event user.renamed",
+			},
+		},
+		"ErrorCaseUnknownEntity": {
+			events: []string{"proxy.*"},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: event proxy.*",
+			},
+		},
+	}
+
+	for x, testcase := range testcases {
+		err := AreValidWebhookEvents(testcase.events)
+		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
+	}
+}
@@ -0,0 +1,612 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/satori/go.uuid"
+)
+
+const (
+	// Webhook event types
+	WEBHOOK_EVENT_ALL                    = "*"
+	WEBHOOK_EVENT_USER_CREATED           = "user.created"
+	WEBHOOK_EVENT_USER_UPDATED           = "user.updated"
+	WEBHOOK_EVENT_USER_DELETED           = "user.deleted"
+	WEBHOOK_EVENT_USER_POLICY_ATTACHED   = "user.policy_attached"
+	WEBHOOK_EVENT_USER_POLICY_DETACHED   = "user.policy_detached"
+	WEBHOOK_EVENT_GROUP_CREATED          = "group.created"
+	WEBHOOK_EVENT_GROUP_UPDATED          = "group.updated"
+	WEBHOOK_EVENT_GROUP_DELETED          = "group.deleted"
+	WEBHOOK_EVENT_GROUP_MEMBER_ADDED     = "group.member_added"
+	WEBHOOK_EVENT_GROUP_MEMBER_REMOVED   = "group.member_removed"
+	WEBHOOK_EVENT_GROUP_POLICY_ATTACHED  = "group.policy_attached"
+	WEBHOOK_EVENT_GROUP_POLICY_DETACHED  = "group.policy_detached"
+	WEBHOOK_EVENT_GROUP_CHILD_ADDED      = "group.child_added"
+	WEBHOOK_EVENT_GROUP_CHILD_REMOVED    = "group.child_removed"
+	WEBHOOK_EVENT_POLICY_CREATED         = "policy.created"
+	WEBHOOK_EVENT_POLICY_UPDATED         = "policy.updated"
+	WEBHOOK_EVENT_POLICY_DELETED         = "policy.deleted"
+	WEBHOOK_EVENT_PROXY_RESOURCE_CREATED = "proxy_resource.created"
+	WEBHOOK_EVENT_PROXY_RESOURCE_UPDATED = "proxy_resource.updated"
+	WEBHOOK_EVENT_PROXY_RESOURCE_DELETED = "proxy_resource.deleted"
+	WEBHOOK_EVENT_UPSTREAM_POOL_CREATED  = "upstream_pool.created"
+	WEBHOOK_EVENT_UPSTREAM_POOL_UPDATED  = "upstream_pool.updated"
+	WEBHOOK_EVENT_UPSTREAM_POOL_DELETED  = "upstream_pool.deleted"
+)
+
+// Event type emitted by each audited action. Actions without event type don't emit events.
+var webhookEventTypes = map[string]string{
+	USER_ACTION_CREATE_USER:          WEBHOOK_EVENT_USER_CREATED,
+	USER_ACTION_UPDATE_USER:          WEBHOOK_EVENT_USER_UPDATED,
+	USER_ACTION_DELETE_USER:          WEBHOOK_EVENT_USER_DELETED,
+	USER_ACTION_ATTACH_USER_POLICY:   WEBHOOK_EVENT_USER_POLICY_ATTACHED,
+	USER_ACTION_DETACH_USER_POLICY:   WEBHOOK_EVENT_USER_POLICY_DETACHED,
+	GROUP_ACTION_CREATE_GROUP:        WEBHOOK_EVENT_GROUP_CREATED,
+	GROUP_ACTION_UPDATE_GROUP:        WEBHOOK_EVENT_GROUP_UPDATED,
+	GROUP_ACTION_DELETE_GROUP:        WEBHOOK_EVENT_GROUP_DELETED,
+	GROUP_ACTION_ADD_MEMBER:          WEBHOOK_EVENT_GROUP_MEMBER_ADDED,
+	GROUP_ACTION_REMOVE_MEMBER:       WEBHOOK_EVENT_GROUP_MEMBER_REMOVED,
+	GROUP_ACTION_ATTACH_GROUP_POLICY: WEBHOOK_EVENT_GROUP_POLICY_ATTACHED,
+	GROUP_ACTION_DETACH_GROUP_POLICY: WEBHOOK_EVENT_GROUP_POLICY_DETACHED,
+	GROUP_ACTION_ADD_CHILD_GROUP:     WEBHOOK_EVENT_GROUP_CHILD_ADDED,
+	GROUP_ACTION_REMOVE_CHILD_GROUP:  WEBHOOK_EVENT_GROUP_CHILD_REMOVED,
+	POLICY_ACTION_CREATE_POLICY:      WEBHOOK_EVENT_POLICY_CREATED,
+	POLICY_ACTION_UPDATE_POLICY:      WEBHOOK_EVENT_POLICY_UPDATED,
+	POLICY_ACTION_DELETE_POLICY:      WEBHOOK_EVENT_POLICY_DELETED,
+	PROXY_ACTION_CREATE_RESOURCE:     WEBHOOK_EVENT_PROXY_RESOURCE_CREATED,
+	PROXY_ACTION_UPDATE_RESOURCE:     WEBHOOK_EVENT_PROXY_RESOURCE_UPDATED,
+	PROXY_ACTION_DELETE_RESOURCE:     WEBHOOK_EVENT_PROXY_RESOURCE_DELETED,
+	UPSTREAM_ACTION_CREATE_POOL:      WEBHOOK_EVENT_UPSTREAM_POOL_CREATED,
+	UPSTREAM_ACTION_UPDATE_POOL:      WEBHOOK_EVENT_UPSTREAM_POOL_UPDATED,
+	UPSTREAM_ACTION_DELETE_POOL:      WEBHOOK_EVENT_UPSTREAM_POOL_DELETED,
+}
+
+// TYPE DEFINITIONS
+
+// Webhook is an endpoint subscribed to change events. Events are filtered by type, and signed with the secret.
+type Webhook struct {
+	ID       string    `json:"id,omitempty"`
+	Name     string    `json:"name,omitempty"`
+	Path     string    `json:"path,omitempty"`
+	Urn      string    `json:"urn,omitempty"`
+	CreateAt time.Time `json:"createAt,omitempty"`
+	UpdateAt time.Time `json:"updateAt,omitempty"`
+	URL      string    `json:"url,omitempty"`
+	Secret   string    `json:"-"`
+	Events   []string  `json:"events,omitempty"`
+}
+
+func (w Webhook) String() string {
+	return fmt.Sprintf("[id: %v, name: %v, path: %v, urn: %v, createAt: %v, updateAt: %v, url: %v, events: %v]",
+		w.ID, w.Name, w.Path, w.Urn, w.CreateAt.Format("2006-01-02 15:04:05 MST"),
+		w.UpdateAt.Format("2006-01-02 15:04:05 MST"), w.URL, w.Events)
+}
+
+func (w Webhook) GetUrn() string {
+	return w.Urn
+}
+
+// Subscribed returns true if any of the webhook event filters matches the event type
+func (w Webhook) Subscribed(eventType string) bool {
+	for _, event := range w.Events {
+		if event == WEBHOOK_EVENT_ALL || event == eventType {
+			return true
+		}
+		if strings.HasSuffix(event, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(event, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEvent is a change made through the API, sent to the subscribed webhooks. Its identifier is the
+// identifier of the audit entry of the change, and Before and After are the states recorded in it.
+type WebhookEvent struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Urn       string          `json:"urn"`
+	Actor     string          `json:"actor,omitempty"`
+	RequestID string          `json:"requestId,omitempty"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	CreateAt  time.Time       `json:"createAt"`
+}
+
+// WebhookDeadLetter is an event that couldn't be delivered to a webhook after all attempts
+type WebhookDeadLetter struct {
+	ID        string       `json:"id,omitempty"`
+	WebhookID string       `json:"-"`
+	Event     WebhookEvent `json:"event"`
+	Attempts  int          `json:"attempts"`
+	LastError string       `json:"lastError,omitempty"`
+	CreateAt  time.Time    `json:"createAt,omitempty"`
+}
+
+// WEBHOOK API IMPLEMENTATION
+
+func (api WorkerAPI) AddWebhook(requestInfo RequestInfo, name string, path string, url string, secret string, events []string) (*Webhook, error) {
+	var webhook *Webhook
+	err := api.withTx(func(txAPI WorkerAPI) error {
+		var err error
+		webhook, err = txAPI.addWebhook(requestInfo, name, path, url, secret, events)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (api WorkerAPI) addWebhook(requestInfo RequestInfo, name string, path string, url string, secret string, events []string) (*Webhook, error) {
+	// Validate fields
+	if !IsValidName(name) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: name %v", name),
+		}
+	}
+	if !IsValidPath(path) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: path %v", path),
+		}
+	}
+	if !IsValidWebhookURL(url) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: url %v", url),
+		}
+	}
+	if len(secret) < 1 || len(secret) > MAX_NAME_LENGTH {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: secret must have between 1 and %v characters", MAX_NAME_LENGTH),
+		}
+	}
+	if err := AreValidWebhookEvents(events); err != nil {
+		return nil, err
+	}
+
+	webhook := createWebhook(name, path, url, secret, events)
+
+	// Check restrictions
+	webhooksFiltered, err := api.GetAuthorizedWebhooks(requestInfo, webhook.Urn, WEBHOOK_ACTION_CREATE_WEBHOOK, []Webhook{webhook})
+	if err != nil {
+		return nil, err
+	}
+	if len(webhooksFiltered) < 1 {
+		return nil, &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, webhook.Urn),
+		}
+	}
+
+	// Check if webhook already exists
+	_, err = api.WebhookRepo.GetWebhookByName(name)
+
+	// Check if webhook could be retrieved
+	if err != nil {
+		// Transform to DB error
+		dbError := err.(*database.Error)
+		switch dbError.Code {
+		// Webhook doesn't exist in DB
+		case database.WEBHOOK_NOT_FOUND:
+			// Create webhook
+			createdWebhook, err := api.WebhookRepo.AddWebhook(webhook)
+
+			// Check if there is an unexpected error in DB
+			if err != nil {
+				//Transform to DB error
+				dbError := err.(*database.Error)
+				return nil, &Error{
+					Code:    UNKNOWN_API_ERROR,
+					Message: dbError.Message,
+				}
+			}
+
+			if err := api.audit(requestInfo, WEBHOOK_ACTION_CREATE_WEBHOOK, createdWebhook.Urn, nil, createdWebhook); err != nil {
+				return nil, err
+			}
+			LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Webhook created %+v", createdWebhook))
+			return createdWebhook, nil
+		default: // Unexpected error
+			return nil, &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: dbError.Message,
+			}
+		}
+	} else { // Fail if webhook exists
+		return nil, &Error{
+			Code:    WEBHOOK_ALREADY_EXIST,
+			Message: fmt.Sprintf("Unable to create webhook, webhook with name %v already exist", name),
+		}
+	}
+}
+
+func (api WorkerAPI) GetWebhookByName(requestInfo RequestInfo, name string) (*Webhook, error) {
+	// Validate fields
+	if !IsValidName(name) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: name %v", name),
+		}
+	}
+
+	// Call repo to retrieve the webhook
+	webhook, err := api.WebhookRepo.GetWebhookByName(name)
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		// Webhook doesn't exist in DB
+		if dbError.Code == database.WEBHOOK_NOT_FOUND {
+			return nil, &Error{
+				Code:    WEBHOOK_BY_NAME_NOT_FOUND,
+				Message: dbError.Message,
+			}
+		}
+		return nil, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	// Check restrictions
+	webhooksFiltered, err := api.GetAuthorizedWebhooks(requestInfo, webhook.Urn, WEBHOOK_ACTION_GET_WEBHOOK, []Webhook{*webhook})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(webhooksFiltered) > 0 {
+		webhookFiltered := webhooksFiltered[0]
+		return &webhookFiltered, nil
+	}
+	return nil, &Error{
+		Code: UNAUTHORIZED_RESOURCES_ERROR,
+		Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+			requestInfo.Identifier, webhook.Urn),
+	}
+}
+
+func (api WorkerAPI) ListWebhooks(requestInfo RequestInfo, filter *Filter) ([]string, int, error) {
+	// Validate fields
+	var total int
+	orderByValidColumns := api.WebhookRepo.OrderByValidColumns(WEBHOOK_ACTION_LIST_WEBHOOKS)
+	err := validateFilter(filter, orderByValidColumns)
+	if err != nil {
+		return nil, total, err
+	}
+
+	// Call repo to retrieve the webhooks
+	webhooks, total, err := api.WebhookRepo.GetWebhooksFiltered(filter)
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, total, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	// Check restrictions to list
+	urnPrefix := GetUrnPrefix("", RESOURCE_WEBHOOK, filter.PathPrefix)
+	webhooksFiltered, err := api.GetAuthorizedWebhooks(requestInfo, urnPrefix, WEBHOOK_ACTION_LIST_WEBHOOKS, webhooks)
+	if err != nil {
+		return nil, total, err
+	}
+
+	webhookNames := []string{}
+	for _, w := range webhooksFiltered {
+		webhookNames = append(webhookNames, w.Name)
+	}
+
+	return webhookNames, total, nil
+}
+
+func (api WorkerAPI) UpdateWebhook(requestInfo RequestInfo, name string, newName string, newPath string, newURL string,
+	newSecret string, newEvents []string) (*Webhook, error) {
+	var webhook *Webhook
+	err := api.withTx(func(txAPI WorkerAPI) error {
+		var err error
+		webhook, err = txAPI.updateWebhook(requestInfo, name, newName, newPath, newURL, newSecret, newEvents)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (api WorkerAPI) updateWebhook(requestInfo RequestInfo, name string, newName string, newPath string, newURL string,
+	newSecret string, newEvents []string) (*Webhook, error) {
+	// Validate fields
+	if !IsValidName(newName) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: new name %v", newName),
+		}
+	}
+	if !IsValidPath(newPath) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: new path %v", newPath),
+		}
+	}
+	if !IsValidWebhookURL(newURL) {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: url %v", newURL),
+		}
+	}
+	if len(newSecret) > MAX_NAME_LENGTH {
+		return nil, &Error{
+			Code:    INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: secret must have between 1 and %v characters", MAX_NAME_LENGTH),
+		}
+	}
+	if err := AreValidWebhookEvents(newEvents); err != nil {
+		return nil, err
+	}
+
+	// Call repo to retrieve the old webhook
+	oldWebhook, err := api.GetWebhookByName(requestInfo, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check restrictions
+	webhooksFiltered, err := api.GetAuthorizedWebhooks(requestInfo, oldWebhook.Urn, WEBHOOK_ACTION_UPDATE_WEBHOOK, []Webhook{*oldWebhook})
+	if err != nil {
+		return nil, err
+	}
+	if len(webhooksFiltered) < 1 {
+		return nil, &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, oldWebhook.Urn),
+		}
+	}
+
+	// Check the request applies to the current version
+	if err := checkIfMatch(requestInfo, oldWebhook.Urn, oldWebhook.UpdateAt); err != nil {
+		return nil, err
+	}
+
+	// Check if webhook with "newName" exists
+	targetWebhook, err := api.GetWebhookByName(requestInfo, newName)
+
+	if err == nil && targetWebhook.ID != oldWebhook.ID {
+		// Webhook already exists
+		return nil, &Error{
+			Code:    WEBHOOK_ALREADY_EXIST,
+			Message: fmt.Sprintf("Webhook name: %v already exists", newName),
+		}
+	}
+
+	if err != nil {
+		if apiError := err.(*Error); apiError.Code != WEBHOOK_BY_NAME_NOT_FOUND {
+			return nil, err
+		}
+	}
+
+	auxWebhook := Webhook{
+		Urn: CreateUrn("", RESOURCE_WEBHOOK, newPath, newName),
+	}
+
+	// Check restrictions
+	webhooksFiltered, err = api.GetAuthorizedWebhooks(requestInfo, auxWebhook.Urn, WEBHOOK_ACTION_UPDATE_WEBHOOK, []Webhook{auxWebhook})
+	if err != nil {
+		return nil, err
+	}
+	if len(webhooksFiltered) < 1 {
+		return nil, &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, auxWebhook.Urn),
+		}
+	}
+
+	// Keep the secret if there isn't a new one
+	if len(newSecret) == 0 {
+		newSecret = oldWebhook.Secret
+	}
+
+	webhook := Webhook{
+		ID:       oldWebhook.ID,
+		Name:     newName,
+		Path:     newPath,
+		Urn:      auxWebhook.Urn,
+		CreateAt: oldWebhook.CreateAt,
+		UpdateAt: time.Now().UTC(),
+		URL:      newURL,
+		Secret:   newSecret,
+		Events:   newEvents,
+	}
+
+	// Update webhook
+	updatedWebhook, err := api.WebhookRepo.UpdateWebhook(webhook, oldWebhook.UpdateAt)
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		switch dbError.Code {
+		case database.VERSION_CONFLICT:
+			return nil, &Error{
+				Code:    PRECONDITION_FAILED,
+				Message: dbError.Message,
+			}
+		default: // Unexpected error
+			return nil, &Error{
+				Code:    UNKNOWN_API_ERROR,
+				Message: dbError.Message,
+			}
+		}
+	}
+
+	if err := api.audit(requestInfo, WEBHOOK_ACTION_UPDATE_WEBHOOK, oldWebhook.Urn, oldWebhook, updatedWebhook); err != nil {
+		return nil, err
+	}
+	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Webhook updated from %+v to %+v",
+		oldWebhook, updatedWebhook))
+	return updatedWebhook, nil
+}
+
+func (api WorkerAPI) RemoveWebhook(requestInfo RequestInfo, name string) error {
+	return api.withTx(func(txAPI WorkerAPI) error {
+		return txAPI.removeWebhook(requestInfo, name)
+	})
+}
+
+func (api WorkerAPI) removeWebhook(requestInfo RequestInfo, name string) error {
+	// Call repo to retrieve the webhook
+	webhook, err := api.GetWebhookByName(requestInfo, name)
+	if err != nil {
+		return err
+	}
+
+	// Check restrictions
+	webhooksFiltered, err := api.GetAuthorizedWebhooks(requestInfo, webhook.Urn, WEBHOOK_ACTION_DELETE_WEBHOOK, []Webhook{*webhook})
+	if err != nil {
+		return err
+	}
+	if len(webhooksFiltered) < 1 {
+		return &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, webhook.Urn),
+		}
+	}
+
+	// Check the request applies to the current version
+	if err := checkIfMatch(requestInfo, webhook.Urn, webhook.UpdateAt); err != nil {
+		return err
+	}
+
+	err = api.WebhookRepo.RemoveWebhook(webhook.ID)
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	if err := api.audit(requestInfo, WEBHOOK_ACTION_DELETE_WEBHOOK, webhook.Urn, webhook, nil); err != nil {
+		return err
+	}
+	LogOperation(requestInfo.RequestID, requestInfo.Identifier, fmt.Sprintf("Webhook deleted %v", webhook))
+	return nil
+}
+
+func (api WorkerAPI) ListWebhookDeadLetters(requestInfo RequestInfo, filter *Filter) ([]WebhookDeadLetter, int, error) {
+	// Validate fields
+	var total int
+	orderByValidColumns := api.WebhookRepo.OrderByValidColumns(WEBHOOK_ACTION_LIST_DEAD_LETTERS)
+	err := validateFilter(filter, orderByValidColumns)
+	if err != nil {
+		return nil, total, err
+	}
+
+	// Call repo to retrieve the webhook
+	webhook, err := api.GetWebhookByName(requestInfo, filter.WebhookName)
+	if err != nil {
+		return nil, total, err
+	}
+
+	// Check restrictions
+	webhooksFiltered, err := api.GetAuthorizedWebhooks(requestInfo, webhook.Urn, WEBHOOK_ACTION_LIST_DEAD_LETTERS, []Webhook{*webhook})
+	if err != nil {
+		return nil, total, err
+	}
+	if len(webhooksFiltered) < 1 {
+		return nil, total, &Error{
+			Code: UNAUTHORIZED_RESOURCES_ERROR,
+			Message: fmt.Sprintf("User with externalId %v is not allowed to access to resource %v",
+				requestInfo.Identifier, webhook.Urn),
+		}
+	}
+
+	// Call repo to retrieve the dead letters
+	deadLetters, total, err := api.WebhookRepo.GetWebhookDeadLettersFiltered(webhook.ID, filter)
+
+	// Error handling
+	if err != nil {
+		//Transform to DB error
+		dbError := err.(*database.Error)
+		return nil, total, &Error{
+			Code:    UNKNOWN_API_ERROR,
+			Message: dbError.Message,
+		}
+	}
+
+	return deadLetters, total, nil
+}
+
+// PRIVATE HELPER METHODS
+
+func createWebhook(name string, path string, url string, secret string, events []string) Webhook {
+	urn := CreateUrn("", RESOURCE_WEBHOOK, path, name)
+	webhook := Webhook{
+		ID:       uuid.NewV4().String(),
+		Name:     name,
+		Path:     path,
+		CreateAt: time.Now().UTC(),
+		UpdateAt: time.Now().UTC(),
+		Urn:      urn,
+		URL:      url,
+		Secret:   secret,
+		Events:   events,
+	}
+
+	return webhook
+}
+
+// emitEvent emits the change event of an audit entry, if its action has one. Inside a transaction
+// the event is published once the transaction is committed.
+func (api WorkerAPI) emitEvent(entry AuditEntry) {
+	eventType, ok := webhookEventTypes[entry.Action]
+	if api.Webhooks == nil || !ok {
+		return
+	}
+
+	event := WebhookEvent{
+		ID:        entry.ID,
+		Type:      eventType,
+		Urn:       entry.Urn,
+		Actor:     entry.Actor,
+		RequestID: entry.RequestID,
+		Before:    entry.Before,
+		After:     entry.After,
+		CreateAt:  entry.CreateAt,
+	}
+	if api.pendingEvents == nil {
+		api.Webhooks.Publish([]WebhookEvent{event})
+		return
+	}
+	*api.pendingEvents = append(*api.pendingEvents, event)
+}
+
+// isValidWebhookEvent validates an event filter
+func isValidWebhookEvent(event string) bool {
+	if event == WEBHOOK_EVENT_ALL {
+		return true
+	}
+	for _, eventType := range webhookEventTypes {
+		if event == eventType || (strings.HasSuffix(event, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(event, "*"))) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/satori/go.uuid"
+)
+
+const (
+	// Headers sent with webhook events
+	WEBHOOK_SIGNATURE_HEADER = "X-Foulkon-Signature"
+	WEBHOOK_EVENT_HEADER     = "X-Foulkon-Event"
+	WEBHOOK_DELIVERY_HEADER  = "X-Foulkon-Delivery"
+)
+
+// WebhookDispatcher delivers change events to the subscribed webhooks. Events are queued and delivered in
+// background, so requests don't wait for the endpoints. Every webhook has its own delivery queue and workers,
+// so a slow or failing endpoint doesn't delay the others. Failed deliveries are scheduled to be retried with
+// exponential backoff, without holding a worker meanwhile, and stored as dead letters after the last attempt.
+// A nil dispatcher is a disabled dispatcher.
+type WebhookDispatcher struct {
+	repo        WebhookRepo
+	client      *http.Client
+	workers     int
+	queueSize   int
+	maxAttempts int
+	backoff     time.Duration
+
+	// mutex guards closed, lanes and retries, so nothing is queued after the queues are closed
+	mutex  sync.RWMutex
+	closed bool
+	queue  chan WebhookEvent
+	// Delivery queue of every webhook by ID, nil after the dispatcher is closed and queued events are routed
+	lanes map[string]chan webhookDelivery
+	// Deliveries waiting for a retry
+	retries map[*time.Timer]webhookDelivery
+	router  sync.WaitGroup
+	senders sync.WaitGroup
+}
+
+// webhookDelivery is an event to deliver to a webhook, with the attempts made and the error of the last one
+type webhookDelivery struct {
+	webhook   Webhook
+	event     WebhookEvent
+	body      []byte
+	attempts  int
+	lastError error
+}
+
+// NewWebhookDispatcher returns a dispatcher that reads subscriptions from repo and delivers events to every
+// webhook with the given number of workers. Events are dropped when more than queueSize are waiting to be
+// routed to the webhooks, or to be delivered to a webhook. Each delivery is attempted maxAttempts times,
+// waiting backoff before the first retry and doubling it in the next ones.
+func NewWebhookDispatcher(repo WebhookRepo, workers int, queueSize int, timeout time.Duration, maxAttempts int,
+	backoff time.Duration) *WebhookDispatcher {
+	if workers < 1 {
+		workers = 1
+	}
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	d := &WebhookDispatcher{
+		repo:        repo,
+		client:      &http.Client{Timeout: timeout},
+		workers:     workers,
+		queueSize:   queueSize,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		queue:       make(chan WebhookEvent, queueSize),
+		lanes:       make(map[string]chan webhookDelivery),
+		retries:     make(map[*time.Timer]webhookDelivery),
+	}
+	d.router.Add(1)
+	go d.run()
+	return d
+}
+
+// Publish queues events to deliver them. It never blocks, events are dropped and logged if the queue is full.
+func (d *WebhookDispatcher) Publish(events []WebhookEvent) {
+	if d == nil {
+		return
+	}
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	for _, event := range events {
+		if d.closed {
+			Log.WithField("requestID", event.RequestID).Warnf("Webhook dispatcher is closed, event %v dropped", event.ID)
+			continue
+		}
+		select {
+		case d.queue <- event:
+		default:
+			Log.WithField("requestID", event.RequestID).Warnf("Webhook queue is full, event %v dropped", event.ID)
+		}
+	}
+}
+
+// Close stops queueing events and waits until the queued ones are delivered. Pending retries aren't
+// waited for, those events are stored as dead letters.
+func (d *WebhookDispatcher) Close() {
+	if d == nil {
+		return
+	}
+	d.mutex.Lock()
+	if d.closed {
+		d.mutex.Unlock()
+		d.router.Wait()
+		d.senders.Wait()
+		return
+	}
+	d.closed = true
+	close(d.queue)
+	retries := d.retries
+	d.retries = make(map[*time.Timer]webhookDelivery)
+	d.mutex.Unlock()
+
+	// Retries removed from the map aren't queued by their timers anymore
+	for timer, delivery := range retries {
+		timer.Stop()
+		d.storeDeadLetter(delivery)
+	}
+
+	// Route queued events, and then deliver them
+	d.router.Wait()
+	d.mutex.Lock()
+	for _, lane := range d.lanes {
+		close(lane)
+	}
+	d.lanes = nil
+	d.mutex.Unlock()
+	d.senders.Wait()
+}
+
+// WebhookSignature returns the signature sent in the X-Foulkon-Signature header, so webhooks can check
+// the body was sent by Foulkon: "sha256=" followed by the hex encoded HMAC-SHA256 of the body with the secret.
+func WebhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// PRIVATE HELPER METHODS
+
+// run routes queued events to the delivery queues of the subscribed webhooks until the queue is closed
+func (d *WebhookDispatcher) run() {
+	defer d.router.Done()
+	for event := range d.queue {
+		webhooks, _, err := d.repo.GetWebhooksFiltered(&Filter{})
+		if err != nil {
+			Log.WithField("requestID", event.RequestID).Errorf("Couldn't retrieve webhooks to deliver event %v: %v", event.ID, err)
+			continue
+		}
+		body, err := json.Marshal(event)
+		if err != nil {
+			Log.WithField("requestID", event.RequestID).Errorf("Couldn't encode event %v: %v", event.ID, err)
+			continue
+		}
+		for _, webhook := range webhooks {
+			if webhook.Subscribed(event.Type) {
+				d.enqueue(webhookDelivery{webhook: webhook, event: event, body: body})
+			}
+		}
+	}
+}
+
+// enqueue adds a delivery to the queue of its webhook, starting the workers of the webhook if it hasn't one yet.
+// Deliveries are dropped if the queue is full, and stored as dead letters if the queues are already closed.
+func (d *WebhookDispatcher) enqueue(delivery webhookDelivery) {
+	d.mutex.Lock()
+	if d.lanes == nil {
+		d.mutex.Unlock()
+		d.storeDeadLetter(delivery)
+		return
+	}
+	lane, ok := d.lanes[delivery.webhook.ID]
+	if !ok {
+		lane = make(chan webhookDelivery, d.queueSize)
+		d.lanes[delivery.webhook.ID] = lane
+		d.senders.Add(d.workers)
+		for i := 0; i < d.workers; i++ {
+			go d.send(lane)
+		}
+	}
+	select {
+	case lane <- delivery:
+	default:
+		Log.WithField("requestID", delivery.event.RequestID).Warnf("Queue of webhook %v is full, event %v dropped",
+			delivery.webhook.Name, delivery.event.ID)
+	}
+	d.mutex.Unlock()
+}
+
+// send delivers the events of a webhook queue until it is closed
+func (d *WebhookDispatcher) send(lane chan webhookDelivery) {
+	defer d.senders.Done()
+	for delivery := range lane {
+		d.deliver(delivery)
+	}
+}
+
+// deliver makes an attempt to send the event to the webhook. If it fails, the delivery is retried later
+// or stored as a dead letter if attempts are exhausted.
+func (d *WebhookDispatcher) deliver(delivery webhookDelivery) {
+	delivery.attempts++
+	delivery.lastError = d.post(delivery.webhook, delivery.event, delivery.body)
+	if delivery.lastError == nil {
+		return
+	}
+	if delivery.attempts >= d.maxAttempts || !d.retry(delivery, d.backoff<<uint(delivery.attempts-1)) {
+		d.storeDeadLetter(delivery)
+	}
+}
+
+// retry queues the delivery again after the delay. It returns false if the dispatcher is closed.
+func (d *WebhookDispatcher) retry(delivery webhookDelivery, delay time.Duration) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.closed {
+		return false
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		d.mutex.Lock()
+		_, ok := d.retries[timer]
+		delete(d.retries, timer)
+		d.mutex.Unlock()
+		// Retries removed by Close are already stored as dead letters
+		if ok {
+			d.enqueue(delivery)
+		}
+	})
+	d.retries[timer] = delivery
+	return true
+}
+
+// storeDeadLetter stores a delivery that couldn't be made
+func (d *WebhookDispatcher) storeDeadLetter(delivery webhookDelivery) {
+	event := delivery.event
+	lastError := "Dispatcher closed before delivery"
+	if delivery.lastError != nil {
+		lastError = delivery.lastError.Error()
+	}
+	deadLetter := WebhookDeadLetter{
+		ID:        uuid.NewV4().String(),
+		WebhookID: delivery.webhook.ID,
+		Event:     event,
+		Attempts:  delivery.attempts,
+		LastError: lastError,
+		CreateAt:  time.Now().UTC(),
+	}
+	if err := d.repo.AddWebhookDeadLetter(deadLetter); err != nil {
+		Log.WithField("requestID", event.RequestID).Errorf("Couldn't store dead letter of event %v for webhook %v: %v",
+			event.ID, delivery.webhook.Name, err)
+		return
+	}
+	Log.WithField("requestID", event.RequestID).Warnf("Event %v couldn't be delivered to webhook %v after %v attempts: %v",
+		event.ID, delivery.webhook.Name, delivery.attempts, lastError)
+}
+
+func (d *WebhookDispatcher) post(webhook Webhook, event WebhookEvent, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WEBHOOK_EVENT_HEADER, event.Type)
+	req.Header.Set(WEBHOOK_DELIVERY_HEADER, event.ID)
+	req.Header.Set(WEBHOOK_SIGNATURE_HEADER, WebhookSignature(webhook.Secret, body))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("Unexpected status code %v", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookDispatcher_Publish(t *testing.T) {
+	testcases := map[string]struct {
+		// Endpoint status
+		status int
+		// Webhook events
+		events []string
+		// Expected result
+		expectedRequests int
+		expectedAttempts int
+	}{
+		"OkCaseDelivered": {
+			status:           http.StatusNoContent,
+			events:           []string{"user.*"},
+			expectedRequests: 1,
+		},
+		"OkCaseNotSubscribed": {
+			status: http.StatusNoContent,
+			events: []string{"group.*"},
+		},
+		"OkCaseDeadLetter": {
+			status:           http.StatusInternalServerError,
+			events:           []string{WEBHOOK_EVENT_ALL},
+			expectedRequests: 3,
+			expectedAttempts: 3,
+		},
+	}
+
+	event := WebhookEvent{
+		ID:        "event1",
+		Type:      WEBHOOK_EVENT_USER_CREATED,
+		Urn:       CreateUrn("", RESOURCE_USER, "/path/", "user1"),
+		Actor:     "admin",
+		RequestID: "RequestID",
+		CreateAt:  time.Now().UTC(),
+	}
+
+	for x, testcase := range testcases {
+		requests := make(chan *http.Request, 10)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			assert.Equal(t, WebhookSignature("secret", body), r.Header.Get(WEBHOOK_SIGNATURE_HEADER), "Error in test case %v", x)
+			requests <- r
+			w.WriteHeader(testcase.status)
+		}))
+
+		testRepo := makeTestRepo()
+		testRepo.ArgsOut[GetWebhooksFilteredMethod][0] = []Webhook{
+			{
+				ID:     "webhook1",
+				Name:   "webhook1",
+				URL:    server.URL,
+				Secret: "secret",
+				Events: testcase.events,
+			},
+		}
+
+		dispatcher := NewWebhookDispatcher(testRepo, 1, 10, time.Second, 3, time.Millisecond)
+		dispatcher.Publish([]WebhookEvent{event})
+
+		// Wait for the expected requests before closing, so retries aren't aborted
+		for i := 0; i < testcase.expectedRequests; i++ {
+			select {
+			case r := <-requests:
+				assert.Equal(t, WEBHOOK_EVENT_USER_CREATED, r.Header.Get(WEBHOOK_EVENT_HEADER), "Error in test case %v", x)
+				assert.Equal(t, "event1", r.Header.Get(WEBHOOK_DELIVERY_HEADER), "Error in test case %v", x)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("Error in test case %v: request %v not received", x, i+1)
+			}
+		}
+		dispatcher.Close()
+		server.Close()
+
+		assert.Equal(t, 0, len(requests), "Error in test case %v", x)
+		if testcase.expectedAttempts > 0 {
+			deadLetter := testRepo.ArgsIn[AddWebhookDeadLetterMethod][0].(WebhookDeadLetter)
+			assert.NotEmpty(t, deadLetter.ID, "Error in test case %v", x)
+			assert.Equal(t, "webhook1", deadLetter.WebhookID, "Error in test case %v", x)
+			assert.Equal(t, event, deadLetter.Event, "Error in test case %v", x)
+			assert.Equal(t, testcase.expectedAttempts, deadLetter.Attempts, "Error in test case %v", x)
+			assert.Equal(t, "Unexpected status code 500", deadLetter.LastError, "Error in test case %v", x)
+		} else {
+			assert.Nil(t, testRepo.ArgsIn[AddWebhookDeadLetterMethod][0], "Error in test case %v", x)
+		}
+	}
+}
+
+func TestWebhookDispatcher_PublishFailingWebhook(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	requests := make(chan *http.Request, 10)
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer healthy.Close()
+
+	testRepo := makeTestRepo()
+	testRepo.ArgsOut[GetWebhooksFilteredMethod][0] = []Webhook{
+		{ID: "webhook1", Name: "webhook1", URL: failing.URL, Events: []string{WEBHOOK_EVENT_ALL}},
+		{ID: "webhook2", Name: "webhook2", URL: healthy.URL, Events: []string{WEBHOOK_EVENT_ALL}},
+	}
+
+	// Retries of the failing webhook wait much longer than the test
+	dispatcher := NewWebhookDispatcher(testRepo, 1, 10, time.Second, 5, time.Hour)
+	dispatcher.Publish([]WebhookEvent{{ID: "event1", Type: WEBHOOK_EVENT_USER_CREATED}, {ID: "event2", Type: WEBHOOK_EVENT_USER_CREATED}})
+
+	// Healthy webhook receives all events meanwhile
+	for _, id := range []string{"event1", "event2"} {
+		select {
+		case r := <-requests:
+			assert.Equal(t, id, r.Header.Get(WEBHOOK_DELIVERY_HEADER))
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Event %v not received by healthy webhook", id)
+		}
+	}
+
+	// Pending retries are stored as dead letters when the dispatcher is closed
+	dispatcher.Close()
+	deadLetter := testRepo.ArgsIn[AddWebhookDeadLetterMethod][0].(WebhookDeadLetter)
+	assert.Equal(t, "webhook1", deadLetter.WebhookID)
+	assert.Equal(t, 1, deadLetter.Attempts)
+	assert.Equal(t, "Unexpected status code 500", deadLetter.LastError)
+}
+
+func TestWebhookDispatcher_Disabled(t *testing.T) {
+	var dispatcher *WebhookDispatcher
+	// A nil dispatcher ignores events
+	dispatcher.Publish([]WebhookEvent{{ID: "event1"}})
+	dispatcher.Close()
+}
+
+func TestWebhookDispatcher_PublishClosed(t *testing.T) {
+	dispatcher := NewWebhookDispatcher(makeTestRepo(), 1, 10, time.Second, 1, time.Millisecond)
+	dispatcher.Close()
+	// Events published after closing are dropped
+	dispatcher.Publish([]WebhookEvent{{ID: "event1"}})
+	assert.Equal(t, 0, len(dispatcher.queue))
+}
+
+func TestWebhookSignature(t *testing.T) {
+	signature := WebhookSignature("secret", []byte("{}"))
+	assert.Equal(t, "sha256=77325902caca812dc259733aacd046b73817372c777b8d95b402647474516e13", signature)
+	assert.NotEqual(t, signature, WebhookSignature("other", []byte("{}")))
+}
@@ -0,0 +1,951 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhook_Subscribed(t *testing.T) {
+	testcases := map[string]struct {
+		events    []string
+		eventType string
+		expected  bool
+	}{
+		"OkCaseEventType": {
+			events:    []string{WEBHOOK_EVENT_USER_CREATED},
+			eventType: WEBHOOK_EVENT_USER_CREATED,
+			expected:  true,
+		},
+		"OkCaseEntityEvents": {
+			events:    []string{"policy.*", "group.*"},
+			eventType: WEBHOOK_EVENT_GROUP_MEMBER_ADDED,
+			expected:  true,
+		},
+		"OkCaseAllEvents": {
+			events:    []string{WEBHOOK_EVENT_ALL},
+			eventType: WEBHOOK_EVENT_POLICY_DELETED,
+			expected:  true,
+		},
+		"OkCaseOtherEventType": {
+			events:    []string{WEBHOOK_EVENT_USER_CREATED},
+			eventType: WEBHOOK_EVENT_USER_DELETED,
+			expected:  false,
+		},
+		"OkCaseOtherEntity": {
+			events:    []string{"user.*"},
+			eventType: WEBHOOK_EVENT_GROUP_CREATED,
+			expected:  false,
+		},
+	}
+
+	for x, testcase := range testcases {
+		webhook := Webhook{Events: testcase.events}
+		checkMethodResponse(t, x, nil, nil, testcase.expected, webhook.Subscribed(testcase.eventType))
+	}
+}
+
+func TestWorkerAPI_AddWebhook(t *testing.T) {
+	testcases := map[string]struct {
+		requestInfo RequestInfo
+		name        string
+		path        string
+		url         string
+		secret      string
+		events      []string
+
+		getUserByExternalIDResult *User
+
+		addWebhookMethodResult       *Webhook
+		getWebhookByNameMethodResult *Webhook
+		wantError                    error
+
+		getWebhookByNameMethodErr error
+		addWebhookMethodErr       error
+	}{
+		"OKCase": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:   "test",
+			path:   "/path/",
+			url:    "https://hooks.test.com/foulkon",
+			secret: "secret",
+			events: []string{"user.*"},
+			getWebhookByNameMethodErr: &database.Error{
+				Code: database.WEBHOOK_NOT_FOUND,
+			},
+			addWebhookMethodResult: &Webhook{
+				ID:     "test1",
+				Name:   "test",
+				Path:   "/path/",
+				Urn:    CreateUrn("", RESOURCE_WEBHOOK, "/path/", "test"),
+				URL:    "https://hooks.test.com/foulkon",
+				Secret: "secret",
+				Events: []string{"user.*"},
+			},
+		},
+		"ErrorCaseWebhookAlreadyExists": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:   "test",
+			path:   "/path/",
+			url:    "https://hooks.test.com/foulkon",
+			secret: "secret",
+			events: []string{"user.*"},
+			getWebhookByNameMethodResult: &Webhook{
+				ID:   "test1",
+				Name: "test",
+				Path: "/path/",
+				Urn:  CreateUrn("", RESOURCE_WEBHOOK, "/path/", "test"),
+			},
+			wantError: &Error{
+				Code:    WEBHOOK_ALREADY_EXIST,
+				Message: "Unable to create webhook, webhook with name test already exist",
+			},
+		},
+		"ErrorCaseBadName": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "**!^#~",
+			path: "/path/",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: name **!^#~",
+			},
+		},
+		"ErrorCaseBadPath": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "test",
+			path: "*/ /**!^#~path/",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: path */ /**!^#~path/",
+			},
+		},
+		"ErrorCaseBadURL": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "test",
+			path: "/path/",
+			url:  "ftp://hooks.test.com",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: url ftp://hooks.test.com",
+			},
+		},
+		"ErrorCaseEmptySecret": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "test",
+			path: "/path/",
+			url:  "https://hooks.test.com/foulkon",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: secret must have between 1 and 128 characters",
+			},
+		},
+		"ErrorCaseBadEvents": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:   "test",
+			path:   "/path/",
+			url:    "https://hooks.test.com/foulkon",
+			secret: "secret",
+			events: []string{"user.renamed"},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: event user.renamed",
+			},
+		},
+		"ErrorCaseNoPermissions": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			name:   "test",
+			path:   "/path/",
+			url:    "https://hooks.test.com/foulkon",
+			secret: "secret",
+			events: []string{"user.*"},
+			getUserByExternalIDResult: &User{
+				ID:         "543210",
+				ExternalID: "123456",
+				Path:       "/path/",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "123456"),
+			},
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId 123456 is not allowed to access to resource urn:iws:iam::webhook/path/test",
+			},
+		},
+		"ErrorCaseAddWebhookErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:   "test",
+			path:   "/path/",
+			url:    "https://hooks.test.com/foulkon",
+			secret: "secret",
+			events: []string{"user.*"},
+			getWebhookByNameMethodErr: &database.Error{
+				Code: database.WEBHOOK_NOT_FOUND,
+			},
+			addWebhookMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+		"ErrorCaseGetWebhookDBErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:   "test",
+			path:   "/path/",
+			url:    "https://hooks.test.com/foulkon",
+			secret: "secret",
+			events: []string{"user.*"},
+			getWebhookByNameMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	testRepo := makeTestRepo()
+	testAPI := makeTestAPI(testRepo)
+
+	for x, testcase := range testcases {
+		testRepo.ArgsOut[AddWebhookMethod][0] = testcase.addWebhookMethodResult
+		testRepo.ArgsOut[AddWebhookMethod][1] = testcase.addWebhookMethodErr
+		testRepo.ArgsOut[GetWebhookByNameMethod][0] = testcase.getWebhookByNameMethodResult
+		testRepo.ArgsOut[GetWebhookByNameMethod][1] = testcase.getWebhookByNameMethodErr
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = testcase.getUserByExternalIDResult
+		webhook, err := testAPI.AddWebhook(testcase.requestInfo, testcase.name, testcase.path, testcase.url,
+			testcase.secret, testcase.events)
+		checkMethodResponse(t, x, testcase.wantError, err, webhook, testcase.addWebhookMethodResult)
+	}
+}
+
+func TestWorkerAPI_GetWebhookByName(t *testing.T) {
+	testcases := map[string]struct {
+		requestInfo RequestInfo
+		name        string
+
+		getUserByExternalIDResult *User
+
+		getWebhookByNameMethodResult *Webhook
+		wantError                    error
+
+		getWebhookByNameMethodErr error
+	}{
+		"OKCase": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "test",
+			getWebhookByNameMethodResult: &Webhook{
+				ID:     "test1",
+				Name:   "test",
+				Path:   "/path/",
+				Urn:    CreateUrn("", RESOURCE_WEBHOOK, "/path/", "test"),
+				URL:    "https://hooks.test.com/foulkon",
+				Events: []string{"*"},
+			},
+		},
+		"ErrorCaseBadName": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "**!^#~",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: name **!^#~",
+			},
+		},
+		"ErrorCaseWebhookNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "test",
+			getWebhookByNameMethodErr: &database.Error{
+				Code:    database.WEBHOOK_NOT_FOUND,
+				Message: "Webhook with name test not found",
+			},
+			wantError: &Error{
+				Code:    WEBHOOK_BY_NAME_NOT_FOUND,
+				Message: "Webhook with name test not found",
+			},
+		},
+		"ErrorCaseInternalError": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "test",
+			getWebhookByNameMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+		"ErrorCaseNoPermissions": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      false,
+			},
+			name: "test",
+			getWebhookByNameMethodResult: &Webhook{
+				ID:   "test1",
+				Name: "test",
+				Path: "/path/",
+				Urn:  CreateUrn("", RESOURCE_WEBHOOK, "/path/", "test"),
+			},
+			getUserByExternalIDResult: &User{
+				ID:         "543210",
+				ExternalID: "123456",
+				Path:       "/path/",
+				Urn:        CreateUrn("", RESOURCE_USER, "/path/", "123456"),
+			},
+			wantError: &Error{
+				Code:    UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "User with externalId 123456 is not allowed to access to resource urn:iws:iam::webhook/path/test",
+			},
+		},
+	}
+
+	testRepo := makeTestRepo()
+	testAPI := makeTestAPI(testRepo)
+
+	for x, testcase := range testcases {
+		testRepo.ArgsOut[GetWebhookByNameMethod][0] = testcase.getWebhookByNameMethodResult
+		testRepo.ArgsOut[GetWebhookByNameMethod][1] = testcase.getWebhookByNameMethodErr
+		testRepo.ArgsOut[GetUserByExternalIDMethod][0] = testcase.getUserByExternalIDResult
+		webhook, err := testAPI.GetWebhookByName(testcase.requestInfo, testcase.name)
+		checkMethodResponse(t, x, testcase.wantError, err, webhook, testcase.getWebhookByNameMethodResult)
+	}
+}
+
+func TestWorkerAPI_ListWebhooks(t *testing.T) {
+	testcases := map[string]struct {
+		requestInfo RequestInfo
+		filter      *Filter
+
+		expectedWebhooks []string
+		wantError        error
+
+		getWebhooksFilteredMethodResult []Webhook
+		getWebhooksFilteredMethodErr    error
+	}{
+		"OKCase": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				PathPrefix: "/path/",
+			},
+			getWebhooksFilteredMethodResult: []Webhook{
+				{
+					ID:   "test1",
+					Name: "webhook1",
+					Path: "/path/",
+					Urn:  CreateUrn("", RESOURCE_WEBHOOK, "/path/", "webhook1"),
+				},
+				{
+					ID:   "test2",
+					Name: "webhook2",
+					Path: "/path/",
+					Urn:  CreateUrn("", RESOURCE_WEBHOOK, "/path/", "webhook2"),
+				},
+			},
+			expectedWebhooks: []string{"webhook1", "webhook2"},
+		},
+		"ErrorCaseInvalidPath": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				PathPrefix: "/path*/ /*",
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: pathPrefix /path*/ /*",
+			},
+		},
+		"ErrorCaseInternalError": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				PathPrefix: "/path/",
+			},
+			getWebhooksFilteredMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	testRepo := makeTestRepo()
+	testAPI := makeTestAPI(testRepo)
+
+	for x, testcase := range testcases {
+		testRepo.ArgsOut[GetWebhooksFilteredMethod][0] = testcase.getWebhooksFilteredMethodResult
+		testRepo.ArgsOut[GetWebhooksFilteredMethod][1] = len(testcase.getWebhooksFilteredMethodResult)
+		testRepo.ArgsOut[GetWebhooksFilteredMethod][2] = testcase.getWebhooksFilteredMethodErr
+		webhooks, total, err := testAPI.ListWebhooks(testcase.requestInfo, testcase.filter)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.expectedWebhooks, webhooks)
+		if testcase.wantError == nil {
+			assert.Equal(t, len(testcase.expectedWebhooks), total, "Error in test case %v", x)
+		}
+	}
+}
+
+func TestWorkerAPI_UpdateWebhook(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API Method args
+		requestInfo RequestInfo
+		name        string
+		newName     string
+		newPath     string
+		newURL      string
+		newSecret   string
+		newEvents   []string
+		// Expected result
+		expectedWebhook *Webhook
+		expectedSecret  string
+		wantError       error
+		// Manager Results
+		getWebhookByNameResult            *Webhook
+		updateWebhookResult               *Webhook
+		getWebhookByNameMethodSpecialFunc func(string) (*Webhook, error)
+		// API Errors
+		getWebhookByNameErr    error
+		updateWebhookMethodErr error
+	}{
+		"OKCaseKeepSecret": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:      "webhook1",
+			newName:   "webhook1",
+			newPath:   "/new/",
+			newURL:    "https://hooks.test.com/new",
+			newEvents: []string{"group.*"},
+			expectedWebhook: &Webhook{
+				ID:     "12345",
+				Name:   "webhook1",
+				Path:   "/new/",
+				Urn:    CreateUrn("", RESOURCE_WEBHOOK, "/new/", "webhook1"),
+				URL:    "https://hooks.test.com/new",
+				Events: []string{"group.*"},
+			},
+			expectedSecret: "oldSecret",
+			getWebhookByNameResult: &Webhook{
+				ID:       "12345",
+				Name:     "webhook1",
+				Path:     "/path/",
+				Urn:      CreateUrn("", RESOURCE_WEBHOOK, "/path/", "webhook1"),
+				UpdateAt: now,
+				Secret:   "oldSecret",
+			},
+			updateWebhookResult: &Webhook{
+				ID:     "12345",
+				Name:   "webhook1",
+				Path:   "/new/",
+				Urn:    CreateUrn("", RESOURCE_WEBHOOK, "/new/", "webhook1"),
+				URL:    "https://hooks.test.com/new",
+				Events: []string{"group.*"},
+			},
+		},
+		"OKCaseNewSecret": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:      "webhook1",
+			newName:   "webhook1",
+			newPath:   "/path/",
+			newURL:    "https://hooks.test.com/foulkon",
+			newSecret: "newSecret",
+			newEvents: []string{"*"},
+			expectedWebhook: &Webhook{
+				ID:     "12345",
+				Name:   "webhook1",
+				Path:   "/path/",
+				Urn:    CreateUrn("", RESOURCE_WEBHOOK, "/path/", "webhook1"),
+				URL:    "https://hooks.test.com/foulkon",
+				Events: []string{"*"},
+			},
+			expectedSecret: "newSecret",
+			getWebhookByNameResult: &Webhook{
+				ID:       "12345",
+				Name:     "webhook1",
+				Path:     "/path/",
+				Urn:      CreateUrn("", RESOURCE_WEBHOOK, "/path/", "webhook1"),
+				UpdateAt: now,
+				Secret:   "oldSecret",
+			},
+			updateWebhookResult: &Webhook{
+				ID:     "12345",
+				Name:   "webhook1",
+				Path:   "/path/",
+				Urn:    CreateUrn("", RESOURCE_WEBHOOK, "/path/", "webhook1"),
+				URL:    "https://hooks.test.com/foulkon",
+				Events: []string{"*"},
+			},
+		},
+		"ErrorCaseInvalidName": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:    "webhook1",
+			newName: "**!^#~",
+			newPath: "/path/",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: new name **!^#~",
+			},
+		},
+		"ErrorCaseInvalidPath": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:    "webhook1",
+			newName: "webhook1",
+			newPath: "/**!^#~/",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: new path /**!^#~/",
+			},
+		},
+		"ErrorCaseInvalidURL": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:    "webhook1",
+			newName: "webhook1",
+			newPath: "/path/",
+			newURL:  "hooks.test.com",
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: url hooks.test.com",
+			},
+		},
+		"ErrorCaseWebhookNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:      "webhook1",
+			newName:   "webhook1",
+			newPath:   "/path/",
+			newURL:    "https://hooks.test.com/foulkon",
+			newEvents: []string{"*"},
+			getWebhookByNameErr: &database.Error{
+				Code:    database.WEBHOOK_NOT_FOUND,
+				Message: "Webhook with name webhook1 not found",
+			},
+			wantError: &Error{
+				Code:    WEBHOOK_BY_NAME_NOT_FOUND,
+				Message: "Webhook with name webhook1 not found",
+			},
+		},
+		"ErrorCaseWebhookAlreadyExists": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:      "webhook1",
+			newName:   "webhook2",
+			newPath:   "/path/",
+			newURL:    "https://hooks.test.com/foulkon",
+			newEvents: []string{"*"},
+			getWebhookByNameMethodSpecialFunc: func(name string) (*Webhook, error) {
+				return &Webhook{
+					ID:   name,
+					Name: name,
+					Path: "/path/",
+					Urn:  CreateUrn("", RESOURCE_WEBHOOK, "/path/", name),
+				}, nil
+			},
+			wantError: &Error{
+				Code:    WEBHOOK_ALREADY_EXIST,
+				Message: "Webhook name: webhook2 already exists",
+			},
+		},
+		"ErrorCaseIfMatchMismatch": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+				IfMatch:    "\"aa\"",
+			},
+			name:      "webhook1",
+			newName:   "webhook1",
+			newPath:   "/path/",
+			newURL:    "https://hooks.test.com/foulkon",
+			newEvents: []string{"*"},
+			getWebhookByNameResult: &Webhook{
+				ID:       "12345",
+				Name:     "webhook1",
+				Path:     "/path/",
+				Urn:      CreateUrn("", RESOURCE_WEBHOOK, "/path/", "webhook1"),
+				UpdateAt: time.Unix(0, 255).UTC(),
+			},
+			wantError: &Error{
+				Code:    PRECONDITION_FAILED,
+				Message: "Resource urn:iws:iam::webhook/path/webhook1 doesn't match any of the versions \"aa\", current version is \"ff\"",
+			},
+		},
+		"ErrorCaseVersionConflict": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:      "webhook1",
+			newName:   "webhook1",
+			newPath:   "/path/",
+			newURL:    "https://hooks.test.com/foulkon",
+			newEvents: []string{"*"},
+			getWebhookByNameResult: &Webhook{
+				ID:   "12345",
+				Name: "webhook1",
+				Path: "/path/",
+				Urn:  CreateUrn("", RESOURCE_WEBHOOK, "/path/", "webhook1"),
+			},
+			updateWebhookMethodErr: &database.Error{
+				Code:    database.VERSION_CONFLICT,
+				Message: "Webhook with id 12345 was modified or removed by another request",
+			},
+			wantError: &Error{
+				Code:    PRECONDITION_FAILED,
+				Message: "Webhook with id 12345 was modified or removed by another request",
+			},
+		},
+		"ErrorCaseUpdateWebhookErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name:      "webhook1",
+			newName:   "webhook1",
+			newPath:   "/path/",
+			newURL:    "https://hooks.test.com/foulkon",
+			newEvents: []string{"*"},
+			getWebhookByNameResult: &Webhook{
+				ID:   "12345",
+				Name: "webhook1",
+				Path: "/path/",
+				Urn:  CreateUrn("", RESOURCE_WEBHOOK, "/path/", "webhook1"),
+			},
+			updateWebhookMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	testRepo := makeTestRepo()
+	testAPI := makeTestAPI(testRepo)
+
+	for x, testcase := range testcases {
+		testRepo.ArgsOut[UpdateWebhookMethod][0] = testcase.updateWebhookResult
+		testRepo.ArgsOut[UpdateWebhookMethod][1] = testcase.updateWebhookMethodErr
+		testRepo.ArgsOut[GetWebhookByNameMethod][0] = testcase.getWebhookByNameResult
+		testRepo.ArgsOut[GetWebhookByNameMethod][1] = testcase.getWebhookByNameErr
+		testRepo.SpecialFuncs[GetWebhookByNameMethod] = testcase.getWebhookByNameMethodSpecialFunc
+
+		webhook, err := testAPI.UpdateWebhook(testcase.requestInfo, testcase.name, testcase.newName, testcase.newPath,
+			testcase.newURL, testcase.newSecret, testcase.newEvents)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.expectedWebhook, webhook)
+		if testcase.wantError == nil {
+			// Check stored webhook
+			storedWebhook := testRepo.ArgsIn[UpdateWebhookMethod][0].(Webhook)
+			assert.Equal(t, testcase.expectedSecret, storedWebhook.Secret, "Error in test case %v", x)
+			assert.Equal(t, testcase.getWebhookByNameResult.UpdateAt, testRepo.ArgsIn[UpdateWebhookMethod][1], "Error in test case %v", x)
+		}
+	}
+}
+
+func TestWorkerAPI_RemoveWebhook(t *testing.T) {
+	testcases := map[string]struct {
+		//API method args
+		requestInfo RequestInfo
+		name        string
+		// Expected result
+		wantError error
+		// Manager Results
+		getWebhookByNameResult *Webhook
+		// Manager Errors
+		getWebhookByNameErr error
+		removeWebhookErr    error
+	}{
+		"OKCase": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "webhook1",
+			getWebhookByNameResult: &Webhook{
+				ID:   "12345",
+				Name: "webhook1",
+				Path: "/path/",
+				Urn:  CreateUrn("", RESOURCE_WEBHOOK, "/path/", "webhook1"),
+			},
+		},
+		"ErrorCaseWebhookNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "webhook1",
+			getWebhookByNameErr: &database.Error{
+				Code:    database.WEBHOOK_NOT_FOUND,
+				Message: "Webhook with name webhook1 not found",
+			},
+			wantError: &Error{
+				Code:    WEBHOOK_BY_NAME_NOT_FOUND,
+				Message: "Webhook with name webhook1 not found",
+			},
+		},
+		"ErrorCaseRemoveWebhookErr": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			name: "webhook1",
+			getWebhookByNameResult: &Webhook{
+				ID:   "12345",
+				Name: "webhook1",
+				Path: "/path/",
+				Urn:  CreateUrn("", RESOURCE_WEBHOOK, "/path/", "webhook1"),
+			},
+			removeWebhookErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	testRepo := makeTestRepo()
+	testAPI := makeTestAPI(testRepo)
+
+	for x, testcase := range testcases {
+		testRepo.ArgsOut[GetWebhookByNameMethod][0] = testcase.getWebhookByNameResult
+		testRepo.ArgsOut[GetWebhookByNameMethod][1] = testcase.getWebhookByNameErr
+		testRepo.ArgsOut[RemoveWebhookMethod][0] = testcase.removeWebhookErr
+		err := testAPI.RemoveWebhook(testcase.requestInfo, testcase.name)
+		checkMethodResponse(t, x, testcase.wantError, err, nil, nil)
+		if testcase.wantError == nil {
+			assert.Equal(t, testcase.getWebhookByNameResult.ID, testRepo.ArgsIn[RemoveWebhookMethod][0], "Error in test case %v", x)
+		}
+	}
+}
+
+func TestWorkerAPI_ListWebhookDeadLetters(t *testing.T) {
+	testcases := map[string]struct {
+		requestInfo RequestInfo
+		filter      *Filter
+
+		wantError error
+
+		getWebhookByNameResult            *Webhook
+		getWebhookDeadLettersMethodResult []WebhookDeadLetter
+		getWebhookByNameErr               error
+		getWebhookDeadLettersMethodErr    error
+	}{
+		"OKCase": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				WebhookName: "webhook1",
+			},
+			getWebhookByNameResult: &Webhook{
+				ID:   "12345",
+				Name: "webhook1",
+				Path: "/path/",
+				Urn:  CreateUrn("", RESOURCE_WEBHOOK, "/path/", "webhook1"),
+			},
+			getWebhookDeadLettersMethodResult: []WebhookDeadLetter{
+				{
+					ID:        "dl1",
+					WebhookID: "12345",
+					Event: WebhookEvent{
+						ID:   "event1",
+						Type: WEBHOOK_EVENT_USER_CREATED,
+					},
+					Attempts:  5,
+					LastError: "Unexpected status code 500",
+				},
+			},
+		},
+		"ErrorCaseInvalidOrderBy": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				WebhookName: "webhook1",
+				OrderBy:     "invalid",
+			},
+			wantError: &Error{
+				Code:    INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: OrderBy invalid",
+			},
+		},
+		"ErrorCaseWebhookNotFound": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				WebhookName: "webhook1",
+			},
+			getWebhookByNameErr: &database.Error{
+				Code:    database.WEBHOOK_NOT_FOUND,
+				Message: "Webhook with name webhook1 not found",
+			},
+			wantError: &Error{
+				Code:    WEBHOOK_BY_NAME_NOT_FOUND,
+				Message: "Webhook with name webhook1 not found",
+			},
+		},
+		"ErrorCaseInternalError": {
+			requestInfo: RequestInfo{
+				Identifier: "123456",
+				Admin:      true,
+			},
+			filter: &Filter{
+				WebhookName: "webhook1",
+			},
+			getWebhookByNameResult: &Webhook{
+				ID:   "12345",
+				Name: "webhook1",
+				Path: "/path/",
+				Urn:  CreateUrn("", RESOURCE_WEBHOOK, "/path/", "webhook1"),
+			},
+			getWebhookDeadLettersMethodErr: &database.Error{
+				Code: database.INTERNAL_ERROR,
+			},
+			wantError: &Error{
+				Code: UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	testRepo := makeTestRepo()
+	testAPI := makeTestAPI(testRepo)
+
+	for x, testcase := range testcases {
+		testRepo.ArgsOut[GetWebhookByNameMethod][0] = testcase.getWebhookByNameResult
+		testRepo.ArgsOut[GetWebhookByNameMethod][1] = testcase.getWebhookByNameErr
+		testRepo.ArgsOut[GetWebhookDeadLettersMethod][0] = testcase.getWebhookDeadLettersMethodResult
+		testRepo.ArgsOut[GetWebhookDeadLettersMethod][1] = len(testcase.getWebhookDeadLettersMethodResult)
+		testRepo.ArgsOut[GetWebhookDeadLettersMethod][2] = testcase.getWebhookDeadLettersMethodErr
+		deadLetters, total, err := testAPI.ListWebhookDeadLetters(testcase.requestInfo, testcase.filter)
+		checkMethodResponse(t, x, testcase.wantError, err, testcase.getWebhookDeadLettersMethodResult, deadLetters)
+		if testcase.wantError == nil {
+			assert.Equal(t, testcase.getWebhookByNameResult.ID, testRepo.ArgsIn[GetWebhookDeadLettersMethod][0], "Error in test case %v", x)
+			assert.Equal(t, len(testcase.getWebhookDeadLettersMethodResult), total, "Error in test case %v", x)
+		}
+	}
+}
+
+func TestWorkerAPI_emitEvent(t *testing.T) {
+	testcases := map[string]struct {
+		// Operation run in a transaction
+		action string
+		fnErr  error
+		// Expected result
+		expectedEvents []string
+	}{
+		"OkCaseMappedAction": {
+			action:         USER_ACTION_CREATE_USER,
+			expectedEvents: []string{WEBHOOK_EVENT_USER_CREATED},
+		},
+		"OkCaseProxyResourceAction": {
+			action:         PROXY_ACTION_UPDATE_RESOURCE,
+			expectedEvents: []string{WEBHOOK_EVENT_PROXY_RESOURCE_UPDATED},
+		},
+		"OkCaseUnmappedAction": {
+			action:         WEBHOOK_ACTION_CREATE_WEBHOOK,
+			expectedEvents: []string{},
+		},
+		"OkCaseRollback": {
+			action:         USER_ACTION_CREATE_USER,
+			fnErr:          &Error{Code: UNKNOWN_API_ERROR},
+			expectedEvents: []string{},
+		},
+	}
+
+	for x, testcase := range testcases {
+		testRepo := makeTestRepo()
+		testAPI := makeTestAPI(testRepo)
+		// Dispatcher without workers, so the queue keeps the published events
+		testAPI.Webhooks = &WebhookDispatcher{queue: make(chan WebhookEvent, 10)}
+		requestInfo := RequestInfo{
+			Identifier: "admin",
+			RequestID:  "RequestID",
+		}
+
+		testAPI.withTx(func(txAPI WorkerAPI) error {
+			err := txAPI.audit(requestInfo, testcase.action, "urn", nil, nil)
+			assert.Nil(t, err, "Error in test case %v", x)
+			// Events aren't published until the transaction is committed
+			assert.Equal(t, 0, len(testAPI.Webhooks.queue), "Error in test case %v", x)
+			return testcase.fnErr
+		})
+
+		events := []string{}
+		for len(testAPI.Webhooks.queue) > 0 {
+			event := <-testAPI.Webhooks.queue
+			assert.Equal(t, "admin", event.Actor, "Error in test case %v", x)
+			assert.Equal(t, "RequestID", event.RequestID, "Error in test case %v", x)
+			assert.Equal(t, "urn", event.Urn, "Error in test case %v", x)
+			events = append(events, event.Type)
+		}
+		assert.Equal(t, testcase.expectedEvents, events, "Error in test case %v", x)
+	}
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+const aboutURL = "/about"
+
+// RESPONSES
+
+type LoggerConfig struct {
+	Type          string `json:"type,omitempty"`
+	Level         string `json:"level,omitempty"`
+	FileDirectory string `json:"directory,omitempty"`
+}
+
+type DatabaseConfig struct {
+	Type         string `json:"type,omitempty"`
+	IdleConns    int    `json:"idleconns,omitempty"`
+	MaxOpenConns int    `json:"maxopenconns,omitempty"`
+	ConnTtl      int    `json:"connttl,omitempty"`
+}
+
+type AuthConnectorConfig struct {
+	Type          string             `json:"type,omitempty"`
+	OidcProviders []api.OidcProvider `json:"oidcProviders,omitempty"`
+}
+
+type AuthzCacheConfig struct {
+	TTL    string `json:"ttl,omitempty"`
+	Size   int    `json:"size,omitempty"`
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+// WorkerConfig is the current configuration of the worker
+type WorkerConfig struct {
+	Logger        LoggerConfig        `json:"logger,omitempty"`
+	Database      DatabaseConfig      `json:"database,omitempty"`
+	AuthConnector AuthConnectorConfig `json:"authenticator,omitempty"`
+	AuthzCache    *AuthzCacheConfig   `json:"authzCache,omitempty"`
+	Version       string              `json:"version,omitempty"`
+}
+
+// ABOUT METHODS
+
+// GetCurrentConfig returns the configuration of the worker, only allowed to the admin user
+func (c *Client) GetCurrentConfig() (*WorkerConfig, error) {
+	config := new(WorkerConfig)
+	if err := c.do(http.MethodGet, aboutURL, nil, nil, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
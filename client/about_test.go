@@ -0,0 +1,48 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+func TestClient_AboutMethods(t *testing.T) {
+	testcases := map[string]clientTestCase{
+		"OkCaseGetCurrentConfig": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetCurrentConfig()
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/about",
+			status:         http.StatusOK,
+			response: `{"logger": {"type": "default", "level": "debug"}, "database": {"type": "postgres", "idleconns": 5,
+				"maxopenconns": 20, "connttl": 300}, "authenticator": {"type": "oidc"},
+				"authzCache": {"ttl": "1m0s", "size": 10, "hits": 3, "misses": 1}, "version": "v0.4.0"}`,
+			expectedResponse: &WorkerConfig{
+				Logger:        LoggerConfig{Type: "default", Level: "debug"},
+				Database:      DatabaseConfig{Type: "postgres", IdleConns: 5, MaxOpenConns: 20, ConnTtl: 300},
+				AuthConnector: AuthConnectorConfig{Type: "oidc"},
+				AuthzCache:    &AuthzCacheConfig{TTL: "1m0s", Size: 10, Hits: 3, Misses: 1},
+				Version:       "v0.4.0",
+			},
+		},
+		"ErrorCaseNotAdmin": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetCurrentConfig()
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/about",
+			status:         http.StatusForbidden,
+			response:       `{"code": "UnauthorizedResourcesError", "message": "Unauthorized, user is not admin"}`,
+			wantError: &Error{
+				Code:       api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message:    "Unauthorized, user is not admin",
+				StatusCode: http.StatusForbidden,
+				RequestID:  "RequestID",
+			},
+		},
+	}
+
+	runClientTestCases(t, testcases)
+}
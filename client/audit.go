@@ -0,0 +1,55 @@
+package client
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+const auditURL = adminRoot + "/audit"
+
+// AuditFilter selects the audit entries listed, the empty fields don't filter
+type AuditFilter struct {
+	Actor  string
+	Urn    string
+	Action string
+	// Time range of the entries, From is inclusive and To exclusive
+	From *time.Time
+	To   *time.Time
+}
+
+// RESPONSES
+
+type AuditEntryList struct {
+	Entries []api.AuditEntry `json:"entries,omitempty"`
+	Page
+}
+
+// AUDIT METHODS
+
+func (c *Client) ListAuditEntries(filter *AuditFilter, opts *ListOptions) (*AuditEntryList, error) {
+	query := opts.query()
+	if filter != nil {
+		if filter.Actor != "" {
+			query.Set("Actor", filter.Actor)
+		}
+		if filter.Urn != "" {
+			query.Set("Urn", filter.Urn)
+		}
+		if filter.Action != "" {
+			query.Set("Action", filter.Action)
+		}
+		if filter.From != nil {
+			query.Set("From", filter.From.Format(time.RFC3339))
+		}
+		if filter.To != nil {
+			query.Set("To", filter.To.Format(time.RFC3339))
+		}
+	}
+	list := new(AuditEntryList)
+	if err := c.do(http.MethodGet, auditURL, query, nil, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+func TestClient_AuditMethods(t *testing.T) {
+	now := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	testcases := map[string]clientTestCase{
+		"OkCaseListAuditEntries": {
+			call: func(c *Client) (interface{}, error) {
+				from := now
+				return c.ListAuditEntries(&AuditFilter{Actor: "admin", Action: "iam:CreateUser", From: &from},
+					&ListOptions{Limit: 1})
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/admin/audit",
+			expectedQuery:  "Action=iam%3ACreateUser&Actor=admin&From=2016-01-01T00%3A00%3A00Z&Limit=1",
+			status:         http.StatusOK,
+			response: `{"entries": [{"id": "AuditEntryID", "actor": "admin", "requestId": "RequestID",
+				"action": "iam:CreateUser", "urn": "urn:iws:iam::user/user1", "after": {"externalId": "user1"},
+				"createAt": "2016-01-01T00:00:00Z"}], "offset": 0, "limit": 1, "total": 1}`,
+			expectedResponse: &AuditEntryList{
+				Entries: []api.AuditEntry{
+					{
+						ID:        "AuditEntryID",
+						Actor:     "admin",
+						RequestID: "RequestID",
+						Action:    "iam:CreateUser",
+						Urn:       "urn:iws:iam::user/user1",
+						After:     json.RawMessage(`{"externalId": "user1"}`),
+						CreateAt:  now,
+					},
+				},
+				Page: Page{Limit: 1, Total: 1},
+			},
+		},
+		"OkCaseListAuditEntriesWithoutFilter": {
+			call: func(c *Client) (interface{}, error) {
+				return c.ListAuditEntries(nil, nil)
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/admin/audit",
+			status:         http.StatusOK,
+			response:       `{"offset": 0, "limit": 20, "total": 0}`,
+			expectedResponse: &AuditEntryList{
+				Page: Page{Limit: 20},
+			},
+		},
+	}
+
+	runClientTestCases(t, testcases)
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+const oidcProvidersURL = adminRoot + "/auth/oidc/providers"
+
+// RESPONSES
+
+type OidcProviderList struct {
+	Providers []string `json:"providers,omitempty"`
+	Page
+}
+
+// OIDC PROVIDER METHODS
+
+func (c *Client) AddOidcProvider(name string, path string, issuerURL string, oidcClients []string) (*api.OidcProvider, error) {
+	body := struct {
+		Name        string   `json:"name,omitempty"`
+		Path        string   `json:"path,omitempty"`
+		IssuerURL   string   `json:"issuerUrl,omitempty"`
+		OidcClients []string `json:"clients,omitempty"`
+	}{name, path, issuerURL, oidcClients}
+	provider := new(api.OidcProvider)
+	if err := c.do(http.MethodPost, oidcProvidersURL, nil, body, provider); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+func (c *Client) GetOidcProviderByName(name string) (*api.OidcProvider, error) {
+	provider := new(api.OidcProvider)
+	if err := c.do(http.MethodGet, urlPath(oidcProvidersURL, name), nil, nil, provider); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+func (c *Client) ListOidcProviders(opts *ListOptions) (*OidcProviderList, error) {
+	list := new(OidcProviderList)
+	if err := c.do(http.MethodGet, oidcProvidersURL, opts.query(), nil, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (c *Client) UpdateOidcProvider(name string, newName string, newPath string, newIssuerURL string,
+	newOidcClients []string) (*api.OidcProvider, error) {
+	body := struct {
+		Name        string   `json:"name,omitempty"`
+		Path        string   `json:"path,omitempty"`
+		IssuerURL   string   `json:"issuerUrl,omitempty"`
+		OidcClients []string `json:"clients,omitempty"`
+	}{newName, newPath, newIssuerURL, newOidcClients}
+	provider := new(api.OidcProvider)
+	if err := c.do(http.MethodPut, urlPath(oidcProvidersURL, name), nil, body, provider); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+func (c *Client) RemoveOidcProvider(name string) error {
+	return c.do(http.MethodDelete, urlPath(oidcProvidersURL, name), nil, nil, nil)
+}
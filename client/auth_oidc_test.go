@@ -0,0 +1,84 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+func TestClient_OidcProviderMethods(t *testing.T) {
+	now := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	provider := &api.OidcProvider{
+		ID:          "OidcProviderID",
+		Name:        "provider1",
+		Path:        "/path/",
+		Urn:         "urn:iws:auth::oidc/path/provider1",
+		CreateAt:    now,
+		UpdateAt:    now,
+		IssuerURL:   "https://accounts.google.com",
+		OidcClients: []api.OidcClient{{Name: "client1"}},
+	}
+	providerJSON := `{"id": "OidcProviderID", "name": "provider1", "path": "/path/", "urn": "urn:iws:auth::oidc/path/provider1",
+		"createAt": "2016-01-01T00:00:00Z", "updateAt": "2016-01-01T00:00:00Z",
+		"issuerUrl": "https://accounts.google.com", "clients": [{"name": "client1"}]}`
+	testcases := map[string]clientTestCase{
+		"OkCaseAddOidcProvider": {
+			call: func(c *Client) (interface{}, error) {
+				return c.AddOidcProvider("provider1", "/path/", "https://accounts.google.com", []string{"client1"})
+			},
+			expectedMethod:   http.MethodPost,
+			expectedPath:     "/api/v1/admin/auth/oidc/providers",
+			expectedBody:     `{"name": "provider1", "path": "/path/", "issuerUrl": "https://accounts.google.com", "clients": ["client1"]}`,
+			status:           http.StatusCreated,
+			response:         providerJSON,
+			expectedResponse: provider,
+		},
+		"OkCaseGetOidcProviderByName": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetOidcProviderByName("provider1")
+			},
+			expectedMethod:   http.MethodGet,
+			expectedPath:     "/api/v1/admin/auth/oidc/providers/provider1",
+			status:           http.StatusOK,
+			response:         providerJSON,
+			expectedResponse: provider,
+		},
+		"OkCaseListOidcProviders": {
+			call: func(c *Client) (interface{}, error) {
+				return c.ListOidcProviders(&ListOptions{OrderBy: "name"})
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/admin/auth/oidc/providers",
+			expectedQuery:  "OrderBy=name",
+			status:         http.StatusOK,
+			response:       `{"providers": ["provider1"], "offset": 0, "limit": 20, "total": 1}`,
+			expectedResponse: &OidcProviderList{
+				Providers: []string{"provider1"},
+				Page:      Page{Limit: 20, Total: 1},
+			},
+		},
+		"OkCaseUpdateOidcProvider": {
+			call: func(c *Client) (interface{}, error) {
+				return c.UpdateOidcProvider("provider0", "provider1", "/path/", "https://accounts.google.com", []string{"client1"})
+			},
+			expectedMethod:   http.MethodPut,
+			expectedPath:     "/api/v1/admin/auth/oidc/providers/provider0",
+			expectedBody:     `{"name": "provider1", "path": "/path/", "issuerUrl": "https://accounts.google.com", "clients": ["client1"]}`,
+			status:           http.StatusOK,
+			response:         providerJSON,
+			expectedResponse: provider,
+		},
+		"OkCaseRemoveOidcProvider": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.RemoveOidcProvider("provider1")
+			},
+			expectedMethod: http.MethodDelete,
+			expectedPath:   "/api/v1/admin/auth/oidc/providers/provider1",
+			status:         http.StatusNoContent,
+		},
+	}
+
+	runClientTestCases(t, testcases)
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+const (
+	resourceURL         = apiVersion1 + "/resource"
+	resourceBatchURL    = resourceURL + "/batch"
+	authorizeExplainURL = apiVersion1 + "/authorize/explain"
+	simulateURL         = apiVersion1 + "/simulate"
+)
+
+// AUTHORIZATION METHODS
+
+// GetAuthorizedExternalResources returns the resources the authenticated user is allowed to do the action with
+func (c *Client) GetAuthorizedExternalResources(action string, resources []string) ([]string, error) {
+	body := struct {
+		Action    string   `json:"action,omitempty"`
+		Resources []string `json:"resources,omitempty"`
+	}{action, resources}
+	response := struct {
+		ResourcesAllowed []string `json:"resourcesAllowed,omitempty"`
+	}{}
+	if err := c.do(http.MethodPost, resourceURL, nil, body, &response); err != nil {
+		return nil, err
+	}
+	return response.ResourcesAllowed, nil
+}
+
+// GetAuthorizedExternalResourcesBatch returns the resources the authenticated user is allowed to do the action
+// of each check with, in the order of the checks
+func (c *Client) GetAuthorizedExternalResourcesBatch(checks []api.AuthorizationCheck) ([]api.AuthorizationCheckResult, error) {
+	body := struct {
+		Checks []api.AuthorizationCheck `json:"checks,omitempty"`
+	}{checks}
+	response := struct {
+		Results []api.AuthorizationCheckResult `json:"results,omitempty"`
+	}{}
+	if err := c.do(http.MethodPost, resourceBatchURL, nil, body, &response); err != nil {
+		return nil, err
+	}
+	return response.Results, nil
+}
+
+// ExplainAuthorization returns the groups, policies and statements that decide if a user is allowed to do
+// the action with the resource
+func (c *Client) ExplainAuthorization(externalID string, action string, resource string) (*api.AuthorizationExplanation, error) {
+	body := struct {
+		ExternalID string `json:"externalId,omitempty"`
+		Action     string `json:"action,omitempty"`
+		Resource   string `json:"resource,omitempty"`
+	}{externalID, action, resource}
+	explanation := new(api.AuthorizationExplanation)
+	if err := c.do(http.MethodPost, authorizeExplainURL, nil, body, explanation); err != nil {
+		return nil, err
+	}
+	return explanation, nil
+}
+
+// SimulatePolicies returns the authorization decisions of the checks for a user before and after applying
+// the draft policies
+func (c *Client) SimulatePolicies(externalID string, policies []api.DraftPolicy, checks []api.SimulationCheck) ([]api.SimulationResult, error) {
+	body := struct {
+		ExternalID string                `json:"externalId,omitempty"`
+		Policies   []api.DraftPolicy     `json:"policies,omitempty"`
+		Checks     []api.SimulationCheck `json:"checks,omitempty"`
+	}{externalID, policies, checks}
+	response := struct {
+		Results []api.SimulationResult `json:"results,omitempty"`
+	}{}
+	if err := c.do(http.MethodPost, simulateURL, nil, body, &response); err != nil {
+		return nil, err
+	}
+	return response.Results, nil
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+func TestClient_AuthorizationMethods(t *testing.T) {
+	testcases := map[string]clientTestCase{
+		"OkCaseGetAuthorizedExternalResources": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetAuthorizedExternalResources("example:get", []string{"urn:ews:example:instance1:resource/get"})
+			},
+			expectedMethod:   http.MethodPost,
+			expectedPath:     "/api/v1/resource",
+			expectedBody:     `{"action": "example:get", "resources": ["urn:ews:example:instance1:resource/get"]}`,
+			status:           http.StatusOK,
+			response:         `{"resourcesAllowed": ["urn:ews:example:instance1:resource/get"]}`,
+			expectedResponse: []string{"urn:ews:example:instance1:resource/get"},
+		},
+		"OkCaseGetAuthorizedExternalResourcesBatch": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetAuthorizedExternalResourcesBatch([]api.AuthorizationCheck{
+					{Action: "example:get", Resources: []string{"urn:ews:example:instance1:resource/get"}},
+					{Action: "example:delete", Resources: []string{"urn:ews:example:instance1:resource/get"}},
+				})
+			},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/resource/batch",
+			expectedBody: `{"checks": [{"action": "example:get", "resources": ["urn:ews:example:instance1:resource/get"]},
+				{"action": "example:delete", "resources": ["urn:ews:example:instance1:resource/get"]}]}`,
+			status: http.StatusOK,
+			response: `{"results": [{"action": "example:get", "resourcesAllowed": ["urn:ews:example:instance1:resource/get"]},
+				{"action": "example:delete", "resourcesAllowed": []}]}`,
+			expectedResponse: []api.AuthorizationCheckResult{
+				{Action: "example:get", ResourcesAllowed: []string{"urn:ews:example:instance1:resource/get"}},
+				{Action: "example:delete", ResourcesAllowed: []string{}},
+			},
+		},
+		"OkCaseExplainAuthorization": {
+			call: func(c *Client) (interface{}, error) {
+				return c.ExplainAuthorization("user1", "example:get", "urn:ews:example:instance1:resource/get")
+			},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/authorize/explain",
+			expectedBody:   `{"externalId": "user1", "action": "example:get", "resource": "urn:ews:example:instance1:resource/get"}`,
+			status:         http.StatusOK,
+			response: `{"externalId": "user1", "action": "example:get", "resource": "urn:ews:example:instance1:resource/get",
+				"allowed": true, "groups": [{"org": "org1", "name": "group1"}], "policies": [{"org": "org1", "name": "policy1"}],
+				"statements": [{"group": {"org": "org1", "name": "group1"}, "policy": {"org": "org1", "name": "policy1"},
+				"statement": {"effect": "allow", "actions": ["example:get"], "resources": ["urn:ews:example:instance1:resource/*"]}}]}`,
+			expectedResponse: &api.AuthorizationExplanation{
+				ExternalID: "user1",
+				Action:     "example:get",
+				Resource:   "urn:ews:example:instance1:resource/get",
+				Allowed:    true,
+				Groups:     []api.GroupIdentity{{Org: "org1", Name: "group1"}},
+				Policies:   []api.PolicyIdentity{{Org: "org1", Name: "policy1"}},
+				Statements: []api.StatementSource{
+					{
+						Group:  &api.GroupIdentity{Org: "org1", Name: "group1"},
+						Policy: api.PolicyIdentity{Org: "org1", Name: "policy1"},
+						Statement: api.Statement{
+							Effect:    "allow",
+							Actions:   []string{"example:get"},
+							Resources: []string{"urn:ews:example:instance1:resource/*"},
+						},
+					},
+				},
+			},
+		},
+		"OkCaseSimulatePolicies": {
+			call: func(c *Client) (interface{}, error) {
+				return c.SimulatePolicies("user1",
+					[]api.DraftPolicy{
+						{
+							Org:  "org1",
+							Name: "policy1",
+							Statements: []api.Statement{
+								{Effect: "deny", Actions: []string{"example:get"}, Resources: []string{"urn:ews:example:instance1:resource/*"}},
+							},
+						},
+					},
+					[]api.SimulationCheck{{Action: "example:get", Resource: "urn:ews:example:instance1:resource/get"}})
+			},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/simulate",
+			expectedBody: `{"externalId": "user1", "policies": [{"org": "org1", "name": "policy1", "statements": [{"effect": "deny",
+				"actions": ["example:get"], "resources": ["urn:ews:example:instance1:resource/*"]}]}],
+				"checks": [{"action": "example:get", "resource": "urn:ews:example:instance1:resource/get"}]}`,
+			status: http.StatusOK,
+			response: `{"results": [{"action": "example:get", "resource": "urn:ews:example:instance1:resource/get",
+				"before": true, "after": false}]}`,
+			expectedResponse: []api.SimulationResult{
+				{Action: "example:get", Resource: "urn:ews:example:instance1:resource/get", Before: true, After: false},
+			},
+		},
+		"ErrorCaseUnauthorized": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetAuthorizedExternalResources("example:get", []string{"urn:ews:example:instance1:resource/get"})
+			},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/resource",
+			expectedBody:   `{"action": "example:get", "resources": ["urn:ews:example:instance1:resource/get"]}`,
+			status:         http.StatusForbidden,
+			response:       `{"code": "UnauthorizedResourcesError", "message": "User is not allowed to access any resource"}`,
+			wantError: &Error{
+				Code:       api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message:    "User is not allowed to access any resource",
+				StatusCode: http.StatusForbidden,
+				RequestID:  "RequestID",
+			},
+		},
+	}
+
+	runClientTestCases(t, testcases)
+}
@@ -0,0 +1,249 @@
+// Package client calls the REST API of a Foulkon worker.
+//
+// Methods return the types of the api package, and the error responses of the worker as *Error,
+// with the code of the api.Error:
+//
+//	c := &client.Client{
+//		URL:  "http://localhost:8000",
+//		Auth: client.BasicAuth{Username: "admin", Password: "admin"},
+//	}
+//	user, err := c.GetUserByExternalID("user1")
+//	if client.IsNotFound(err) {
+//		user, err = c.AddUser("user1", "/")
+//	}
+//
+// Updates and removals can be made conditional on the version returned by Get methods, whose ETag
+// is api.EntityTag(UpdateAt), so they fail if the resource was modified meanwhile:
+//
+//	group, err := c.GetGroupByName("example", "group1")
+//	...
+//	_, err = c.WithIfMatch(api.EntityTag(group.UpdateAt)).UpdateGroup("example", "group1", "group2", "/")
+//	if client.IsPreconditionFailed(err) {
+//		...
+//	}
+//
+// Lists can be walked page by page with iterators:
+//
+//	it := c.IterateMembers("example", "group1", nil)
+//	for it.Next() {
+//		fmt.Println(it.Value().User)
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+const (
+	// API root reference
+	apiVersion1 = "/api/v1"
+
+	// Admin API root reference
+	adminRoot = apiVersion1 + "/admin"
+
+	// Header with the ID of the requests in the worker logs
+	requestIDHeader = "X-Request-Id"
+
+	// Header with the versions of the resource to update or remove
+	ifMatchHeader = "If-Match"
+)
+
+// Client calls the API of the worker in URL, like http://localhost:8000
+type Client struct {
+	// URL of the worker
+	URL string
+	// Authentication added to the requests, if it isn't nil
+	Auth Authenticator
+	// HTTP client used to send the requests, http.DefaultClient if it is nil
+	HTTPClient *http.Client
+	// ID sent in the X-Request-Id header of the requests, if it isn't empty
+	RequestID string
+	// Entity tags sent in the If-Match header of the requests, if it isn't empty
+	IfMatch string
+}
+
+// WithRequestID returns a copy of the client that sends requestID in the requests, so the calls
+// made to handle a request of the caller share its ID in the worker logs
+func (c *Client) WithRequestID(requestID string) *Client {
+	clone := *c
+	clone.RequestID = requestID
+	return &clone
+}
+
+// WithIfMatch returns a copy of the client that sends etag in the If-Match header of the requests, so
+// updates and removals fail with a PreconditionFailed error if the resource doesn't have that version
+func (c *Client) WithIfMatch(etag string) *Client {
+	clone := *c
+	clone.IfMatch = etag
+	return &clone
+}
+
+// Authenticator adds the credentials of the caller to a request
+type Authenticator interface {
+	Authenticate(req *http.Request)
+}
+
+// BasicAuth authenticates with user and password, like the worker admin user
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+func (a BasicAuth) Authenticate(req *http.Request) {
+	req.SetBasicAuth(a.Username, a.Password)
+}
+
+// BearerToken authenticates with a token, like an OIDC ID token
+type BearerToken string
+
+func (t BearerToken) Authenticate(req *http.Request) {
+	req.Header.Set("Authorization", "Bearer "+string(t))
+}
+
+// HeaderAuth authenticates with a header, like the one trusted by the header authenticator of the worker
+type HeaderAuth struct {
+	Name  string
+	Value string
+}
+
+func (a HeaderAuth) Authenticate(req *http.Request) {
+	req.Header.Set(a.Name, a.Value)
+}
+
+// ListOptions are the filter and pagination of list requests, the defaults of the worker are used for the empty ones
+type ListOptions struct {
+	PathPrefix string
+	Offset     int
+	Limit      int
+	OrderBy    string
+}
+
+// Page is the pagination of a list response
+type Page struct {
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+	Total  int `json:"total"`
+}
+
+// Private helper methods
+
+func (opts *ListOptions) query() url.Values {
+	query := url.Values{}
+	if opts == nil {
+		return query
+	}
+	if opts.PathPrefix != "" {
+		query.Set("PathPrefix", opts.PathPrefix)
+	}
+	if opts.Offset != 0 {
+		query.Set("Offset", strconv.Itoa(opts.Offset))
+	}
+	if opts.Limit != 0 {
+		query.Set("Limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.OrderBy != "" {
+		query.Set("OrderBy", opts.OrderBy)
+	}
+	return query
+}
+
+// urlPath joins the escaped path segments to the path
+func urlPath(path string, segments ...string) string {
+	for _, s := range segments {
+		path += "/" + url.PathEscape(s)
+	}
+	return path
+}
+
+// do sends a request with the JSON encoding of body, if it isn't nil, and decodes the JSON response
+// in response, if it isn't nil. If the worker responds with an error, an *Error is returned.
+func (c *Client) do(method string, path string, query url.Values, body interface{}, response interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewBuffer(b)
+	}
+
+	reqURL := strings.TrimSuffix(c.URL, "/") + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, reqURL, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.RequestID != "" {
+		req.Header.Set(requestIDHeader, c.RequestID)
+	}
+	if c.IfMatch != "" {
+		req.Header.Set(ifMatchHeader, c.IfMatch)
+	}
+	if c.Auth != nil {
+		c.Auth.Authenticate(req)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return responseError(res)
+	}
+	if response == nil || res.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+		return fmt.Errorf("Error parsing foulkon response %v", err.Error())
+	}
+	return nil
+}
+
+// responseError returns the *Error of an error response. Responses without api.Error, like the ones of
+// authentication failures, get a code for their status code.
+func responseError(res *http.Response) *Error {
+	clientErr := &Error{
+		StatusCode: res.StatusCode,
+		RequestID:  res.Header.Get(requestIDHeader),
+	}
+	b, _ := ioutil.ReadAll(res.Body)
+	apiErr := new(api.Error)
+	if err := json.Unmarshal(b, apiErr); err == nil && apiErr.Code != "" {
+		clientErr.Code = apiErr.Code
+		clientErr.Message = apiErr.Message
+		return clientErr
+	}
+
+	if res.StatusCode == http.StatusUnauthorized {
+		clientErr.Code = api.AUTHENTICATION_API_ERROR
+		clientErr.Message = strings.TrimSpace(string(b))
+	} else {
+		clientErr.Code = api.UNKNOWN_API_ERROR
+		clientErr.Message = fmt.Sprintf("Unexpected status code %v", res.StatusCode)
+	}
+	return clientErr
+}
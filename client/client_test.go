@@ -0,0 +1,260 @@
+package client
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/stretchr/testify/assert"
+)
+
+// clientTestCase is a call of a client method, with the request the worker expects and its response
+type clientTestCase struct {
+	call func(c *Client) (interface{}, error)
+	// Expected request
+	expectedMethod string
+	expectedPath   string
+	expectedQuery  string
+	expectedBody   string
+	// Worker response
+	status   int
+	response string
+	// Expected result
+	expectedResponse interface{}
+	wantError        error
+}
+
+// runClientTestCases runs the test cases against a test server that checks the requests and writes the responses
+func runClientTestCases(t *testing.T, testcases map[string]clientTestCase) {
+	for n, test := range testcases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, test.expectedMethod, r.Method, "Error in test case %v", n)
+			assert.Equal(t, test.expectedPath, r.URL.EscapedPath(), "Error in test case %v", n)
+			assert.Equal(t, test.expectedQuery, r.URL.RawQuery, "Error in test case %v", n)
+			body, err := ioutil.ReadAll(r.Body)
+			assert.NoError(t, err, "Error in test case %v", n)
+			if test.expectedBody != "" {
+				assert.JSONEq(t, test.expectedBody, string(body), "Error in test case %v", n)
+			} else {
+				assert.Empty(t, body, "Error in test case %v", n)
+			}
+			username, password, _ := r.BasicAuth()
+			assert.Equal(t, "admin", username, "Error in test case %v", n)
+			assert.Equal(t, "password", password, "Error in test case %v", n)
+
+			w.Header().Set("X-Request-Id", "RequestID")
+			w.WriteHeader(test.status)
+			w.Write([]byte(test.response))
+		}))
+
+		c := &Client{
+			URL:  server.URL,
+			Auth: BasicAuth{Username: "admin", Password: "password"},
+		}
+		response, err := test.call(c)
+		server.Close()
+
+		if test.wantError != nil {
+			assert.Equal(t, test.wantError, err, "Error in test case %v", n)
+			continue
+		}
+		if assert.NoError(t, err, "Error in test case %v", n) {
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestClient_Auth(t *testing.T) {
+	testcases := map[string]struct {
+		auth                  Authenticator
+		expectedAuthorization string
+		expectedUserHeader    string
+	}{
+		"OkCaseBasicAuth": {
+			auth:                  BasicAuth{Username: "admin", Password: "admin"},
+			expectedAuthorization: "Basic YWRtaW46YWRtaW4=",
+		},
+		"OkCaseBearerToken": {
+			auth:                  BearerToken("token"),
+			expectedAuthorization: "Bearer token",
+		},
+		"OkCaseHeaderAuth": {
+			auth:               HeaderAuth{Name: "X-Remote-User", Value: "user1"},
+			expectedUserHeader: "user1",
+		},
+		"OkCaseNoAuth": {},
+	}
+
+	for n, test := range testcases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, test.expectedAuthorization, r.Header.Get("Authorization"), "Error in test case %v", n)
+			assert.Equal(t, test.expectedUserHeader, r.Header.Get("X-Remote-User"), "Error in test case %v", n)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		c := &Client{URL: server.URL + "/", Auth: test.auth}
+		assert.NoError(t, c.RemoveUser("user1"), "Error in test case %v", n)
+		server.Close()
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	testcases := map[string]clientTestCase{
+		"ErrorCaseApiError": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetUserByExternalID("user1")
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/users/user1",
+			status:         http.StatusNotFound,
+			response:       `{"code": "UserWithExternalIDNotFound", "message": "User with externalId user1 not found"}`,
+			wantError: &Error{
+				Code:       api.USER_BY_EXTERNAL_ID_NOT_FOUND,
+				Message:    "User with externalId user1 not found",
+				StatusCode: http.StatusNotFound,
+				RequestID:  "RequestID",
+			},
+		},
+		"ErrorCaseUnexpectedStatus": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetUserByExternalID("user1")
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/users/user1",
+			status:         http.StatusBadGateway,
+			response:       "Bad gateway",
+			wantError: &Error{
+				Code:       api.UNKNOWN_API_ERROR,
+				Message:    "Unexpected status code 502",
+				StatusCode: http.StatusBadGateway,
+				RequestID:  "RequestID",
+			},
+		},
+		"ErrorCaseAuthenticationFailed": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetUserByExternalID("user1")
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/users/user1",
+			status:         http.StatusUnauthorized,
+			response:       "Authentication failed\n",
+			wantError: &Error{
+				Code:       api.AUTHENTICATION_API_ERROR,
+				Message:    "Authentication failed",
+				StatusCode: http.StatusUnauthorized,
+				RequestID:  "RequestID",
+			},
+		},
+	}
+
+	runClientTestCases(t, testcases)
+}
+
+func TestClient_RequestID(t *testing.T) {
+	testcases := map[string]struct {
+		requestID string
+	}{
+		"OkCaseRequestID": {
+			requestID: "RequestID",
+		},
+		"OkCaseNoRequestID": {},
+	}
+
+	for n, test := range testcases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, test.requestID, r.Header.Get("X-Request-Id"), "Error in test case %v", n)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		c := &Client{URL: server.URL}
+		assert.NoError(t, c.WithRequestID(test.requestID).RemoveUser("user1"), "Error in test case %v", n)
+		assert.Empty(t, c.RequestID, "Error in test case %v", n)
+		server.Close()
+	}
+}
+
+func TestClient_IfMatch(t *testing.T) {
+	updateAt := time.Now().UTC()
+	testcases := map[string]struct {
+		etag string
+	}{
+		"OkCaseIfMatch": {
+			etag: api.EntityTag(updateAt),
+		},
+		"OkCaseNoIfMatch": {},
+	}
+
+	for n, test := range testcases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, test.etag, r.Header.Get("If-Match"), "Error in test case %v", n)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		c := &Client{URL: server.URL}
+		assert.NoError(t, c.WithIfMatch(test.etag).RemoveGroup("example", "group1"), "Error in test case %v", n)
+		assert.Empty(t, c.IfMatch, "Error in test case %v", n)
+		server.Close()
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	testcases := map[string]struct {
+		err error
+		// Expected result
+		expectedCode               string
+		expectedNotFound           bool
+		expectedConflict           bool
+		expectedInvalidParameter   bool
+		expectedUnauthorized       bool
+		expectedPreconditionFailed bool
+	}{
+		"OkCaseNotFound": {
+			err:              &Error{Code: api.GROUP_BY_ORG_AND_NAME_NOT_FOUND},
+			expectedCode:     api.GROUP_BY_ORG_AND_NAME_NOT_FOUND,
+			expectedNotFound: true,
+		},
+		"OkCaseUpstreamPoolNotFound": {
+			err:              &Error{Code: api.UPSTREAM_POOL_BY_ORG_AND_NAME_NOT_FOUND},
+			expectedCode:     api.UPSTREAM_POOL_BY_ORG_AND_NAME_NOT_FOUND,
+			expectedNotFound: true,
+		},
+		"OkCaseUpstreamPoolInUse": {
+			err:              &Error{Code: api.UPSTREAM_POOL_IN_USE},
+			expectedCode:     api.UPSTREAM_POOL_IN_USE,
+			expectedConflict: true,
+		},
+		"OkCasePreconditionFailed": {
+			err:                        &Error{Code: api.PRECONDITION_FAILED},
+			expectedCode:               api.PRECONDITION_FAILED,
+			expectedPreconditionFailed: true,
+		},
+		"OkCaseConflict": {
+			err:              &Error{Code: api.USER_IS_ALREADY_A_MEMBER_OF_GROUP},
+			expectedCode:     api.USER_IS_ALREADY_A_MEMBER_OF_GROUP,
+			expectedConflict: true,
+		},
+		"OkCaseInvalidParameter": {
+			err:                      &Error{Code: api.REGEX_NO_MATCH},
+			expectedCode:             api.REGEX_NO_MATCH,
+			expectedInvalidParameter: true,
+		},
+		"OkCaseUnauthorized": {
+			err:                  &Error{Code: api.UNAUTHORIZED_RESOURCES_ERROR},
+			expectedCode:         api.UNAUTHORIZED_RESOURCES_ERROR,
+			expectedUnauthorized: true,
+		},
+		"OkCaseOtherError": {
+			err: errors.New("connection refused"),
+		},
+	}
+
+	for n, test := range testcases {
+		assert.Equal(t, test.expectedCode, ErrorCode(test.err), "Error in test case %v", n)
+		assert.Equal(t, test.expectedNotFound, IsNotFound(test.err), "Error in test case %v", n)
+		assert.Equal(t, test.expectedConflict, IsConflict(test.err), "Error in test case %v", n)
+		assert.Equal(t, test.expectedInvalidParameter, IsInvalidParameter(test.err), "Error in test case %v", n)
+		assert.Equal(t, test.expectedUnauthorized, IsUnauthorized(test.err), "Error in test case %v", n)
+		assert.Equal(t, test.expectedPreconditionFailed, IsPreconditionFailed(test.err), "Error in test case %v", n)
+	}
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+// Error codes by kind of error
+var (
+	notFoundCodes = map[string]bool{
+		api.USER_BY_EXTERNAL_ID_NOT_FOUND:            true,
+		api.GROUP_BY_ORG_AND_NAME_NOT_FOUND:          true,
+		api.POLICY_BY_ORG_AND_NAME_NOT_FOUND:         true,
+		api.PROXY_RESOURCE_BY_ORG_AND_NAME_NOT_FOUND: true,
+		api.UPSTREAM_POOL_BY_ORG_AND_NAME_NOT_FOUND:  true,
+		api.AUTH_OIDC_PROVIDER_BY_NAME_NOT_FOUND:     true,
+		api.WEBHOOK_BY_NAME_NOT_FOUND:                true,
+		api.USER_IS_NOT_A_MEMBER_OF_GROUP:            true,
+		api.GROUP_IS_NOT_A_CHILD_OF_GROUP:            true,
+		api.POLICY_IS_NOT_ATTACHED_TO_GROUP:          true,
+		api.POLICY_IS_NOT_ATTACHED_TO_USER:           true,
+	}
+	conflictCodes = map[string]bool{
+		api.USER_ALREADY_EXIST:                  true,
+		api.GROUP_ALREADY_EXIST:                 true,
+		api.POLICY_ALREADY_EXIST:                true,
+		api.PROXY_RESOURCE_ALREADY_EXIST:        true,
+		api.AUTH_OIDC_PROVIDER_ALREADY_EXIST:    true,
+		api.WEBHOOK_ALREADY_EXIST:               true,
+		api.USER_IS_ALREADY_A_MEMBER_OF_GROUP:   true,
+		api.GROUP_IS_ALREADY_A_CHILD_OF_GROUP:   true,
+		api.GROUP_HIERARCHY_CYCLE:               true,
+		api.POLICY_IS_ALREADY_ATTACHED_TO_GROUP: true,
+		api.POLICY_IS_ALREADY_ATTACHED_TO_USER:  true,
+		api.PROXY_RESOURCES_ROUTES_CONFLICT:     true,
+		api.UPSTREAM_POOL_ALREADY_EXIST:         true,
+		api.UPSTREAM_POOL_IN_USE:                true,
+	}
+	invalidParameterCodes = map[string]bool{
+		api.INVALID_PARAMETER_ERROR: true,
+		api.REGEX_NO_MATCH:          true,
+	}
+)
+
+// Error is an error response of the worker, with the code and message of its api.Error
+type Error struct {
+	// Code of the error, like api.USER_BY_EXTERNAL_ID_NOT_FOUND
+	Code    string
+	Message string
+	// HTTP status code of the response
+	StatusCode int
+	// ID of the request in the worker logs, from the X-Request-Id header of the response
+	RequestID string
+}
+
+func (e *Error) Error() string {
+	if e.RequestID == "" {
+		return fmt.Sprintf("Code: %v, Message: %v", e.Code, e.Message)
+	}
+	return fmt.Sprintf("Code: %v, Message: %v, Request ID: %v", e.Code, e.Message, e.RequestID)
+}
+
+// ErrorCode returns the code of a worker error, or an empty code for other errors, like connection errors
+func ErrorCode(err error) string {
+	if e, ok := err.(*Error); ok {
+		return e.Code
+	}
+	return ""
+}
+
+// IsNotFound returns true if err is a worker error because a resource or relation doesn't exist
+func IsNotFound(err error) bool {
+	return notFoundCodes[ErrorCode(err)]
+}
+
+// IsConflict returns true if err is a worker error because a resource or relation already exists,
+// or because it conflicts with others
+func IsConflict(err error) bool {
+	return conflictCodes[ErrorCode(err)]
+}
+
+// IsInvalidParameter returns true if err is a worker error because of an invalid parameter
+func IsInvalidParameter(err error) bool {
+	return invalidParameterCodes[ErrorCode(err)]
+}
+
+// IsUnauthorized returns true if err is a worker error because the caller isn't allowed to make the request
+func IsUnauthorized(err error) bool {
+	return ErrorCode(err) == api.UNAUTHORIZED_RESOURCES_ERROR
+}
+
+// IsPreconditionFailed returns true if err is a worker error because the resource was modified after
+// the version sent in If-Match
+func IsPreconditionFailed(err error) bool {
+	return ErrorCode(err) == api.PRECONDITION_FAILED
+}
+
+// IsAuthenticationError returns true if err is a worker error because the caller isn't authenticated
+func IsAuthenticationError(err error) bool {
+	return ErrorCode(err) == api.AUTHENTICATION_API_ERROR
+}
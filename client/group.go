@@ -0,0 +1,148 @@
+package client
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+const organizationsURL = apiVersion1 + "/organizations"
+
+// RESPONSES
+
+type GroupList struct {
+	Groups []string `json:"groups,omitempty"`
+	Page
+}
+
+type GroupIdentityList struct {
+	Groups []api.GroupIdentity `json:"groups,omitempty"`
+	Page
+}
+
+type MemberList struct {
+	Members []api.GroupMembers `json:"members,omitempty"`
+	Page
+}
+
+type GroupPolicyList struct {
+	AttachedPolicies []api.GroupPolicies `json:"policies,omitempty"`
+	Page
+}
+
+type ChildGroupList struct {
+	Groups []api.GroupChildren `json:"groups,omitempty"`
+	Page
+}
+
+// GROUP METHODS
+
+func (c *Client) AddGroup(org string, name string, path string) (*api.Group, error) {
+	body := struct {
+		Name string `json:"name,omitempty"`
+		Path string `json:"path,omitempty"`
+	}{name, path}
+	group := new(api.Group)
+	if err := c.do(http.MethodPost, urlPath(organizationsURL, org, "groups"), nil, body, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (c *Client) GetGroupByName(org string, name string) (*api.Group, error) {
+	group := new(api.Group)
+	if err := c.do(http.MethodGet, urlPath(organizationsURL, org, "groups", name), nil, nil, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (c *Client) ListGroups(org string, opts *ListOptions) (*GroupList, error) {
+	list := new(GroupList)
+	if err := c.do(http.MethodGet, urlPath(organizationsURL, org, "groups"), opts.query(), nil, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ListAllGroups lists the groups of all organizations
+func (c *Client) ListAllGroups(opts *ListOptions) (*GroupIdentityList, error) {
+	list := new(GroupIdentityList)
+	if err := c.do(http.MethodGet, apiVersion1+"/groups", opts.query(), nil, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (c *Client) UpdateGroup(org string, name string, newName string, newPath string) (*api.Group, error) {
+	body := struct {
+		Name string `json:"name,omitempty"`
+		Path string `json:"path,omitempty"`
+	}{newName, newPath}
+	group := new(api.Group)
+	if err := c.do(http.MethodPut, urlPath(organizationsURL, org, "groups", name), nil, body, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (c *Client) RemoveGroup(org string, name string) error {
+	return c.do(http.MethodDelete, urlPath(organizationsURL, org, "groups", name), nil, nil, nil)
+}
+
+// AddMember adds the user to the group, until expiresAt if it isn't nil
+func (c *Client) AddMember(externalID string, name string, org string, expiresAt *time.Time) error {
+	body := struct {
+		ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	}{expiresAt}
+	return c.do(http.MethodPost, urlPath(organizationsURL, org, "groups", name, "users", externalID), nil, body, nil)
+}
+
+func (c *Client) RemoveMember(externalID string, name string, org string) error {
+	return c.do(http.MethodDelete, urlPath(organizationsURL, org, "groups", name, "users", externalID), nil, nil, nil)
+}
+
+func (c *Client) ListMembers(org string, name string, opts *ListOptions) (*MemberList, error) {
+	list := new(MemberList)
+	if err := c.do(http.MethodGet, urlPath(organizationsURL, org, "groups", name, "users"), opts.query(), nil, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// AttachPolicyToGroup attaches the policy of the organization to the group, until expiresAt if it isn't nil
+func (c *Client) AttachPolicyToGroup(org string, name string, policyName string, expiresAt *time.Time) error {
+	body := struct {
+		ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	}{expiresAt}
+	return c.do(http.MethodPost, urlPath(organizationsURL, org, "groups", name, "policies", policyName), nil, body, nil)
+}
+
+func (c *Client) DetachPolicyToGroup(org string, name string, policyName string) error {
+	return c.do(http.MethodDelete, urlPath(organizationsURL, org, "groups", name, "policies", policyName), nil, nil, nil)
+}
+
+func (c *Client) ListAttachedGroupPolicies(org string, name string, opts *ListOptions) (*GroupPolicyList, error) {
+	list := new(GroupPolicyList)
+	if err := c.do(http.MethodGet, urlPath(organizationsURL, org, "groups", name, "policies"), opts.query(), nil, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (c *Client) AddChildGroup(org string, name string, childName string) error {
+	return c.do(http.MethodPost, urlPath(organizationsURL, org, "groups", name, "groups", childName), nil, nil, nil)
+}
+
+func (c *Client) RemoveChildGroup(org string, name string, childName string) error {
+	return c.do(http.MethodDelete, urlPath(organizationsURL, org, "groups", name, "groups", childName), nil, nil, nil)
+}
+
+func (c *Client) ListChildGroups(org string, name string, opts *ListOptions) (*ChildGroupList, error) {
+	list := new(ChildGroupList)
+	if err := c.do(http.MethodGet, urlPath(organizationsURL, org, "groups", name, "groups"), opts.query(), nil, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,200 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+func TestClient_GroupMethods(t *testing.T) {
+	now := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	group := &api.Group{
+		ID:       "GroupID",
+		Name:     "group1",
+		Path:     "/path/",
+		Org:      "org1",
+		Urn:      "urn:iws:iam:org1:group/path/group1",
+		CreateAt: now,
+		UpdateAt: now,
+	}
+	groupJSON := `{"id": "GroupID", "name": "group1", "path": "/path/", "org": "org1", "urn": "urn:iws:iam:org1:group/path/group1",
+		"createAt": "2016-01-01T00:00:00Z", "updateAt": "2016-01-01T00:00:00Z"}`
+	testcases := map[string]clientTestCase{
+		"OkCaseAddGroup": {
+			call: func(c *Client) (interface{}, error) {
+				return c.AddGroup("org1", "group1", "/path/")
+			},
+			expectedMethod:   http.MethodPost,
+			expectedPath:     "/api/v1/organizations/org1/groups",
+			expectedBody:     `{"name": "group1", "path": "/path/"}`,
+			status:           http.StatusCreated,
+			response:         groupJSON,
+			expectedResponse: group,
+		},
+		"OkCaseGetGroupByName": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetGroupByName("org1", "group1")
+			},
+			expectedMethod:   http.MethodGet,
+			expectedPath:     "/api/v1/organizations/org1/groups/group1",
+			status:           http.StatusOK,
+			response:         groupJSON,
+			expectedResponse: group,
+		},
+		"OkCaseListGroups": {
+			call: func(c *Client) (interface{}, error) {
+				return c.ListGroups("org1", &ListOptions{Limit: 1})
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/organizations/org1/groups",
+			expectedQuery:  "Limit=1",
+			status:         http.StatusOK,
+			response:       `{"groups": ["group1"], "offset": 0, "limit": 1, "total": 2}`,
+			expectedResponse: &GroupList{
+				Groups: []string{"group1"},
+				Page:   Page{Limit: 1, Total: 2},
+			},
+		},
+		"OkCaseListAllGroups": {
+			call: func(c *Client) (interface{}, error) {
+				return c.ListAllGroups(nil)
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/groups",
+			status:         http.StatusOK,
+			response:       `{"groups": [{"org": "org1", "name": "group1"}], "offset": 0, "limit": 20, "total": 1}`,
+			expectedResponse: &GroupIdentityList{
+				Groups: []api.GroupIdentity{{Org: "org1", Name: "group1"}},
+				Page:   Page{Limit: 20, Total: 1},
+			},
+		},
+		"OkCaseUpdateGroup": {
+			call: func(c *Client) (interface{}, error) {
+				return c.UpdateGroup("org1", "group0", "group1", "/path/")
+			},
+			expectedMethod:   http.MethodPut,
+			expectedPath:     "/api/v1/organizations/org1/groups/group0",
+			expectedBody:     `{"name": "group1", "path": "/path/"}`,
+			status:           http.StatusOK,
+			response:         groupJSON,
+			expectedResponse: group,
+		},
+		"OkCaseRemoveGroup": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.RemoveGroup("org1", "group1")
+			},
+			expectedMethod: http.MethodDelete,
+			expectedPath:   "/api/v1/organizations/org1/groups/group1",
+			status:         http.StatusNoContent,
+		},
+		"OkCaseAddMember": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.AddMember("user1", "group1", "org1", &now)
+			},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/organizations/org1/groups/group1/users/user1",
+			expectedBody:   `{"expiresAt": "2016-01-01T00:00:00Z"}`,
+			status:         http.StatusNoContent,
+		},
+		"OkCaseRemoveMember": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.RemoveMember("user1", "group1", "org1")
+			},
+			expectedMethod: http.MethodDelete,
+			expectedPath:   "/api/v1/organizations/org1/groups/group1/users/user1",
+			status:         http.StatusNoContent,
+		},
+		"OkCaseListMembers": {
+			call: func(c *Client) (interface{}, error) {
+				return c.ListMembers("org1", "group1", nil)
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/organizations/org1/groups/group1/users",
+			status:         http.StatusOK,
+			response:       `{"members": [{"user": "user1", "joined": "2016-01-01T00:00:00Z"}], "offset": 0, "limit": 20, "total": 1}`,
+			expectedResponse: &MemberList{
+				Members: []api.GroupMembers{{User: "user1", CreateAt: now}},
+				Page:    Page{Limit: 20, Total: 1},
+			},
+		},
+		"OkCaseAttachPolicyToGroup": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.AttachPolicyToGroup("org1", "group1", "policy1", nil)
+			},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/organizations/org1/groups/group1/policies/policy1",
+			expectedBody:   `{}`,
+			status:         http.StatusNoContent,
+		},
+		"OkCaseDetachPolicyToGroup": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.DetachPolicyToGroup("org1", "group1", "policy1")
+			},
+			expectedMethod: http.MethodDelete,
+			expectedPath:   "/api/v1/organizations/org1/groups/group1/policies/policy1",
+			status:         http.StatusNoContent,
+		},
+		"OkCaseListAttachedGroupPolicies": {
+			call: func(c *Client) (interface{}, error) {
+				return c.ListAttachedGroupPolicies("org1", "group1", nil)
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/organizations/org1/groups/group1/policies",
+			status:         http.StatusOK,
+			response:       `{"policies": [{"policy": "policy1", "attached": "2016-01-01T00:00:00Z"}], "offset": 0, "limit": 20, "total": 1}`,
+			expectedResponse: &GroupPolicyList{
+				AttachedPolicies: []api.GroupPolicies{{Policy: "policy1", CreateAt: now}},
+				Page:             Page{Limit: 20, Total: 1},
+			},
+		},
+		"OkCaseAddChildGroup": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.AddChildGroup("org1", "group1", "group2")
+			},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/organizations/org1/groups/group1/groups/group2",
+			status:         http.StatusNoContent,
+		},
+		"OkCaseRemoveChildGroup": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.RemoveChildGroup("org1", "group1", "group2")
+			},
+			expectedMethod: http.MethodDelete,
+			expectedPath:   "/api/v1/organizations/org1/groups/group1/groups/group2",
+			status:         http.StatusNoContent,
+		},
+		"OkCaseListChildGroups": {
+			call: func(c *Client) (interface{}, error) {
+				return c.ListChildGroups("org1", "group1", nil)
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/organizations/org1/groups/group1/groups",
+			status:         http.StatusOK,
+			response:       `{"groups": [{"group": "group2", "joined": "2016-01-01T00:00:00Z"}], "offset": 0, "limit": 20, "total": 1}`,
+			expectedResponse: &ChildGroupList{
+				Groups: []api.GroupChildren{{Group: "group2", CreateAt: now}},
+				Page:   Page{Limit: 20, Total: 1},
+			},
+		},
+		"ErrorCaseAlreadyExist": {
+			call: func(c *Client) (interface{}, error) {
+				return c.AddGroup("org1", "group1", "/path/")
+			},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/organizations/org1/groups",
+			expectedBody:   `{"name": "group1", "path": "/path/"}`,
+			status:         http.StatusConflict,
+			response:       `{"code": "GroupAlreadyExist", "message": "Unable to create group, group with org org1 and name group1 already exist"}`,
+			wantError: &Error{
+				Code:       api.GROUP_ALREADY_EXIST,
+				Message:    "Unable to create group, group with org org1 and name group1 already exist",
+				StatusCode: http.StatusConflict,
+				RequestID:  "RequestID",
+			},
+		},
+	}
+
+	runClientTestCases(t, testcases)
+}
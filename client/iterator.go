@@ -0,0 +1,417 @@
+package client
+
+import (
+	"github.com/Tecsisa/foulkon/api"
+)
+
+// pager walks the items of a list request page by page. The iterators embed it and return the items
+// of the current page.
+type pager struct {
+	opts ListOptions
+	// fetch requests the page of opts, keeps its items in the iterator and returns how many there are
+	fetch func(opts *ListOptions) (int, *Page, error)
+	// Index of the current item in the page, and number of items of the page
+	index int
+	count int
+	// last is true when there aren't more pages
+	last bool
+	err  error
+}
+
+func newPager(opts *ListOptions, fetch func(opts *ListOptions) (int, *Page, error)) *pager {
+	p := &pager{
+		fetch: fetch,
+		index: -1,
+	}
+	if opts != nil {
+		p.opts = *opts
+	}
+	return p
+}
+
+// Next advances to the next item, requesting the next page when the current one is finished. It returns
+// false when there are no more items or a request fails, check Err to know it.
+func (p *pager) Next() bool {
+	if p.err != nil {
+		return false
+	}
+	p.index++
+	for p.index >= p.count {
+		if p.last {
+			return false
+		}
+		count, page, err := p.fetch(&p.opts)
+		if err != nil {
+			p.err = err
+			return false
+		}
+		p.index, p.count = 0, count
+		p.opts.Offset += count
+		p.last = count == 0 || p.opts.Offset >= page.Total
+	}
+	return true
+}
+
+// Err returns the error of the last request, if it failed
+func (p *pager) Err() error {
+	return p.err
+}
+
+// StringIterator iterates the names or IDs of a list, like the external IDs of the users
+type StringIterator struct {
+	*pager
+	items []string
+}
+
+// Value returns the current item
+func (it *StringIterator) Value() string {
+	return it.items[it.index]
+}
+
+// IterateUsers returns an iterator of the external IDs of the users, starting at the offset of opts
+func (c *Client) IterateUsers(opts *ListOptions) *StringIterator {
+	it := new(StringIterator)
+	it.pager = newPager(opts, func(opts *ListOptions) (int, *Page, error) {
+		list, err := c.ListUsers(opts)
+		if err != nil {
+			return 0, nil, err
+		}
+		it.items = list.ExternalIDs
+		return len(it.items), &list.Page, nil
+	})
+	return it
+}
+
+// IterateGroups returns an iterator of the group names of an organization, starting at the offset of opts
+func (c *Client) IterateGroups(org string, opts *ListOptions) *StringIterator {
+	it := new(StringIterator)
+	it.pager = newPager(opts, func(opts *ListOptions) (int, *Page, error) {
+		list, err := c.ListGroups(org, opts)
+		if err != nil {
+			return 0, nil, err
+		}
+		it.items = list.Groups
+		return len(it.items), &list.Page, nil
+	})
+	return it
+}
+
+// IteratePolicies returns an iterator of the policy names of an organization, starting at the offset of opts
+func (c *Client) IteratePolicies(org string, opts *ListOptions) *StringIterator {
+	it := new(StringIterator)
+	it.pager = newPager(opts, func(opts *ListOptions) (int, *Page, error) {
+		list, err := c.ListPolicies(org, opts)
+		if err != nil {
+			return 0, nil, err
+		}
+		it.items = list.Policies
+		return len(it.items), &list.Page, nil
+	})
+	return it
+}
+
+// IterateProxyResources returns an iterator of the proxy resource names of an organization, starting at the offset of opts
+func (c *Client) IterateProxyResources(org string, opts *ListOptions) *StringIterator {
+	it := new(StringIterator)
+	it.pager = newPager(opts, func(opts *ListOptions) (int, *Page, error) {
+		list, err := c.ListProxyResources(org, opts)
+		if err != nil {
+			return 0, nil, err
+		}
+		it.items = list.Resources
+		return len(it.items), &list.Page, nil
+	})
+	return it
+}
+
+// IterateUpstreamPools returns an iterator of the upstream pool names of an organization, starting at the offset of opts
+func (c *Client) IterateUpstreamPools(org string, opts *ListOptions) *StringIterator {
+	it := new(StringIterator)
+	it.pager = newPager(opts, func(opts *ListOptions) (int, *Page, error) {
+		list, err := c.ListUpstreamPools(org, opts)
+		if err != nil {
+			return 0, nil, err
+		}
+		it.items = list.Pools
+		return len(it.items), &list.Page, nil
+	})
+	return it
+}
+
+// IterateOidcProviders returns an iterator of the OIDC provider names, starting at the offset of opts
+func (c *Client) IterateOidcProviders(opts *ListOptions) *StringIterator {
+	it := new(StringIterator)
+	it.pager = newPager(opts, func(opts *ListOptions) (int, *Page, error) {
+		list, err := c.ListOidcProviders(opts)
+		if err != nil {
+			return 0, nil, err
+		}
+		it.items = list.Providers
+		return len(it.items), &list.Page, nil
+	})
+	return it
+}
+
+// IterateWebhooks returns an iterator of the webhook names, starting at the offset of opts
+func (c *Client) IterateWebhooks(opts *ListOptions) *StringIterator {
+	it := new(StringIterator)
+	it.pager = newPager(opts, func(opts *ListOptions) (int, *Page, error) {
+		list, err := c.ListWebhooks(opts)
+		if err != nil {
+			return 0, nil, err
+		}
+		it.items = list.Webhooks
+		return len(it.items), &list.Page, nil
+	})
+	return it
+}
+
+// UserGroupIterator iterates the groups of a user
+type UserGroupIterator struct {
+	*pager
+	items []api.UserGroups
+}
+
+// IterateGroupsByUser returns an iterator of the groups of a user, starting at the offset of opts
+func (c *Client) IterateGroupsByUser(externalID string, opts *ListOptions) *UserGroupIterator {
+	it := new(UserGroupIterator)
+	it.pager = newPager(opts, func(opts *ListOptions) (int, *Page, error) {
+		list, err := c.ListGroupsByUser(externalID, opts)
+		if err != nil {
+			return 0, nil, err
+		}
+		it.items = list.Groups
+		return len(it.items), &list.Page, nil
+	})
+	return it
+}
+
+// Value returns the current item
+func (it *UserGroupIterator) Value() api.UserGroups {
+	return it.items[it.index]
+}
+
+// UserPolicyIterator iterates the policies attached to a user
+type UserPolicyIterator struct {
+	*pager
+	items []api.UserPolicies
+}
+
+// IterateAttachedUserPolicies returns an iterator of the policies attached to a user, starting at the offset of opts
+func (c *Client) IterateAttachedUserPolicies(externalID string, opts *ListOptions) *UserPolicyIterator {
+	it := new(UserPolicyIterator)
+	it.pager = newPager(opts, func(opts *ListOptions) (int, *Page, error) {
+		list, err := c.ListAttachedUserPolicies(externalID, opts)
+		if err != nil {
+			return 0, nil, err
+		}
+		it.items = list.AttachedPolicies
+		return len(it.items), &list.Page, nil
+	})
+	return it
+}
+
+// Value returns the current item
+func (it *UserPolicyIterator) Value() api.UserPolicies {
+	return it.items[it.index]
+}
+
+// GroupIdentityIterator iterates the groups of all organizations
+type GroupIdentityIterator struct {
+	*pager
+	items []api.GroupIdentity
+}
+
+// IterateAllGroups returns an iterator of the groups of all organizations, starting at the offset of opts
+func (c *Client) IterateAllGroups(opts *ListOptions) *GroupIdentityIterator {
+	it := new(GroupIdentityIterator)
+	it.pager = newPager(opts, func(opts *ListOptions) (int, *Page, error) {
+		list, err := c.ListAllGroups(opts)
+		if err != nil {
+			return 0, nil, err
+		}
+		it.items = list.Groups
+		return len(it.items), &list.Page, nil
+	})
+	return it
+}
+
+// Value returns the current item
+func (it *GroupIdentityIterator) Value() api.GroupIdentity {
+	return it.items[it.index]
+}
+
+// MemberIterator iterates the members of a group
+type MemberIterator struct {
+	*pager
+	items []api.GroupMembers
+}
+
+// IterateMembers returns an iterator of the members of a group, starting at the offset of opts
+func (c *Client) IterateMembers(org string, name string, opts *ListOptions) *MemberIterator {
+	it := new(MemberIterator)
+	it.pager = newPager(opts, func(opts *ListOptions) (int, *Page, error) {
+		list, err := c.ListMembers(org, name, opts)
+		if err != nil {
+			return 0, nil, err
+		}
+		it.items = list.Members
+		return len(it.items), &list.Page, nil
+	})
+	return it
+}
+
+// Value returns the current item
+func (it *MemberIterator) Value() api.GroupMembers {
+	return it.items[it.index]
+}
+
+// GroupPolicyIterator iterates the policies attached to a group
+type GroupPolicyIterator struct {
+	*pager
+	items []api.GroupPolicies
+}
+
+// IterateAttachedGroupPolicies returns an iterator of the policies attached to a group, starting at the offset of opts
+func (c *Client) IterateAttachedGroupPolicies(org string, name string, opts *ListOptions) *GroupPolicyIterator {
+	it := new(GroupPolicyIterator)
+	it.pager = newPager(opts, func(opts *ListOptions) (int, *Page, error) {
+		list, err := c.ListAttachedGroupPolicies(org, name, opts)
+		if err != nil {
+			return 0, nil, err
+		}
+		it.items = list.AttachedPolicies
+		return len(it.items), &list.Page, nil
+	})
+	return it
+}
+
+// Value returns the current item
+func (it *GroupPolicyIterator) Value() api.GroupPolicies {
+	return it.items[it.index]
+}
+
+// ChildGroupIterator iterates the child groups of a group
+type ChildGroupIterator struct {
+	*pager
+	items []api.GroupChildren
+}
+
+// IterateChildGroups returns an iterator of the child groups of a group, starting at the offset of opts
+func (c *Client) IterateChildGroups(org string, name string, opts *ListOptions) *ChildGroupIterator {
+	it := new(ChildGroupIterator)
+	it.pager = newPager(opts, func(opts *ListOptions) (int, *Page, error) {
+		list, err := c.ListChildGroups(org, name, opts)
+		if err != nil {
+			return 0, nil, err
+		}
+		it.items = list.Groups
+		return len(it.items), &list.Page, nil
+	})
+	return it
+}
+
+// Value returns the current item
+func (it *ChildGroupIterator) Value() api.GroupChildren {
+	return it.items[it.index]
+}
+
+// PolicyIdentityIterator iterates the policies of all organizations
+type PolicyIdentityIterator struct {
+	*pager
+	items []api.PolicyIdentity
+}
+
+// IterateAllPolicies returns an iterator of the policies of all organizations, starting at the offset of opts
+func (c *Client) IterateAllPolicies(opts *ListOptions) *PolicyIdentityIterator {
+	it := new(PolicyIdentityIterator)
+	it.pager = newPager(opts, func(opts *ListOptions) (int, *Page, error) {
+		list, err := c.ListAllPolicies(opts)
+		if err != nil {
+			return 0, nil, err
+		}
+		it.items = list.Policies
+		return len(it.items), &list.Page, nil
+	})
+	return it
+}
+
+// Value returns the current item
+func (it *PolicyIdentityIterator) Value() api.PolicyIdentity {
+	return it.items[it.index]
+}
+
+// PolicyGroupIterator iterates the groups with a policy attached
+type PolicyGroupIterator struct {
+	*pager
+	items []api.PolicyGroups
+}
+
+// IterateAttachedGroups returns an iterator of the groups with a policy attached, starting at the offset of opts
+func (c *Client) IterateAttachedGroups(org string, name string, opts *ListOptions) *PolicyGroupIterator {
+	it := new(PolicyGroupIterator)
+	it.pager = newPager(opts, func(opts *ListOptions) (int, *Page, error) {
+		list, err := c.ListAttachedGroups(org, name, opts)
+		if err != nil {
+			return 0, nil, err
+		}
+		it.items = list.Groups
+		return len(it.items), &list.Page, nil
+	})
+	return it
+}
+
+// Value returns the current item
+func (it *PolicyGroupIterator) Value() api.PolicyGroups {
+	return it.items[it.index]
+}
+
+// AuditEntryIterator iterates the audit entries selected by the filter
+type AuditEntryIterator struct {
+	*pager
+	items []api.AuditEntry
+}
+
+// IterateAuditEntries returns an iterator of the audit entries selected by the filter, starting at the offset of opts
+func (c *Client) IterateAuditEntries(filter *AuditFilter, opts *ListOptions) *AuditEntryIterator {
+	it := new(AuditEntryIterator)
+	it.pager = newPager(opts, func(opts *ListOptions) (int, *Page, error) {
+		list, err := c.ListAuditEntries(filter, opts)
+		if err != nil {
+			return 0, nil, err
+		}
+		it.items = list.Entries
+		return len(it.items), &list.Page, nil
+	})
+	return it
+}
+
+// Value returns the current item
+func (it *AuditEntryIterator) Value() api.AuditEntry {
+	return it.items[it.index]
+}
+
+// WebhookDeadLetterIterator iterates the dead letters of a webhook
+type WebhookDeadLetterIterator struct {
+	*pager
+	items []api.WebhookDeadLetter
+}
+
+// IterateWebhookDeadLetters returns an iterator of the dead letters of a webhook, starting at the offset of opts
+func (c *Client) IterateWebhookDeadLetters(name string, opts *ListOptions) *WebhookDeadLetterIterator {
+	it := new(WebhookDeadLetterIterator)
+	it.pager = newPager(opts, func(opts *ListOptions) (int, *Page, error) {
+		list, err := c.ListWebhookDeadLetters(name, opts)
+		if err != nil {
+			return 0, nil, err
+		}
+		it.items = list.DeadLetters
+		return len(it.items), &list.Page, nil
+	})
+	return it
+}
+
+// Value returns the current item
+func (it *WebhookDeadLetterIterator) Value() api.WebhookDeadLetter {
+	return it.items[it.index]
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Iterators(t *testing.T) {
+	testcases := map[string]struct {
+		// Users in the worker, and offset of the page that fails
+		users      []string
+		failOffset int
+		opts       *ListOptions
+		// Expected result
+		expectedUsers   []string
+		expectedOffsets []string
+		wantError       error
+	}{
+		"OkCaseSeveralPages": {
+			users:           []string{"user1", "user2", "user3", "user4", "user5"},
+			opts:            &ListOptions{Limit: 2},
+			expectedUsers:   []string{"user1", "user2", "user3", "user4", "user5"},
+			expectedOffsets: []string{"", "2", "4"},
+		},
+		"OkCaseStartingOffset": {
+			users:           []string{"user1", "user2", "user3"},
+			opts:            &ListOptions{Offset: 1, Limit: 5},
+			expectedUsers:   []string{"user2", "user3"},
+			expectedOffsets: []string{"1"},
+		},
+		"OkCaseEmptyList": {
+			expectedOffsets: []string{""},
+		},
+		"ErrorCaseRequestFailed": {
+			users:           []string{"user1", "user2", "user3"},
+			failOffset:      2,
+			opts:            &ListOptions{Limit: 2},
+			expectedUsers:   []string{"user1", "user2"},
+			expectedOffsets: []string{"", "2"},
+			wantError: &Error{
+				Code:       api.UNKNOWN_API_ERROR,
+				Message:    "Unexpected status code 500",
+				StatusCode: http.StatusInternalServerError,
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		offsets := []string{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/v1/users", r.URL.Path, "Error in test case %v", n)
+			offsets = append(offsets, r.URL.Query().Get("Offset"))
+			offset, _ := strconv.Atoi(r.URL.Query().Get("Offset"))
+			limit, _ := strconv.Atoi(r.URL.Query().Get("Limit"))
+			if limit == 0 {
+				limit = 20
+			}
+			if test.failOffset != 0 && offset == test.failOffset {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			end := offset + limit
+			if end > len(test.users) {
+				end = len(test.users)
+			}
+			users := []string{}
+			if offset < end {
+				users = test.users[offset:end]
+			}
+			json.NewEncoder(w).Encode(&UserList{
+				ExternalIDs: users,
+				Page:        Page{Offset: offset, Limit: limit, Total: len(test.users)},
+			})
+		}))
+
+		c := &Client{URL: server.URL}
+		users := []string{}
+		it := c.IterateUsers(test.opts)
+		for it.Next() {
+			users = append(users, it.Value())
+		}
+		server.Close()
+
+		assert.Equal(t, test.wantError, it.Err(), "Error in test case %v", n)
+		if test.expectedUsers == nil {
+			test.expectedUsers = []string{}
+		}
+		assert.Equal(t, test.expectedUsers, users, "Error in test case %v", n)
+		assert.Equal(t, test.expectedOffsets, offsets, "Error in test case %v", n)
+	}
+}
+
+func TestClient_TypedIterator(t *testing.T) {
+	testcases := map[string]clientTestCase{
+		"OkCaseIterateMembers": {
+			call: func(c *Client) (interface{}, error) {
+				members := []string{}
+				it := c.IterateMembers("org1", "group1", nil)
+				for it.Next() {
+					members = append(members, it.Value().User)
+				}
+				return members, it.Err()
+			},
+			expectedMethod:   http.MethodGet,
+			expectedPath:     "/api/v1/organizations/org1/groups/group1/users",
+			status:           http.StatusOK,
+			response:         `{"members": [{"user": "user1"}, {"user": "user2"}], "offset": 0, "limit": 20, "total": 2}`,
+			expectedResponse: []string{"user1", "user2"},
+		},
+	}
+
+	runClientTestCases(t, testcases)
+}
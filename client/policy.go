@@ -0,0 +1,90 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+// RESPONSES
+
+type PolicyList struct {
+	Policies []string `json:"policies,omitempty"`
+	Page
+}
+
+type PolicyIdentityList struct {
+	Policies []api.PolicyIdentity `json:"policies,omitempty"`
+	Page
+}
+
+type PolicyGroupList struct {
+	Groups []api.PolicyGroups `json:"groups,omitempty"`
+	Page
+}
+
+// POLICY METHODS
+
+func (c *Client) AddPolicy(name string, path string, org string, statements []api.Statement) (*api.Policy, error) {
+	body := struct {
+		Name       string          `json:"name,omitempty"`
+		Path       string          `json:"path,omitempty"`
+		Statements []api.Statement `json:"statements,omitempty"`
+	}{name, path, statements}
+	policy := new(api.Policy)
+	if err := c.do(http.MethodPost, urlPath(organizationsURL, org, "policies"), nil, body, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (c *Client) GetPolicyByName(org string, name string) (*api.Policy, error) {
+	policy := new(api.Policy)
+	if err := c.do(http.MethodGet, urlPath(organizationsURL, org, "policies", name), nil, nil, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (c *Client) ListPolicies(org string, opts *ListOptions) (*PolicyList, error) {
+	list := new(PolicyList)
+	if err := c.do(http.MethodGet, urlPath(organizationsURL, org, "policies"), opts.query(), nil, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ListAllPolicies lists the policies of all organizations
+func (c *Client) ListAllPolicies(opts *ListOptions) (*PolicyIdentityList, error) {
+	list := new(PolicyIdentityList)
+	if err := c.do(http.MethodGet, apiVersion1+"/policies", opts.query(), nil, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (c *Client) UpdatePolicy(org string, name string, newName string, newPath string, statements []api.Statement) (*api.Policy, error) {
+	body := struct {
+		Name       string          `json:"name,omitempty"`
+		Path       string          `json:"path,omitempty"`
+		Statements []api.Statement `json:"statements,omitempty"`
+	}{newName, newPath, statements}
+	policy := new(api.Policy)
+	if err := c.do(http.MethodPut, urlPath(organizationsURL, org, "policies", name), nil, body, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (c *Client) RemovePolicy(org string, name string) error {
+	return c.do(http.MethodDelete, urlPath(organizationsURL, org, "policies", name), nil, nil, nil)
+}
+
+// ListAttachedGroups lists the groups with the policy attached
+func (c *Client) ListAttachedGroups(org string, name string, opts *ListOptions) (*PolicyGroupList, error) {
+	list := new(PolicyGroupList)
+	if err := c.do(http.MethodGet, urlPath(organizationsURL, org, "policies", name, "groups"), opts.query(), nil, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
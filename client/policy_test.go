@@ -0,0 +1,119 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+func TestClient_PolicyMethods(t *testing.T) {
+	now := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	statements := []api.Statement{
+		{
+			Effect:    "allow",
+			Actions:   []string{api.USER_ACTION_GET_USER},
+			Resources: []string{"urn:everything:*"},
+		},
+	}
+	policy := &api.Policy{
+		ID:         "PolicyID",
+		Name:       "policy1",
+		Path:       "/path/",
+		Org:        "org1",
+		Urn:        "urn:iws:iam:org1:policy/path/policy1",
+		CreateAt:   now,
+		UpdateAt:   now,
+		Statements: &statements,
+	}
+	policyJSON := `{"id": "PolicyID", "name": "policy1", "path": "/path/", "org": "org1", "urn": "urn:iws:iam:org1:policy/path/policy1",
+		"createAt": "2016-01-01T00:00:00Z", "updateAt": "2016-01-01T00:00:00Z",
+		"statements": [{"effect": "allow", "actions": ["iam:GetUser"], "resources": ["urn:everything:*"]}]}`
+	testcases := map[string]clientTestCase{
+		"OkCaseAddPolicy": {
+			call: func(c *Client) (interface{}, error) {
+				return c.AddPolicy("policy1", "/path/", "org1", statements)
+			},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/organizations/org1/policies",
+			expectedBody: `{"name": "policy1", "path": "/path/",
+				"statements": [{"effect": "allow", "actions": ["iam:GetUser"], "resources": ["urn:everything:*"]}]}`,
+			status:           http.StatusCreated,
+			response:         policyJSON,
+			expectedResponse: policy,
+		},
+		"OkCaseGetPolicyByName": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetPolicyByName("org1", "policy1")
+			},
+			expectedMethod:   http.MethodGet,
+			expectedPath:     "/api/v1/organizations/org1/policies/policy1",
+			status:           http.StatusOK,
+			response:         policyJSON,
+			expectedResponse: policy,
+		},
+		"OkCaseListPolicies": {
+			call: func(c *Client) (interface{}, error) {
+				return c.ListPolicies("org1", &ListOptions{PathPrefix: "/path/"})
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/organizations/org1/policies",
+			expectedQuery:  "PathPrefix=%2Fpath%2F",
+			status:         http.StatusOK,
+			response:       `{"policies": ["policy1"], "offset": 0, "limit": 20, "total": 1}`,
+			expectedResponse: &PolicyList{
+				Policies: []string{"policy1"},
+				Page:     Page{Limit: 20, Total: 1},
+			},
+		},
+		"OkCaseListAllPolicies": {
+			call: func(c *Client) (interface{}, error) {
+				return c.ListAllPolicies(nil)
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/policies",
+			status:         http.StatusOK,
+			response:       `{"policies": [{"org": "org1", "name": "policy1"}], "offset": 0, "limit": 20, "total": 1}`,
+			expectedResponse: &PolicyIdentityList{
+				Policies: []api.PolicyIdentity{{Org: "org1", Name: "policy1"}},
+				Page:     Page{Limit: 20, Total: 1},
+			},
+		},
+		"OkCaseUpdatePolicy": {
+			call: func(c *Client) (interface{}, error) {
+				return c.UpdatePolicy("org1", "policy0", "policy1", "/path/", statements)
+			},
+			expectedMethod: http.MethodPut,
+			expectedPath:   "/api/v1/organizations/org1/policies/policy0",
+			expectedBody: `{"name": "policy1", "path": "/path/",
+				"statements": [{"effect": "allow", "actions": ["iam:GetUser"], "resources": ["urn:everything:*"]}]}`,
+			status:           http.StatusOK,
+			response:         policyJSON,
+			expectedResponse: policy,
+		},
+		"OkCaseRemovePolicy": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.RemovePolicy("org1", "policy1")
+			},
+			expectedMethod: http.MethodDelete,
+			expectedPath:   "/api/v1/organizations/org1/policies/policy1",
+			status:         http.StatusNoContent,
+		},
+		"OkCaseListAttachedGroups": {
+			call: func(c *Client) (interface{}, error) {
+				return c.ListAttachedGroups("org1", "policy1", nil)
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/organizations/org1/policies/policy1/groups",
+			status:         http.StatusOK,
+			response:       `{"groups": [{"group": "group1", "attached": "2016-01-01T00:00:00Z"}], "offset": 0, "limit": 20, "total": 1}`,
+			expectedResponse: &PolicyGroupList{
+				Groups: []api.PolicyGroups{{Group: "group1", CreateAt: now}},
+				Page:   Page{Limit: 20, Total: 1},
+			},
+		},
+	}
+
+	runClientTestCases(t, testcases)
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+// RESPONSES
+
+type ProxyResourceList struct {
+	Resources []string `json:"resources,omitempty"`
+	Page
+}
+
+// PROXY RESOURCE METHODS
+
+func (c *Client) AddProxyResource(name string, org string, path string, resource api.ResourceEntity) (*api.ProxyResource, error) {
+	body := struct {
+		Name     string             `json:"name,omitempty"`
+		Path     string             `json:"path,omitempty"`
+		Resource api.ResourceEntity `json:"resource,omitempty"`
+	}{name, path, resource}
+	proxyResource := new(api.ProxyResource)
+	if err := c.do(http.MethodPost, urlPath(organizationsURL, org, "proxy-resources"), nil, body, proxyResource); err != nil {
+		return nil, err
+	}
+	return proxyResource, nil
+}
+
+func (c *Client) GetProxyResourceByName(org string, name string) (*api.ProxyResource, error) {
+	proxyResource := new(api.ProxyResource)
+	if err := c.do(http.MethodGet, urlPath(organizationsURL, org, "proxy-resources", name), nil, nil, proxyResource); err != nil {
+		return nil, err
+	}
+	return proxyResource, nil
+}
+
+func (c *Client) ListProxyResources(org string, opts *ListOptions) (*ProxyResourceList, error) {
+	list := new(ProxyResourceList)
+	if err := c.do(http.MethodGet, urlPath(organizationsURL, org, "proxy-resources"), opts.query(), nil, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (c *Client) UpdateProxyResource(org string, name string, newName string, newPath string,
+	resource api.ResourceEntity) (*api.ProxyResource, error) {
+	body := struct {
+		Name     string             `json:"name,omitempty"`
+		Path     string             `json:"path,omitempty"`
+		Resource api.ResourceEntity `json:"resource,omitempty"`
+	}{newName, newPath, resource}
+	proxyResource := new(api.ProxyResource)
+	if err := c.do(http.MethodPut, urlPath(organizationsURL, org, "proxy-resources", name), nil, body, proxyResource); err != nil {
+		return nil, err
+	}
+	return proxyResource, nil
+}
+
+func (c *Client) RemoveProxyResource(org string, name string) error {
+	return c.do(http.MethodDelete, urlPath(organizationsURL, org, "proxy-resources", name), nil, nil, nil)
+}
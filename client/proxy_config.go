@@ -0,0 +1,35 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+const (
+	proxyConfigURL = adminRoot + "/proxy-config"
+)
+
+// PROXY CONFIG METHODS
+
+// GetProxyConfig returns the proxy resources and upstream pools if their revision isn't revision. Otherwise the
+// worker waits up to wait for a change, and nil is returned if there wasn't any. The HTTP client must not time out
+// before the wait.
+func (c *Client) GetProxyConfig(revision int64, wait time.Duration) (*api.ProxyConfig, error) {
+	query := url.Values{}
+	query.Set("Revision", strconv.FormatInt(revision, 10))
+	if wait > 0 {
+		query.Set("Wait", strconv.Itoa(int(wait/time.Second)))
+	}
+	config := new(api.ProxyConfig)
+	if err := c.do(http.MethodGet, proxyConfigURL, query, nil, config); err != nil {
+		if clientErr, ok := err.(*Error); ok && clientErr.StatusCode == http.StatusNotModified {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return config, nil
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+func TestClient_ProxyConfigMethods(t *testing.T) {
+	testcases := map[string]clientTestCase{
+		"OkCaseGetProxyConfig": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetProxyConfig(0, 0)
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/admin/proxy-config",
+			expectedQuery:  "Revision=0",
+			status:         http.StatusOK,
+			response: `{"revision": 2, "resources": [{"id": "ID1", "name": "resource1", "org": "org1",
+				"resource": {"host": "http://localhost:8080", "path": "/path", "method": "GET", "urn": "urn", "action": "action"}}],
+				"upstreamPools": []}`,
+			expectedResponse: &api.ProxyConfig{
+				Revision: 2,
+				Resources: []api.ProxyResource{
+					{
+						ID:   "ID1",
+						Name: "resource1",
+						Org:  "org1",
+						Resource: api.ResourceEntity{
+							Host:   "http://localhost:8080",
+							Path:   "/path",
+							Method: "GET",
+							Urn:    "urn",
+							Action: "action",
+						},
+					},
+				},
+				UpstreamPools: []api.UpstreamPool{},
+			},
+		},
+		"OkCaseNotModified": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetProxyConfig(2, 30*time.Second)
+			},
+			expectedMethod:   http.MethodGet,
+			expectedPath:     "/api/v1/admin/proxy-config",
+			expectedQuery:    "Revision=2&Wait=30",
+			status:           http.StatusNotModified,
+			expectedResponse: (*api.ProxyConfig)(nil),
+		},
+		"ErrorCaseNotAdmin": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetProxyConfig(0, 0)
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/admin/proxy-config",
+			expectedQuery:  "Revision=0",
+			status:         http.StatusForbidden,
+			response:       `{"code": "UnauthorizedResourcesError", "message": "Not allowed"}`,
+			wantError: &Error{
+				StatusCode: http.StatusForbidden,
+				Code:       api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message:    "Not allowed",
+				RequestID:  "RequestID",
+			},
+		},
+	}
+
+	runClientTestCases(t, testcases)
+}
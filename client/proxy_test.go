@@ -0,0 +1,108 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+func TestClient_ProxyResourceMethods(t *testing.T) {
+	now := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	resource := api.ResourceEntity{
+		Host:   "https://httpbin.org",
+		Path:   "/get",
+		Method: "GET",
+		Urn:    "urn:ews:example:instance1:resource/get",
+		Action: "example:get",
+	}
+	proxyResource := &api.ProxyResource{
+		ID:       "ProxyResourceID",
+		Name:     "proxy1",
+		Org:      "org1",
+		Path:     "/path/",
+		Urn:      "urn:iws:iam:org1:proxy/path/proxy1",
+		Resource: resource,
+		CreateAt: now,
+		UpdateAt: now,
+	}
+	resourceJSON := `{"host": "https://httpbin.org", "path": "/get", "method": "GET",
+		"urn": "urn:ews:example:instance1:resource/get", "action": "example:get"}`
+	proxyResourceJSON := `{"id": "ProxyResourceID", "name": "proxy1", "org": "org1", "path": "/path/",
+		"urn": "urn:iws:iam:org1:proxy/path/proxy1", "resource": ` + resourceJSON + `,
+		"createAt": "2016-01-01T00:00:00Z", "updateAt": "2016-01-01T00:00:00Z"}`
+	testcases := map[string]clientTestCase{
+		"OkCaseAddProxyResource": {
+			call: func(c *Client) (interface{}, error) {
+				return c.AddProxyResource("proxy1", "org1", "/path/", resource)
+			},
+			expectedMethod:   http.MethodPost,
+			expectedPath:     "/api/v1/organizations/org1/proxy-resources",
+			expectedBody:     `{"name": "proxy1", "path": "/path/", "resource": ` + resourceJSON + `}`,
+			status:           http.StatusCreated,
+			response:         proxyResourceJSON,
+			expectedResponse: proxyResource,
+		},
+		"OkCaseGetProxyResourceByName": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetProxyResourceByName("org1", "proxy1")
+			},
+			expectedMethod:   http.MethodGet,
+			expectedPath:     "/api/v1/organizations/org1/proxy-resources/proxy1",
+			status:           http.StatusOK,
+			response:         proxyResourceJSON,
+			expectedResponse: proxyResource,
+		},
+		"OkCaseListProxyResources": {
+			call: func(c *Client) (interface{}, error) {
+				return c.ListProxyResources("org1", &ListOptions{Offset: 1})
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/organizations/org1/proxy-resources",
+			expectedQuery:  "Offset=1",
+			status:         http.StatusOK,
+			response:       `{"resources": ["proxy1"], "offset": 1, "limit": 20, "total": 2}`,
+			expectedResponse: &ProxyResourceList{
+				Resources: []string{"proxy1"},
+				Page:      Page{Offset: 1, Limit: 20, Total: 2},
+			},
+		},
+		"OkCaseUpdateProxyResource": {
+			call: func(c *Client) (interface{}, error) {
+				return c.UpdateProxyResource("org1", "proxy0", "proxy1", "/path/", resource)
+			},
+			expectedMethod:   http.MethodPut,
+			expectedPath:     "/api/v1/organizations/org1/proxy-resources/proxy0",
+			expectedBody:     `{"name": "proxy1", "path": "/path/", "resource": ` + resourceJSON + `}`,
+			status:           http.StatusOK,
+			response:         proxyResourceJSON,
+			expectedResponse: proxyResource,
+		},
+		"OkCaseRemoveProxyResource": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.RemoveProxyResource("org1", "proxy1")
+			},
+			expectedMethod: http.MethodDelete,
+			expectedPath:   "/api/v1/organizations/org1/proxy-resources/proxy1",
+			status:         http.StatusNoContent,
+		},
+		"ErrorCaseNotFound": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetProxyResourceByName("org1", "proxy1")
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/organizations/org1/proxy-resources/proxy1",
+			status:         http.StatusNotFound,
+			response:       `{"code": "ProxyResourceWithOrgAndNameNotFound", "message": "Proxy resource not found"}`,
+			wantError: &Error{
+				Code:       api.PROXY_RESOURCE_BY_ORG_AND_NAME_NOT_FOUND,
+				Message:    "Proxy resource not found",
+				StatusCode: http.StatusNotFound,
+				RequestID:  "RequestID",
+			},
+		},
+	}
+
+	runClientTestCases(t, testcases)
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+const (
+	exportStateURL = adminRoot + "/export"
+	importStateURL = adminRoot + "/import"
+)
+
+// STATE METHODS
+
+// ExportState returns the IAM state of an organization, or of all of them if org is empty
+func (c *Client) ExportState(org string) (*api.State, error) {
+	query := url.Values{}
+	if org != "" {
+		query.Set("Org", org)
+	}
+	state := new(api.State)
+	if err := c.do(http.MethodGet, exportStateURL, query, nil, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// ImportState imports a state document with one of the api.IMPORT_MODE_* modes, and returns the changes made,
+// or the ones that would be made in a dry run
+func (c *Client) ImportState(state *api.State, mode string, dryRun bool) (*api.ImportResult, error) {
+	query := url.Values{}
+	if mode != "" {
+		query.Set("Mode", mode)
+	}
+	query.Set("DryRun", strconv.FormatBool(dryRun))
+	result := new(api.ImportResult)
+	if err := c.do(http.MethodPost, importStateURL, query, state, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+func TestClient_StateMethods(t *testing.T) {
+	state := &api.State{
+		Version: api.STATE_VERSION,
+		Org:     "org1",
+		Groups: []api.StateGroup{
+			{Org: "org1", Name: "group1", Path: "/", Members: []api.StateMember{{ExternalID: "user1"}}},
+		},
+	}
+	testcases := map[string]clientTestCase{
+		"OkCaseExportState": {
+			call: func(c *Client) (interface{}, error) {
+				return c.ExportState("org1")
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/admin/export",
+			expectedQuery:  "Org=org1",
+			status:         http.StatusOK,
+			response: `{"version": 1, "org": "org1", "users": null, "policies": null, "proxyResources": null, "upstreamPools": null,
+				"oidcProviders": null, "groups": [{"org": "org1", "name": "group1", "path": "/", "members": [{"externalId": "user1"}]}]}`,
+			expectedResponse: state,
+		},
+		"OkCaseImportStateDryRun": {
+			call: func(c *Client) (interface{}, error) {
+				return c.ImportState(state, api.IMPORT_MODE_UPSERT, true)
+			},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/admin/import",
+			expectedQuery:  "DryRun=true&Mode=upsert",
+			expectedBody: `{"version": 1, "org": "org1", "users": null, "policies": null, "proxyResources": null, "upstreamPools": null,
+				"oidcProviders": null, "groups": [{"org": "org1", "name": "group1", "path": "/", "members": [{"externalId": "user1"}]}]}`,
+			status: http.StatusOK,
+			response: `{"mode": "upsert", "dryRun": true, "changes": [{"action": "iam:CreateGroup", "urn": "urn:iws:iam:org1:group/group1"},
+				{"action": "iam:AddMember", "urn": "urn:iws:iam:org1:group/group1", "related": "urn:iws:iam::user/user1"}]}`,
+			expectedResponse: &api.ImportResult{
+				Mode:   api.IMPORT_MODE_UPSERT,
+				DryRun: true,
+				Changes: []api.StateChange{
+					{Action: "iam:CreateGroup", Urn: "urn:iws:iam:org1:group/group1"},
+					{Action: "iam:AddMember", Urn: "urn:iws:iam:org1:group/group1", Related: "urn:iws:iam::user/user1"},
+				},
+			},
+		},
+	}
+
+	runClientTestCases(t, testcases)
+}
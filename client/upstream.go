@@ -0,0 +1,63 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+// RESPONSES
+
+type UpstreamPoolList struct {
+	Pools []string `json:"pools,omitempty"`
+	Page
+}
+
+// UPSTREAM POOL METHODS
+
+func (c *Client) AddUpstreamPool(name string, org string, path string, config api.UpstreamPoolConfig) (*api.UpstreamPool, error) {
+	body := struct {
+		Name   string                 `json:"name,omitempty"`
+		Path   string                 `json:"path,omitempty"`
+		Config api.UpstreamPoolConfig `json:"config,omitempty"`
+	}{name, path, config}
+	pool := new(api.UpstreamPool)
+	if err := c.do(http.MethodPost, urlPath(organizationsURL, org, "upstream-pools"), nil, body, pool); err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+func (c *Client) GetUpstreamPoolByName(org string, name string) (*api.UpstreamPool, error) {
+	pool := new(api.UpstreamPool)
+	if err := c.do(http.MethodGet, urlPath(organizationsURL, org, "upstream-pools", name), nil, nil, pool); err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+func (c *Client) ListUpstreamPools(org string, opts *ListOptions) (*UpstreamPoolList, error) {
+	list := new(UpstreamPoolList)
+	if err := c.do(http.MethodGet, urlPath(organizationsURL, org, "upstream-pools"), opts.query(), nil, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (c *Client) UpdateUpstreamPool(org string, name string, newName string, newPath string,
+	config api.UpstreamPoolConfig) (*api.UpstreamPool, error) {
+	body := struct {
+		Name   string                 `json:"name,omitempty"`
+		Path   string                 `json:"path,omitempty"`
+		Config api.UpstreamPoolConfig `json:"config,omitempty"`
+	}{newName, newPath, config}
+	pool := new(api.UpstreamPool)
+	if err := c.do(http.MethodPut, urlPath(organizationsURL, org, "upstream-pools", name), nil, body, pool); err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+func (c *Client) RemoveUpstreamPool(org string, name string) error {
+	return c.do(http.MethodDelete, urlPath(organizationsURL, org, "upstream-pools", name), nil, nil, nil)
+}
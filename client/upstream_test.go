@@ -0,0 +1,106 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+func TestClient_UpstreamPoolMethods(t *testing.T) {
+	now := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	config := api.UpstreamPoolConfig{
+		Targets:     []string{"http://10.0.0.1:8080", "http://10.0.0.2:8080"},
+		Balancer:    api.UPSTREAM_BALANCER_LEAST_CONNECTIONS,
+		HealthCheck: &api.UpstreamHealthCheck{Path: "/health"},
+	}
+	pool := &api.UpstreamPool{
+		ID:       "UpstreamPoolID",
+		Name:     "pool1",
+		Org:      "org1",
+		Path:     "/path/",
+		Urn:      "urn:iws:iam:org1:upstream/path/pool1",
+		Config:   config,
+		CreateAt: now,
+		UpdateAt: now,
+	}
+	configJSON := `{"targets": ["http://10.0.0.1:8080", "http://10.0.0.2:8080"], "balancer": "least-connections",
+		"healthCheck": {"path": "/health"}, "ejection": {}, "transport": {}}`
+	poolJSON := `{"id": "UpstreamPoolID", "name": "pool1", "org": "org1", "path": "/path/",
+		"urn": "urn:iws:iam:org1:upstream/path/pool1", "config": ` + configJSON + `,
+		"createAt": "2016-01-01T00:00:00Z", "updateAt": "2016-01-01T00:00:00Z"}`
+	testcases := map[string]clientTestCase{
+		"OkCaseAddUpstreamPool": {
+			call: func(c *Client) (interface{}, error) {
+				return c.AddUpstreamPool("pool1", "org1", "/path/", config)
+			},
+			expectedMethod:   http.MethodPost,
+			expectedPath:     "/api/v1/organizations/org1/upstream-pools",
+			expectedBody:     `{"name": "pool1", "path": "/path/", "config": ` + configJSON + `}`,
+			status:           http.StatusCreated,
+			response:         poolJSON,
+			expectedResponse: pool,
+		},
+		"OkCaseGetUpstreamPoolByName": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetUpstreamPoolByName("org1", "pool1")
+			},
+			expectedMethod:   http.MethodGet,
+			expectedPath:     "/api/v1/organizations/org1/upstream-pools/pool1",
+			status:           http.StatusOK,
+			response:         poolJSON,
+			expectedResponse: pool,
+		},
+		"OkCaseListUpstreamPools": {
+			call: func(c *Client) (interface{}, error) {
+				return c.ListUpstreamPools("org1", &ListOptions{Offset: 1})
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/organizations/org1/upstream-pools",
+			expectedQuery:  "Offset=1",
+			status:         http.StatusOK,
+			response:       `{"pools": ["pool1"], "offset": 1, "limit": 20, "total": 2}`,
+			expectedResponse: &UpstreamPoolList{
+				Pools: []string{"pool1"},
+				Page:  Page{Offset: 1, Limit: 20, Total: 2},
+			},
+		},
+		"OkCaseUpdateUpstreamPool": {
+			call: func(c *Client) (interface{}, error) {
+				return c.UpdateUpstreamPool("org1", "pool0", "pool1", "/path/", config)
+			},
+			expectedMethod:   http.MethodPut,
+			expectedPath:     "/api/v1/organizations/org1/upstream-pools/pool0",
+			expectedBody:     `{"name": "pool1", "path": "/path/", "config": ` + configJSON + `}`,
+			status:           http.StatusOK,
+			response:         poolJSON,
+			expectedResponse: pool,
+		},
+		"OkCaseRemoveUpstreamPool": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.RemoveUpstreamPool("org1", "pool1")
+			},
+			expectedMethod: http.MethodDelete,
+			expectedPath:   "/api/v1/organizations/org1/upstream-pools/pool1",
+			status:         http.StatusNoContent,
+		},
+		"ErrorCaseInUse": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.RemoveUpstreamPool("org1", "pool1")
+			},
+			expectedMethod: http.MethodDelete,
+			expectedPath:   "/api/v1/organizations/org1/upstream-pools/pool1",
+			status:         http.StatusConflict,
+			response:       `{"code": "UpstreamPoolInUse", "message": "Upstream pool in use"}`,
+			wantError: &Error{
+				Code:       api.UPSTREAM_POOL_IN_USE,
+				Message:    "Upstream pool in use",
+				StatusCode: http.StatusConflict,
+				RequestID:  "RequestID",
+			},
+		},
+	}
+
+	runClientTestCases(t, testcases)
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+const usersURL = apiVersion1 + "/users"
+
+// RESPONSES
+
+type UserList struct {
+	ExternalIDs []string `json:"users,omitempty"`
+	Page
+}
+
+type UserGroupList struct {
+	Groups []api.UserGroups `json:"groups,omitempty"`
+	Page
+}
+
+type UserPolicyList struct {
+	AttachedPolicies []api.UserPolicies `json:"policies,omitempty"`
+	Page
+}
+
+// USER METHODS
+
+func (c *Client) AddUser(externalID string, path string) (*api.User, error) {
+	body := struct {
+		ExternalID string `json:"externalId,omitempty"`
+		Path       string `json:"path,omitempty"`
+	}{externalID, path}
+	user := new(api.User)
+	if err := c.do(http.MethodPost, usersURL, nil, body, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (c *Client) GetUserByExternalID(externalID string) (*api.User, error) {
+	user := new(api.User)
+	if err := c.do(http.MethodGet, urlPath(usersURL, externalID), nil, nil, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (c *Client) ListUsers(opts *ListOptions) (*UserList, error) {
+	list := new(UserList)
+	if err := c.do(http.MethodGet, usersURL, opts.query(), nil, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (c *Client) UpdateUser(externalID string, newPath string) (*api.User, error) {
+	body := struct {
+		Path string `json:"path,omitempty"`
+	}{newPath}
+	user := new(api.User)
+	if err := c.do(http.MethodPut, urlPath(usersURL, externalID), nil, body, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (c *Client) RemoveUser(externalID string) error {
+	return c.do(http.MethodDelete, urlPath(usersURL, externalID), nil, nil, nil)
+}
+
+func (c *Client) ListGroupsByUser(externalID string, opts *ListOptions) (*UserGroupList, error) {
+	list := new(UserGroupList)
+	if err := c.do(http.MethodGet, urlPath(usersURL, externalID, "groups"), opts.query(), nil, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (c *Client) ListAttachedUserPolicies(externalID string, opts *ListOptions) (*UserPolicyList, error) {
+	list := new(UserPolicyList)
+	if err := c.do(http.MethodGet, urlPath(usersURL, externalID, "policies"), opts.query(), nil, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (c *Client) AttachPolicyToUser(externalID string, org string, policyName string) error {
+	return c.do(http.MethodPost, urlPath(usersURL, externalID, "policies", org, policyName), nil, nil, nil)
+}
+
+func (c *Client) DetachPolicyFromUser(externalID string, org string, policyName string) error {
+	return c.do(http.MethodDelete, urlPath(usersURL, externalID, "policies", org, policyName), nil, nil, nil)
+}
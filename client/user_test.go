@@ -0,0 +1,123 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+func TestClient_UserMethods(t *testing.T) {
+	now := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	user := &api.User{
+		ID:         "UserID",
+		ExternalID: "user 1",
+		Path:       "/path/",
+		Urn:        "urn:iws:iam::user/path/user 1",
+		CreateAt:   now,
+		UpdateAt:   now,
+	}
+	userJSON := `{"id": "UserID", "externalId": "user 1", "path": "/path/", "urn": "urn:iws:iam::user/path/user 1",
+		"createAt": "2016-01-01T00:00:00Z", "updateAt": "2016-01-01T00:00:00Z"}`
+	testcases := map[string]clientTestCase{
+		"OkCaseAddUser": {
+			call: func(c *Client) (interface{}, error) {
+				return c.AddUser("user 1", "/path/")
+			},
+			expectedMethod:   http.MethodPost,
+			expectedPath:     "/api/v1/users",
+			expectedBody:     `{"externalId": "user 1", "path": "/path/"}`,
+			status:           http.StatusCreated,
+			response:         userJSON,
+			expectedResponse: user,
+		},
+		"OkCaseGetUserByExternalID": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetUserByExternalID("user 1")
+			},
+			expectedMethod:   http.MethodGet,
+			expectedPath:     "/api/v1/users/user%201",
+			status:           http.StatusOK,
+			response:         userJSON,
+			expectedResponse: user,
+		},
+		"OkCaseListUsers": {
+			call: func(c *Client) (interface{}, error) {
+				return c.ListUsers(&ListOptions{PathPrefix: "/path/", Offset: 1, Limit: 2, OrderBy: "path-desc"})
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/users",
+			expectedQuery:  "Limit=2&Offset=1&OrderBy=path-desc&PathPrefix=%2Fpath%2F",
+			status:         http.StatusOK,
+			response:       `{"users": ["user 1", "user2"], "offset": 1, "limit": 2, "total": 5}`,
+			expectedResponse: &UserList{
+				ExternalIDs: []string{"user 1", "user2"},
+				Page:        Page{Offset: 1, Limit: 2, Total: 5},
+			},
+		},
+		"OkCaseUpdateUser": {
+			call: func(c *Client) (interface{}, error) {
+				return c.UpdateUser("user 1", "/path/")
+			},
+			expectedMethod:   http.MethodPut,
+			expectedPath:     "/api/v1/users/user%201",
+			expectedBody:     `{"path": "/path/"}`,
+			status:           http.StatusOK,
+			response:         userJSON,
+			expectedResponse: user,
+		},
+		"OkCaseRemoveUser": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.RemoveUser("user 1")
+			},
+			expectedMethod: http.MethodDelete,
+			expectedPath:   "/api/v1/users/user%201",
+			status:         http.StatusNoContent,
+		},
+		"OkCaseListGroupsByUser": {
+			call: func(c *Client) (interface{}, error) {
+				return c.ListGroupsByUser("user 1", nil)
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/users/user%201/groups",
+			status:         http.StatusOK,
+			response:       `{"groups": [{"org": "org1", "name": "group1", "joined": "2016-01-01T00:00:00Z"}], "offset": 0, "limit": 20, "total": 1}`,
+			expectedResponse: &UserGroupList{
+				Groups: []api.UserGroups{{Org: "org1", Name: "group1", CreateAt: now}},
+				Page:   Page{Limit: 20, Total: 1},
+			},
+		},
+		"OkCaseListAttachedUserPolicies": {
+			call: func(c *Client) (interface{}, error) {
+				return c.ListAttachedUserPolicies("user 1", nil)
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/users/user%201/policies",
+			status:         http.StatusOK,
+			response:       `{"policies": [{"org": "org1", "policy": "policy1", "attached": "2016-01-01T00:00:00Z"}], "offset": 0, "limit": 20, "total": 1}`,
+			expectedResponse: &UserPolicyList{
+				AttachedPolicies: []api.UserPolicies{{Org: "org1", Policy: "policy1", CreateAt: now}},
+				Page:             Page{Limit: 20, Total: 1},
+			},
+		},
+		"OkCaseAttachPolicyToUser": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.AttachPolicyToUser("user 1", "org1", "policy1")
+			},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/users/user%201/policies/org1/policy1",
+			status:         http.StatusNoContent,
+		},
+		"OkCaseDetachPolicyFromUser": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.DetachPolicyFromUser("user 1", "org1", "policy1")
+			},
+			expectedMethod: http.MethodDelete,
+			expectedPath:   "/api/v1/users/user%201/policies/org1/policy1",
+			status:         http.StatusNoContent,
+		},
+	}
+
+	runClientTestCases(t, testcases)
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+const webhooksURL = adminRoot + "/webhooks"
+
+// RESPONSES
+
+type WebhookList struct {
+	Webhooks []string `json:"webhooks,omitempty"`
+	Page
+}
+
+type WebhookDeadLetterList struct {
+	DeadLetters []api.WebhookDeadLetter `json:"deadLetters,omitempty"`
+	Page
+}
+
+// WEBHOOK METHODS
+
+func (c *Client) AddWebhook(name string, path string, url string, secret string, events []string) (*api.Webhook, error) {
+	body := struct {
+		Name   string   `json:"name,omitempty"`
+		Path   string   `json:"path,omitempty"`
+		URL    string   `json:"url,omitempty"`
+		Secret string   `json:"secret,omitempty"`
+		Events []string `json:"events,omitempty"`
+	}{name, path, url, secret, events}
+	webhook := new(api.Webhook)
+	if err := c.do(http.MethodPost, webhooksURL, nil, body, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (c *Client) GetWebhookByName(name string) (*api.Webhook, error) {
+	webhook := new(api.Webhook)
+	if err := c.do(http.MethodGet, urlPath(webhooksURL, name), nil, nil, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (c *Client) ListWebhooks(opts *ListOptions) (*WebhookList, error) {
+	list := new(WebhookList)
+	if err := c.do(http.MethodGet, webhooksURL, opts.query(), nil, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (c *Client) UpdateWebhook(name string, newName string, newPath string, newURL string, newSecret string,
+	newEvents []string) (*api.Webhook, error) {
+	body := struct {
+		Name   string   `json:"name,omitempty"`
+		Path   string   `json:"path,omitempty"`
+		URL    string   `json:"url,omitempty"`
+		Secret string   `json:"secret,omitempty"`
+		Events []string `json:"events,omitempty"`
+	}{newName, newPath, newURL, newSecret, newEvents}
+	webhook := new(api.Webhook)
+	if err := c.do(http.MethodPut, urlPath(webhooksURL, name), nil, body, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (c *Client) RemoveWebhook(name string) error {
+	return c.do(http.MethodDelete, urlPath(webhooksURL, name), nil, nil, nil)
+}
+
+func (c *Client) ListWebhookDeadLetters(name string, opts *ListOptions) (*WebhookDeadLetterList, error) {
+	list := new(WebhookDeadLetterList)
+	if err := c.do(http.MethodGet, urlPath(webhooksURL, name, "dead-letters"), opts.query(), nil, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+func TestClient_WebhookMethods(t *testing.T) {
+	now := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	webhook := &api.Webhook{
+		ID:       "WebhookID",
+		Name:     "webhook1",
+		Path:     "/path/",
+		Urn:      "urn:iws:iam::webhook/path/webhook1",
+		CreateAt: now,
+		UpdateAt: now,
+		URL:      "https://example.com/events",
+		Events:   []string{"iam:CreateUser"},
+	}
+	webhookJSON := `{"id": "WebhookID", "name": "webhook1", "path": "/path/", "urn": "urn:iws:iam::webhook/path/webhook1",
+		"createAt": "2016-01-01T00:00:00Z", "updateAt": "2016-01-01T00:00:00Z",
+		"url": "https://example.com/events", "events": ["iam:CreateUser"]}`
+	testcases := map[string]clientTestCase{
+		"OkCaseAddWebhook": {
+			call: func(c *Client) (interface{}, error) {
+				return c.AddWebhook("webhook1", "/path/", "https://example.com/events", "secret", []string{"iam:CreateUser"})
+			},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/admin/webhooks",
+			expectedBody: `{"name": "webhook1", "path": "/path/", "url": "https://example.com/events", "secret": "secret",
+				"events": ["iam:CreateUser"]}`,
+			status:           http.StatusCreated,
+			response:         webhookJSON,
+			expectedResponse: webhook,
+		},
+		"OkCaseGetWebhookByName": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetWebhookByName("webhook1")
+			},
+			expectedMethod:   http.MethodGet,
+			expectedPath:     "/api/v1/admin/webhooks/webhook1",
+			status:           http.StatusOK,
+			response:         webhookJSON,
+			expectedResponse: webhook,
+		},
+		"OkCaseListWebhooks": {
+			call: func(c *Client) (interface{}, error) {
+				return c.ListWebhooks(&ListOptions{PathPrefix: "/path/"})
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/admin/webhooks",
+			expectedQuery:  "PathPrefix=%2Fpath%2F",
+			status:         http.StatusOK,
+			response:       `{"webhooks": ["webhook1"], "offset": 0, "limit": 20, "total": 1}`,
+			expectedResponse: &WebhookList{
+				Webhooks: []string{"webhook1"},
+				Page:     Page{Limit: 20, Total: 1},
+			},
+		},
+		"OkCaseUpdateWebhook": {
+			call: func(c *Client) (interface{}, error) {
+				return c.UpdateWebhook("webhook0", "webhook1", "/path/", "https://example.com/events", "", []string{"iam:CreateUser"})
+			},
+			expectedMethod:   http.MethodPut,
+			expectedPath:     "/api/v1/admin/webhooks/webhook0",
+			expectedBody:     `{"name": "webhook1", "path": "/path/", "url": "https://example.com/events", "events": ["iam:CreateUser"]}`,
+			status:           http.StatusOK,
+			response:         webhookJSON,
+			expectedResponse: webhook,
+		},
+		"OkCaseRemoveWebhook": {
+			call: func(c *Client) (interface{}, error) {
+				return nil, c.RemoveWebhook("webhook1")
+			},
+			expectedMethod: http.MethodDelete,
+			expectedPath:   "/api/v1/admin/webhooks/webhook1",
+			status:         http.StatusNoContent,
+		},
+		"OkCaseListWebhookDeadLetters": {
+			call: func(c *Client) (interface{}, error) {
+				return c.ListWebhookDeadLetters("webhook1", nil)
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/admin/webhooks/webhook1/dead-letters",
+			status:         http.StatusOK,
+			response: `{"deadLetters": [{"id": "DeadLetterID", "event": {"id": "EventID", "type": "iam:CreateUser",
+				"urn": "urn:iws:iam::user/user1", "createAt": "2016-01-01T00:00:00Z"}, "attempts": 5,
+				"lastError": "Unexpected status code 500", "createAt": "2016-01-01T00:00:00Z"}],
+				"offset": 0, "limit": 20, "total": 1}`,
+			expectedResponse: &WebhookDeadLetterList{
+				DeadLetters: []api.WebhookDeadLetter{
+					{
+						ID: "DeadLetterID",
+						Event: api.WebhookEvent{
+							ID:       "EventID",
+							Type:     "iam:CreateUser",
+							Urn:      "urn:iws:iam::user/user1",
+							CreateAt: now,
+						},
+						Attempts:  5,
+						LastError: "Unexpected status code 500",
+						CreateAt:  now,
+					},
+				},
+				Page: Page{Limit: 20, Total: 1},
+			},
+		},
+		"ErrorCaseWebhookNotFound": {
+			call: func(c *Client) (interface{}, error) {
+				return c.GetWebhookByName("webhook1")
+			},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/admin/webhooks/webhook1",
+			status:         http.StatusNotFound,
+			response:       `{"code": "WebhookWithNameNotFound", "message": "Webhook with name webhook1 not found"}`,
+			wantError: &Error{
+				Code:       api.WEBHOOK_BY_NAME_NOT_FOUND,
+				Message:    "Webhook with name webhook1 not found",
+				StatusCode: http.StatusNotFound,
+				RequestID:  "RequestID",
+			},
+		},
+	}
+
+	runClientTestCases(t, testcases)
+}
@@ -0,0 +1,490 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/client"
+	"gopkg.in/yaml.v2"
+)
+
+// applyState makes the worker reach the IAM state declared in path, a state document or a directory
+// with them. The changes planned are written to out, and they are made unless it is a dry run.
+// Entities are created and updated, and only removed with prune. Users and OIDC providers
+// aren't removed unless they are declared in the documents. The current state is read and the
+// changes are made with the API of each resource, so the caller needs the permissions of each
+// change instead of admin credentials.
+func applyState(c *client.Client, path string, prune bool, dryRun bool, out io.Writer) error {
+	desired, err := readStateDocuments(path)
+	if err != nil {
+		return err
+	}
+
+	// Keep the users and OIDC providers not declared, with the relations to the policies declared
+	current, err := readCurrentState(c, desired.Org)
+	if err != nil {
+		return err
+	}
+	mergeUndeclaredState(desired, current)
+
+	mode := api.IMPORT_MODE_UPSERT
+	if prune {
+		mode = api.IMPORT_MODE_REPLACE
+	}
+	if err := api.ValidateState(*desired, mode); err != nil {
+		return err
+	}
+	plan, err := api.PlanImport(*current, *desired, mode)
+	if err != nil {
+		return err
+	}
+	if len(plan) == 0 {
+		fmt.Fprintln(out, "No changes, the IAM state is up to date")
+		return nil
+	}
+	changes := []api.StateChange{}
+	for _, change := range plan {
+		changes = append(changes, change.StateChange)
+	}
+	fmt.Fprintf(out, "Plan: %v changes\n", len(changes))
+	printChanges(out, changes)
+	if dryRun {
+		return nil
+	}
+
+	for i, change := range plan {
+		if err := applyChange(c, change); err != nil {
+			fmt.Fprintf(out, "Applied %v of %v changes\n", i, len(plan))
+			return err
+		}
+	}
+	fmt.Fprintf(out, "Applied %v changes\n", len(plan))
+	return nil
+}
+
+// readCurrentState reads the IAM state of the worker like the state export, only with the resources of
+// the org and the policies of the org attached to users if it isn't empty
+func readCurrentState(c *client.Client, org string) (*api.State, error) {
+	state := &api.State{
+		Version: api.STATE_VERSION,
+		Org:     org,
+	}
+
+	// Policies
+	policies := []api.PolicyIdentity{}
+	if len(org) > 0 {
+		it := c.IteratePolicies(org, nil)
+		for it.Next() {
+			policies = append(policies, api.PolicyIdentity{Org: org, Name: it.Value()})
+		}
+		if err := it.Err(); err != nil {
+			return nil, err
+		}
+	} else {
+		it := c.IterateAllPolicies(nil)
+		for it.Next() {
+			policies = append(policies, it.Value())
+		}
+		if err := it.Err(); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range policies {
+		p, err := c.GetPolicyByName(id.Org, id.Name)
+		if err != nil {
+			return nil, err
+		}
+		statements := []api.Statement{}
+		if p.Statements != nil {
+			statements = *p.Statements
+		}
+		state.Policies = append(state.Policies, api.StatePolicy{
+			Org:        p.Org,
+			Name:       p.Name,
+			Path:       p.Path,
+			Statements: statements,
+		})
+	}
+
+	// Groups with their relations
+	groups := []api.GroupIdentity{}
+	if len(org) > 0 {
+		it := c.IterateGroups(org, nil)
+		for it.Next() {
+			groups = append(groups, api.GroupIdentity{Org: org, Name: it.Value()})
+		}
+		if err := it.Err(); err != nil {
+			return nil, err
+		}
+	} else {
+		it := c.IterateAllGroups(nil)
+		for it.Next() {
+			groups = append(groups, it.Value())
+		}
+		if err := it.Err(); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range groups {
+		g, err := c.GetGroupByName(id.Org, id.Name)
+		if err != nil {
+			return nil, err
+		}
+		group := api.StateGroup{
+			Org:  g.Org,
+			Name: g.Name,
+			Path: g.Path,
+		}
+		members := c.IterateMembers(g.Org, g.Name, nil)
+		for members.Next() {
+			group.Members = append(group.Members, api.StateMember{
+				ExternalID: members.Value().User,
+				ExpiresAt:  members.Value().ExpiresAt,
+			})
+		}
+		if err := members.Err(); err != nil {
+			return nil, err
+		}
+		groupPolicies := c.IterateAttachedGroupPolicies(g.Org, g.Name, nil)
+		for groupPolicies.Next() {
+			group.Policies = append(group.Policies, api.StateGroupPolicy{
+				Name:      groupPolicies.Value().Policy,
+				ExpiresAt: groupPolicies.Value().ExpiresAt,
+			})
+		}
+		if err := groupPolicies.Err(); err != nil {
+			return nil, err
+		}
+		children := c.IterateChildGroups(g.Org, g.Name, nil)
+		for children.Next() {
+			group.Groups = append(group.Groups, children.Value().Group)
+		}
+		if err := children.Err(); err != nil {
+			return nil, err
+		}
+		state.Groups = append(state.Groups, group)
+	}
+
+	// Users with their attached policies, only the org ones if org is set
+	users := c.IterateUsers(nil)
+	for users.Next() {
+		u, err := c.GetUserByExternalID(users.Value())
+		if err != nil {
+			return nil, err
+		}
+		user := api.StateUser{
+			ExternalID: u.ExternalID,
+			Path:       u.Path,
+		}
+		userPolicies := c.IterateAttachedUserPolicies(u.ExternalID, nil)
+		for userPolicies.Next() {
+			if len(org) > 0 && userPolicies.Value().Org != org {
+				continue
+			}
+			user.Policies = append(user.Policies, api.StatePolicyRef{
+				Org:  userPolicies.Value().Org,
+				Name: userPolicies.Value().Policy,
+			})
+		}
+		if err := userPolicies.Err(); err != nil {
+			return nil, err
+		}
+		state.Users = append(state.Users, user)
+	}
+	if err := users.Err(); err != nil {
+		return nil, err
+	}
+
+	// Proxy resources and upstream pools, the proxy config has the ones of all the organizations
+	if len(org) > 0 {
+		resources := c.IterateProxyResources(org, nil)
+		for resources.Next() {
+			pr, err := c.GetProxyResourceByName(org, resources.Value())
+			if err != nil {
+				return nil, err
+			}
+			state.ProxyResources = append(state.ProxyResources, stateProxyResource(*pr))
+		}
+		if err := resources.Err(); err != nil {
+			return nil, err
+		}
+		pools := c.IterateUpstreamPools(org, nil)
+		for pools.Next() {
+			p, err := c.GetUpstreamPoolByName(org, pools.Value())
+			if err != nil {
+				return nil, err
+			}
+			state.UpstreamPools = append(state.UpstreamPools, stateUpstreamPool(*p))
+		}
+		if err := pools.Err(); err != nil {
+			return nil, err
+		}
+	} else {
+		// There isn't any config if it still has the initial revision
+		config, err := c.GetProxyConfig(0, 0)
+		if err != nil {
+			return nil, err
+		}
+		if config != nil {
+			for _, pr := range config.Resources {
+				state.ProxyResources = append(state.ProxyResources, stateProxyResource(pr))
+			}
+			for _, p := range config.UpstreamPools {
+				state.UpstreamPools = append(state.UpstreamPools, stateUpstreamPool(p))
+			}
+		}
+	}
+
+	// OIDC providers don't belong to any org
+	if len(org) == 0 {
+		providers := c.IterateOidcProviders(nil)
+		for providers.Next() {
+			op, err := c.GetOidcProviderByName(providers.Value())
+			if err != nil {
+				return nil, err
+			}
+			clients := []string{}
+			for _, oidcClient := range op.OidcClients {
+				clients = append(clients, oidcClient.Name)
+			}
+			state.OidcProviders = append(state.OidcProviders, api.StateOidcProvider{
+				Name:      op.Name,
+				Path:      op.Path,
+				IssuerURL: op.IssuerURL,
+				Clients:   clients,
+			})
+		}
+		if err := providers.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return state, nil
+}
+
+func stateProxyResource(pr api.ProxyResource) api.StateProxyResource {
+	return api.StateProxyResource{
+		Org:      pr.Org,
+		Name:     pr.Name,
+		Path:     pr.Path,
+		Resource: pr.Resource,
+	}
+}
+
+func stateUpstreamPool(p api.UpstreamPool) api.StateUpstreamPool {
+	return api.StateUpstreamPool{
+		Org:    p.Org,
+		Name:   p.Name,
+		Path:   p.Path,
+		Config: p.Config,
+	}
+}
+
+// applyChange makes a planned change with the API call of its action
+func applyChange(c *client.Client, change api.PlannedChange) error {
+	var err error
+	switch change.Action {
+	case api.POLICY_ACTION_CREATE_POLICY:
+		p := change.Policy
+		_, err = c.AddPolicy(p.Name, p.Path, p.Org, p.Statements)
+	case api.POLICY_ACTION_UPDATE_POLICY:
+		p := change.Policy
+		_, err = c.UpdatePolicy(p.Org, p.Name, p.Name, p.Path, p.Statements)
+	case api.POLICY_ACTION_DELETE_POLICY:
+		err = c.RemovePolicy(change.Policy.Org, change.Policy.Name)
+	case api.USER_ACTION_CREATE_USER:
+		_, err = c.AddUser(change.User.ExternalID, change.User.Path)
+	case api.USER_ACTION_UPDATE_USER:
+		_, err = c.UpdateUser(change.User.ExternalID, change.User.Path)
+	case api.USER_ACTION_DELETE_USER:
+		err = c.RemoveUser(change.User.ExternalID)
+	case api.USER_ACTION_ATTACH_USER_POLICY:
+		err = c.AttachPolicyToUser(change.User.ExternalID, change.UserPolicy.Org, change.UserPolicy.Name)
+	case api.USER_ACTION_DETACH_USER_POLICY:
+		err = c.DetachPolicyFromUser(change.User.ExternalID, change.UserPolicy.Org, change.UserPolicy.Name)
+	case api.GROUP_ACTION_CREATE_GROUP:
+		g := change.Group
+		_, err = c.AddGroup(g.Org, g.Name, g.Path)
+	case api.GROUP_ACTION_UPDATE_GROUP:
+		g := change.Group
+		_, err = c.UpdateGroup(g.Org, g.Name, g.Name, g.Path)
+	case api.GROUP_ACTION_DELETE_GROUP:
+		err = c.RemoveGroup(change.Group.Org, change.Group.Name)
+	case api.GROUP_ACTION_ADD_MEMBER:
+		g := change.Group
+		err = c.AddMember(change.Member.ExternalID, g.Name, g.Org, change.Member.ExpiresAt)
+	case api.GROUP_ACTION_REMOVE_MEMBER:
+		g := change.Group
+		err = c.RemoveMember(change.Member.ExternalID, g.Name, g.Org)
+	case api.GROUP_ACTION_ATTACH_GROUP_POLICY:
+		g := change.Group
+		err = c.AttachPolicyToGroup(g.Org, g.Name, change.GroupPolicy.Name, change.GroupPolicy.ExpiresAt)
+	case api.GROUP_ACTION_DETACH_GROUP_POLICY:
+		g := change.Group
+		err = c.DetachPolicyToGroup(g.Org, g.Name, change.GroupPolicy.Name)
+	case api.GROUP_ACTION_ADD_CHILD_GROUP:
+		err = c.AddChildGroup(change.Group.Org, change.Group.Name, change.ChildGroup)
+	case api.GROUP_ACTION_REMOVE_CHILD_GROUP:
+		err = c.RemoveChildGroup(change.Group.Org, change.Group.Name, change.ChildGroup)
+	case api.UPSTREAM_ACTION_CREATE_POOL:
+		p := change.UpstreamPool
+		_, err = c.AddUpstreamPool(p.Name, p.Org, p.Path, p.Config)
+	case api.UPSTREAM_ACTION_UPDATE_POOL:
+		p := change.UpstreamPool
+		_, err = c.UpdateUpstreamPool(p.Org, p.Name, p.Name, p.Path, p.Config)
+	case api.UPSTREAM_ACTION_DELETE_POOL:
+		err = c.RemoveUpstreamPool(change.UpstreamPool.Org, change.UpstreamPool.Name)
+	case api.PROXY_ACTION_CREATE_RESOURCE:
+		pr := change.ProxyResource
+		_, err = c.AddProxyResource(pr.Name, pr.Org, pr.Path, pr.Resource)
+	case api.PROXY_ACTION_UPDATE_RESOURCE:
+		pr := change.ProxyResource
+		_, err = c.UpdateProxyResource(pr.Org, pr.Name, pr.Name, pr.Path, pr.Resource)
+	case api.PROXY_ACTION_DELETE_RESOURCE:
+		err = c.RemoveProxyResource(change.ProxyResource.Org, change.ProxyResource.Name)
+	case api.AUTH_OIDC_ACTION_CREATE_PROVIDER:
+		op := change.OidcProvider
+		_, err = c.AddOidcProvider(op.Name, op.Path, op.IssuerURL, op.Clients)
+	case api.AUTH_OIDC_ACTION_UPDATE_PROVIDER:
+		op := change.OidcProvider
+		_, err = c.UpdateOidcProvider(op.Name, op.Name, op.Path, op.IssuerURL, op.Clients)
+	case api.AUTH_OIDC_ACTION_DELETE_PROVIDER:
+		err = c.RemoveOidcProvider(change.OidcProvider.Name)
+	}
+	return err
+}
+
+// readStateDocuments reads the state document of path, or the ones in it with extension .json, .yaml
+// or .yml if it is a directory, in lexical order. Documents are merged in one, so they must have
+// the same organization.
+func readStateDocuments(path string) (*api.State, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		files = []string{}
+		for _, pattern := range []string{"*.json", "*.yaml", "*.yml"} {
+			matches, err := filepath.Glob(filepath.Join(path, pattern))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, matches...)
+		}
+		sort.Strings(files)
+		if len(files) == 0 {
+			return nil, fmt.Errorf("There are no state documents in directory %v", path)
+		}
+	}
+
+	state := &api.State{
+		Version: api.STATE_VERSION,
+	}
+	for i, file := range files {
+		doc, err := readStateDocument(file)
+		if err != nil {
+			return nil, err
+		}
+		if doc.Version != 0 && doc.Version != api.STATE_VERSION {
+			return nil, fmt.Errorf("Unexpected version %v in state document %v", doc.Version, file)
+		}
+		if i == 0 {
+			state.Org = doc.Org
+		} else if doc.Org != state.Org {
+			return nil, fmt.Errorf("State document %v has org %v, but %v was expected", file, doc.Org, state.Org)
+		}
+		state.Users = append(state.Users, doc.Users...)
+		state.Groups = append(state.Groups, doc.Groups...)
+		state.Policies = append(state.Policies, doc.Policies...)
+		state.ProxyResources = append(state.ProxyResources, doc.ProxyResources...)
+		state.UpstreamPools = append(state.UpstreamPools, doc.UpstreamPools...)
+		state.OidcProviders = append(state.OidcProviders, doc.OidcProviders...)
+	}
+	return state, nil
+}
+
+// readStateDocument decodes a state document with JSON if it has .json extension or YAML otherwise,
+// failing with unknown fields
+func readStateDocument(file string) (*api.State, error) {
+	body, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		// JSON documents are converted to YAML, so they are decoded with the same strict decoder
+		var value interface{}
+		if err := json.Unmarshal(body, &value); err != nil {
+			return nil, fmt.Errorf("Invalid state document %v: %v", file, err)
+		}
+		if body, err = yaml.Marshal(value); err != nil {
+			return nil, fmt.Errorf("Invalid state document %v: %v", file, err)
+		}
+	}
+	doc := new(api.State)
+	if err := yaml.UnmarshalStrict(body, doc); err != nil {
+		return nil, fmt.Errorf("Invalid state document %v: %v", file, err)
+	}
+	return doc, nil
+}
+
+// mergeUndeclaredState adds to desired the users of current not declared in it, with the policies
+// attached that are declared, and the OIDC providers of current if it doesn't declare any
+func mergeUndeclaredState(desired *api.State, current *api.State) {
+	declaredUsers := make(map[string]bool)
+	for _, u := range desired.Users {
+		declaredUsers[u.ExternalID] = true
+	}
+	declaredPolicies := make(map[string]bool)
+	for _, p := range desired.Policies {
+		declaredPolicies[p.Org+"/"+p.Name] = true
+	}
+
+	for _, u := range current.Users {
+		if declaredUsers[u.ExternalID] {
+			continue
+		}
+		var policies []api.StatePolicyRef
+		for _, p := range u.Policies {
+			if declaredPolicies[p.Org+"/"+p.Name] {
+				policies = append(policies, p)
+			}
+		}
+		desired.Users = append(desired.Users, api.StateUser{
+			ExternalID: u.ExternalID,
+			Path:       u.Path,
+			Policies:   policies,
+		})
+	}
+
+	// OIDC providers don't belong to any organization
+	if len(desired.Org) == 0 && len(desired.OidcProviders) == 0 {
+		desired.OidcProviders = current.OidcProviders
+	}
+}
+
+// printChanges writes a line for each change, prefixed with + if it adds something, - if it removes it or ~ if it updates it
+func printChanges(out io.Writer, changes []api.StateChange) {
+	for _, change := range changes {
+		action := change.Action[strings.Index(change.Action, ":")+1:]
+		sign := "-"
+		switch {
+		case strings.HasPrefix(action, "Create"), strings.HasPrefix(action, "Add"), strings.HasPrefix(action, "Attach"):
+			sign = "+"
+		case strings.HasPrefix(action, "Update"):
+			sign = "~"
+		}
+		if len(change.Related) > 0 {
+			fmt.Fprintf(out, "  %v %v %v %v\n", sign, change.Action, change.Urn, change.Related)
+		} else {
+			fmt.Fprintf(out, "  %v %v %v\n", sign, change.Action, change.Urn)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/client"
+	"github.com/Tecsisa/foulkon/foulkon"
+	internalhttp "github.com/Tecsisa/foulkon/http"
+	"github.com/pelletier/go-toml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadStateDocuments(t *testing.T) {
+	testcases := map[string]struct {
+		// Files in directory
+		files map[string]string
+		// Expected result
+		expectedState *api.State
+		wantError     bool
+	}{
+		"OkCase": {
+			files: map[string]string{
+				"groups.yaml": "org: example\ngroups:\n- org: example\n  name: group1\n  path: /\n  members:\n  - externalId: user1\n",
+				"policies.json": `{"org": "example", "policies": [{"org": "example", "name": "policy1", "path": "/",
+				"statements": [{"effect": "allow", "actions": ["iam:*"], "resources": ["urn:everything:*"]}]}]}`,
+				"pools.yaml": "org: example\nupstreamPools:\n- org: example\n  name: pool1\n  path: /\n  config:\n    targets:\n    - http://localhost:8080\n",
+				"README.md":  "Not a state document",
+			},
+			expectedState: &api.State{
+				Version: api.STATE_VERSION,
+				Org:     "example",
+				Groups: []api.StateGroup{
+					{Org: "example", Name: "group1", Path: "/", Members: []api.StateMember{{ExternalID: "user1"}}},
+				},
+				Policies: []api.StatePolicy{
+					{
+						Org:  "example",
+						Name: "policy1",
+						Path: "/",
+						Statements: []api.Statement{
+							{Effect: "allow", Actions: []string{"iam:*"}, Resources: []string{"urn:everything:*"}},
+						},
+					},
+				},
+				UpstreamPools: []api.StateUpstreamPool{
+					{
+						Org:    "example",
+						Name:   "pool1",
+						Path:   "/",
+						Config: api.UpstreamPoolConfig{Targets: []string{"http://localhost:8080"}},
+					},
+				},
+			},
+		},
+		"ErrorCaseDifferentOrgs": {
+			files: map[string]string{
+				"a.yaml": "org: example\n",
+				"b.yaml": "org: other\n",
+			},
+			wantError: true,
+		},
+		"ErrorCaseUnknownField": {
+			files: map[string]string{
+				"a.yaml": "groups:\n- org: example\n  name: group1\n  pth: /\n",
+			},
+			wantError: true,
+		},
+		"ErrorCaseUnknownJSONField": {
+			files: map[string]string{
+				"a.json": `{"groups": [{"org": "example", "name": "group1", "pth": "/"}]}`,
+			},
+			wantError: true,
+		},
+		"ErrorCaseInvalidVersion": {
+			files: map[string]string{
+				"a.json": `{"version": 2}`,
+			},
+			wantError: true,
+		},
+		"ErrorCaseNoDocuments": {
+			files:     map[string]string{},
+			wantError: true,
+		},
+	}
+
+	for n, test := range testcases {
+		dir, err := ioutil.TempDir("", "foulkon-apply")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, content := range test.files {
+			if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		state, err := readStateDocuments(dir)
+		os.RemoveAll(dir)
+		if test.wantError {
+			assert.Error(t, err, "Error in test case %v", n)
+			continue
+		}
+		if !assert.NoError(t, err, "Error in test case %v", n) {
+			continue
+		}
+		assert.Equal(t, test.expectedState, state, "Error in test case %v", n)
+	}
+}
+
+func TestMergeUndeclaredState(t *testing.T) {
+	desired := &api.State{
+		Users: []api.StateUser{
+			{ExternalID: "user1", Path: "/new/"},
+		},
+		Policies: []api.StatePolicy{
+			{Org: "example", Name: "policy1", Path: "/"},
+		},
+	}
+	current := &api.State{
+		Users: []api.StateUser{
+			{ExternalID: "user1", Path: "/", Policies: []api.StatePolicyRef{{Org: "example", Name: "policy1"}}},
+			{ExternalID: "user2", Path: "/", Policies: []api.StatePolicyRef{{Org: "example", Name: "policy1"}, {Org: "example", Name: "policy2"}}},
+		},
+		OidcProviders: []api.StateOidcProvider{
+			{Name: "provider1", Path: "/", IssuerURL: "https://issuer.com"},
+		},
+	}
+
+	mergeUndeclaredState(desired, current)
+	assert.Equal(t, []api.StateUser{
+		{ExternalID: "user1", Path: "/new/"},
+		{ExternalID: "user2", Path: "/", Policies: []api.StatePolicyRef{{Org: "example", Name: "policy1"}}},
+	}, desired.Users)
+	assert.Equal(t, current.OidcProviders, desired.OidcProviders)
+}
+
+func TestApplyState(t *testing.T) {
+	config, err := toml.Load(`
+[server]
+host = "localhost"
+port = "8000"
+[logger]
+level = "error"
+[database]
+type = "memory"
+[authenticator]
+type = "header"
+[authenticator.header]
+name = "X-User"
+[admin]
+username = "admin"
+password = "password"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worker, err := foulkon.NewWorker(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(internalhttp.WorkerHandlerRouter(worker))
+	defer server.Close()
+	admin := &client.Client{
+		URL:  server.URL,
+		Auth: client.BasicAuth{Username: "admin", Password: "password"},
+	}
+
+	dir, err := ioutil.TempDir("", "foulkon-apply")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "state.yaml")
+	writeState := func(groups string) {
+		if err := ioutil.WriteFile(file, []byte("org: example\n"+
+			"users:\n- externalId: user1\n  path: /\n  policies:\n  - org: example\n    name: policy1\n"+
+			"policies:\n- org: example\n  name: policy1\n  path: /\n  statements:\n"+
+			"  - effect: allow\n    actions: [\"iam:*\"]\n    resources: [\"*\"]\n"+
+			"upstreamPools:\n- org: example\n  name: pool1\n  path: /\n  config:\n    targets:\n    - http://localhost:8080\n"+
+			"groups:\n"+groups), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Admin user creates the declared entities
+	writeState("- org: example\n  name: group1\n  path: /\n  members:\n  - externalId: user1\n")
+	out := new(bytes.Buffer)
+	assert.NoError(t, applyState(admin, file, false, false, out))
+	assert.Equal(t, "Plan: 6 changes\n"+
+		"  + iam:CreatePolicy urn:iws:iam:example:policy/policy1\n"+
+		"  + iam:CreateUser urn:iws:iam::user/user1\n"+
+		"  + iam:CreateGroup urn:iws:iam:example:group/group1\n"+
+		"  + iam:CreateUpstreamPool urn:iws:iam:example:upstream/pool1\n"+
+		"  + iam:AttachUserPolicy urn:iws:iam::user/user1 urn:iws:iam:example:policy/policy1\n"+
+		"  + iam:AddMember urn:iws:iam:example:group/group1 urn:iws:iam::user/user1\n"+
+		"Applied 6 changes\n", out.String())
+	state, err := admin.ExportState("example")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []api.StateGroup{
+			{Org: "example", Name: "group1", Path: "/", Members: []api.StateMember{{ExternalID: "user1"}}},
+		}, state.Groups)
+		assert.Equal(t, []api.StateUser{
+			{ExternalID: "user1", Path: "/", Policies: []api.StatePolicyRef{{Org: "example", Name: "policy1"}}},
+		}, state.Users)
+	}
+
+	// Nothing changes applying it again
+	out.Reset()
+	assert.NoError(t, applyState(admin, file, false, false, out))
+	assert.Equal(t, "No changes, the IAM state is up to date\n", out.String())
+
+	// Undeclared entities are only removed with prune, and not in a dry run
+	_, err = admin.AddGroup("example", "group2", "/")
+	assert.NoError(t, err)
+	out.Reset()
+	assert.NoError(t, applyState(admin, file, false, false, out))
+	assert.Equal(t, "No changes, the IAM state is up to date\n", out.String())
+	out.Reset()
+	assert.NoError(t, applyState(admin, file, true, true, out))
+	assert.Equal(t, "Plan: 1 changes\n  - iam:DeleteGroup urn:iws:iam:example:group/group2\n", out.String())
+	_, err = admin.GetGroupByName("example", "group2")
+	assert.NoError(t, err)
+	out.Reset()
+	assert.NoError(t, applyState(admin, file, true, false, out))
+	assert.Equal(t, "Plan: 1 changes\n  - iam:DeleteGroup urn:iws:iam:example:group/group2\nApplied 1 changes\n", out.String())
+	_, err = admin.GetGroupByName("example", "group2")
+	assert.True(t, client.IsNotFound(err))
+
+	// Users without admin credentials read the state and apply changes with their permissions
+	writeState("- org: example\n  name: group1\n  path: /\n  members:\n  - externalId: user1\n" +
+		"- org: example\n  name: group3\n  path: /\n")
+	_, err = admin.AddUser("user2", "/")
+	assert.NoError(t, err)
+	out.Reset()
+	err = applyState(&client.Client{URL: server.URL, Auth: client.HeaderAuth{Name: "X-User", Value: "user2"}}, file, false, false, out)
+	if assert.IsType(t, &client.Error{}, err) {
+		assert.Equal(t, api.UNAUTHORIZED_RESOURCES_ERROR, err.(*client.Error).Code)
+	}
+	assert.Equal(t, "", out.String())
+	out.Reset()
+	assert.NoError(t, applyState(&client.Client{URL: server.URL, Auth: client.HeaderAuth{Name: "X-User", Value: "user1"}}, file, false, false, out))
+	assert.Equal(t, "Plan: 1 changes\n  + iam:CreateGroup urn:iws:iam:example:group/group3\nApplied 1 changes\n", out.String())
+	_, err = admin.GetGroupByName("example", "group3")
+	assert.NoError(t, err)
+
+	// Documents without org are compared with the entities of all organizations
+	body, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(file, bytes.TrimPrefix(body, []byte("org: example\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	assert.NoError(t, applyState(admin, file, true, true, out))
+	assert.Equal(t, "No changes, the IAM state is up to date\n", out.String())
+}
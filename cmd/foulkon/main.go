@@ -0,0 +1,121 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Tecsisa/foulkon/client"
+	"github.com/Tecsisa/foulkon/foulkon"
+	"github.com/pelletier/go-toml"
+)
+
+const usage = `Usage: foulkon <command> [options]
+
+Commands:
+  migrate -config-file=<worker config file> up|down|status
+        Apply pending schema migrations, revert the last applied one or show their status
+  apply -config-file=<worker config file> -f=<file or directory> [-url=<worker url>] [-prune] [-dry-run]
+  apply -url=<worker url> -token=<OIDC token> -f=<file or directory> [-prune] [-dry-run]
+        Make a running worker reach the IAM state declared in state documents, printing the changes planned
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(1)
+	}
+
+	switch os.Args[1] {
+	case "migrate":
+		os.Exit(migrate(os.Args[2:]))
+	case "apply":
+		os.Exit(apply(os.Args[2:]))
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(1)
+	}
+}
+
+func migrate(args []string) int {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	configFile := fs.String("config-file", "", "Config file for worker")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprint(os.Stderr, usage)
+		return 1
+	}
+
+	// Access to file
+	config, err := toml.LoadFile(*configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot read configuration file %v, error: %v\n", *configFile, err)
+		return 1
+	}
+
+	if err := foulkon.MigrateSchema(config, fs.Arg(0), os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func apply(args []string) int {
+	fs := flag.NewFlagSet("apply", flag.ExitOnError)
+	configFile := fs.String("config-file", "", "Config file for worker, to call it with the admin user")
+	path := fs.String("f", "", "State document or directory with state documents")
+	workerURL := fs.String("url", "", "Worker URL, by default the server address of the config file")
+	token := fs.String("token", "", "OIDC token to call the worker with, instead of the admin user of the config file")
+	prune := fs.Bool("prune", false, "Remove the entities and relations not declared")
+	dryRun := fs.Bool("dry-run", false, "Print the changes planned without making them")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	if fs.NArg() != 0 || *path == "" || (*configFile == "" && (*workerURL == "" || *token == "")) {
+		fmt.Fprint(os.Stderr, usage)
+		return 1
+	}
+
+	url := *workerURL
+	var auth client.Authenticator = client.BearerToken(*token)
+	if *configFile != "" {
+		// Access to file
+		config, err := toml.LoadFile(*configFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot read configuration file %v, error: %v\n", *configFile, err)
+			return 1
+		}
+		serverURL, adminUser, adminPassword, err := foulkon.WorkerAPIConfig(config)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+		if url == "" {
+			url = serverURL
+		}
+		if *token == "" {
+			auth = client.BasicAuth{Username: adminUser, Password: adminPassword}
+		}
+	}
+
+	c := &client.Client{
+		URL:        url,
+		Auth:       auth,
+		HTTPClient: &http.Client{Timeout: 5 * time.Minute},
+	}
+	if err := applyState(c, *path, *prune, *dryRun, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	return 0
+}
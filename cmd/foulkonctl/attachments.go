@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+)
+
+// Attachments are the policies attached to users and groups, with the kind of identity as first argument
+var attachmentCommands = map[string]command{
+	"list": {
+		args:        "user <externalId> | group <org> <group> [-offset=<n>] [-limit=<n>] [-order-by=<column>]",
+		description: "List the policies attached to a user or group",
+		run:         listAttachments,
+	},
+	"attach": {
+		args:        "user <externalId> <org> <policy> | group <org> <group> <policy> [-expires-at=<RFC 3339 date>]",
+		description: "Attach a policy to a user or group, the expiration is only allowed for groups",
+		run:         attachPolicy,
+	},
+	"detach": {
+		args:        "user <externalId> <org> <policy> | group <org> <group> <policy>",
+		description: "Detach a policy from a user or group",
+		run:         detachPolicy,
+	},
+}
+
+func listAttachments(c *ctl, fs *flag.FlagSet, args []string) error {
+	opts := listFlags(fs)
+	args, err := parseArgs(fs, args, 2, 3)
+	if err != nil {
+		return err
+	}
+	switch {
+	case args[0] == "user" && len(args) == 2:
+		policies, err := c.client.ListAttachedUserPolicies(args[1], opts)
+		if err != nil {
+			return err
+		}
+		rows := [][]string{}
+		for _, policy := range policies.AttachedPolicies {
+			rows = append(rows, []string{policy.Org, policy.Policy, formatTime(&policy.CreateAt), "-"})
+		}
+		return c.out.printList(policies, policies.Page, []string{"ORG", "POLICY", "ATTACHED", "EXPIRES"}, rows)
+	case args[0] == "group" && len(args) == 3:
+		policies, err := c.client.ListAttachedGroupPolicies(args[1], args[2], opts)
+		if err != nil {
+			return err
+		}
+		rows := [][]string{}
+		for _, policy := range policies.AttachedPolicies {
+			rows = append(rows, []string{args[1], policy.Policy, formatTime(&policy.CreateAt), formatTime(policy.ExpiresAt)})
+		}
+		return c.out.printList(policies, policies.Page, []string{"ORG", "POLICY", "ATTACHED", "EXPIRES"}, rows)
+	default:
+		return errUsage
+	}
+}
+
+func attachPolicy(c *ctl, fs *flag.FlagSet, args []string) error {
+	expiration := fs.String("expires-at", "", "Date when the attachment to the group expires, in RFC 3339 format")
+	args, err := parseArgs(fs, args, 4, 4)
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "user":
+		if *expiration != "" {
+			return errUsage
+		}
+		if err := c.client.AttachPolicyToUser(args[1], args[2], args[3]); err != nil {
+			return err
+		}
+		c.out.message("Policy %v attached to user %v", args[3], args[1])
+	case "group":
+		expiresAt, err := parseExpiration(*expiration)
+		if err != nil {
+			return err
+		}
+		if err := c.client.AttachPolicyToGroup(args[1], args[2], args[3], expiresAt); err != nil {
+			return err
+		}
+		c.out.message("Policy %v attached to group %v", args[3], args[2])
+	default:
+		return errUsage
+	}
+	return nil
+}
+
+func detachPolicy(c *ctl, fs *flag.FlagSet, args []string) error {
+	args, err := parseArgs(fs, args, 4, 4)
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "user":
+		if err := c.client.DetachPolicyFromUser(args[1], args[2], args[3]); err != nil {
+			return err
+		}
+		c.out.message("Policy %v detached from user %v", args[3], args[1])
+	case "group":
+		if err := c.client.DetachPolicyToGroup(args[1], args[2], args[3]); err != nil {
+			return err
+		}
+		c.out.message("Policy %v detached from group %v", args[3], args[2])
+	default:
+		return errUsage
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/Tecsisa/foulkon/client"
+)
+
+// authorizeCommands has the command without subcommands, stored with an empty name
+var authorizeCommands = map[string]command{
+	"": {
+		args:        "-action=<action> <urn>...",
+		description: "Check which resources the authenticated user is allowed to access with an action",
+		run:         authorize,
+	},
+}
+
+// authorization is the result of the authorize command
+type authorization struct {
+	ResourcesAllowed []string `json:"resourcesAllowed"`
+}
+
+func authorize(c *ctl, fs *flag.FlagSet, args []string) error {
+	action := fs.String("action", "", "Action checked")
+	args, err := parseArgs(fs, args, 1, -1)
+	if err != nil {
+		return err
+	}
+	if *action == "" {
+		return errUsage
+	}
+	// The worker returns an error when no resource is allowed
+	allowed, err := c.client.GetAuthorizedExternalResources(*action, args)
+	if client.IsUnauthorized(err) {
+		allowed, err = []string{}, nil
+	}
+	if err != nil {
+		return err
+	}
+	allowedSet := make(map[string]bool)
+	for _, urn := range allowed {
+		allowedSet[urn] = true
+	}
+	rows := [][]string{}
+	for _, urn := range args {
+		result := "denied"
+		if allowedSet[urn] {
+			result = "allowed"
+		}
+		rows = append(rows, []string{urn, result})
+	}
+	return c.out.print(authorization{ResourcesAllowed: allowed}, []string{"RESOURCE", "RESULT"}, rows)
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+var groupCommands = map[string]command{
+	"list": {
+		args:        "[-org=<org>] [-path-prefix=<prefix>] [-offset=<n>] [-limit=<n>] [-order-by=<column>]",
+		description: "List the groups of an organization, or of all organizations without -org",
+		run:         listGroups,
+	},
+	"get": {
+		args:        "<org> <name>",
+		description: "Show a group",
+		run:         getGroup,
+	},
+	"create": {
+		args:        "<org> <name> [-path=<path>]",
+		description: "Create a group",
+		run:         createGroup,
+	},
+	"update": {
+		args:        "<org> <name> [-name=<new name>] [-path=<new path>]",
+		description: "Update the name or path of a group",
+		run:         updateGroup,
+	},
+	"delete": {
+		args:        "<org> <name>",
+		description: "Delete a group",
+		run:         deleteGroup,
+	},
+	"children": {
+		args:        "<org> <name> [-offset=<n>] [-limit=<n>] [-order-by=<column>]",
+		description: "List the child groups of a group",
+		run:         listChildGroups,
+	},
+	"add-child": {
+		args:        "<org> <name> <child>",
+		description: "Add a child group to a group",
+		run:         addChildGroup,
+	},
+	"remove-child": {
+		args:        "<org> <name> <child>",
+		description: "Remove a child group from a group",
+		run:         removeChildGroup,
+	},
+}
+
+var memberCommands = map[string]command{
+	"list": {
+		args:        "<org> <group> [-offset=<n>] [-limit=<n>] [-order-by=<column>]",
+		description: "List the members of a group",
+		run:         listMembers,
+	},
+	"add": {
+		args:        "<org> <group> <externalId> [-expires-at=<RFC 3339 date>]",
+		description: "Add a user to a group",
+		run:         addMember,
+	},
+	"remove": {
+		args:        "<org> <group> <externalId>",
+		description: "Remove a user from a group",
+		run:         removeMember,
+	},
+}
+
+func listGroups(c *ctl, fs *flag.FlagSet, args []string) error {
+	org := fs.String("org", "", "Organization of the groups")
+	opts := listFlags(fs)
+	if _, err := parseArgs(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *org == "" {
+		groups, err := c.client.ListAllGroups(opts)
+		if err != nil {
+			return err
+		}
+		rows := [][]string{}
+		for _, group := range groups.Groups {
+			rows = append(rows, []string{group.Org, group.Name})
+		}
+		return c.out.printList(groups, groups.Page, []string{"ORG", "NAME"}, rows)
+	}
+
+	groups, err := c.client.ListGroups(*org, opts)
+	if err != nil {
+		return err
+	}
+	rows := [][]string{}
+	for _, name := range groups.Groups {
+		rows = append(rows, []string{*org, name})
+	}
+	return c.out.printList(groups, groups.Page, []string{"ORG", "NAME"}, rows)
+}
+
+func getGroup(c *ctl, fs *flag.FlagSet, args []string) error {
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	group, err := c.client.GetGroupByName(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	return printGroup(c, group)
+}
+
+func createGroup(c *ctl, fs *flag.FlagSet, args []string) error {
+	path := fs.String("path", "/", "Path of the group")
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	group, err := c.client.AddGroup(args[0], args[1], *path)
+	if err != nil {
+		return err
+	}
+	return printGroup(c, group)
+}
+
+func updateGroup(c *ctl, fs *flag.FlagSet, args []string) error {
+	newName := fs.String("name", "", "New name of the group")
+	newPath := fs.String("path", "", "New path of the group")
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	if *newName == "" && *newPath == "" {
+		return errUsage
+	}
+	// The worker updates name and path together, so the current values are kept if they aren't set
+	group, err := c.client.GetGroupByName(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	if *newName != "" {
+		group.Name = *newName
+	}
+	if *newPath != "" {
+		group.Path = *newPath
+	}
+	group, err = c.client.UpdateGroup(args[0], args[1], group.Name, group.Path)
+	if err != nil {
+		return err
+	}
+	return printGroup(c, group)
+}
+
+func deleteGroup(c *ctl, fs *flag.FlagSet, args []string) error {
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	if err := c.client.RemoveGroup(args[0], args[1]); err != nil {
+		return err
+	}
+	c.out.message("Group %v deleted from organization %v", args[1], args[0])
+	return nil
+}
+
+func listChildGroups(c *ctl, fs *flag.FlagSet, args []string) error {
+	opts := listFlags(fs)
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	children, err := c.client.ListChildGroups(args[0], args[1], opts)
+	if err != nil {
+		return err
+	}
+	rows := [][]string{}
+	for _, child := range children.Groups {
+		rows = append(rows, []string{child.Group, formatTime(&child.CreateAt)})
+	}
+	return c.out.printList(children, children.Page, []string{"GROUP", "JOINED"}, rows)
+}
+
+func addChildGroup(c *ctl, fs *flag.FlagSet, args []string) error {
+	args, err := parseArgs(fs, args, 3, 3)
+	if err != nil {
+		return err
+	}
+	if err := c.client.AddChildGroup(args[0], args[1], args[2]); err != nil {
+		return err
+	}
+	c.out.message("Group %v added to group %v", args[2], args[1])
+	return nil
+}
+
+func removeChildGroup(c *ctl, fs *flag.FlagSet, args []string) error {
+	args, err := parseArgs(fs, args, 3, 3)
+	if err != nil {
+		return err
+	}
+	if err := c.client.RemoveChildGroup(args[0], args[1], args[2]); err != nil {
+		return err
+	}
+	c.out.message("Group %v removed from group %v", args[2], args[1])
+	return nil
+}
+
+func listMembers(c *ctl, fs *flag.FlagSet, args []string) error {
+	opts := listFlags(fs)
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	members, err := c.client.ListMembers(args[0], args[1], opts)
+	if err != nil {
+		return err
+	}
+	rows := [][]string{}
+	for _, member := range members.Members {
+		rows = append(rows, []string{member.User, formatTime(&member.CreateAt), formatTime(member.ExpiresAt)})
+	}
+	return c.out.printList(members, members.Page, []string{"USER", "JOINED", "EXPIRES"}, rows)
+}
+
+func addMember(c *ctl, fs *flag.FlagSet, args []string) error {
+	expiration := fs.String("expires-at", "", "Date when the membership expires, in RFC 3339 format")
+	args, err := parseArgs(fs, args, 3, 3)
+	if err != nil {
+		return err
+	}
+	expiresAt, err := parseExpiration(*expiration)
+	if err != nil {
+		return err
+	}
+	if err := c.client.AddMember(args[2], args[1], args[0], expiresAt); err != nil {
+		return err
+	}
+	c.out.message("User %v added to group %v", args[2], args[1])
+	return nil
+}
+
+func removeMember(c *ctl, fs *flag.FlagSet, args []string) error {
+	args, err := parseArgs(fs, args, 3, 3)
+	if err != nil {
+		return err
+	}
+	if err := c.client.RemoveMember(args[2], args[1], args[0]); err != nil {
+		return err
+	}
+	c.out.message("User %v removed from group %v", args[2], args[1])
+	return nil
+}
+
+func printGroup(c *ctl, group *api.Group) error {
+	return c.out.print(group, nil, [][]string{
+		{"ID:", group.ID},
+		{"Name:", group.Name},
+		{"Path:", group.Path},
+		{"Org:", group.Org},
+		{"URN:", group.Urn},
+		{"Created:", formatTime(&group.CreateAt)},
+		{"Updated:", formatTime(&group.UpdateAt)},
+	})
+}
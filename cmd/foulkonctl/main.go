@@ -0,0 +1,239 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Tecsisa/foulkon/client"
+)
+
+const usage = `Usage: foulkonctl [options] <command> <subcommand> [arguments]
+
+Options:
+  -config=<file>            Profiles file, $HOME/.foulkonctl.toml by default
+  -profile=<name>           Profile with the worker URL and credentials, $FOULKONCTL_PROFILE or default by default
+  -url=<worker url>         Worker URL, instead of the one of the profile
+  -username=<username>      User of basic authentication, instead of the one of the profile
+  -password=<password>      Password of basic authentication, instead of the one of the profile
+  -token=<token>            Token of bearer authentication, instead of the one of the profile
+  -output=table|json|yaml   Output format, table by default
+
+Commands:
+`
+
+// command is a subcommand of foulkonctl, like users list
+type command struct {
+	// Arguments of the command, shown in its usage
+	args string
+	// Description of the command, shown in its usage
+	description string
+	// run adds the flags of the command to fs and runs it with args
+	run func(c *ctl, fs *flag.FlagSet, args []string) error
+}
+
+// errUsage is returned by commands run with wrong arguments
+var errUsage = errors.New("Invalid arguments")
+
+// commands by name and subcommand name
+var commands = map[string]map[string]command{
+	"users":           userCommands,
+	"groups":          groupCommands,
+	"members":         memberCommands,
+	"policies":        policyCommands,
+	"attachments":     attachmentCommands,
+	"proxy-resources": proxyResourceCommands,
+	"upstream-pools":  upstreamPoolCommands,
+	"oidc-providers":  oidcProviderCommands,
+	"authorize":       authorizeCommands,
+}
+
+// ctl has the client and the output of the commands
+type ctl struct {
+	client *client.Client
+	out    *printer
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command of args, and returns the exit status
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("foulkonctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { printUsage(stderr) }
+	configFile := fs.String("config", "", "Profiles file")
+	profileName := fs.String("profile", os.Getenv("FOULKONCTL_PROFILE"), "Profile name")
+	workerURL := fs.String("url", "", "Worker URL")
+	username := fs.String("username", "", "User of basic authentication")
+	password := fs.String("password", "", "Password of basic authentication")
+	token := fs.String("token", "", "Token of bearer authentication")
+	output := fs.String("output", OUTPUT_TABLE, "Output format")
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if fs.NArg() < 1 || commands[fs.Arg(0)] == nil {
+		printUsage(stderr)
+		return 1
+	}
+	// Commands without subcommands are stored with an empty subcommand name
+	name := fs.Arg(0)
+	cmdArgs := fs.Args()[1:]
+	cmd, ok := commands[name][""]
+	if !ok {
+		if fs.NArg() < 2 || commands[name][fs.Arg(1)].run == nil {
+			printCommandUsage(stderr, name)
+			return 1
+		}
+		name += " " + fs.Arg(1)
+		cmd = commands[fs.Arg(0)][fs.Arg(1)]
+		cmdArgs = fs.Args()[2:]
+	}
+
+	if *output != OUTPUT_TABLE && *output != OUTPUT_JSON && *output != OUTPUT_YAML {
+		fmt.Fprintf(stderr, "Unexpected output format %v, use table, json or yaml\n", *output)
+		return 1
+	}
+
+	// Flags override the values of the profile
+	p, err := loadProfile(*configFile, *profileName)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	if *workerURL != "" {
+		p.URL = *workerURL
+	}
+	if *username != "" {
+		p.Username = *username
+	}
+	if *password != "" {
+		p.Password = *password
+	}
+	if *token != "" {
+		p.Token = *token
+	}
+
+	c := &ctl{
+		client: p.client(),
+		out:    &printer{format: *output, w: stdout},
+	}
+	cmdFs := flag.NewFlagSet(name, flag.ContinueOnError)
+	cmdFs.SetOutput(stderr)
+	cmdFs.Usage = func() {}
+	if err := cmd.run(c, cmdFs, cmdArgs); err != nil {
+		if err == errUsage {
+			fmt.Fprintf(stderr, "Usage: foulkonctl %v %v\n  %v\n", name, cmd.args, cmd.description)
+			cmdFs.PrintDefaults()
+		} else {
+			fmt.Fprintln(stderr, err.Error())
+		}
+		return 1
+	}
+
+	return 0
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, usage)
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		subcommands := make([]string, 0, len(commands[name]))
+		for subcommand := range commands[name] {
+			subcommands = append(subcommands, subcommand)
+		}
+		sort.Strings(subcommands)
+		if cmd, ok := commands[name][""]; ok {
+			fmt.Fprintf(w, "  %-17v %v\n", name, cmd.args)
+		} else {
+			fmt.Fprintf(w, "  %-17v %v\n", name, strings.Join(subcommands, ", "))
+		}
+	}
+	fmt.Fprintln(w, "\nRun foulkonctl <command> to see the usage of its subcommands.")
+}
+
+func printCommandUsage(w io.Writer, name string) {
+	subcommands := make([]string, 0, len(commands[name]))
+	for subcommand := range commands[name] {
+		subcommands = append(subcommands, subcommand)
+	}
+	sort.Strings(subcommands)
+	fmt.Fprintf(w, "Usage: foulkonctl [options] %v <subcommand> [arguments]\n\nSubcommands:\n", name)
+	for _, subcommand := range subcommands {
+		cmd := commands[name][subcommand]
+		fmt.Fprintf(w, "  %v %v\n        %v\n", subcommand, cmd.args, cmd.description)
+	}
+}
+
+// Aux methods for commands
+
+// parseArgs parses the flags of args, that can be mixed with the positional arguments, and returns
+// the positional arguments. It returns errUsage if a flag is invalid, or if there are less positional
+// arguments than min or more than max, unless max is negative.
+func parseArgs(fs *flag.FlagSet, args []string, min int, max int) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, errUsage
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if len(positional) < min || (max >= 0 && len(positional) > max) {
+		return nil, errUsage
+	}
+	return positional, nil
+}
+
+// listFlags adds the flags of list options to fs
+func listFlags(fs *flag.FlagSet) *client.ListOptions {
+	opts := new(client.ListOptions)
+	fs.StringVar(&opts.PathPrefix, "path-prefix", "", "Only list the items with path starting with this prefix")
+	fs.IntVar(&opts.Offset, "offset", 0, "Offset of the first item listed")
+	fs.IntVar(&opts.Limit, "limit", 0, "Maximum number of items listed, the worker default if it is 0")
+	fs.StringVar(&opts.OrderBy, "order-by", "", "Column to order by, with -desc suffix for descending order")
+	return opts
+}
+
+// parseExpiration parses an optional expiration date in RFC 3339 format
+func parseExpiration(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	expiresAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid expiration date %v, use RFC 3339 format like 2006-01-02T15:04:05Z", value)
+	}
+	return &expiresAt, nil
+}
+
+// formatTime formats a date for table output, or returns - for nil or zero dates
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+// formatValue formats an optional value for table output, or returns - if it is empty
+func formatValue(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
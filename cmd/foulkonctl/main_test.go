@@ -0,0 +1,342 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	userJSON := `{"id": "UserID", "externalId": "user1", "path": "/path/", "urn": "urn:iws:iam::user/path/user1",
+		"createAt": "2016-01-01T00:00:00Z", "updateAt": "2016-01-01T00:00:00Z"}`
+	testcases := map[string]struct {
+		args []string
+		// Expected request
+		expectedMethod string
+		expectedPath   string
+		expectedQuery  string
+		expectedBody   string
+		// Worker response
+		status   int
+		response string
+		// Expected result
+		expectedStatus int
+		expectedStdout string
+		expectedStderr string
+	}{
+		"OkCaseGetUserTable": {
+			args:           []string{"users", "get", "user1"},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/users/user1",
+			status:         http.StatusOK,
+			response:       userJSON,
+			expectedStdout: "ID:            UserID\n" +
+				"External ID:   user1\n" +
+				"Path:          /path/\n" +
+				"URN:           urn:iws:iam::user/path/user1\n" +
+				"Created:       2016-01-01T00:00:00Z\n" +
+				"Updated:       2016-01-01T00:00:00Z\n",
+		},
+		"OkCaseGetUserYAML": {
+			args:           []string{"-output=yaml", "users", "get", "user1"},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/users/user1",
+			status:         http.StatusOK,
+			response:       userJSON,
+			expectedStdout: "id: UserID\n" +
+				"externalId: user1\n" +
+				"path: /path/\n" +
+				"urn: urn:iws:iam::user/path/user1\n" +
+				"createAt: \"2016-01-01T00:00:00Z\"\n" +
+				"updateAt: \"2016-01-01T00:00:00Z\"\n",
+		},
+		"OkCaseListUsersPaginated": {
+			args:           []string{"users", "list", "-limit=1", "-order-by=externalId"},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/users",
+			expectedQuery:  "Limit=1&OrderBy=externalId",
+			status:         http.StatusOK,
+			response:       `{"users": ["user1"], "offset": 0, "limit": 1, "total": 2}`,
+			expectedStdout: "EXTERNAL ID\nuser1\nShowing 1-1 of 2\n",
+		},
+		"OkCaseListUsersJSON": {
+			args:           []string{"-output=json", "users", "list"},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/users",
+			status:         http.StatusOK,
+			response:       `{"users": ["user1"], "offset": 0, "limit": 20, "total": 1}`,
+			expectedStdout: "{\n  \"users\": [\n    \"user1\"\n  ],\n  \"offset\": 0,\n  \"limit\": 20,\n  \"total\": 1\n}\n",
+		},
+		"OkCaseAddMember": {
+			args:           []string{"members", "add", "example", "group1", "user1", "-expires-at=2016-02-01T00:00:00Z"},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/organizations/example/groups/group1/users/user1",
+			expectedBody:   `{"expiresAt": "2016-02-01T00:00:00Z"}`,
+			status:         http.StatusNoContent,
+			expectedStdout: "User user1 added to group group1\n",
+		},
+		"OkCaseAttachPolicyToUser": {
+			args:           []string{"attachments", "attach", "user", "user1", "example", "policy1"},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/users/user1/policies/example/policy1",
+			status:         http.StatusNoContent,
+			expectedStdout: "Policy policy1 attached to user user1\n",
+		},
+		"OkCaseAuthorize": {
+			args:           []string{"authorize", "-action=example:get", "urn:ews:example:instance1:resource/a", "urn:ews:example:instance1:resource/b"},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/resource",
+			expectedBody: `{"action": "example:get",
+				"resources": ["urn:ews:example:instance1:resource/a", "urn:ews:example:instance1:resource/b"]}`,
+			status:   http.StatusOK,
+			response: `{"resourcesAllowed": ["urn:ews:example:instance1:resource/a"]}`,
+			expectedStdout: "RESOURCE                               RESULT\n" +
+				"urn:ews:example:instance1:resource/a   allowed\n" +
+				"urn:ews:example:instance1:resource/b   denied\n",
+		},
+		"OkCaseAuthorizeNoResourceAllowed": {
+			args:           []string{"-output=json", "authorize", "-action=example:get", "urn:ews:example:instance1:resource/a"},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/resource",
+			expectedBody:   `{"action": "example:get", "resources": ["urn:ews:example:instance1:resource/a"]}`,
+			status:         http.StatusForbidden,
+			response:       `{"code": "UnauthorizedResourcesError", "message": "Unauthorized"}`,
+			expectedStdout: "{\n  \"resourcesAllowed\": []\n}\n",
+		},
+		"OkCaseCreateUpstreamPool": {
+			args: []string{"upstream-pools", "create", "example", "pool1", "-target=http://10.0.0.1:8080",
+				"-target=http://10.0.0.2:8080", "-balancer=least-connections", "-health-path=/health"},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/api/v1/organizations/example/upstream-pools",
+			expectedBody: `{"name": "pool1", "path": "/", "config": {"targets": ["http://10.0.0.1:8080", "http://10.0.0.2:8080"],
+				"balancer": "least-connections", "healthCheck": {"path": "/health"}, "ejection": {}, "transport": {}}}`,
+			status: http.StatusCreated,
+			response: `{"id": "PoolID", "name": "pool1", "org": "example", "path": "/", "urn": "urn:iws:iam:example:upstream/pool1",
+				"config": {"targets": ["http://10.0.0.1:8080", "http://10.0.0.2:8080"], "balancer": "least-connections",
+				"healthCheck": {"path": "/health", "interval": 10, "timeout": 2, "threshold": 2},
+				"ejection": {"maxFailures": 5, "duration": 30}, "transport": {"maxIdleConns": 100, "idleConnTimeout": 90}},
+				"createAt": "2016-01-01T00:00:00Z", "updateAt": "2016-01-01T00:00:00Z"}`,
+			expectedStdout: "ID:                  PoolID\n" +
+				"Name:                pool1\n" +
+				"Path:                /\n" +
+				"Org:                 example\n" +
+				"URN:                 urn:iws:iam:example:upstream/pool1\n" +
+				"Targets:             http://10.0.0.1:8080, http://10.0.0.2:8080\n" +
+				"Balancer:            least-connections\n" +
+				"Health check:        /health every 10s, timeout 2s, threshold 2\n" +
+				"Ejection:            5 failures, 30s\n" +
+				"Max idle conns:      100\n" +
+				"Idle conn timeout:   90s\n" +
+				"Response timeout:    -\n" +
+				"Created:             2016-01-01T00:00:00Z\n" +
+				"Updated:             2016-01-01T00:00:00Z\n",
+		},
+		"ErrorCaseCreateUpstreamPoolWithoutTargets": {
+			args:           []string{"upstream-pools", "create", "example", "pool1"},
+			expectedStatus: 1,
+			expectedStderr: "Usage: foulkonctl upstream-pools create <org> <name> -target=<url>...",
+		},
+		"ErrorCaseWorkerError": {
+			args:           []string{"users", "get", "user1"},
+			expectedMethod: http.MethodGet,
+			expectedPath:   "/api/v1/users/user1",
+			status:         http.StatusNotFound,
+			response:       `{"code": "UserWithExternalIDNotFound", "message": "User with externalId user1 not found"}`,
+			expectedStatus: 1,
+			expectedStderr: "Code: UserWithExternalIDNotFound, Message: User with externalId user1 not found\n",
+		},
+		"ErrorCaseInvalidArguments": {
+			args:           []string{"users", "get"},
+			expectedStatus: 1,
+			expectedStderr: "Usage: foulkonctl users get <externalId>\n  Show a user\n",
+		},
+		"ErrorCaseInvalidOutput": {
+			args:           []string{"-output=xml", "users", "list"},
+			expectedStatus: 1,
+			expectedStderr: "Unexpected output format xml, use table, json or yaml\n",
+		},
+	}
+
+	for n, test := range testcases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, test.expectedMethod, r.Method, "Error in test case %v", n)
+			assert.Equal(t, test.expectedPath, r.URL.EscapedPath(), "Error in test case %v", n)
+			assert.Equal(t, test.expectedQuery, r.URL.RawQuery, "Error in test case %v", n)
+			body, err := ioutil.ReadAll(r.Body)
+			assert.NoError(t, err, "Error in test case %v", n)
+			if test.expectedBody != "" {
+				assert.JSONEq(t, test.expectedBody, string(body), "Error in test case %v", n)
+			}
+			username, password, _ := r.BasicAuth()
+			assert.Equal(t, "admin", username, "Error in test case %v", n)
+			assert.Equal(t, "password", password, "Error in test case %v", n)
+
+			w.WriteHeader(test.status)
+			w.Write([]byte(test.response))
+		}))
+
+		stdout := new(bytes.Buffer)
+		stderr := new(bytes.Buffer)
+		args := append([]string{"-config=/dev/null", "-url=" + server.URL, "-username=admin", "-password=password"}, test.args...)
+		status := run(args, stdout, stderr)
+		server.Close()
+
+		assert.Equal(t, test.expectedStatus, status, "Error in test case %v", n)
+		assert.Equal(t, test.expectedStdout, stdout.String(), "Error in test case %v", n)
+		if test.expectedStatus == 0 {
+			assert.Empty(t, stderr.String(), "Error in test case %v", n)
+		} else {
+			assert.Contains(t, stderr.String(), test.expectedStderr, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "foulkonctl")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "profiles.toml")
+	err = ioutil.WriteFile(file, []byte(`
+[default]
+url = "http://foulkon:8000"
+username = "admin"
+password = "${FOULKONCTL_TEST_PASSWORD}"
+
+[production]
+url = "https://foulkon.example.com"
+token = "token1"
+`), 0644)
+	assert.NoError(t, err)
+	os.Setenv("FOULKONCTL_TEST_PASSWORD", "password")
+	defer os.Unsetenv("FOULKONCTL_TEST_PASSWORD")
+
+	testcases := map[string]struct {
+		file string
+		name string
+		// Expected result
+		expectedProfile *profile
+		wantError       bool
+	}{
+		"OkCaseDefaultProfile": {
+			file: file,
+			expectedProfile: &profile{
+				URL:      "http://foulkon:8000",
+				Username: "admin",
+				Password: "password",
+			},
+		},
+		"OkCaseNamedProfile": {
+			file: file,
+			name: "production",
+			expectedProfile: &profile{
+				URL:   "https://foulkon.example.com",
+				Token: "token1",
+			},
+		},
+		"OkCaseDefaultProfileNotInFile": {
+			file: "/dev/null",
+			expectedProfile: &profile{
+				URL: DEFAULT_WORKER_URL,
+			},
+		},
+		"ErrorCaseProfileNotFound": {
+			file:      file,
+			name:      "staging",
+			wantError: true,
+		},
+		"ErrorCaseFileNotFound": {
+			file:      filepath.Join(dir, "missing.toml"),
+			wantError: true,
+		},
+	}
+
+	for n, test := range testcases {
+		p, err := loadProfile(test.file, test.name)
+		if test.wantError {
+			assert.Error(t, err, "Error in test case %v", n)
+			continue
+		}
+		if assert.NoError(t, err, "Error in test case %v", n) {
+			assert.Equal(t, test.expectedProfile, p, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestReadStatements(t *testing.T) {
+	testcases := map[string]struct {
+		name    string
+		content string
+		// Expected result
+		expectedStatements []api.Statement
+		wantError          bool
+	}{
+		"OkCaseJSON": {
+			name: "statements.json",
+			content: `[{"effect": "allow", "actions": ["iam:*"], "resources": ["urn:everything:*"],
+				"conditions": {"IpAddress": {"foulkon:SourceIp": ["10.0.0.0/8"]}}}]`,
+			expectedStatements: []api.Statement{
+				{
+					Effect:     "allow",
+					Actions:    []string{"iam:*"},
+					Resources:  []string{"urn:everything:*"},
+					Conditions: api.Conditions{"IpAddress": {"foulkon:SourceIp": []string{"10.0.0.0/8"}}},
+				},
+			},
+		},
+		"OkCaseYAML": {
+			name:    "statements.yaml",
+			content: "- effect: deny\n  actions:\n  - iam:*\n  resources:\n  - urn:everything:*\n",
+			expectedStatements: []api.Statement{
+				{
+					Effect:    "deny",
+					Actions:   []string{"iam:*"},
+					Resources: []string{"urn:everything:*"},
+				},
+			},
+		},
+		"ErrorCaseUnknownJSONField": {
+			name:      "statements.json",
+			content:   `[{"effect": "allow", "action": ["iam:*"], "resources": ["urn:everything:*"]}]`,
+			wantError: true,
+		},
+		"ErrorCaseUnknownYAMLField": {
+			name:      "statements.yaml",
+			content:   "- effect: allow\n  action:\n  - iam:*\n",
+			wantError: true,
+		},
+		"ErrorCaseInvalidJSON": {
+			name:      "statements.json",
+			content:   `[{"effect": "allow"`,
+			wantError: true,
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "foulkonctl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for n, test := range testcases {
+		file := filepath.Join(dir, test.name)
+		if err := ioutil.WriteFile(file, []byte(test.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		statements, err := readStatements(file)
+		if test.wantError {
+			assert.Error(t, err, "Error in test case %v", n)
+			continue
+		}
+		if assert.NoError(t, err, "Error in test case %v", n) {
+			assert.Equal(t, test.expectedStatements, statements, "Error in test case %v", n)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+var oidcProviderCommands = map[string]command{
+	"list": {
+		args:        "[-path-prefix=<prefix>] [-offset=<n>] [-limit=<n>] [-order-by=<column>]",
+		description: "List the OIDC providers",
+		run:         listOidcProviders,
+	},
+	"get": {
+		args:        "<name>",
+		description: "Show an OIDC provider",
+		run:         getOidcProvider,
+	},
+	"create": {
+		args:        "<name> -issuer-url=<url> -clients=<client>[,<client>...] [-path=<path>]",
+		description: "Create an OIDC provider",
+		run:         createOidcProvider,
+	},
+	"update": {
+		args:        "<name> [-name=<new name>] [-path=<new path>] [-issuer-url=<url>] [-clients=<client>[,<client>...]]",
+		description: "Update an OIDC provider",
+		run:         updateOidcProvider,
+	},
+	"delete": {
+		args:        "<name>",
+		description: "Delete an OIDC provider",
+		run:         deleteOidcProvider,
+	},
+}
+
+func listOidcProviders(c *ctl, fs *flag.FlagSet, args []string) error {
+	opts := listFlags(fs)
+	if _, err := parseArgs(fs, args, 0, 0); err != nil {
+		return err
+	}
+	providers, err := c.client.ListOidcProviders(opts)
+	if err != nil {
+		return err
+	}
+	rows := [][]string{}
+	for _, name := range providers.Providers {
+		rows = append(rows, []string{name})
+	}
+	return c.out.printList(providers, providers.Page, []string{"NAME"}, rows)
+}
+
+func getOidcProvider(c *ctl, fs *flag.FlagSet, args []string) error {
+	args, err := parseArgs(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	provider, err := c.client.GetOidcProviderByName(args[0])
+	if err != nil {
+		return err
+	}
+	return printOidcProvider(c, provider)
+}
+
+func createOidcProvider(c *ctl, fs *flag.FlagSet, args []string) error {
+	path := fs.String("path", "/", "Path of the OIDC provider")
+	issuerURL := fs.String("issuer-url", "", "URL of the issuer of the tokens")
+	clients := fs.String("clients", "", "Comma separated list of client IDs accepted")
+	args, err := parseArgs(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if *issuerURL == "" || *clients == "" {
+		return errUsage
+	}
+	provider, err := c.client.AddOidcProvider(args[0], *path, *issuerURL, strings.Split(*clients, ","))
+	if err != nil {
+		return err
+	}
+	return printOidcProvider(c, provider)
+}
+
+func updateOidcProvider(c *ctl, fs *flag.FlagSet, args []string) error {
+	newName := fs.String("name", "", "New name of the OIDC provider")
+	newPath := fs.String("path", "", "New path of the OIDC provider")
+	issuerURL := fs.String("issuer-url", "", "New URL of the issuer of the tokens")
+	clients := fs.String("clients", "", "New comma separated list of client IDs accepted")
+	args, err := parseArgs(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	// The worker updates all the fields together, so the current values are kept if they aren't set
+	provider, err := c.client.GetOidcProviderByName(args[0])
+	if err != nil {
+		return err
+	}
+	if *newName != "" {
+		provider.Name = *newName
+	}
+	if *newPath != "" {
+		provider.Path = *newPath
+	}
+	if *issuerURL != "" {
+		provider.IssuerURL = *issuerURL
+	}
+	clientIDs := oidcClientNames(provider)
+	if *clients != "" {
+		clientIDs = strings.Split(*clients, ",")
+	}
+	provider, err = c.client.UpdateOidcProvider(args[0], provider.Name, provider.Path, provider.IssuerURL, clientIDs)
+	if err != nil {
+		return err
+	}
+	return printOidcProvider(c, provider)
+}
+
+func deleteOidcProvider(c *ctl, fs *flag.FlagSet, args []string) error {
+	args, err := parseArgs(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if err := c.client.RemoveOidcProvider(args[0]); err != nil {
+		return err
+	}
+	c.out.message("OIDC provider %v deleted", args[0])
+	return nil
+}
+
+func oidcClientNames(provider *api.OidcProvider) []string {
+	names := []string{}
+	for _, client := range provider.OidcClients {
+		names = append(names, client.Name)
+	}
+	return names
+}
+
+func printOidcProvider(c *ctl, provider *api.OidcProvider) error {
+	return c.out.print(provider, nil, [][]string{
+		{"ID:", provider.ID},
+		{"Name:", provider.Name},
+		{"Path:", provider.Path},
+		{"URN:", provider.Urn},
+		{"Issuer URL:", provider.IssuerURL},
+		{"Clients:", strings.Join(oidcClientNames(provider), ",")},
+		{"Created:", formatTime(&provider.CreateAt)},
+		{"Updated:", formatTime(&provider.UpdateAt)},
+	})
+}
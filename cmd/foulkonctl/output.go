@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Tecsisa/foulkon/client"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// Output formats
+	OUTPUT_TABLE = "table"
+	OUTPUT_JSON  = "json"
+	OUTPUT_YAML  = "yaml"
+)
+
+// printer writes the results of the commands in the output format
+type printer struct {
+	format string
+	w      io.Writer
+}
+
+// print writes v as JSON or YAML, or header and rows as aligned columns in table format.
+// Tables without header are written as a list of properties.
+func (p *printer) print(v interface{}, header []string, rows [][]string) error {
+	switch p.format {
+	case OUTPUT_JSON:
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(p.w, string(b))
+		return err
+	case OUTPUT_YAML:
+		b, err := toYAML(v)
+		if err != nil {
+			return err
+		}
+		_, err = p.w.Write(b)
+		return err
+	default:
+		tw := tabwriter.NewWriter(p.w, 0, 0, 3, ' ', 0)
+		if header != nil {
+			fmt.Fprintln(tw, strings.Join(header, "\t"))
+		}
+		for _, row := range rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	}
+}
+
+// printList writes a list like print, with the pagination after the rows in table format
+func (p *printer) printList(v interface{}, page client.Page, header []string, rows [][]string) error {
+	if err := p.print(v, header, rows); err != nil {
+		return err
+	}
+	if p.format == OUTPUT_TABLE && page.Total > len(rows) {
+		fmt.Fprintf(p.w, "Showing %v-%v of %v\n", page.Offset+1, page.Offset+len(rows), page.Total)
+	}
+	return nil
+}
+
+// message writes a message in table format, for commands without result
+func (p *printer) message(format string, a ...interface{}) {
+	if p.format == OUTPUT_TABLE {
+		fmt.Fprintf(p.w, format+"\n", a...)
+	}
+}
+
+// toYAML encodes v with YAML using the JSON field names and order
+func toYAML(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(map[string]interface{}{"v": v})
+	if err != nil {
+		return nil, err
+	}
+	// Mappings nested in a yaml.MapSlice are decoded with their order too
+	wrapper := yaml.MapSlice{}
+	if err := yaml.Unmarshal(b, &wrapper); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(wrapper[0].Value)
+}
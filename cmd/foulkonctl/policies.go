@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/Tecsisa/foulkon/api"
+	"gopkg.in/yaml.v2"
+)
+
+var policyCommands = map[string]command{
+	"list": {
+		args:        "[-org=<org>] [-path-prefix=<prefix>] [-offset=<n>] [-limit=<n>] [-order-by=<column>]",
+		description: "List the policies of an organization, or of all organizations without -org",
+		run:         listPolicies,
+	},
+	"get": {
+		args:        "<org> <name>",
+		description: "Show a policy",
+		run:         getPolicy,
+	},
+	"create": {
+		args:        "<org> <name> -f=<statements file> [-path=<path>]",
+		description: "Create a policy with the statements of a JSON or YAML file",
+		run:         createPolicy,
+	},
+	"update": {
+		args:        "<org> <name> [-f=<statements file>] [-name=<new name>] [-path=<new path>]",
+		description: "Update the statements, name or path of a policy",
+		run:         updatePolicy,
+	},
+	"delete": {
+		args:        "<org> <name>",
+		description: "Delete a policy",
+		run:         deletePolicy,
+	},
+	"groups": {
+		args:        "<org> <name> [-offset=<n>] [-limit=<n>] [-order-by=<column>]",
+		description: "List the groups with a policy attached",
+		run:         listPolicyGroups,
+	},
+}
+
+func listPolicies(c *ctl, fs *flag.FlagSet, args []string) error {
+	org := fs.String("org", "", "Organization of the policies")
+	opts := listFlags(fs)
+	if _, err := parseArgs(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *org == "" {
+		policies, err := c.client.ListAllPolicies(opts)
+		if err != nil {
+			return err
+		}
+		rows := [][]string{}
+		for _, policy := range policies.Policies {
+			rows = append(rows, []string{policy.Org, policy.Name})
+		}
+		return c.out.printList(policies, policies.Page, []string{"ORG", "NAME"}, rows)
+	}
+
+	policies, err := c.client.ListPolicies(*org, opts)
+	if err != nil {
+		return err
+	}
+	rows := [][]string{}
+	for _, name := range policies.Policies {
+		rows = append(rows, []string{*org, name})
+	}
+	return c.out.printList(policies, policies.Page, []string{"ORG", "NAME"}, rows)
+}
+
+func getPolicy(c *ctl, fs *flag.FlagSet, args []string) error {
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	policy, err := c.client.GetPolicyByName(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	return printPolicy(c, policy)
+}
+
+func createPolicy(c *ctl, fs *flag.FlagSet, args []string) error {
+	file := fs.String("f", "", "JSON or YAML file with the list of statements")
+	path := fs.String("path", "/", "Path of the policy")
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	if *file == "" {
+		return errUsage
+	}
+	statements, err := readStatements(*file)
+	if err != nil {
+		return err
+	}
+	policy, err := c.client.AddPolicy(args[1], *path, args[0], statements)
+	if err != nil {
+		return err
+	}
+	return printPolicy(c, policy)
+}
+
+func updatePolicy(c *ctl, fs *flag.FlagSet, args []string) error {
+	file := fs.String("f", "", "JSON or YAML file with the new list of statements")
+	newName := fs.String("name", "", "New name of the policy")
+	newPath := fs.String("path", "", "New path of the policy")
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	if *file == "" && *newName == "" && *newPath == "" {
+		return errUsage
+	}
+	// The worker updates all the fields together, so the current values are kept if they aren't set
+	policy, err := c.client.GetPolicyByName(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	statements := []api.Statement{}
+	if policy.Statements != nil {
+		statements = *policy.Statements
+	}
+	if *file != "" {
+		if statements, err = readStatements(*file); err != nil {
+			return err
+		}
+	}
+	if *newName != "" {
+		policy.Name = *newName
+	}
+	if *newPath != "" {
+		policy.Path = *newPath
+	}
+	policy, err = c.client.UpdatePolicy(args[0], args[1], policy.Name, policy.Path, statements)
+	if err != nil {
+		return err
+	}
+	return printPolicy(c, policy)
+}
+
+func deletePolicy(c *ctl, fs *flag.FlagSet, args []string) error {
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	if err := c.client.RemovePolicy(args[0], args[1]); err != nil {
+		return err
+	}
+	c.out.message("Policy %v deleted from organization %v", args[1], args[0])
+	return nil
+}
+
+func listPolicyGroups(c *ctl, fs *flag.FlagSet, args []string) error {
+	opts := listFlags(fs)
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	groups, err := c.client.ListAttachedGroups(args[0], args[1], opts)
+	if err != nil {
+		return err
+	}
+	rows := [][]string{}
+	for _, group := range groups.Groups {
+		rows = append(rows, []string{group.Group, formatTime(&group.CreateAt)})
+	}
+	return c.out.printList(groups, groups.Page, []string{"GROUP", "ATTACHED"}, rows)
+}
+
+// readStatements reads a list of statements from a JSON file, or from a YAML file for other extensions
+func readStatements(file string) ([]api.Statement, error) {
+	body, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		// JSON files are converted to YAML, so they are decoded with the same strict decoder
+		var value interface{}
+		if err := json.Unmarshal(body, &value); err != nil {
+			return nil, fmt.Errorf("Invalid statements file %v: %v", file, err)
+		}
+		if body, err = yaml.Marshal(value); err != nil {
+			return nil, fmt.Errorf("Invalid statements file %v: %v", file, err)
+		}
+	}
+	statements := []api.Statement{}
+	if err := yaml.UnmarshalStrict(body, &statements); err != nil {
+		return nil, fmt.Errorf("Invalid statements file %v: %v", file, err)
+	}
+	return statements, nil
+}
+
+func printPolicy(c *ctl, policy *api.Policy) error {
+	rows := [][]string{
+		{"ID:", policy.ID},
+		{"Name:", policy.Name},
+		{"Path:", policy.Path},
+		{"Org:", policy.Org},
+		{"URN:", policy.Urn},
+		{"Created:", formatTime(&policy.CreateAt)},
+		{"Updated:", formatTime(&policy.UpdateAt)},
+	}
+	if policy.Statements != nil {
+		for _, s := range *policy.Statements {
+			rows = append(rows, []string{"Statement:", fmt.Sprintf("%v %v on %v",
+				s.Effect, strings.Join(s.Actions, ","), strings.Join(s.Resources, ","))})
+		}
+	}
+	return c.out.print(policy, nil, rows)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/Tecsisa/foulkon/client"
+	"github.com/pelletier/go-toml"
+)
+
+const (
+	DEFAULT_PROFILE    = "default"
+	DEFAULT_WORKER_URL = "http://localhost:8000"
+)
+
+// aux var for ${OS_ENV_VAR} regex
+var rEnvVar = regexp.MustCompile(`^\$\{(\w+)\}$`)
+
+// profile is a worker URL with the credentials to call it, stored in a table of the profiles file like:
+//
+//	[default]
+//	url = "http://localhost:8000"
+//	username = "admin"
+//	password = "${FOULKON_ADMIN_PASSWORD}"
+//
+// Values like ${SOME_KEY} are read from the OS ENV vars. The token is used instead of the user if it is set.
+type profile struct {
+	URL      string
+	Username string
+	Password string
+	Token    string
+}
+
+// loadProfile reads a profile of the profiles file, $HOME/.foulkonctl.toml if file is empty. The
+// default profile is used if name is empty, and an empty profile if it isn't in the file.
+func loadProfile(file string, name string) (*profile, error) {
+	explicit := name != ""
+	if name == "" {
+		name = DEFAULT_PROFILE
+	}
+	if file == "" {
+		file = filepath.Join(os.Getenv("HOME"), ".foulkonctl.toml")
+		if _, err := os.Stat(file); os.IsNotExist(err) && !explicit {
+			return &profile{URL: DEFAULT_WORKER_URL}, nil
+		}
+	}
+
+	config, err := toml.LoadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read profiles file %v, error: %v", file, err)
+	}
+	tree, ok := config.Get(name).(*toml.Tree)
+	if !ok {
+		if explicit {
+			return nil, fmt.Errorf("Profile %v not found in profiles file %v", name, file)
+		}
+		return &profile{URL: DEFAULT_WORKER_URL}, nil
+	}
+
+	p := &profile{
+		URL:      getProfileValue(tree, "url"),
+		Username: getProfileValue(tree, "username"),
+		Password: getProfileValue(tree, "password"),
+		Token:    getProfileValue(tree, "token"),
+	}
+	if p.URL == "" {
+		p.URL = DEFAULT_WORKER_URL
+	}
+	return p, nil
+}
+
+// client returns a client of the worker authenticated with the credentials of the profile
+func (p *profile) client() *client.Client {
+	c := &client.Client{
+		URL:        p.URL,
+		HTTPClient: &http.Client{Timeout: time.Minute},
+	}
+	if p.Token != "" {
+		c.Auth = client.BearerToken(p.Token)
+	} else if p.Username != "" {
+		c.Auth = client.BasicAuth{Username: p.Username, Password: p.Password}
+	}
+	return c
+}
+
+// getProfileValue returns the string value of key, resolving OS ENV vars
+func getProfileValue(tree *toml.Tree, key string) string {
+	value, _ := tree.Get(key).(string)
+	if match := rEnvVar.FindStringSubmatch(value); match != nil {
+		return os.Getenv(match[1])
+	}
+	return value
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+var proxyResourceCommands = map[string]command{
+	"list": {
+		args:        "<org> [-path-prefix=<prefix>] [-offset=<n>] [-limit=<n>] [-order-by=<column>]",
+		description: "List the proxy resources of an organization",
+		run:         listProxyResources,
+	},
+	"get": {
+		args:        "<org> <name>",
+		description: "Show a proxy resource",
+		run:         getProxyResource,
+	},
+	"create": {
+		args: "<org> <name> -host=<host>|-upstream=<pool> -resource-path=<path> -method=<method> -urn=<urn> " +
+			"-action=<action> [-path=<path>] [-match-host=<host>] [-match-header=<name: value>]...",
+		description: "Create a proxy resource",
+		run:         createProxyResource,
+	},
+	"update": {
+		args: "<org> <name> [-name=<new name>] [-path=<new path>] [-host=<host>|-upstream=<pool>] [-resource-path=<path>] " +
+			"[-method=<method>] [-urn=<urn>] [-action=<action>] [-clear-matchers] [-match-host=<host>] " +
+			"[-match-header=<name: value>]...",
+		description: "Update a proxy resource",
+		run:         updateProxyResource,
+	},
+	"delete": {
+		args:        "<org> <name>",
+		description: "Delete a proxy resource",
+		run:         deleteProxyResource,
+	},
+}
+
+// resourceFlags adds the flags of the resource of a proxy resource to fs
+func resourceFlags(fs *flag.FlagSet) *api.ResourceEntity {
+	resource := new(api.ResourceEntity)
+	fs.StringVar(&resource.Host, "host", "", "Host the requests are forwarded to")
+	fs.StringVar(&resource.Upstream, "upstream", "", "Upstream pool of the organization the requests are balanced to, instead of a host")
+	fs.StringVar(&resource.Path, "resource-path", "", "Path of the requests, with :param segments")
+	fs.StringVar(&resource.Method, "method", "", "HTTP method of the requests")
+	fs.StringVar(&resource.Urn, "urn", "", "URN of the resource authorized, with the params of the path")
+	fs.StringVar(&resource.Action, "action", "", "Action authorized")
+	fs.StringVar(&resource.MatchHost, "match-host", "", "Virtual host of the requests, any host if it isn't set")
+	fs.Var((*headerMatchers)(&resource.MatchHeaders), "match-header", "Header that the requests must have, like \"X-Version: 2\". "+
+		"It can be repeated")
+	return resource
+}
+
+// headerMatchers is a flag that adds a header matcher every time it is set
+type headerMatchers []api.HeaderMatcher
+
+func (h *headerMatchers) String() string {
+	if h == nil {
+		return ""
+	}
+	headers := make([]string, len(*h))
+	for i, header := range *h {
+		headers[i] = header.Name + ": " + header.Value
+	}
+	return strings.Join(headers, ", ")
+}
+
+func (h *headerMatchers) Set(value string) error {
+	header := strings.SplitN(value, ":", 2)
+	if len(header) != 2 {
+		return fmt.Errorf("Invalid header %v, use name: value", value)
+	}
+	*h = append(*h, api.HeaderMatcher{Name: strings.TrimSpace(header[0]), Value: strings.TrimSpace(header[1])})
+	return nil
+}
+
+func listProxyResources(c *ctl, fs *flag.FlagSet, args []string) error {
+	opts := listFlags(fs)
+	args, err := parseArgs(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	resources, err := c.client.ListProxyResources(args[0], opts)
+	if err != nil {
+		return err
+	}
+	rows := [][]string{}
+	for _, name := range resources.Resources {
+		rows = append(rows, []string{args[0], name})
+	}
+	return c.out.printList(resources, resources.Page, []string{"ORG", "NAME"}, rows)
+}
+
+func getProxyResource(c *ctl, fs *flag.FlagSet, args []string) error {
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	resource, err := c.client.GetProxyResourceByName(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	return printProxyResource(c, resource)
+}
+
+func createProxyResource(c *ctl, fs *flag.FlagSet, args []string) error {
+	path := fs.String("path", "/", "Path of the proxy resource")
+	resource := resourceFlags(fs)
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	proxyResource, err := c.client.AddProxyResource(args[1], args[0], *path, *resource)
+	if err != nil {
+		return err
+	}
+	return printProxyResource(c, proxyResource)
+}
+
+func updateProxyResource(c *ctl, fs *flag.FlagSet, args []string) error {
+	newName := fs.String("name", "", "New name of the proxy resource")
+	newPath := fs.String("path", "", "New path of the proxy resource")
+	clearMatchers := fs.Bool("clear-matchers", false, "Match requests to any host with any headers, unless new ones are set")
+	resource := resourceFlags(fs)
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	// The worker updates all the fields together, so the current values are kept if they aren't set
+	proxyResource, err := c.client.GetProxyResourceByName(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	if *newName != "" {
+		proxyResource.Name = *newName
+	}
+	if *newPath != "" {
+		proxyResource.Path = *newPath
+	}
+	current := &proxyResource.Resource
+	// Requests are forwarded either to a host or to an upstream pool
+	if resource.Host != "" {
+		current.Upstream = ""
+	}
+	if resource.Upstream != "" {
+		current.Host = ""
+	}
+	for _, field := range []struct{ value, current *string }{
+		{&resource.Host, &current.Host},
+		{&resource.Upstream, &current.Upstream},
+		{&resource.Path, &current.Path},
+		{&resource.Method, &current.Method},
+		{&resource.Urn, &current.Urn},
+		{&resource.Action, &current.Action},
+	} {
+		if *field.value != "" {
+			*field.current = *field.value
+		}
+	}
+	if *clearMatchers {
+		current.MatchHost = ""
+		current.MatchHeaders = nil
+	}
+	if resource.MatchHost != "" {
+		current.MatchHost = resource.MatchHost
+	}
+	if len(resource.MatchHeaders) > 0 {
+		current.MatchHeaders = resource.MatchHeaders
+	}
+	proxyResource, err = c.client.UpdateProxyResource(args[0], args[1], proxyResource.Name, proxyResource.Path,
+		proxyResource.Resource)
+	if err != nil {
+		return err
+	}
+	return printProxyResource(c, proxyResource)
+}
+
+func deleteProxyResource(c *ctl, fs *flag.FlagSet, args []string) error {
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	if err := c.client.RemoveProxyResource(args[0], args[1]); err != nil {
+		return err
+	}
+	c.out.message("Proxy resource %v deleted from organization %v", args[1], args[0])
+	return nil
+}
+
+func printProxyResource(c *ctl, resource *api.ProxyResource) error {
+	return c.out.print(resource, nil, [][]string{
+		{"ID:", resource.ID},
+		{"Name:", resource.Name},
+		{"Path:", resource.Path},
+		{"Org:", resource.Org},
+		{"URN:", resource.Urn},
+		{"Host:", formatValue(resource.Resource.Host)},
+		{"Upstream:", formatValue(resource.Resource.Upstream)},
+		{"Resource path:", resource.Resource.Path},
+		{"Method:", resource.Resource.Method},
+		{"Resource URN:", resource.Resource.Urn},
+		{"Action:", resource.Resource.Action},
+		{"Match host:", formatValue(resource.Resource.MatchHost)},
+		{"Match headers:", formatValue((*headerMatchers)(&resource.Resource.MatchHeaders).String())},
+		{"Created:", formatTime(&resource.CreateAt)},
+		{"Updated:", formatTime(&resource.UpdateAt)},
+	})
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+var upstreamPoolCommands = map[string]command{
+	"list": {
+		args:        "<org> [-path-prefix=<prefix>] [-offset=<n>] [-limit=<n>] [-order-by=<column>]",
+		description: "List the upstream pools of an organization",
+		run:         listUpstreamPools,
+	},
+	"get": {
+		args:        "<org> <name>",
+		description: "Show an upstream pool",
+		run:         getUpstreamPool,
+	},
+	"create": {
+		args: "<org> <name> -target=<url>... [-path=<path>] [-balancer=<balancer>] [-health-path=<path>] " +
+			"[-health-interval=<s>] [-health-timeout=<s>] [-health-threshold=<n>] [-max-failures=<n>] " +
+			"[-ejection-duration=<s>] [-max-idle-conns=<n>] [-idle-conn-timeout=<s>] [-response-timeout=<s>]",
+		description: "Create an upstream pool",
+		run:         createUpstreamPool,
+	},
+	"update": {
+		args: "<org> <name> [-name=<new name>] [-path=<new path>] [-target=<url>]... [-balancer=<balancer>] " +
+			"[-no-health-check] [-health-path=<path>] [-health-interval=<s>] [-health-timeout=<s>] " +
+			"[-health-threshold=<n>] [-max-failures=<n>] [-ejection-duration=<s>] [-max-idle-conns=<n>] " +
+			"[-idle-conn-timeout=<s>] [-response-timeout=<s>]",
+		description: "Update an upstream pool",
+		run:         updateUpstreamPool,
+	},
+	"delete": {
+		args:        "<org> <name>",
+		description: "Delete an upstream pool",
+		run:         deleteUpstreamPool,
+	},
+}
+
+// upstreamPoolConfigFlags adds the flags of the config of an upstream pool to fs. The health check
+// is returned apart, because it is only enabled if its flags are set.
+func upstreamPoolConfigFlags(fs *flag.FlagSet) (*api.UpstreamPoolConfig, *api.UpstreamHealthCheck) {
+	config := new(api.UpstreamPoolConfig)
+	healthCheck := new(api.UpstreamHealthCheck)
+	fs.Var((*stringList)(&config.Targets), "target", "Target URL of the pool. It can be repeated")
+	fs.StringVar(&config.Balancer, "balancer", "", "Selection of targets: round-robin or least-connections")
+	fs.StringVar(&healthCheck.Path, "health-path", "", "Path requested by the active health checks, disabled if it isn't set")
+	fs.IntVar(&healthCheck.Interval, "health-interval", 0, "Seconds between health checks")
+	fs.IntVar(&healthCheck.Timeout, "health-timeout", 0, "Seconds to wait for a health check")
+	fs.IntVar(&healthCheck.Threshold, "health-threshold", 0, "Health checks in a row that change the health of a target")
+	fs.IntVar(&config.Ejection.MaxFailures, "max-failures", 0, "Failed requests in a row that eject a target")
+	fs.IntVar(&config.Ejection.Duration, "ejection-duration", 0, "Seconds a target is ejected")
+	fs.IntVar(&config.Transport.MaxIdleConns, "max-idle-conns", 0, "Idle connections kept for each target")
+	fs.IntVar(&config.Transport.IdleConnTimeout, "idle-conn-timeout", 0, "Seconds an idle connection is kept")
+	fs.IntVar(&config.Transport.ResponseTimeout, "response-timeout", 0, "Seconds to wait for the response headers, no limit if it is 0")
+	return config, healthCheck
+}
+
+// stringList is a flag that adds a value every time it is set
+type stringList []string
+
+func (s *stringList) String() string {
+	if s == nil {
+		return ""
+	}
+	return strings.Join(*s, ", ")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+func listUpstreamPools(c *ctl, fs *flag.FlagSet, args []string) error {
+	opts := listFlags(fs)
+	args, err := parseArgs(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	pools, err := c.client.ListUpstreamPools(args[0], opts)
+	if err != nil {
+		return err
+	}
+	rows := [][]string{}
+	for _, name := range pools.Pools {
+		rows = append(rows, []string{args[0], name})
+	}
+	return c.out.printList(pools, pools.Page, []string{"ORG", "NAME"}, rows)
+}
+
+func getUpstreamPool(c *ctl, fs *flag.FlagSet, args []string) error {
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	pool, err := c.client.GetUpstreamPoolByName(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	return printUpstreamPool(c, pool)
+}
+
+func createUpstreamPool(c *ctl, fs *flag.FlagSet, args []string) error {
+	path := fs.String("path", "/", "Path of the upstream pool")
+	config, healthCheck := upstreamPoolConfigFlags(fs)
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	if len(config.Targets) == 0 {
+		return errUsage
+	}
+	if healthCheck.Path != "" {
+		config.HealthCheck = healthCheck
+	}
+	pool, err := c.client.AddUpstreamPool(args[1], args[0], *path, *config)
+	if err != nil {
+		return err
+	}
+	return printUpstreamPool(c, pool)
+}
+
+func updateUpstreamPool(c *ctl, fs *flag.FlagSet, args []string) error {
+	newName := fs.String("name", "", "New name of the upstream pool")
+	newPath := fs.String("path", "", "New path of the upstream pool")
+	noHealthCheck := fs.Bool("no-health-check", false, "Disable the active health checks, unless new ones are set")
+	config, healthCheck := upstreamPoolConfigFlags(fs)
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	// The worker updates all the fields together, so the current values are kept if they aren't set
+	pool, err := c.client.GetUpstreamPoolByName(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	if *newName != "" {
+		pool.Name = *newName
+	}
+	if *newPath != "" {
+		pool.Path = *newPath
+	}
+	current := &pool.Config
+	if len(config.Targets) > 0 {
+		current.Targets = config.Targets
+	}
+	if config.Balancer != "" {
+		current.Balancer = config.Balancer
+	}
+	if *noHealthCheck {
+		current.HealthCheck = nil
+	}
+	if healthCheck.Path != "" && current.HealthCheck == nil {
+		current.HealthCheck = new(api.UpstreamHealthCheck)
+	}
+	if current.HealthCheck != nil {
+		if healthCheck.Path != "" {
+			current.HealthCheck.Path = healthCheck.Path
+		}
+		for _, field := range []struct{ value, current *int }{
+			{&healthCheck.Interval, &current.HealthCheck.Interval},
+			{&healthCheck.Timeout, &current.HealthCheck.Timeout},
+			{&healthCheck.Threshold, &current.HealthCheck.Threshold},
+		} {
+			if *field.value != 0 {
+				*field.current = *field.value
+			}
+		}
+	}
+	for _, field := range []struct{ value, current *int }{
+		{&config.Ejection.MaxFailures, &current.Ejection.MaxFailures},
+		{&config.Ejection.Duration, &current.Ejection.Duration},
+		{&config.Transport.MaxIdleConns, &current.Transport.MaxIdleConns},
+		{&config.Transport.IdleConnTimeout, &current.Transport.IdleConnTimeout},
+	} {
+		if *field.value != 0 {
+			*field.current = *field.value
+		}
+	}
+	// 0 is a valid response timeout, so it is changed whenever the flag is set
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "response-timeout" {
+			current.Transport.ResponseTimeout = config.Transport.ResponseTimeout
+		}
+	})
+	pool, err = c.client.UpdateUpstreamPool(args[0], args[1], pool.Name, pool.Path, pool.Config)
+	if err != nil {
+		return err
+	}
+	return printUpstreamPool(c, pool)
+}
+
+func deleteUpstreamPool(c *ctl, fs *flag.FlagSet, args []string) error {
+	args, err := parseArgs(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	if err := c.client.RemoveUpstreamPool(args[0], args[1]); err != nil {
+		return err
+	}
+	c.out.message("Upstream pool %v deleted from organization %v", args[1], args[0])
+	return nil
+}
+
+func printUpstreamPool(c *ctl, pool *api.UpstreamPool) error {
+	config := pool.Config
+	healthCheck := "-"
+	if config.HealthCheck != nil {
+		healthCheck = fmt.Sprintf("%v every %vs, timeout %vs, threshold %v", config.HealthCheck.Path,
+			config.HealthCheck.Interval, config.HealthCheck.Timeout, config.HealthCheck.Threshold)
+	}
+	responseTimeout := "-"
+	if config.Transport.ResponseTimeout > 0 {
+		responseTimeout = fmt.Sprintf("%vs", config.Transport.ResponseTimeout)
+	}
+	return c.out.print(pool, nil, [][]string{
+		{"ID:", pool.ID},
+		{"Name:", pool.Name},
+		{"Path:", pool.Path},
+		{"Org:", pool.Org},
+		{"URN:", pool.Urn},
+		{"Targets:", (*stringList)(&config.Targets).String()},
+		{"Balancer:", config.Balancer},
+		{"Health check:", healthCheck},
+		{"Ejection:", fmt.Sprintf("%v failures, %vs", config.Ejection.MaxFailures, config.Ejection.Duration)},
+		{"Max idle conns:", fmt.Sprintf("%v", config.Transport.MaxIdleConns)},
+		{"Idle conn timeout:", fmt.Sprintf("%vs", config.Transport.IdleConnTimeout)},
+		{"Response timeout:", responseTimeout},
+		{"Created:", formatTime(&pool.CreateAt)},
+		{"Updated:", formatTime(&pool.UpdateAt)},
+	})
+}
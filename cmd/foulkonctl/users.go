@@ -0,0 +1,137 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+var userCommands = map[string]command{
+	"list": {
+		args:        "[-path-prefix=<prefix>] [-offset=<n>] [-limit=<n>] [-order-by=<column>]",
+		description: "List the external IDs of the users",
+		run:         listUsers,
+	},
+	"get": {
+		args:        "<externalId>",
+		description: "Show a user",
+		run:         getUser,
+	},
+	"create": {
+		args:        "<externalId> [-path=<path>]",
+		description: "Create a user",
+		run:         createUser,
+	},
+	"update": {
+		args:        "<externalId> -path=<path>",
+		description: "Update the path of a user",
+		run:         updateUser,
+	},
+	"delete": {
+		args:        "<externalId>",
+		description: "Delete a user",
+		run:         deleteUser,
+	},
+	"groups": {
+		args:        "<externalId> [-offset=<n>] [-limit=<n>] [-order-by=<column>]",
+		description: "List the groups of a user",
+		run:         listUserGroups,
+	},
+}
+
+func listUsers(c *ctl, fs *flag.FlagSet, args []string) error {
+	opts := listFlags(fs)
+	if _, err := parseArgs(fs, args, 0, 0); err != nil {
+		return err
+	}
+	users, err := c.client.ListUsers(opts)
+	if err != nil {
+		return err
+	}
+	rows := [][]string{}
+	for _, externalID := range users.ExternalIDs {
+		rows = append(rows, []string{externalID})
+	}
+	return c.out.printList(users, users.Page, []string{"EXTERNAL ID"}, rows)
+}
+
+func getUser(c *ctl, fs *flag.FlagSet, args []string) error {
+	args, err := parseArgs(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	user, err := c.client.GetUserByExternalID(args[0])
+	if err != nil {
+		return err
+	}
+	return printUser(c, user)
+}
+
+func createUser(c *ctl, fs *flag.FlagSet, args []string) error {
+	path := fs.String("path", "/", "Path of the user")
+	args, err := parseArgs(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	user, err := c.client.AddUser(args[0], *path)
+	if err != nil {
+		return err
+	}
+	return printUser(c, user)
+}
+
+func updateUser(c *ctl, fs *flag.FlagSet, args []string) error {
+	path := fs.String("path", "", "New path of the user")
+	args, err := parseArgs(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if *path == "" {
+		return errUsage
+	}
+	user, err := c.client.UpdateUser(args[0], *path)
+	if err != nil {
+		return err
+	}
+	return printUser(c, user)
+}
+
+func deleteUser(c *ctl, fs *flag.FlagSet, args []string) error {
+	args, err := parseArgs(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if err := c.client.RemoveUser(args[0]); err != nil {
+		return err
+	}
+	c.out.message("User %v deleted", args[0])
+	return nil
+}
+
+func listUserGroups(c *ctl, fs *flag.FlagSet, args []string) error {
+	opts := listFlags(fs)
+	args, err := parseArgs(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	groups, err := c.client.ListGroupsByUser(args[0], opts)
+	if err != nil {
+		return err
+	}
+	rows := [][]string{}
+	for _, group := range groups.Groups {
+		rows = append(rows, []string{group.Org, group.Name, formatTime(&group.CreateAt)})
+	}
+	return c.out.printList(groups, groups.Page, []string{"ORG", "NAME", "JOINED"}, rows)
+}
+
+func printUser(c *ctl, user *api.User) error {
+	return c.out.print(user, nil, [][]string{
+		{"ID:", user.ID},
+		{"External ID:", user.ExternalID},
+		{"Path:", user.Path},
+		{"URN:", user.Urn},
+		{"Created:", formatTime(&user.CreateAt)},
+		{"Updated:", formatTime(&user.UpdateAt)},
+	})
+}
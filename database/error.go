@@ -24,8 +24,17 @@ const (
 	// Proxy resource Codes
 	PROXY_RESOURCE_NOT_FOUND = "ProxyResourceNotFound"
 
+	// Upstream pool Codes
+	UPSTREAM_POOL_NOT_FOUND = "UpstreamPoolNotFound"
+
 	// Auth Provider Codes
 	AUTH_OIDC_PROVIDER_NOT_FOUND = "AuthOidcProviderNotFound"
+
+	// Webhook Codes
+	WEBHOOK_NOT_FOUND = "WebhookNotFound"
+
+	// Concurrency Codes
+	VERSION_CONFLICT = "VersionConflict"
 )
 
 type Error struct {
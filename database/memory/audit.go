@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"github.com/Tecsisa/foulkon/api"
+)
+
+// AUDIT REPOSITORY IMPLEMENTATION
+
+func (mr *MemoryRepo) AddAuditEntry(entry api.AuditEntry) error {
+	mr.lock()
+	defer mr.unlock()
+
+	// Check unique keys
+	for _, e := range mr.auditEntries {
+		if e.ID == entry.ID {
+			return duplicatedKeyError("audit entry", entry.ID)
+		}
+	}
+
+	// Store audit entry
+	entry.CreateAt = storedTime(entry.CreateAt)
+	mr.auditEntries = append(mr.auditEntries, entry)
+
+	return nil
+}
+
+func (mr *MemoryRepo) GetAuditEntriesFiltered(filter *api.Filter) ([]api.AuditEntry, int, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	entries := []api.AuditEntry{}
+	for _, e := range mr.auditEntries {
+		switch {
+		case len(filter.Actor) > 0 && e.Actor != filter.Actor:
+		case len(filter.ResourceUrn) > 0 && e.Urn != filter.ResourceUrn:
+		case len(filter.Action) > 0 && e.Action != filter.Action:
+		case filter.From != nil && e.CreateAt.Before(*filter.From):
+		case filter.To != nil && !e.CreateAt.Before(*filter.To):
+		default:
+			entries = append(entries, e)
+		}
+	}
+	sortByColumn(entries, filter.OrderBy, func(i int, column string) interface{} {
+		return auditEntryColumn(&entries[i], column)
+	})
+
+	start, end := pageBounds(len(entries), filter)
+	return entries[start:end], len(entries), nil
+}
+
+// PRIVATE HELPER METHODS
+
+// Column value of an audit entry used to sort them
+func auditEntryColumn(entry *api.AuditEntry, column string) interface{} {
+	switch column {
+	case "actor":
+		return entry.Actor
+	case "action":
+		return entry.Action
+	case "urn":
+		return entry.Urn
+	case "create_at":
+		return entry.CreateAt
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRepo_AddAuditEntry(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousEntries []api.AuditEntry
+		// Memory Repo Args
+		entryToCreate api.AuditEntry
+		// Expected result
+		expectedError *database.Error
+	}{
+		"OkCase": {
+			entryToCreate: api.AuditEntry{
+				ID:        "EntryID",
+				Actor:     "admin",
+				RequestID: "RequestID",
+				Action:    api.USER_ACTION_CREATE_USER,
+				Urn:       "urn",
+				After:     json.RawMessage(`{"externalId":"user"}`),
+				CreateAt:  now,
+			},
+		},
+		"ErrorCaseEntryAlreadyExist": {
+			previousEntries: []api.AuditEntry{
+				{ID: "EntryID"},
+			},
+			entryToCreate: api.AuditEntry{
+				ID: "EntryID",
+			},
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Duplicated key EntryID for audit entry",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{auditEntries: test.previousEntries}}
+
+		err := repo.AddAuditEntry(test.entryToCreate)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check audit entry stored
+			assert.Equal(t, []api.AuditEntry{test.entryToCreate}, repo.auditEntries, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestMemoryRepo_GetAuditEntriesFiltered(t *testing.T) {
+	now := time.Now().UTC()
+	later := now.Add(time.Minute)
+	entries := []api.AuditEntry{
+		{ID: "1", Actor: "admin", Action: api.USER_ACTION_CREATE_USER, Urn: "urn:user", CreateAt: now},
+		{ID: "2", Actor: "user", Action: api.GROUP_ACTION_ADD_MEMBER, Urn: "urn:group", CreateAt: later},
+		{ID: "3", Actor: "admin", Action: api.USER_ACTION_DELETE_USER, Urn: "urn:user", CreateAt: later.Add(time.Minute)},
+	}
+	testcases := map[string]struct {
+		// Memory Repo Args
+		filter *api.Filter
+		// Expected result
+		expectedIDs   []string
+		expectedTotal int
+	}{
+		"OkCaseAll": {
+			filter:        &api.Filter{},
+			expectedIDs:   []string{"1", "2", "3"},
+			expectedTotal: 3,
+		},
+		"OkCaseActor": {
+			filter:        &api.Filter{Actor: "admin"},
+			expectedIDs:   []string{"1", "3"},
+			expectedTotal: 2,
+		},
+		"OkCaseUrnAndAction": {
+			filter:        &api.Filter{ResourceUrn: "urn:user", Action: api.USER_ACTION_DELETE_USER},
+			expectedIDs:   []string{"3"},
+			expectedTotal: 1,
+		},
+		"OkCaseTimeRange": {
+			filter:        &api.Filter{From: &later, To: &later},
+			expectedIDs:   []string{},
+			expectedTotal: 0,
+		},
+		"OkCaseFrom": {
+			filter:        &api.Filter{From: &later},
+			expectedIDs:   []string{"2", "3"},
+			expectedTotal: 2,
+		},
+		"OkCaseTo": {
+			filter:        &api.Filter{To: &later},
+			expectedIDs:   []string{"1"},
+			expectedTotal: 1,
+		},
+		"OkCaseOrderAndPage": {
+			filter:        &api.Filter{OrderBy: "create_at desc", Offset: 1, Limit: 1},
+			expectedIDs:   []string{"2"},
+			expectedTotal: 3,
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{auditEntries: entries}}
+
+		result, total, err := repo.GetAuditEntriesFiltered(test.filter)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedTotal, total, "Error in test case %v", n)
+		ids := []string{}
+		for _, e := range result {
+			ids = append(ids, e.ID)
+		}
+		assert.Equal(t, test.expectedIDs, ids, "Error in test case %v", n)
+	}
+}
@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+)
+
+// AUTH OIDC PROVIDER REPOSITORY IMPLEMENTATION
+
+func (mr *MemoryRepo) AddOidcProvider(oidcProvider api.OidcProvider) (*api.OidcProvider, error) {
+	mr.lock()
+	defer mr.unlock()
+
+	// Check unique keys
+	for _, op := range mr.oidcProviders {
+		switch {
+		case op.ID == oidcProvider.ID:
+			return nil, duplicatedKeyError("OIDC provider", oidcProvider.ID)
+		case op.Urn == oidcProvider.Urn:
+			return nil, duplicatedKeyError("OIDC provider", oidcProvider.Urn)
+		}
+	}
+
+	// Store OIDC Provider with its OIDC Clients
+	oidcProviderDB := storedOidcProvider(oidcProvider)
+	mr.oidcProviders = append(mr.oidcProviders, oidcProviderDB)
+
+	// Create API OIDC Provider
+	oidcProviderApi := copyOidcProvider(oidcProviderDB)
+	oidcProviderApi.OidcClients = oidcProvider.OidcClients
+
+	return &oidcProviderApi, nil
+}
+
+func (mr *MemoryRepo) GetOidcProviderByName(name string) (*api.OidcProvider, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	for _, op := range mr.oidcProviders {
+		if op.Name == name {
+			oidcProvider := copyOidcProvider(op)
+			return &oidcProvider, nil
+		}
+	}
+
+	return nil, &database.Error{
+		Code:    database.AUTH_OIDC_PROVIDER_NOT_FOUND,
+		Message: fmt.Sprintf("OIDC Provider with name %v not found", name),
+	}
+}
+
+func (mr *MemoryRepo) GetOidcProvidersFiltered(filter *api.Filter) ([]api.OidcProvider, int, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	oidcProviders := []api.OidcProvider{}
+	for _, op := range mr.oidcProviders {
+		if strings.HasPrefix(op.Path, filter.PathPrefix) {
+			oidcProviders = append(oidcProviders, copyOidcProvider(op))
+		}
+	}
+	sortByColumn(oidcProviders, filter.OrderBy, func(i int, column string) interface{} {
+		return oidcProviderColumn(&oidcProviders[i], column)
+	})
+
+	start, end := pageBounds(len(oidcProviders), filter)
+	return oidcProviders[start:end], len(oidcProviders), nil
+}
+
+func (mr *MemoryRepo) UpdateOidcProvider(oidcProvider api.OidcProvider, oldUpdateAt time.Time) (*api.OidcProvider, error) {
+	mr.lock()
+	defer mr.unlock()
+
+	// Replace OIDC Provider and its OIDC Clients
+	for i, op := range mr.oidcProviders {
+		if op.ID == oidcProvider.ID && op.UpdateAt.Equal(oldUpdateAt) {
+			mr.oidcProviders[i] = storedOidcProvider(oidcProvider)
+			return &oidcProvider, nil
+		}
+	}
+
+	return nil, versionConflictError("OIDC provider", oidcProvider.ID)
+}
+
+func (mr *MemoryRepo) RemoveOidcProvider(id string) error {
+	mr.lock()
+	defer mr.unlock()
+
+	// Delete OIDC Provider with its OIDC Clients
+	oidcProviders := []api.OidcProvider{}
+	for _, op := range mr.oidcProviders {
+		if op.ID != id {
+			oidcProviders = append(oidcProviders, op)
+		}
+	}
+	mr.oidcProviders = oidcProviders
+
+	return nil
+}
+
+// PRIVATE HELPER METHODS
+
+// Transform a OIDC Provider for API into the OIDC Provider stored, that doesn't share OIDC Clients with it
+func storedOidcProvider(oidcProvider api.OidcProvider) api.OidcProvider {
+	oidcProvider.CreateAt = storedTime(oidcProvider.CreateAt)
+	oidcProvider.UpdateAt = storedTime(oidcProvider.UpdateAt)
+	return copyOidcProvider(oidcProvider)
+}
+
+// Copy a OIDC Provider, so the copy can be modified without changing the original OIDC Clients
+func copyOidcProvider(oidcProvider api.OidcProvider) api.OidcProvider {
+	oidcProvider.OidcClients = append([]api.OidcClient{}, oidcProvider.OidcClients...)
+	return oidcProvider
+}
+
+// Column value of a OIDC Provider used to sort them
+func oidcProviderColumn(oidcProvider *api.OidcProvider, column string) interface{} {
+	switch column {
+	case "name":
+		return oidcProvider.Name
+	case "path":
+		return oidcProvider.Path
+	case "create_at":
+		return oidcProvider.CreateAt
+	case "update_at":
+		return oidcProvider.UpdateAt
+	case "urn":
+		return oidcProvider.Urn
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRepo_AddOidcProvider(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousOidcProviders []api.OidcProvider
+		// Memory Repo Args
+		oidcProviderToCreate *api.OidcProvider
+		// Expected result
+		expectedResponse *api.OidcProvider
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			oidcProviderToCreate: &api.OidcProvider{
+				ID:          "OidcProviderID",
+				Name:        "Name",
+				Path:        "Path",
+				Urn:         "urn",
+				CreateAt:    now,
+				UpdateAt:    now,
+				IssuerURL:   "https://accounts.google.com",
+				OidcClients: []api.OidcClient{{Name: "client"}},
+			},
+			expectedResponse: &api.OidcProvider{
+				ID:          "OidcProviderID",
+				Name:        "Name",
+				Path:        "Path",
+				Urn:         "urn",
+				CreateAt:    now,
+				UpdateAt:    now,
+				IssuerURL:   "https://accounts.google.com",
+				OidcClients: []api.OidcClient{{Name: "client"}},
+			},
+		},
+		"ErrorCaseOidcProviderAlreadyExist": {
+			previousOidcProviders: []api.OidcProvider{
+				{ID: "OidcProviderID", Urn: "urn"},
+			},
+			oidcProviderToCreate: &api.OidcProvider{
+				ID:   "OidcProviderID",
+				Name: "Name",
+				Urn:  "urn",
+			},
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Duplicated key OidcProviderID for OIDC provider",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{oidcProviders: test.previousOidcProviders}}
+
+		storedOidcProvider, err := repo.AddOidcProvider(*test.oidcProviderToCreate)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, storedOidcProvider, "Error in test case %v", n)
+
+			// Check OIDC Provider stored
+			oidcProvider, err := repo.GetOidcProviderByName(test.oidcProviderToCreate.Name)
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, oidcProvider, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestMemoryRepo_GetOidcProviderByName(t *testing.T) {
+	repo := &MemoryRepo{memoryStore: &memoryStore{
+		oidcProviders: []api.OidcProvider{
+			{ID: "OidcProviderID", Name: "Name"},
+		},
+	}}
+
+	_, err := repo.GetOidcProviderByName("OtherName")
+	dbError, _ := err.(*database.Error)
+	assert.Equal(t, &database.Error{
+		Code:    database.AUTH_OIDC_PROVIDER_NOT_FOUND,
+		Message: "OIDC Provider with name OtherName not found",
+	}, dbError, "Error getting OIDC provider")
+}
+
+func TestMemoryRepo_GetOidcProvidersFiltered(t *testing.T) {
+	oidcProvider1 := api.OidcProvider{ID: "OidcProviderID1", Name: "b", Path: "/path/", OidcClients: []api.OidcClient{}}
+	oidcProvider2 := api.OidcProvider{ID: "OidcProviderID2", Name: "a", Path: "/other/", OidcClients: []api.OidcClient{}}
+	testcases := map[string]struct {
+		// Memory Repo Args
+		filter *api.Filter
+		// Expected result
+		expectedResponse []api.OidcProvider
+		expectedTotal    int
+	}{
+		"OkCasePathPrefix": {
+			filter:           &api.Filter{PathPrefix: "/path/"},
+			expectedResponse: []api.OidcProvider{oidcProvider1},
+			expectedTotal:    1,
+		},
+		"OkCaseOrderBy": {
+			filter:           &api.Filter{OrderBy: "name asc"},
+			expectedResponse: []api.OidcProvider{oidcProvider2, oidcProvider1},
+			expectedTotal:    2,
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{oidcProviders: []api.OidcProvider{oidcProvider1, oidcProvider2}}}
+
+		oidcProviders, total, err := repo.GetOidcProvidersFiltered(test.filter)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedTotal, total, "Error in test case %v", n)
+		assert.Equal(t, test.expectedResponse, oidcProviders, "Error in test case %v", n)
+	}
+}
+
+func TestMemoryRepo_UpdateOidcProvider(t *testing.T) {
+	repo := &MemoryRepo{memoryStore: &memoryStore{
+		oidcProviders: []api.OidcProvider{
+			{ID: "OidcProviderID", Name: "Name", OidcClients: []api.OidcClient{{Name: "client1"}}},
+		},
+	}}
+	oidcProviderToUpdate := api.OidcProvider{
+		ID:          "OidcProviderID",
+		Name:        "NewName",
+		OidcClients: []api.OidcClient{{Name: "client2"}},
+	}
+
+	updatedOidcProvider, err := repo.UpdateOidcProvider(oidcProviderToUpdate, time.Time{})
+	assert.Nil(t, err, "Error updating OIDC provider")
+	assert.Equal(t, &oidcProviderToUpdate, updatedOidcProvider, "Error updating OIDC provider")
+
+	oidcProvider, err := repo.GetOidcProviderByName("NewName")
+	assert.Nil(t, err, "Error updating OIDC provider")
+	assert.Equal(t, &oidcProviderToUpdate, oidcProvider, "Error updating OIDC provider")
+}
+
+func TestMemoryRepo_RemoveOidcProvider(t *testing.T) {
+	repo := &MemoryRepo{memoryStore: &memoryStore{
+		oidcProviders: []api.OidcProvider{
+			{ID: "OidcProviderID1"},
+			{ID: "OidcProviderID2"},
+		},
+	}}
+
+	err := repo.RemoveOidcProvider("OidcProviderID1")
+	assert.Nil(t, err, "Error removing OIDC provider")
+	assert.Equal(t, []api.OidcProvider{{ID: "OidcProviderID2"}}, repo.oidcProviders, "Error removing OIDC provider")
+}
@@ -0,0 +1,508 @@
+package memory
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+)
+
+// GROUP REPOSITORY IMPLEMENTATION
+
+func (mr *MemoryRepo) AddGroup(group api.Group) (*api.Group, error) {
+	mr.lock()
+	defer mr.unlock()
+
+	// Check unique keys
+	for _, g := range mr.groups {
+		switch {
+		case g.ID == group.ID:
+			return nil, duplicatedKeyError("group", group.ID)
+		case g.Urn == group.Urn:
+			return nil, duplicatedKeyError("group", group.Urn)
+		}
+	}
+
+	// Store group
+	groupDB := storedGroup(group)
+	mr.groups = append(mr.groups, groupDB)
+
+	return &groupDB, nil
+}
+
+func (mr *MemoryRepo) GetGroupByName(org string, name string) (*api.Group, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	for _, g := range mr.groups {
+		if g.Org == org && g.Name == name {
+			group := g
+			return &group, nil
+		}
+	}
+
+	return nil, &database.Error{
+		Code:    database.GROUP_NOT_FOUND,
+		Message: fmt.Sprintf("Group with organization %v and name %v not found", org, name),
+	}
+}
+
+func (mr *MemoryRepo) GetGroupById(id string) (*api.Group, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	return mr.getGroupByID(id)
+}
+
+func (mr *MemoryRepo) GetGroupsFiltered(filter *api.Filter) ([]api.Group, int, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	groups := []api.Group{}
+	for _, g := range mr.groups {
+		if (len(filter.Org) < 1 || g.Org == filter.Org) && strings.HasPrefix(g.Path, filter.PathPrefix) {
+			groups = append(groups, g)
+		}
+	}
+	sortByColumn(groups, filter.OrderBy, func(i int, column string) interface{} {
+		return groupColumn(&groups[i], column)
+	})
+
+	start, end := pageBounds(len(groups), filter)
+	return groups[start:end], len(groups), nil
+}
+
+func (mr *MemoryRepo) UpdateGroup(group api.Group, oldUpdateAt time.Time) (*api.Group, error) {
+	mr.lock()
+	defer mr.unlock()
+
+	for i, g := range mr.groups {
+		if g.ID == group.ID {
+			if !g.UpdateAt.Equal(oldUpdateAt) {
+				return nil, versionConflictError("Group", group.ID)
+			}
+			mr.groups[i] = storedGroup(group)
+			return &group, nil
+		}
+	}
+
+	return nil, &database.Error{
+		Code:    database.GROUP_NOT_FOUND,
+		Message: fmt.Sprintf("Group with name %v not found", group.Name),
+	}
+}
+
+func (mr *MemoryRepo) RemoveGroup(id string) error {
+	mr.lock()
+	defer mr.unlock()
+
+	// Delete group
+	groups := []api.Group{}
+	for _, g := range mr.groups {
+		if g.ID != id {
+			groups = append(groups, g)
+		}
+	}
+	mr.groups = groups
+
+	// Delete all group relations
+	mr.removeGroupUserRelations(func(r groupUserRelation) bool {
+		return r.groupID == id
+	})
+
+	// Delete all policy relations
+	mr.removeGroupPolicyRelations(func(r groupPolicyRelation) bool {
+		return r.groupID == id
+	})
+
+	// Delete all parent and child group relations
+	mr.removeGroupGroupRelations(func(r groupGroupRelation) bool {
+		return r.parentID == id || r.childID == id
+	})
+
+	return nil
+}
+
+func (mr *MemoryRepo) AddMember(userID string, groupID string, expiresAt *time.Time) error {
+	mr.lock()
+	defer mr.unlock()
+
+	// Check unique keys
+	for _, r := range mr.groupUserRelations {
+		if r.userID == userID && r.groupID == groupID {
+			return duplicatedKeyError("group user relation", userID+"-"+groupID)
+		}
+	}
+
+	// Store relation
+	mr.groupUserRelations = append(mr.groupUserRelations, groupUserRelation{
+		userID:    userID,
+		groupID:   groupID,
+		createAt:  storedTime(time.Now()),
+		expiresAt: storedExpiration(expiresAt),
+	})
+
+	return nil
+}
+
+func (mr *MemoryRepo) RemoveMember(userID string, groupID string) error {
+	mr.lock()
+	defer mr.unlock()
+
+	mr.removeGroupUserRelations(func(r groupUserRelation) bool {
+		return r.userID == userID && r.groupID == groupID
+	})
+
+	return nil
+}
+
+func (mr *MemoryRepo) IsMemberOfGroup(userID string, groupID string) (bool, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	for _, r := range mr.groupUserRelations {
+		if r.userID == userID && r.groupID == groupID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (mr *MemoryRepo) GetGroupMembers(groupID string, filter *api.Filter) ([]api.UserGroupRelation, int, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	relations := []groupUserRelation{}
+	for _, r := range mr.groupUserRelations {
+		if r.groupID == groupID {
+			relations = append(relations, r)
+		}
+	}
+	sortByColumn(relations, filter.OrderBy, func(i int, column string) interface{} {
+		return relations[i].createAt
+	})
+
+	start, end := pageBounds(len(relations), filter)
+	members := make([]api.UserGroupRelation, 0, end-start)
+	// Transform relations to API domain
+	for _, r := range relations[start:end] {
+		user, err := mr.getUserByID(r.userID)
+		// Error handling
+		if err != nil {
+			return nil, len(relations), &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: err.Error(),
+			}
+		}
+		members = append(members, &GroupUser{
+			User:      user,
+			CreateAt:  r.createAt,
+			ExpiresAt: r.expiresAt,
+		})
+	}
+
+	return members, len(relations), nil
+}
+
+func (mr *MemoryRepo) AttachPolicy(groupID string, policyID string, expiresAt *time.Time) error {
+	mr.lock()
+	defer mr.unlock()
+
+	// Check unique keys
+	for _, r := range mr.groupPolicyRelations {
+		if r.groupID == groupID && r.policyID == policyID {
+			return duplicatedKeyError("group policy relation", groupID+"-"+policyID)
+		}
+	}
+
+	// Store relation
+	mr.groupPolicyRelations = append(mr.groupPolicyRelations, groupPolicyRelation{
+		groupID:   groupID,
+		policyID:  policyID,
+		createAt:  storedTime(time.Now()),
+		expiresAt: storedExpiration(expiresAt),
+	})
+
+	return nil
+}
+
+func (mr *MemoryRepo) DetachPolicy(groupID string, policyID string) error {
+	mr.lock()
+	defer mr.unlock()
+
+	mr.removeGroupPolicyRelations(func(r groupPolicyRelation) bool {
+		return r.groupID == groupID && r.policyID == policyID
+	})
+
+	return nil
+}
+
+func (mr *MemoryRepo) IsAttachedToGroup(groupID string, policyID string) (bool, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	for _, r := range mr.groupPolicyRelations {
+		if r.groupID == groupID && r.policyID == policyID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (mr *MemoryRepo) GetAttachedPolicies(groupID string, filter *api.Filter) ([]api.PolicyGroupRelation, int, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	relations := []groupPolicyRelation{}
+	for _, r := range mr.groupPolicyRelations {
+		if r.groupID == groupID {
+			relations = append(relations, r)
+		}
+	}
+	sortByColumn(relations, filter.OrderBy, func(i int, column string) interface{} {
+		return relations[i].createAt
+	})
+
+	start, end := pageBounds(len(relations), filter)
+	policies := make([]api.PolicyGroupRelation, 0, end-start)
+	// Transform relations to API domain
+	for _, r := range relations[start:end] {
+		policy, err := mr.getPolicyByID(r.policyID)
+		// Error handling
+		if err != nil {
+			return nil, len(relations), &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: err.Error(),
+			}
+		}
+		policies = append(policies, &PolicyGroup{
+			Policy:    policy,
+			CreateAt:  r.createAt,
+			ExpiresAt: r.expiresAt,
+		})
+	}
+
+	return policies, len(relations), nil
+}
+
+func (mr *MemoryRepo) AddChildGroup(childID string, parentID string) error {
+	mr.lock()
+	defer mr.unlock()
+
+	// Check unique keys
+	for _, r := range mr.groupGroupRelations {
+		if r.childID == childID && r.parentID == parentID {
+			return duplicatedKeyError("group group relation", parentID+"-"+childID)
+		}
+	}
+
+	// Store relation
+	mr.groupGroupRelations = append(mr.groupGroupRelations, groupGroupRelation{
+		parentID: parentID,
+		childID:  childID,
+		createAt: storedTime(time.Now()),
+	})
+
+	return nil
+}
+
+func (mr *MemoryRepo) RemoveChildGroup(childID string, parentID string) error {
+	mr.lock()
+	defer mr.unlock()
+
+	mr.removeGroupGroupRelations(func(r groupGroupRelation) bool {
+		return r.childID == childID && r.parentID == parentID
+	})
+
+	return nil
+}
+
+func (mr *MemoryRepo) IsChildOfGroup(childID string, parentID string) (bool, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	for _, r := range mr.groupGroupRelations {
+		if r.childID == childID && r.parentID == parentID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (mr *MemoryRepo) GetChildGroups(parentID string, filter *api.Filter) ([]api.GroupGroupRelation, int, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	relations := []groupGroupRelation{}
+	for _, r := range mr.groupGroupRelations {
+		if r.parentID == parentID {
+			relations = append(relations, r)
+		}
+	}
+	sortByColumn(relations, filter.OrderBy, func(i int, column string) interface{} {
+		return relations[i].createAt
+	})
+
+	start, end := pageBounds(len(relations), filter)
+	children := make([]api.GroupGroupRelation, 0, end-start)
+	// Transform relations to API domain
+	for _, r := range relations[start:end] {
+		group, err := mr.getGroupByID(r.childID)
+		// Error handling
+		if err != nil {
+			return nil, len(relations), &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: err.Error(),
+			}
+		}
+		children = append(children, &GroupGroup{
+			Child:    group,
+			CreateAt: r.createAt,
+		})
+	}
+
+	return children, len(relations), nil
+}
+
+func (mr *MemoryRepo) GetAncestorGroups(groupIDs []string) ([]api.Group, error) {
+	if len(groupIDs) < 1 {
+		return nil, nil
+	}
+
+	mr.rLock()
+	defer mr.rUnlock()
+
+	// Resolve all transitive parents. Visited groups aren't expanded again,
+	// so it ends even if the relations have a cycle.
+	ancestors := map[string]bool{}
+	pending := groupIDs
+	for len(pending) > 0 {
+		next := []string{}
+		for _, id := range pending {
+			for _, r := range mr.groupGroupRelations {
+				if r.childID == id && !ancestors[r.parentID] {
+					ancestors[r.parentID] = true
+					next = append(next, r.parentID)
+				}
+			}
+		}
+		pending = next
+	}
+
+	groups := []api.Group{}
+	for _, g := range mr.groups {
+		if ancestors[g.ID] {
+			groups = append(groups, g)
+		}
+	}
+	sortByColumn(groups, "create_at", func(i int, column string) interface{} {
+		return groupColumn(&groups[i], column)
+	})
+
+	return groups, nil
+}
+
+func (mr *MemoryRepo) RemoveExpiredRelations(date time.Time) (int, error) {
+	mr.lock()
+	defer mr.unlock()
+
+	// Delete expired memberships
+	members := mr.removeGroupUserRelations(func(r groupUserRelation) bool {
+		return r.expiresAt != nil && !r.expiresAt.After(date)
+	})
+
+	// Delete expired policy attachments
+	policies := mr.removeGroupPolicyRelations(func(r groupPolicyRelation) bool {
+		return r.expiresAt != nil && !r.expiresAt.After(date)
+	})
+
+	return members + policies, nil
+}
+
+// PRIVATE HELPER METHODS
+
+// Retrieve a group by its id. Caller must hold the lock
+func (mr *MemoryRepo) getGroupByID(id string) (*api.Group, error) {
+	for _, g := range mr.groups {
+		if g.ID == id {
+			group := g
+			return &group, nil
+		}
+	}
+
+	return nil, &database.Error{
+		Code:    database.GROUP_NOT_FOUND,
+		Message: fmt.Sprintf("Group with id %v not found", id),
+	}
+}
+
+// Delete the group user relations that match. Caller must hold the lock
+func (mr *MemoryRepo) removeGroupUserRelations(match func(r groupUserRelation) bool) int {
+	relations := []groupUserRelation{}
+	for _, r := range mr.groupUserRelations {
+		if !match(r) {
+			relations = append(relations, r)
+		}
+	}
+	removed := len(mr.groupUserRelations) - len(relations)
+	mr.groupUserRelations = relations
+	return removed
+}
+
+// Delete the group policy relations that match. Caller must hold the lock
+func (mr *MemoryRepo) removeGroupPolicyRelations(match func(r groupPolicyRelation) bool) int {
+	relations := []groupPolicyRelation{}
+	for _, r := range mr.groupPolicyRelations {
+		if !match(r) {
+			relations = append(relations, r)
+		}
+	}
+	removed := len(mr.groupPolicyRelations) - len(relations)
+	mr.groupPolicyRelations = relations
+	return removed
+}
+
+// Delete the group group relations that match. Caller must hold the lock
+func (mr *MemoryRepo) removeGroupGroupRelations(match func(r groupGroupRelation) bool) int {
+	relations := []groupGroupRelation{}
+	for _, r := range mr.groupGroupRelations {
+		if !match(r) {
+			relations = append(relations, r)
+		}
+	}
+	removed := len(mr.groupGroupRelations) - len(relations)
+	mr.groupGroupRelations = relations
+	return removed
+}
+
+// Transform a group for API into the group stored
+func storedGroup(group api.Group) api.Group {
+	group.CreateAt = storedTime(group.CreateAt)
+	group.UpdateAt = storedTime(group.UpdateAt)
+	return group
+}
+
+// Column value of a group used to sort them
+func groupColumn(group *api.Group, column string) interface{} {
+	switch column {
+	case "name":
+		return group.Name
+	case "path":
+		return group.Path
+	case "org":
+		return group.Org
+	case "create_at":
+		return group.CreateAt
+	case "update_at":
+		return group.UpdateAt
+	case "urn":
+		return group.Urn
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,480 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRepo_AddGroup(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousGroups []api.Group
+		// Memory Repo Args
+		groupToCreate *api.Group
+		// Expected result
+		expectedResponse *api.Group
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			groupToCreate: &api.Group{
+				ID:       "GroupID",
+				Name:     "Name",
+				Path:     "Path",
+				Urn:      "urn",
+				CreateAt: now,
+				UpdateAt: now,
+				Org:      "Org",
+			},
+			expectedResponse: &api.Group{
+				ID:       "GroupID",
+				Name:     "Name",
+				Path:     "Path",
+				Urn:      "urn",
+				CreateAt: now,
+				UpdateAt: now,
+				Org:      "Org",
+			},
+		},
+		"ErrorCaseGroupAlreadyExist": {
+			previousGroups: []api.Group{
+				{ID: "OtherGroupID", Urn: "urn"},
+			},
+			groupToCreate: &api.Group{
+				ID:   "GroupID",
+				Name: "Name",
+				Urn:  "urn",
+				Org:  "Org",
+			},
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Duplicated key urn for group",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{groups: test.previousGroups}}
+
+		storedGroup, err := repo.AddGroup(*test.groupToCreate)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, storedGroup, "Error in test case %v", n)
+
+			// Check group stored
+			group, err := repo.GetGroupByName(test.groupToCreate.Org, test.groupToCreate.Name)
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, group, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestMemoryRepo_GetGroupByName(t *testing.T) {
+	testcases := map[string]struct {
+		// Previous data
+		previousGroups []api.Group
+		// Memory Repo Args
+		org  string
+		name string
+		// Expected result
+		expectedResponse *api.Group
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			previousGroups: []api.Group{
+				{ID: "GroupID1", Name: "Name", Org: "Org1"},
+				{ID: "GroupID2", Name: "Name", Org: "Org2"},
+			},
+			org:              "Org2",
+			name:             "Name",
+			expectedResponse: &api.Group{ID: "GroupID2", Name: "Name", Org: "Org2"},
+		},
+		"ErrorCaseGroupNotExist": {
+			previousGroups: []api.Group{
+				{ID: "GroupID1", Name: "Name", Org: "Org1"},
+			},
+			org:  "Org2",
+			name: "Name",
+			expectedError: &database.Error{
+				Code:    database.GROUP_NOT_FOUND,
+				Message: "Group with organization Org2 and name Name not found",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{groups: test.previousGroups}}
+
+		group, err := repo.GetGroupByName(test.org, test.name)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, group, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestMemoryRepo_GetGroupsFiltered(t *testing.T) {
+	group1 := api.Group{ID: "GroupID1", Name: "b", Path: "/path/", Org: "Org1"}
+	group2 := api.Group{ID: "GroupID2", Name: "a", Path: "/path/", Org: "Org2"}
+	group3 := api.Group{ID: "GroupID3", Name: "c", Path: "/other/", Org: "Org1"}
+	testcases := map[string]struct {
+		// Memory Repo Args
+		filter *api.Filter
+		// Expected result
+		expectedResponse []api.Group
+		expectedTotal    int
+	}{
+		"OkCaseOrg": {
+			filter:           &api.Filter{Org: "Org1"},
+			expectedResponse: []api.Group{group1, group3},
+			expectedTotal:    2,
+		},
+		"OkCasePathPrefix": {
+			filter:           &api.Filter{PathPrefix: "/path/"},
+			expectedResponse: []api.Group{group1, group2},
+			expectedTotal:    2,
+		},
+		"OkCaseOrderBy": {
+			filter:           &api.Filter{OrderBy: "name asc"},
+			expectedResponse: []api.Group{group2, group1, group3},
+			expectedTotal:    3,
+		},
+		"OkCasePagination": {
+			filter:           &api.Filter{Offset: 2, Limit: 20},
+			expectedResponse: []api.Group{group3},
+			expectedTotal:    3,
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{groups: []api.Group{group1, group2, group3}}}
+
+		groups, total, err := repo.GetGroupsFiltered(test.filter)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedTotal, total, "Error in test case %v", n)
+		assert.Equal(t, test.expectedResponse, groups, "Error in test case %v", n)
+	}
+}
+
+func TestMemoryRepo_UpdateGroup(t *testing.T) {
+	testcases := map[string]struct {
+		// Previous data
+		previousGroups []api.Group
+		// Memory Repo Args
+		groupToUpdate *api.Group
+		// Expected result
+		expectedError *database.Error
+	}{
+		"OkCase": {
+			previousGroups: []api.Group{
+				{ID: "GroupID", Name: "Name", Org: "Org"},
+			},
+			groupToUpdate: &api.Group{ID: "GroupID", Name: "NewName", Org: "Org"},
+		},
+		"ErrorCaseGroupNotExist": {
+			groupToUpdate: &api.Group{ID: "GroupID", Name: "NewName", Org: "Org"},
+			expectedError: &database.Error{
+				Code:    database.GROUP_NOT_FOUND,
+				Message: "Group with name NewName not found",
+			},
+		},
+		"ErrorCaseVersionConflict": {
+			previousGroups: []api.Group{
+				{ID: "GroupID", Name: "Name", Org: "Org", UpdateAt: time.Now().UTC()},
+			},
+			groupToUpdate: &api.Group{ID: "GroupID", Name: "NewName", Org: "Org"},
+			expectedError: &database.Error{
+				Code:    database.VERSION_CONFLICT,
+				Message: "Group with id GroupID was modified or removed by another request",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{groups: test.previousGroups}}
+
+		updatedGroup, err := repo.UpdateGroup(*test.groupToUpdate, time.Time{})
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.groupToUpdate, updatedGroup, "Error in test case %v", n)
+			group, err := repo.GetGroupById(test.groupToUpdate.ID)
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.groupToUpdate, group, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestMemoryRepo_RemoveGroup(t *testing.T) {
+	repo := &MemoryRepo{memoryStore: &memoryStore{
+		groups: []api.Group{
+			{ID: "GroupID1"},
+			{ID: "GroupID2"},
+		},
+		groupUserRelations: []groupUserRelation{
+			{userID: "UserID", groupID: "GroupID1"},
+			{userID: "UserID", groupID: "GroupID2"},
+		},
+		groupPolicyRelations: []groupPolicyRelation{
+			{groupID: "GroupID1", policyID: "PolicyID"},
+			{groupID: "GroupID2", policyID: "PolicyID"},
+		},
+		groupGroupRelations: []groupGroupRelation{
+			{parentID: "GroupID1", childID: "GroupID2"},
+			{parentID: "GroupID2", childID: "GroupID3"},
+			{parentID: "GroupID3", childID: "GroupID1"},
+		},
+	}}
+
+	err := repo.RemoveGroup("GroupID1")
+	assert.Nil(t, err, "Error removing group")
+
+	// Check group and its relations were removed
+	assert.Equal(t, []api.Group{{ID: "GroupID2"}}, repo.groups, "Error removing group")
+	assert.Equal(t, []groupUserRelation{{userID: "UserID", groupID: "GroupID2"}}, repo.groupUserRelations,
+		"Error removing group members")
+	assert.Equal(t, []groupPolicyRelation{{groupID: "GroupID2", policyID: "PolicyID"}}, repo.groupPolicyRelations,
+		"Error removing group policies")
+	assert.Equal(t, []groupGroupRelation{{parentID: "GroupID2", childID: "GroupID3"}}, repo.groupGroupRelations,
+		"Error removing group parent and child relations")
+}
+
+func TestMemoryRepo_AddMember(t *testing.T) {
+	expiresAt := time.Now().UTC().Add(time.Hour)
+	testcases := map[string]struct {
+		// Previous data
+		previousRelations []groupUserRelation
+		// Memory Repo Args
+		expiresAt *time.Time
+		// Expected result
+		expectedError *database.Error
+	}{
+		"OkCase": {},
+		"OkCaseExpiring": {
+			expiresAt: &expiresAt,
+		},
+		"ErrorCaseAlreadyMember": {
+			previousRelations: []groupUserRelation{
+				{userID: "UserID", groupID: "GroupID"},
+			},
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Duplicated key UserID-GroupID for group user relation",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{
+			users:              []api.User{{ID: "UserID"}},
+			groupUserRelations: test.previousRelations,
+		}}
+
+		err := repo.AddMember("UserID", "GroupID", test.expiresAt)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			members, total, err := repo.GetGroupMembers("GroupID", &api.Filter{})
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, 1, total, "Error in test case %v", n)
+			assert.Equal(t, &api.User{ID: "UserID"}, members[0].GetUser(), "Error in test case %v", n)
+			assert.Equal(t, test.expiresAt, members[0].GetExpiresAt(), "Error in test case %v", n)
+		}
+	}
+}
+
+func TestMemoryRepo_RemoveMember(t *testing.T) {
+	repo := &MemoryRepo{memoryStore: &memoryStore{
+		groupUserRelations: []groupUserRelation{
+			{userID: "UserID1", groupID: "GroupID"},
+			{userID: "UserID2", groupID: "GroupID"},
+		},
+	}}
+
+	err := repo.RemoveMember("UserID1", "GroupID")
+	assert.Nil(t, err, "Error removing member")
+
+	isMember, _ := repo.IsMemberOfGroup("UserID1", "GroupID")
+	assert.False(t, isMember, "Error removing member")
+	isMember, _ = repo.IsMemberOfGroup("UserID2", "GroupID")
+	assert.True(t, isMember, "Error removing member")
+}
+
+func TestMemoryRepo_GetAttachedPolicies(t *testing.T) {
+	now := time.Now().UTC()
+	statements := &[]api.Statement{
+		{
+			Effect:    "allow",
+			Actions:   []string{"iam:*"},
+			Resources: []string{"urn:everything:*"},
+		},
+	}
+	testcases := map[string]struct {
+		// Previous data
+		previousRelations []groupPolicyRelation
+		// Memory Repo Args
+		filter *api.Filter
+		// Expected result
+		expectedResponse []api.PolicyGroupRelation
+		expectedTotal    int
+	}{
+		"OkCase": {
+			previousRelations: []groupPolicyRelation{
+				{groupID: "GroupID", policyID: "PolicyID1", createAt: now},
+				{groupID: "GroupID", policyID: "PolicyID2", createAt: now.Add(time.Second)},
+			},
+			filter: &api.Filter{Limit: 1},
+			expectedResponse: []api.PolicyGroupRelation{
+				&PolicyGroup{
+					Policy:   &api.Policy{ID: "PolicyID1", Statements: statements},
+					CreateAt: now,
+				},
+			},
+			expectedTotal: 2,
+		},
+		"OkCaseOrderBy": {
+			previousRelations: []groupPolicyRelation{
+				{groupID: "GroupID", policyID: "PolicyID1", createAt: now},
+				{groupID: "GroupID", policyID: "PolicyID2", createAt: now.Add(time.Second)},
+			},
+			filter: &api.Filter{Limit: 1, OrderBy: "create_at desc"},
+			expectedResponse: []api.PolicyGroupRelation{
+				&PolicyGroup{
+					Policy:   &api.Policy{ID: "PolicyID2", Statements: statements},
+					CreateAt: now.Add(time.Second),
+				},
+			},
+			expectedTotal: 2,
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{
+			policies: []api.Policy{
+				{ID: "PolicyID1", Statements: statements},
+				{ID: "PolicyID2", Statements: statements},
+			},
+			groupPolicyRelations: test.previousRelations,
+		}}
+
+		policies, total, err := repo.GetAttachedPolicies("GroupID", test.filter)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedTotal, total, "Error in test case %v", n)
+		assert.Equal(t, test.expectedResponse, policies, "Error in test case %v", n)
+	}
+}
+
+func TestMemoryRepo_GetChildGroups(t *testing.T) {
+	now := time.Now().UTC()
+	repo := &MemoryRepo{memoryStore: &memoryStore{
+		groups: []api.Group{
+			{ID: "ParentID"},
+			{ID: "ChildID"},
+		},
+		groupGroupRelations: []groupGroupRelation{
+			{parentID: "ParentID", childID: "ChildID", createAt: now},
+		},
+	}}
+
+	children, total, err := repo.GetChildGroups("ParentID", &api.Filter{})
+	assert.Nil(t, err, "Error getting child groups")
+	assert.Equal(t, 1, total, "Error getting child groups")
+	assert.Equal(t, []api.GroupGroupRelation{
+		&GroupGroup{
+			Child:    &api.Group{ID: "ChildID"},
+			CreateAt: now,
+		},
+	}, children, "Error getting child groups")
+
+	isChild, _ := repo.IsChildOfGroup("ChildID", "ParentID")
+	assert.True(t, isChild, "Error getting child groups")
+}
+
+func TestMemoryRepo_GetAncestorGroups(t *testing.T) {
+	now := time.Now().UTC()
+	grandparent := api.Group{ID: "GrandparentID", CreateAt: now}
+	parent := api.Group{ID: "ParentID", CreateAt: now.Add(time.Second)}
+	testcases := map[string]struct {
+		// Previous data
+		previousRelations []groupGroupRelation
+		// Memory Repo Args
+		groupIDs []string
+		// Expected result
+		expectedResponse []api.Group
+	}{
+		"OkCaseTransitive": {
+			previousRelations: []groupGroupRelation{
+				{parentID: "ParentID", childID: "ChildID"},
+				{parentID: "GrandparentID", childID: "ParentID"},
+			},
+			groupIDs:         []string{"ChildID"},
+			expectedResponse: []api.Group{grandparent, parent},
+		},
+		"OkCaseCycle": {
+			previousRelations: []groupGroupRelation{
+				{parentID: "ParentID", childID: "GrandparentID"},
+				{parentID: "GrandparentID", childID: "ParentID"},
+			},
+			groupIDs:         []string{"ParentID"},
+			expectedResponse: []api.Group{grandparent, parent},
+		},
+		"OkCaseNoAncestors": {
+			groupIDs:         []string{"ChildID"},
+			expectedResponse: []api.Group{},
+		},
+		"OkCaseNoGroups": {},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{
+			groups:              []api.Group{parent, grandparent, {ID: "ChildID"}},
+			groupGroupRelations: test.previousRelations,
+		}}
+
+		groups, err := repo.GetAncestorGroups(test.groupIDs)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedResponse, groups, "Error in test case %v", n)
+	}
+}
+
+func TestMemoryRepo_RemoveExpiredRelations(t *testing.T) {
+	now := time.Now().UTC()
+	expired := now.Add(-time.Hour)
+	notExpired := now.Add(time.Hour)
+	repo := &MemoryRepo{memoryStore: &memoryStore{
+		groupUserRelations: []groupUserRelation{
+			{userID: "UserID1", groupID: "GroupID", expiresAt: &expired},
+			{userID: "UserID2", groupID: "GroupID", expiresAt: &notExpired},
+			{userID: "UserID3", groupID: "GroupID"},
+		},
+		groupPolicyRelations: []groupPolicyRelation{
+			{groupID: "GroupID", policyID: "PolicyID1", expiresAt: &expired},
+			{groupID: "GroupID", policyID: "PolicyID2"},
+		},
+	}}
+
+	removed, err := repo.RemoveExpiredRelations(now)
+	assert.Nil(t, err, "Error removing expired relations")
+	assert.Equal(t, 2, removed, "Error removing expired relations")
+	assert.Equal(t, []groupUserRelation{
+		{userID: "UserID2", groupID: "GroupID", expiresAt: &notExpired},
+		{userID: "UserID3", groupID: "GroupID"},
+	}, repo.groupUserRelations, "Error removing expired memberships")
+	assert.Equal(t, []groupPolicyRelation{
+		{groupID: "GroupID", policyID: "PolicyID2"},
+	}, repo.groupPolicyRelations, "Error removing expired policy attachments")
+}
@@ -0,0 +1,329 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+)
+
+// MemoryRepo stores all entities in memory. It is meant for local development and integration tests,
+// so data is lost when the process ends and it isn't shared between processes.
+type MemoryRepo struct {
+	*memoryStore
+
+	// True when the repository is bound to a transaction started by WithTx, that already holds the store lock
+	inTx bool
+}
+
+// memoryStore holds the data shared by a repository and the repositories bound to its transactions
+type memoryStore struct {
+	mutex sync.RWMutex
+
+	// Entities in insertion order
+	users          []api.User
+	groups         []api.Group
+	policies       []api.Policy
+	proxyResources []api.ProxyResource
+	upstreamPools  []api.UpstreamPool
+	oidcProviders  []api.OidcProvider
+	webhooks       []api.Webhook
+
+	// Changes of the proxy config. Its revision is the number of changes plus 1, like in a new database
+	proxyChanges int64
+
+	// Relations in insertion order
+	groupUserRelations   []groupUserRelation
+	groupPolicyRelations []groupPolicyRelation
+	groupGroupRelations  []groupGroupRelation
+	userPolicyRelations  []userPolicyRelation
+
+	// Audit entries and webhook dead letters in insertion order
+	auditEntries       []api.AuditEntry
+	webhookDeadLetters []api.WebhookDeadLetter
+}
+
+// Group-Users Relationship. expiresAt is nil when the membership doesn't expire
+type groupUserRelation struct {
+	userID    string
+	groupID   string
+	createAt  time.Time
+	expiresAt *time.Time
+}
+
+// Group-Policies Relationship. expiresAt is nil when the attachment doesn't expire
+type groupPolicyRelation struct {
+	groupID   string
+	policyID  string
+	createAt  time.Time
+	expiresAt *time.Time
+}
+
+// Group-Groups Relationship
+type groupGroupRelation struct {
+	parentID string
+	childID  string
+	createAt time.Time
+}
+
+// User-Policies Relationship
+type userPolicyRelation struct {
+	userID   string
+	policyID string
+	createAt time.Time
+}
+
+// NewMemoryRepo returns an empty repository
+func NewMemoryRepo() *MemoryRepo {
+	return &MemoryRepo{memoryStore: &memoryStore{}}
+}
+
+// WithTx calls fn with a repository bound to the transaction, and restores all entities, relations, audit entries
+// and dead letters if fn returns an error. The store lock is held until the transaction ends, so transactions are
+// isolated from other reads and writes, and a rollback never discards changes made outside the transaction.
+func (mr *MemoryRepo) WithTx(fn func(repos api.TxRepos) error) error {
+	// Nested calls join the current transaction
+	if mr.inTx {
+		return fn(mr.txRepos())
+	}
+
+	mr.mutex.Lock()
+	defer mr.mutex.Unlock()
+
+	txRepo := &MemoryRepo{
+		memoryStore: mr.memoryStore,
+		inTx:        true,
+	}
+	snapshot := txRepo.snapshot()
+	if err := fn(txRepo.txRepos()); err != nil {
+		txRepo.restore(snapshot)
+		return err
+	}
+	return nil
+}
+
+func (mr *MemoryRepo) txRepos() api.TxRepos {
+	return api.TxRepos{
+		UserRepo:     mr,
+		GroupRepo:    mr,
+		PolicyRepo:   mr,
+		ProxyRepo:    mr,
+		AuthOidcRepo: mr,
+		AuditRepo:    mr,
+		WebhookRepo:  mr,
+	}
+}
+
+// Operations lock the store, unless they run in a transaction that already holds the lock
+
+func (mr *MemoryRepo) lock() {
+	if !mr.inTx {
+		mr.mutex.Lock()
+	}
+}
+
+func (mr *MemoryRepo) unlock() {
+	if !mr.inTx {
+		mr.mutex.Unlock()
+	}
+}
+
+func (mr *MemoryRepo) rLock() {
+	if !mr.inTx {
+		mr.mutex.RLock()
+	}
+}
+
+func (mr *MemoryRepo) rUnlock() {
+	if !mr.inTx {
+		mr.mutex.RUnlock()
+	}
+}
+
+func (mr *MemoryRepo) OrderByValidColumns(action string) []string {
+	switch action {
+	case api.USER_ACTION_LIST_USERS:
+		return []string{"path", "external_id", "create_at", "update_at", "urn"}
+	case api.USER_ACTION_LIST_GROUPS_FOR_USER:
+		return []string{"create_at"}
+	case api.USER_ACTION_LIST_ATTACHED_USER_POLICIES:
+		return []string{"create_at"}
+	case api.GROUP_ACTION_LIST_GROUPS:
+		return []string{"name", "path", "org", "create_at", "update_at", "urn"}
+	case api.GROUP_ACTION_LIST_MEMBERS:
+		return []string{"create_at"}
+	case api.GROUP_ACTION_LIST_ATTACHED_GROUP_POLICIES:
+		return []string{"create_at"}
+	case api.GROUP_ACTION_LIST_CHILD_GROUPS:
+		return []string{"create_at"}
+	case api.POLICY_ACTION_LIST_POLICIES:
+		return []string{"name", "path", "org", "create_at", "update_at", "urn"}
+	case api.POLICY_ACTION_LIST_ATTACHED_GROUPS:
+		return []string{"create_at"}
+	case api.PROXY_ACTION_LIST_RESOURCES:
+		return []string{"name", "path", "org", "host", "upstream", "path_resource", "method",
+			"urn_resource", "urn", "action", "match_host", "create_at", "update_at"}
+	case api.UPSTREAM_ACTION_LIST_POOLS:
+		return []string{"name", "path", "org", "urn", "balancer", "create_at", "update_at"}
+	case api.AUTH_OIDC_ACTION_LIST_PROVIDERS:
+		return []string{"name", "path", "create_at", "update_at", "urn"}
+	case api.AUDIT_ACTION_LIST_ENTRIES:
+		return []string{"actor", "action", "urn", "create_at"}
+	case api.WEBHOOK_ACTION_LIST_WEBHOOKS:
+		return []string{"name", "path", "create_at", "update_at", "urn"}
+	case api.WEBHOOK_ACTION_LIST_DEAD_LETTERS:
+		return []string{"attempts", "create_at"}
+	default:
+		return nil
+	}
+}
+
+// PRIVATE HELPER METHODS
+
+// sortByColumn sorts a slice by the column and direction in order ("column asc" or "column desc"),
+// keeping insertion order between equal values. Value returns the column value of the item in position i,
+// that must be a string, an int or a time.
+func sortByColumn(slice interface{}, order string, value func(i int, column string) interface{}) {
+	if len(order) < 1 {
+		return
+	}
+	fields := strings.Fields(order)
+	column := fields[0]
+	desc := len(fields) > 1 && strings.EqualFold(fields[1], "desc")
+
+	sort.SliceStable(slice, func(i, j int) bool {
+		if desc {
+			return less(value(j, column), value(i, column))
+		}
+		return less(value(i, column), value(j, column))
+	})
+}
+
+// less compares two column values of the same type
+func less(a interface{}, b interface{}) bool {
+	switch va := a.(type) {
+	case string:
+		vb, _ := b.(string)
+		return va < vb
+	case int:
+		vb, _ := b.(int)
+		return va < vb
+	case time.Time:
+		vb, _ := b.(time.Time)
+		return va.Before(vb)
+	default:
+		return false
+	}
+}
+
+// pageBounds returns the bounds of the page selected by filter offset and limit over total items.
+// All items from offset are selected if there isn't limit.
+func pageBounds(total int, filter *api.Filter) (int, int) {
+	start := filter.Offset
+	if start < 0 {
+		start = 0
+	}
+	if start > total {
+		start = total
+	}
+	end := total
+	if filter.Limit > 0 && start+filter.Limit < total {
+		end = start + filter.Limit
+	}
+	return start, end
+}
+
+// Transform a time into the value stored, with the same precision and location that a database returns
+func storedTime(date time.Time) time.Time {
+	return date.Round(0).UTC()
+}
+
+// Transform an optional expiration date into the value stored
+func storedExpiration(expiresAt *time.Time) *time.Time {
+	if expiresAt == nil {
+		return nil
+	}
+	date := storedTime(*expiresAt)
+	return &date
+}
+
+// Error returned when a stored entity or relation has the same key
+func duplicatedKeyError(entity string, key string) *database.Error {
+	return &database.Error{
+		Code:    database.INTERNAL_ERROR,
+		Message: fmt.Sprintf("Duplicated key %v for %v", key, entity),
+	}
+}
+
+// Error returned when a stored entity was modified or removed after the version being updated
+func versionConflictError(entity string, id string) *database.Error {
+	return &database.Error{
+		Code:    database.VERSION_CONFLICT,
+		Message: fmt.Sprintf("%v with id %v was modified or removed by another request", entity, id),
+	}
+}
+
+// Copy of the entities, relations, audit entries and dead letters that a transaction can change
+type repoSnapshot struct {
+	users                []api.User
+	groups               []api.Group
+	policies             []api.Policy
+	proxyResources       []api.ProxyResource
+	upstreamPools        []api.UpstreamPool
+	oidcProviders        []api.OidcProvider
+	webhooks             []api.Webhook
+	proxyChanges         int64
+	groupUserRelations   []groupUserRelation
+	groupPolicyRelations []groupPolicyRelation
+	groupGroupRelations  []groupGroupRelation
+	userPolicyRelations  []userPolicyRelation
+	auditEntries         []api.AuditEntry
+	webhookDeadLetters   []api.WebhookDeadLetter
+}
+
+// snapshot copies the slices, so later changes in the repository don't modify it
+func (mr *MemoryRepo) snapshot() repoSnapshot {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	return repoSnapshot{
+		users:                append([]api.User(nil), mr.users...),
+		groups:               append([]api.Group(nil), mr.groups...),
+		policies:             append([]api.Policy(nil), mr.policies...),
+		proxyResources:       append([]api.ProxyResource(nil), mr.proxyResources...),
+		upstreamPools:        append([]api.UpstreamPool(nil), mr.upstreamPools...),
+		oidcProviders:        append([]api.OidcProvider(nil), mr.oidcProviders...),
+		webhooks:             append([]api.Webhook(nil), mr.webhooks...),
+		proxyChanges:         mr.proxyChanges,
+		groupUserRelations:   append([]groupUserRelation(nil), mr.groupUserRelations...),
+		groupPolicyRelations: append([]groupPolicyRelation(nil), mr.groupPolicyRelations...),
+		groupGroupRelations:  append([]groupGroupRelation(nil), mr.groupGroupRelations...),
+		userPolicyRelations:  append([]userPolicyRelation(nil), mr.userPolicyRelations...),
+		auditEntries:         append([]api.AuditEntry(nil), mr.auditEntries...),
+		webhookDeadLetters:   append([]api.WebhookDeadLetter(nil), mr.webhookDeadLetters...),
+	}
+}
+
+func (mr *MemoryRepo) restore(snapshot repoSnapshot) {
+	mr.lock()
+	defer mr.unlock()
+
+	mr.users = snapshot.users
+	mr.groups = snapshot.groups
+	mr.policies = snapshot.policies
+	mr.proxyResources = snapshot.proxyResources
+	mr.upstreamPools = snapshot.upstreamPools
+	mr.oidcProviders = snapshot.oidcProviders
+	mr.webhooks = snapshot.webhooks
+	mr.proxyChanges = snapshot.proxyChanges
+	mr.groupUserRelations = snapshot.groupUserRelations
+	mr.groupPolicyRelations = snapshot.groupPolicyRelations
+	mr.groupGroupRelations = snapshot.groupGroupRelations
+	mr.userPolicyRelations = snapshot.userPolicyRelations
+	mr.auditEntries = snapshot.auditEntries
+	mr.webhookDeadLetters = snapshot.webhookDeadLetters
+}
@@ -0,0 +1,248 @@
+package memory
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/stretchr/testify/assert"
+)
+
+// MemoryRepo must implement all repositories
+var (
+	_ api.UserRepo     = NewMemoryRepo()
+	_ api.GroupRepo    = NewMemoryRepo()
+	_ api.PolicyRepo   = NewMemoryRepo()
+	_ api.ProxyRepo    = NewMemoryRepo()
+	_ api.AuthOidcRepo = NewMemoryRepo()
+	_ api.AuditRepo    = NewMemoryRepo()
+)
+
+func TestMemoryRepo_OrderByValidColumns(t *testing.T) {
+	testcases := map[string]struct {
+		action          string
+		expectedColumns []string
+	}{
+		"OkCaseAction-" + api.USER_ACTION_LIST_USERS: {
+			action:          api.USER_ACTION_LIST_USERS,
+			expectedColumns: []string{"path", "external_id", "create_at", "update_at", "urn"},
+		},
+		"OkCaseAction-" + api.USER_ACTION_LIST_GROUPS_FOR_USER: {
+			action:          api.USER_ACTION_LIST_GROUPS_FOR_USER,
+			expectedColumns: []string{"create_at"},
+		},
+		"OkCaseAction-" + api.GROUP_ACTION_LIST_GROUPS: {
+			action:          api.GROUP_ACTION_LIST_GROUPS,
+			expectedColumns: []string{"name", "path", "org", "create_at", "update_at", "urn"},
+		},
+		"OkCaseAction-" + api.POLICY_ACTION_LIST_POLICIES: {
+			action:          api.POLICY_ACTION_LIST_POLICIES,
+			expectedColumns: []string{"name", "path", "org", "create_at", "update_at", "urn"},
+		},
+		"OkCaseAction-" + api.PROXY_ACTION_LIST_RESOURCES: {
+			action: api.PROXY_ACTION_LIST_RESOURCES,
+			expectedColumns: []string{"name", "path", "org", "host", "upstream", "path_resource", "method",
+				"urn_resource", "urn", "action", "match_host", "create_at", "update_at"},
+		},
+		"OkCaseAction-" + api.UPSTREAM_ACTION_LIST_POOLS: {
+			action:          api.UPSTREAM_ACTION_LIST_POOLS,
+			expectedColumns: []string{"name", "path", "org", "urn", "balancer", "create_at", "update_at"},
+		},
+		"OkCaseAction-" + api.AUTH_OIDC_ACTION_LIST_PROVIDERS: {
+			action:          api.AUTH_OIDC_ACTION_LIST_PROVIDERS,
+			expectedColumns: []string{"name", "path", "create_at", "update_at", "urn"},
+		},
+		"OkCaseAction-" + api.AUDIT_ACTION_LIST_ENTRIES: {
+			action:          api.AUDIT_ACTION_LIST_ENTRIES,
+			expectedColumns: []string{"actor", "action", "urn", "create_at"},
+		},
+		"OkCaseAction-" + api.WEBHOOK_ACTION_LIST_WEBHOOKS: {
+			action:          api.WEBHOOK_ACTION_LIST_WEBHOOKS,
+			expectedColumns: []string{"name", "path", "create_at", "update_at", "urn"},
+		},
+		"OkCaseAction-" + api.WEBHOOK_ACTION_LIST_DEAD_LETTERS: {
+			action:          api.WEBHOOK_ACTION_LIST_DEAD_LETTERS,
+			expectedColumns: []string{"attempts", "create_at"},
+		},
+		"OkCaseOtherActions": {
+			action:          "other",
+			expectedColumns: nil,
+		},
+	}
+
+	for n, test := range testcases {
+		validColumns := NewMemoryRepo().OrderByValidColumns(test.action)
+		assert.Equal(t, test.expectedColumns, validColumns, "Error in test case %v", n)
+	}
+}
+
+func TestMemoryRepo_WithTx(t *testing.T) {
+	testcases := map[string]struct {
+		// Error returned after removing the user
+		txErr error
+		// Expected result
+		expectedError        error
+		expectedUser         bool
+		expectedMember       bool
+		expectedAuditEntries int
+	}{
+		"OkCaseCommit": {
+			expectedUser:         false,
+			expectedMember:       false,
+			expectedAuditEntries: 1,
+		},
+		"ErrorCaseRollback": {
+			txErr:                errors.New("Failure"),
+			expectedError:        errors.New("Failure"),
+			expectedUser:         true,
+			expectedMember:       true,
+			expectedAuditEntries: 0,
+		},
+	}
+
+	for n, test := range testcases {
+		repo := NewMemoryRepo()
+		_, err := repo.AddUser(api.User{ID: "UserID", ExternalID: "ExternalID", Path: "/path/", Urn: "urn:user"})
+		assert.Nil(t, err, "Error in test case %v", n)
+		_, err = repo.AddGroup(api.Group{ID: "GroupID", Name: "Name", Org: "Org", Path: "/path/", Urn: "urn:group"})
+		assert.Nil(t, err, "Error in test case %v", n)
+		err = repo.AddMember("UserID", "GroupID", nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		err = repo.WithTx(func(repos api.TxRepos) error {
+			// Remove user and its memberships, audit it, then fail
+			if err := repos.UserRepo.RemoveUser("UserID"); err != nil {
+				return err
+			}
+			if err := repos.AuditRepo.AddAuditEntry(api.AuditEntry{ID: "EntryID", Urn: "urn:user"}); err != nil {
+				return err
+			}
+			return test.txErr
+		})
+		assert.Equal(t, test.expectedError, err, "Error in test case %v", n)
+
+		// Check database
+		_, err = repo.GetUserByExternalID("ExternalID")
+		assert.Equal(t, test.expectedUser, err == nil, "Error in test case %v", n)
+		isMember, err := repo.IsMemberOfGroup("UserID", "GroupID")
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedMember, isMember, "Error in test case %v", n)
+		_, total, err := repo.GetAuditEntriesFiltered(&api.Filter{})
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedAuditEntries, total, "Error in test case %v", n)
+	}
+}
+
+func TestMemoryRepo_WithTxConcurrentWrites(t *testing.T) {
+	repo := NewMemoryRepo()
+	written := make(chan error)
+
+	err := repo.WithTx(func(repos api.TxRepos) error {
+		if _, err := repos.UserRepo.AddUser(api.User{ID: "TxUserID", ExternalID: "TxExternalID"}); err != nil {
+			return err
+		}
+		// Write outside the transaction while it runs, then roll it back
+		go func() {
+			_, err := repo.AddUser(api.User{ID: "UserID", ExternalID: "ExternalID"})
+			written <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+		return errors.New("Failure")
+	})
+	assert.Equal(t, errors.New("Failure"), err, "Error in test case %v", "ErrorCaseRollback")
+	assert.Nil(t, <-written, "Error in test case %v", "ErrorCaseRollback")
+
+	// The rollback only discards the changes of the transaction
+	_, err = repo.GetUserByExternalID("TxExternalID")
+	assert.NotNil(t, err, "Error in test case %v", "ErrorCaseRollback")
+	_, err = repo.GetUserByExternalID("ExternalID")
+	assert.Nil(t, err, "Error in test case %v", "ErrorCaseRollback")
+}
+
+func Test_sortByColumn(t *testing.T) {
+	now := time.Now().UTC()
+	users := []api.User{
+		{ID: "1", Path: "/b/", CreateAt: now.Add(time.Second)},
+		{ID: "2", Path: "/a/", CreateAt: now},
+		{ID: "3", Path: "/b/", CreateAt: now.Add(2 * time.Second)},
+	}
+	testcases := map[string]struct {
+		order string
+		// Expected result
+		expectedIDs []string
+	}{
+		"OkCaseNoOrder": {
+			expectedIDs: []string{"1", "2", "3"},
+		},
+		"OkCaseStringAsc": {
+			order:       "path asc",
+			expectedIDs: []string{"2", "1", "3"},
+		},
+		"OkCaseStringDesc": {
+			order:       "path desc",
+			expectedIDs: []string{"1", "3", "2"},
+		},
+		"OkCaseTimeAsc": {
+			order:       "create_at asc",
+			expectedIDs: []string{"2", "1", "3"},
+		},
+		"OkCaseTimeDesc": {
+			order:       "create_at desc",
+			expectedIDs: []string{"3", "1", "2"},
+		},
+	}
+
+	for n, test := range testcases {
+		sorted := append([]api.User{}, users...)
+		sortByColumn(sorted, test.order, func(i int, column string) interface{} {
+			return userColumn(&sorted[i], column)
+		})
+		ids := []string{}
+		for _, u := range sorted {
+			ids = append(ids, u.ID)
+		}
+		assert.Equal(t, test.expectedIDs, ids, "Error in test case %v", n)
+	}
+}
+
+func Test_pageBounds(t *testing.T) {
+	testcases := map[string]struct {
+		total  int
+		filter *api.Filter
+		// Expected result
+		expectedStart int
+		expectedEnd   int
+	}{
+		"OkCaseNoLimit": {
+			total:         5,
+			filter:        &api.Filter{},
+			expectedStart: 0,
+			expectedEnd:   5,
+		},
+		"OkCaseOffsetAndLimit": {
+			total:         5,
+			filter:        &api.Filter{Offset: 1, Limit: 2},
+			expectedStart: 1,
+			expectedEnd:   3,
+		},
+		"OkCaseLimitExceedsTotal": {
+			total:         5,
+			filter:        &api.Filter{Offset: 4, Limit: 20},
+			expectedStart: 4,
+			expectedEnd:   5,
+		},
+		"OkCaseOffsetExceedsTotal": {
+			total:         5,
+			filter:        &api.Filter{Offset: 10, Limit: 20},
+			expectedStart: 5,
+			expectedEnd:   5,
+		},
+	}
+
+	for n, test := range testcases {
+		start, end := pageBounds(test.total, test.filter)
+		assert.Equal(t, test.expectedStart, start, "Error in test case %v", n)
+		assert.Equal(t, test.expectedEnd, end, "Error in test case %v", n)
+	}
+}
@@ -0,0 +1,233 @@
+package memory
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+)
+
+// POLICY REPOSITORY IMPLEMENTATION
+
+func (mr *MemoryRepo) AddPolicy(policy api.Policy) (*api.Policy, error) {
+	mr.lock()
+	defer mr.unlock()
+
+	// Check unique keys
+	for _, p := range mr.policies {
+		switch {
+		case p.ID == policy.ID:
+			return nil, duplicatedKeyError("policy", policy.ID)
+		case p.Urn == policy.Urn:
+			return nil, duplicatedKeyError("policy", policy.Urn)
+		}
+	}
+
+	// Store policy with its statements
+	policyDB := storedPolicy(policy)
+	mr.policies = append(mr.policies, policyDB)
+
+	// Create API policy
+	policyApi := copyPolicy(policyDB)
+	policyApi.Statements = policy.Statements
+
+	return &policyApi, nil
+}
+
+func (mr *MemoryRepo) GetPolicyByName(org string, name string) (*api.Policy, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	for _, p := range mr.policies {
+		if p.Org == org && p.Name == name {
+			policy := copyPolicy(p)
+			return &policy, nil
+		}
+	}
+
+	return nil, &database.Error{
+		Code:    database.POLICY_NOT_FOUND,
+		Message: fmt.Sprintf("Policy with organization %v and name %v not found", org, name),
+	}
+}
+
+func (mr *MemoryRepo) GetPolicyById(id string) (*api.Policy, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	return mr.getPolicyByID(id)
+}
+
+func (mr *MemoryRepo) GetPoliciesFiltered(filter *api.Filter) ([]api.Policy, int, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	policies := []api.Policy{}
+	for _, p := range mr.policies {
+		if (len(filter.Org) < 1 || p.Org == filter.Org) && strings.HasPrefix(p.Path, filter.PathPrefix) {
+			policies = append(policies, copyPolicy(p))
+		}
+	}
+	sortByColumn(policies, filter.OrderBy, func(i int, column string) interface{} {
+		return policyColumn(&policies[i], column)
+	})
+
+	start, end := pageBounds(len(policies), filter)
+	return policies[start:end], len(policies), nil
+}
+
+func (mr *MemoryRepo) UpdatePolicy(policy api.Policy, oldUpdateAt time.Time) (*api.Policy, error) {
+	mr.lock()
+	defer mr.unlock()
+
+	// Replace policy and its statements
+	for i, p := range mr.policies {
+		if p.ID == policy.ID && p.UpdateAt.Equal(oldUpdateAt) {
+			mr.policies[i] = storedPolicy(policy)
+			return &policy, nil
+		}
+	}
+
+	return nil, versionConflictError("Policy", policy.ID)
+}
+
+func (mr *MemoryRepo) RemovePolicy(id string) error {
+	mr.lock()
+	defer mr.unlock()
+
+	// Delete policy relations (group)
+	mr.removeGroupPolicyRelations(func(r groupPolicyRelation) bool {
+		return r.policyID == id
+	})
+
+	// Delete policy relations (user)
+	mr.removeUserPolicyRelations(func(r userPolicyRelation) bool {
+		return r.policyID == id
+	})
+
+	// Delete policy with its statements
+	policies := []api.Policy{}
+	for _, p := range mr.policies {
+		if p.ID != id {
+			policies = append(policies, p)
+		}
+	}
+	mr.policies = policies
+
+	return nil
+}
+
+func (mr *MemoryRepo) GetAttachedGroups(policyID string, filter *api.Filter) ([]api.PolicyGroupRelation, int, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	relations := []groupPolicyRelation{}
+	for _, r := range mr.groupPolicyRelations {
+		if r.policyID == policyID {
+			relations = append(relations, r)
+		}
+	}
+	sortByColumn(relations, filter.OrderBy, func(i int, column string) interface{} {
+		return relations[i].createAt
+	})
+
+	start, end := pageBounds(len(relations), filter)
+	groups := make([]api.PolicyGroupRelation, 0, end-start)
+	// Transform relations to API domain
+	for _, r := range relations[start:end] {
+		group, err := mr.getGroupByID(r.groupID)
+		// Error handling
+		if err != nil {
+			return nil, len(relations), &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: err.Error(),
+			}
+		}
+		groups = append(groups, &PolicyGroup{
+			Group:     group,
+			CreateAt:  r.createAt,
+			ExpiresAt: r.expiresAt,
+		})
+	}
+
+	return groups, len(relations), nil
+}
+
+// PRIVATE HELPER METHODS
+
+// Retrieve a policy by its id. Caller must hold the lock
+func (mr *MemoryRepo) getPolicyByID(id string) (*api.Policy, error) {
+	for _, p := range mr.policies {
+		if p.ID == id {
+			policy := copyPolicy(p)
+			return &policy, nil
+		}
+	}
+
+	return nil, &database.Error{
+		Code:    database.POLICY_NOT_FOUND,
+		Message: fmt.Sprintf("Policy with id %v not found", id),
+	}
+}
+
+// Transform a policy for API into the policy stored, that doesn't share statements with it
+func storedPolicy(policy api.Policy) api.Policy {
+	policy.CreateAt = storedTime(policy.CreateAt)
+	policy.UpdateAt = storedTime(policy.UpdateAt)
+	return copyPolicy(policy)
+}
+
+// Copy a policy, so the copy can be modified without changing the original statements
+func copyPolicy(policy api.Policy) api.Policy {
+	statements := []api.Statement{}
+	if policy.Statements != nil {
+		for _, s := range *policy.Statements {
+			statements = append(statements, api.Statement{
+				Effect:     s.Effect,
+				Actions:    append([]string{}, s.Actions...),
+				Resources:  append([]string{}, s.Resources...),
+				Conditions: copyConditions(s.Conditions),
+			})
+		}
+	}
+	policy.Statements = &statements
+	return policy
+}
+
+// Copy statement conditions, nil if there aren't conditions
+func copyConditions(conditions api.Conditions) api.Conditions {
+	if len(conditions) < 1 {
+		return nil
+	}
+	copied := api.Conditions{}
+	for operator, values := range conditions {
+		copied[operator] = map[string][]string{}
+		for key, v := range values {
+			copied[operator][key] = append([]string{}, v...)
+		}
+	}
+
+	return copied
+}
+
+// Column value of a policy used to sort them
+func policyColumn(policy *api.Policy, column string) interface{} {
+	switch column {
+	case "name":
+		return policy.Name
+	case "path":
+		return policy.Path
+	case "org":
+		return policy.Org
+	case "create_at":
+		return policy.CreateAt
+	case "update_at":
+		return policy.UpdateAt
+	case "urn":
+		return policy.Urn
+	default:
+		return nil
+	}
+}
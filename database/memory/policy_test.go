@@ -0,0 +1,262 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRepo_AddPolicy(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousPolicies []api.Policy
+		// Memory Repo Args
+		policyToCreate *api.Policy
+		// Expected result
+		expectedResponse *api.Policy
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			policyToCreate: &api.Policy{
+				ID:       "PolicyID",
+				Name:     "Name",
+				Path:     "Path",
+				Urn:      "urn",
+				CreateAt: now,
+				UpdateAt: now,
+				Org:      "Org",
+				Statements: &[]api.Statement{
+					{
+						Effect:    "allow",
+						Actions:   []string{"iam:*"},
+						Resources: []string{"urn:everything:*"},
+						Conditions: api.Conditions{
+							"IpAddress": {"foulkon:SourceIp": {"10.0.0.0/8"}},
+						},
+					},
+				},
+			},
+			expectedResponse: &api.Policy{
+				ID:       "PolicyID",
+				Name:     "Name",
+				Path:     "Path",
+				Urn:      "urn",
+				CreateAt: now,
+				UpdateAt: now,
+				Org:      "Org",
+				Statements: &[]api.Statement{
+					{
+						Effect:    "allow",
+						Actions:   []string{"iam:*"},
+						Resources: []string{"urn:everything:*"},
+						Conditions: api.Conditions{
+							"IpAddress": {"foulkon:SourceIp": {"10.0.0.0/8"}},
+						},
+					},
+				},
+			},
+		},
+		"ErrorCasePolicyAlreadyExist": {
+			previousPolicies: []api.Policy{
+				{ID: "PolicyID", Urn: "urn"},
+			},
+			policyToCreate: &api.Policy{
+				ID:         "PolicyID",
+				Name:       "Name",
+				Urn:        "urn",
+				Org:        "Org",
+				Statements: &[]api.Statement{},
+			},
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Duplicated key PolicyID for policy",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{policies: test.previousPolicies}}
+
+		storedPolicy, err := repo.AddPolicy(*test.policyToCreate)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, storedPolicy, "Error in test case %v", n)
+
+			// Check policy stored doesn't share statements with the created one
+			(*test.policyToCreate.Statements)[0].Actions[0] = "changed"
+			policy, err := repo.GetPolicyByName(test.policyToCreate.Org, test.policyToCreate.Name)
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, policy, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestMemoryRepo_GetPolicyByName(t *testing.T) {
+	testcases := map[string]struct {
+		// Previous data
+		previousPolicies []api.Policy
+		// Memory Repo Args
+		org  string
+		name string
+		// Expected result
+		expectedResponse *api.Policy
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			previousPolicies: []api.Policy{
+				{ID: "PolicyID", Name: "Name", Org: "Org"},
+			},
+			org:              "Org",
+			name:             "Name",
+			expectedResponse: &api.Policy{ID: "PolicyID", Name: "Name", Org: "Org", Statements: &[]api.Statement{}},
+		},
+		"ErrorCasePolicyNotExist": {
+			org:  "Org",
+			name: "Name",
+			expectedError: &database.Error{
+				Code:    database.POLICY_NOT_FOUND,
+				Message: "Policy with organization Org and name Name not found",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{policies: test.previousPolicies}}
+
+		policy, err := repo.GetPolicyByName(test.org, test.name)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, policy, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestMemoryRepo_GetPoliciesFiltered(t *testing.T) {
+	policy1 := api.Policy{ID: "PolicyID1", Name: "b", Path: "/path/", Org: "Org1", Statements: &[]api.Statement{}}
+	policy2 := api.Policy{ID: "PolicyID2", Name: "a", Path: "/path/", Org: "Org2", Statements: &[]api.Statement{}}
+	policy3 := api.Policy{ID: "PolicyID3", Name: "c", Path: "/other/", Org: "Org1", Statements: &[]api.Statement{}}
+	testcases := map[string]struct {
+		// Memory Repo Args
+		filter *api.Filter
+		// Expected result
+		expectedResponse []api.Policy
+		expectedTotal    int
+	}{
+		"OkCaseOrgAndPathPrefix": {
+			filter:           &api.Filter{Org: "Org1", PathPrefix: "/path/"},
+			expectedResponse: []api.Policy{policy1},
+			expectedTotal:    1,
+		},
+		"OkCaseOrderBy": {
+			filter:           &api.Filter{OrderBy: "name desc"},
+			expectedResponse: []api.Policy{policy3, policy1, policy2},
+			expectedTotal:    3,
+		},
+		"OkCasePagination": {
+			filter:           &api.Filter{Offset: 1, Limit: 1},
+			expectedResponse: []api.Policy{policy2},
+			expectedTotal:    3,
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{policies: []api.Policy{policy1, policy2, policy3}}}
+
+		policies, total, err := repo.GetPoliciesFiltered(test.filter)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedTotal, total, "Error in test case %v", n)
+		assert.Equal(t, test.expectedResponse, policies, "Error in test case %v", n)
+	}
+}
+
+func TestMemoryRepo_UpdatePolicy(t *testing.T) {
+	repo := &MemoryRepo{memoryStore: &memoryStore{
+		policies: []api.Policy{
+			{
+				ID:   "PolicyID",
+				Name: "Name",
+				Org:  "Org",
+				Statements: &[]api.Statement{
+					{Effect: "allow", Actions: []string{"iam:*"}, Resources: []string{"urn:everything:*"}},
+				},
+			},
+		},
+	}}
+	policyToUpdate := api.Policy{
+		ID:   "PolicyID",
+		Name: "NewName",
+		Org:  "Org",
+		Statements: &[]api.Statement{
+			{Effect: "deny", Actions: []string{"iam:*"}, Resources: []string{"urn:everything:*"}},
+		},
+	}
+
+	updatedPolicy, err := repo.UpdatePolicy(policyToUpdate, time.Time{})
+	assert.Nil(t, err, "Error updating policy")
+	assert.Equal(t, &policyToUpdate, updatedPolicy, "Error updating policy")
+
+	policy, err := repo.GetPolicyById("PolicyID")
+	assert.Nil(t, err, "Error updating policy")
+	assert.Equal(t, &policyToUpdate, policy, "Error updating policy")
+}
+
+func TestMemoryRepo_RemovePolicy(t *testing.T) {
+	repo := &MemoryRepo{memoryStore: &memoryStore{
+		policies: []api.Policy{
+			{ID: "PolicyID1"},
+			{ID: "PolicyID2"},
+		},
+		groupPolicyRelations: []groupPolicyRelation{
+			{groupID: "GroupID", policyID: "PolicyID1"},
+			{groupID: "GroupID", policyID: "PolicyID2"},
+		},
+		userPolicyRelations: []userPolicyRelation{
+			{userID: "UserID", policyID: "PolicyID1"},
+			{userID: "UserID", policyID: "PolicyID2"},
+		},
+	}}
+
+	err := repo.RemovePolicy("PolicyID1")
+	assert.Nil(t, err, "Error removing policy")
+
+	// Check policy and its relations were removed
+	assert.Equal(t, []api.Policy{{ID: "PolicyID2"}}, repo.policies, "Error removing policy")
+	assert.Equal(t, []groupPolicyRelation{{groupID: "GroupID", policyID: "PolicyID2"}}, repo.groupPolicyRelations,
+		"Error removing policy group relations")
+	assert.Equal(t, []userPolicyRelation{{userID: "UserID", policyID: "PolicyID2"}}, repo.userPolicyRelations,
+		"Error removing policy user relations")
+}
+
+func TestMemoryRepo_GetAttachedGroups(t *testing.T) {
+	now := time.Now().UTC()
+	repo := &MemoryRepo{memoryStore: &memoryStore{
+		groups: []api.Group{
+			{ID: "GroupID1"},
+			{ID: "GroupID2"},
+		},
+		groupPolicyRelations: []groupPolicyRelation{
+			{groupID: "GroupID1", policyID: "PolicyID", createAt: now},
+			{groupID: "GroupID2", policyID: "OtherPolicyID", createAt: now},
+		},
+	}}
+
+	groups, total, err := repo.GetAttachedGroups("PolicyID", &api.Filter{})
+	assert.Nil(t, err, "Error getting attached groups")
+	assert.Equal(t, 1, total, "Error getting attached groups")
+	assert.Equal(t, []api.PolicyGroupRelation{
+		&PolicyGroup{
+			Group:    &api.Group{ID: "GroupID1"},
+			CreateAt: now,
+		},
+	}, groups, "Error getting attached groups")
+}
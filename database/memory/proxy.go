@@ -0,0 +1,177 @@
+package memory
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+)
+
+// PROXY REPOSITORY IMPLEMENTATION
+
+func (mr *MemoryRepo) GetProxyResourceByName(org string, name string) (*api.ProxyResource, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	for _, r := range mr.proxyResources {
+		if r.Org == org && r.Name == name {
+			proxyResource := r
+			return &proxyResource, nil
+		}
+	}
+
+	return nil, &database.Error{
+		Code:    database.PROXY_RESOURCE_NOT_FOUND,
+		Message: fmt.Sprintf("Proxy resource with organization %v and name %v not found", org, name),
+	}
+}
+
+func (mr *MemoryRepo) GetProxyResources(filter *api.Filter) ([]api.ProxyResource, int, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	resources := []api.ProxyResource{}
+	for _, r := range mr.proxyResources {
+		if (len(filter.Org) < 1 || r.Org == filter.Org) && strings.HasPrefix(r.Path, filter.PathPrefix) {
+			resources = append(resources, r)
+		}
+	}
+	sortByColumn(resources, filter.OrderBy, func(i int, column string) interface{} {
+		return proxyResourceColumn(&resources[i], column)
+	})
+
+	start, end := pageBounds(len(resources), filter)
+	return resources[start:end], len(resources), nil
+}
+
+func (mr *MemoryRepo) AddProxyResource(proxyResource api.ProxyResource) (*api.ProxyResource, error) {
+	mr.lock()
+	defer mr.unlock()
+
+	// Check unique keys
+	for _, r := range mr.proxyResources {
+		switch {
+		case r.ID == proxyResource.ID:
+			return nil, duplicatedKeyError("proxy resource", proxyResource.ID)
+		case sameResource(r.Resource, proxyResource.Resource):
+			return nil, duplicatedKeyError("proxy resource", fmt.Sprintf("%v", proxyResource.Resource))
+		}
+	}
+
+	// Store proxyResource
+	proxyResourceDB := storedProxyResource(proxyResource)
+	mr.proxyResources = append(mr.proxyResources, proxyResourceDB)
+
+	return &proxyResourceDB, nil
+}
+
+func (mr *MemoryRepo) UpdateProxyResource(proxyResource api.ProxyResource, oldUpdateAt time.Time) (*api.ProxyResource, error) {
+	mr.lock()
+	defer mr.unlock()
+
+	// Check unique keys
+	for _, r := range mr.proxyResources {
+		if r.ID != proxyResource.ID && sameResource(r.Resource, proxyResource.Resource) {
+			return nil, duplicatedKeyError("proxy resource", fmt.Sprintf("%v", proxyResource.Resource))
+		}
+	}
+
+	for i, r := range mr.proxyResources {
+		if r.ID == proxyResource.ID && r.UpdateAt.Equal(oldUpdateAt) {
+			mr.proxyResources[i] = storedProxyResource(proxyResource)
+			return &proxyResource, nil
+		}
+	}
+
+	return nil, versionConflictError("Proxy resource", proxyResource.ID)
+}
+
+func (mr *MemoryRepo) RemoveProxyResource(id string) error {
+	mr.lock()
+	defer mr.unlock()
+
+	resources := []api.ProxyResource{}
+	for _, r := range mr.proxyResources {
+		if r.ID != id {
+			resources = append(resources, r)
+		}
+	}
+	mr.proxyResources = resources
+
+	return nil
+}
+
+func (mr *MemoryRepo) GetProxyRevision() (int64, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	return mr.proxyChanges + 1, nil
+}
+
+func (mr *MemoryRepo) IncrementProxyRevision() error {
+	mr.lock()
+	defer mr.unlock()
+
+	mr.proxyChanges++
+	return nil
+}
+
+// PRIVATE HELPER METHODS
+
+// Transform a proxyResource for API into the proxyResource stored
+func storedProxyResource(proxyResource api.ProxyResource) api.ProxyResource {
+	proxyResource.CreateAt = storedTime(proxyResource.CreateAt)
+	proxyResource.UpdateAt = storedTime(proxyResource.UpdateAt)
+	proxyResource.Resource.MatchHeaders = append([]api.HeaderMatcher(nil), proxyResource.Resource.MatchHeaders...)
+	return proxyResource
+}
+
+// Check if two resources have the same unique key
+func sameResource(a api.ResourceEntity, b api.ResourceEntity) bool {
+	if a.Host != b.Host || a.Upstream != b.Upstream || a.Path != b.Path || a.Method != b.Method || a.Urn != b.Urn || a.Action != b.Action ||
+		a.MatchHost != b.MatchHost || len(a.MatchHeaders) != len(b.MatchHeaders) {
+		return false
+	}
+	for i := range a.MatchHeaders {
+		if a.MatchHeaders[i] != b.MatchHeaders[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Column value of a proxyResource used to sort them
+func proxyResourceColumn(proxyResource *api.ProxyResource, column string) interface{} {
+	switch column {
+	case "name":
+		return proxyResource.Name
+	case "path":
+		return proxyResource.Path
+	case "org":
+		return proxyResource.Org
+	case "host":
+		return proxyResource.Resource.Host
+	case "upstream":
+		return proxyResource.Resource.Upstream
+	case "path_resource":
+		return proxyResource.Resource.Path
+	case "method":
+		return proxyResource.Resource.Method
+	case "urn_resource":
+		return proxyResource.Resource.Urn
+	case "urn":
+		return proxyResource.Urn
+	case "action":
+		return proxyResource.Resource.Action
+	case "match_host":
+		return proxyResource.Resource.MatchHost
+	case "create_at":
+		return proxyResource.CreateAt
+	case "update_at":
+		return proxyResource.UpdateAt
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,214 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRepo_AddProxyResource(t *testing.T) {
+	now := time.Now().UTC()
+	resource := api.ResourceEntity{
+		Host:   "host",
+		Path:   "/path",
+		Method: "GET",
+		Urn:    "urn:example",
+		Action: "example:get",
+	}
+	testcases := map[string]struct {
+		// Previous data
+		previousProxyResources []api.ProxyResource
+		// Memory Repo Args
+		proxyResourceToCreate *api.ProxyResource
+		// Expected result
+		expectedResponse *api.ProxyResource
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			proxyResourceToCreate: &api.ProxyResource{
+				ID:       "ResourceID",
+				Name:     "Name",
+				Org:      "Org",
+				Path:     "/path/",
+				Urn:      "urn",
+				Resource: resource,
+				CreateAt: now,
+				UpdateAt: now,
+			},
+			expectedResponse: &api.ProxyResource{
+				ID:       "ResourceID",
+				Name:     "Name",
+				Org:      "Org",
+				Path:     "/path/",
+				Urn:      "urn",
+				Resource: resource,
+				CreateAt: now,
+				UpdateAt: now,
+			},
+		},
+		"ErrorCaseResourceAlreadyExist": {
+			previousProxyResources: []api.ProxyResource{
+				{ID: "OtherResourceID", Resource: resource},
+			},
+			proxyResourceToCreate: &api.ProxyResource{
+				ID:       "ResourceID",
+				Name:     "Name",
+				Org:      "Org",
+				Resource: resource,
+			},
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Duplicated key {host  /path GET urn:example example:get  []} for proxy resource",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{proxyResources: test.previousProxyResources}}
+
+		storedProxyResource, err := repo.AddProxyResource(*test.proxyResourceToCreate)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, storedProxyResource, "Error in test case %v", n)
+
+			// Check proxy resource stored
+			proxyResource, err := repo.GetProxyResourceByName(test.proxyResourceToCreate.Org, test.proxyResourceToCreate.Name)
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, proxyResource, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestMemoryRepo_GetProxyResourceByName(t *testing.T) {
+	repo := &MemoryRepo{memoryStore: &memoryStore{
+		proxyResources: []api.ProxyResource{
+			{ID: "ResourceID", Name: "Name", Org: "Org"},
+		},
+	}}
+
+	_, err := repo.GetProxyResourceByName("OtherOrg", "Name")
+	dbError, _ := err.(*database.Error)
+	assert.Equal(t, &database.Error{
+		Code:    database.PROXY_RESOURCE_NOT_FOUND,
+		Message: "Proxy resource with organization OtherOrg and name Name not found",
+	}, dbError, "Error getting proxy resource")
+}
+
+func TestMemoryRepo_GetProxyResources(t *testing.T) {
+	resource1 := api.ProxyResource{ID: "ResourceID1", Org: "Org1", Path: "/path/", Resource: api.ResourceEntity{Host: "b"}}
+	resource2 := api.ProxyResource{ID: "ResourceID2", Org: "Org2", Path: "/path/", Resource: api.ResourceEntity{Host: "a"}}
+	resource3 := api.ProxyResource{ID: "ResourceID3", Org: "Org1", Path: "/other/", Resource: api.ResourceEntity{Host: "c"}}
+	testcases := map[string]struct {
+		// Memory Repo Args
+		filter *api.Filter
+		// Expected result
+		expectedResponse []api.ProxyResource
+		expectedTotal    int
+	}{
+		"OkCaseNoFilter": {
+			filter:           &api.Filter{},
+			expectedResponse: []api.ProxyResource{resource1, resource2, resource3},
+			expectedTotal:    3,
+		},
+		"OkCaseOrgAndPathPrefix": {
+			filter:           &api.Filter{Org: "Org1", PathPrefix: "/path/"},
+			expectedResponse: []api.ProxyResource{resource1},
+			expectedTotal:    1,
+		},
+		"OkCaseOrderBy": {
+			filter:           &api.Filter{OrderBy: "host asc"},
+			expectedResponse: []api.ProxyResource{resource2, resource1, resource3},
+			expectedTotal:    3,
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{proxyResources: []api.ProxyResource{resource1, resource2, resource3}}}
+
+		resources, total, err := repo.GetProxyResources(test.filter)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedTotal, total, "Error in test case %v", n)
+		assert.Equal(t, test.expectedResponse, resources, "Error in test case %v", n)
+	}
+}
+
+func TestMemoryRepo_UpdateProxyResource(t *testing.T) {
+	testcases := map[string]struct {
+		// Memory Repo Args
+		proxyResourceToUpdate *api.ProxyResource
+		// Expected result
+		expectedError *database.Error
+	}{
+		"OkCase": {
+			proxyResourceToUpdate: &api.ProxyResource{
+				ID:       "ResourceID1",
+				Name:     "NewName",
+				Resource: api.ResourceEntity{Host: "newhost"},
+			},
+		},
+		"ErrorCaseResourceAlreadyExist": {
+			proxyResourceToUpdate: &api.ProxyResource{
+				ID:       "ResourceID1",
+				Name:     "NewName",
+				Resource: api.ResourceEntity{Host: "host2"},
+			},
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Duplicated key {host2       []} for proxy resource",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{
+			proxyResources: []api.ProxyResource{
+				{ID: "ResourceID1", Name: "Name1", Resource: api.ResourceEntity{Host: "host1"}},
+				{ID: "ResourceID2", Name: "Name2", Resource: api.ResourceEntity{Host: "host2"}},
+			},
+		}}
+
+		updatedProxyResource, err := repo.UpdateProxyResource(*test.proxyResourceToUpdate, time.Time{})
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.proxyResourceToUpdate, updatedProxyResource, "Error in test case %v", n)
+			assert.Equal(t, *test.proxyResourceToUpdate, repo.proxyResources[0], "Error in test case %v", n)
+		}
+	}
+}
+
+func TestMemoryRepo_RemoveProxyResource(t *testing.T) {
+	repo := &MemoryRepo{memoryStore: &memoryStore{
+		proxyResources: []api.ProxyResource{
+			{ID: "ResourceID1"},
+			{ID: "ResourceID2"},
+		},
+	}}
+
+	err := repo.RemoveProxyResource("ResourceID1")
+	assert.Nil(t, err, "Error removing proxy resource")
+	assert.Equal(t, []api.ProxyResource{{ID: "ResourceID2"}}, repo.proxyResources, "Error removing proxy resource")
+}
+
+func TestMemoryRepo_ProxyRevision(t *testing.T) {
+	repo := NewMemoryRepo()
+
+	// New repositories start at revision 1
+	revision, err := repo.GetProxyRevision()
+	assert.Nil(t, err, "Error getting proxy revision")
+	assert.Equal(t, int64(1), revision, "Error getting proxy revision")
+
+	err = repo.IncrementProxyRevision()
+	assert.Nil(t, err, "Error increasing proxy revision")
+	revision, err = repo.GetProxyRevision()
+	assert.Nil(t, err, "Error getting proxy revision")
+	assert.Equal(t, int64(2), revision, "Error getting proxy revision")
+}
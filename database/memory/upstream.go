@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+)
+
+// UPSTREAM POOL REPOSITORY IMPLEMENTATION
+
+func (mr *MemoryRepo) GetUpstreamPoolByName(org string, name string) (*api.UpstreamPool, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	for _, p := range mr.upstreamPools {
+		if p.Org == org && p.Name == name {
+			pool := copyUpstreamPool(p)
+			return &pool, nil
+		}
+	}
+
+	return nil, &database.Error{
+		Code:    database.UPSTREAM_POOL_NOT_FOUND,
+		Message: fmt.Sprintf("Upstream pool with organization %v and name %v not found", org, name),
+	}
+}
+
+func (mr *MemoryRepo) GetUpstreamPools(filter *api.Filter) ([]api.UpstreamPool, int, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	pools := []api.UpstreamPool{}
+	for _, p := range mr.upstreamPools {
+		if (len(filter.Org) < 1 || p.Org == filter.Org) && strings.HasPrefix(p.Path, filter.PathPrefix) {
+			pools = append(pools, copyUpstreamPool(p))
+		}
+	}
+	sortByColumn(pools, filter.OrderBy, func(i int, column string) interface{} {
+		return upstreamPoolColumn(&pools[i], column)
+	})
+
+	start, end := pageBounds(len(pools), filter)
+	return pools[start:end], len(pools), nil
+}
+
+func (mr *MemoryRepo) AddUpstreamPool(pool api.UpstreamPool) (*api.UpstreamPool, error) {
+	mr.lock()
+	defer mr.unlock()
+
+	// Check unique keys
+	for _, p := range mr.upstreamPools {
+		switch {
+		case p.ID == pool.ID:
+			return nil, duplicatedKeyError("upstream pool", pool.ID)
+		case p.Urn == pool.Urn:
+			return nil, duplicatedKeyError("upstream pool", pool.Urn)
+		case p.Org == pool.Org && p.Name == pool.Name:
+			return nil, duplicatedKeyError("upstream pool", fmt.Sprintf("%v %v", pool.Org, pool.Name))
+		}
+	}
+
+	// Store upstream pool
+	poolDB := storedUpstreamPool(pool)
+	mr.upstreamPools = append(mr.upstreamPools, poolDB)
+
+	createdPool := copyUpstreamPool(poolDB)
+	return &createdPool, nil
+}
+
+func (mr *MemoryRepo) UpdateUpstreamPool(pool api.UpstreamPool, oldUpdateAt time.Time) (*api.UpstreamPool, error) {
+	mr.lock()
+	defer mr.unlock()
+
+	// Check unique keys
+	for _, p := range mr.upstreamPools {
+		if p.ID != pool.ID && (p.Urn == pool.Urn || (p.Org == pool.Org && p.Name == pool.Name)) {
+			return nil, duplicatedKeyError("upstream pool", pool.Urn)
+		}
+	}
+
+	for i, p := range mr.upstreamPools {
+		if p.ID == pool.ID && p.UpdateAt.Equal(oldUpdateAt) {
+			mr.upstreamPools[i] = storedUpstreamPool(pool)
+			updatedPool := copyUpstreamPool(mr.upstreamPools[i])
+			return &updatedPool, nil
+		}
+	}
+
+	return nil, versionConflictError("Upstream pool", pool.ID)
+}
+
+func (mr *MemoryRepo) RemoveUpstreamPool(id string) error {
+	mr.lock()
+	defer mr.unlock()
+
+	pools := []api.UpstreamPool{}
+	for _, p := range mr.upstreamPools {
+		if p.ID != id {
+			pools = append(pools, p)
+		}
+	}
+	mr.upstreamPools = pools
+
+	return nil
+}
+
+// PRIVATE HELPER METHODS
+
+// Transform an upstream pool for API into the upstream pool stored
+func storedUpstreamPool(pool api.UpstreamPool) api.UpstreamPool {
+	pool = copyUpstreamPool(pool)
+	pool.CreateAt = storedTime(pool.CreateAt)
+	pool.UpdateAt = storedTime(pool.UpdateAt)
+	return pool
+}
+
+// Copy an upstream pool, so changes in its targets or health check don't modify the stored one
+func copyUpstreamPool(pool api.UpstreamPool) api.UpstreamPool {
+	pool.Config.Targets = append([]string(nil), pool.Config.Targets...)
+	if pool.Config.HealthCheck != nil {
+		healthCheck := *pool.Config.HealthCheck
+		pool.Config.HealthCheck = &healthCheck
+	}
+	return pool
+}
+
+// Column value of an upstream pool used to sort them
+func upstreamPoolColumn(pool *api.UpstreamPool, column string) interface{} {
+	switch column {
+	case "name":
+		return pool.Name
+	case "path":
+		return pool.Path
+	case "org":
+		return pool.Org
+	case "urn":
+		return pool.Urn
+	case "balancer":
+		return pool.Config.Balancer
+	case "create_at":
+		return pool.CreateAt
+	case "update_at":
+		return pool.UpdateAt
+	default:
+		return nil
+	}
+}
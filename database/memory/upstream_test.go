@@ -0,0 +1,192 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRepo_AddUpstreamPool(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousPools []api.UpstreamPool
+		// Memory Repo Args
+		poolToCreate *api.UpstreamPool
+		// Expected result
+		expectedResponse *api.UpstreamPool
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			poolToCreate: &api.UpstreamPool{
+				ID:       "PoolID",
+				Name:     "Name",
+				Org:      "Org",
+				Path:     "Path",
+				Urn:      "urn",
+				CreateAt: now,
+				UpdateAt: now,
+				Config: api.UpstreamPoolConfig{
+					Targets:     []string{"http://10.0.0.1:8080"},
+					HealthCheck: &api.UpstreamHealthCheck{Path: "/health"},
+				},
+			},
+			expectedResponse: &api.UpstreamPool{
+				ID:       "PoolID",
+				Name:     "Name",
+				Org:      "Org",
+				Path:     "Path",
+				Urn:      "urn",
+				CreateAt: now,
+				UpdateAt: now,
+				Config: api.UpstreamPoolConfig{
+					Targets:     []string{"http://10.0.0.1:8080"},
+					HealthCheck: &api.UpstreamHealthCheck{Path: "/health"},
+				},
+			},
+		},
+		"ErrorCaseUpstreamPoolAlreadyExist": {
+			previousPools: []api.UpstreamPool{
+				{ID: "OtherID", Name: "Name", Org: "Org", Urn: "otherUrn"},
+			},
+			poolToCreate: &api.UpstreamPool{
+				ID:   "PoolID",
+				Name: "Name",
+				Org:  "Org",
+				Urn:  "urn",
+			},
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Duplicated key Org Name for upstream pool",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{upstreamPools: test.previousPools}}
+
+		storedPool, err := repo.AddUpstreamPool(*test.poolToCreate)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, storedPool, "Error in test case %v", n)
+
+			// Check upstream pool stored
+			pool, err := repo.GetUpstreamPoolByName(test.poolToCreate.Org, test.poolToCreate.Name)
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, pool, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestMemoryRepo_GetUpstreamPoolByName(t *testing.T) {
+	repo := &MemoryRepo{memoryStore: &memoryStore{
+		upstreamPools: []api.UpstreamPool{
+			{ID: "PoolID", Name: "Name", Org: "Org", Config: api.UpstreamPoolConfig{Targets: []string{"http://10.0.0.1"}}},
+		},
+	}}
+
+	// Changes in the returned upstream pool don't modify the stored one
+	pool, err := repo.GetUpstreamPoolByName("Org", "Name")
+	assert.Nil(t, err, "Error getting upstream pool")
+	pool.Config.Targets[0] = "http://10.0.0.2"
+	assert.Equal(t, "http://10.0.0.1", repo.upstreamPools[0].Config.Targets[0], "Error getting upstream pool")
+
+	_, err = repo.GetUpstreamPoolByName("OtherOrg", "Name")
+	dbError, _ := err.(*database.Error)
+	assert.Equal(t, &database.Error{
+		Code:    database.UPSTREAM_POOL_NOT_FOUND,
+		Message: "Upstream pool with organization OtherOrg and name Name not found",
+	}, dbError, "Error getting upstream pool")
+}
+
+func TestMemoryRepo_GetUpstreamPools(t *testing.T) {
+	pool1 := api.UpstreamPool{ID: "PoolID1", Name: "b", Org: "org1", Path: "/path/"}
+	pool2 := api.UpstreamPool{ID: "PoolID2", Name: "a", Org: "org1", Path: "/other/"}
+	pool3 := api.UpstreamPool{ID: "PoolID3", Name: "c", Org: "org2", Path: "/path/"}
+	testcases := map[string]struct {
+		// Memory Repo Args
+		filter *api.Filter
+		// Expected result
+		expectedResponse []api.UpstreamPool
+		expectedTotal    int
+	}{
+		"OkCaseOrg": {
+			filter:           &api.Filter{Org: "org1"},
+			expectedResponse: []api.UpstreamPool{pool1, pool2},
+			expectedTotal:    2,
+		},
+		"OkCasePathPrefix": {
+			filter:           &api.Filter{PathPrefix: "/path/"},
+			expectedResponse: []api.UpstreamPool{pool1, pool3},
+			expectedTotal:    2,
+		},
+		"OkCaseOrderBy": {
+			filter:           &api.Filter{OrderBy: "name asc", Limit: 2},
+			expectedResponse: []api.UpstreamPool{pool2, pool1},
+			expectedTotal:    3,
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{upstreamPools: []api.UpstreamPool{pool1, pool2, pool3}}}
+
+		pools, total, err := repo.GetUpstreamPools(test.filter)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedTotal, total, "Error in test case %v", n)
+		assert.Equal(t, test.expectedResponse, pools, "Error in test case %v", n)
+	}
+}
+
+func TestMemoryRepo_UpdateUpstreamPool(t *testing.T) {
+	repo := &MemoryRepo{memoryStore: &memoryStore{
+		upstreamPools: []api.UpstreamPool{
+			{ID: "PoolID", Name: "Name", Org: "Org", Urn: "urn", Config: api.UpstreamPoolConfig{Targets: []string{"http://10.0.0.1"}}},
+			{ID: "OtherID", Name: "Other", Org: "Org", Urn: "otherUrn"},
+		},
+	}}
+	poolToUpdate := api.UpstreamPool{
+		ID:     "PoolID",
+		Name:   "NewName",
+		Org:    "Org",
+		Urn:    "newUrn",
+		Config: api.UpstreamPoolConfig{Targets: []string{"http://10.0.0.2"}},
+	}
+
+	updatedPool, err := repo.UpdateUpstreamPool(poolToUpdate, time.Time{})
+	assert.Nil(t, err, "Error updating upstream pool")
+	assert.Equal(t, &poolToUpdate, updatedPool, "Error updating upstream pool")
+
+	pool, err := repo.GetUpstreamPoolByName("Org", "NewName")
+	assert.Nil(t, err, "Error updating upstream pool")
+	assert.Equal(t, &poolToUpdate, pool, "Error updating upstream pool")
+
+	// Updates of an old version fail
+	_, err = repo.UpdateUpstreamPool(poolToUpdate, time.Now())
+	dbError, _ := err.(*database.Error)
+	assert.Equal(t, database.VERSION_CONFLICT, dbError.Code, "Error updating upstream pool")
+
+	// Names are unique in an org
+	poolToUpdate.Name = "Other"
+	_, err = repo.UpdateUpstreamPool(poolToUpdate, time.Time{})
+	dbError, _ = err.(*database.Error)
+	assert.Equal(t, database.INTERNAL_ERROR, dbError.Code, "Error updating upstream pool")
+}
+
+func TestMemoryRepo_RemoveUpstreamPool(t *testing.T) {
+	repo := &MemoryRepo{memoryStore: &memoryStore{
+		upstreamPools: []api.UpstreamPool{
+			{ID: "PoolID1"},
+			{ID: "PoolID2"},
+		},
+	}}
+
+	err := repo.RemoveUpstreamPool("PoolID1")
+	assert.Nil(t, err, "Error removing upstream pool")
+	assert.Equal(t, []api.UpstreamPool{{ID: "PoolID2"}}, repo.upstreamPools, "Error removing upstream pool")
+}
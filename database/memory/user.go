@@ -0,0 +1,288 @@
+package memory
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+)
+
+// USER REPOSITORY IMPLEMENTATION
+
+func (mr *MemoryRepo) AddUser(user api.User) (*api.User, error) {
+	mr.lock()
+	defer mr.unlock()
+
+	// Check unique keys
+	for _, u := range mr.users {
+		switch {
+		case u.ID == user.ID:
+			return nil, duplicatedKeyError("user", user.ID)
+		case u.ExternalID == user.ExternalID:
+			return nil, duplicatedKeyError("user", user.ExternalID)
+		case u.Urn == user.Urn:
+			return nil, duplicatedKeyError("user", user.Urn)
+		}
+	}
+
+	// Store user
+	userDB := storedUser(user)
+	mr.users = append(mr.users, userDB)
+
+	return &userDB, nil
+}
+
+func (mr *MemoryRepo) GetUserByExternalID(id string) (*api.User, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	for _, u := range mr.users {
+		if u.ExternalID == id {
+			user := u
+			return &user, nil
+		}
+	}
+
+	return nil, &database.Error{
+		Code:    database.USER_NOT_FOUND,
+		Message: fmt.Sprintf("User with externalId %v not found", id),
+	}
+}
+
+func (mr *MemoryRepo) GetUserByID(id string) (*api.User, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	return mr.getUserByID(id)
+}
+
+func (mr *MemoryRepo) GetUsersFiltered(filter *api.Filter) ([]api.User, int, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	users := []api.User{}
+	for _, u := range mr.users {
+		if strings.HasPrefix(u.Path, filter.PathPrefix) {
+			users = append(users, u)
+		}
+	}
+	sortByColumn(users, filter.OrderBy, func(i int, column string) interface{} {
+		return userColumn(&users[i], column)
+	})
+
+	start, end := pageBounds(len(users), filter)
+	return users[start:end], len(users), nil
+}
+
+func (mr *MemoryRepo) UpdateUser(user api.User, oldUpdateAt time.Time) (*api.User, error) {
+	mr.lock()
+	defer mr.unlock()
+
+	for i, u := range mr.users {
+		if u.ID == user.ID && u.UpdateAt.Equal(oldUpdateAt) {
+			mr.users[i] = storedUser(user)
+			return &user, nil
+		}
+	}
+
+	return nil, versionConflictError("User", user.ID)
+}
+
+func (mr *MemoryRepo) RemoveUser(id string) error {
+	mr.lock()
+	defer mr.unlock()
+
+	// Delete user
+	users := []api.User{}
+	for _, u := range mr.users {
+		if u.ID != id {
+			users = append(users, u)
+		}
+	}
+	mr.users = users
+
+	// Delete all user relations
+	mr.removeGroupUserRelations(func(r groupUserRelation) bool {
+		return r.userID == id
+	})
+
+	// Delete user policy relations
+	mr.removeUserPolicyRelations(func(r userPolicyRelation) bool {
+		return r.userID == id
+	})
+
+	return nil
+}
+
+func (mr *MemoryRepo) GetGroupsByUserID(id string, filter *api.Filter) ([]api.UserGroupRelation, int, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	relations := []groupUserRelation{}
+	for _, r := range mr.groupUserRelations {
+		if r.userID == id {
+			relations = append(relations, r)
+		}
+	}
+	sortByColumn(relations, filter.OrderBy, func(i int, column string) interface{} {
+		return relations[i].createAt
+	})
+
+	start, end := pageBounds(len(relations), filter)
+	groups := make([]api.UserGroupRelation, 0, end-start)
+	// Transform relations to API domain
+	for _, r := range relations[start:end] {
+		group, err := mr.getGroupByID(r.groupID)
+		// Error handling
+		if err != nil {
+			return nil, len(relations), &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: err.Error(),
+			}
+		}
+		groups = append(groups, &GroupUser{
+			Group:     group,
+			CreateAt:  r.createAt,
+			ExpiresAt: r.expiresAt,
+		})
+	}
+
+	return groups, len(relations), nil
+}
+
+func (mr *MemoryRepo) AttachUserPolicy(userID string, policyID string) error {
+	mr.lock()
+	defer mr.unlock()
+
+	// Check unique keys
+	for _, r := range mr.userPolicyRelations {
+		if r.userID == userID && r.policyID == policyID {
+			return duplicatedKeyError("user policy relation", userID+"-"+policyID)
+		}
+	}
+
+	// Store relation
+	mr.userPolicyRelations = append(mr.userPolicyRelations, userPolicyRelation{
+		userID:   userID,
+		policyID: policyID,
+		createAt: storedTime(time.Now()),
+	})
+
+	return nil
+}
+
+func (mr *MemoryRepo) DetachUserPolicy(userID string, policyID string) error {
+	mr.lock()
+	defer mr.unlock()
+
+	mr.removeUserPolicyRelations(func(r userPolicyRelation) bool {
+		return r.userID == userID && r.policyID == policyID
+	})
+
+	return nil
+}
+
+func (mr *MemoryRepo) IsAttachedToUser(userID string, policyID string) (bool, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	for _, r := range mr.userPolicyRelations {
+		if r.userID == userID && r.policyID == policyID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (mr *MemoryRepo) GetAttachedUserPolicies(userID string, filter *api.Filter) ([]api.PolicyUserRelation, int, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	relations := []userPolicyRelation{}
+	for _, r := range mr.userPolicyRelations {
+		if r.userID == userID {
+			relations = append(relations, r)
+		}
+	}
+	sortByColumn(relations, filter.OrderBy, func(i int, column string) interface{} {
+		return relations[i].createAt
+	})
+
+	start, end := pageBounds(len(relations), filter)
+	policies := make([]api.PolicyUserRelation, 0, end-start)
+	// Transform relations to API domain
+	for _, r := range relations[start:end] {
+		policy, err := mr.getPolicyByID(r.policyID)
+		// Error handling
+		if err != nil {
+			return nil, len(relations), &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: err.Error(),
+			}
+		}
+		policies = append(policies, &PolicyUser{
+			Policy:   policy,
+			CreateAt: r.createAt,
+		})
+	}
+
+	return policies, len(relations), nil
+}
+
+// PRIVATE HELPER METHODS
+
+// Retrieve a user by its id. Caller must hold the lock
+func (mr *MemoryRepo) getUserByID(id string) (*api.User, error) {
+	for _, u := range mr.users {
+		if u.ID == id {
+			user := u
+			return &user, nil
+		}
+	}
+
+	return nil, &database.Error{
+		Code:    database.USER_NOT_FOUND,
+		Message: fmt.Sprintf("User with id %v not found", id),
+	}
+}
+
+// Delete the user policy relations that match. Caller must hold the lock
+func (mr *MemoryRepo) removeUserPolicyRelations(match func(r userPolicyRelation) bool) int {
+	relations := []userPolicyRelation{}
+	for _, r := range mr.userPolicyRelations {
+		if !match(r) {
+			relations = append(relations, r)
+		}
+	}
+	removed := len(mr.userPolicyRelations) - len(relations)
+	mr.userPolicyRelations = relations
+	return removed
+}
+
+// Transform a user for API into the user stored
+func storedUser(user api.User) api.User {
+	user.CreateAt = storedTime(user.CreateAt)
+	user.UpdateAt = storedTime(user.UpdateAt)
+	return user
+}
+
+// Column value of a user used to sort them
+func userColumn(user *api.User, column string) interface{} {
+	switch column {
+	case "path":
+		return user.Path
+	case "external_id":
+		return user.ExternalID
+	case "create_at":
+		return user.CreateAt
+	case "update_at":
+		return user.UpdateAt
+	case "urn":
+		return user.Urn
+	default:
+		return nil
+	}
+}
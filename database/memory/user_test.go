@@ -0,0 +1,351 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRepo_AddUser(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousUsers []api.User
+		// Memory Repo Args
+		userToCreate *api.User
+		// Expected result
+		expectedResponse *api.User
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			userToCreate: &api.User{
+				ID:         "UserID",
+				ExternalID: "ExternalID",
+				Path:       "Path",
+				Urn:        "urn",
+				CreateAt:   now,
+				UpdateAt:   now,
+			},
+			expectedResponse: &api.User{
+				ID:         "UserID",
+				ExternalID: "ExternalID",
+				Path:       "Path",
+				Urn:        "urn",
+				CreateAt:   now,
+				UpdateAt:   now,
+			},
+		},
+		"ErrorCaseUserAlreadyExist": {
+			previousUsers: []api.User{
+				{
+					ID:         "UserID",
+					ExternalID: "ExternalID",
+					Urn:        "urn",
+				},
+			},
+			userToCreate: &api.User{
+				ID:         "UserID",
+				ExternalID: "ExternalID",
+				Path:       "Path",
+				Urn:        "urn",
+				CreateAt:   now,
+				UpdateAt:   now,
+			},
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Duplicated key UserID for user",
+			},
+		},
+		"ErrorCaseExternalIDAlreadyExist": {
+			previousUsers: []api.User{
+				{
+					ID:         "UserID1",
+					ExternalID: "ExternalID",
+					Urn:        "urn1",
+				},
+			},
+			userToCreate: &api.User{
+				ID:         "UserID2",
+				ExternalID: "ExternalID",
+				Path:       "Path",
+				Urn:        "urn2",
+				CreateAt:   now,
+				UpdateAt:   now,
+			},
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Duplicated key ExternalID for user",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{users: test.previousUsers}}
+
+		// Call to repository to store an user
+		storedUser, err := repo.AddUser(*test.userToCreate)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, storedUser, "Error in test case %v", n)
+
+			// Check user stored
+			user, err := repo.GetUserByExternalID(test.userToCreate.ExternalID)
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, user, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestMemoryRepo_GetUserByExternalID(t *testing.T) {
+	testcases := map[string]struct {
+		// Previous data
+		previousUsers []api.User
+		// Memory Repo Args
+		externalID string
+		// Expected result
+		expectedResponse *api.User
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			previousUsers: []api.User{
+				{ID: "UserID1", ExternalID: "ExternalID1"},
+				{ID: "UserID2", ExternalID: "ExternalID2"},
+			},
+			externalID:       "ExternalID2",
+			expectedResponse: &api.User{ID: "UserID2", ExternalID: "ExternalID2"},
+		},
+		"ErrorCaseUserNotExist": {
+			previousUsers: []api.User{
+				{ID: "UserID1", ExternalID: "ExternalID1"},
+			},
+			externalID: "ExternalID2",
+			expectedError: &database.Error{
+				Code:    database.USER_NOT_FOUND,
+				Message: "User with externalId ExternalID2 not found",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{users: test.previousUsers}}
+
+		user, err := repo.GetUserByExternalID(test.externalID)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, user, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestMemoryRepo_GetUsersFiltered(t *testing.T) {
+	now := time.Now().UTC()
+	user1 := api.User{ID: "UserID1", ExternalID: "ExternalID1", Path: "/path/a/", CreateAt: now}
+	user2 := api.User{ID: "UserID2", ExternalID: "ExternalID2", Path: "/path/b/", CreateAt: now.Add(time.Second)}
+	user3 := api.User{ID: "UserID3", ExternalID: "ExternalID3", Path: "/other/", CreateAt: now.Add(2 * time.Second)}
+	testcases := map[string]struct {
+		// Memory Repo Args
+		filter *api.Filter
+		// Expected result
+		expectedResponse []api.User
+		expectedTotal    int
+	}{
+		"OkCaseNoFilter": {
+			filter:           &api.Filter{},
+			expectedResponse: []api.User{user1, user2, user3},
+			expectedTotal:    3,
+		},
+		"OkCasePathPrefix": {
+			filter:           &api.Filter{PathPrefix: "/path/"},
+			expectedResponse: []api.User{user1, user2},
+			expectedTotal:    2,
+		},
+		"OkCaseOrderBy": {
+			filter:           &api.Filter{OrderBy: "create_at desc"},
+			expectedResponse: []api.User{user3, user2, user1},
+			expectedTotal:    3,
+		},
+		"OkCasePagination": {
+			filter:           &api.Filter{Offset: 1, Limit: 1},
+			expectedResponse: []api.User{user2},
+			expectedTotal:    3,
+		},
+		"OkCaseNoResults": {
+			filter:           &api.Filter{PathPrefix: "/none/"},
+			expectedResponse: []api.User{},
+			expectedTotal:    0,
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{users: []api.User{user1, user2, user3}}}
+
+		users, total, err := repo.GetUsersFiltered(test.filter)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedTotal, total, "Error in test case %v", n)
+		assert.Equal(t, test.expectedResponse, users, "Error in test case %v", n)
+	}
+}
+
+func TestMemoryRepo_RemoveUser(t *testing.T) {
+	repo := &MemoryRepo{memoryStore: &memoryStore{
+		users: []api.User{
+			{ID: "UserID1", ExternalID: "ExternalID1"},
+			{ID: "UserID2", ExternalID: "ExternalID2"},
+		},
+		groupUserRelations: []groupUserRelation{
+			{userID: "UserID1", groupID: "GroupID"},
+			{userID: "UserID2", groupID: "GroupID"},
+		},
+		userPolicyRelations: []userPolicyRelation{
+			{userID: "UserID1", policyID: "PolicyID"},
+			{userID: "UserID2", policyID: "PolicyID"},
+		},
+	}}
+
+	err := repo.RemoveUser("UserID1")
+	assert.Nil(t, err, "Error removing user")
+
+	// Check user and its relations were removed
+	assert.Equal(t, []api.User{{ID: "UserID2", ExternalID: "ExternalID2"}}, repo.users, "Error removing user")
+	assert.Equal(t, []groupUserRelation{{userID: "UserID2", groupID: "GroupID"}}, repo.groupUserRelations,
+		"Error removing user relations")
+	assert.Equal(t, []userPolicyRelation{{userID: "UserID2", policyID: "PolicyID"}}, repo.userPolicyRelations,
+		"Error removing user policy relations")
+}
+
+func TestMemoryRepo_GetGroupsByUserID(t *testing.T) {
+	now := time.Now().UTC()
+	expiresAt := now.Add(time.Hour)
+	testcases := map[string]struct {
+		// Previous data
+		previousGroups    []api.Group
+		previousRelations []groupUserRelation
+		// Memory Repo Args
+		userID string
+		filter *api.Filter
+		// Expected result
+		expectedResponse []api.UserGroupRelation
+		expectedTotal    int
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			previousGroups: []api.Group{
+				{ID: "GroupID1", Name: "Name1"},
+				{ID: "GroupID2", Name: "Name2"},
+			},
+			previousRelations: []groupUserRelation{
+				{userID: "UserID", groupID: "GroupID1", createAt: now},
+				{userID: "OtherUserID", groupID: "GroupID1", createAt: now},
+				{userID: "UserID", groupID: "GroupID2", createAt: now.Add(time.Second), expiresAt: &expiresAt},
+			},
+			userID: "UserID",
+			filter: &api.Filter{OrderBy: "create_at desc"},
+			expectedResponse: []api.UserGroupRelation{
+				&GroupUser{
+					Group:     &api.Group{ID: "GroupID2", Name: "Name2"},
+					CreateAt:  now.Add(time.Second),
+					ExpiresAt: &expiresAt,
+				},
+				&GroupUser{
+					Group:    &api.Group{ID: "GroupID1", Name: "Name1"},
+					CreateAt: now,
+				},
+			},
+			expectedTotal: 2,
+		},
+		"OkCaseNoGroups": {
+			userID:           "UserID",
+			filter:           &api.Filter{},
+			expectedResponse: []api.UserGroupRelation{},
+		},
+		"ErrorCaseGroupNotExist": {
+			previousRelations: []groupUserRelation{
+				{userID: "UserID", groupID: "GroupID1", createAt: now},
+			},
+			userID: "UserID",
+			filter: &api.Filter{},
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Code: GroupNotFound, Message: Group with id GroupID1 not found",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{
+			groups:             test.previousGroups,
+			groupUserRelations: test.previousRelations,
+		}}
+
+		groups, total, err := repo.GetGroupsByUserID(test.userID, test.filter)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedTotal, total, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, groups, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestMemoryRepo_AttachUserPolicy(t *testing.T) {
+	testcases := map[string]struct {
+		// Previous data
+		previousRelations []userPolicyRelation
+		// Expected result
+		expectedError *database.Error
+	}{
+		"OkCase": {},
+		"ErrorCaseAlreadyAttached": {
+			previousRelations: []userPolicyRelation{
+				{userID: "UserID", policyID: "PolicyID"},
+			},
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Duplicated key UserID-PolicyID for user policy relation",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{userPolicyRelations: test.previousRelations}}
+
+		err := repo.AttachUserPolicy("UserID", "PolicyID")
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			attached, err := repo.IsAttachedToUser("UserID", "PolicyID")
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.True(t, attached, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestMemoryRepo_DetachUserPolicy(t *testing.T) {
+	repo := &MemoryRepo{memoryStore: &memoryStore{
+		userPolicyRelations: []userPolicyRelation{
+			{userID: "UserID", policyID: "PolicyID1"},
+			{userID: "UserID", policyID: "PolicyID2"},
+		},
+	}}
+
+	err := repo.DetachUserPolicy("UserID", "PolicyID1")
+	assert.Nil(t, err, "Error detaching policy")
+
+	attached, _ := repo.IsAttachedToUser("UserID", "PolicyID1")
+	assert.False(t, attached, "Error detaching policy")
+	attached, _ = repo.IsAttachedToUser("UserID", "PolicyID2")
+	assert.True(t, attached, "Error detaching policy")
+}
@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+)
+
+// GroupUser struct contains (Group-User) relationship
+type GroupUser struct {
+	User      *api.User
+	Group     *api.Group
+	CreateAt  time.Time
+	ExpiresAt *time.Time
+}
+
+// GetUser returns a member of a GroupUser relation
+func (gu *GroupUser) GetUser() *api.User {
+	return gu.User
+}
+
+// GetGroup returns a Group of a GroupUser relation
+func (gu *GroupUser) GetGroup() *api.Group {
+	return gu.Group
+}
+
+// GetDate returns the date when the relation was created
+func (gu *GroupUser) GetDate() time.Time {
+	return gu.CreateAt
+}
+
+// GetExpiresAt returns the date when the relation expires, or nil if it doesn't expire
+func (gu *GroupUser) GetExpiresAt() *time.Time {
+	return gu.ExpiresAt
+}
+
+// PolicyGroup struct contains (Policy-Group) relationship
+type PolicyGroup struct {
+	Group     *api.Group
+	Policy    *api.Policy
+	CreateAt  time.Time
+	ExpiresAt *time.Time
+}
+
+// GetGroup returns a Group of a PolicyGroup relation
+func (pg *PolicyGroup) GetGroup() *api.Group {
+	return pg.Group
+}
+
+// GetPolicy returns a Policy of a PolicyGroup relation
+func (pg *PolicyGroup) GetPolicy() *api.Policy {
+	return pg.Policy
+}
+
+// GetDate returns the date when the relation was created
+func (pg *PolicyGroup) GetDate() time.Time {
+	return pg.CreateAt
+}
+
+// GetExpiresAt returns the date when the relation expires, or nil if it doesn't expire
+func (pg *PolicyGroup) GetExpiresAt() *time.Time {
+	return pg.ExpiresAt
+}
+
+// PolicyUser struct contains (Policy-User) relationship
+type PolicyUser struct {
+	User     *api.User
+	Policy   *api.Policy
+	CreateAt time.Time
+}
+
+// GetUser returns a User of a PolicyUser relation
+func (pu *PolicyUser) GetUser() *api.User {
+	return pu.User
+}
+
+// GetPolicy returns a Policy of a PolicyUser relation
+func (pu *PolicyUser) GetPolicy() *api.Policy {
+	return pu.Policy
+}
+
+// GetDate returns the date when the relation was created
+func (pu *PolicyUser) GetDate() time.Time {
+	return pu.CreateAt
+}
+
+// GroupGroup struct contains (Parent-Child) group relationship
+type GroupGroup struct {
+	Parent   *api.Group
+	Child    *api.Group
+	CreateAt time.Time
+}
+
+// GetParent returns the parent Group of a GroupGroup relation
+func (gg *GroupGroup) GetParent() *api.Group {
+	return gg.Parent
+}
+
+// GetChild returns the child Group of a GroupGroup relation
+func (gg *GroupGroup) GetChild() *api.Group {
+	return gg.Child
+}
+
+// GetDate returns the date when the relation was created
+func (gg *GroupGroup) GetDate() time.Time {
+	return gg.CreateAt
+}
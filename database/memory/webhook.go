@@ -0,0 +1,193 @@
+package memory
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+)
+
+// WEBHOOK REPOSITORY IMPLEMENTATION
+
+func (mr *MemoryRepo) AddWebhook(webhook api.Webhook) (*api.Webhook, error) {
+	mr.lock()
+	defer mr.unlock()
+
+	// Check unique keys
+	for _, w := range mr.webhooks {
+		switch {
+		case w.ID == webhook.ID:
+			return nil, duplicatedKeyError("webhook", webhook.ID)
+		case w.Name == webhook.Name:
+			return nil, duplicatedKeyError("webhook", webhook.Name)
+		case w.Urn == webhook.Urn:
+			return nil, duplicatedKeyError("webhook", webhook.Urn)
+		}
+	}
+
+	webhookDB := storedWebhook(webhook)
+	mr.webhooks = append(mr.webhooks, webhookDB)
+
+	createdWebhook := copyWebhook(webhookDB)
+	return &createdWebhook, nil
+}
+
+func (mr *MemoryRepo) GetWebhookByName(name string) (*api.Webhook, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	for _, w := range mr.webhooks {
+		if w.Name == name {
+			webhook := copyWebhook(w)
+			return &webhook, nil
+		}
+	}
+
+	return nil, &database.Error{
+		Code:    database.WEBHOOK_NOT_FOUND,
+		Message: fmt.Sprintf("Webhook with name %v not found", name),
+	}
+}
+
+func (mr *MemoryRepo) GetWebhooksFiltered(filter *api.Filter) ([]api.Webhook, int, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	webhooks := []api.Webhook{}
+	for _, w := range mr.webhooks {
+		if strings.HasPrefix(w.Path, filter.PathPrefix) {
+			webhooks = append(webhooks, copyWebhook(w))
+		}
+	}
+	sortByColumn(webhooks, filter.OrderBy, func(i int, column string) interface{} {
+		return webhookColumn(&webhooks[i], column)
+	})
+
+	start, end := pageBounds(len(webhooks), filter)
+	return webhooks[start:end], len(webhooks), nil
+}
+
+func (mr *MemoryRepo) UpdateWebhook(webhook api.Webhook, oldUpdateAt time.Time) (*api.Webhook, error) {
+	mr.lock()
+	defer mr.unlock()
+
+	for i, w := range mr.webhooks {
+		if w.ID == webhook.ID && w.UpdateAt.Equal(oldUpdateAt) {
+			mr.webhooks[i] = storedWebhook(webhook)
+			updatedWebhook := copyWebhook(mr.webhooks[i])
+			return &updatedWebhook, nil
+		}
+	}
+
+	return nil, versionConflictError("Webhook", webhook.ID)
+}
+
+func (mr *MemoryRepo) RemoveWebhook(id string) error {
+	mr.lock()
+	defer mr.unlock()
+
+	// Delete webhook with its dead letters
+	webhooks := []api.Webhook{}
+	for _, w := range mr.webhooks {
+		if w.ID != id {
+			webhooks = append(webhooks, w)
+		}
+	}
+	mr.webhooks = webhooks
+
+	deadLetters := []api.WebhookDeadLetter{}
+	for _, dl := range mr.webhookDeadLetters {
+		if dl.WebhookID != id {
+			deadLetters = append(deadLetters, dl)
+		}
+	}
+	mr.webhookDeadLetters = deadLetters
+
+	return nil
+}
+
+func (mr *MemoryRepo) AddWebhookDeadLetter(deadLetter api.WebhookDeadLetter) error {
+	mr.lock()
+	defer mr.unlock()
+
+	for _, dl := range mr.webhookDeadLetters {
+		if dl.ID == deadLetter.ID {
+			return duplicatedKeyError("webhook dead letter", deadLetter.ID)
+		}
+	}
+
+	deadLetter.CreateAt = storedTime(deadLetter.CreateAt)
+	deadLetter.Event.CreateAt = storedTime(deadLetter.Event.CreateAt)
+	mr.webhookDeadLetters = append(mr.webhookDeadLetters, deadLetter)
+
+	return nil
+}
+
+func (mr *MemoryRepo) GetWebhookDeadLettersFiltered(webhookID string, filter *api.Filter) ([]api.WebhookDeadLetter, int, error) {
+	mr.rLock()
+	defer mr.rUnlock()
+
+	deadLetters := []api.WebhookDeadLetter{}
+	for _, dl := range mr.webhookDeadLetters {
+		if dl.WebhookID == webhookID {
+			deadLetters = append(deadLetters, dl)
+		}
+	}
+	orderBy := filter.OrderBy
+	if len(orderBy) < 1 {
+		orderBy = "create_at"
+	}
+	sortByColumn(deadLetters, orderBy, func(i int, column string) interface{} {
+		return webhookDeadLetterColumn(&deadLetters[i], column)
+	})
+
+	start, end := pageBounds(len(deadLetters), filter)
+	return deadLetters[start:end], len(deadLetters), nil
+}
+
+// PRIVATE HELPER METHODS
+
+// Transform a webhook for API into the webhook stored, that doesn't share events with it
+func storedWebhook(webhook api.Webhook) api.Webhook {
+	webhook.CreateAt = storedTime(webhook.CreateAt)
+	webhook.UpdateAt = storedTime(webhook.UpdateAt)
+	return copyWebhook(webhook)
+}
+
+// Copy a webhook, so the copy can be modified without changing the original events
+func copyWebhook(webhook api.Webhook) api.Webhook {
+	webhook.Events = append([]string{}, webhook.Events...)
+	return webhook
+}
+
+// Column value of a webhook used to sort them
+func webhookColumn(webhook *api.Webhook, column string) interface{} {
+	switch column {
+	case "name":
+		return webhook.Name
+	case "path":
+		return webhook.Path
+	case "create_at":
+		return webhook.CreateAt
+	case "update_at":
+		return webhook.UpdateAt
+	case "urn":
+		return webhook.Urn
+	default:
+		return nil
+	}
+}
+
+// Column value of a webhook dead letter used to sort them
+func webhookDeadLetterColumn(deadLetter *api.WebhookDeadLetter, column string) interface{} {
+	switch column {
+	case "attempts":
+		return deadLetter.Attempts
+	case "create_at":
+		return deadLetter.CreateAt
+	default:
+		return nil
+	}
+}
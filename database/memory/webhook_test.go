@@ -0,0 +1,230 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRepo_AddWebhook(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousWebhooks []api.Webhook
+		// Memory Repo Args
+		webhookToCreate *api.Webhook
+		// Expected result
+		expectedResponse *api.Webhook
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			webhookToCreate: &api.Webhook{
+				ID:       "WebhookID",
+				Name:     "Name",
+				Path:     "Path",
+				Urn:      "urn",
+				CreateAt: now,
+				UpdateAt: now,
+				URL:      "https://hooks.example.com",
+				Secret:   "secret",
+				Events:   []string{"user.*"},
+			},
+			expectedResponse: &api.Webhook{
+				ID:       "WebhookID",
+				Name:     "Name",
+				Path:     "Path",
+				Urn:      "urn",
+				CreateAt: now,
+				UpdateAt: now,
+				URL:      "https://hooks.example.com",
+				Secret:   "secret",
+				Events:   []string{"user.*"},
+			},
+		},
+		"ErrorCaseWebhookAlreadyExist": {
+			previousWebhooks: []api.Webhook{
+				{ID: "WebhookID", Urn: "urn"},
+			},
+			webhookToCreate: &api.Webhook{
+				ID:   "WebhookID",
+				Name: "Name",
+				Urn:  "urn",
+			},
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Duplicated key WebhookID for webhook",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{webhooks: test.previousWebhooks}}
+
+		storedWebhook, err := repo.AddWebhook(*test.webhookToCreate)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, storedWebhook, "Error in test case %v", n)
+
+			// Check webhook stored
+			webhook, err := repo.GetWebhookByName(test.webhookToCreate.Name)
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, webhook, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestMemoryRepo_GetWebhookByName(t *testing.T) {
+	repo := &MemoryRepo{memoryStore: &memoryStore{
+		webhooks: []api.Webhook{
+			{ID: "WebhookID", Name: "Name"},
+		},
+	}}
+
+	_, err := repo.GetWebhookByName("OtherName")
+	dbError, _ := err.(*database.Error)
+	assert.Equal(t, &database.Error{
+		Code:    database.WEBHOOK_NOT_FOUND,
+		Message: "Webhook with name OtherName not found",
+	}, dbError, "Error getting webhook")
+}
+
+func TestMemoryRepo_GetWebhooksFiltered(t *testing.T) {
+	webhook1 := api.Webhook{ID: "WebhookID1", Name: "b", Path: "/path/", Events: []string{}}
+	webhook2 := api.Webhook{ID: "WebhookID2", Name: "a", Path: "/other/", Events: []string{}}
+	testcases := map[string]struct {
+		// Memory Repo Args
+		filter *api.Filter
+		// Expected result
+		expectedResponse []api.Webhook
+		expectedTotal    int
+	}{
+		"OkCasePathPrefix": {
+			filter:           &api.Filter{PathPrefix: "/path/"},
+			expectedResponse: []api.Webhook{webhook1},
+			expectedTotal:    1,
+		},
+		"OkCaseOrderBy": {
+			filter:           &api.Filter{OrderBy: "name asc"},
+			expectedResponse: []api.Webhook{webhook2, webhook1},
+			expectedTotal:    2,
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{webhooks: []api.Webhook{webhook1, webhook2}}}
+
+		webhooks, total, err := repo.GetWebhooksFiltered(test.filter)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedTotal, total, "Error in test case %v", n)
+		assert.Equal(t, test.expectedResponse, webhooks, "Error in test case %v", n)
+	}
+}
+
+func TestMemoryRepo_UpdateWebhook(t *testing.T) {
+	repo := &MemoryRepo{memoryStore: &memoryStore{
+		webhooks: []api.Webhook{
+			{ID: "WebhookID", Name: "Name", Events: []string{"user.*"}},
+		},
+	}}
+	webhookToUpdate := api.Webhook{
+		ID:     "WebhookID",
+		Name:   "NewName",
+		Events: []string{"group.*"},
+	}
+
+	updatedWebhook, err := repo.UpdateWebhook(webhookToUpdate, time.Time{})
+	assert.Nil(t, err, "Error updating webhook")
+	assert.Equal(t, &webhookToUpdate, updatedWebhook, "Error updating webhook")
+
+	webhook, err := repo.GetWebhookByName("NewName")
+	assert.Nil(t, err, "Error updating webhook")
+	assert.Equal(t, &webhookToUpdate, webhook, "Error updating webhook")
+
+	// Updates of an old version fail
+	_, err = repo.UpdateWebhook(webhookToUpdate, time.Now())
+	dbError, _ := err.(*database.Error)
+	assert.Equal(t, database.VERSION_CONFLICT, dbError.Code, "Error updating webhook")
+}
+
+func TestMemoryRepo_RemoveWebhook(t *testing.T) {
+	repo := &MemoryRepo{memoryStore: &memoryStore{
+		webhooks: []api.Webhook{
+			{ID: "WebhookID1"},
+			{ID: "WebhookID2"},
+		},
+		webhookDeadLetters: []api.WebhookDeadLetter{
+			{ID: "DeadLetterID1", WebhookID: "WebhookID1"},
+			{ID: "DeadLetterID2", WebhookID: "WebhookID2"},
+		},
+	}}
+
+	err := repo.RemoveWebhook("WebhookID1")
+	assert.Nil(t, err, "Error removing webhook")
+	assert.Equal(t, []api.Webhook{{ID: "WebhookID2"}}, repo.webhooks, "Error removing webhook")
+	assert.Equal(t, []api.WebhookDeadLetter{{ID: "DeadLetterID2", WebhookID: "WebhookID2"}}, repo.webhookDeadLetters,
+		"Error removing webhook")
+}
+
+func TestMemoryRepo_AddWebhookDeadLetter(t *testing.T) {
+	repo := &MemoryRepo{memoryStore: &memoryStore{
+		webhookDeadLetters: []api.WebhookDeadLetter{
+			{ID: "DeadLetterID1", WebhookID: "WebhookID"},
+		},
+	}}
+
+	err := repo.AddWebhookDeadLetter(api.WebhookDeadLetter{ID: "DeadLetterID1"})
+	dbError, _ := err.(*database.Error)
+	assert.Equal(t, &database.Error{
+		Code:    database.INTERNAL_ERROR,
+		Message: "Duplicated key DeadLetterID1 for webhook dead letter",
+	}, dbError, "Error adding webhook dead letter")
+
+	err = repo.AddWebhookDeadLetter(api.WebhookDeadLetter{ID: "DeadLetterID2", WebhookID: "WebhookID"})
+	assert.Nil(t, err, "Error adding webhook dead letter")
+	assert.Equal(t, 2, len(repo.webhookDeadLetters), "Error adding webhook dead letter")
+}
+
+func TestMemoryRepo_GetWebhookDeadLettersFiltered(t *testing.T) {
+	now := time.Now().UTC()
+	deadLetter1 := api.WebhookDeadLetter{ID: "DeadLetterID1", WebhookID: "WebhookID", Attempts: 5, CreateAt: now}
+	deadLetter2 := api.WebhookDeadLetter{ID: "DeadLetterID2", WebhookID: "WebhookID", Attempts: 3, CreateAt: now.Add(-time.Hour)}
+	deadLetter3 := api.WebhookDeadLetter{ID: "DeadLetterID3", WebhookID: "OtherWebhookID", Attempts: 1, CreateAt: now}
+	testcases := map[string]struct {
+		// Memory Repo Args
+		filter *api.Filter
+		// Expected result
+		expectedResponse []api.WebhookDeadLetter
+		expectedTotal    int
+	}{
+		"OkCaseDefaultOrder": {
+			filter:           &api.Filter{},
+			expectedResponse: []api.WebhookDeadLetter{deadLetter2, deadLetter1},
+			expectedTotal:    2,
+		},
+		"OkCaseOrderBy": {
+			filter:           &api.Filter{OrderBy: "attempts desc"},
+			expectedResponse: []api.WebhookDeadLetter{deadLetter1, deadLetter2},
+			expectedTotal:    2,
+		},
+		"OkCaseLimit": {
+			filter:           &api.Filter{Limit: 1},
+			expectedResponse: []api.WebhookDeadLetter{deadLetter2},
+			expectedTotal:    2,
+		},
+	}
+
+	for n, test := range testcases {
+		repo := &MemoryRepo{memoryStore: &memoryStore{webhookDeadLetters: []api.WebhookDeadLetter{deadLetter1, deadLetter2, deadLetter3}}}
+
+		deadLetters, total, err := repo.GetWebhookDeadLettersFiltered("WebhookID", test.filter)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedTotal, total, "Error in test case %v", n)
+		assert.Equal(t, test.expectedResponse, deadLetters, "Error in test case %v", n)
+	}
+}
@@ -0,0 +1,102 @@
+package postgresql
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+)
+
+// AUDIT REPOSITORY IMPLEMENTATION
+
+func (pr PostgresRepo) AddAuditEntry(entry api.AuditEntry) error {
+	// Create audit entry model
+	entryDB := &AuditEntry{
+		ID:        entry.ID,
+		Actor:     entry.Actor,
+		RequestID: entry.RequestID,
+		Action:    entry.Action,
+		Urn:       entry.Urn,
+		Before:    string(entry.Before),
+		After:     string(entry.After),
+		CreateAt:  entry.CreateAt.UnixNano(),
+	}
+
+	// Store audit entry
+	if err := pr.Dbmap.Create(entryDB).Error; err != nil {
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+func (pr PostgresRepo) GetAuditEntriesFiltered(filter *api.Filter) ([]api.AuditEntry, int, error) {
+	var total int
+	entries := []AuditEntry{}
+	query := pr.Dbmap
+
+	if len(filter.Actor) > 0 {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if len(filter.ResourceUrn) > 0 {
+		query = query.Where("urn = ?", filter.ResourceUrn)
+	}
+	if len(filter.Action) > 0 {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.From != nil {
+		query = query.Where("create_at >= ?", filter.From.UnixNano())
+	}
+	if filter.To != nil {
+		query = query.Where("create_at < ?", filter.To.UnixNano())
+	}
+	if len(filter.OrderBy) > 0 {
+		query = query.Order(filter.OrderBy)
+	} else {
+		query = query.Order("create_at")
+	}
+
+	// Error handling
+	if err := query.Find(&entries).Count(&total).Offset(filter.Offset).Limit(filter.Limit).Find(&entries).Error; err != nil {
+		return nil, total, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Transform audit entries for API
+	var apiEntries []api.AuditEntry
+	if entries != nil {
+		apiEntries = make([]api.AuditEntry, len(entries), cap(entries))
+		for i, e := range entries {
+			apiEntries[i] = *dbAuditEntryToAPIAuditEntry(&e)
+		}
+	}
+
+	return apiEntries, total, nil
+}
+
+// PRIVATE HELPER METHODS
+
+// Transform an audit entry retrieved from db into an audit entry for API
+func dbAuditEntryToAPIAuditEntry(entryDB *AuditEntry) *api.AuditEntry {
+	entry := &api.AuditEntry{
+		ID:        entryDB.ID,
+		Actor:     entryDB.Actor,
+		RequestID: entryDB.RequestID,
+		Action:    entryDB.Action,
+		Urn:       entryDB.Urn,
+		CreateAt:  time.Unix(0, entryDB.CreateAt).UTC(),
+	}
+	if len(entryDB.Before) > 0 {
+		entry.Before = json.RawMessage(entryDB.Before)
+	}
+	if len(entryDB.After) > 0 {
+		entry.After = json.RawMessage(entryDB.After)
+	}
+	return entry
+}
@@ -24,11 +24,11 @@ func (pr PostgresRepo) AddOidcProvider(oidcProvider api.OidcProvider) (*api.Oidc
 		IssuerURL: oidcProvider.IssuerURL,
 	}
 
-	transaction := pr.Dbmap.Begin()
+	transaction := pr.begin()
 
 	// Create OIDC Provider
 	if err := transaction.Create(oidcProviderDB).Error; err != nil {
-		transaction.Rollback()
+		pr.rollback(transaction)
 		return nil, &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
@@ -44,7 +44,7 @@ func (pr PostgresRepo) AddOidcProvider(oidcProvider api.OidcProvider) (*api.Oidc
 			Name:           oidcClientApi.Name,
 		}
 		if err := transaction.Create(oidcClientDB).Error; err != nil {
-			transaction.Rollback()
+			pr.rollback(transaction)
 			return nil, &database.Error{
 				Code:    database.INTERNAL_ERROR,
 				Message: err.Error(),
@@ -52,7 +52,7 @@ func (pr PostgresRepo) AddOidcProvider(oidcProvider api.OidcProvider) (*api.Oidc
 		}
 	}
 
-	transaction.Commit()
+	pr.commit(transaction)
 
 	// Create API OIDC Provider
 	oidcProviderApi := dbOidcProviderToAPIOidcProvider(oidcProviderDB)
@@ -149,7 +149,7 @@ func (pr PostgresRepo) GetOidcProvidersFiltered(filter *api.Filter) ([]api.OidcP
 	return apiOidcProviders, total, nil
 }
 
-func (pr PostgresRepo) UpdateOidcProvider(oidcProvider api.OidcProvider) (*api.OidcProvider, error) {
+func (pr PostgresRepo) UpdateOidcProvider(oidcProvider api.OidcProvider, oldUpdateAt time.Time) (*api.OidcProvider, error) {
 	oidcProviderDB := OidcProvider{
 		ID:        oidcProvider.ID,
 		Name:      oidcProvider.Name,
@@ -160,20 +160,30 @@ func (pr PostgresRepo) UpdateOidcProvider(oidcProvider api.OidcProvider) (*api.O
 		IssuerURL: oidcProvider.IssuerURL,
 	}
 
-	transaction := pr.Dbmap.Begin()
+	transaction := pr.begin()
 
 	// Update OIDC Provider
-	if err := transaction.Model(&OidcProvider{ID: oidcProvider.ID}).Update(oidcProviderDB).Error; err != nil {
-		transaction.Rollback()
+	query := transaction.Model(&OidcProvider{ID: oidcProvider.ID}).Where("update_at = ?", oldUpdateAt.UTC().UnixNano()).Update(oidcProviderDB)
+	if err := query.Error; err != nil {
+		pr.rollback(transaction)
 		return nil, &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
 		}
 	}
 
+	// Check if it was modified or removed by another request
+	if query.RowsAffected == 0 {
+		pr.rollback(transaction)
+		return nil, &database.Error{
+			Code:    database.VERSION_CONFLICT,
+			Message: fmt.Sprintf("OIDC provider with id %v was modified or removed by another request", oidcProvider.ID),
+		}
+	}
+
 	// Clean old OIDC Clients
 	if err := transaction.Where("oidc_provider_id like ?", oidcProvider.ID).Delete(OidcClient{}).Error; err != nil {
-		transaction.Rollback()
+		pr.rollback(transaction)
 		return nil, &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
@@ -188,7 +198,7 @@ func (pr PostgresRepo) UpdateOidcProvider(oidcProvider api.OidcProvider) (*api.O
 			Name:           oc.Name,
 		}
 		if err := transaction.Create(oidcClientDB).Error; err != nil {
-			transaction.Rollback()
+			pr.rollback(transaction)
 			return nil, &database.Error{
 				Code:    database.INTERNAL_ERROR,
 				Message: err.Error(),
@@ -196,18 +206,18 @@ func (pr PostgresRepo) UpdateOidcProvider(oidcProvider api.OidcProvider) (*api.O
 		}
 	}
 
-	transaction.Commit()
+	pr.commit(transaction)
 
 	return &oidcProvider, nil
 }
 
 func (pr PostgresRepo) RemoveOidcProvider(id string) error {
-	transaction := pr.Dbmap.Begin()
+	transaction := pr.begin()
 
 	// Delete OIDC Provider
 	transaction.Where("id like ?", id).Delete(&OidcProvider{})
 	if err := transaction.Error; err != nil {
-		transaction.Rollback()
+		pr.rollback(transaction)
 		return &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
@@ -217,7 +227,7 @@ func (pr PostgresRepo) RemoveOidcProvider(id string) error {
 	// Delete all OIDC Clients
 	transaction.Where("oidc_provider_id like ?", id).Delete(&OidcClient{})
 	if err := transaction.Error; err != nil {
-		transaction.Rollback()
+		pr.rollback(transaction)
 		return &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
@@ -225,7 +235,7 @@ func (pr PostgresRepo) RemoveOidcProvider(id string) error {
 
 	}
 
-	transaction.Commit()
+	pr.commit(transaction)
 	return nil
 }
 
@@ -118,7 +118,7 @@ func (pr PostgresRepo) GetGroupsFiltered(filter *api.Filter) ([]api.Group, int,
 	return apiGroups, total, nil
 }
 
-func (pr PostgresRepo) UpdateGroup(group api.Group) (*api.Group, error) {
+func (pr PostgresRepo) UpdateGroup(group api.Group, oldUpdateAt time.Time) (*api.Group, error) {
 	groupDB := Group{
 		ID:       group.ID,
 		Name:     group.Name,
@@ -130,7 +130,7 @@ func (pr PostgresRepo) UpdateGroup(group api.Group) (*api.Group, error) {
 	}
 
 	// Update group
-	query := pr.Dbmap.Model(&Group{ID: group.ID}).Updates(groupDB)
+	query := pr.Dbmap.Model(&Group{ID: group.ID}).Where("update_at = ?", oldUpdateAt.UTC().UnixNano()).Updates(groupDB)
 
 	// Check if group exist
 	if query.RecordNotFound() {
@@ -148,16 +148,24 @@ func (pr PostgresRepo) UpdateGroup(group api.Group) (*api.Group, error) {
 		}
 	}
 
+	// Check if it was modified or removed by another request
+	if query.RowsAffected == 0 {
+		return nil, &database.Error{
+			Code:    database.VERSION_CONFLICT,
+			Message: fmt.Sprintf("Group with id %v was modified or removed by another request", group.ID),
+		}
+	}
+
 	return &group, nil
 }
 
 func (pr PostgresRepo) RemoveGroup(id string) error {
-	transaction := pr.Dbmap.Begin()
+	transaction := pr.begin()
 
 	// Delete group
 	transaction.Where("id like ?", id).Delete(&Group{})
 	if err := transaction.Error; err != nil {
-		transaction.Rollback()
+		pr.rollback(transaction)
 		return &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
@@ -167,7 +175,7 @@ func (pr PostgresRepo) RemoveGroup(id string) error {
 	// Delete all group relations
 	transaction.Where("group_id like ?", id).Delete(&GroupUserRelation{})
 	if err := transaction.Error; err != nil {
-		transaction.Rollback()
+		pr.rollback(transaction)
 		return &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
@@ -177,23 +185,34 @@ func (pr PostgresRepo) RemoveGroup(id string) error {
 	// Delete all policy relations
 	transaction.Where("group_id like ?", id).Delete(&GroupPolicyRelation{})
 	if err := transaction.Error; err != nil {
-		transaction.Rollback()
+		pr.rollback(transaction)
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Delete all parent and child group relations
+	transaction.Where("parent_id like ? OR child_id like ?", id, id).Delete(&GroupGroupRelation{})
+	if err := transaction.Error; err != nil {
+		pr.rollback(transaction)
 		return &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
 		}
 	}
 
-	transaction.Commit()
+	pr.commit(transaction)
 	return nil
 }
 
-func (pr PostgresRepo) AddMember(userID string, groupID string) error {
+func (pr PostgresRepo) AddMember(userID string, groupID string, expiresAt *time.Time) error {
 	// Create relation
 	relation := &GroupUserRelation{
-		UserID:   userID,
-		GroupID:  groupID,
-		CreateAt: time.Now().UTC().UnixNano(),
+		UserID:    userID,
+		GroupID:   groupID,
+		CreateAt:  time.Now().UTC().UnixNano(),
+		ExpiresAt: apiExpirationToDB(expiresAt),
 	}
 
 	// Store relation
@@ -276,8 +295,9 @@ func (pr PostgresRepo) GetGroupMembers(groupID string, filter *api.Filter) ([]ap
 			}
 
 			membersList[i] = &GroupUser{
-				User:     user,
-				CreateAt: time.Unix(0, m.CreateAt).UTC(),
+				User:      user,
+				CreateAt:  time.Unix(0, m.CreateAt).UTC(),
+				ExpiresAt: dbExpirationToAPI(m.ExpiresAt),
 			}
 		}
 	}
@@ -285,12 +305,13 @@ func (pr PostgresRepo) GetGroupMembers(groupID string, filter *api.Filter) ([]ap
 	return membersList, total, nil
 }
 
-func (pr PostgresRepo) AttachPolicy(groupID string, policyID string) error {
+func (pr PostgresRepo) AttachPolicy(groupID string, policyID string, expiresAt *time.Time) error {
 	// Create relation
 	relation := &GroupPolicyRelation{
-		GroupID:  groupID,
-		PolicyID: policyID,
-		CreateAt: time.Now().UTC().UnixNano(),
+		GroupID:   groupID,
+		PolicyID:  policyID,
+		CreateAt:  time.Now().UTC().UnixNano(),
+		ExpiresAt: apiExpirationToDB(expiresAt),
 	}
 
 	// Store relation
@@ -373,8 +394,9 @@ func (pr PostgresRepo) GetAttachedPolicies(groupID string, filter *api.Filter) (
 			}
 
 			policies[i] = &PolicyGroup{
-				Policy:   policy,
-				CreateAt: time.Unix(0, r.CreateAt).UTC(),
+				Policy:    policy,
+				CreateAt:  time.Unix(0, r.CreateAt).UTC(),
+				ExpiresAt: dbExpirationToAPI(r.ExpiresAt),
 			}
 		}
 	}
@@ -382,6 +404,166 @@ func (pr PostgresRepo) GetAttachedPolicies(groupID string, filter *api.Filter) (
 	return policies, total, nil
 }
 
+func (pr PostgresRepo) AddChildGroup(childID string, parentID string) error {
+	// Create relation
+	relation := &GroupGroupRelation{
+		ParentID: parentID,
+		ChildID:  childID,
+		CreateAt: time.Now().UTC().UnixNano(),
+	}
+
+	// Store relation
+	err := pr.Dbmap.Create(relation).Error
+
+	// Error handling
+	if err != nil {
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+func (pr PostgresRepo) RemoveChildGroup(childID string, parentID string) error {
+	err := pr.Dbmap.Where("child_id like ? AND parent_id like ?", childID, parentID).Delete(&GroupGroupRelation{}).Error
+
+	// Error handling
+	if err != nil {
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+	return nil
+}
+
+func (pr PostgresRepo) IsChildOfGroup(childID string, parentID string) (bool, error) {
+	relation := GroupGroupRelation{}
+	query := pr.Dbmap.Where("child_id like ? AND parent_id like ?", childID, parentID).First(&relation)
+
+	// Check if relation exists
+	if query.RecordNotFound() {
+		return false, nil
+	}
+
+	// Error Handling
+	if err := query.Error; err != nil {
+		return false, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return true, nil
+}
+
+func (pr PostgresRepo) GetChildGroups(parentID string, filter *api.Filter) ([]api.GroupGroupRelation, int, error) {
+	var total int
+	relations := []GroupGroupRelation{}
+	query := pr.Dbmap.Where("parent_id like ?", parentID)
+
+	if len(filter.OrderBy) > 0 {
+		query = query.Order(filter.OrderBy)
+	}
+
+	// Error handling
+	if err := query.Find(&relations).Count(&total).Offset(filter.Offset).Limit(filter.Limit).Find(&relations).Error; err != nil {
+		return nil, total, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	var children []api.GroupGroupRelation
+	// Transform relations to API domain
+	if relations != nil {
+		children = make([]api.GroupGroupRelation, len(relations), cap(relations))
+		for i, r := range relations {
+			group, err := pr.GetGroupById(r.ChildID)
+
+			// Error handling
+			if err != nil {
+				return nil, total, &database.Error{
+					Code:    database.INTERNAL_ERROR,
+					Message: err.Error(),
+				}
+			}
+
+			children[i] = &GroupGroup{
+				Child:    group,
+				CreateAt: time.Unix(0, r.CreateAt).UTC(),
+			}
+		}
+	}
+
+	return children, total, nil
+}
+
+func (pr PostgresRepo) GetAncestorGroups(groupIDs []string) ([]api.Group, error) {
+	if len(groupIDs) < 1 {
+		return nil, nil
+	}
+
+	// Resolve all transitive parents in a single recursive query. UNION discards
+	// repeated rows, so the query ends even if the relations have a cycle.
+	groups := []Group{}
+	err := pr.Dbmap.Raw(`WITH RECURSIVE ancestors(id) AS (
+			SELECT parent_id FROM group_group_relations WHERE child_id IN (?)
+			UNION
+			SELECT r.parent_id FROM group_group_relations r INNER JOIN ancestors a ON r.child_id = a.id
+		)
+		SELECT * FROM groups WHERE id IN (SELECT id FROM ancestors) ORDER BY create_at`, groupIDs).Scan(&groups).Error
+
+	// Error handling
+	if err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Transform groups for API
+	var apiGroups []api.Group
+	if groups != nil {
+		apiGroups = make([]api.Group, len(groups), cap(groups))
+		for i, g := range groups {
+			apiGroups[i] = *dbGroupToAPIGroup(&g)
+		}
+	}
+
+	return apiGroups, nil
+}
+
+func (pr PostgresRepo) RemoveExpiredRelations(date time.Time) (int, error) {
+	transaction := pr.begin()
+	expiration := date.UTC().UnixNano()
+
+	// Delete expired memberships
+	members := transaction.Where("expires_at > 0 AND expires_at <= ?", expiration).Delete(&GroupUserRelation{})
+	if err := members.Error; err != nil {
+		pr.rollback(transaction)
+		return 0, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Delete expired policy attachments
+	policies := transaction.Where("expires_at > 0 AND expires_at <= ?", expiration).Delete(&GroupPolicyRelation{})
+	if err := policies.Error; err != nil {
+		pr.rollback(transaction)
+		return 0, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	pr.commit(transaction)
+	return int(members.RowsAffected + policies.RowsAffected), nil
+}
+
 // PRIVATE HELPER METHODS
 
 // Transform a Group retrieved from db into a group for API
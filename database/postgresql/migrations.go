@@ -0,0 +1,324 @@
+package postgresql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Migration is a numbered change of the database schema. Up statements apply the change,
+// and Down statements revert it. Statements must work in both PostgreSQL and SQLite.
+type Migration struct {
+	Version     int
+	Description string
+	Up          []string
+	Down        []string
+}
+
+// Migrations holds all schema changes ordered by version. Never modify a released migration,
+// append a new one instead.
+var Migrations = []Migration{
+	// Same schema that releases without migrations created, so their databases are adopted as they are
+	{
+		Version:     1,
+		Description: "Create initial schema",
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS "users" ("id" text NOT NULL,"external_id" text NOT NULL UNIQUE,"path" text NOT NULL,` +
+				`"create_at" bigint NOT NULL,"update_at" bigint NOT NULL,"urn" text NOT NULL UNIQUE, PRIMARY KEY ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "groups" ("id" text NOT NULL,"name" text NOT NULL,"path" text NOT NULL,"org" text NOT NULL,` +
+				`"create_at" bigint NOT NULL,"update_at" bigint NOT NULL,"urn" text NOT NULL UNIQUE, PRIMARY KEY ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "policies" ("id" text NOT NULL,"name" text NOT NULL,"path" text NOT NULL,"org" text NOT NULL,` +
+				`"create_at" bigint NOT NULL,"update_at" bigint NOT NULL,"urn" text NOT NULL UNIQUE, PRIMARY KEY ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "statements" ("id" text NOT NULL,"policy_id" text NOT NULL,"effect" text NOT NULL,` +
+				`"actions" text NOT NULL,"resources" text NOT NULL, PRIMARY KEY ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "group_user_relations" ("user_id" text NOT NULL,"group_id" text NOT NULL,` +
+				`"create_at" bigint NOT NULL, PRIMARY KEY ("user_id","group_id"))`,
+			`CREATE TABLE IF NOT EXISTS "group_policy_relations" ("group_id" text NOT NULL,"policy_id" text NOT NULL,` +
+				`"create_at" bigint NOT NULL, PRIMARY KEY ("group_id","policy_id"))`,
+			`CREATE TABLE IF NOT EXISTS "proxy_resources" ("id" text NOT NULL,"name" text NOT NULL,"org" text NOT NULL,` +
+				`"path" text NOT NULL,"host" text NOT NULL,"path_resource" text NOT NULL,"method" text NOT NULL,` +
+				`"urn_resource" text NOT NULL,"urn" text NOT NULL,"action" text NOT NULL,"create_at" bigint NOT NULL,` +
+				`"update_at" bigint NOT NULL, PRIMARY KEY ("id"))`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS idx_resource ON "proxy_resources"("host", "path_resource", "method", "urn_resource", "action")`,
+			`CREATE TABLE IF NOT EXISTS "oidc_providers" ("id" text NOT NULL,"name" text NOT NULL,"path" text NOT NULL,` +
+				`"urn" text NOT NULL UNIQUE,"create_at" bigint NOT NULL,"update_at" bigint NOT NULL,"issuer_url" text NOT NULL, PRIMARY KEY ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "oidc_clients" ("id" text NOT NULL,"oidc_provider_id" text NOT NULL,"name" text NOT NULL, PRIMARY KEY ("id"))`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS idx_oidc_client ON "oidc_clients"("oidc_provider_id", "name")`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS "oidc_clients"`,
+			`DROP TABLE IF EXISTS "oidc_providers"`,
+			`DROP TABLE IF EXISTS "proxy_resources"`,
+			`DROP TABLE IF EXISTS "group_policy_relations"`,
+			`DROP TABLE IF EXISTS "group_user_relations"`,
+			`DROP TABLE IF EXISTS "statements"`,
+			`DROP TABLE IF EXISTS "policies"`,
+			`DROP TABLE IF EXISTS "groups"`,
+			`DROP TABLE IF EXISTS "users"`,
+		},
+	},
+	{
+		Version:     2,
+		Description: "Add policy conditions, relation expiration, group hierarchy and user policies",
+		Up: []string{
+			`ALTER TABLE "statements" ADD COLUMN "conditions" text NOT NULL DEFAULT ''`,
+			`ALTER TABLE "group_user_relations" ADD COLUMN "expires_at" bigint NOT NULL DEFAULT 0`,
+			`ALTER TABLE "group_policy_relations" ADD COLUMN "expires_at" bigint NOT NULL DEFAULT 0`,
+			`CREATE TABLE IF NOT EXISTS "group_group_relations" ("parent_id" text NOT NULL,"child_id" text NOT NULL,` +
+				`"create_at" bigint NOT NULL, PRIMARY KEY ("parent_id","child_id"))`,
+			`CREATE TABLE IF NOT EXISTS "user_policy_relations" ("user_id" text NOT NULL,"policy_id" text NOT NULL,` +
+				`"create_at" bigint NOT NULL, PRIMARY KEY ("user_id","policy_id"))`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS "user_policy_relations"`,
+			`DROP TABLE IF EXISTS "group_group_relations"`,
+			`ALTER TABLE "group_policy_relations" DROP COLUMN "expires_at"`,
+			`ALTER TABLE "group_user_relations" DROP COLUMN "expires_at"`,
+			`ALTER TABLE "statements" DROP COLUMN "conditions"`,
+		},
+	},
+	{
+		Version:     3,
+		Description: "Create audit log",
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS "audit_entries" ("id" text NOT NULL,"actor" text NOT NULL,"request_id" text NOT NULL,` +
+				`"action" text NOT NULL,"urn" text NOT NULL,"before" text NOT NULL DEFAULT '',"after" text NOT NULL DEFAULT '',` +
+				`"create_at" bigint NOT NULL, PRIMARY KEY ("id"))`,
+			`CREATE INDEX IF NOT EXISTS idx_audit_entries_create_at ON "audit_entries"("create_at")`,
+			`CREATE INDEX IF NOT EXISTS idx_audit_entries_actor ON "audit_entries"("actor", "create_at")`,
+			`CREATE INDEX IF NOT EXISTS idx_audit_entries_urn ON "audit_entries"("urn", "create_at")`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS "audit_entries"`,
+		},
+	},
+	{
+		Version:     4,
+		Description: "Create webhooks",
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS "webhooks" ("id" text NOT NULL,"name" text NOT NULL UNIQUE,"path" text NOT NULL,` +
+				`"urn" text NOT NULL UNIQUE,"url" text NOT NULL,"secret" text NOT NULL,"events" text NOT NULL,` +
+				`"create_at" bigint NOT NULL,"update_at" bigint NOT NULL, PRIMARY KEY ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "webhook_dead_letters" ("id" text NOT NULL,"webhook_id" text NOT NULL,` +
+				`"event" text NOT NULL,"attempts" bigint NOT NULL,"last_error" text NOT NULL,"create_at" bigint NOT NULL, PRIMARY KEY ("id"))`,
+			`CREATE INDEX IF NOT EXISTS idx_webhook_dead_letters_webhook ON "webhook_dead_letters"("webhook_id", "create_at")`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS "webhook_dead_letters"`,
+			`DROP TABLE IF EXISTS "webhooks"`,
+		},
+	},
+	{
+		Version:     5,
+		Description: "Match proxy resources by host and headers",
+		Up: []string{
+			`ALTER TABLE "proxy_resources" ADD COLUMN "match_host" text NOT NULL DEFAULT ''`,
+			`ALTER TABLE "proxy_resources" ADD COLUMN "match_headers" text NOT NULL DEFAULT ''`,
+			`DROP INDEX IF EXISTS idx_resource`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS idx_resource ON "proxy_resources"("host", "path_resource", "method", "urn_resource", "action", ` +
+				`"match_host", "match_headers")`,
+		},
+		Down: []string{
+			`DROP INDEX IF EXISTS idx_resource`,
+			`ALTER TABLE "proxy_resources" DROP COLUMN "match_headers"`,
+			`ALTER TABLE "proxy_resources" DROP COLUMN "match_host"`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS idx_resource ON "proxy_resources"("host", "path_resource", "method", "urn_resource", "action")`,
+		},
+	},
+	{
+		Version:     6,
+		Description: "Create upstream pools",
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS "upstream_pools" ("id" text NOT NULL,"name" text NOT NULL,"org" text NOT NULL,` +
+				`"path" text NOT NULL,"urn" text NOT NULL UNIQUE,"targets" text NOT NULL,"balancer" text NOT NULL,` +
+				`"health_check" text NOT NULL,"ejection" text NOT NULL,"transport" text NOT NULL,"create_at" bigint NOT NULL,` +
+				`"update_at" bigint NOT NULL, PRIMARY KEY ("id"))`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS idx_upstream_pool ON "upstream_pools"("org", "name")`,
+			`ALTER TABLE "proxy_resources" ADD COLUMN "upstream" text NOT NULL DEFAULT ''`,
+			`DROP INDEX IF EXISTS idx_resource`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS idx_resource ON "proxy_resources"("host", "path_resource", "method", "urn_resource", "action", ` +
+				`"match_host", "match_headers", "upstream")`,
+		},
+		Down: []string{
+			`DROP INDEX IF EXISTS idx_resource`,
+			`ALTER TABLE "proxy_resources" DROP COLUMN "upstream"`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS idx_resource ON "proxy_resources"("host", "path_resource", "method", "urn_resource", "action", ` +
+				`"match_host", "match_headers")`,
+			`DROP TABLE IF EXISTS "upstream_pools"`,
+		},
+	},
+	{
+		Version:     7,
+		Description: "Track proxy config revision",
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS "proxy_revisions" ("id" bigint NOT NULL,"revision" bigint NOT NULL, PRIMARY KEY ("id"))`,
+			`INSERT INTO "proxy_revisions" ("id","revision") VALUES (1, 1)`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS "proxy_revisions"`,
+		},
+	},
+}
+
+// SchemaMigration table, with a row for every applied migration
+type SchemaMigration struct {
+	Version     int    `gorm:"primary_key"`
+	Description string `gorm:"not null"`
+	AppliedAt   int64  `gorm:"not null"`
+}
+
+// SchemaMigration's table name
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus tells whether a migration is applied in the database
+type MigrationStatus struct {
+	Version     int
+	Description string
+	Applied     bool
+	AppliedAt   *time.Time
+}
+
+// LatestSchemaVersion returns the schema version expected by this release
+func LatestSchemaVersion() int {
+	return Migrations[len(Migrations)-1].Version
+}
+
+// SchemaVersion returns the version of the last migration applied in the database, 0 if none
+func SchemaVersion(db *gorm.DB) (int, error) {
+	if !db.HasTable(&SchemaMigration{}) {
+		return 0, nil
+	}
+
+	applied := []SchemaMigration{}
+	if err := db.Order("version desc").Limit(1).Find(&applied).Error; err != nil {
+		return 0, err
+	}
+	if len(applied) == 0 {
+		return 0, nil
+	}
+
+	return applied[0].Version, nil
+}
+
+// MigrationsStatus returns all known migrations and whether they are applied in the database
+func MigrationsStatus(db *gorm.DB) ([]MigrationStatus, error) {
+	applied := map[int]SchemaMigration{}
+	if db.HasTable(&SchemaMigration{}) {
+		rows := []SchemaMigration{}
+		if err := db.Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			applied[r.Version] = r
+		}
+	}
+
+	status := make([]MigrationStatus, len(Migrations))
+	for i, m := range Migrations {
+		status[i] = MigrationStatus{
+			Version:     m.Version,
+			Description: m.Description,
+		}
+		if r, ok := applied[m.Version]; ok {
+			appliedAt := time.Unix(0, r.AppliedAt).UTC()
+			status[i].Applied = true
+			status[i].AppliedAt = &appliedAt
+		}
+	}
+
+	return status, nil
+}
+
+// MigrateUp applies all pending migrations in order, and returns the applied ones
+func MigrateUp(db *gorm.DB) ([]Migration, error) {
+	if err := createSchemaMigrationsTable(db); err != nil {
+		return nil, err
+	}
+	version, err := SchemaVersion(db)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := []Migration{}
+	for _, m := range Migrations {
+		if m.Version <= version {
+			continue
+		}
+		if err := runMigration(db, m, true); err != nil {
+			return applied, err
+		}
+		applied = append(applied, m)
+	}
+
+	return applied, nil
+}
+
+// MigrateDown reverts the last applied migration, and returns it. If there isn't any migration
+// applied it returns nil
+func MigrateDown(db *gorm.DB) (*Migration, error) {
+	version, err := SchemaVersion(db)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(Migrations) - 1; i >= 0; i-- {
+		if Migrations[i].Version == version {
+			if err := runMigration(db, Migrations[i], false); err != nil {
+				return nil, err
+			}
+			return &Migrations[i], nil
+		}
+	}
+
+	if version > 0 {
+		return nil, fmt.Errorf("Unknown schema version %v, database was migrated by a newer release", version)
+	}
+	return nil, nil
+}
+
+// Private helper methods
+
+func createSchemaMigrationsTable(db *gorm.DB) error {
+	return db.Exec(`CREATE TABLE IF NOT EXISTS "schema_migrations" ("version" bigint NOT NULL,` +
+		`"description" text NOT NULL,"applied_at" bigint NOT NULL, PRIMARY KEY ("version"))`).Error
+}
+
+// runMigration applies or reverts a migration, and records it in schema_migrations table in the same transaction
+func runMigration(db *gorm.DB, m Migration, up bool) error {
+	statements := m.Up
+	if !up {
+		statements = m.Down
+	}
+
+	transaction := db.Begin()
+	if err := transaction.Error; err != nil {
+		return err
+	}
+
+	for _, statement := range statements {
+		if err := transaction.Exec(statement).Error; err != nil {
+			transaction.Rollback()
+			return fmt.Errorf("Migration %v (%v) failed: %v", m.Version, m.Description, err)
+		}
+	}
+
+	var err error
+	if up {
+		err = transaction.Create(&SchemaMigration{
+			Version:     m.Version,
+			Description: m.Description,
+			AppliedAt:   time.Now().UTC().UnixNano(),
+		}).Error
+	} else {
+		err = transaction.Where("version = ?", m.Version).Delete(&SchemaMigration{}).Error
+	}
+	if err != nil {
+		transaction.Rollback()
+		return fmt.Errorf("Migration %v (%v) failed: %v", m.Version, m.Description, err)
+	}
+
+	return transaction.Commit().Error
+}
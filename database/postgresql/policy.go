@@ -1,6 +1,7 @@
 package postgresql
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -24,11 +25,11 @@ func (pr PostgresRepo) AddPolicy(policy api.Policy) (*api.Policy, error) {
 		Org:      policy.Org,
 	}
 
-	transaction := pr.Dbmap.Begin()
+	transaction := pr.begin()
 
 	// Create policy
 	if err := transaction.Create(policyDB).Error; err != nil {
-		transaction.Rollback()
+		pr.rollback(transaction)
 		return nil, &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
@@ -39,14 +40,15 @@ func (pr PostgresRepo) AddPolicy(policy api.Policy) (*api.Policy, error) {
 	for _, statementApi := range *policy.Statements {
 		// Create statement model
 		statementDB := &Statement{
-			ID:        uuid.NewV4().String(),
-			PolicyID:  policy.ID,
-			Effect:    statementApi.Effect,
-			Actions:   stringArrayToString(statementApi.Actions),
-			Resources: stringArrayToString(statementApi.Resources),
+			ID:         uuid.NewV4().String(),
+			PolicyID:   policy.ID,
+			Effect:     statementApi.Effect,
+			Actions:    stringArrayToString(statementApi.Actions),
+			Resources:  stringArrayToString(statementApi.Resources),
+			Conditions: conditionsToString(statementApi.Conditions),
 		}
 		if err := transaction.Create(statementDB).Error; err != nil {
-			transaction.Rollback()
+			pr.rollback(transaction)
 			return nil, &database.Error{
 				Code:    database.INTERNAL_ERROR,
 				Message: err.Error(),
@@ -54,7 +56,7 @@ func (pr PostgresRepo) AddPolicy(policy api.Policy) (*api.Policy, error) {
 		}
 	}
 
-	transaction.Commit()
+	pr.commit(transaction)
 
 	// Create API policy
 	policyApi := dbPolicyToAPIPolicy(policyDB)
@@ -192,7 +194,7 @@ func (pr PostgresRepo) GetPoliciesFiltered(filter *api.Filter) ([]api.Policy, in
 	return apiPolicies, total, nil
 }
 
-func (pr PostgresRepo) UpdatePolicy(policy api.Policy) (*api.Policy, error) {
+func (pr PostgresRepo) UpdatePolicy(policy api.Policy, oldUpdateAt time.Time) (*api.Policy, error) {
 
 	policyDB := Policy{
 		ID:       policy.ID,
@@ -204,20 +206,30 @@ func (pr PostgresRepo) UpdatePolicy(policy api.Policy) (*api.Policy, error) {
 		Org:      policy.Org,
 	}
 
-	transaction := pr.Dbmap.Begin()
+	transaction := pr.begin()
 
 	// Update policy
-	if err := transaction.Model(&Policy{ID: policy.ID}).Update(policyDB).Error; err != nil {
-		transaction.Rollback()
+	query := transaction.Model(&Policy{ID: policy.ID}).Where("update_at = ?", oldUpdateAt.UTC().UnixNano()).Update(policyDB)
+	if err := query.Error; err != nil {
+		pr.rollback(transaction)
 		return nil, &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
 		}
 	}
 
+	// Check if it was modified or removed by another request
+	if query.RowsAffected == 0 {
+		pr.rollback(transaction)
+		return nil, &database.Error{
+			Code:    database.VERSION_CONFLICT,
+			Message: fmt.Sprintf("Policy with id %v was modified or removed by another request", policy.ID),
+		}
+	}
+
 	// Clear old statements
 	if err := transaction.Where("policy_id like ?", policy.ID).Delete(Statement{}).Error; err != nil {
-		transaction.Rollback()
+		pr.rollback(transaction)
 		return nil, &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
@@ -227,14 +239,15 @@ func (pr PostgresRepo) UpdatePolicy(policy api.Policy) (*api.Policy, error) {
 	// Create new statements
 	for _, s := range *policy.Statements {
 		statementDB := &Statement{
-			ID:        uuid.NewV4().String(),
-			PolicyID:  policy.ID,
-			Effect:    s.Effect,
-			Actions:   stringArrayToString(s.Actions),
-			Resources: stringArrayToString(s.Resources),
+			ID:         uuid.NewV4().String(),
+			PolicyID:   policy.ID,
+			Effect:     s.Effect,
+			Actions:    stringArrayToString(s.Actions),
+			Resources:  stringArrayToString(s.Resources),
+			Conditions: conditionsToString(s.Conditions),
 		}
 		if err := transaction.Create(statementDB).Error; err != nil {
-			transaction.Rollback()
+			pr.rollback(transaction)
 			return nil, &database.Error{
 				Code:    database.INTERNAL_ERROR,
 				Message: err.Error(),
@@ -242,19 +255,28 @@ func (pr PostgresRepo) UpdatePolicy(policy api.Policy) (*api.Policy, error) {
 		}
 	}
 
-	transaction.Commit()
+	pr.commit(transaction)
 
 	return &policy, nil
 }
 
 func (pr PostgresRepo) RemovePolicy(id string) error {
 
-	transaction := pr.Dbmap.Begin()
+	transaction := pr.begin()
 
 	// Delete policy relations (group)
 	transaction.Where("policy_id like ?", id).Delete(&GroupPolicyRelation{})
 	if err := transaction.Error; err != nil {
-		transaction.Rollback()
+		pr.rollback(transaction)
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+	// Delete policy relations (user)
+	transaction.Where("policy_id like ?", id).Delete(&UserPolicyRelation{})
+	if err := transaction.Error; err != nil {
+		pr.rollback(transaction)
 		return &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
@@ -263,7 +285,7 @@ func (pr PostgresRepo) RemovePolicy(id string) error {
 	// Delete policy statements
 	transaction.Where("policy_id like ?", id).Delete(&Statement{})
 	if err := transaction.Error; err != nil {
-		transaction.Rollback()
+		pr.rollback(transaction)
 		return &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
@@ -272,14 +294,14 @@ func (pr PostgresRepo) RemovePolicy(id string) error {
 	//  Delete policy
 	transaction.Where("id like ?", id).Delete(&Policy{})
 	if err := transaction.Error; err != nil {
-		transaction.Rollback()
+		pr.rollback(transaction)
 		return &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
 		}
 	}
 
-	transaction.Commit()
+	pr.commit(transaction)
 	return nil
 }
 
@@ -317,8 +339,9 @@ func (pr PostgresRepo) GetAttachedGroups(policyID string, filter *api.Filter) ([
 			}
 
 			groups[i] = &PolicyGroup{
-				Group:    group,
-				CreateAt: time.Unix(0, r.CreateAt).UTC(),
+				Group:     group,
+				CreateAt:  time.Unix(0, r.CreateAt).UTC(),
+				ExpiresAt: dbExpirationToAPI(r.ExpiresAt),
 			}
 		}
 	}
@@ -346,9 +369,10 @@ func dbStatementsToAPIStatements(statements []Statement) *[]api.Statement {
 	statementsApi := make([]api.Statement, len(statements), cap(statements))
 	for i, s := range statements {
 		statementsApi[i] = api.Statement{
-			Actions:   strings.Split(s.Actions, ";"),
-			Effect:    s.Effect,
-			Resources: strings.Split(s.Resources, ";"),
+			Actions:    strings.Split(s.Actions, ";"),
+			Effect:     s.Effect,
+			Resources:  strings.Split(s.Resources, ";"),
+			Conditions: stringToConditions(s.Conditions),
 		}
 	}
 
@@ -368,3 +392,29 @@ func stringArrayToString(array []string) string {
 
 	return stringVal
 }
+
+// Transform statement conditions into a JSON string, empty if there aren't conditions
+func conditionsToString(conditions api.Conditions) string {
+	if len(conditions) < 1 {
+		return ""
+	}
+	b, err := json.Marshal(conditions)
+	if err != nil {
+		return ""
+	}
+
+	return string(b)
+}
+
+// Transform a JSON string from db into statement conditions
+func stringToConditions(value string) api.Conditions {
+	if len(value) < 1 {
+		return nil
+	}
+	conditions := api.Conditions{}
+	if err := json.Unmarshal([]byte(value), &conditions); err != nil {
+		return nil
+	}
+
+	return conditions
+}
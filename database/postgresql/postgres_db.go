@@ -3,15 +3,20 @@ package postgresql
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
 	"github.com/jinzhu/gorm"
 	_ "github.com/lib/pq" //GORM needs to import the lib/pq driver
 )
 
 type PostgresRepo struct {
 	Dbmap *gorm.DB
+
+	// True when Dbmap is a transaction started by WithTx
+	inTx bool
 }
 
 func InitDb(datasourcename string, idleConns string, maxOpenConns string, connTTL string) (*gorm.DB, error) {
@@ -44,14 +49,103 @@ func InitDb(datasourcename string, idleConns string, maxOpenConns string, connTT
 		return nil, err
 	}
 
-	// Create tables if not exist
-	err = db.AutoMigrate(&User{}, &Group{}, &Policy{}, &Statement{}, &GroupUserRelation{}, &GroupPolicyRelation{},
-		&ProxyResource{}, &OidcProvider{}, &OidcClient{}).Error
-	if err != nil {
-		return nil, err
+	return db, nil
+}
+
+// Attempts of a transaction that fails because it can't be serialized with concurrent transactions
+const maxTxAttempts = 5
+
+// Message of PostgreSQL serialization failures. Repositories only keep the message of driver errors.
+const serializationFailureMessage = "could not serialize access"
+
+// WithTx calls fn in a SERIALIZABLE transaction, so the rows read by fn can't be changed by other transactions
+// before it commits. When PostgreSQL aborts the transaction because of a concurrent one, fn is called again
+// in a new transaction.
+func (pr PostgresRepo) WithTx(fn func(repos api.TxRepos) error) error {
+	// Nested calls join the current transaction
+	if pr.inTx {
+		return fn(pr.txRepos())
 	}
 
-	return db, nil
+	var err error
+	for attempt := 1; attempt <= maxTxAttempts; attempt++ {
+		err = pr.runTx(fn)
+		if err == nil || !strings.Contains(err.Error(), serializationFailureMessage) {
+			return err
+		}
+	}
+	return err
+}
+
+func (pr PostgresRepo) runTx(fn func(repos api.TxRepos) error) error {
+	transaction := pr.Dbmap.Begin()
+	if err := transaction.Error; err != nil {
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+	// SQLite transactions are always serializable
+	if transaction.NewScope(nil).Dialect().GetName() == "postgres" {
+		if err := transaction.Exec("SET TRANSACTION ISOLATION LEVEL SERIALIZABLE").Error; err != nil {
+			transaction.Rollback()
+			return &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: err.Error(),
+			}
+		}
+	}
+
+	txRepo := PostgresRepo{
+		Dbmap: transaction,
+		inTx:  true,
+	}
+	if err := fn(txRepo.txRepos()); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	if err := transaction.Commit().Error; err != nil {
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+	return nil
+}
+
+func (pr PostgresRepo) txRepos() api.TxRepos {
+	return api.TxRepos{
+		UserRepo:     pr,
+		GroupRepo:    pr,
+		PolicyRepo:   pr,
+		ProxyRepo:    pr,
+		AuthOidcRepo: pr,
+		AuditRepo:    pr,
+		WebhookRepo:  pr,
+	}
+}
+
+// Operations that change several tables run in their own transaction, or join the WithTx one.
+// In that case WithTx commits or rolls back the transaction.
+
+func (pr PostgresRepo) begin() *gorm.DB {
+	if pr.inTx {
+		return pr.Dbmap
+	}
+	return pr.Dbmap.Begin()
+}
+
+func (pr PostgresRepo) commit(transaction *gorm.DB) {
+	if !pr.inTx {
+		transaction.Commit()
+	}
+}
+
+func (pr PostgresRepo) rollback(transaction *gorm.DB) {
+	if !pr.inTx {
+		transaction.Rollback()
+	}
 }
 
 // User table
@@ -103,11 +197,12 @@ func (Policy) TableName() string {
 
 // Statement table
 type Statement struct {
-	ID        string `gorm:"primary_key"`
-	PolicyID  string `gorm:"not null"`
-	Effect    string `gorm:"not null"`
-	Actions   string `gorm:"not null"`
-	Resources string `gorm:"not null"`
+	ID         string `gorm:"primary_key"`
+	PolicyID   string `gorm:"not null"`
+	Effect     string `gorm:"not null"`
+	Actions    string `gorm:"not null"`
+	Resources  string `gorm:"not null"`
+	Conditions string `gorm:"not null;default:''"`
 }
 
 // Statement's table name
@@ -115,11 +210,12 @@ func (Statement) TableName() string {
 	return "statements"
 }
 
-// Group-Users Relationship
+// Group-Users Relationship. ExpiresAt is 0 when the membership doesn't expire
 type GroupUserRelation struct {
-	UserID   string `gorm:"primary_key"`
-	GroupID  string `gorm:"primary_key"`
-	CreateAt int64  `gorm:"not null"`
+	UserID    string `gorm:"primary_key;not null"`
+	GroupID   string `gorm:"primary_key;not null"`
+	CreateAt  int64  `gorm:"not null"`
+	ExpiresAt int64  `gorm:"not null;default:0"`
 }
 
 // GroupUserRelation's table name
@@ -127,11 +223,12 @@ func (GroupUserRelation) TableName() string {
 	return "group_user_relations"
 }
 
-// Group Policy table
+// Group Policy table. ExpiresAt is 0 when the attachment doesn't expire
 type GroupPolicyRelation struct {
-	GroupID  string `gorm:"primary_key"`
-	PolicyID string `gorm:"primary_key"`
-	CreateAt int64  `gorm:"not null"`
+	GroupID   string `gorm:"primary_key;not null"`
+	PolicyID  string `gorm:"primary_key;not null"`
+	CreateAt  int64  `gorm:"not null"`
+	ExpiresAt int64  `gorm:"not null;default:0"`
 }
 
 // GroupPolicyRelation's table name
@@ -139,33 +236,69 @@ func (GroupPolicyRelation) TableName() string {
 	return "group_policy_relations"
 }
 
+// Group-Groups Relationship
+type GroupGroupRelation struct {
+	ParentID string `gorm:"primary_key;not null"`
+	ChildID  string `gorm:"primary_key;not null"`
+	CreateAt int64  `gorm:"not null"`
+}
+
+// GroupGroupRelation's table name
+func (GroupGroupRelation) TableName() string {
+	return "group_group_relations"
+}
+
+// User-Policies Relationship
+type UserPolicyRelation struct {
+	UserID   string `gorm:"primary_key;not null"`
+	PolicyID string `gorm:"primary_key;not null"`
+	CreateAt int64  `gorm:"not null"`
+}
+
+// UserPolicyRelation's table name
+func (UserPolicyRelation) TableName() string {
+	return "user_policy_relations"
+}
+
 func (pr PostgresRepo) OrderByValidColumns(action string) []string {
 	switch action {
 	case api.USER_ACTION_LIST_USERS:
 		return []string{"path", "external_id", "create_at", "update_at", "urn"}
 	case api.USER_ACTION_LIST_GROUPS_FOR_USER:
 		return []string{"create_at"}
+	case api.USER_ACTION_LIST_ATTACHED_USER_POLICIES:
+		return []string{"create_at"}
 	case api.GROUP_ACTION_LIST_GROUPS:
 		return []string{"name", "path", "org", "create_at", "update_at", "urn"}
 	case api.GROUP_ACTION_LIST_MEMBERS:
 		return []string{"create_at"}
 	case api.GROUP_ACTION_LIST_ATTACHED_GROUP_POLICIES:
 		return []string{"create_at"}
+	case api.GROUP_ACTION_LIST_CHILD_GROUPS:
+		return []string{"create_at"}
 	case api.POLICY_ACTION_LIST_POLICIES:
 		return []string{"name", "path", "org", "create_at", "update_at", "urn"}
 	case api.POLICY_ACTION_LIST_ATTACHED_GROUPS:
 		return []string{"create_at"}
 	case api.PROXY_ACTION_LIST_RESOURCES:
-		return []string{"name", "path", "org", "host", "path_resource", "method",
-			"urn_resource", "urn", "action", "create_at", "update_at"}
+		return []string{"name", "path", "org", "host", "upstream", "path_resource", "method",
+			"urn_resource", "urn", "action", "match_host", "create_at", "update_at"}
+	case api.UPSTREAM_ACTION_LIST_POOLS:
+		return []string{"name", "path", "org", "urn", "balancer", "create_at", "update_at"}
 	case api.AUTH_OIDC_ACTION_LIST_PROVIDERS:
 		return []string{"name", "path", "create_at", "update_at", "urn"}
+	case api.AUDIT_ACTION_LIST_ENTRIES:
+		return []string{"actor", "action", "urn", "create_at"}
+	case api.WEBHOOK_ACTION_LIST_WEBHOOKS:
+		return []string{"name", "path", "create_at", "update_at", "urn"}
+	case api.WEBHOOK_ACTION_LIST_DEAD_LETTERS:
+		return []string{"attempts", "create_at"}
 	default:
 		return nil
 	}
 }
 
-// ProxyResource table
+// ProxyResource table. MatchHeaders are the header matchers as "Name: value" lines
 type ProxyResource struct {
 	ID           string `gorm:"primary_key"`
 	Name         string `gorm:"not null"`
@@ -177,6 +310,9 @@ type ProxyResource struct {
 	UrnResource  string `gorm:"not null;unique_index:idx_resource"`
 	Urn          string `gorm:"not null"`
 	Action       string `gorm:"not null;unique_index:idx_resource"`
+	MatchHost    string `gorm:"not null;unique_index:idx_resource"`
+	MatchHeaders string `gorm:"not null;unique_index:idx_resource"`
+	Upstream     string `gorm:"not null;unique_index:idx_resource"`
 	CreateAt     int64  `gorm:"not null"`
 	UpdateAt     int64  `gorm:"not null"`
 }
@@ -186,6 +322,38 @@ func (ProxyResource) TableName() string {
 	return "proxy_resources"
 }
 
+// Upstream pool table. Health check, ejection and transport settings are encoded as JSON
+type UpstreamPool struct {
+	ID          string `gorm:"primary_key"`
+	Name        string `gorm:"not null;unique_index:idx_upstream_pool"`
+	Org         string `gorm:"not null;unique_index:idx_upstream_pool"`
+	Path        string `gorm:"not null"`
+	Urn         string `gorm:"not null;unique"`
+	Targets     string `gorm:"not null"`
+	Balancer    string `gorm:"not null"`
+	HealthCheck string `gorm:"not null"`
+	Ejection    string `gorm:"not null"`
+	Transport   string `gorm:"not null"`
+	CreateAt    int64  `gorm:"not null"`
+	UpdateAt    int64  `gorm:"not null"`
+}
+
+// UpstreamPool's table name
+func (UpstreamPool) TableName() string {
+	return "upstream_pools"
+}
+
+// Proxy revision table, with a single row whose revision is increased on every change of the proxy config
+type ProxyRevision struct {
+	ID       int64 `gorm:"primary_key"`
+	Revision int64 `gorm:"not null"`
+}
+
+// ProxyRevision's table name
+func (ProxyRevision) TableName() string {
+	return "proxy_revisions"
+}
+
 // Auth OIDC Provider table
 type OidcProvider struct {
 	ID        string `gorm:"primary_key"`
@@ -213,3 +381,53 @@ type OidcClient struct {
 func (OidcClient) TableName() string {
 	return "oidc_clients"
 }
+
+// Audit entry table
+type AuditEntry struct {
+	ID        string `gorm:"primary_key"`
+	Actor     string `gorm:"not null"`
+	RequestID string `gorm:"not null"`
+	Action    string `gorm:"not null"`
+	Urn       string `gorm:"not null"`
+	Before    string `gorm:"not null"`
+	After     string `gorm:"not null"`
+	CreateAt  int64  `gorm:"not null"`
+}
+
+// AuditEntry's table name
+func (AuditEntry) TableName() string {
+	return "audit_entries"
+}
+
+// Webhook table. Events are the event filters joined by commas
+type Webhook struct {
+	ID       string `gorm:"primary_key"`
+	Name     string `gorm:"not null;unique"`
+	Path     string `gorm:"not null"`
+	Urn      string `gorm:"not null;unique"`
+	URL      string `gorm:"not null"`
+	Secret   string `gorm:"not null"`
+	Events   string `gorm:"not null"`
+	CreateAt int64  `gorm:"not null"`
+	UpdateAt int64  `gorm:"not null"`
+}
+
+// Webhook's table name
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// Webhook dead letter table. Event is the undelivered event encoded as JSON
+type WebhookDeadLetter struct {
+	ID        string `gorm:"primary_key"`
+	WebhookID string `gorm:"not null"`
+	Event     string `gorm:"not null"`
+	Attempts  int    `gorm:"not null"`
+	LastError string `gorm:"not null"`
+	CreateAt  int64  `gorm:"not null"`
+}
+
+// WebhookDeadLetter's table name
+func (WebhookDeadLetter) TableName() string {
+	return "webhook_dead_letters"
+}
@@ -2,13 +2,18 @@ package postgresql
 
 import (
 	"fmt"
+	"strings"
 
 	"time"
 
 	"github.com/Tecsisa/foulkon/api"
 	"github.com/Tecsisa/foulkon/database"
+	"github.com/jinzhu/gorm"
 )
 
+// ID of the row of proxy_revisions table
+const proxyRevisionID = 1
+
 // PROXY REPOSITORY IMPLEMENTATION
 
 func (pr PostgresRepo) GetProxyResourceByName(org string, name string) (*api.ProxyResource, error) {
@@ -46,6 +51,8 @@ func (pr PostgresRepo) GetProxyResources(filter *api.Filter) ([]api.ProxyResourc
 	}
 	if len(filter.OrderBy) > 0 {
 		query = query.Order(filter.OrderBy)
+	} else {
+		query = query.Order("create_at")
 	}
 
 	// Error handling
@@ -80,6 +87,9 @@ func (pr PostgresRepo) AddProxyResource(proxyResource api.ProxyResource) (*api.P
 		Method:       proxyResource.Resource.Method,
 		UrnResource:  proxyResource.Resource.Urn,
 		Action:       proxyResource.Resource.Action,
+		MatchHost:    proxyResource.Resource.MatchHost,
+		MatchHeaders: encodeHeaderMatchers(proxyResource.Resource.MatchHeaders),
+		Upstream:     proxyResource.Resource.Upstream,
 		Urn:          proxyResource.Urn,
 		CreateAt:     proxyResource.CreateAt.UnixNano(),
 		UpdateAt:     proxyResource.UpdateAt.UnixNano(),
@@ -99,24 +109,24 @@ func (pr PostgresRepo) AddProxyResource(proxyResource api.ProxyResource) (*api.P
 	return dbResourceToApiResource(proxyResourceDB), nil
 }
 
-func (pr PostgresRepo) UpdateProxyResource(proxyResource api.ProxyResource) (*api.ProxyResource, error) {
-	proxyResourceDB := &ProxyResource{
-		ID:           proxyResource.ID,
-		Name:         proxyResource.Name,
-		Org:          proxyResource.Org,
-		Path:         proxyResource.Path,
-		Host:         proxyResource.Resource.Host,
-		PathResource: proxyResource.Resource.Path,
-		Method:       proxyResource.Resource.Method,
-		UrnResource:  proxyResource.Resource.Urn,
-		Action:       proxyResource.Resource.Action,
-		Urn:          proxyResource.Urn,
-		CreateAt:     proxyResource.CreateAt.UnixNano(),
-		UpdateAt:     proxyResource.UpdateAt.UnixNano(),
-	}
-
-	// Store proxyResource
-	query := pr.Dbmap.Model(&ProxyResource{ID: proxyResource.ID}).Updates(proxyResourceDB)
+func (pr PostgresRepo) UpdateProxyResource(proxyResource api.ProxyResource, oldUpdateAt time.Time) (*api.ProxyResource, error) {
+	// Store proxyResource. Empty values of a struct aren't updated, so columns are given in a map
+	query := pr.Dbmap.Model(&ProxyResource{ID: proxyResource.ID}).Where("update_at = ?", oldUpdateAt.UnixNano()).Updates(map[string]interface{}{
+		"name":          proxyResource.Name,
+		"org":           proxyResource.Org,
+		"path":          proxyResource.Path,
+		"host":          proxyResource.Resource.Host,
+		"path_resource": proxyResource.Resource.Path,
+		"method":        proxyResource.Resource.Method,
+		"urn_resource":  proxyResource.Resource.Urn,
+		"action":        proxyResource.Resource.Action,
+		"match_host":    proxyResource.Resource.MatchHost,
+		"match_headers": encodeHeaderMatchers(proxyResource.Resource.MatchHeaders),
+		"upstream":      proxyResource.Resource.Upstream,
+		"urn":           proxyResource.Urn,
+		"create_at":     proxyResource.CreateAt.UnixNano(),
+		"update_at":     proxyResource.UpdateAt.UnixNano(),
+	})
 
 	// Error Handling
 	if err := query.Error; err != nil {
@@ -126,6 +136,14 @@ func (pr PostgresRepo) UpdateProxyResource(proxyResource api.ProxyResource) (*ap
 		}
 	}
 
+	// Check if it was modified or removed by another request
+	if query.RowsAffected == 0 {
+		return nil, &database.Error{
+			Code:    database.VERSION_CONFLICT,
+			Message: fmt.Sprintf("Proxy resource with id %v was modified or removed by another request", proxyResource.ID),
+		}
+	}
+
 	return &proxyResource, nil
 }
 
@@ -144,6 +162,36 @@ func (pr PostgresRepo) RemoveProxyResource(id string) error {
 	return nil
 }
 
+func (pr PostgresRepo) GetProxyRevision() (int64, error) {
+	revision := &ProxyRevision{}
+	query := pr.Dbmap.Where("id = ?", proxyRevisionID).First(revision)
+
+	// Error handling
+	if err := query.Error; err != nil {
+		return 0, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return revision.Revision, nil
+}
+
+func (pr PostgresRepo) IncrementProxyRevision() error {
+	query := pr.Dbmap.Model(&ProxyRevision{}).Where("id = ?", proxyRevisionID).
+		UpdateColumn("revision", gorm.Expr("revision + ?", 1))
+
+	// Error handling
+	if err := query.Error; err != nil {
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
 // PRIVATE HELPER METHODS
 
 // Transform a proxyResource retrieved from db into a proxyResource for API
@@ -154,14 +202,41 @@ func dbResourceToApiResource(pr *ProxyResource) *api.ProxyResource {
 		Path: pr.Path,
 		Org:  pr.Org,
 		Resource: api.ResourceEntity{
-			Host:   pr.Host,
-			Path:   pr.PathResource,
-			Method: pr.Method,
-			Urn:    pr.UrnResource,
-			Action: pr.Action,
+			Host:         pr.Host,
+			Path:         pr.PathResource,
+			Method:       pr.Method,
+			Urn:          pr.UrnResource,
+			Action:       pr.Action,
+			MatchHost:    pr.MatchHost,
+			MatchHeaders: decodeHeaderMatchers(pr.MatchHeaders),
+			Upstream:     pr.Upstream,
 		},
 		Urn:      pr.Urn,
 		CreateAt: time.Unix(0, pr.CreateAt).UTC(),
 		UpdateAt: time.Unix(0, pr.UpdateAt).UTC(),
 	}
 }
+
+// Transform header matchers into "Name: value" lines
+func encodeHeaderMatchers(headers []api.HeaderMatcher) string {
+	lines := make([]string, len(headers))
+	for i, h := range headers {
+		lines[i] = h.Name + ": " + h.Value
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Transform "Name: value" lines into header matchers
+func decodeHeaderMatchers(value string) []api.HeaderMatcher {
+	if len(value) == 0 {
+		return nil
+	}
+	var headers []api.HeaderMatcher
+	for _, line := range strings.Split(value, "\n") {
+		h := strings.SplitN(line, ": ", 2)
+		if len(h) == 2 {
+			headers = append(headers, api.HeaderMatcher{Name: h[0], Value: h[1]})
+		}
+	}
+	return headers
+}
@@ -0,0 +1,159 @@
+package repotest
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/Tecsisa/foulkon/database/postgresql"
+	"github.com/stretchr/testify/assert"
+)
+
+func testAddAuditEntry(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousEntry *postgresql.AuditEntry
+		// Postgres Repo Args
+		entryToCreate api.AuditEntry
+		// Expected result
+		expectedError *database.Error
+	}{
+		"OkCase": {
+			entryToCreate: api.AuditEntry{
+				ID:        "EntryID",
+				Actor:     "admin",
+				RequestID: "RequestID",
+				Action:    api.USER_ACTION_CREATE_USER,
+				Urn:       "urn",
+				After:     json.RawMessage(`{"externalId":"user"}`),
+				CreateAt:  now,
+			},
+		},
+		"ErrorCaseEntryAlreadyExist": {
+			previousEntry: &postgresql.AuditEntry{
+				ID:       "EntryID",
+				Actor:    "admin",
+				Action:   api.USER_ACTION_CREATE_USER,
+				Urn:      "urn",
+				CreateAt: now.UnixNano(),
+			},
+			entryToCreate: api.AuditEntry{
+				ID:       "EntryID",
+				Actor:    "admin",
+				Action:   api.USER_ACTION_CREATE_USER,
+				Urn:      "urn",
+				CreateAt: now,
+			},
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: driver.UniqueViolation("audit_entries_pkey"),
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean audit_entries database
+		cleanAuditEntriesTable(t, n)
+
+		// Insert previous data
+		if test.previousEntry != nil {
+			insertAuditEntry(t, n, *test.previousEntry)
+		}
+		// Call to repository to store an audit entry
+		err := repoDB.AddAuditEntry(test.entryToCreate)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check database
+			count := getAuditEntriesCountFiltered(t, n, test.entryToCreate.ID, test.entryToCreate.Actor,
+				test.entryToCreate.Action, test.entryToCreate.Urn)
+			assert.Equal(t, 1, count, "Error in test case %v", n)
+		}
+	}
+}
+
+func testGetAuditEntriesFiltered(t *testing.T) {
+	now := time.Now().UTC()
+	later := now.Add(time.Minute)
+	previousEntries := []postgresql.AuditEntry{
+		{ID: "1", Actor: "admin", Action: api.USER_ACTION_CREATE_USER, Urn: "urn:user", After: `{"externalId":"user"}`, CreateAt: now.UnixNano()},
+		{ID: "2", Actor: "user", Action: api.GROUP_ACTION_ADD_MEMBER, Urn: "urn:group", CreateAt: later.UnixNano()},
+		{ID: "3", Actor: "admin", Action: api.USER_ACTION_DELETE_USER, Urn: "urn:user", Before: `{"externalId":"user"}`, CreateAt: later.Add(time.Minute).UnixNano()},
+	}
+	testcases := map[string]struct {
+		// Postgres Repo Args
+		filter *api.Filter
+		// Expected result
+		expectedIDs   []string
+		expectedTotal int
+	}{
+		"OkCaseAll": {
+			filter:        &api.Filter{},
+			expectedIDs:   []string{"1", "2", "3"},
+			expectedTotal: 3,
+		},
+		"OkCaseActor": {
+			filter:        &api.Filter{Actor: "admin"},
+			expectedIDs:   []string{"1", "3"},
+			expectedTotal: 2,
+		},
+		"OkCaseUrnAndAction": {
+			filter:        &api.Filter{ResourceUrn: "urn:user", Action: api.USER_ACTION_DELETE_USER},
+			expectedIDs:   []string{"3"},
+			expectedTotal: 1,
+		},
+		"OkCaseTimeRange": {
+			filter:        &api.Filter{From: &later, To: &later},
+			expectedIDs:   []string{},
+			expectedTotal: 0,
+		},
+		"OkCaseFrom": {
+			filter:        &api.Filter{From: &later},
+			expectedIDs:   []string{"2", "3"},
+			expectedTotal: 2,
+		},
+		"OkCaseTo": {
+			filter:        &api.Filter{To: &later},
+			expectedIDs:   []string{"1"},
+			expectedTotal: 1,
+		},
+		"OkCaseOrderAndPage": {
+			filter:        &api.Filter{OrderBy: "create_at desc", Offset: 1, Limit: 1},
+			expectedIDs:   []string{"2"},
+			expectedTotal: 3,
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean audit_entries database
+		cleanAuditEntriesTable(t, n)
+
+		// Insert previous data
+		for _, e := range previousEntries {
+			insertAuditEntry(t, n, e)
+		}
+		// Call to repository to get audit entries
+		entries, total, err := repoDB.GetAuditEntriesFiltered(test.filter)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedTotal, total, "Error in test case %v", n)
+		ids := []string{}
+		for _, e := range entries {
+			ids = append(ids, e.ID)
+			// Check stored state
+			switch e.ID {
+			case "1":
+				assert.Equal(t, json.RawMessage(`{"externalId":"user"}`), e.After, "Error in test case %v", n)
+				assert.Nil(t, e.Before, "Error in test case %v", n)
+			case "3":
+				assert.Equal(t, json.RawMessage(`{"externalId":"user"}`), e.Before, "Error in test case %v", n)
+				assert.Nil(t, e.After, "Error in test case %v", n)
+			}
+		}
+		assert.Equal(t, test.expectedIDs, ids, "Error in test case %v", n)
+	}
+}
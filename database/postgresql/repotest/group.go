@@ -1,4 +1,4 @@
-package postgresql
+package repotest
 
 import (
 	"testing"
@@ -6,15 +6,15 @@ import (
 
 	"github.com/Tecsisa/foulkon/api"
 	"github.com/Tecsisa/foulkon/database"
-
+	"github.com/Tecsisa/foulkon/database/postgresql"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestPostgresRepo_AddGroup(t *testing.T) {
+func testAddGroup(t *testing.T) {
 	now := time.Now().UTC()
 	testcases := map[string]struct {
 		// Previous data
-		previousGroup *Group
+		previousGroup *postgresql.Group
 		// Postgres Repo Args
 		groupToCreate *api.Group
 		// Expected result
@@ -42,7 +42,7 @@ func TestPostgresRepo_AddGroup(t *testing.T) {
 			},
 		},
 		"ErrorCasegroupAlreadyExist": {
-			previousGroup: &Group{
+			previousGroup: &postgresql.Group{
 				ID:       "GroupID",
 				Name:     "Name",
 				Path:     "Path",
@@ -62,7 +62,7 @@ func TestPostgresRepo_AddGroup(t *testing.T) {
 			},
 			expectedError: &database.Error{
 				Code:    database.INTERNAL_ERROR,
-				Message: "pq: duplicate key value violates unique constraint \"groups_pkey\"",
+				Message: driver.UniqueViolation("groups_pkey"),
 			},
 		},
 	}
@@ -96,11 +96,11 @@ func TestPostgresRepo_AddGroup(t *testing.T) {
 	}
 }
 
-func TestPostgresRepo_GetGroupByName(t *testing.T) {
+func testGetGroupByName(t *testing.T) {
 	now := time.Now().UTC()
 	testcases := map[string]struct {
 		// Previous data
-		previousGroup *Group
+		previousGroup *postgresql.Group
 		// Postgres Repo Args
 		org  string
 		name string
@@ -109,7 +109,7 @@ func TestPostgresRepo_GetGroupByName(t *testing.T) {
 		expectedError    *database.Error
 	}{
 		"OkCase": {
-			previousGroup: &Group{
+			previousGroup: &postgresql.Group{
 				ID:       "GroupID",
 				Name:     "Name",
 				Path:     "Path",
@@ -131,7 +131,7 @@ func TestPostgresRepo_GetGroupByName(t *testing.T) {
 			},
 		},
 		"ErrorCaseGroupNotExist": {
-			previousGroup: &Group{
+			previousGroup: &postgresql.Group{
 				ID:       "GroupID",
 				Name:     "Name",
 				Path:     "Path",
@@ -171,11 +171,11 @@ func TestPostgresRepo_GetGroupByName(t *testing.T) {
 	}
 }
 
-func TestPostgresRepo_GetGroupById(t *testing.T) {
+func testGetGroupById(t *testing.T) {
 	now := time.Now().UTC()
 	testcases := map[string]struct {
 		// Previous data
-		previousGroup *Group
+		previousGroup *postgresql.Group
 		// Postgres Repo Args
 		groupID string
 		// Expected result
@@ -183,7 +183,7 @@ func TestPostgresRepo_GetGroupById(t *testing.T) {
 		expectedError    *database.Error
 	}{
 		"OkCase": {
-			previousGroup: &Group{
+			previousGroup: &postgresql.Group{
 				ID:       "GroupID",
 				Name:     "Name",
 				Path:     "Path",
@@ -204,7 +204,7 @@ func TestPostgresRepo_GetGroupById(t *testing.T) {
 			},
 		},
 		"ErrorCaseGroupNotExist": {
-			previousGroup: &Group{
+			previousGroup: &postgresql.Group{
 				ID:       "GroupID",
 				Name:     "Name",
 				Path:     "Path",
@@ -243,18 +243,18 @@ func TestPostgresRepo_GetGroupById(t *testing.T) {
 	}
 }
 
-func TestPostgresRepo_GetGroupsFiltered(t *testing.T) {
+func testGetGroupsFiltered(t *testing.T) {
 	now := time.Now().UTC()
 	testcases := map[string]struct {
 		// Previous data
-		previousGroups []Group
+		previousGroups []postgresql.Group
 		// Postgres Repo Args
 		filter *api.Filter
 		// Expected result
 		expectedResponse []api.Group
 	}{
 		"OkCasePathPrefix1": {
-			previousGroups: []Group{
+			previousGroups: []postgresql.Group{
 				{
 					ID:       "GroupID1",
 					Name:     "Name1",
@@ -301,7 +301,7 @@ func TestPostgresRepo_GetGroupsFiltered(t *testing.T) {
 			},
 		},
 		"OkCasePathPrefix2": {
-			previousGroups: []Group{
+			previousGroups: []postgresql.Group{
 				{
 					ID:       "GroupID1",
 					Name:     "Name1",
@@ -339,7 +339,7 @@ func TestPostgresRepo_GetGroupsFiltered(t *testing.T) {
 			},
 		},
 		"OkCasePathPrefix3": {
-			previousGroups: []Group{
+			previousGroups: []postgresql.Group{
 				{
 					ID:       "GroupID1",
 					Name:     "Name1",
@@ -367,7 +367,7 @@ func TestPostgresRepo_GetGroupsFiltered(t *testing.T) {
 			expectedResponse: []api.Group{},
 		},
 		"OkCaseGetByOrg": {
-			previousGroups: []Group{
+			previousGroups: []postgresql.Group{
 				{
 					ID:       "GroupID1",
 					Name:     "Name1",
@@ -403,7 +403,7 @@ func TestPostgresRepo_GetGroupsFiltered(t *testing.T) {
 			},
 		},
 		"OkCaseOrderBy": {
-			previousGroups: []Group{
+			previousGroups: []postgresql.Group{
 				{
 					ID:       "GroupID1",
 					Name:     "Name1",
@@ -448,7 +448,7 @@ func TestPostgresRepo_GetGroupsFiltered(t *testing.T) {
 			},
 		},
 		"OkCaseGetByOrgAndPathPrefix": {
-			previousGroups: []Group{
+			previousGroups: []postgresql.Group{
 				{
 					ID:       "GroupID1",
 					Name:     "Name1",
@@ -487,7 +487,7 @@ func TestPostgresRepo_GetGroupsFiltered(t *testing.T) {
 			},
 		},
 		"OkCaseWithoutParams": {
-			previousGroups: []Group{
+			previousGroups: []postgresql.Group{
 				{
 					ID:       "GroupID1",
 					Name:     "Name1",
@@ -553,11 +553,11 @@ func TestPostgresRepo_GetGroupsFiltered(t *testing.T) {
 	}
 }
 
-func TestPostgresRepo_UpdateGroup(t *testing.T) {
+func testUpdateGroup(t *testing.T) {
 	now := time.Now().UTC()
 	testcases := map[string]struct {
 		// Previous data
-		previousGroups []Group
+		previousGroups []postgresql.Group
 		// Postgres Repo Args
 		groupToUpdate *api.Group
 		// Expected result
@@ -565,7 +565,7 @@ func TestPostgresRepo_UpdateGroup(t *testing.T) {
 		expectedError    *database.Error
 	}{
 		"OkCase": {
-			previousGroups: []Group{
+			previousGroups: []postgresql.Group{
 				{
 					ID:       "GroupID",
 					Name:     "Name",
@@ -596,7 +596,7 @@ func TestPostgresRepo_UpdateGroup(t *testing.T) {
 			},
 		},
 		"ErrorCaseDuplicateUrn": {
-			previousGroups: []Group{
+			previousGroups: []postgresql.Group{
 				{
 					ID:       "GroupID",
 					Name:     "Name",
@@ -626,7 +626,33 @@ func TestPostgresRepo_UpdateGroup(t *testing.T) {
 			},
 			expectedError: &database.Error{
 				Code:    database.INTERNAL_ERROR,
-				Message: "pq: duplicate key value violates unique constraint \"groups_urn_key\"",
+				Message: driver.UniqueViolation("groups_urn_key"),
+			},
+		},
+		"ErrorCaseVersionConflict": {
+			previousGroups: []postgresql.Group{
+				{
+					ID:       "GroupID",
+					Name:     "Name",
+					Path:     "Path",
+					Urn:      "Urn",
+					CreateAt: now.UnixNano(),
+					UpdateAt: now.Add(time.Second).UnixNano(),
+					Org:      "Org",
+				},
+			},
+			groupToUpdate: &api.Group{
+				ID:       "GroupID",
+				Name:     "NewName",
+				Path:     "NewPath",
+				Urn:      "NewUrn",
+				CreateAt: now,
+				UpdateAt: now,
+				Org:      "Org",
+			},
+			expectedError: &database.Error{
+				Code:    database.VERSION_CONFLICT,
+				Message: "Group with id GroupID was modified or removed by another request",
 			},
 		},
 	}
@@ -643,7 +669,7 @@ func TestPostgresRepo_UpdateGroup(t *testing.T) {
 		}
 
 		// Call to repository to update group
-		updatedGroup, err := repoDB.UpdateGroup(*test.groupToUpdate)
+		updatedGroup, err := repoDB.UpdateGroup(*test.groupToUpdate, now)
 		if test.expectedError != nil {
 			dbError, _ := err.(*database.Error)
 			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
@@ -660,7 +686,7 @@ func TestPostgresRepo_UpdateGroup(t *testing.T) {
 	}
 }
 
-func TestPostgresRepo_RemoveGroup(t *testing.T) {
+func testRemoveGroup(t *testing.T) {
 	type userRelation struct {
 		userID   string
 		groupID  string
@@ -671,17 +697,23 @@ func TestPostgresRepo_RemoveGroup(t *testing.T) {
 		groupID  string
 		CreateAt int64
 	}
+	type groupRelation struct {
+		childID  string
+		parentID string
+		CreateAt int64
+	}
 	now := time.Now().UTC()
 	testcases := map[string]struct {
 		// Previous data
-		previousGroups  []Group
+		previousGroups  []postgresql.Group
 		userRelations   []userRelation
 		policyRelations []policyRelation
+		groupRelations  []groupRelation
 		// Postgres Repo Args
 		groupToDelete string
 	}{
 		"OkCase": {
-			previousGroups: []Group{
+			previousGroups: []postgresql.Group{
 				{
 					ID:       "GroupID",
 					Name:     "Name",
@@ -725,6 +757,23 @@ func TestPostgresRepo_RemoveGroup(t *testing.T) {
 					CreateAt: now.UnixNano(),
 				},
 			},
+			groupRelations: []groupRelation{
+				{
+					childID:  "GroupID",
+					parentID: "GroupID2",
+					CreateAt: now.UnixNano(),
+				},
+				{
+					childID:  "GroupID3",
+					parentID: "GroupID",
+					CreateAt: now.UnixNano(),
+				},
+				{
+					childID:  "GroupID3",
+					parentID: "GroupID2",
+					CreateAt: now.UnixNano(),
+				},
+			},
 			groupToDelete: "GroupID",
 		},
 	}
@@ -733,6 +782,7 @@ func TestPostgresRepo_RemoveGroup(t *testing.T) {
 		cleanGroupTable(t, n)
 		cleanGroupUserRelationTable(t, n)
 		cleanGroupPolicyRelationTable(t, n)
+		cleanGroupGroupRelationTable(t, n)
 
 		// Insert previous data
 		if test.previousGroups != nil {
@@ -751,6 +801,11 @@ func TestPostgresRepo_RemoveGroup(t *testing.T) {
 				insertGroupPolicyRelation(t, n, rel.groupID, rel.policyID, rel.CreateAt)
 			}
 		}
+		if test.groupRelations != nil {
+			for _, rel := range test.groupRelations {
+				insertGroupGroupRelation(t, n, rel.childID, rel.parentID, rel.CreateAt)
+			}
+		}
 		// Call to repository to remove group
 		err := repoDB.RemoveGroup(test.groupToDelete)
 		assert.Nil(t, err, "Error in test case %v", n)
@@ -778,14 +833,24 @@ func TestPostgresRepo_RemoveGroup(t *testing.T) {
 		// Check total group policy relations
 		totalRelations = getGroupPolicyRelationCount(t, n, "", "")
 		assert.Equal(t, 1, totalRelations, "Error in test case %v", n)
+
+		// Check parent and child group relations
+		relations = getGroupGroupRelations(t, n, test.groupToDelete, "") + getGroupGroupRelations(t, n, "", test.groupToDelete)
+		assert.Equal(t, 0, relations, "Error in test case %v", n)
+
+		// Check total parent and child group relations
+		totalRelations = getGroupGroupRelations(t, n, "", "")
+		assert.Equal(t, 1, totalRelations, "Error in test case %v", n)
 	}
 }
 
-func TestPostgresRepo_AddMember(t *testing.T) {
+func testAddMember(t *testing.T) {
+	expiresAt := time.Now().UTC().Add(time.Hour)
 	testcases := map[string]struct {
 		// Postgres Repo Args
-		userID  string
-		groupID string
+		userID    string
+		groupID   string
+		expiresAt *time.Time
 		// Expected result
 		expectedError *database.Error
 	}{
@@ -793,11 +858,16 @@ func TestPostgresRepo_AddMember(t *testing.T) {
 			userID:  "UserID",
 			groupID: "GroupID",
 		},
+		"OkCaseWithExpiration": {
+			userID:    "UserID",
+			groupID:   "GroupID",
+			expiresAt: &expiresAt,
+		},
 		"ErrorCaseInternalError": {
 			groupID: "GroupID",
 			expectedError: &database.Error{
 				Code:    database.INTERNAL_ERROR,
-				Message: "pq: null value in column \"user_id\" violates not-null constraint",
+				Message: driver.NotNullViolation("group_user_relations", "user_id"),
 			},
 		},
 	}
@@ -807,7 +877,7 @@ func TestPostgresRepo_AddMember(t *testing.T) {
 		cleanGroupUserRelationTable(t, n)
 
 		// Call to repository to store member
-		err := repoDB.AddMember(test.userID, test.groupID)
+		err := repoDB.AddMember(test.userID, test.groupID, test.expiresAt)
 		if test.expectedError != nil {
 			dbError, _ := err.(*database.Error)
 			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
@@ -817,11 +887,17 @@ func TestPostgresRepo_AddMember(t *testing.T) {
 			// Check database
 			relations := getGroupUserRelations(t, n, test.groupID, test.userID)
 			assert.Equal(t, 1, relations, "Error in test case %v", n)
+
+			// Check expiration
+			relation := postgresql.GroupUserRelation{}
+			err = repoDB.Dbmap.Where("user_id = ? AND group_id = ?", test.userID, test.groupID).First(&relation).Error
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, apiExpirationToDB(test.expiresAt), relation.ExpiresAt, "Error in test case %v", n)
 		}
 	}
 }
 
-func TestPostgresRepo_RemoveMember(t *testing.T) {
+func testRemoveMember(t *testing.T) {
 	type relation struct {
 		userID   string
 		groupID  string
@@ -867,7 +943,7 @@ func TestPostgresRepo_RemoveMember(t *testing.T) {
 	}
 }
 
-func TestPostgresRepo_IsMemberOfGroup(t *testing.T) {
+func testIsMemberOfGroup(t *testing.T) {
 	type relation struct {
 		userID   string
 		groupID  string
@@ -916,9 +992,9 @@ func TestPostgresRepo_IsMemberOfGroup(t *testing.T) {
 	}
 }
 
-func TestPostgresRepo_GetGroupMembers(t *testing.T) {
+func testGetGroupMembers(t *testing.T) {
 	type relations struct {
-		users        []User
+		users        []postgresql.User
 		groupID      string
 		createAt     []int64
 		userNotFound bool
@@ -931,12 +1007,12 @@ func TestPostgresRepo_GetGroupMembers(t *testing.T) {
 		groupID string
 		filter  *api.Filter
 		// Expected result
-		expectedResponse []*GroupUser
+		expectedResponse []*postgresql.GroupUser
 		expectedError    *database.Error
 	}{
 		"OkCase": {
 			relations: &relations{
-				users: []User{
+				users: []postgresql.User{
 					{
 						ID:         "UserID1",
 						ExternalID: "ExternalID1",
@@ -959,7 +1035,7 @@ func TestPostgresRepo_GetGroupMembers(t *testing.T) {
 			},
 			groupID: "GroupID",
 			filter:  testFilter,
-			expectedResponse: []*GroupUser{
+			expectedResponse: []*postgresql.GroupUser{
 				{
 					User: &api.User{
 						ID:         "UserID1",
@@ -986,7 +1062,7 @@ func TestPostgresRepo_GetGroupMembers(t *testing.T) {
 		},
 		"OkCaseOrderBy": {
 			relations: &relations{
-				users: []User{
+				users: []postgresql.User{
 					{
 						ID:         "UserID1",
 						ExternalID: "ExternalID1",
@@ -1011,7 +1087,7 @@ func TestPostgresRepo_GetGroupMembers(t *testing.T) {
 			filter: &api.Filter{
 				OrderBy: "create_at desc",
 			},
-			expectedResponse: []*GroupUser{
+			expectedResponse: []*postgresql.GroupUser{
 				{
 					User: &api.User{
 						ID:         "UserID1",
@@ -1038,7 +1114,7 @@ func TestPostgresRepo_GetGroupMembers(t *testing.T) {
 		},
 		"ErrorCase": {
 			relations: &relations{
-				users: []User{
+				users: []postgresql.User{
 					{
 						ID: "UserID1",
 					},
@@ -1090,11 +1166,13 @@ func TestPostgresRepo_GetGroupMembers(t *testing.T) {
 	}
 }
 
-func TestPostgresRepo_AttachPolicy(t *testing.T) {
+func testAttachPolicy(t *testing.T) {
+	expiresAt := time.Now().UTC().Add(time.Hour)
 	testcases := map[string]struct {
 		// Postgres Repo Args
-		policyID string
-		groupID  string
+		policyID  string
+		groupID   string
+		expiresAt *time.Time
 		// Expected result
 		expectedError *database.Error
 	}{
@@ -1102,10 +1180,15 @@ func TestPostgresRepo_AttachPolicy(t *testing.T) {
 			policyID: "PolicyID",
 			groupID:  "GroupID",
 		},
+		"OkCaseWithExpiration": {
+			policyID:  "PolicyID",
+			groupID:   "GroupID",
+			expiresAt: &expiresAt,
+		},
 		"ErrorCaseInternalError": {
 			expectedError: &database.Error{
 				Code:    database.INTERNAL_ERROR,
-				Message: "pq: null value in column \"group_id\" violates not-null constraint",
+				Message: driver.NotNullViolation("group_policy_relations", "group_id"),
 			},
 		},
 	}
@@ -1115,7 +1198,7 @@ func TestPostgresRepo_AttachPolicy(t *testing.T) {
 		cleanGroupPolicyRelationTable(t, n)
 
 		// Call to repository to attach policy
-		err := repoDB.AttachPolicy(test.groupID, test.policyID)
+		err := repoDB.AttachPolicy(test.groupID, test.policyID, test.expiresAt)
 		if test.expectedError != nil {
 			dbError, _ := err.(*database.Error)
 			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
@@ -1124,11 +1207,17 @@ func TestPostgresRepo_AttachPolicy(t *testing.T) {
 			// Check database
 			relations := getGroupPolicyRelationCount(t, n, test.policyID, test.groupID)
 			assert.Equal(t, 1, relations, "Error in test case %v", n)
+
+			// Check expiration
+			relation := postgresql.GroupPolicyRelation{}
+			err = repoDB.Dbmap.Where("group_id = ? AND policy_id = ?", test.groupID, test.policyID).First(&relation).Error
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, apiExpirationToDB(test.expiresAt), relation.ExpiresAt, "Error in test case %v", n)
 		}
 	}
 }
 
-func TestPostgresRepo_DetachPolicy(t *testing.T) {
+func testDetachPolicy(t *testing.T) {
 	type relation struct {
 		policyID string
 		groupID  string
@@ -1174,7 +1263,7 @@ func TestPostgresRepo_DetachPolicy(t *testing.T) {
 	}
 }
 
-func TestPostgresRepo_IsAttachedToGroup(t *testing.T) {
+func testIsAttachedToGroup(t *testing.T) {
 	type relation struct {
 		groupID  string
 		policyID string
@@ -1228,9 +1317,9 @@ func TestPostgresRepo_IsAttachedToGroup(t *testing.T) {
 	}
 }
 
-func TestPostgresRepo_GetAttachedPolicies(t *testing.T) {
+func testGetAttachedPolicies(t *testing.T) {
 	type relations struct {
-		policies       []Policy
+		policies       []postgresql.Policy
 		groupID        string
 		createAt       []int64
 		policyNotFound bool
@@ -1239,17 +1328,17 @@ func TestPostgresRepo_GetAttachedPolicies(t *testing.T) {
 	testcases := map[string]struct {
 		// Previous data
 		relations  *relations
-		statements []Statement
+		statements []postgresql.Statement
 		// Postgres Repo Args
 		groupID string
 		filter  *api.Filter
 		// Expected result
-		expectedResponse []*PolicyGroup
+		expectedResponse []*postgresql.PolicyGroup
 		expectedError    *database.Error
 	}{
 		"OkCase": {
 			relations: &relations{
-				policies: []Policy{
+				policies: []postgresql.Policy{
 					{
 						ID:       "PolicyID1",
 						Name:     "Name1",
@@ -1272,12 +1361,12 @@ func TestPostgresRepo_GetAttachedPolicies(t *testing.T) {
 				groupID:  "GroupID",
 				createAt: []int64{now.UnixNano() - 1, now.UnixNano()},
 			},
-			statements: []Statement{},
+			statements: []postgresql.Statement{},
 			groupID:    "GroupID",
 			filter: &api.Filter{
 				OrderBy: "create_at desc",
 			},
-			expectedResponse: []*PolicyGroup{
+			expectedResponse: []*postgresql.PolicyGroup{
 				{
 					Policy: &api.Policy{
 						ID:         "PolicyID2",
@@ -1308,7 +1397,7 @@ func TestPostgresRepo_GetAttachedPolicies(t *testing.T) {
 		},
 		"ErrorCase": {
 			relations: &relations{
-				policies: []Policy{
+				policies: []postgresql.Policy{
 					{
 						ID:       "PolicyID1",
 						Name:     "Name1",
@@ -1332,7 +1421,7 @@ func TestPostgresRepo_GetAttachedPolicies(t *testing.T) {
 				createAt:       []int64{now.UnixNano(), now.UnixNano()},
 				policyNotFound: true,
 			},
-			statements: []Statement{},
+			statements: []postgresql.Statement{},
 			groupID:    "GroupID",
 			filter:     testFilter,
 			expectedError: &database.Error{
@@ -1376,3 +1465,441 @@ func TestPostgresRepo_GetAttachedPolicies(t *testing.T) {
 		}
 	}
 }
+
+func testAddChildGroup(t *testing.T) {
+	testcases := map[string]struct {
+		// Postgres Repo Args
+		childID  string
+		parentID string
+		// Expected result
+		expectedError *database.Error
+	}{
+		"OkCase": {
+			childID:  "ChildID",
+			parentID: "ParentID",
+		},
+		"ErrorCaseInternalError": {
+			parentID: "ParentID",
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: driver.NotNullViolation("group_group_relations", "child_id"),
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean GroupGroupRelation database
+		cleanGroupGroupRelationTable(t, n)
+
+		// Call to repository to store child group
+		err := repoDB.AddChildGroup(test.childID, test.parentID)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+
+			// Check database
+			relations := getGroupGroupRelations(t, n, test.childID, test.parentID)
+			assert.Equal(t, 1, relations, "Error in test case %v", n)
+		}
+	}
+}
+
+func testRemoveChildGroup(t *testing.T) {
+	type relation struct {
+		childID  string
+		parentID string
+		createAt int64
+	}
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		relation *relation
+		// Postgres Repo Args
+		childID  string
+		parentID string
+	}{
+		"OkCase": {
+			relation: &relation{
+				childID:  "ChildID",
+				parentID: "ParentID",
+				createAt: now.UnixNano(),
+			},
+			childID:  "ChildID",
+			parentID: "ParentID",
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean GroupGroupRelation database
+		cleanGroupGroupRelationTable(t, n)
+
+		// Insert previous data
+		if test.relation != nil {
+			insertGroupGroupRelation(t, n, test.relation.childID, test.relation.parentID, test.relation.createAt)
+		}
+
+		// Call to repository to remove child group
+		err := repoDB.RemoveChildGroup(test.childID, test.parentID)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// Check database
+		relations := getGroupGroupRelations(t, n, test.childID, test.parentID)
+		assert.Equal(t, 0, relations, "Error in test case %v", n)
+	}
+}
+
+func testIsChildOfGroup(t *testing.T) {
+	type relation struct {
+		childID  string
+		parentID string
+		createAt int64
+	}
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		relation *relation
+		// Postgres Repo Args
+		childID  string
+		parentID string
+		// Expected result
+		isChild bool
+	}{
+		"OkCaseIsChild": {
+			relation: &relation{
+				childID:  "ChildID",
+				parentID: "ParentID",
+				createAt: now.UnixNano(),
+			},
+			childID:  "ChildID",
+			parentID: "ParentID",
+			isChild:  true,
+		},
+		"OkCaseIsParent": {
+			relation: &relation{
+				childID:  "ChildID",
+				parentID: "ParentID",
+				createAt: now.UnixNano(),
+			},
+			childID:  "ParentID",
+			parentID: "ChildID",
+			isChild:  false,
+		},
+		"OkCaseIsNotChild": {
+			childID:  "ChildID",
+			parentID: "ParentID",
+			isChild:  false,
+		},
+	}
+
+	for n, test := range testcases {
+		cleanGroupGroupRelationTable(t, n)
+
+		// Insert previous data
+		if test.relation != nil {
+			insertGroupGroupRelation(t, n, test.relation.childID, test.relation.parentID, test.relation.createAt)
+		}
+
+		isChild, err := repoDB.IsChildOfGroup(test.childID, test.parentID)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// Check response
+		assert.Equal(t, test.isChild, isChild, "Error in test case %v", n)
+	}
+}
+
+func testGetChildGroups(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousGroups []postgresql.Group
+		parentID       string
+		childIDs       []string
+		// Postgres Repo Args
+		filter *api.Filter
+		// Expected result
+		expectedResponse []api.GroupGroupRelation
+		expectedTotal    int
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			previousGroups: []postgresql.Group{
+				{
+					ID:       "ChildID1",
+					Name:     "Child1",
+					Path:     "Path",
+					Urn:      "Urn1",
+					CreateAt: now.UnixNano(),
+					UpdateAt: now.UnixNano(),
+					Org:      "Org",
+				},
+				{
+					ID:       "ChildID2",
+					Name:     "Child2",
+					Path:     "Path",
+					Urn:      "Urn2",
+					CreateAt: now.UnixNano(),
+					UpdateAt: now.UnixNano(),
+					Org:      "Org",
+				},
+			},
+			parentID: "ParentID",
+			childIDs: []string{"ChildID1", "ChildID2"},
+			filter: &api.Filter{
+				OrderBy: "create_at",
+			},
+			expectedResponse: []api.GroupGroupRelation{
+				&postgresql.GroupGroup{
+					Child: &api.Group{
+						ID:       "ChildID1",
+						Name:     "Child1",
+						Path:     "Path",
+						Urn:      "Urn1",
+						CreateAt: now,
+						UpdateAt: now,
+						Org:      "Org",
+					},
+					CreateAt: now,
+				},
+				&postgresql.GroupGroup{
+					Child: &api.Group{
+						ID:       "ChildID2",
+						Name:     "Child2",
+						Path:     "Path",
+						Urn:      "Urn2",
+						CreateAt: now,
+						UpdateAt: now,
+						Org:      "Org",
+					},
+					CreateAt: now.Add(time.Second),
+				},
+			},
+			expectedTotal: 2,
+		},
+		"ErrorCaseChildGroupNotFound": {
+			parentID: "ParentID",
+			childIDs: []string{"ChildID1"},
+			filter:   testFilter,
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Code: GroupNotFound, Message: Group with id ChildID1 not found",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		cleanGroupTable(t, n)
+		cleanGroupGroupRelationTable(t, n)
+
+		// Insert previous data
+		for _, g := range test.previousGroups {
+			insertGroup(t, n, g)
+		}
+		for i, childID := range test.childIDs {
+			insertGroupGroupRelation(t, n, childID, test.parentID, now.Add(time.Duration(i)*time.Second).UnixNano())
+		}
+
+		children, total, err := repoDB.GetChildGroups(test.parentID, test.filter)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedTotal, total, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, children, "Error in test case %v", n)
+		}
+	}
+}
+
+func testGetAncestorGroups(t *testing.T) {
+	type relation struct {
+		childID  string
+		parentID string
+	}
+	now := time.Now().UTC()
+	groups := []postgresql.Group{
+		{
+			ID:       "GroupID1",
+			Name:     "Group1",
+			Path:     "Path",
+			Urn:      "Urn1",
+			CreateAt: now.UnixNano(),
+			UpdateAt: now.UnixNano(),
+			Org:      "Org",
+		},
+		{
+			ID:       "GroupID2",
+			Name:     "Group2",
+			Path:     "Path",
+			Urn:      "Urn2",
+			CreateAt: now.Add(time.Second).UnixNano(),
+			UpdateAt: now.UnixNano(),
+			Org:      "Org",
+		},
+		{
+			ID:       "GroupID3",
+			Name:     "Group3",
+			Path:     "Path",
+			Urn:      "Urn3",
+			CreateAt: now.Add(2 * time.Second).UnixNano(),
+			UpdateAt: now.UnixNano(),
+			Org:      "Org",
+		},
+	}
+	testcases := map[string]struct {
+		// Previous data
+		relations []relation
+		// Postgres Repo Args
+		groupIDs []string
+		// Expected result
+		expectedResponse []api.Group
+	}{
+		"OkCaseTransitiveParents": {
+			relations: []relation{
+				{
+					childID:  "GroupID1",
+					parentID: "GroupID2",
+				},
+				{
+					childID:  "GroupID2",
+					parentID: "GroupID3",
+				},
+			},
+			groupIDs: []string{"GroupID1"},
+			expectedResponse: []api.Group{
+				*dbGroupToAPIGroup(&groups[1]),
+				*dbGroupToAPIGroup(&groups[2]),
+			},
+		},
+		"OkCaseSharedParent": {
+			relations: []relation{
+				{
+					childID:  "GroupID1",
+					parentID: "GroupID3",
+				},
+				{
+					childID:  "GroupID2",
+					parentID: "GroupID3",
+				},
+			},
+			groupIDs: []string{"GroupID1", "GroupID2"},
+			expectedResponse: []api.Group{
+				*dbGroupToAPIGroup(&groups[2]),
+			},
+		},
+		"OkCaseCycle": {
+			relations: []relation{
+				{
+					childID:  "GroupID1",
+					parentID: "GroupID2",
+				},
+				{
+					childID:  "GroupID2",
+					parentID: "GroupID1",
+				},
+			},
+			groupIDs: []string{"GroupID1"},
+			expectedResponse: []api.Group{
+				*dbGroupToAPIGroup(&groups[0]),
+				*dbGroupToAPIGroup(&groups[1]),
+			},
+		},
+		"OkCaseNoParents": {
+			groupIDs:         []string{"GroupID1"},
+			expectedResponse: []api.Group{},
+		},
+		"OkCaseNoGroups": {
+			groupIDs: []string{},
+		},
+	}
+
+	for n, test := range testcases {
+		cleanGroupTable(t, n)
+		cleanGroupGroupRelationTable(t, n)
+
+		// Insert previous data
+		for _, g := range groups {
+			insertGroup(t, n, g)
+		}
+		for _, rel := range test.relations {
+			insertGroupGroupRelation(t, n, rel.childID, rel.parentID, now.UnixNano())
+		}
+
+		ancestors, err := repoDB.GetAncestorGroups(test.groupIDs)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedResponse, ancestors, "Error in test case %v", n)
+	}
+}
+
+func testRemoveExpiredRelations(t *testing.T) {
+	type relation struct {
+		firstID   string
+		secondID  string
+		expiresAt int64
+	}
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		members  []relation
+		policies []relation
+		// Postgres Repo Args
+		date time.Time
+		// Expected result
+		expectedRemoved  int
+		expectedMembers  int
+		expectedPolicies int
+	}{
+		"OkCase": {
+			members: []relation{
+				{firstID: "UserID1", secondID: "GroupID", expiresAt: now.Add(-time.Hour).UnixNano()},
+				{firstID: "UserID2", secondID: "GroupID", expiresAt: now.Add(time.Hour).UnixNano()},
+				{firstID: "UserID3", secondID: "GroupID"},
+			},
+			policies: []relation{
+				{firstID: "GroupID", secondID: "PolicyID1", expiresAt: now.UnixNano()},
+				{firstID: "GroupID", secondID: "PolicyID2"},
+			},
+			date:             now,
+			expectedRemoved:  2,
+			expectedMembers:  2,
+			expectedPolicies: 1,
+		},
+		"OkCaseNothingExpired": {
+			members: []relation{
+				{firstID: "UserID1", secondID: "GroupID", expiresAt: now.Add(time.Hour).UnixNano()},
+			},
+			policies: []relation{
+				{firstID: "GroupID", secondID: "PolicyID1"},
+			},
+			date:             now,
+			expectedMembers:  1,
+			expectedPolicies: 1,
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean database
+		cleanGroupUserRelationTable(t, n)
+		cleanGroupPolicyRelationTable(t, n)
+
+		// Insert previous data
+		for _, m := range test.members {
+			insertExpiringGroupUserRelation(t, n, m.firstID, m.secondID, now.UnixNano(), m.expiresAt)
+		}
+		for _, p := range test.policies {
+			insertExpiringGroupPolicyRelation(t, n, p.firstID, p.secondID, now.UnixNano(), p.expiresAt)
+		}
+
+		// Call repository to remove expired relations
+		removed, err := repoDB.RemoveExpiredRelations(test.date)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedRemoved, removed, "Error in test case %v", n)
+
+		// Check database
+		members := getGroupUserRelations(t, n, "", "")
+		assert.Equal(t, test.expectedMembers, members, "Error in test case %v", n)
+		policies := getGroupPolicyRelationCount(t, n, "", "")
+		assert.Equal(t, test.expectedPolicies, policies, "Error in test case %v", n)
+	}
+}
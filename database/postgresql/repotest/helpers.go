@@ -0,0 +1,576 @@
+package repotest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database/postgresql"
+	"github.com/stretchr/testify/assert"
+)
+
+// Aux methods
+
+func insertUser(t *testing.T, testcase string, user postgresql.User) {
+	err := repoDB.Dbmap.Exec("INSERT INTO users (id, external_id, path, create_at, update_at, urn) VALUES (?, ?, ?, ?, ?, ?)",
+		user.ID, user.ExternalID, user.Path, user.CreateAt, user.UpdateAt, user.Urn).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func insertGroupUserRelation(t *testing.T, testcase string, userID string, groupID string, createAt int64) {
+	err := repoDB.Dbmap.Exec("INSERT INTO group_user_relations (user_id, group_id, create_at) VALUES (?, ?, ?)",
+		userID, groupID, createAt).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func insertExpiringGroupUserRelation(t *testing.T, testcase string, userID string, groupID string, createAt int64, expiresAt int64) {
+	err := repoDB.Dbmap.Exec("INSERT INTO group_user_relations (user_id, group_id, create_at, expires_at) VALUES (?, ?, ?, ?)",
+		userID, groupID, createAt, expiresAt).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func getUsersCountFiltered(t *testing.T, testcase string,
+	id string, externalID string, path string, createAt int64, updateAt int64, urn string, pathPrefix string) int {
+	query := repoDB.Dbmap.Table(postgresql.User{}.TableName())
+	if id != "" {
+		query = query.Where("id = ?", id)
+	}
+	if externalID != "" {
+		query = query.Where("external_id = ?", externalID)
+	}
+	if path != "" {
+		query = query.Where("path = ?", path)
+	}
+	if pathPrefix != "" {
+		query = query.Where("path like ?", pathPrefix+"%")
+	}
+	if createAt != 0 {
+		query = query.Where("create_at = ?", createAt)
+	}
+	if updateAt != 0 {
+		query = query.Where("update_at = ?", updateAt)
+	}
+	if urn != "" {
+		query = query.Where("urn = ?", urn)
+	}
+	var number int
+	err := query.Count(&number).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	return number
+}
+
+func cleanUserTable(t *testing.T, testcase string) {
+	err := repoDB.Dbmap.Delete(&postgresql.User{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func cleanGroupUserRelationTable(t *testing.T, testcase string) {
+	err := repoDB.Dbmap.Delete(&postgresql.GroupUserRelation{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func cleanUserPolicyRelationTable(t *testing.T, testcase string) {
+	err := repoDB.Dbmap.Delete(&postgresql.UserPolicyRelation{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func insertUserPolicyRelation(t *testing.T, testcase string, userID string, policyID string, createAt int64) {
+	err := repoDB.Dbmap.Exec("INSERT INTO user_policy_relations (user_id, policy_id, create_at) VALUES (?, ?, ?)",
+		userID, policyID, createAt).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func getUserPolicyRelationCount(t *testing.T, testcase string, userID string, policyID string) int {
+	query := repoDB.Dbmap.Table(postgresql.UserPolicyRelation{}.TableName())
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if policyID != "" {
+		query = query.Where("policy_id = ?", policyID)
+	}
+	var number int
+	err := query.Count(&number).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	return number
+}
+
+// GROUP
+
+func insertGroup(t *testing.T, testcase string, group postgresql.Group) {
+	err := repoDB.Dbmap.Exec("INSERT INTO groups (id, name, path, create_at, update_at, urn, org) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		group.ID, group.Name, group.Path, group.CreateAt, group.UpdateAt, group.Urn, group.Org).Error
+
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func getGroupsCountFiltered(t *testing.T, testcase string,
+	id string, name string, path string, createAt int64, updateAt int64, urn string, org string) int {
+	query := repoDB.Dbmap.Table(postgresql.Group{}.TableName())
+	if id != "" {
+		query = query.Where("id = ?", id)
+	}
+	if name != "" {
+		query = query.Where("name = ?", name)
+	}
+	if path != "" {
+		query = query.Where("path = ?", path)
+	}
+	if createAt != 0 {
+		query = query.Where("create_at = ?", createAt)
+	}
+	if updateAt != 0 {
+		query = query.Where("update_at = ?", updateAt)
+	}
+	if urn != "" {
+		query = query.Where("urn = ?", urn)
+	}
+	if org != "" {
+		query = query.Where("org = ?", org)
+	}
+	var number int
+	err := query.Count(&number).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	return number
+}
+
+func getGroupUserRelations(t *testing.T, testcase string, groupID string, userID string) int {
+	query := repoDB.Dbmap.Table(postgresql.GroupUserRelation{}.TableName())
+	if groupID != "" {
+		query = query.Where("group_id = ?", groupID)
+	}
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var number int
+	err := query.Count(&number).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	return number
+}
+
+func cleanGroupTable(t *testing.T, testcase string) {
+	err := repoDB.Dbmap.Delete(&postgresql.Group{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func cleanGroupPolicyRelationTable(t *testing.T, testcase string) {
+	err := repoDB.Dbmap.Delete(&postgresql.GroupPolicyRelation{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func cleanGroupGroupRelationTable(t *testing.T, testcase string) {
+	err := repoDB.Dbmap.Delete(&postgresql.GroupGroupRelation{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func insertGroupGroupRelation(t *testing.T, testcase string, childID string, parentID string, createAt int64) {
+	err := repoDB.Dbmap.Exec("INSERT INTO group_group_relations (parent_id, child_id, create_at) VALUES (?, ?, ?)",
+		parentID, childID, createAt).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func getGroupGroupRelations(t *testing.T, testcase string, childID string, parentID string) int {
+	query := repoDB.Dbmap.Table(postgresql.GroupGroupRelation{}.TableName())
+	if childID != "" {
+		query = query.Where("child_id = ?", childID)
+	}
+	if parentID != "" {
+		query = query.Where("parent_id = ?", parentID)
+	}
+
+	var number int
+	err := query.Count(&number).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	return number
+}
+
+// POLICY
+
+func cleanPolicyTable(t *testing.T, testcase string) {
+	err := repoDB.Dbmap.Delete(&postgresql.Policy{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func cleanStatementTable(t *testing.T, testcase string) {
+	err := repoDB.Dbmap.Delete(&postgresql.Statement{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func insertPolicy(t *testing.T, testcase string, policy postgresql.Policy, statements []postgresql.Statement) {
+	err := repoDB.Dbmap.Exec("INSERT INTO policies (id, name, org, path, create_at, update_at, urn) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		policy.ID, policy.Name, policy.Org, policy.Path, policy.CreateAt, policy.UpdateAt, policy.Urn).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	for _, v := range statements {
+		v.PolicyID = policy.ID
+		insertStatements(t, testcase, v)
+		// Error handling
+		assert.Nil(t, err, "Error in test case %v", testcase)
+	}
+}
+
+func insertStatements(t *testing.T, testcase string, statement postgresql.Statement) {
+	err := repoDB.Dbmap.Exec("INSERT INTO statements (id, policy_id, effect, actions, resources, conditions) VALUES (?, ?, ?, ?, ?, ?)",
+		statement.ID, statement.PolicyID, statement.Effect, statement.Actions, statement.Resources, statement.Conditions).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func getPoliciesCountFiltered(t *testing.T, testcase string,
+	id string, org string, name string, path string, createAt int64, urn string) int {
+	query := repoDB.Dbmap.Table(postgresql.Policy{}.TableName())
+	if id != "" {
+		query = query.Where("id = ?", id)
+	}
+	if org != "" {
+		query = query.Where("org = ?", org)
+	}
+	if path != "" {
+		query = query.Where("path = ?", path)
+	}
+	if name != "" {
+		query = query.Where("name = ?", name)
+	}
+	if createAt != 0 {
+		query = query.Where("create_at = ?", createAt)
+	}
+	if urn != "" {
+		query = query.Where("urn = ?", urn)
+	}
+	var number int
+	err := query.Count(&number).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	return number
+}
+
+func getGroupPolicyRelationCount(t *testing.T, testcase string, policyID string, groupID string) int {
+	query := repoDB.Dbmap.Table(postgresql.GroupPolicyRelation{}.TableName())
+	if policyID != "" {
+		query = query.Where("policy_id = ?", policyID)
+	}
+	if groupID != "" {
+		query = query.Where("group_id = ?", groupID)
+	}
+	var number int
+	err := query.Count(&number).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	return number
+}
+
+func insertGroupPolicyRelation(t *testing.T, testcase string, groupID string, policyID string, createAt int64) {
+	err := repoDB.Dbmap.Exec("INSERT INTO group_policy_relations (group_id, policy_id, create_at) VALUES (?, ?, ?)",
+		groupID, policyID, createAt).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func insertExpiringGroupPolicyRelation(t *testing.T, testcase string, groupID string, policyID string, createAt int64, expiresAt int64) {
+	err := repoDB.Dbmap.Exec("INSERT INTO group_policy_relations (group_id, policy_id, create_at, expires_at) VALUES (?, ?, ?, ?)",
+		groupID, policyID, createAt, expiresAt).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func getStatementsCountFiltered(t *testing.T, testcase string,
+	id string, policyId string, effect string, actions string, resources string) int {
+	query := repoDB.Dbmap.Table(postgresql.Statement{}.TableName())
+	if id != "" {
+		query = query.Where("id = ?", id)
+	}
+	if policyId != "" {
+		query = query.Where("policy_id = ?", policyId)
+	}
+	if effect != "" {
+		query = query.Where("effect = ?", effect)
+	}
+	if actions != "" {
+		query = query.Where("actions = ?", actions)
+	}
+	if resources != "" {
+		query = query.Where("resources = ?", resources)
+	}
+	var number int
+	err := query.Count(&number).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	return number
+}
+
+// PROXY
+
+func cleanProxyResourcesTable(t *testing.T, testcase string) {
+	err := repoDB.Dbmap.Delete(&postgresql.ProxyResource{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func insertProxyResource(t *testing.T, testcase string, pr postgresql.ProxyResource) {
+	err := repoDB.Dbmap.Exec("INSERT INTO proxy_resources (id, name, org, path, host, path_resource, method, urn_resource, "+
+		"urn, action, create_at, update_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		pr.ID, pr.Name, pr.Org, pr.Path, pr.Host, pr.PathResource, pr.Method, pr.UrnResource, pr.Urn, pr.Action, pr.CreateAt, pr.UpdateAt).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in testcase %v", testcase)
+}
+
+func getProxyResourcesCountFiltered(t *testing.T, testcase string, id string,
+	name string, org string, path string, urn string, createAt int64, updateAt int64) int {
+	query := repoDB.Dbmap.Table(postgresql.ProxyResource{}.TableName())
+	if id != "" {
+		query = query.Where("id = ?", id)
+	}
+	if org != "" {
+		query = query.Where("org = ?", org)
+	}
+	if path != "" {
+		query = query.Where("path = ?", path)
+	}
+	if name != "" {
+		query = query.Where("name = ?", name)
+	}
+	if urn != "" {
+		query = query.Where("urn = ?", urn)
+	}
+	if createAt != 0 {
+		query = query.Where("create_at = ?", createAt)
+	}
+	if updateAt != 0 {
+		query = query.Where("update_at = ?", updateAt)
+	}
+	var number int
+	err := query.Count(&number).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	return number
+}
+
+// AUTH OIDC
+
+func cleanOidcProvidersTable(t *testing.T, testcase string) {
+	err := repoDB.Dbmap.Delete(&postgresql.OidcProvider{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func cleanOidcClientsTable(t *testing.T, testcase string) {
+	err := repoDB.Dbmap.Delete(&postgresql.OidcClient{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func insertOidcProvider(t *testing.T, testcase string, oidcProvider postgresql.OidcProvider, oidcClients []postgresql.OidcClient) {
+	err := repoDB.Dbmap.Exec("INSERT INTO oidc_providers (id, name, path, create_at, update_at, urn, issuer_url) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		oidcProvider.ID, oidcProvider.Name, oidcProvider.Path, oidcProvider.CreateAt, oidcProvider.UpdateAt, oidcProvider.Urn, oidcProvider.IssuerURL).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	for _, v := range oidcClients {
+		v.OidcProviderID = oidcProvider.ID
+		insertOidcClient(t, testcase, v)
+		// Error handling
+		assert.Nil(t, err, "Error in test case %v", testcase)
+	}
+}
+
+func insertOidcClient(t *testing.T, testcase string, oidcClient postgresql.OidcClient) {
+	err := repoDB.Dbmap.Exec("INSERT INTO oidc_clients (id, oidc_provider_id, name) VALUES (?, ?, ?)",
+		oidcClient.ID, oidcClient.OidcProviderID, oidcClient.Name).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func getOidcProvidersCountFiltered(t *testing.T, testcase string,
+	id string, name string, path string, createAt int64, updateAt int64, urn string, issuer string) int {
+	query := repoDB.Dbmap.Table(postgresql.OidcProvider{}.TableName())
+	if id != "" {
+		query = query.Where("id = ?", id)
+	}
+	if path != "" {
+		query = query.Where("path = ?", path)
+	}
+	if name != "" {
+		query = query.Where("name = ?", name)
+	}
+	if createAt != 0 {
+		query = query.Where("create_at = ?", createAt)
+	}
+	if updateAt != 0 {
+		query = query.Where("update_at = ?", updateAt)
+	}
+	if urn != "" {
+		query = query.Where("urn = ?", urn)
+	}
+	if issuer != "" {
+		query = query.Where("issuer_url = ?", issuer)
+	}
+	var number int
+	err := query.Count(&number).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	return number
+}
+
+func getOidcClientsCountFiltered(t *testing.T, testcase string,
+	id string, oidcProviderId string, name string) int {
+	query := repoDB.Dbmap.Table(postgresql.OidcClient{}.TableName())
+	if id != "" {
+		query = query.Where("id = ?", id)
+	}
+	if oidcProviderId != "" {
+		query = query.Where("oidc_provider_id = ?", oidcProviderId)
+	}
+	if name != "" {
+		query = query.Where("name = ?", name)
+	}
+	var number int
+	err := query.Count(&number).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	return number
+}
+
+// Transform an expiration date for API into its stored value, as the PostgreSQL repository does
+func apiExpirationToDB(expiresAt *time.Time) int64 {
+	if expiresAt == nil {
+		return 0
+	}
+	return expiresAt.UTC().UnixNano()
+}
+
+// Transform a Group model into a group for API, as the PostgreSQL repository does
+func dbGroupToAPIGroup(groupdb *postgresql.Group) *api.Group {
+	return &api.Group{
+		ID:       groupdb.ID,
+		Name:     groupdb.Name,
+		Path:     groupdb.Path,
+		CreateAt: time.Unix(0, groupdb.CreateAt).UTC(),
+		UpdateAt: time.Unix(0, groupdb.UpdateAt).UTC(),
+		Urn:      groupdb.Urn,
+		Org:      groupdb.Org,
+	}
+}
+
+// Transform an array of strings into the semicolon-separated string stored
+func stringArrayToString(array []string) string {
+	return strings.Join(array, ";")
+}
+
+// AUDIT
+
+func cleanAuditEntriesTable(t *testing.T, testcase string) {
+	err := repoDB.Dbmap.Delete(&postgresql.AuditEntry{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func insertAuditEntry(t *testing.T, testcase string, entry postgresql.AuditEntry) {
+	err := repoDB.Dbmap.Exec("INSERT INTO audit_entries (id, actor, request_id, action, urn, before, after, create_at) "+
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		entry.ID, entry.Actor, entry.RequestID, entry.Action, entry.Urn, entry.Before, entry.After, entry.CreateAt).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func getAuditEntriesCountFiltered(t *testing.T, testcase string, id string, actor string, action string, urn string) int {
+	query := repoDB.Dbmap.Table(postgresql.AuditEntry{}.TableName())
+	if id != "" {
+		query = query.Where("id = ?", id)
+	}
+	if actor != "" {
+		query = query.Where("actor = ?", actor)
+	}
+	if action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if urn != "" {
+		query = query.Where("urn = ?", urn)
+	}
+	var number int
+	err := query.Count(&number).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	return number
+}
+
+// WEBHOOK
+
+func cleanWebhooksTable(t *testing.T, testcase string) {
+	err := repoDB.Dbmap.Delete(&postgresql.Webhook{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+	err = repoDB.Dbmap.Delete(&postgresql.WebhookDeadLetter{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func insertWebhook(t *testing.T, testcase string, webhook postgresql.Webhook) {
+	err := repoDB.Dbmap.Exec("INSERT INTO webhooks (id, name, path, urn, url, secret, events, create_at, update_at) "+
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		webhook.ID, webhook.Name, webhook.Path, webhook.Urn, webhook.URL, webhook.Secret, webhook.Events,
+		webhook.CreateAt, webhook.UpdateAt).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func insertWebhookDeadLetter(t *testing.T, testcase string, deadLetter postgresql.WebhookDeadLetter) {
+	err := repoDB.Dbmap.Exec("INSERT INTO webhook_dead_letters (id, webhook_id, event, attempts, last_error, create_at) "+
+		"VALUES (?, ?, ?, ?, ?, ?)",
+		deadLetter.ID, deadLetter.WebhookID, deadLetter.Event, deadLetter.Attempts, deadLetter.LastError, deadLetter.CreateAt).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func getWebhooksCountFiltered(t *testing.T, testcase string, id string) int {
+	var number int
+	err := repoDB.Dbmap.Table(postgresql.Webhook{}.TableName()).Where("id = ?", id).Count(&number).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	return number
+}
+
+// UPSTREAM POOL
+
+func cleanUpstreamPoolsTable(t *testing.T, testcase string) {
+	err := repoDB.Dbmap.Delete(&postgresql.UpstreamPool{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func insertUpstreamPool(t *testing.T, testcase string, pool postgresql.UpstreamPool) {
+	err := repoDB.Dbmap.Exec("INSERT INTO upstream_pools (id, name, org, path, urn, targets, balancer, health_check, "+
+		"ejection, transport, create_at, update_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		pool.ID, pool.Name, pool.Org, pool.Path, pool.Urn, pool.Targets, pool.Balancer, pool.HealthCheck,
+		pool.Ejection, pool.Transport, pool.CreateAt, pool.UpdateAt).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func getUpstreamPoolsCountFiltered(t *testing.T, testcase string, id string) int {
+	var number int
+	err := repoDB.Dbmap.Table(postgresql.UpstreamPool{}.TableName()).Where("id = ?", id).Count(&number).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+
+	return number
+}
@@ -1,20 +1,21 @@
-package postgresql
+package repotest
 
 import (
 	"testing"
-
 	"time"
 
 	"github.com/Tecsisa/foulkon/api"
 	"github.com/Tecsisa/foulkon/database"
+	"github.com/Tecsisa/foulkon/database/postgresql"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestPostgresRepo_GetProxyResources(t *testing.T) {
+func testGetProxyResources(t *testing.T) {
 	now := time.Now().UTC()
+	later := now.Add(time.Second)
 	testcases := map[string]struct {
 		// Previous data
-		previousResources []ProxyResource
+		previousResources []postgresql.ProxyResource
 		// Postgres Repo Args
 		filter *api.Filter
 		// Expected result
@@ -22,7 +23,7 @@ func TestPostgresRepo_GetProxyResources(t *testing.T) {
 		expectedError    *database.Error
 	}{
 		"OkCase1": {
-			previousResources: []ProxyResource{
+			previousResources: []postgresql.ProxyResource{
 				{
 					ID:           "ID",
 					Name:         "name",
@@ -94,7 +95,7 @@ func TestPostgresRepo_GetProxyResources(t *testing.T) {
 			},
 		},
 		"OkCase2": {
-			previousResources: []ProxyResource{
+			previousResources: []postgresql.ProxyResource{
 				{
 					ID:           "ID",
 					Name:         "name",
@@ -165,6 +166,77 @@ func TestPostgresRepo_GetProxyResources(t *testing.T) {
 				},
 			},
 		},
+		"OkCaseOrderedByCreationDate": {
+			previousResources: []postgresql.ProxyResource{
+				{
+					ID:           "ID2",
+					Name:         "name2",
+					Path:         "path2",
+					Org:          "org",
+					Host:         "host2",
+					PathResource: "/path2",
+					Method:       "Method2",
+					UrnResource:  "urnr2",
+					Action:       "action2",
+					Urn:          "urn2",
+					CreateAt:     later.UnixNano(),
+					UpdateAt:     later.UnixNano(),
+				},
+				{
+					ID:           "ID",
+					Name:         "name",
+					Path:         "path",
+					Org:          "org",
+					Host:         "host",
+					PathResource: "/path",
+					Method:       "Method",
+					UrnResource:  "urnr",
+					Action:       "action",
+					Urn:          "urn",
+					CreateAt:     now.UnixNano(),
+					UpdateAt:     now.UnixNano(),
+				},
+			},
+			filter: &api.Filter{
+				Org:    "org",
+				Offset: 0,
+				Limit:  20,
+			},
+			expectedResponse: []api.ProxyResource{
+				{
+					ID:   "ID",
+					Name: "name",
+					Path: "path",
+					Org:  "org",
+					Resource: api.ResourceEntity{
+						Host:   "host",
+						Path:   "/path",
+						Method: "Method",
+						Urn:    "urnr",
+						Action: "action",
+					},
+					Urn:      "urn",
+					CreateAt: now,
+					UpdateAt: now,
+				},
+				{
+					ID:   "ID2",
+					Name: "name2",
+					Path: "path2",
+					Org:  "org",
+					Resource: api.ResourceEntity{
+						Host:   "host2",
+						Path:   "/path2",
+						Method: "Method2",
+						Urn:    "urnr2",
+						Action: "action2",
+					},
+					Urn:      "urn2",
+					CreateAt: later,
+					UpdateAt: later,
+				},
+			},
+		},
 	}
 
 	for n, test := range testcases {
@@ -190,11 +262,11 @@ func TestPostgresRepo_GetProxyResources(t *testing.T) {
 	}
 }
 
-func TestPostgresRepo_GetProxyResourceByName(t *testing.T) {
+func testGetProxyResourceByName(t *testing.T) {
 	now := time.Now().UTC()
 	testcases := map[string]struct {
 		// Previous data
-		previousResource *ProxyResource
+		previousResource *postgresql.ProxyResource
 		// Postgres Repo Args
 		org  string
 		name string
@@ -203,7 +275,7 @@ func TestPostgresRepo_GetProxyResourceByName(t *testing.T) {
 		expectedError    *database.Error
 	}{
 		"OkCase": {
-			previousResource: &ProxyResource{
+			previousResource: &postgresql.ProxyResource{
 				ID:           "ID",
 				Name:         "name",
 				Path:         "path",
@@ -237,7 +309,7 @@ func TestPostgresRepo_GetProxyResourceByName(t *testing.T) {
 			},
 		},
 		"ErrorCaseProxyResourceNotExist": {
-			previousResource: &ProxyResource{
+			previousResource: &postgresql.ProxyResource{
 				ID:           "ID",
 				Name:         "name",
 				Path:         "path",
@@ -283,11 +355,11 @@ func TestPostgresRepo_GetProxyResourceByName(t *testing.T) {
 	}
 }
 
-func TestPostgresRepo_AddProxyResource(t *testing.T) {
+func testAddProxyResource(t *testing.T) {
 	now := time.Now().UTC()
 	testcases := map[string]struct {
 		// Previous data
-		previousResource *ProxyResource
+		previousResource *postgresql.ProxyResource
 		// Postgres Repo Args
 		proxyResource *api.ProxyResource
 		// Expected result
@@ -329,7 +401,7 @@ func TestPostgresRepo_AddProxyResource(t *testing.T) {
 			},
 		},
 		"ErrorCaseUserAlreadyExist": {
-			previousResource: &ProxyResource{
+			previousResource: &postgresql.ProxyResource{
 				ID:           "ID",
 				Name:         "name",
 				Path:         "path",
@@ -361,7 +433,7 @@ func TestPostgresRepo_AddProxyResource(t *testing.T) {
 			},
 			expectedError: &database.Error{
 				Code:    database.INTERNAL_ERROR,
-				Message: "pq: duplicate key value violates unique constraint \"proxy_resources_pkey\"",
+				Message: driver.UniqueViolation("proxy_resources_pkey"),
 			},
 		},
 	}
@@ -392,11 +464,11 @@ func TestPostgresRepo_AddProxyResource(t *testing.T) {
 	}
 }
 
-func TestPostgresRepo_UpdateProxyResource(t *testing.T) {
+func testUpdateProxyResource(t *testing.T) {
 	now := time.Now().UTC()
 	testcases := map[string]struct {
 		// Previous data
-		previousProxyResources []ProxyResource
+		previousProxyResources []postgresql.ProxyResource
 		// Postgres Repo Args
 		proxyResourceToUpdate *api.ProxyResource
 		// Expected result
@@ -404,7 +476,7 @@ func TestPostgresRepo_UpdateProxyResource(t *testing.T) {
 		expectedError    *database.Error
 	}{
 		"OKCase": {
-			previousProxyResources: []ProxyResource{
+			previousProxyResources: []postgresql.ProxyResource{
 				{
 					ID:           "ID",
 					Name:         "name",
@@ -467,7 +539,7 @@ func TestPostgresRepo_UpdateProxyResource(t *testing.T) {
 		}
 
 		// Call to repository to update proxy resource
-		updateProxyResource, err := repoDB.UpdateProxyResource(*test.proxyResourceToUpdate)
+		updateProxyResource, err := repoDB.UpdateProxyResource(*test.proxyResourceToUpdate, now)
 		if test.expectedError != nil {
 			dbError, _ := err.(*database.Error)
 			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
@@ -483,16 +555,16 @@ func TestPostgresRepo_UpdateProxyResource(t *testing.T) {
 	}
 }
 
-func TestPostgresRepo_RemoveProxyResource(t *testing.T) {
+func testRemoveProxyResource(t *testing.T) {
 	now := time.Now().UTC()
 	testcases := map[string]struct {
 		// Previous data
-		previousProxyResources []ProxyResource
+		previousProxyResources []postgresql.ProxyResource
 		// Postgres Repo Args
 		proxyResourceToDelete string
 	}{
 		"OKCase": {
-			previousProxyResources: []ProxyResource{
+			previousProxyResources: []postgresql.ProxyResource{
 				{
 					ID:           "PrID1",
 					Name:         "Name1",
@@ -550,3 +622,15 @@ func TestPostgresRepo_RemoveProxyResource(t *testing.T) {
 		assert.Equal(t, 1, totalPrNumber, "Error in test case %v", n)
 	}
 }
+
+func testProxyRevision(t *testing.T) {
+	revision, err := repoDB.GetProxyRevision()
+	assert.Nil(t, err, "Error in test")
+
+	// Revision is increased by one
+	err = repoDB.IncrementProxyRevision()
+	assert.Nil(t, err, "Error in test")
+	newRevision, err := repoDB.GetProxyRevision()
+	assert.Nil(t, err, "Error in test")
+	assert.Equal(t, revision+1, newRevision, "Error in test")
+}
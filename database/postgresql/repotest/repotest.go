@@ -0,0 +1,176 @@
+// Package repotest contains the repository tests shared by the database backends built on the
+// PostgreSQL repository, so every backend runs the same cases with its own driver error messages.
+package repotest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database/postgresql"
+	"github.com/stretchr/testify/assert"
+)
+
+// Driver describes the error messages that a database driver returns when a statement fails
+type Driver struct {
+	// Message of an insert that duplicates the values of the given unique constraint,
+	// named as in the PostgreSQL schema (e.g. users_pkey)
+	UniqueViolation func(constraint string) string
+	// Message of an insert without a value in a not null column of a table
+	NotNullViolation func(table string, column string) string
+	// Message of a query that references a column that doesn't exist
+	UndefinedColumn func(column string) string
+}
+
+var repoDB postgresql.PostgresRepo
+var driver Driver
+var testFilter = &api.Filter{
+	PathPrefix: "",
+	Offset:     0,
+	Limit:      0,
+}
+
+var suite = []struct {
+	name string
+	test func(t *testing.T)
+}{
+	{"WithTx", testWithTx},
+	{"AddAuditEntry", testAddAuditEntry},
+	{"GetAuditEntriesFiltered", testGetAuditEntriesFiltered},
+	{"AddOidcProvider", testAddOidcProvider},
+	{"GetOidcProviderByName", testGetOidcProviderByName},
+	{"GetOidcProvidersFiltered", testGetOidcProvidersFiltered},
+	{"UpdateOidcProvider", testUpdateOidcProvider},
+	{"RemoveOidcProvider", testRemoveOidcProvider},
+	{"AddGroup", testAddGroup},
+	{"GetGroupByName", testGetGroupByName},
+	{"GetGroupById", testGetGroupById},
+	{"GetGroupsFiltered", testGetGroupsFiltered},
+	{"UpdateGroup", testUpdateGroup},
+	{"RemoveGroup", testRemoveGroup},
+	{"AddMember", testAddMember},
+	{"RemoveMember", testRemoveMember},
+	{"IsMemberOfGroup", testIsMemberOfGroup},
+	{"GetGroupMembers", testGetGroupMembers},
+	{"AttachPolicy", testAttachPolicy},
+	{"DetachPolicy", testDetachPolicy},
+	{"IsAttachedToGroup", testIsAttachedToGroup},
+	{"GetAttachedPolicies", testGetAttachedPolicies},
+	{"AddChildGroup", testAddChildGroup},
+	{"RemoveChildGroup", testRemoveChildGroup},
+	{"IsChildOfGroup", testIsChildOfGroup},
+	{"GetChildGroups", testGetChildGroups},
+	{"GetAncestorGroups", testGetAncestorGroups},
+	{"RemoveExpiredRelations", testRemoveExpiredRelations},
+	{"AddPolicy", testAddPolicy},
+	{"GetPolicyByName", testGetPolicyByName},
+	{"GetPolicyById", testGetPolicyById},
+	{"GetPoliciesFiltered", testGetPoliciesFiltered},
+	{"UpdatePolicy", testUpdatePolicy},
+	{"RemovePolicy", testRemovePolicy},
+	{"GetAttachedGroups", testGetAttachedGroups},
+	{"GetProxyResources", testGetProxyResources},
+	{"GetProxyResourceByName", testGetProxyResourceByName},
+	{"AddProxyResource", testAddProxyResource},
+	{"UpdateProxyResource", testUpdateProxyResource},
+	{"RemoveProxyResource", testRemoveProxyResource},
+	{"ProxyRevision", testProxyRevision},
+	{"AddUpstreamPool", testAddUpstreamPool},
+	{"GetUpstreamPoolByName", testGetUpstreamPoolByName},
+	{"GetUpstreamPools", testGetUpstreamPools},
+	{"UpdateUpstreamPool", testUpdateUpstreamPool},
+	{"RemoveUpstreamPool", testRemoveUpstreamPool},
+	{"AddUser", testAddUser},
+	{"GetUserByExternalID", testGetUserByExternalID},
+	{"GetUserByID", testGetUserByID},
+	{"GetUsersFiltered", testGetUsersFiltered},
+	{"UpdateUser", testUpdateUser},
+	{"RemoveUser", testRemoveUser},
+	{"GetGroupsByUserID", testGetGroupsByUserID},
+	{"AttachUserPolicy", testAttachUserPolicy},
+	{"DetachUserPolicy", testDetachUserPolicy},
+	{"IsAttachedToUser", testIsAttachedToUser},
+	{"GetAttachedUserPolicies", testGetAttachedUserPolicies},
+	{"AddWebhook", testAddWebhook},
+	{"GetWebhookByName", testGetWebhookByName},
+	{"GetWebhooksFiltered", testGetWebhooksFiltered},
+	{"UpdateWebhook", testUpdateWebhook},
+	{"RemoveWebhook", testRemoveWebhook},
+	{"AddWebhookDeadLetter", testAddWebhookDeadLetter},
+	{"GetWebhookDeadLettersFiltered", testGetWebhookDeadLettersFiltered},
+}
+
+// Run executes the repository test suite against a migrated database. The suite cleans the tables it uses,
+// so the database mustn't be shared with other data.
+func Run(t *testing.T, repo postgresql.PostgresRepo, d Driver) {
+	repoDB = repo
+	driver = d
+	for _, s := range suite {
+		t.Run(s.name, s.test)
+	}
+}
+
+func testWithTx(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Error returned after removing the user
+		txErr error
+		// Expected result
+		expectedError        error
+		expectedUsers        int
+		expectedRelations    int
+		expectedAuditEntries int
+	}{
+		"OkCaseCommit": {
+			expectedUsers:        0,
+			expectedRelations:    0,
+			expectedAuditEntries: 1,
+		},
+		"ErrorCaseRollback": {
+			txErr:                errors.New("Failure"),
+			expectedError:        errors.New("Failure"),
+			expectedUsers:        1,
+			expectedRelations:    1,
+			expectedAuditEntries: 0,
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean database
+		cleanUserTable(t, n)
+		cleanGroupUserRelationTable(t, n)
+		cleanAuditEntriesTable(t, n)
+
+		// Insert previous data
+		insertUser(t, n, postgresql.User{
+			ID:         "UserID",
+			ExternalID: "ExternalID",
+			Path:       "Path",
+			CreateAt:   now.UnixNano(),
+			UpdateAt:   now.UnixNano(),
+			Urn:        "urn",
+		})
+		insertGroupUserRelation(t, n, "UserID", "GroupID", now.UnixNano())
+
+		err := repoDB.WithTx(func(repos api.TxRepos) error {
+			// Remove user and its relations in the same transaction, audit it, then fail
+			if err := repos.UserRepo.RemoveUser("UserID"); err != nil {
+				return err
+			}
+			if err := repos.AuditRepo.AddAuditEntry(api.AuditEntry{ID: "EntryID", Urn: "urn", CreateAt: now}); err != nil {
+				return err
+			}
+			return test.txErr
+		})
+		assert.Equal(t, test.expectedError, err, "Error in test case %v", n)
+
+		// Check database
+		users := getUsersCountFiltered(t, n, "UserID", "", "", 0, 0, "", "")
+		assert.Equal(t, test.expectedUsers, users, "Error in test case %v", n)
+		relations := getGroupUserRelations(t, n, "", "UserID")
+		assert.Equal(t, test.expectedRelations, relations, "Error in test case %v", n)
+		entries := getAuditEntriesCountFiltered(t, n, "", "", "", "")
+		assert.Equal(t, test.expectedAuditEntries, entries, "Error in test case %v", n)
+	}
+}
@@ -0,0 +1,349 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/Tecsisa/foulkon/database/postgresql"
+	"github.com/stretchr/testify/assert"
+)
+
+func testAddUpstreamPool(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousPool *postgresql.UpstreamPool
+		// Postgres Repo Args
+		poolToCreate *api.UpstreamPool
+		// Expected result
+		expectedResponse *api.UpstreamPool
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			poolToCreate: &api.UpstreamPool{
+				ID:       "PoolID",
+				Name:     "Name",
+				Org:      "Org",
+				Path:     "Path",
+				Urn:      "urn",
+				CreateAt: now,
+				UpdateAt: now,
+				Config: api.UpstreamPoolConfig{
+					Targets:     []string{"http://10.0.0.1:8080", "http://10.0.0.2:8080"},
+					Balancer:    "least-connections",
+					HealthCheck: &api.UpstreamHealthCheck{Path: "/health", Interval: 10, Timeout: 2, Threshold: 2},
+					Ejection:    api.UpstreamEjection{MaxFailures: 5, Duration: 30},
+					Transport:   api.UpstreamTransport{MaxIdleConns: 100, IdleConnTimeout: 90},
+				},
+			},
+			expectedResponse: &api.UpstreamPool{
+				ID:       "PoolID",
+				Name:     "Name",
+				Org:      "Org",
+				Path:     "Path",
+				Urn:      "urn",
+				CreateAt: now,
+				UpdateAt: now,
+				Config: api.UpstreamPoolConfig{
+					Targets:     []string{"http://10.0.0.1:8080", "http://10.0.0.2:8080"},
+					Balancer:    "least-connections",
+					HealthCheck: &api.UpstreamHealthCheck{Path: "/health", Interval: 10, Timeout: 2, Threshold: 2},
+					Ejection:    api.UpstreamEjection{MaxFailures: 5, Duration: 30},
+					Transport:   api.UpstreamTransport{MaxIdleConns: 100, IdleConnTimeout: 90},
+				},
+			},
+		},
+		"ErrorCaseUpstreamPoolAlreadyExist": {
+			previousPool: &postgresql.UpstreamPool{
+				ID:   "OtherID",
+				Name: "Name",
+				Org:  "Org",
+				Urn:  "otherUrn",
+			},
+			poolToCreate: &api.UpstreamPool{
+				ID:   "PoolID",
+				Name: "Name",
+				Org:  "Org",
+				Urn:  "urn",
+			},
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: driver.UniqueViolation("idx_upstream_pool"),
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean upstream pools database
+		cleanUpstreamPoolsTable(t, n)
+
+		// Insert previous data
+		if test.previousPool != nil {
+			insertUpstreamPool(t, n, *test.previousPool)
+		}
+		// Call to repository to store an upstream pool
+		storedPool, err := repoDB.AddUpstreamPool(*test.poolToCreate)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, storedPool, "Error in test case %v", n)
+			// Check database
+			pool, err := repoDB.GetUpstreamPoolByName(test.poolToCreate.Org, test.poolToCreate.Name)
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, pool, "Error in test case %v", n)
+		}
+	}
+}
+
+func testGetUpstreamPoolByName(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousPool *postgresql.UpstreamPool
+		// Postgres Repo Args
+		org  string
+		name string
+		// Expected result
+		expectedResponse *api.UpstreamPool
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			previousPool: &postgresql.UpstreamPool{
+				ID:        "PoolID",
+				Name:      "Name",
+				Org:       "Org",
+				Path:      "Path",
+				Urn:       "urn",
+				Targets:   `["http://10.0.0.1:8080"]`,
+				Balancer:  "round-robin",
+				Ejection:  `{"maxFailures":5,"duration":30}`,
+				Transport: `{"maxIdleConns":100,"idleConnTimeout":90}`,
+				CreateAt:  now.UnixNano(),
+				UpdateAt:  now.UnixNano(),
+			},
+			org:  "Org",
+			name: "Name",
+			expectedResponse: &api.UpstreamPool{
+				ID:   "PoolID",
+				Name: "Name",
+				Org:  "Org",
+				Path: "Path",
+				Urn:  "urn",
+				Config: api.UpstreamPoolConfig{
+					Targets:   []string{"http://10.0.0.1:8080"},
+					Balancer:  "round-robin",
+					Ejection:  api.UpstreamEjection{MaxFailures: 5, Duration: 30},
+					Transport: api.UpstreamTransport{MaxIdleConns: 100, IdleConnTimeout: 90},
+				},
+				CreateAt: now,
+				UpdateAt: now,
+			},
+		},
+		"ErrorCaseUpstreamPoolNotFound": {
+			org:  "Org",
+			name: "Name",
+			expectedError: &database.Error{
+				Code:    database.UPSTREAM_POOL_NOT_FOUND,
+				Message: "Upstream pool with organization Org and name Name not found",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean upstream pools database
+		cleanUpstreamPoolsTable(t, n)
+
+		// Insert previous data
+		if test.previousPool != nil {
+			insertUpstreamPool(t, n, *test.previousPool)
+		}
+		// Call to repository to get an upstream pool
+		pool, err := repoDB.GetUpstreamPoolByName(test.org, test.name)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, pool, "Error in test case %v", n)
+		}
+	}
+}
+
+func testGetUpstreamPools(t *testing.T) {
+	previousPools := []postgresql.UpstreamPool{
+		{ID: "PoolID1", Name: "b", Org: "org1", Path: "/path/", Urn: "urn1", Targets: "[]", Ejection: "{}", Transport: "{}"},
+		{ID: "PoolID2", Name: "a", Org: "org1", Path: "/other/", Urn: "urn2", Targets: "[]", Ejection: "{}", Transport: "{}"},
+		{ID: "PoolID3", Name: "c", Org: "org2", Path: "/path/", Urn: "urn3", Targets: "[]", Ejection: "{}", Transport: "{}"},
+	}
+	testcases := map[string]struct {
+		// Postgres Repo Args
+		filter *api.Filter
+		// Expected result
+		expectedNames []string
+		expectedTotal int
+	}{
+		"OkCaseOrg": {
+			filter:        &api.Filter{Org: "org1", OrderBy: "name asc"},
+			expectedNames: []string{"a", "b"},
+			expectedTotal: 2,
+		},
+		"OkCasePathPrefix": {
+			filter:        &api.Filter{PathPrefix: "/path/", OrderBy: "name asc"},
+			expectedNames: []string{"b", "c"},
+			expectedTotal: 2,
+		},
+		"OkCaseLimit": {
+			filter:        &api.Filter{OrderBy: "name desc", Limit: 1},
+			expectedNames: []string{"c"},
+			expectedTotal: 3,
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean upstream pools database
+		cleanUpstreamPoolsTable(t, n)
+
+		// Insert previous data
+		for _, pool := range previousPools {
+			insertUpstreamPool(t, n, pool)
+		}
+		// Call to repository to get upstream pools
+		pools, total, err := repoDB.GetUpstreamPools(test.filter)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedTotal, total, "Error in test case %v", n)
+		names := []string{}
+		for _, pool := range pools {
+			names = append(names, pool.Name)
+		}
+		assert.Equal(t, test.expectedNames, names, "Error in test case %v", n)
+	}
+}
+
+func testUpdateUpstreamPool(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousPool *postgresql.UpstreamPool
+		// Postgres Repo Args
+		poolToUpdate *api.UpstreamPool
+		oldUpdateAt  time.Time
+		// Expected result
+		expectedResponse *api.UpstreamPool
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			previousPool: &postgresql.UpstreamPool{
+				ID:          "PoolID",
+				Name:        "Name",
+				Org:         "Org",
+				Path:        "Path",
+				Urn:         "urn",
+				Targets:     `["http://10.0.0.1:8080"]`,
+				Balancer:    "round-robin",
+				HealthCheck: `{"path":"/health","interval":10,"timeout":2,"threshold":2}`,
+				Ejection:    `{"maxFailures":5,"duration":30}`,
+				Transport:   `{"maxIdleConns":100,"idleConnTimeout":90}`,
+				CreateAt:    now.UnixNano(),
+				UpdateAt:    now.UnixNano(),
+			},
+			poolToUpdate: &api.UpstreamPool{
+				ID:   "PoolID",
+				Name: "NewName",
+				Org:  "Org",
+				Path: "NewPath",
+				Urn:  "newUrn",
+				Config: api.UpstreamPoolConfig{
+					Targets:   []string{"http://10.0.0.2:8080"},
+					Balancer:  "least-connections",
+					Ejection:  api.UpstreamEjection{MaxFailures: 3, Duration: 60},
+					Transport: api.UpstreamTransport{MaxIdleConns: 10, IdleConnTimeout: 30, ResponseTimeout: 5},
+				},
+				CreateAt: now,
+				UpdateAt: now.Add(time.Second),
+			},
+			oldUpdateAt: now,
+			expectedResponse: &api.UpstreamPool{
+				ID:   "PoolID",
+				Name: "NewName",
+				Org:  "Org",
+				Path: "NewPath",
+				Urn:  "newUrn",
+				Config: api.UpstreamPoolConfig{
+					Targets:   []string{"http://10.0.0.2:8080"},
+					Balancer:  "least-connections",
+					Ejection:  api.UpstreamEjection{MaxFailures: 3, Duration: 60},
+					Transport: api.UpstreamTransport{MaxIdleConns: 10, IdleConnTimeout: 30, ResponseTimeout: 5},
+				},
+				CreateAt: now,
+				UpdateAt: now.Add(time.Second),
+			},
+		},
+		"ErrorCaseVersionConflict": {
+			previousPool: &postgresql.UpstreamPool{
+				ID:        "PoolID",
+				Name:      "Name",
+				Org:       "Org",
+				Urn:       "urn",
+				Targets:   "[]",
+				Ejection:  "{}",
+				Transport: "{}",
+				UpdateAt:  now.UnixNano(),
+			},
+			poolToUpdate: &api.UpstreamPool{
+				ID:   "PoolID",
+				Name: "NewName",
+				Org:  "Org",
+				Urn:  "urn",
+			},
+			oldUpdateAt: now.Add(-time.Second),
+			expectedError: &database.Error{
+				Code:    database.VERSION_CONFLICT,
+				Message: "Upstream pool with id PoolID was modified or removed by another request",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean upstream pools database
+		cleanUpstreamPoolsTable(t, n)
+
+		// Insert previous data
+		if test.previousPool != nil {
+			insertUpstreamPool(t, n, *test.previousPool)
+		}
+		// Call to repository to update an upstream pool
+		updatedPool, err := repoDB.UpdateUpstreamPool(*test.poolToUpdate, test.oldUpdateAt)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, updatedPool, "Error in test case %v", n)
+			// Check database
+			pool, err := repoDB.GetUpstreamPoolByName(test.poolToUpdate.Org, test.poolToUpdate.Name)
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, pool, "Error in test case %v", n)
+		}
+	}
+}
+
+func testRemoveUpstreamPool(t *testing.T) {
+	// Clean upstream pools database
+	cleanUpstreamPoolsTable(t, "RemoveUpstreamPool")
+
+	// Insert previous data
+	insertUpstreamPool(t, "RemoveUpstreamPool", postgresql.UpstreamPool{ID: "PoolID1", Name: "Name1", Org: "Org", Urn: "urn1"})
+	insertUpstreamPool(t, "RemoveUpstreamPool", postgresql.UpstreamPool{ID: "PoolID2", Name: "Name2", Org: "Org", Urn: "urn2"})
+
+	// Call to repository to remove an upstream pool
+	err := repoDB.RemoveUpstreamPool("PoolID1")
+	assert.Nil(t, err, "Error removing upstream pool")
+
+	// Check database
+	assert.Equal(t, 0, getUpstreamPoolsCountFiltered(t, "RemoveUpstreamPool", "PoolID1"), "Error removing upstream pool")
+	assert.Equal(t, 1, getUpstreamPoolsCountFiltered(t, "RemoveUpstreamPool", "PoolID2"), "Error removing upstream pool")
+}
@@ -1,4 +1,4 @@
-package postgresql
+package repotest
 
 import (
 	"testing"
@@ -6,14 +6,15 @@ import (
 
 	"github.com/Tecsisa/foulkon/api"
 	"github.com/Tecsisa/foulkon/database"
+	"github.com/Tecsisa/foulkon/database/postgresql"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestPostgresRepo_AddUser(t *testing.T) {
+func testAddUser(t *testing.T) {
 	now := time.Now().UTC()
 	testcases := map[string]struct {
 		// Previous data
-		previousUser *User
+		previousUser *postgresql.User
 		// Postgres Repo Args
 		userToCreate *api.User
 		// Expected result
@@ -39,7 +40,7 @@ func TestPostgresRepo_AddUser(t *testing.T) {
 			},
 		},
 		"ErrorCaseUserAlreadyExist": {
-			previousUser: &User{
+			previousUser: &postgresql.User{
 				ID:         "UserID",
 				ExternalID: "ExternalID",
 				Path:       "Path",
@@ -57,7 +58,7 @@ func TestPostgresRepo_AddUser(t *testing.T) {
 			},
 			expectedError: &database.Error{
 				Code:    database.INTERNAL_ERROR,
-				Message: "pq: duplicate key value violates unique constraint \"users_pkey\"",
+				Message: driver.UniqueViolation("users_pkey"),
 			},
 		},
 	}
@@ -88,11 +89,11 @@ func TestPostgresRepo_AddUser(t *testing.T) {
 	}
 }
 
-func TestPostgresRepo_GetUserByExternalID(t *testing.T) {
+func testGetUserByExternalID(t *testing.T) {
 	now := time.Now().UTC()
 	testcases := map[string]struct {
 		// Previous data
-		previousUser *User
+		previousUser *postgresql.User
 		// Postgres Repo Args
 		externalID string
 		// Expected result
@@ -100,7 +101,7 @@ func TestPostgresRepo_GetUserByExternalID(t *testing.T) {
 		expectedError    *database.Error
 	}{
 		"OkCase": {
-			previousUser: &User{
+			previousUser: &postgresql.User{
 				ID:         "UserID",
 				ExternalID: "ExternalID",
 				Path:       "Path",
@@ -119,7 +120,7 @@ func TestPostgresRepo_GetUserByExternalID(t *testing.T) {
 			},
 		},
 		"ErrorCaseUserNotExist": {
-			previousUser: &User{
+			previousUser: &postgresql.User{
 				ID:         "UserID",
 				ExternalID: "ExternalID",
 				Path:       "Path",
@@ -158,11 +159,11 @@ func TestPostgresRepo_GetUserByExternalID(t *testing.T) {
 	}
 }
 
-func TestPostgresRepo_GetUserByID(t *testing.T) {
+func testGetUserByID(t *testing.T) {
 	now := time.Now().UTC()
 	testcases := map[string]struct {
 		// Previous data
-		previousUser *User
+		previousUser *postgresql.User
 		// Postgres Repo Args
 		userID string
 		// Expected result
@@ -170,7 +171,7 @@ func TestPostgresRepo_GetUserByID(t *testing.T) {
 		expectedError    *database.Error
 	}{
 		"OkCase": {
-			previousUser: &User{
+			previousUser: &postgresql.User{
 				ID:         "UserID",
 				ExternalID: "ExternalID",
 				Path:       "Path",
@@ -189,7 +190,7 @@ func TestPostgresRepo_GetUserByID(t *testing.T) {
 			},
 		},
 		"ErrorCaseUserNotExist": {
-			previousUser: &User{
+			previousUser: &postgresql.User{
 				ID:         "UserID",
 				ExternalID: "ExternalID",
 				Path:       "Path",
@@ -227,18 +228,18 @@ func TestPostgresRepo_GetUserByID(t *testing.T) {
 	}
 }
 
-func TestPostgresRepo_GetUsersFiltered(t *testing.T) {
+func testGetUsersFiltered(t *testing.T) {
 	now := time.Now().UTC()
 	testcases := map[string]struct {
 		// Previous data
-		previousUsers []User
+		previousUsers []postgresql.User
 		// Postgres Repo Args
 		filter *api.Filter
 		// Expected result
 		expectedResponse []api.User
 	}{
 		"OkCase1": {
-			previousUsers: []User{
+			previousUsers: []postgresql.User{
 				{
 					ID:         "UserID1",
 					ExternalID: "ExternalID1",
@@ -282,7 +283,7 @@ func TestPostgresRepo_GetUsersFiltered(t *testing.T) {
 			},
 		},
 		"OkCase2": {
-			previousUsers: []User{
+			previousUsers: []postgresql.User{
 				{
 					ID:         "UserID1",
 					ExternalID: "ExternalID1",
@@ -317,7 +318,7 @@ func TestPostgresRepo_GetUsersFiltered(t *testing.T) {
 			},
 		},
 		"OkCase3": {
-			previousUsers: []User{
+			previousUsers: []postgresql.User{
 				{
 					ID:         "UserID1",
 					ExternalID: "ExternalID1",
@@ -366,18 +367,19 @@ func TestPostgresRepo_GetUsersFiltered(t *testing.T) {
 	}
 }
 
-func TestPostgresRepo_UpdateUser(t *testing.T) {
+func testUpdateUser(t *testing.T) {
 	now := time.Now().UTC()
 	testcases := map[string]struct {
 		// Previous data
-		previousUser *User
+		previousUser *postgresql.User
 		// Postgres Repo Args
 		userToUpdate *api.User
 		// Expected result
 		expectedResponse *api.User
+		expectedError    *database.Error
 	}{
 		"OkCase": {
-			previousUser: &User{
+			previousUser: &postgresql.User{
 				ID:         "UserID",
 				ExternalID: "ExternalID",
 				Path:       "OldPath",
@@ -402,6 +404,28 @@ func TestPostgresRepo_UpdateUser(t *testing.T) {
 				UpdateAt:   now,
 			},
 		},
+		"ErrorCaseVersionConflict": {
+			previousUser: &postgresql.User{
+				ID:         "UserID",
+				ExternalID: "ExternalID",
+				Path:       "OldPath",
+				Urn:        "Oldurn",
+				CreateAt:   now.UnixNano(),
+				UpdateAt:   now.Add(time.Second).UnixNano(),
+			},
+			userToUpdate: &api.User{
+				ID:         "UserID",
+				ExternalID: "ExternalID",
+				Path:       "NewPath",
+				Urn:        "NewUrn",
+				CreateAt:   now,
+				UpdateAt:   now,
+			},
+			expectedError: &database.Error{
+				Code:    database.VERSION_CONFLICT,
+				Message: "User with id UserID was modified or removed by another request",
+			},
+		},
 	}
 
 	for n, test := range testcases {
@@ -413,35 +437,41 @@ func TestPostgresRepo_UpdateUser(t *testing.T) {
 			insertUser(t, n, *test.previousUser)
 		}
 		// Call to repository to update an user
-		updatedUser, err := repoDB.UpdateUser(*test.userToUpdate)
-		assert.Nil(t, err, "Error in test case %v", n)
-
-		// Check response
-		assert.Equal(t, test.expectedResponse, updatedUser, "Error in test case %v", n)
-		// Check database
-		userNumber := getUsersCountFiltered(t, n, test.expectedResponse.ID, test.expectedResponse.ExternalID, test.expectedResponse.Path,
-			test.expectedResponse.CreateAt.UnixNano(), test.expectedResponse.UpdateAt.UnixNano(), test.expectedResponse.Urn, "")
-		assert.Equal(t, 1, userNumber, "Error in test case %v", n)
+		updatedUser, err := repoDB.UpdateUser(*test.userToUpdate, now)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check response
+			assert.Equal(t, test.expectedResponse, updatedUser, "Error in test case %v", n)
+			// Check database
+			userNumber := getUsersCountFiltered(t, n, test.expectedResponse.ID, test.expectedResponse.ExternalID, test.expectedResponse.Path,
+				test.expectedResponse.CreateAt.UnixNano(), test.expectedResponse.UpdateAt.UnixNano(), test.expectedResponse.Urn, "")
+			assert.Equal(t, 1, userNumber, "Error in test case %v", n)
+		}
 	}
 }
 
-func TestPostgresRepo_RemoveUser(t *testing.T) {
+func testRemoveUser(t *testing.T) {
 	type relation struct {
 		userID        string
 		groupID       string
+		policyID      string
 		createAt      int64
 		groupNotFound bool
 	}
 	now := time.Now().UTC()
 	testcases := map[string]struct {
 		// Previous data
-		previousUsers []User
-		relations     []relation
+		previousUsers   []postgresql.User
+		relations       []relation
+		policyRelations []relation
 		// Postgres Repo Args
 		userToDelete string
 	}{
 		"OkCase": {
-			previousUsers: []User{
+			previousUsers: []postgresql.User{
 				{
 					ID:         "UserID",
 					ExternalID: "ExternalID",
@@ -471,6 +501,18 @@ func TestPostgresRepo_RemoveUser(t *testing.T) {
 					createAt: now.UnixNano(),
 				},
 			},
+			policyRelations: []relation{
+				{
+					userID:   "UserID",
+					policyID: "PolicyID",
+					createAt: now.UnixNano(),
+				},
+				{
+					userID:   "UserID2",
+					policyID: "PolicyID",
+					createAt: now.UnixNano(),
+				},
+			},
 			userToDelete: "UserID",
 		},
 	}
@@ -479,6 +521,7 @@ func TestPostgresRepo_RemoveUser(t *testing.T) {
 		// Clean user database
 		cleanUserTable(t, n)
 		cleanGroupUserRelationTable(t, n)
+		cleanUserPolicyRelationTable(t, n)
 
 		// Insert previous data
 		if test.previousUsers != nil {
@@ -491,6 +534,11 @@ func TestPostgresRepo_RemoveUser(t *testing.T) {
 				insertGroupUserRelation(t, n, rel.userID, rel.groupID, rel.createAt)
 			}
 		}
+		if test.policyRelations != nil {
+			for _, rel := range test.policyRelations {
+				insertUserPolicyRelation(t, n, rel.userID, rel.policyID, rel.createAt)
+			}
+		}
 		// Call to repository to remove user
 		err := repoDB.RemoveUser(test.userToDelete)
 		assert.Nil(t, err, "Error in test case %v", n)
@@ -510,13 +558,21 @@ func TestPostgresRepo_RemoveUser(t *testing.T) {
 		// Check total user relations
 		totalRelations := getGroupUserRelations(t, n, "", "")
 		assert.Equal(t, 1, totalRelations, "Error in test case %v", n)
+
+		// Check user deleted policy relations
+		policyRelations := getUserPolicyRelationCount(t, n, test.userToDelete, "")
+		assert.Equal(t, 0, policyRelations, "Error in test case %v", n)
+
+		// Check total user policy relations
+		totalPolicyRelations := getUserPolicyRelationCount(t, n, "", "")
+		assert.Equal(t, 1, totalPolicyRelations, "Error in test case %v", n)
 	}
 }
 
-func TestPostgresRepo_GetGroupsByUserID(t *testing.T) {
+func testGetGroupsByUserID(t *testing.T) {
 	type relation struct {
 		userID        string
-		groups        []Group
+		groups        []postgresql.Group
 		createAt      []int64
 		groupNotFound bool
 	}
@@ -528,13 +584,13 @@ func TestPostgresRepo_GetGroupsByUserID(t *testing.T) {
 		userID string
 		filter *api.Filter
 		// Expected result
-		expectedResponse []*GroupUser
+		expectedResponse []*postgresql.GroupUser
 		expectedError    *database.Error
 	}{
 		"OkCase": {
 			relation: &relation{
 				userID: "UserID",
-				groups: []Group{
+				groups: []postgresql.Group{
 					{
 						ID:       "GroupID1",
 						Name:     "Name1",
@@ -558,7 +614,7 @@ func TestPostgresRepo_GetGroupsByUserID(t *testing.T) {
 			},
 			userID: "UserID",
 			filter: testFilter,
-			expectedResponse: []*GroupUser{
+			expectedResponse: []*postgresql.GroupUser{
 				{
 					Group: &api.Group{
 						ID:       "GroupID1",
@@ -588,7 +644,7 @@ func TestPostgresRepo_GetGroupsByUserID(t *testing.T) {
 		"OkCaseOrderBy": {
 			relation: &relation{
 				userID: "UserID",
-				groups: []Group{
+				groups: []postgresql.Group{
 					{
 						ID:       "GroupID1",
 						Name:     "Name1",
@@ -614,7 +670,7 @@ func TestPostgresRepo_GetGroupsByUserID(t *testing.T) {
 			filter: &api.Filter{
 				OrderBy: "create_at desc",
 			},
-			expectedResponse: []*GroupUser{
+			expectedResponse: []*postgresql.GroupUser{
 				{
 					Group: &api.Group{
 						ID:       "GroupID2",
@@ -644,7 +700,7 @@ func TestPostgresRepo_GetGroupsByUserID(t *testing.T) {
 		"ErrorCase": {
 			relation: &relation{
 				userID: "UserID",
-				groups: []Group{
+				groups: []postgresql.Group{
 					{
 						ID: "GroupID1",
 					},
@@ -699,3 +755,275 @@ func TestPostgresRepo_GetGroupsByUserID(t *testing.T) {
 		}
 	}
 }
+
+func testAttachUserPolicy(t *testing.T) {
+	testcases := map[string]struct {
+		// Postgres Repo Args
+		userID   string
+		policyID string
+		// Expected result
+		expectedError *database.Error
+	}{
+		"OkCase": {
+			userID:   "UserID",
+			policyID: "PolicyID",
+		},
+		"ErrorCaseInternalError": {
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: driver.NotNullViolation("user_policy_relations", "user_id"),
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean UserPolicyRelation database
+		cleanUserPolicyRelationTable(t, n)
+
+		// Call to repository to attach policy
+		err := repoDB.AttachUserPolicy(test.userID, test.policyID)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check database
+			relations := getUserPolicyRelationCount(t, n, test.userID, test.policyID)
+			assert.Equal(t, 1, relations, "Error in test case %v", n)
+		}
+	}
+}
+
+func testDetachUserPolicy(t *testing.T) {
+	type relation struct {
+		userID   string
+		policyID string
+		createAt int64
+	}
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		relation *relation
+		// Postgres Repo Args
+		userID   string
+		policyID string
+	}{
+		"OkCase": {
+			relation: &relation{
+				userID:   "UserID",
+				policyID: "PolicyID",
+				createAt: now.UnixNano(),
+			},
+			userID:   "UserID",
+			policyID: "PolicyID",
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean UserPolicyRelation database
+		cleanUserPolicyRelationTable(t, n)
+
+		// Insert previous data
+		if test.relation != nil {
+			insertUserPolicyRelation(t, n, test.relation.userID, test.relation.policyID, test.relation.createAt)
+		}
+
+		// Call to repository to detach policy
+		err := repoDB.DetachUserPolicy(test.userID, test.policyID)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// Check database
+		relations := getUserPolicyRelationCount(t, n, test.userID, test.policyID)
+		assert.Equal(t, 0, relations, "Error in test case %v", n)
+	}
+}
+
+func testIsAttachedToUser(t *testing.T) {
+	type relation struct {
+		userID   string
+		policyID string
+		createAt int64
+	}
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		relation *relation
+		// Postgres Repo Args
+		userID   string
+		policyID string
+		// Expected result
+		expectedResult bool
+	}{
+		"OkCase": {
+			relation: &relation{
+				userID:   "UserID",
+				policyID: "PolicyID",
+				createAt: now.UnixNano(),
+			},
+			userID:         "UserID",
+			policyID:       "PolicyID",
+			expectedResult: true,
+		},
+		"OkCaseNotFound": {
+			relation: &relation{
+				userID:   "UserID",
+				policyID: "PolicyID",
+				createAt: now.UnixNano(),
+			},
+			userID:         "UserID",
+			policyID:       "PolicyIDXXXXXXX",
+			expectedResult: false,
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean UserPolicyRelation database
+		cleanUserPolicyRelationTable(t, n)
+
+		// Insert previous data
+		if test.relation != nil {
+			insertUserPolicyRelation(t, n, test.relation.userID, test.relation.policyID, test.relation.createAt)
+		}
+
+		// Call repository to check if policy is attached to user
+		result, err := repoDB.IsAttachedToUser(test.userID, test.policyID)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedResult, result, "Error in test case %v", n)
+	}
+}
+
+func testGetAttachedUserPolicies(t *testing.T) {
+	type relations struct {
+		policies       []postgresql.Policy
+		userID         string
+		createAt       []int64
+		policyNotFound bool
+	}
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		relations *relations
+		// Postgres Repo Args
+		userID string
+		filter *api.Filter
+		// Expected result
+		expectedResponse []*postgresql.PolicyUser
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			relations: &relations{
+				policies: []postgresql.Policy{
+					{
+						ID:       "PolicyID1",
+						Name:     "Name1",
+						Org:      "org1",
+						Path:     "/path/",
+						CreateAt: now.UnixNano(),
+						UpdateAt: now.UnixNano(),
+						Urn:      "Urn1",
+					},
+					{
+						ID:       "PolicyID2",
+						Name:     "Name2",
+						Org:      "org2",
+						Path:     "/path/",
+						CreateAt: now.UnixNano(),
+						UpdateAt: now.UnixNano(),
+						Urn:      "Urn2",
+					},
+				},
+				userID:   "UserID",
+				createAt: []int64{now.UnixNano() - 1, now.UnixNano()},
+			},
+			userID: "UserID",
+			filter: &api.Filter{
+				OrderBy: "create_at desc",
+			},
+			expectedResponse: []*postgresql.PolicyUser{
+				{
+					Policy: &api.Policy{
+						ID:         "PolicyID2",
+						Name:       "Name2",
+						Org:        "org2",
+						Path:       "/path/",
+						CreateAt:   now,
+						UpdateAt:   now,
+						Urn:        "Urn2",
+						Statements: &[]api.Statement{},
+					},
+					CreateAt: now,
+				},
+				{
+					Policy: &api.Policy{
+						ID:         "PolicyID1",
+						Name:       "Name1",
+						Org:        "org1",
+						Path:       "/path/",
+						CreateAt:   now,
+						UpdateAt:   now,
+						Urn:        "Urn1",
+						Statements: &[]api.Statement{},
+					},
+					CreateAt: now.Add(-1),
+				},
+			},
+		},
+		"ErrorCase": {
+			relations: &relations{
+				policies: []postgresql.Policy{
+					{
+						ID:       "PolicyID1",
+						Name:     "Name1",
+						Org:      "org1",
+						Path:     "/path/",
+						CreateAt: now.UnixNano(),
+						UpdateAt: now.UnixNano(),
+						Urn:      "Urn1",
+					},
+				},
+				userID:         "UserID",
+				createAt:       []int64{now.UnixNano()},
+				policyNotFound: true,
+			},
+			userID: "UserID",
+			filter: testFilter,
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: "Code: PolicyNotFound, Message: Policy with id PolicyID1 not found",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		cleanPolicyTable(t, n)
+		cleanUserPolicyRelationTable(t, n)
+
+		// Insert previous data
+		if test.relations != nil {
+			for i, policy := range test.relations.policies {
+				insertUserPolicyRelation(t, n, test.relations.userID, policy.ID, test.relations.createAt[i])
+				if !test.relations.policyNotFound {
+					insertPolicy(t, n, policy, []postgresql.Statement{})
+				}
+			}
+		}
+
+		receivedPolicies, total, err := repoDB.GetAttachedUserPolicies(test.userID, test.filter)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+
+			// Check total
+			assert.Equal(t, len(test.expectedResponse), total, "Error in test case %v", n)
+
+			// Check response
+			for i, r := range receivedPolicies {
+				assert.Equal(t, test.expectedResponse[i].GetUser(), r.GetUser(), "Error in test case %v", n)
+				assert.Equal(t, test.expectedResponse[i].GetPolicy(), r.GetPolicy(), "Error in test case %v", n)
+				assert.Equal(t, test.expectedResponse[i].GetDate(), r.GetDate(), "Error in test case %v", n)
+			}
+		}
+	}
+}
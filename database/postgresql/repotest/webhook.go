@@ -0,0 +1,413 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+	"github.com/Tecsisa/foulkon/database/postgresql"
+	"github.com/stretchr/testify/assert"
+)
+
+func testAddWebhook(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousWebhook *postgresql.Webhook
+		// Postgres Repo Args
+		webhookToCreate *api.Webhook
+		// Expected result
+		expectedResponse *api.Webhook
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			webhookToCreate: &api.Webhook{
+				ID:       "WebhookID",
+				Name:     "Name",
+				Path:     "Path",
+				Urn:      "urn",
+				CreateAt: now,
+				UpdateAt: now,
+				URL:      "https://hooks.example.com",
+				Secret:   "secret",
+				Events:   []string{"user.*", "group.created"},
+			},
+			expectedResponse: &api.Webhook{
+				ID:       "WebhookID",
+				Name:     "Name",
+				Path:     "Path",
+				Urn:      "urn",
+				CreateAt: now,
+				UpdateAt: now,
+				URL:      "https://hooks.example.com",
+				Secret:   "secret",
+				Events:   []string{"user.*", "group.created"},
+			},
+		},
+		"ErrorCaseWebhookAlreadyExist": {
+			previousWebhook: &postgresql.Webhook{
+				ID:   "WebhookID",
+				Name: "Name",
+				Urn:  "urn",
+			},
+			webhookToCreate: &api.Webhook{
+				ID:   "WebhookID",
+				Name: "Name",
+				Urn:  "urn",
+			},
+			expectedError: &database.Error{
+				Code:    database.INTERNAL_ERROR,
+				Message: driver.UniqueViolation("webhooks_pkey"),
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean webhooks database
+		cleanWebhooksTable(t, n)
+
+		// Insert previous data
+		if test.previousWebhook != nil {
+			insertWebhook(t, n, *test.previousWebhook)
+		}
+		// Call to repository to store a webhook
+		storedWebhook, err := repoDB.AddWebhook(*test.webhookToCreate)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, storedWebhook, "Error in test case %v", n)
+			// Check database
+			webhook, err := repoDB.GetWebhookByName(test.webhookToCreate.Name)
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, webhook, "Error in test case %v", n)
+		}
+	}
+}
+
+func testGetWebhookByName(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousWebhook *postgresql.Webhook
+		// Postgres Repo Args
+		name string
+		// Expected result
+		expectedResponse *api.Webhook
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			previousWebhook: &postgresql.Webhook{
+				ID:       "WebhookID",
+				Name:     "Name",
+				Path:     "Path",
+				Urn:      "urn",
+				URL:      "https://hooks.example.com",
+				Secret:   "secret",
+				Events:   "*",
+				CreateAt: now.UnixNano(),
+				UpdateAt: now.UnixNano(),
+			},
+			name: "Name",
+			expectedResponse: &api.Webhook{
+				ID:       "WebhookID",
+				Name:     "Name",
+				Path:     "Path",
+				Urn:      "urn",
+				URL:      "https://hooks.example.com",
+				Secret:   "secret",
+				Events:   []string{"*"},
+				CreateAt: now,
+				UpdateAt: now,
+			},
+		},
+		"ErrorCaseWebhookNotFound": {
+			name: "Name",
+			expectedError: &database.Error{
+				Code:    database.WEBHOOK_NOT_FOUND,
+				Message: "Webhook with name Name not found",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean webhooks database
+		cleanWebhooksTable(t, n)
+
+		// Insert previous data
+		if test.previousWebhook != nil {
+			insertWebhook(t, n, *test.previousWebhook)
+		}
+		// Call to repository to get a webhook
+		webhook, err := repoDB.GetWebhookByName(test.name)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, webhook, "Error in test case %v", n)
+		}
+	}
+}
+
+func testGetWebhooksFiltered(t *testing.T) {
+	previousWebhooks := []postgresql.Webhook{
+		{ID: "WebhookID1", Name: "b", Path: "/path/", Urn: "urn1", Events: "*"},
+		{ID: "WebhookID2", Name: "a", Path: "/other/", Urn: "urn2", Events: "*"},
+	}
+	testcases := map[string]struct {
+		// Postgres Repo Args
+		filter *api.Filter
+		// Expected result
+		expectedNames []string
+		expectedTotal int
+	}{
+		"OkCaseAll": {
+			filter:        &api.Filter{OrderBy: "name"},
+			expectedNames: []string{"a", "b"},
+			expectedTotal: 2,
+		},
+		"OkCasePathPrefix": {
+			filter:        &api.Filter{PathPrefix: "/path/"},
+			expectedNames: []string{"b"},
+			expectedTotal: 1,
+		},
+		"OkCaseLimit": {
+			filter:        &api.Filter{OrderBy: "name desc", Limit: 1},
+			expectedNames: []string{"b"},
+			expectedTotal: 2,
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean webhooks database
+		cleanWebhooksTable(t, n)
+
+		// Insert previous data
+		for _, webhook := range previousWebhooks {
+			insertWebhook(t, n, webhook)
+		}
+		// Call to repository to get webhooks
+		webhooks, total, err := repoDB.GetWebhooksFiltered(test.filter)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedTotal, total, "Error in test case %v", n)
+		names := []string{}
+		for _, webhook := range webhooks {
+			names = append(names, webhook.Name)
+		}
+		assert.Equal(t, test.expectedNames, names, "Error in test case %v", n)
+	}
+}
+
+func testUpdateWebhook(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// Previous data
+		previousWebhook *postgresql.Webhook
+		// Postgres Repo Args
+		webhookToUpdate *api.Webhook
+		oldUpdateAt     time.Time
+		// Expected result
+		expectedResponse *api.Webhook
+		expectedError    *database.Error
+	}{
+		"OkCase": {
+			previousWebhook: &postgresql.Webhook{
+				ID:       "WebhookID",
+				Name:     "Name",
+				Path:     "Path",
+				Urn:      "urn",
+				URL:      "https://hooks.example.com",
+				Secret:   "secret",
+				Events:   "*",
+				CreateAt: now.UnixNano(),
+				UpdateAt: now.UnixNano(),
+			},
+			webhookToUpdate: &api.Webhook{
+				ID:       "WebhookID",
+				Name:     "NewName",
+				Path:     "NewPath",
+				Urn:      "newUrn",
+				URL:      "https://hooks.example.com/new",
+				Secret:   "newSecret",
+				Events:   []string{"policy.*"},
+				CreateAt: now,
+				UpdateAt: now.Add(time.Second),
+			},
+			oldUpdateAt: now,
+			expectedResponse: &api.Webhook{
+				ID:       "WebhookID",
+				Name:     "NewName",
+				Path:     "NewPath",
+				Urn:      "newUrn",
+				URL:      "https://hooks.example.com/new",
+				Secret:   "newSecret",
+				Events:   []string{"policy.*"},
+				CreateAt: now,
+				UpdateAt: now.Add(time.Second),
+			},
+		},
+		"ErrorCaseVersionConflict": {
+			previousWebhook: &postgresql.Webhook{
+				ID:       "WebhookID",
+				Name:     "Name",
+				Urn:      "urn",
+				Events:   "*",
+				UpdateAt: now.UnixNano(),
+			},
+			webhookToUpdate: &api.Webhook{
+				ID:     "WebhookID",
+				Name:   "NewName",
+				Urn:    "urn",
+				Events: []string{"*"},
+			},
+			oldUpdateAt: now.Add(-time.Second),
+			expectedError: &database.Error{
+				Code:    database.VERSION_CONFLICT,
+				Message: "Webhook with id WebhookID was modified or removed by another request",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean webhooks database
+		cleanWebhooksTable(t, n)
+
+		// Insert previous data
+		if test.previousWebhook != nil {
+			insertWebhook(t, n, *test.previousWebhook)
+		}
+		// Call to repository to update a webhook
+		updatedWebhook, err := repoDB.UpdateWebhook(*test.webhookToUpdate, test.oldUpdateAt)
+		if test.expectedError != nil {
+			dbError, _ := err.(*database.Error)
+			assert.Equal(t, test.expectedError, dbError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, updatedWebhook, "Error in test case %v", n)
+			// Check database
+			webhook, err := repoDB.GetWebhookByName(test.webhookToUpdate.Name)
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, webhook, "Error in test case %v", n)
+		}
+	}
+}
+
+func testRemoveWebhook(t *testing.T) {
+	// Clean webhooks database
+	cleanWebhooksTable(t, "RemoveWebhook")
+
+	// Insert previous data
+	insertWebhook(t, "RemoveWebhook", postgresql.Webhook{ID: "WebhookID1", Name: "Name1", Urn: "urn1"})
+	insertWebhook(t, "RemoveWebhook", postgresql.Webhook{ID: "WebhookID2", Name: "Name2", Urn: "urn2"})
+	insertWebhookDeadLetter(t, "RemoveWebhook", postgresql.WebhookDeadLetter{ID: "DeadLetterID1", WebhookID: "WebhookID1", Event: "{}"})
+	insertWebhookDeadLetter(t, "RemoveWebhook", postgresql.WebhookDeadLetter{ID: "DeadLetterID2", WebhookID: "WebhookID2", Event: "{}"})
+
+	// Call to repository to remove a webhook
+	err := repoDB.RemoveWebhook("WebhookID1")
+	assert.Nil(t, err, "Error removing webhook")
+
+	// Check database
+	assert.Equal(t, 0, getWebhooksCountFiltered(t, "RemoveWebhook", "WebhookID1"), "Error removing webhook")
+	assert.Equal(t, 1, getWebhooksCountFiltered(t, "RemoveWebhook", "WebhookID2"), "Error removing webhook")
+	_, total, err := repoDB.GetWebhookDeadLettersFiltered("WebhookID1", &api.Filter{})
+	assert.Nil(t, err, "Error removing webhook")
+	assert.Equal(t, 0, total, "Error removing webhook")
+	_, total, err = repoDB.GetWebhookDeadLettersFiltered("WebhookID2", &api.Filter{})
+	assert.Nil(t, err, "Error removing webhook")
+	assert.Equal(t, 1, total, "Error removing webhook")
+}
+
+func testAddWebhookDeadLetter(t *testing.T) {
+	now := time.Now().UTC()
+	deadLetter := api.WebhookDeadLetter{
+		ID:        "DeadLetterID",
+		WebhookID: "WebhookID",
+		Event: api.WebhookEvent{
+			ID:        "EventID",
+			Type:      api.WEBHOOK_EVENT_USER_CREATED,
+			Urn:       "urn",
+			Actor:     "admin",
+			RequestID: "RequestID",
+			After:     []byte(`{"externalId":"user"}`),
+			CreateAt:  now,
+		},
+		Attempts:  5,
+		LastError: "Unexpected status code 500",
+		CreateAt:  now,
+	}
+
+	// Clean webhooks database
+	cleanWebhooksTable(t, "AddWebhookDeadLetter")
+
+	// Call to repository to store a dead letter
+	err := repoDB.AddWebhookDeadLetter(deadLetter)
+	assert.Nil(t, err, "Error adding webhook dead letter")
+
+	// Check database
+	deadLetters, total, err := repoDB.GetWebhookDeadLettersFiltered("WebhookID", &api.Filter{})
+	assert.Nil(t, err, "Error adding webhook dead letter")
+	assert.Equal(t, 1, total, "Error adding webhook dead letter")
+	assert.Equal(t, []api.WebhookDeadLetter{deadLetter}, deadLetters, "Error adding webhook dead letter")
+
+	// Duplicated dead letter
+	err = repoDB.AddWebhookDeadLetter(deadLetter)
+	dbError, _ := err.(*database.Error)
+	assert.Equal(t, &database.Error{
+		Code:    database.INTERNAL_ERROR,
+		Message: driver.UniqueViolation("webhook_dead_letters_pkey"),
+	}, dbError, "Error adding webhook dead letter")
+}
+
+func testGetWebhookDeadLettersFiltered(t *testing.T) {
+	now := time.Now().UTC()
+	previousDeadLetters := []postgresql.WebhookDeadLetter{
+		{ID: "1", WebhookID: "WebhookID", Event: "{}", Attempts: 5, CreateAt: now.UnixNano()},
+		{ID: "2", WebhookID: "WebhookID", Event: "{}", Attempts: 3, CreateAt: now.Add(-time.Minute).UnixNano()},
+		{ID: "3", WebhookID: "OtherWebhookID", Event: "{}", Attempts: 1, CreateAt: now.UnixNano()},
+	}
+	testcases := map[string]struct {
+		// Postgres Repo Args
+		filter *api.Filter
+		// Expected result
+		expectedIDs   []string
+		expectedTotal int
+	}{
+		"OkCaseDefaultOrder": {
+			filter:        &api.Filter{},
+			expectedIDs:   []string{"2", "1"},
+			expectedTotal: 2,
+		},
+		"OkCaseOrderBy": {
+			filter:        &api.Filter{OrderBy: "attempts desc"},
+			expectedIDs:   []string{"1", "2"},
+			expectedTotal: 2,
+		},
+		"OkCaseLimit": {
+			filter:        &api.Filter{Limit: 1, Offset: 1},
+			expectedIDs:   []string{"1"},
+			expectedTotal: 2,
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean webhooks database
+		cleanWebhooksTable(t, n)
+
+		// Insert previous data
+		for _, deadLetter := range previousDeadLetters {
+			insertWebhookDeadLetter(t, n, deadLetter)
+		}
+		// Call to repository to get dead letters
+		deadLetters, total, err := repoDB.GetWebhookDeadLettersFiltered("WebhookID", test.filter)
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedTotal, total, "Error in test case %v", n)
+		ids := []string{}
+		for _, deadLetter := range deadLetters {
+			ids = append(ids, deadLetter.ID)
+		}
+		assert.Equal(t, test.expectedIDs, ids, "Error in test case %v", n)
+	}
+}
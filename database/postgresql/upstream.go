@@ -0,0 +1,235 @@
+package postgresql
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+)
+
+// UPSTREAM POOL REPOSITORY IMPLEMENTATION
+
+func (pr PostgresRepo) GetUpstreamPoolByName(org string, name string) (*api.UpstreamPool, error) {
+	pool := &UpstreamPool{}
+	query := pr.Dbmap.Where("org like ? AND name like ?", org, name).First(pool)
+
+	// Check if upstream pool exists
+	if query.RecordNotFound() {
+		return nil, &database.Error{
+			Code:    database.UPSTREAM_POOL_NOT_FOUND,
+			Message: fmt.Sprintf("Upstream pool with organization %v and name %v not found", org, name),
+		}
+	}
+	// Error Handling
+	if err := query.Error; err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	apiPool, err := dbUpstreamPoolToAPIUpstreamPool(pool)
+	if err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return apiPool, nil
+}
+
+func (pr PostgresRepo) GetUpstreamPools(filter *api.Filter) ([]api.UpstreamPool, int, error) {
+	var total int
+	pools := []UpstreamPool{}
+	query := pr.Dbmap
+
+	if len(filter.Org) > 0 {
+		query = query.Where("org like ? ", filter.Org)
+	}
+	if len(filter.PathPrefix) > 0 {
+		query = query.Where("path like ? ", filter.PathPrefix+"%")
+	}
+	if len(filter.OrderBy) > 0 {
+		query = query.Order(filter.OrderBy)
+	} else {
+		query = query.Order("create_at")
+	}
+
+	// Error handling
+	if err := query.Find(&pools).Count(&total).Offset(filter.Offset).Limit(filter.Limit).Find(&pools).Error; err != nil {
+		return nil, total, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Transform upstream pools to API domain
+	var apiPools []api.UpstreamPool
+	if pools != nil {
+		apiPools = make([]api.UpstreamPool, len(pools), cap(pools))
+		for i, p := range pools {
+			pool, err := dbUpstreamPoolToAPIUpstreamPool(&p)
+			if err != nil {
+				return nil, total, &database.Error{
+					Code:    database.INTERNAL_ERROR,
+					Message: err.Error(),
+				}
+			}
+			apiPools[i] = *pool
+		}
+	}
+
+	return apiPools, total, nil
+}
+
+func (pr PostgresRepo) AddUpstreamPool(pool api.UpstreamPool) (*api.UpstreamPool, error) {
+	// Create upstream pool model
+	poolDB, err := apiUpstreamPoolToDBUpstreamPool(pool)
+	if err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Store upstream pool
+	if err := pr.Dbmap.Create(poolDB).Error; err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return &pool, nil
+}
+
+func (pr PostgresRepo) UpdateUpstreamPool(pool api.UpstreamPool, oldUpdateAt time.Time) (*api.UpstreamPool, error) {
+	poolDB, err := apiUpstreamPoolToDBUpstreamPool(pool)
+	if err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Store upstream pool. Empty values of a struct aren't updated, so columns are given in a map
+	query := pr.Dbmap.Model(&UpstreamPool{ID: pool.ID}).Where("update_at = ?", oldUpdateAt.UnixNano()).Updates(map[string]interface{}{
+		"name":         poolDB.Name,
+		"org":          poolDB.Org,
+		"path":         poolDB.Path,
+		"urn":          poolDB.Urn,
+		"targets":      poolDB.Targets,
+		"balancer":     poolDB.Balancer,
+		"health_check": poolDB.HealthCheck,
+		"ejection":     poolDB.Ejection,
+		"transport":    poolDB.Transport,
+		"create_at":    poolDB.CreateAt,
+		"update_at":    poolDB.UpdateAt,
+	})
+
+	// Error Handling
+	if err := query.Error; err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Check if it was modified or removed by another request
+	if query.RowsAffected == 0 {
+		return nil, &database.Error{
+			Code:    database.VERSION_CONFLICT,
+			Message: fmt.Sprintf("Upstream pool with id %v was modified or removed by another request", pool.ID),
+		}
+	}
+
+	return &pool, nil
+}
+
+func (pr PostgresRepo) RemoveUpstreamPool(id string) error {
+	// Remove upstream pool
+	query := pr.Dbmap.Where("id like ?", id).Delete(&UpstreamPool{})
+
+	// Error handling
+	if err := query.Error; err != nil {
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+// PRIVATE HELPER METHODS
+
+// Transform an upstream pool for API into an upstream pool to store in db
+func apiUpstreamPoolToDBUpstreamPool(pool api.UpstreamPool) (*UpstreamPool, error) {
+	targets, err := json.Marshal(pool.Config.Targets)
+	if err != nil {
+		return nil, err
+	}
+	var healthCheck []byte
+	if pool.Config.HealthCheck != nil {
+		if healthCheck, err = json.Marshal(pool.Config.HealthCheck); err != nil {
+			return nil, err
+		}
+	}
+	ejection, err := json.Marshal(pool.Config.Ejection)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := json.Marshal(pool.Config.Transport)
+	if err != nil {
+		return nil, err
+	}
+	return &UpstreamPool{
+		ID:          pool.ID,
+		Name:        pool.Name,
+		Org:         pool.Org,
+		Path:        pool.Path,
+		Urn:         pool.Urn,
+		Targets:     string(targets),
+		Balancer:    pool.Config.Balancer,
+		HealthCheck: string(healthCheck),
+		Ejection:    string(ejection),
+		Transport:   string(transport),
+		CreateAt:    pool.CreateAt.UTC().UnixNano(),
+		UpdateAt:    pool.UpdateAt.UTC().UnixNano(),
+	}, nil
+}
+
+// Transform an upstream pool retrieved from db into an upstream pool for API
+func dbUpstreamPoolToAPIUpstreamPool(pool *UpstreamPool) (*api.UpstreamPool, error) {
+	config := api.UpstreamPoolConfig{
+		Balancer: pool.Balancer,
+	}
+	if err := json.Unmarshal([]byte(pool.Targets), &config.Targets); err != nil {
+		return nil, err
+	}
+	if len(pool.HealthCheck) > 0 {
+		config.HealthCheck = &api.UpstreamHealthCheck{}
+		if err := json.Unmarshal([]byte(pool.HealthCheck), config.HealthCheck); err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal([]byte(pool.Ejection), &config.Ejection); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(pool.Transport), &config.Transport); err != nil {
+		return nil, err
+	}
+	return &api.UpstreamPool{
+		ID:       pool.ID,
+		Name:     pool.Name,
+		Org:      pool.Org,
+		Path:     pool.Path,
+		Urn:      pool.Urn,
+		Config:   config,
+		CreateAt: time.Unix(0, pool.CreateAt).UTC(),
+		UpdateAt: time.Unix(0, pool.UpdateAt).UTC(),
+	}, nil
+}
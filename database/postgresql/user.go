@@ -113,7 +113,7 @@ func (pr PostgresRepo) GetUsersFiltered(filter *api.Filter) ([]api.User, int, er
 	return apiusers, total, nil
 }
 
-func (pr PostgresRepo) UpdateUser(user api.User) (*api.User, error) {
+func (pr PostgresRepo) UpdateUser(user api.User, oldUpdateAt time.Time) (*api.User, error) {
 	userDB := User{
 		ID:         user.ID,
 		ExternalID: user.ExternalID,
@@ -124,7 +124,7 @@ func (pr PostgresRepo) UpdateUser(user api.User) (*api.User, error) {
 	}
 
 	// Update user
-	query := pr.Dbmap.Model(&User{ID: user.ID}).Updates(userDB)
+	query := pr.Dbmap.Model(&User{ID: user.ID}).Where("update_at = ?", oldUpdateAt.UnixNano()).Updates(userDB)
 
 	// Error Handling
 	if err := query.Error; err != nil {
@@ -134,17 +134,25 @@ func (pr PostgresRepo) UpdateUser(user api.User) (*api.User, error) {
 		}
 	}
 
+	// Check if it was modified or removed by another request
+	if query.RowsAffected == 0 {
+		return nil, &database.Error{
+			Code:    database.VERSION_CONFLICT,
+			Message: fmt.Sprintf("User with id %v was modified or removed by another request", user.ID),
+		}
+	}
+
 	return &user, nil
 }
 
 func (pr PostgresRepo) RemoveUser(id string) error {
-	transaction := pr.Dbmap.Begin()
+	transaction := pr.begin()
 	// Delete user
 	transaction.Where("id like ?", id).Delete(&User{})
 
 	// Error handling
 	if err := transaction.Error; err != nil {
-		transaction.Rollback()
+		pr.rollback(transaction)
 		return &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
@@ -156,14 +164,26 @@ func (pr PostgresRepo) RemoveUser(id string) error {
 
 	// Error handling
 	if err := transaction.Error; err != nil {
-		transaction.Rollback()
+		pr.rollback(transaction)
 		return &database.Error{
 			Code:    database.INTERNAL_ERROR,
 			Message: err.Error(),
 		}
 	}
 
-	transaction.Commit()
+	// Delete user policy relations
+	transaction.Where("user_id like ?", id).Delete(&UserPolicyRelation{})
+
+	// Error handling
+	if err := transaction.Error; err != nil {
+		pr.rollback(transaction)
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	pr.commit(transaction)
 	return nil
 }
 
@@ -200,8 +220,9 @@ func (pr PostgresRepo) GetGroupsByUserID(id string, filter *api.Filter) ([]api.U
 				}
 			}
 			groups[i] = &GroupUser{
-				Group:    group,
-				CreateAt: time.Unix(0, r.CreateAt).UTC(),
+				Group:     group,
+				CreateAt:  time.Unix(0, r.CreateAt).UTC(),
+				ExpiresAt: dbExpirationToAPI(r.ExpiresAt),
 			}
 		}
 	}
@@ -209,6 +230,103 @@ func (pr PostgresRepo) GetGroupsByUserID(id string, filter *api.Filter) ([]api.U
 	return groups, total, nil
 }
 
+func (pr PostgresRepo) AttachUserPolicy(userID string, policyID string) error {
+	// Create relation
+	relation := &UserPolicyRelation{
+		UserID:   userID,
+		PolicyID: policyID,
+		CreateAt: time.Now().UTC().UnixNano(),
+	}
+
+	// Store relation
+	err := pr.Dbmap.Create(relation).Error
+
+	// Error handling
+	if err != nil {
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+func (pr PostgresRepo) DetachUserPolicy(userID string, policyID string) error {
+	// Remove relation
+	err := pr.Dbmap.Where("user_id like ? AND policy_id like ?", userID, policyID).Delete(&UserPolicyRelation{}).Error
+
+	// Error handling
+	if err != nil {
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+func (pr PostgresRepo) IsAttachedToUser(userID string, policyID string) (bool, error) {
+	relation := UserPolicyRelation{}
+	query := pr.Dbmap.Where("user_id like ? AND policy_id like ?", userID, policyID).First(&relation)
+
+	// Check if relation exists
+	if query.RecordNotFound() {
+		return false, nil
+	}
+
+	// Error Handling
+	if err := query.Error; err != nil {
+		return false, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return true, nil
+}
+
+func (pr PostgresRepo) GetAttachedUserPolicies(userID string, filter *api.Filter) ([]api.PolicyUserRelation, int, error) {
+	var total int
+	relations := []UserPolicyRelation{}
+	query := pr.Dbmap.Where("user_id like ?", userID)
+
+	if len(filter.OrderBy) > 0 {
+		query = query.Order(filter.OrderBy)
+	}
+
+	// Error Handling
+	if err := query.Find(&relations).Count(&total).Offset(filter.Offset).Limit(filter.Limit).Find(&relations).Error; err != nil {
+		return nil, total, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+	var policies []api.PolicyUserRelation
+	// Transform relations to API domain
+	if relations != nil {
+		policies = make([]api.PolicyUserRelation, len(relations), cap(relations))
+		for i, r := range relations {
+			policy, err := pr.GetPolicyById(r.PolicyID)
+			// Error handling
+			if err != nil {
+				return nil, total, &database.Error{
+					Code:    database.INTERNAL_ERROR,
+					Message: err.Error(),
+				}
+			}
+
+			policies[i] = &PolicyUser{
+				Policy:   policy,
+				CreateAt: time.Unix(0, r.CreateAt).UTC(),
+			}
+		}
+	}
+
+	return policies, total, nil
+}
+
 // PRIVATE HELPER METHODS
 
 // Transform a user retrieved from db into a user for API
@@ -8,9 +8,10 @@ import (
 
 // GroupUser struct contains (Group-User) relationship
 type GroupUser struct {
-	User     *api.User
-	Group    *api.Group
-	CreateAt time.Time
+	User      *api.User
+	Group     *api.Group
+	CreateAt  time.Time
+	ExpiresAt *time.Time
 }
 
 // GetUser returns a member of a GroupUser relation
@@ -28,11 +29,17 @@ func (gu *GroupUser) GetDate() time.Time {
 	return gu.CreateAt
 }
 
+// GetExpiresAt returns the date when the relation expires, or nil if it doesn't expire
+func (gu *GroupUser) GetExpiresAt() *time.Time {
+	return gu.ExpiresAt
+}
+
 // PolicyGroup struct contains (Policy-Group) relationship
 type PolicyGroup struct {
-	Group    *api.Group
-	Policy   *api.Policy
-	CreateAt time.Time
+	Group     *api.Group
+	Policy    *api.Policy
+	CreateAt  time.Time
+	ExpiresAt *time.Time
 }
 
 // GetGroup returns a Group of a PolicyGroup relation
@@ -49,3 +56,69 @@ func (pg *PolicyGroup) GetPolicy() *api.Policy {
 func (pg *PolicyGroup) GetDate() time.Time {
 	return pg.CreateAt
 }
+
+// GetExpiresAt returns the date when the relation expires, or nil if it doesn't expire
+func (pg *PolicyGroup) GetExpiresAt() *time.Time {
+	return pg.ExpiresAt
+}
+
+// PolicyUser struct contains (Policy-User) relationship
+type PolicyUser struct {
+	User     *api.User
+	Policy   *api.Policy
+	CreateAt time.Time
+}
+
+// GetUser returns a User of a PolicyUser relation
+func (pu *PolicyUser) GetUser() *api.User {
+	return pu.User
+}
+
+// GetPolicy returns a Policy of a PolicyUser relation
+func (pu *PolicyUser) GetPolicy() *api.Policy {
+	return pu.Policy
+}
+
+// GetDate returns the date when the relation was created
+func (pu *PolicyUser) GetDate() time.Time {
+	return pu.CreateAt
+}
+
+// GroupGroup struct contains (Parent-Child) group relationship
+type GroupGroup struct {
+	Parent   *api.Group
+	Child    *api.Group
+	CreateAt time.Time
+}
+
+// GetParent returns the parent Group of a GroupGroup relation
+func (gg *GroupGroup) GetParent() *api.Group {
+	return gg.Parent
+}
+
+// GetChild returns the child Group of a GroupGroup relation
+func (gg *GroupGroup) GetChild() *api.Group {
+	return gg.Child
+}
+
+// GetDate returns the date when the relation was created
+func (gg *GroupGroup) GetDate() time.Time {
+	return gg.CreateAt
+}
+
+// Transform an expiration date for API into its stored value, 0 when it doesn't expire
+func apiExpirationToDB(expiresAt *time.Time) int64 {
+	if expiresAt == nil {
+		return 0
+	}
+	return expiresAt.UTC().UnixNano()
+}
+
+// Transform a stored expiration date into an expiration date for API, nil when it doesn't expire
+func dbExpirationToAPI(expiresAt int64) *time.Time {
+	if expiresAt == 0 {
+		return nil
+	}
+	date := time.Unix(0, expiresAt).UTC()
+	return &date
+}
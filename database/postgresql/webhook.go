@@ -0,0 +1,253 @@
+package postgresql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database"
+)
+
+// WEBHOOK REPOSITORY IMPLEMENTATION
+
+func (pr PostgresRepo) AddWebhook(webhook api.Webhook) (*api.Webhook, error) {
+	// Create webhook model
+	webhookDB := apiWebhookToDBWebhook(webhook)
+
+	// Store webhook
+	if err := pr.Dbmap.Create(webhookDB).Error; err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return dbWebhookToAPIWebhook(webhookDB), nil
+}
+
+func (pr PostgresRepo) GetWebhookByName(name string) (*api.Webhook, error) {
+	webhook := &Webhook{}
+	query := pr.Dbmap.Where("name like ?", name).First(webhook)
+
+	// Check if webhook exists
+	if query.RecordNotFound() {
+		return nil, &database.Error{
+			Code:    database.WEBHOOK_NOT_FOUND,
+			Message: fmt.Sprintf("Webhook with name %v not found", name),
+		}
+	}
+
+	// Error Handling
+	if err := query.Error; err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return dbWebhookToAPIWebhook(webhook), nil
+}
+
+func (pr PostgresRepo) GetWebhooksFiltered(filter *api.Filter) ([]api.Webhook, int, error) {
+	var total int
+	webhooks := []Webhook{}
+	query := pr.Dbmap
+
+	if len(filter.PathPrefix) > 0 {
+		query = query.Where("path like ?", filter.PathPrefix+"%")
+	}
+	if len(filter.OrderBy) > 0 {
+		query = query.Order(filter.OrderBy)
+	}
+
+	// Error handling
+	if err := query.Find(&webhooks).Count(&total).Offset(filter.Offset).Limit(filter.Limit).Find(&webhooks).Error; err != nil {
+		return nil, total, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Transform webhooks for API
+	var apiWebhooks []api.Webhook
+	if webhooks != nil {
+		apiWebhooks = make([]api.Webhook, len(webhooks), cap(webhooks))
+		for i, w := range webhooks {
+			apiWebhooks[i] = *dbWebhookToAPIWebhook(&w)
+		}
+	}
+
+	return apiWebhooks, total, nil
+}
+
+func (pr PostgresRepo) UpdateWebhook(webhook api.Webhook, oldUpdateAt time.Time) (*api.Webhook, error) {
+	webhookDB := apiWebhookToDBWebhook(webhook)
+
+	// Update webhook
+	query := pr.Dbmap.Model(&Webhook{ID: webhook.ID}).Where("update_at = ?", oldUpdateAt.UTC().UnixNano()).Update(webhookDB)
+	if err := query.Error; err != nil {
+		return nil, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Check if it was modified or removed by another request
+	if query.RowsAffected == 0 {
+		return nil, &database.Error{
+			Code:    database.VERSION_CONFLICT,
+			Message: fmt.Sprintf("Webhook with id %v was modified or removed by another request", webhook.ID),
+		}
+	}
+
+	return dbWebhookToAPIWebhook(webhookDB), nil
+}
+
+func (pr PostgresRepo) RemoveWebhook(id string) error {
+	transaction := pr.begin()
+
+	// Delete webhook
+	transaction.Where("id like ?", id).Delete(&Webhook{})
+	if err := transaction.Error; err != nil {
+		pr.rollback(transaction)
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Delete all dead letters
+	transaction.Where("webhook_id like ?", id).Delete(&WebhookDeadLetter{})
+	if err := transaction.Error; err != nil {
+		pr.rollback(transaction)
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	pr.commit(transaction)
+	return nil
+}
+
+func (pr PostgresRepo) AddWebhookDeadLetter(deadLetter api.WebhookDeadLetter) error {
+	event, err := json.Marshal(deadLetter.Event)
+	if err != nil {
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Create dead letter model
+	deadLetterDB := &WebhookDeadLetter{
+		ID:        deadLetter.ID,
+		WebhookID: deadLetter.WebhookID,
+		Event:     string(event),
+		Attempts:  deadLetter.Attempts,
+		LastError: deadLetter.LastError,
+		CreateAt:  deadLetter.CreateAt.UnixNano(),
+	}
+
+	// Store dead letter
+	if err := pr.Dbmap.Create(deadLetterDB).Error; err != nil {
+		return &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+func (pr PostgresRepo) GetWebhookDeadLettersFiltered(webhookID string, filter *api.Filter) ([]api.WebhookDeadLetter, int, error) {
+	var total int
+	deadLetters := []WebhookDeadLetter{}
+	query := pr.Dbmap.Where("webhook_id = ?", webhookID)
+
+	if len(filter.OrderBy) > 0 {
+		query = query.Order(filter.OrderBy)
+	} else {
+		query = query.Order("create_at")
+	}
+
+	// Error handling
+	if err := query.Find(&deadLetters).Count(&total).Offset(filter.Offset).Limit(filter.Limit).Find(&deadLetters).Error; err != nil {
+		return nil, total, &database.Error{
+			Code:    database.INTERNAL_ERROR,
+			Message: err.Error(),
+		}
+	}
+
+	// Transform dead letters for API
+	var apiDeadLetters []api.WebhookDeadLetter
+	if deadLetters != nil {
+		apiDeadLetters = make([]api.WebhookDeadLetter, len(deadLetters), cap(deadLetters))
+		for i, dl := range deadLetters {
+			deadLetter, err := dbWebhookDeadLetterToAPIWebhookDeadLetter(&dl)
+			if err != nil {
+				return nil, total, &database.Error{
+					Code:    database.INTERNAL_ERROR,
+					Message: err.Error(),
+				}
+			}
+			apiDeadLetters[i] = *deadLetter
+		}
+	}
+
+	return apiDeadLetters, total, nil
+}
+
+// PRIVATE HELPER METHODS
+
+// Transform a webhook for API into a webhook to store in db
+func apiWebhookToDBWebhook(webhook api.Webhook) *Webhook {
+	return &Webhook{
+		ID:       webhook.ID,
+		Name:     webhook.Name,
+		Path:     webhook.Path,
+		Urn:      webhook.Urn,
+		URL:      webhook.URL,
+		Secret:   webhook.Secret,
+		Events:   strings.Join(webhook.Events, ","),
+		CreateAt: webhook.CreateAt.UTC().UnixNano(),
+		UpdateAt: webhook.UpdateAt.UTC().UnixNano(),
+	}
+}
+
+// Transform a webhook retrieved from db into a webhook for API
+func dbWebhookToAPIWebhook(webhook *Webhook) *api.Webhook {
+	events := []string{}
+	if len(webhook.Events) > 0 {
+		events = strings.Split(webhook.Events, ",")
+	}
+	return &api.Webhook{
+		ID:       webhook.ID,
+		Name:     webhook.Name,
+		Path:     webhook.Path,
+		Urn:      webhook.Urn,
+		URL:      webhook.URL,
+		Secret:   webhook.Secret,
+		Events:   events,
+		CreateAt: time.Unix(0, webhook.CreateAt).UTC(),
+		UpdateAt: time.Unix(0, webhook.UpdateAt).UTC(),
+	}
+}
+
+// Transform a dead letter retrieved from db into a dead letter for API
+func dbWebhookDeadLetterToAPIWebhookDeadLetter(deadLetter *WebhookDeadLetter) (*api.WebhookDeadLetter, error) {
+	event := api.WebhookEvent{}
+	if err := json.Unmarshal([]byte(deadLetter.Event), &event); err != nil {
+		return nil, err
+	}
+	return &api.WebhookDeadLetter{
+		ID:        deadLetter.ID,
+		WebhookID: deadLetter.WebhookID,
+		Event:     event,
+		Attempts:  deadLetter.Attempts,
+		LastError: deadLetter.LastError,
+		CreateAt:  time.Unix(0, deadLetter.CreateAt).UTC(),
+	}, nil
+}
@@ -0,0 +1,157 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/Tecsisa/foulkon/database/postgresql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateUpAndDown(t *testing.T) {
+	db, err := InitDb(":memory:")
+	assert.Nil(t, err, "Error opening database")
+	defer db.Close()
+
+	// Empty database
+	version, err := postgresql.SchemaVersion(db)
+	assert.Nil(t, err, "Error getting schema version")
+	assert.Equal(t, 0, version, "Unexpected schema version")
+	status, err := postgresql.MigrationsStatus(db)
+	assert.Nil(t, err, "Error getting migrations status")
+	assert.Equal(t, len(postgresql.Migrations), len(status), "Unexpected migrations status")
+	for _, s := range status {
+		assert.False(t, s.Applied, "Migration %v shouldn't be applied", s.Version)
+		assert.Nil(t, s.AppliedAt, "Migration %v shouldn't be applied", s.Version)
+	}
+
+	// Apply all migrations
+	applied, err := postgresql.MigrateUp(db)
+	assert.Nil(t, err, "Error applying migrations")
+	assert.Equal(t, postgresql.Migrations, applied, "Unexpected applied migrations")
+	version, err = postgresql.SchemaVersion(db)
+	assert.Nil(t, err, "Error getting schema version")
+	assert.Equal(t, postgresql.LatestSchemaVersion(), version, "Unexpected schema version")
+	assert.True(t, db.HasTable(&postgresql.User{}), "Users table wasn't created")
+	status, err = postgresql.MigrationsStatus(db)
+	assert.Nil(t, err, "Error getting migrations status")
+	for _, s := range status {
+		assert.True(t, s.Applied, "Migration %v should be applied", s.Version)
+		assert.NotNil(t, s.AppliedAt, "Migration %v should be applied", s.Version)
+	}
+
+	// Nothing left to apply
+	applied, err = postgresql.MigrateUp(db)
+	assert.Nil(t, err, "Error applying migrations")
+	assert.Equal(t, []postgresql.Migration{}, applied, "Unexpected applied migrations")
+
+	// Revert migrations one by one
+	for i := len(postgresql.Migrations) - 1; i >= 0; i-- {
+		reverted, err := postgresql.MigrateDown(db)
+		assert.Nil(t, err, "Error reverting migration")
+		assert.Equal(t, &postgresql.Migrations[i], reverted, "Unexpected reverted migration")
+	}
+	version, err = postgresql.SchemaVersion(db)
+	assert.Nil(t, err, "Error getting schema version")
+	assert.Equal(t, 0, version, "Unexpected schema version")
+	assert.False(t, db.HasTable(&postgresql.User{}), "Users table wasn't removed")
+
+	// Nothing left to revert
+	reverted, err := postgresql.MigrateDown(db)
+	assert.Nil(t, err, "Error reverting migration")
+	assert.Nil(t, reverted, "Unexpected reverted migration")
+}
+
+// Proxy resources table of releases without migrations
+type legacyProxyResource struct {
+	ID           string `gorm:"primary_key"`
+	Name         string `gorm:"not null"`
+	Org          string `gorm:"not null"`
+	Path         string `gorm:"not null"`
+	Host         string `gorm:"not null;unique_index:idx_resource"`
+	PathResource string `gorm:"not null;unique_index:idx_resource"`
+	Method       string `gorm:"not null;unique_index:idx_resource"`
+	UrnResource  string `gorm:"not null;unique_index:idx_resource"`
+	Urn          string `gorm:"not null"`
+	Action       string `gorm:"not null;unique_index:idx_resource"`
+	CreateAt     int64  `gorm:"not null"`
+	UpdateAt     int64  `gorm:"not null"`
+}
+
+func (legacyProxyResource) TableName() string {
+	return "proxy_resources"
+}
+
+// Statements table of releases without migrations
+type legacyStatement struct {
+	ID        string `gorm:"primary_key"`
+	PolicyID  string `gorm:"not null"`
+	Effect    string `gorm:"not null"`
+	Actions   string `gorm:"not null"`
+	Resources string `gorm:"not null"`
+}
+
+func (legacyStatement) TableName() string {
+	return "statements"
+}
+
+// Group memberships table of releases without migrations
+type legacyGroupUserRelation struct {
+	UserID   string `gorm:"primary_key"`
+	GroupID  string `gorm:"primary_key"`
+	CreateAt int64  `gorm:"not null"`
+}
+
+func (legacyGroupUserRelation) TableName() string {
+	return "group_user_relations"
+}
+
+// Group policy attachments table of releases without migrations
+type legacyGroupPolicyRelation struct {
+	GroupID  string `gorm:"primary_key"`
+	PolicyID string `gorm:"primary_key"`
+	CreateAt int64  `gorm:"not null"`
+}
+
+func (legacyGroupPolicyRelation) TableName() string {
+	return "group_policy_relations"
+}
+
+func TestMigrateUpExistingSchema(t *testing.T) {
+	db, err := InitDb(":memory:")
+	assert.Nil(t, err, "Error opening database")
+	defer db.Close()
+
+	// Schema created by releases without migrations
+	err = db.AutoMigrate(&postgresql.User{}, &postgresql.Group{}, &postgresql.Policy{}, &legacyStatement{},
+		&legacyGroupUserRelation{}, &legacyGroupPolicyRelation{}, &legacyProxyResource{}, &postgresql.OidcProvider{},
+		&postgresql.OidcClient{}).Error
+	assert.Nil(t, err, "Error creating schema")
+	err = db.Exec("INSERT INTO users (id, external_id, path, create_at, update_at, urn) VALUES (?, ?, ?, ?, ?, ?)",
+		"UserID", "ExternalID", "/path/", 0, 0, "urn").Error
+	assert.Nil(t, err, "Error inserting user")
+
+	_, err = postgresql.MigrateUp(db)
+	assert.Nil(t, err, "Error applying migrations")
+	version, err := postgresql.SchemaVersion(db)
+	assert.Nil(t, err, "Error getting schema version")
+	assert.Equal(t, postgresql.LatestSchemaVersion(), version, "Unexpected schema version")
+
+	// Data is kept
+	var total int
+	err = db.Table(postgresql.User{}.TableName()).Count(&total).Error
+	assert.Nil(t, err, "Error counting users")
+	assert.Equal(t, 1, total, "Users were removed by migrations")
+
+	// Columns and tables added after the initial schema can be written
+	err = db.Create(&postgresql.Statement{ID: "StatementID", PolicyID: "PolicyID", Effect: "allow", Actions: "action",
+		Resources: "resource", Conditions: "conditions"}).Error
+	assert.Nil(t, err, "Error inserting statement with conditions")
+	err = db.Create(&postgresql.GroupUserRelation{UserID: "UserID", GroupID: "GroupID", ExpiresAt: 1}).Error
+	assert.Nil(t, err, "Error inserting expiring group membership")
+	err = db.Create(&postgresql.GroupPolicyRelation{GroupID: "GroupID", PolicyID: "PolicyID", ExpiresAt: 1}).Error
+	assert.Nil(t, err, "Error inserting expiring policy attachment")
+	err = db.Create(&postgresql.GroupGroupRelation{ParentID: "GroupID", ChildID: "ChildID"}).Error
+	assert.Nil(t, err, "Error inserting group hierarchy")
+	err = db.Create(&postgresql.UserPolicyRelation{UserID: "UserID", PolicyID: "PolicyID"}).Error
+	assert.Nil(t, err, "Error inserting user policy")
+}
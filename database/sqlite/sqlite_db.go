@@ -0,0 +1,35 @@
+package sqlite
+
+import (
+	"fmt"
+
+	"github.com/Tecsisa/foulkon/database/postgresql"
+	"github.com/jinzhu/gorm"
+	_ "github.com/mattn/go-sqlite3" //GORM needs to import the go-sqlite3 driver
+)
+
+// SqliteRepo stores all entities in an embedded SQLite database. It uses the same GORM models,
+// queries and schema migrations as the PostgreSQL repository, so all repository methods are inherited from it.
+type SqliteRepo struct {
+	postgresql.PostgresRepo
+}
+
+func InitDb(path string) (*gorm.DB, error) {
+	// connect to db using GORM - github.com/jinzhu/gorm. LIKE is case sensitive as in PostgreSQL,
+	// and writers wait for locked tables instead of failing.
+	db, err := gorm.Open("sqlite3", fmt.Sprintf("%v?_busy_timeout=5000&_cslike=true", path))
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows a single writer, and every connection to an in-memory database opens a different one
+	db.DB().SetMaxOpenConns(1)
+
+	// Check connection
+	err = db.DB().Ping()
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
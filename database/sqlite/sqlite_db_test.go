@@ -0,0 +1,268 @@
+package sqlite
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database/postgresql"
+	"github.com/Tecsisa/foulkon/database/postgresql/repotest"
+	"github.com/stretchr/testify/assert"
+)
+
+var repoDB SqliteRepo
+
+// Columns that SQLite reports for each unique constraint of the PostgreSQL schema
+var uniqueConstraintColumns = map[string]string{
+	"audit_entries_pkey":        "audit_entries.id",
+	"groups_pkey":               "groups.id",
+	"groups_urn_key":            "groups.urn",
+	"idx_oidc_client":           "oidc_clients.oidc_provider_id, oidc_clients.name",
+	"idx_upstream_pool":         "upstream_pools.org, upstream_pools.name",
+	"oidc_providers_pkey":       "oidc_providers.id",
+	"oidc_providers_urn_key":    "oidc_providers.urn",
+	"policies_pkey":             "policies.id",
+	"users_pkey":                "users.id",
+	"webhook_dead_letters_pkey": "webhook_dead_letters.id",
+	"webhooks_pkey":             "webhooks.id",
+	// SQLite checks the resource index before the primary key, so a duplicated proxy resource fails on it
+	"proxy_resources_pkey": "proxy_resources.host, proxy_resources.path_resource, proxy_resources.method, " +
+		"proxy_resources.urn_resource, proxy_resources.action, proxy_resources.match_host, proxy_resources.match_headers, " +
+		"proxy_resources.upstream",
+}
+
+func TestMain(m *testing.M) {
+	// Retrieve db connector to run test
+	dbmap, err := InitDb(":memory:")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "There was an error starting connector", err)
+		os.Exit(1)
+	}
+	if _, err := postgresql.MigrateUp(dbmap); err != nil {
+		fmt.Fprintln(os.Stderr, "There was an error migrating database schema", err)
+		os.Exit(1)
+	}
+	repoDB = SqliteRepo{
+		PostgresRepo: postgresql.PostgresRepo{
+			Dbmap: dbmap,
+		},
+	}
+
+	result := m.Run()
+
+	os.Exit(result)
+}
+
+func TestInitDb(t *testing.T) {
+	testcases := map[string]struct {
+		path          string
+		expectedError string
+	}{
+		"OkCaseFile": {
+			path: filepath.Join(os.TempDir(), "foulkon_test.db"),
+		},
+		"ErrorCaseInvalidPath": {
+			path:          filepath.Join(os.TempDir(), "foulkon_not_exist", "foulkon_test.db"),
+			expectedError: "unable to open database file: no such file or directory",
+		},
+	}
+
+	for n, test := range testcases {
+		db, err := InitDb(test.path)
+		if test.expectedError != "" {
+			assert.EqualError(t, err, test.expectedError, "Error in test case %v", n)
+		} else {
+			assert.Nil(t, err, "Error in test case %v", n)
+			db.Close()
+			os.Remove(test.path)
+		}
+	}
+}
+
+func TestSqliteRepo_OrderByValidColumns(t *testing.T) {
+	testcases := map[string]struct {
+		action          string
+		expectedColumns []string
+	}{
+		"OkCaseAction-" + api.USER_ACTION_LIST_USERS: {
+			action:          api.USER_ACTION_LIST_USERS,
+			expectedColumns: []string{"path", "external_id", "create_at", "update_at", "urn"},
+		},
+		"OkCaseAction-" + api.USER_ACTION_LIST_GROUPS_FOR_USER: {
+			action:          api.USER_ACTION_LIST_GROUPS_FOR_USER,
+			expectedColumns: []string{"create_at"},
+		},
+		"OkCaseAction-" + api.USER_ACTION_LIST_ATTACHED_USER_POLICIES: {
+			action:          api.USER_ACTION_LIST_ATTACHED_USER_POLICIES,
+			expectedColumns: []string{"create_at"},
+		},
+		"OkCaseAction-" + api.GROUP_ACTION_LIST_GROUPS: {
+			action:          api.GROUP_ACTION_LIST_GROUPS,
+			expectedColumns: []string{"name", "path", "org", "create_at", "update_at", "urn"},
+		},
+		"OkCaseAction-" + api.GROUP_ACTION_LIST_MEMBERS: {
+			action:          api.GROUP_ACTION_LIST_MEMBERS,
+			expectedColumns: []string{"create_at"},
+		},
+		"OkCaseAction-" + api.GROUP_ACTION_LIST_ATTACHED_GROUP_POLICIES: {
+			action:          api.GROUP_ACTION_LIST_ATTACHED_GROUP_POLICIES,
+			expectedColumns: []string{"create_at"},
+		},
+		"OkCaseAction-" + api.GROUP_ACTION_LIST_CHILD_GROUPS: {
+			action:          api.GROUP_ACTION_LIST_CHILD_GROUPS,
+			expectedColumns: []string{"create_at"},
+		},
+		"OkCaseAction-" + api.POLICY_ACTION_LIST_POLICIES: {
+			action:          api.POLICY_ACTION_LIST_POLICIES,
+			expectedColumns: []string{"name", "path", "org", "create_at", "update_at", "urn"},
+		},
+		"OkCaseAction-" + api.POLICY_ACTION_LIST_ATTACHED_GROUPS: {
+			action:          api.POLICY_ACTION_LIST_ATTACHED_GROUPS,
+			expectedColumns: []string{"create_at"},
+		},
+		"OkCaseAction-" + api.PROXY_ACTION_LIST_RESOURCES: {
+			action: api.PROXY_ACTION_LIST_RESOURCES,
+			expectedColumns: []string{"name", "path", "org", "host", "upstream", "path_resource", "method",
+				"urn_resource", "urn", "action", "match_host", "create_at", "update_at"},
+		},
+		"OkCaseAction-" + api.UPSTREAM_ACTION_LIST_POOLS: {
+			action:          api.UPSTREAM_ACTION_LIST_POOLS,
+			expectedColumns: []string{"name", "path", "org", "urn", "balancer", "create_at", "update_at"},
+		},
+		"OkCaseAction-" + api.AUDIT_ACTION_LIST_ENTRIES: {
+			action:          api.AUDIT_ACTION_LIST_ENTRIES,
+			expectedColumns: []string{"actor", "action", "urn", "create_at"},
+		},
+		"OkCaseAction-" + api.WEBHOOK_ACTION_LIST_WEBHOOKS: {
+			action:          api.WEBHOOK_ACTION_LIST_WEBHOOKS,
+			expectedColumns: []string{"name", "path", "create_at", "update_at", "urn"},
+		},
+		"OkCaseAction-" + api.WEBHOOK_ACTION_LIST_DEAD_LETTERS: {
+			action:          api.WEBHOOK_ACTION_LIST_DEAD_LETTERS,
+			expectedColumns: []string{"attempts", "create_at"},
+		},
+		"OkCaseOtherActions": {
+			action:          "other",
+			expectedColumns: nil,
+		},
+	}
+
+	for n, test := range testcases {
+		validColumns := SqliteRepo{}.OrderByValidColumns(test.action)
+		assert.Equal(t, test.expectedColumns, validColumns, "Error in test case %v", n)
+	}
+}
+
+func TestSqliteRepo(t *testing.T) {
+	repotest.Run(t, repoDB.PostgresRepo, repotest.Driver{
+		UniqueViolation: func(constraint string) string {
+			return "UNIQUE constraint failed: " + uniqueConstraintColumns[constraint]
+		},
+		NotNullViolation: func(table string, column string) string {
+			return fmt.Sprintf("NOT NULL constraint failed: %v.%v", table, column)
+		},
+		UndefinedColumn: func(column string) string {
+			return "no such column: " + column
+		},
+	})
+}
+
+func TestSqliteRepo_CaseSensitiveLike(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		pathPrefix    string
+		expectedTotal int
+	}{
+		"OkCaseSameCase": {
+			pathPrefix:    "/path/",
+			expectedTotal: 1,
+		},
+		"OkCaseOtherCase": {
+			pathPrefix:    "/PATH/",
+			expectedTotal: 0,
+		},
+	}
+
+	for n, test := range testcases {
+		// Clean database
+		cleanUserTable(t, n)
+
+		// Insert previous data
+		insertUser(t, n, postgresql.User{
+			ID:         "UserID",
+			ExternalID: "ExternalID",
+			Path:       "/path/",
+			CreateAt:   now.UnixNano(),
+			UpdateAt:   now.UnixNano(),
+			Urn:        "urn",
+		})
+
+		users, total, err := repoDB.GetUsersFiltered(&api.Filter{PathPrefix: test.pathPrefix})
+		assert.Nil(t, err, "Error in test case %v", n)
+		assert.Equal(t, test.expectedTotal, total, "Error in test case %v", n)
+		assert.Equal(t, test.expectedTotal, len(users), "Error in test case %v", n)
+	}
+}
+
+func TestSqliteRepo_ConcurrentWrites(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "foulkon_concurrent_test.db")
+	os.Remove(path)
+	defer os.Remove(path)
+
+	// Two connectors over the same file lock each other on every write
+	repos := make([]SqliteRepo, 2)
+	for i := range repos {
+		db, err := InitDb(path)
+		assert.Nil(t, err, "Error opening database")
+		defer db.Close()
+		repos[i] = SqliteRepo{PostgresRepo: postgresql.PostgresRepo{Dbmap: db}}
+	}
+	_, err := postgresql.MigrateUp(repos[0].Dbmap)
+	assert.Nil(t, err, "Error migrating database schema")
+
+	writes := 50
+	errs := make(chan error, len(repos)*writes)
+	var wg sync.WaitGroup
+	for i, repo := range repos {
+		wg.Add(1)
+		go func(i int, repo SqliteRepo) {
+			defer wg.Done()
+			for j := 0; j < writes; j++ {
+				errs <- repo.WithTx(func(repos api.TxRepos) error {
+					id := fmt.Sprintf("UserID-%v-%v", i, j)
+					_, err := repos.UserRepo.AddUser(api.User{ID: id, ExternalID: id, Path: "/path/", Urn: id})
+					return err
+				})
+			}
+		}(i, repo)
+	}
+	wg.Wait()
+	close(errs)
+
+	// Writers wait for the lock instead of failing with "database is locked"
+	for err := range errs {
+		assert.Nil(t, err, "Error writing concurrently")
+	}
+	_, total, err := repos[0].GetUsersFiltered(&api.Filter{})
+	assert.Nil(t, err, "Error retrieving users")
+	assert.Equal(t, len(repos)*writes, total, "Unexpected stored users")
+}
+
+// Aux methods
+
+func insertUser(t *testing.T, testcase string, user postgresql.User) {
+	err := repoDB.Dbmap.Exec("INSERT INTO users (id, external_id, path, create_at, update_at, urn) VALUES (?, ?, ?, ?, ?, ?)",
+		user.ID, user.ExternalID, user.Path, user.CreateAt, user.UpdateAt, user.Urn).Error
+
+	// Error handling
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
+
+func cleanUserTable(t *testing.T, testcase string) {
+	err := repoDB.Dbmap.Delete(&postgresql.User{}).Error
+	assert.Nil(t, err, "Error in test case %v", testcase)
+}
@@ -0,0 +1,77 @@
+package foulkon
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/pelletier/go-toml"
+)
+
+var decisionLog *api.DecisionLog
+
+// newDecisionLog creates the authorization decision log configured in the decisionlog section,
+// or returns nil if it is disabled
+func newDecisionLog(config *toml.Tree) (*api.DecisionLog, error) {
+	sinkType := getDefaultValue(config, "decisionlog.type", "none")
+	if sinkType == "none" || sinkType == "" {
+		api.Log.Info("Decision log disabled")
+		return nil, nil
+	}
+
+	allowSampleRate, err := strconv.ParseFloat(getDefaultValue(config, "decisionlog.allow_sample_rate", "1"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid decisionlog.allow_sample_rate value: %v", err)
+	}
+	denySampleRate, err := strconv.ParseFloat(getDefaultValue(config, "decisionlog.deny_sample_rate", "1"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid decisionlog.deny_sample_rate value: %v", err)
+	}
+
+	var sink api.DecisionSink
+	switch sinkType {
+	case "stdout":
+		sink = api.NewWriterDecisionSink(os.Stdout)
+	case "file":
+		path := getDefaultValue(config, "decisionlog.file.path", "/tmp/foulkon-decisions.log")
+		maxSize, err := strconv.ParseInt(getDefaultValue(config, "decisionlog.file.maxsize", "104857600"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid decisionlog.file.maxsize value: %v", err)
+		}
+		maxBackups, err := strconv.Atoi(getDefaultValue(config, "decisionlog.file.maxbackups", "5"))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid decisionlog.file.maxbackups value: %v", err)
+		}
+		sink, err = api.NewFileDecisionSink(path, maxSize, maxBackups)
+		if err != nil {
+			return nil, err
+		}
+	case "webhook":
+		url, err := getMandatoryValue(config, "decisionlog.webhook.url")
+		if err != nil {
+			return nil, err
+		}
+		timeout, err := time.ParseDuration(getDefaultValue(config, "decisionlog.webhook.timeout", "5s"))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid decisionlog.webhook.timeout value: %v", err)
+		}
+		bufferSize, err := strconv.Atoi(getDefaultValue(config, "decisionlog.webhook.buffer", "1000"))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid decisionlog.webhook.buffer value: %v", err)
+		}
+		sink = api.NewWebhookDecisionSink(url, timeout, bufferSize)
+	default:
+		return nil, fmt.Errorf("Unexpected decisionlog.type value in configuration file: '%v'", sinkType)
+	}
+
+	decisions := api.NewDecisionLog(sink, allowSampleRate, denySampleRate)
+	if decisions == nil {
+		api.Log.Info("Decision log disabled, sample rates are 0")
+		return nil, sink.Close()
+	}
+	api.Log.Infof("Decision log configured with type: %v, allow sample rate: %v, deny sample rate: %v",
+		sinkType, allowSampleRate, denySampleRate)
+	return decisions, nil
+}
@@ -0,0 +1,121 @@
+package foulkon
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database/postgresql"
+	"github.com/Tecsisa/foulkon/database/sqlite"
+	"github.com/jinzhu/gorm"
+	"github.com/pelletier/go-toml"
+)
+
+// MigrateSchema runs a schema migration command (up, down or status) against the database
+// configured in the worker configuration file, and writes the result to out
+func MigrateSchema(config *toml.Tree, command string, out io.Writer) error {
+	if command != "up" && command != "down" && command != "status" {
+		return fmt.Errorf("Unexpected migrate command %v, use up, down or status", command)
+	}
+
+	gormDB, err := openSchemaDb(config)
+	if err != nil {
+		return err
+	}
+	defer gormDB.Close()
+
+	switch command {
+	case "up":
+		applied, err := postgresql.MigrateUp(gormDB)
+		for _, m := range applied {
+			fmt.Fprintf(out, "Applied migration %v: %v\n", m.Version, m.Description)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(out, "Database schema is up to date")
+		}
+	case "down":
+		reverted, err := postgresql.MigrateDown(gormDB)
+		if err != nil {
+			return err
+		}
+		if reverted == nil {
+			fmt.Fprintln(out, "There are no migrations to revert")
+		} else {
+			fmt.Fprintf(out, "Reverted migration %v: %v\n", reverted.Version, reverted.Description)
+		}
+	case "status":
+		status, err := postgresql.MigrationsStatus(gormDB)
+		if err != nil {
+			return err
+		}
+		for _, s := range status {
+			state := "pending"
+			if s.Applied {
+				state = "applied at " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(out, "%v\t%v\t%v\n", s.Version, s.Description, state)
+		}
+	}
+
+	return nil
+}
+
+// checkSchemaVersion refuses to start with a database schema older than the expected one,
+// unless pending migrations are allowed to be applied on startup
+func checkSchemaVersion(gormDB *gorm.DB, autoMigrate bool) error {
+	version, err := postgresql.SchemaVersion(gormDB)
+	if err != nil {
+		return err
+	}
+	latest := postgresql.LatestSchemaVersion()
+
+	switch {
+	case version > latest:
+		api.Log.Warnf("Database schema version %v is newer than expected version %v", version, latest)
+	case version < latest && autoMigrate:
+		applied, err := postgresql.MigrateUp(gormDB)
+		for _, m := range applied {
+			api.Log.Infof("Applied schema migration %v: %v", m.Version, m.Description)
+		}
+		if err != nil {
+			return err
+		}
+	case version < latest:
+		return fmt.Errorf("Database schema version %v is behind expected version %v, run 'foulkon migrate up' "+
+			"or enable database.automigrate", version, latest)
+	}
+
+	return nil
+}
+
+// openSchemaDb opens the configured database to manage its schema
+func openSchemaDb(config *toml.Tree) (*gorm.DB, error) {
+	dbType, err := getMandatoryValue(config, "database.type")
+	if err != nil {
+		return nil, err
+	}
+
+	switch dbType {
+	case "postgres":
+		dbdsn, err := getMandatoryValue(config, "database.postgres.datasourcename")
+		if err != nil {
+			return nil, err
+		}
+		return postgresql.InitDb(dbdsn,
+			getDefaultValue(config, "database.postgres.idleconns", "5"),
+			getDefaultValue(config, "database.postgres.maxopenconns", "20"),
+			getDefaultValue(config, "database.postgres.connttl", "300"),
+		)
+	case "sqlite":
+		dbpath, err := getMandatoryValue(config, "database.sqlite.path")
+		if err != nil {
+			return nil, err
+		}
+		return sqlite.InitDb(dbpath)
+	default:
+		return nil, fmt.Errorf("Database type %v doesn't have a schema to migrate", dbType)
+	}
+}
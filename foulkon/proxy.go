@@ -8,13 +8,24 @@ import (
 
 	"fmt"
 
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Tecsisa/foulkon/api"
 	"github.com/pelletier/go-toml"
 	"github.com/sirupsen/logrus"
 
+	"github.com/Tecsisa/foulkon/database/memory"
 	"github.com/Tecsisa/foulkon/database/postgresql"
+	"github.com/Tecsisa/foulkon/database/sqlite"
+)
+
+const (
+	// Sources of the proxy config
+	CONFIG_SOURCE_DATABASE = "database"
+	CONFIG_SOURCE_WORKER   = "worker"
 )
 
 var proxyLogfile *os.File
@@ -25,6 +36,10 @@ type Proxy struct {
 	Host string
 	Port string
 
+	// Address of the admin endpoints, that aren't served if AdminPort is empty
+	AdminHost string
+	AdminPort string
+
 	// Worker location
 	WorkerHost string
 
@@ -40,6 +55,29 @@ type Proxy struct {
 
 	// Refresh time
 	RefreshTime time.Duration
+
+	// Source of the proxy resources and upstream pools, one of CONFIG_SOURCE_*. The worker source waits for
+	// changes, and it is only polled again after a failure.
+	ConfigSource string
+
+	// File where the last config loaded is saved, and loaded from if the source is unavailable at startup.
+	// Disabled if it is empty.
+	ConfigFile string
+
+	// Secret of the worker webhook that notifies changes of the proxy resources. Notifications are disabled if
+	// it is empty.
+	NotifySecret string
+
+	// Authorization decision cache, disabled if DecisionCacheSize is 0
+	DecisionCacheSize           int
+	DecisionCachePositiveTTL    time.Duration
+	DecisionCacheNegativeTTL    time.Duration
+	DecisionCacheIdentityHeader string
+	// Request headers evaluated by policy conditions, the only ones that change the cached decisions
+	DecisionCacheHeaders []string
+
+	// Log of authorization decisions, disabled if nil
+	DecisionLog *api.DecisionLog
 }
 
 func NewProxy(config *toml.Tree) (*Proxy, error) {
@@ -70,45 +108,22 @@ func NewProxy(config *toml.Tree) (*Proxy, error) {
 	}
 	api.Log.Infof("Logger type: %v, LogLevel: %v", loggerType, api.Log.Level.String())
 
-	// Start DB with API
-	var prApi api.ProxyAPI
-
-	dbType, err := getMandatoryValue(config, "database.type")
-	if err != nil {
-		api.Log.Error(err)
-		return nil, err
+	// Proxy config source
+	configSource := getDefaultValue(config, "resources.source", CONFIG_SOURCE_DATABASE)
+	if configSource == "" {
+		configSource = CONFIG_SOURCE_DATABASE
 	}
-	switch dbType {
-	case "postgres": // PostgreSQL DB
-		api.Log.Info("Connecting to postgres database")
-		dbdsn, err := getMandatoryValue(config, "database.postgres.datasourcename")
-		if err != nil {
-			api.Log.Error(err)
-			return nil, err
-		}
-		gormDB, err := postgresql.InitDb(dbdsn,
-			getDefaultValue(config, "database.postgres.idleconns", "5"),
-			getDefaultValue(config, "database.postgres.maxopenconns", "20"),
-			getDefaultValue(config, "database.postgres.connttl", "300"),
-		)
-		if err != nil {
-			api.Log.Error(err)
-			return nil, err
-		}
-		db = gormDB.DB()
-		api.Log.Info("Connected to postgres database")
-
-		// Create repository
-		repoDB := postgresql.PostgresRepo{
-			Dbmap: gormDB,
-		}
-		prApi = api.ProxyAPI{
-			ProxyRepo: repoDB,
-		}
-
+	var prApi api.InternalProxyAPI
+	switch configSource {
+	case CONFIG_SOURCE_DATABASE:
+		prApi, err = newDatabaseProxyAPI(config)
+	case CONFIG_SOURCE_WORKER:
+		prApi, err = newWorkerProxyAPI(config)
 	default:
-		err := errors.New("Unexpected db_type value in configuration file (Maybe it is empty)")
+		err = errors.New("Unexpected resources.source value in configuration file")
 		api.Log.Error(err)
+	}
+	if err != nil {
 		return nil, err
 	}
 
@@ -140,24 +155,156 @@ func NewProxy(config *toml.Tree) (*Proxy, error) {
 		return nil, err
 	}
 
+	decisionCacheSize, err := strconv.Atoi(getDefaultValue(config, "cache.size", "0"))
+	if err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
+	decisionCachePositiveTTL, err := time.ParseDuration(getDefaultValue(config, "cache.positive_ttl", "10s"))
+	if err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
+	decisionCacheNegativeTTL, err := time.ParseDuration(getDefaultValue(config, "cache.negative_ttl", "5s"))
+	if err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
+	decisionCacheIdentityHeader := getDefaultValue(config, "cache.identity_header", "")
+	decisionCacheHeaders := []string{}
+	for _, header := range strings.Split(getDefaultValue(config, "cache.headers", ""), ",") {
+		if header = strings.TrimSpace(header); header != "" {
+			decisionCacheHeaders = append(decisionCacheHeaders, http.CanonicalHeaderKey(header))
+		}
+	}
+	if decisionCacheSize > 0 {
+		api.Log.Infof("Decision cache configured with size: %v, positive TTL: %v, negative TTL: %v",
+			decisionCacheSize, decisionCachePositiveTTL, decisionCacheNegativeTTL)
+	}
+
+	// Authorization decision log
+	decisionLog, err = newDecisionLog(config)
+	if err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
+
 	return &Proxy{
 		Host:               host,
 		Port:               port,
+		AdminHost:          getDefaultValue(config, "server.admin-host", "127.0.0.1"),
+		AdminPort:          getDefaultValue(config, "server.admin-port", ""),
 		WorkerHost:         workerHost,
 		CertFile:           getDefaultValue(config, "server.certfile", ""),
 		KeyFile:            getDefaultValue(config, "server.keyfile", ""),
 		ProxyApi:           prApi,
 		ProxyFlushInterval: proxyFlushInterval,
 		RefreshTime:        refresh,
+		NotifySecret:       getDefaultValue(config, "resources.notify_secret", ""),
+		ConfigSource:       configSource,
+		ConfigFile:         getDefaultValue(config, "resources.config_file", ""),
+
+		DecisionCacheSize:           decisionCacheSize,
+		DecisionCachePositiveTTL:    decisionCachePositiveTTL,
+		DecisionCacheNegativeTTL:    decisionCacheNegativeTTL,
+		DecisionCacheIdentityHeader: decisionCacheIdentityHeader,
+		DecisionCacheHeaders:        decisionCacheHeaders,
+
+		DecisionLog: decisionLog,
 	}, nil
 }
 
+// newDatabaseProxyAPI connects to the database of the worker to read the proxy config
+func newDatabaseProxyAPI(config *toml.Tree) (api.InternalProxyAPI, error) {
+	var prApi api.ProxyAPI
+
+	dbType, err := getMandatoryValue(config, "database.type")
+	if err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
+	switch dbType {
+	case "postgres": // PostgreSQL DB
+		api.Log.Info("Connecting to postgres database")
+		dbdsn, err := getMandatoryValue(config, "database.postgres.datasourcename")
+		if err != nil {
+			api.Log.Error(err)
+			return nil, err
+		}
+		gormDB, err := postgresql.InitDb(dbdsn,
+			getDefaultValue(config, "database.postgres.idleconns", "5"),
+			getDefaultValue(config, "database.postgres.maxopenconns", "20"),
+			getDefaultValue(config, "database.postgres.connttl", "300"),
+		)
+		if err != nil {
+			api.Log.Error(err)
+			return nil, err
+		}
+		db = gormDB.DB()
+		api.Log.Info("Connected to postgres database")
+
+		// Create repository
+		repoDB := postgresql.PostgresRepo{
+			Dbmap: gormDB,
+		}
+		prApi = api.ProxyAPI{
+			ProxyRepo: repoDB,
+		}
+
+	case "memory": // In-memory DB
+		api.Log.Info("Using in-memory database, proxy resources aren't shared with the worker")
+
+		// Create repository
+		prApi = api.ProxyAPI{
+			ProxyRepo: memory.NewMemoryRepo(),
+		}
+
+	case "sqlite": // Embedded SQLite DB
+		api.Log.Info("Connecting to sqlite database")
+		dbpath, err := getMandatoryValue(config, "database.sqlite.path")
+		if err != nil {
+			api.Log.Error(err)
+			return nil, err
+		}
+		gormDB, err := sqlite.InitDb(dbpath)
+		if err != nil {
+			api.Log.Error(err)
+			return nil, err
+		}
+		db = gormDB.DB()
+		api.Log.Info("Connected to sqlite database")
+
+		// Create repository
+		repoDB := sqlite.SqliteRepo{
+			PostgresRepo: postgresql.PostgresRepo{
+				Dbmap: gormDB,
+			},
+		}
+		prApi = api.ProxyAPI{
+			ProxyRepo: repoDB,
+		}
+
+	default:
+		err := errors.New("Unexpected db_type value in configuration file (Maybe it is empty)")
+		api.Log.Error(err)
+		return nil, err
+	}
+
+	return prApi, nil
+}
+
 func CloseProxy() int {
 	status := 0
-	if err := db.Close(); err != nil {
-		api.Log.Errorf("Couldn't close DB connection: %v", err)
+	if err := decisionLog.Close(); err != nil {
+		api.Log.Errorf("Couldn't close decision log: %v", err)
 		status = 1
 	}
+	if db != nil {
+		if err := db.Close(); err != nil {
+			api.Log.Errorf("Couldn't close DB connection: %v", err)
+			status = 1
+		}
+	}
 	if proxyLogfile != nil {
 		if err := proxyLogfile.Close(); err != nil {
 			fmt.Fprintf(os.Stderr, "Couldn't close logfile: %v", err)
@@ -0,0 +1,88 @@
+package foulkon
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/client"
+	"github.com/pelletier/go-toml"
+)
+
+// Time that the worker has to respond after the wait for changes of the proxy config
+const workerResponseTimeout = 10 * time.Second
+
+// workerProxyAPI reads the proxy config from the API of the worker, so the proxy doesn't need access to
+// the database. Requests for a new config wait for changes in the worker.
+type workerProxyAPI struct {
+	client *client.Client
+	wait   time.Duration
+}
+
+// newWorkerProxyAPI creates the client of the worker with the credentials of a user allowed to get the proxy config
+func newWorkerProxyAPI(config *toml.Tree) (api.InternalProxyAPI, error) {
+	workerHost, err := getMandatoryValue(config, "server.worker-host")
+	if err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
+	username, err := getMandatoryValue(config, "resources.worker.username")
+	if err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
+	// Users that aren't the admin are authenticated by the header authenticator of the worker
+	var auth client.Authenticator
+	if header := getDefaultValue(config, "resources.worker.header", ""); header != "" {
+		auth = client.HeaderAuth{Name: header, Value: username}
+	} else {
+		password, err := getMandatoryValue(config, "resources.worker.password")
+		if err != nil {
+			api.Log.Error(err)
+			return nil, err
+		}
+		auth = client.BasicAuth{Username: username, Password: password}
+	}
+	wait, err := time.ParseDuration(getDefaultValue(config, "resources.worker.wait", "30s"))
+	if err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
+	// Shorter waits would poll the worker without pause
+	if wait < time.Second {
+		err := fmt.Errorf("Invalid resources.worker.wait value %v, it must be 1s at least", wait)
+		api.Log.Error(err)
+		return nil, err
+	}
+	api.Log.Infof("Reading proxy config from worker %v", workerHost)
+
+	return &workerProxyAPI{
+		client: &client.Client{
+			URL:        workerHost,
+			Auth:       auth,
+			HTTPClient: &http.Client{Timeout: wait + workerResponseTimeout},
+		},
+		wait: wait,
+	}, nil
+}
+
+func (wp *workerProxyAPI) GetProxyResources() ([]api.ProxyResource, error) {
+	config, err := wp.client.GetProxyConfig(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return config.Resources, nil
+}
+
+func (wp *workerProxyAPI) GetUpstreamPools() ([]api.UpstreamPool, error) {
+	config, err := wp.client.GetProxyConfig(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return config.UpstreamPools, nil
+}
+
+func (wp *workerProxyAPI) GetProxyConfig(revision int64) (*api.ProxyConfig, error) {
+	return wp.client.GetProxyConfig(revision, wp.wait)
+}
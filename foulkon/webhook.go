@@ -0,0 +1,46 @@
+package foulkon
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/pelletier/go-toml"
+)
+
+var webhookDispatcher *api.WebhookDispatcher
+
+// newWebhookDispatcher creates the dispatcher of change events configured in the webhooks section,
+// or returns nil if it is disabled
+func newWebhookDispatcher(config *toml.Tree, repo api.WebhookRepo) (*api.WebhookDispatcher, error) {
+	workers, err := strconv.Atoi(getDefaultValue(config, "webhooks.workers", "2"))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid webhooks.workers value: %v", err)
+	}
+	if workers < 1 {
+		api.Log.Info("Webhooks disabled")
+		return nil, nil
+	}
+
+	queueSize, err := strconv.Atoi(getDefaultValue(config, "webhooks.queue", "1000"))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid webhooks.queue value: %v", err)
+	}
+	timeout, err := time.ParseDuration(getDefaultValue(config, "webhooks.timeout", "5s"))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid webhooks.timeout value: %v", err)
+	}
+	maxAttempts, err := strconv.Atoi(getDefaultValue(config, "webhooks.max_attempts", "5"))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid webhooks.max_attempts value: %v", err)
+	}
+	backoff, err := time.ParseDuration(getDefaultValue(config, "webhooks.backoff", "1s"))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid webhooks.backoff value: %v", err)
+	}
+
+	api.Log.Infof("Webhooks configured with workers: %v, queue: %v, timeout: %v, max attempts: %v, backoff: %v",
+		workers, queueSize, timeout, maxAttempts, backoff)
+	return api.NewWebhookDispatcher(repo, workers, queueSize, timeout, maxAttempts, backoff), nil
+}
@@ -2,6 +2,7 @@ package foulkon
 
 import (
 	"io"
+	"net"
 	"regexp"
 
 	"errors"
@@ -14,8 +15,12 @@ import (
 
 	"strconv"
 
+	"time"
+
 	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/database/memory"
 	"github.com/Tecsisa/foulkon/database/postgresql"
+	"github.com/Tecsisa/foulkon/database/sqlite"
 	"github.com/Tecsisa/foulkon/middleware"
 	"github.com/Tecsisa/foulkon/middleware/auth"
 	"github.com/Tecsisa/foulkon/middleware/auth/header"
@@ -45,13 +50,27 @@ type Worker struct {
 	CertFile string
 	KeyFile  string
 
+	// Proxies whose X-Forwarded-For and X-Forwarded-Proto headers are trusted
+	TrustedProxies []*net.IPNet
+
 	// APIs
-	UserApi     api.UserAPI
-	GroupApi    api.GroupAPI
-	PolicyApi   api.PolicyAPI
-	AuthzApi    api.AuthzAPI
-	ProxyApi    api.ProxyResourcesAPI
-	AuthOidcAPI api.AuthOidcAPI
+	UserApi         api.UserAPI
+	GroupApi        api.GroupAPI
+	PolicyApi       api.PolicyAPI
+	AuthzApi        api.AuthzAPI
+	ProxyApi        api.ProxyResourcesAPI
+	AuthOidcAPI     api.AuthOidcAPI
+	AuditAPI        api.AuditAPI
+	WebhookAPI      api.WebhookAPI
+	StateAPI        api.StateAPI
+	UpstreamPoolAPI api.UpstreamPoolAPI
+
+	// Internal API to remove expired group relations every SweeperInterval
+	InternalGroupApi api.InternalGroupAPI
+	SweeperInterval  time.Duration
+
+	// Cache of effective policies per user, disabled if nil
+	AuthzCache *api.AuthzCache
 
 	//  Middleware handler
 	MiddlewareHandler *middleware.MiddlewareHandler
@@ -69,6 +88,7 @@ type WorkerConfig struct {
 
 	// Database Config
 	DBType       string
+	AutoMigrate  bool
 	IdleConns    int
 	MaxOpenConns int
 	ConnTtl      int
@@ -125,6 +145,14 @@ func NewWorker(config *toml.Tree) (*Worker, error) {
 	}
 	wc.DBType = dbType
 
+	// Pending schema migrations are only applied on startup if they are explicitly enabled
+	wc.AutoMigrate, err = strconv.ParseBool(getDefaultValue(config, "database.automigrate", "false"))
+	if err != nil {
+		err = fmt.Errorf("Invalid database.automigrate value: %v", err)
+		api.Log.Error(err)
+		return nil, err
+	}
+
 	switch dbType {
 	case "postgres": // PostgreSQL DB
 		api.Log.Info("Connecting to postgres database")
@@ -143,6 +171,10 @@ func NewWorker(config *toml.Tree) (*Worker, error) {
 		}
 		db = gormDB.DB()
 		api.Log.Info("Connected to postgres database")
+		if err := checkSchemaVersion(gormDB, wc.AutoMigrate); err != nil {
+			api.Log.Error(err)
+			return nil, err
+		}
 
 		// Create repository
 		repoDB := postgresql.PostgresRepo{
@@ -154,17 +186,103 @@ func NewWorker(config *toml.Tree) (*Worker, error) {
 			PolicyRepo:   repoDB,
 			ProxyRepo:    repoDB,
 			AuthOidcRepo: repoDB,
+			AuditRepo:    repoDB,
+			WebhookRepo:  repoDB,
 		}
 		wc.IdleConns, _ = strconv.Atoi(dbIdleconns)
 		wc.MaxOpenConns, _ = strconv.Atoi(dbMaxopenconns)
 		wc.ConnTtl, _ = strconv.Atoi(dbConttl)
 
+	case "memory": // In-memory DB
+		api.Log.Info("Using in-memory database, data will be lost when the worker stops")
+
+		// Create repository
+		repoDB := memory.NewMemoryRepo()
+		authApi = api.WorkerAPI{
+			GroupRepo:    repoDB,
+			UserRepo:     repoDB,
+			PolicyRepo:   repoDB,
+			ProxyRepo:    repoDB,
+			AuthOidcRepo: repoDB,
+			AuditRepo:    repoDB,
+			WebhookRepo:  repoDB,
+		}
+
+	case "sqlite": // Embedded SQLite DB
+		api.Log.Info("Connecting to sqlite database")
+		dbpath, err := getMandatoryValue(config, "database.sqlite.path")
+		if err != nil {
+			api.Log.Error(err)
+			return nil, err
+		}
+		gormDB, err := sqlite.InitDb(dbpath)
+		if err != nil {
+			api.Log.Error(err)
+			return nil, err
+		}
+		db = gormDB.DB()
+		api.Log.Info("Connected to sqlite database")
+		if err := checkSchemaVersion(gormDB, wc.AutoMigrate); err != nil {
+			api.Log.Error(err)
+			return nil, err
+		}
+
+		// Create repository
+		repoDB := sqlite.SqliteRepo{
+			PostgresRepo: postgresql.PostgresRepo{
+				Dbmap: gormDB,
+			},
+		}
+		authApi = api.WorkerAPI{
+			GroupRepo:    repoDB,
+			UserRepo:     repoDB,
+			PolicyRepo:   repoDB,
+			ProxyRepo:    repoDB,
+			AuthOidcRepo: repoDB,
+			AuditRepo:    repoDB,
+			WebhookRepo:  repoDB,
+		}
+
 	default:
 		err := errors.New("Unexpected db_type value in configuration file (Maybe it is empty)")
 		api.Log.Error(err)
 		return nil, err
 	}
 
+	// Authorization cache
+	authzCacheTtl, err := time.ParseDuration(getDefaultValue(config, "authz.cache.ttl", "10s"))
+	if err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
+	authzCacheSize, err := strconv.Atoi(getDefaultValue(config, "authz.cache.size", "1000"))
+	if err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
+	authApi.AuthzCache = api.NewAuthzCache(authzCacheTtl, authzCacheSize)
+	if authApi.AuthzCache != nil {
+		api.Log.Infof("Authorization cache configured with TTL: %v, size: %v", authzCacheTtl, authzCacheSize)
+	} else {
+		api.Log.Info("Authorization cache disabled")
+	}
+
+	// Authorization decision log
+	decisionLog, err = newDecisionLog(config)
+	if err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
+	authApi.DecisionLog = decisionLog
+
+	// Change events dispatcher
+	webhookDispatcher, err = newWebhookDispatcher(config, authApi.WebhookRepo)
+	if err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
+	authApi.Webhooks = webhookDispatcher
+
 	// Instantiate Auth Connector
 	var authConnector auth.AuthConnector
 	authType, err := getMandatoryValue(config, "authenticator.type")
@@ -251,6 +369,18 @@ func NewWorker(config *toml.Tree) (*Worker, error) {
 		return nil, err
 	}
 
+	trustedProxies, err := parseTrustedProxies(getDefaultValue(config, "server.trusted-proxies", ""))
+	if err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
+
+	sweeperInterval, err := time.ParseDuration(getDefaultValue(config, "sweeper.interval", "1m"))
+	if err != nil {
+		api.Log.Error(err)
+		return nil, err
+	}
+
 	wc.Version = FOULKON_VERSION
 
 	return &Worker{
@@ -258,6 +388,7 @@ func NewWorker(config *toml.Tree) (*Worker, error) {
 		Port:              port,
 		CertFile:          getDefaultValue(config, "server.certfile", ""),
 		KeyFile:           getDefaultValue(config, "server.keyfile", ""),
+		TrustedProxies:    trustedProxies,
 		MiddlewareHandler: &middleware.MiddlewareHandler{Middlewares: middlewares},
 		UserApi:           authApi,
 		GroupApi:          authApi,
@@ -265,16 +396,58 @@ func NewWorker(config *toml.Tree) (*Worker, error) {
 		AuthzApi:          authApi,
 		ProxyApi:          authApi,
 		AuthOidcAPI:       authApi,
+		AuditAPI:          authApi,
+		WebhookAPI:        authApi,
+		StateAPI:          authApi,
+		UpstreamPoolAPI:   authApi,
+		InternalGroupApi:  authApi,
+		SweeperInterval:   sweeperInterval,
+		AuthzCache:        authApi.AuthzCache,
 		Config:            wc,
 	}, nil
 }
 
+// WorkerAPIConfig returns the URL of the worker API and the admin user credentials of a worker configuration,
+// to call the API from command line tools
+func WorkerAPIConfig(config *toml.Tree) (url string, adminUser string, adminPassword string, err error) {
+	host, err := getMandatoryValue(config, "server.host")
+	if err != nil {
+		return "", "", "", err
+	}
+	port, err := getMandatoryValue(config, "server.port")
+	if err != nil {
+		return "", "", "", err
+	}
+	adminUser, err = getMandatoryValue(config, "admin.username")
+	if err != nil {
+		return "", "", "", err
+	}
+	adminPassword, err = getMandatoryValue(config, "admin.password")
+	if err != nil {
+		return "", "", "", err
+	}
+
+	scheme := "http"
+	if config.Has("server.certfile") && getVar(config, "server.certfile") != "" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%v://%v", scheme, net.JoinHostPort(host, port)), adminUser, adminPassword, nil
+}
+
 func CloseWorker() int {
 	status := 0
-	if err := db.Close(); err != nil {
-		api.Log.Errorf("Couldn't close DB connection: %v", err)
+	// Deliver queued events before closing the DB, undelivered ones are stored as dead letters
+	webhookDispatcher.Close()
+	if err := decisionLog.Close(); err != nil {
+		api.Log.Errorf("Couldn't close decision log: %v", err)
 		status = 1
 	}
+	if db != nil {
+		if err := db.Close(); err != nil {
+			api.Log.Errorf("Couldn't close DB connection: %v", err)
+			status = 1
+		}
+	}
 	if workerLogfile != nil {
 		if err := workerLogfile.Close(); err != nil {
 			fmt.Fprintf(os.Stderr, "Couldn't close logfile: %v", err)
@@ -285,6 +458,27 @@ func CloseWorker() int {
 }
 
 // This aux method returns mandatory config value or any error occurred
+// parseTrustedProxies parses a comma separated list of IP addresses and CIDR blocks
+func parseTrustedProxies(value string) ([]*net.IPNet, error) {
+	trustedProxies := []*net.IPNet{}
+	for _, address := range strings.Split(value, ",") {
+		address = strings.TrimSpace(address)
+		if address == "" {
+			continue
+		}
+		if ip := net.ParseIP(address); ip != nil {
+			trustedProxies = append(trustedProxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(address)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid trusted proxy %v, it must be an IP address or CIDR block", address)
+		}
+		trustedProxies = append(trustedProxies, network)
+	}
+	return trustedProxies, nil
+}
+
 func getMandatoryValue(config *toml.Tree, key string) (string, error) {
 	if !config.Has(key) {
 		return "", fmt.Errorf("Cannot retrieve configuration value %v", key)
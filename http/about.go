@@ -27,10 +27,18 @@ type AuthConnectorConfig struct {
 	OidcProviders []api.OidcProvider `json:"oidcProviders,omitempty"`
 }
 
+type AuthzCacheConfig struct {
+	TTL    string `json:"ttl,omitempty"`
+	Size   int    `json:"size,omitempty"`
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
 type Config struct {
 	Logger        LoggerConfig        `json:"logger,omitempty"`
 	Database      DatabaseConfig      `json:"database,omitempty"`
 	AuthConnector AuthConnectorConfig `json:"authenticator,omitempty"`
+	AuthzCache    *AuthzCacheConfig   `json:"authzCache,omitempty"`
 	Version       string              `json:"version,omitempty"`
 }
 
@@ -76,11 +84,24 @@ func (wh *WorkerHandler) HandleGetCurrentConfig(w http.ResponseWriter, r *http.R
 		OidcProviders: wc.OidcProviders,
 	}
 
+	// Get Authorization cache config and counters
+	var authzCache *AuthzCacheConfig
+	if wh.worker.AuthzCache != nil {
+		stats := wh.worker.AuthzCache.Stats()
+		authzCache = &AuthzCacheConfig{
+			TTL:    stats.TTL.String(),
+			Size:   stats.Size,
+			Hits:   stats.Hits,
+			Misses: stats.Misses,
+		}
+	}
+
 	// Config Response
 	response := Config{
 		Logger:        logger,
 		Database:      db,
 		AuthConnector: auth,
+		AuthzCache:    authzCache,
 		Version:       wc.Version,
 	}
 
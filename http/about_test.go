@@ -56,6 +56,10 @@ func TestWorkerHandler_HandleGetCurrentConfig(t *testing.T) {
 						},
 					},
 				},
+				AuthzCache: &AuthzCacheConfig{
+					TTL:  "1m0s",
+					Size: 100,
+				},
 				Version: "test",
 			},
 		},
@@ -0,0 +1,78 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/julienschmidt/httprouter"
+)
+
+// RESPONSES
+
+type ListAuditEntriesResponse struct {
+	Entries []api.AuditEntry `json:"entries,omitempty"`
+	Limit   int              `json:"limit"`
+	Offset  int              `json:"offset"`
+	Total   int              `json:"total"`
+}
+
+// HANDLERS
+
+func (wh *WorkerHandler) HandleListAuditEntries(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	if apiErr == nil {
+		apiErr = getAuditFilterData(r, filterData)
+	}
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+
+	// Call audit API to list the audit entries
+	result, total, err := wh.worker.AuditAPI.ListAuditEntries(requestInfo, filterData)
+	// Create response
+	response := &ListAuditEntriesResponse{
+		Entries: result,
+		Offset:  filterData.Offset,
+		Limit:   filterData.Limit,
+		Total:   total,
+	}
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
+}
+
+// Private helper methods
+
+// getAuditFilterData fills the filter with the audit query parameters
+func getAuditFilterData(r *http.Request, filterData *api.Filter) *api.Error {
+	filterData.Actor = r.URL.Query().Get("Actor")
+	filterData.ResourceUrn = r.URL.Query().Get("Urn")
+	filterData.Action = r.URL.Query().Get("Action")
+
+	var err *api.Error
+	if filterData.From, err = getDateParam(r, "From"); err != nil {
+		return err
+	}
+	if filterData.To, err = getDateParam(r, "To"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// getDateParam retrieves an optional query parameter with RFC 3339 format, nil if it isn't passed
+func getDateParam(r *http.Request, param string) (*time.Time, *api.Error) {
+	value := r.URL.Query().Get(param)
+	if len(value) == 0 {
+		return nil, nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, &api.Error{
+			Code:    api.INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: %v %v", param, value),
+		}
+	}
+	return &date, nil
+}
@@ -0,0 +1,183 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerHandler_HandleListAuditEntries(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	later := now.Add(time.Hour)
+	testcases := map[string]struct {
+		// API method args
+		queryParams  map[string]string
+		ignoreArgsIn bool
+		// Expected result
+		expectedFilter     *api.Filter
+		expectedStatusCode int
+		expectedResponse   ListAuditEntriesResponse
+		expectedError      api.Error
+		// Manager Results
+		listAuditEntriesResult []api.AuditEntry
+		listAuditEntriesTotal  int
+		// Manager Errors
+		listAuditEntriesErr error
+	}{
+		"OkCase": {
+			queryParams: map[string]string{
+				"Actor":  "admin",
+				"Urn":    "urn:iws:iam::user/path/user1",
+				"Action": api.USER_ACTION_CREATE_USER,
+				"From":   now.Format(time.RFC3339),
+				"To":     later.Format(time.RFC3339),
+				"Offset": "1",
+				"Limit":  "10",
+			},
+			expectedFilter: &api.Filter{
+				Actor:       "admin",
+				ResourceUrn: "urn:iws:iam::user/path/user1",
+				Action:      api.USER_ACTION_CREATE_USER,
+				From:        &now,
+				To:          &later,
+				Offset:      1,
+				Limit:       10,
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: ListAuditEntriesResponse{
+				Entries: []api.AuditEntry{
+					{
+						ID:        "ID",
+						Actor:     "admin",
+						RequestID: "RequestID",
+						Action:    api.USER_ACTION_CREATE_USER,
+						Urn:       "urn:iws:iam::user/path/user1",
+						After:     json.RawMessage(`{"externalId":"user1"}`),
+						CreateAt:  now,
+					},
+				},
+				Offset: 1,
+				Limit:  10,
+				Total:  2,
+			},
+			listAuditEntriesResult: []api.AuditEntry{
+				{
+					ID:        "ID",
+					Actor:     "admin",
+					RequestID: "RequestID",
+					Action:    api.USER_ACTION_CREATE_USER,
+					Urn:       "urn:iws:iam::user/path/user1",
+					After:     json.RawMessage(`{"externalId":"user1"}`),
+					CreateAt:  now,
+				},
+			},
+			listAuditEntriesTotal: 2,
+		},
+		"ErrorCaseInvalidFilterParams": {
+			queryParams: map[string]string{
+				"Limit": "-1",
+			},
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: Limit -1",
+			},
+		},
+		"ErrorCaseInvalidFrom": {
+			queryParams: map[string]string{
+				"From": "yesterday",
+			},
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: From yesterday",
+			},
+		},
+		"ErrorCaseInvalidTo": {
+			queryParams: map[string]string{
+				"To": "2017-01-01",
+			},
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: To 2017-01-01",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			expectedFilter:     &api.Filter{},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			listAuditEntriesErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			expectedFilter:     &api.Filter{},
+			expectedStatusCode: http.StatusInternalServerError,
+			listAuditEntriesErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+		testApi.ArgsIn[ListAuditEntriesMethod][1] = nil
+		testApi.ArgsOut[ListAuditEntriesMethod][0] = test.listAuditEntriesResult
+		testApi.ArgsOut[ListAuditEntriesMethod][1] = test.listAuditEntriesTotal
+		testApi.ArgsOut[ListAuditEntriesMethod][2] = test.listAuditEntriesErr
+
+		url := fmt.Sprintf(server.URL + AUDIT_ROOT_URL)
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		q := req.URL.Query()
+		for param, value := range test.queryParams {
+			q.Add(param, value)
+		}
+		req.URL.RawQuery = q.Encode()
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// Check received parameters
+		if test.ignoreArgsIn {
+			assert.Nil(t, testApi.ArgsIn[ListAuditEntriesMethod][1], "Error in test case %v", n)
+		} else {
+			assert.Equal(t, test.expectedFilter, testApi.ArgsIn[ListAuditEntriesMethod][1], "Error in test case %v", n)
+		}
+
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			listAuditEntriesResponse := ListAuditEntriesResponse{}
+			err = json.NewDecoder(res.Body).Decode(&listAuditEntriesResponse)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, listAuditEntriesResponse, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
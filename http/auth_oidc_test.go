@@ -333,6 +333,7 @@ func TestWorkerHandler_HandleGetOidcProviderByName(t *testing.T) {
 			assert.Nil(t, err, "Error in test case %v", n)
 			// Check result
 			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+			assert.Equal(t, api.EntityTag(test.expectedResponse.UpdateAt), res.Header.Get(ETAG_HEADER), "Error in test case %v", n)
 		case http.StatusInternalServerError: // Empty message so continue
 			continue
 		default:
@@ -3,6 +3,8 @@ package http
 import (
 	"net/http"
 
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/middleware"
 	"github.com/julienschmidt/httprouter"
 )
 
@@ -13,12 +15,36 @@ type AuthorizeResourcesRequest struct {
 	Resources []string `json:"resources,omitempty"`
 }
 
+type AuthorizeResourcesBatchRequest struct {
+	Checks []api.AuthorizationCheck `json:"checks,omitempty"`
+}
+
+type ExplainAuthorizationRequest struct {
+	ExternalID string `json:"externalId,omitempty"`
+	Action     string `json:"action,omitempty"`
+	Resource   string `json:"resource,omitempty"`
+}
+
+type SimulatePoliciesRequest struct {
+	ExternalID string                `json:"externalId,omitempty"`
+	Policies   []api.DraftPolicy     `json:"policies,omitempty"`
+	Checks     []api.SimulationCheck `json:"checks,omitempty"`
+}
+
 // RESPONSES
 
 type AuthorizeResourcesResponse struct {
 	ResourcesAllowed []string `json:"resourcesAllowed,omitempty"`
 }
 
+type AuthorizeResourcesBatchResponse struct {
+	Results []api.AuthorizationCheckResult `json:"results,omitempty"`
+}
+
+type SimulatePoliciesResponse struct {
+	Results []api.SimulationResult `json:"results,omitempty"`
+}
+
 // HANDLERS
 
 func (wh *WorkerHandler) HandleGetAuthorizedExternalResources(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
@@ -30,6 +56,8 @@ func (wh *WorkerHandler) HandleGetAuthorizedExternalResources(w http.ResponseWri
 		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
 		return
 	}
+	// Let the proxy know the authenticated user of its decisions
+	w.Header().Set(middleware.USER_ID_HEADER, requestInfo.Identifier)
 
 	// Retrieve allowed resources
 	result, err := wh.worker.AuthzApi.GetAuthorizedExternalResources(requestInfo, request.Action, request.Resources)
@@ -38,3 +66,51 @@ func (wh *WorkerHandler) HandleGetAuthorizedExternalResources(w http.ResponseWri
 	}
 	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
 }
+
+func (wh *WorkerHandler) HandleGetAuthorizedExternalResourcesBatch(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	// Process request
+	request := &AuthorizeResourcesBatchRequest{}
+	requestInfo, _, apiErr := wh.processHttpRequest(r, w, nil, request)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+
+	// Retrieve allowed resources per check
+	result, err := wh.worker.AuthzApi.GetAuthorizedExternalResourcesBatch(requestInfo, request.Checks)
+	response := AuthorizeResourcesBatchResponse{
+		Results: result,
+	}
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
+}
+
+func (wh *WorkerHandler) HandleExplainAuthorization(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	// Process request
+	request := &ExplainAuthorizationRequest{}
+	requestInfo, _, apiErr := wh.processHttpRequest(r, w, nil, request)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+
+	// Explain authorization decision
+	response, err := wh.worker.AuthzApi.ExplainAuthorization(requestInfo, request.ExternalID, request.Action, request.Resource)
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
+}
+
+func (wh *WorkerHandler) HandleSimulatePolicies(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	// Process request
+	request := &SimulatePoliciesRequest{}
+	requestInfo, _, apiErr := wh.processHttpRequest(r, w, nil, request)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+
+	// Simulate draft policies
+	result, err := wh.worker.AuthzApi.SimulatePolicies(requestInfo, request.ExternalID, request.Policies, request.Checks)
+	response := SimulatePoliciesResponse{
+		Results: result,
+	}
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
+}
@@ -2,11 +2,15 @@ package http
 
 import (
 	"bytes"
+	"crypto/tls"
 	"encoding/json"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/middleware"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -18,6 +22,7 @@ func TestWorkerHandler_HandleGetAuthorizedExternalResources(t *testing.T) {
 		expectedStatusCode int
 		expectedResponse   AuthorizeResourcesResponse
 		expectedError      api.Error
+		expectedUser       string
 		// Manager Results
 		getAuthorizedExternalResourcesResult []string
 		// Manager Errors
@@ -29,6 +34,7 @@ func TestWorkerHandler_HandleGetAuthorizedExternalResources(t *testing.T) {
 				Action:    api.USER_ACTION_GET_USER,
 			},
 			expectedStatusCode: http.StatusOK,
+			expectedUser:       "userID",
 			expectedResponse: AuthorizeResourcesResponse{
 				ResourcesAllowed: []string{"resource1", "resource2"},
 			},
@@ -62,6 +68,7 @@ func TestWorkerHandler_HandleGetAuthorizedExternalResources(t *testing.T) {
 				Action:    api.USER_ACTION_GET_USER,
 			},
 			expectedStatusCode: http.StatusForbidden,
+			expectedUser:       "userID",
 			expectedError: api.Error{
 				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
 				Message: "Error",
@@ -108,6 +115,9 @@ func TestWorkerHandler_HandleGetAuthorizedExternalResources(t *testing.T) {
 
 		// check status code
 		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+		if test.expectedUser != "" {
+			assert.Equal(t, test.expectedUser, res.Header.Get(middleware.USER_ID_HEADER), "Error in test case %v", n)
+		}
 
 		switch res.StatusCode {
 		case http.StatusOK:
@@ -127,3 +137,552 @@ func TestWorkerHandler_HandleGetAuthorizedExternalResources(t *testing.T) {
 		}
 	}
 }
+
+func TestWorkerHandler_HandleGetAuthorizedExternalResourcesBatch(t *testing.T) {
+	testcases := map[string]struct {
+		// API method args
+		request *AuthorizeResourcesBatchRequest
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   AuthorizeResourcesBatchResponse
+		expectedError      api.Error
+		// Manager Results
+		getAuthorizedExternalResourcesBatchResult []api.AuthorizationCheckResult
+		// Manager Errors
+		getAuthorizedExternalResourcesBatchErr error
+	}{
+		"OkCase": {
+			request: &AuthorizeResourcesBatchRequest{
+				Checks: []api.AuthorizationCheck{
+					{
+						Action:    "example:Read",
+						Resources: []string{"urn:ews:example:instance1:resource/1", "urn:ews:example:instance1:resource/2"},
+					},
+					{
+						Action:    "example:Write",
+						Resources: []string{"urn:ews:example:instance1:resource/1"},
+					},
+				},
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: AuthorizeResourcesBatchResponse{
+				Results: []api.AuthorizationCheckResult{
+					{
+						Action:           "example:Read",
+						ResourcesAllowed: []string{"urn:ews:example:instance1:resource/1"},
+					},
+					{
+						Action:           "example:Write",
+						ResourcesAllowed: []string{},
+					},
+				},
+			},
+			getAuthorizedExternalResourcesBatchResult: []api.AuthorizationCheckResult{
+				{
+					Action:           "example:Read",
+					ResourcesAllowed: []string{"urn:ews:example:instance1:resource/1"},
+				},
+				{
+					Action:           "example:Write",
+					ResourcesAllowed: []string{},
+				},
+			},
+		},
+		"ErrorCaseMalformedRequest": {
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "EOF",
+			},
+		},
+		"ErrorCaseInvalidParameterError": {
+			request: &AuthorizeResourcesBatchRequest{
+				Checks: []api.AuthorizationCheck{
+					{
+						Action:    "example:Read",
+						Resources: []string{"invalid"},
+					},
+				},
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Error",
+			},
+			getAuthorizedExternalResourcesBatchErr: &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			request: &AuthorizeResourcesBatchRequest{
+				Checks: []api.AuthorizationCheck{
+					{
+						Action:    "example:Read",
+						Resources: []string{"urn:ews:example:instance1:resource/1"},
+					},
+				},
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Error",
+			},
+			getAuthorizedExternalResourcesBatchErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			request: &AuthorizeResourcesBatchRequest{
+				Checks: []api.AuthorizationCheck{
+					{
+						Action:    "example:Read",
+						Resources: []string{"urn:ews:example:instance1:resource/1"},
+					},
+				},
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			getAuthorizedExternalResourcesBatchErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[GetAuthorizedExternalResourcesBatchMethod][0] = test.getAuthorizedExternalResourcesBatchResult
+		testApi.ArgsOut[GetAuthorizedExternalResourcesBatchMethod][1] = test.getAuthorizedExternalResourcesBatchErr
+
+		var body *bytes.Buffer
+		if test.request != nil {
+			jsonObject, err := json.Marshal(test.request)
+			assert.Nil(t, err, "Error in test case %v", n)
+			body = bytes.NewBuffer(jsonObject)
+		}
+		if body == nil {
+			body = bytes.NewBuffer([]byte{})
+		}
+		req, err := http.NewRequest(http.MethodPost, server.URL+RESOURCE_BATCH_URL, body)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			// Check received parameters
+			assert.Equal(t, test.request.Checks, testApi.ArgsIn[GetAuthorizedExternalResourcesBatchMethod][1], "Error in test case %v", n)
+			batchResponse := AuthorizeResourcesBatchResponse{}
+			err = json.NewDecoder(res.Body).Decode(&batchResponse)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, batchResponse, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleExplainAuthorization(t *testing.T) {
+	testcases := map[string]struct {
+		// API method args
+		request *ExplainAuthorizationRequest
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   *api.AuthorizationExplanation
+		expectedError      api.Error
+		// Manager Results
+		explainAuthorizationResult *api.AuthorizationExplanation
+		// Manager Errors
+		explainAuthorizationErr error
+	}{
+		"OkCase": {
+			request: &ExplainAuthorizationRequest{
+				ExternalID: "user1",
+				Action:     "example:Read",
+				Resource:   "urn:ews:example:instance1:resource/public",
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: &api.AuthorizationExplanation{
+				ExternalID: "user1",
+				Action:     "example:Read",
+				Resource:   "urn:ews:example:instance1:resource/public",
+				Allowed:    true,
+				Groups: []api.GroupIdentity{
+					{
+						Org:  "example",
+						Name: "group1",
+					},
+				},
+			},
+			explainAuthorizationResult: &api.AuthorizationExplanation{
+				ExternalID: "user1",
+				Action:     "example:Read",
+				Resource:   "urn:ews:example:instance1:resource/public",
+				Allowed:    true,
+				Groups: []api.GroupIdentity{
+					{
+						Org:  "example",
+						Name: "group1",
+					},
+				},
+			},
+		},
+		"ErrorCaseMalformedRequest": {
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "EOF",
+			},
+		},
+		"ErrorCaseInvalidParameter": {
+			request: &ExplainAuthorizationRequest{
+				ExternalID: "user1",
+				Action:     "example:*",
+				Resource:   "urn:ews:example:instance1:resource/public",
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Error",
+			},
+			explainAuthorizationErr: &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseUserNotFound": {
+			request: &ExplainAuthorizationRequest{
+				ExternalID: "user1",
+				Action:     "example:Read",
+				Resource:   "urn:ews:example:instance1:resource/public",
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code:    api.USER_BY_EXTERNAL_ID_NOT_FOUND,
+				Message: "Error",
+			},
+			explainAuthorizationErr: &api.Error{
+				Code:    api.USER_BY_EXTERNAL_ID_NOT_FOUND,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			request: &ExplainAuthorizationRequest{
+				ExternalID: "user1",
+				Action:     "example:Read",
+				Resource:   "urn:ews:example:instance1:resource/public",
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Error",
+			},
+			explainAuthorizationErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			request: &ExplainAuthorizationRequest{
+				ExternalID: "user1",
+				Action:     "example:Read",
+				Resource:   "urn:ews:example:instance1:resource/public",
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			explainAuthorizationErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[ExplainAuthorizationMethod][0] = test.explainAuthorizationResult
+		testApi.ArgsOut[ExplainAuthorizationMethod][1] = test.explainAuthorizationErr
+
+		var body *bytes.Buffer
+		if test.request != nil {
+			jsonObject, err := json.Marshal(test.request)
+			assert.Nil(t, err, "Error in test case %v", n)
+			body = bytes.NewBuffer(jsonObject)
+		}
+		if body == nil {
+			body = bytes.NewBuffer([]byte{})
+		}
+		req, err := http.NewRequest(http.MethodPost, server.URL+AUTHORIZE_EXPLAIN_URL, body)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			// Check received parameters
+			assert.Equal(t, test.request.ExternalID, testApi.ArgsIn[ExplainAuthorizationMethod][1], "Error in test case %v", n)
+			assert.Equal(t, test.request.Action, testApi.ArgsIn[ExplainAuthorizationMethod][2], "Error in test case %v", n)
+			assert.Equal(t, test.request.Resource, testApi.ArgsIn[ExplainAuthorizationMethod][3], "Error in test case %v", n)
+			explanation := &api.AuthorizationExplanation{}
+			err = json.NewDecoder(res.Body).Decode(explanation)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, explanation, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleSimulatePolicies(t *testing.T) {
+	testcases := map[string]struct {
+		// API method args
+		request *SimulatePoliciesRequest
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   SimulatePoliciesResponse
+		expectedError      api.Error
+		// Manager Results
+		simulatePoliciesResult []api.SimulationResult
+		// Manager Errors
+		simulatePoliciesErr error
+	}{
+		"OkCase": {
+			request: &SimulatePoliciesRequest{
+				ExternalID: "user1",
+				Policies: []api.DraftPolicy{
+					{
+						Org:  "example",
+						Name: "policy1",
+						Statements: []api.Statement{
+							{
+								Effect:    "allow",
+								Actions:   []string{"example:Read"},
+								Resources: []string{"urn:ews:example:instance1:resource/*"},
+							},
+						},
+					},
+				},
+				Checks: []api.SimulationCheck{
+					{
+						Action:   "example:Read",
+						Resource: "urn:ews:example:instance1:resource/public",
+					},
+				},
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: SimulatePoliciesResponse{
+				Results: []api.SimulationResult{
+					{
+						Action:   "example:Read",
+						Resource: "urn:ews:example:instance1:resource/public",
+						Before:   false,
+						After:    true,
+					},
+				},
+			},
+			simulatePoliciesResult: []api.SimulationResult{
+				{
+					Action:   "example:Read",
+					Resource: "urn:ews:example:instance1:resource/public",
+					Before:   false,
+					After:    true,
+				},
+			},
+		},
+		"ErrorCaseMalformedRequest": {
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "EOF",
+			},
+		},
+		"ErrorCaseInvalidParameter": {
+			request: &SimulatePoliciesRequest{
+				ExternalID: "user1",
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Error",
+			},
+			simulatePoliciesErr: &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseUserNotFound": {
+			request: &SimulatePoliciesRequest{
+				ExternalID: "user1",
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code:    api.USER_BY_EXTERNAL_ID_NOT_FOUND,
+				Message: "Error",
+			},
+			simulatePoliciesErr: &api.Error{
+				Code:    api.USER_BY_EXTERNAL_ID_NOT_FOUND,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			request: &SimulatePoliciesRequest{
+				ExternalID: "user1",
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Error",
+			},
+			simulatePoliciesErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Error",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			request: &SimulatePoliciesRequest{
+				ExternalID: "user1",
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			simulatePoliciesErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[SimulatePoliciesMethod][0] = test.simulatePoliciesResult
+		testApi.ArgsOut[SimulatePoliciesMethod][1] = test.simulatePoliciesErr
+
+		var body *bytes.Buffer
+		if test.request != nil {
+			jsonObject, err := json.Marshal(test.request)
+			assert.Nil(t, err, "Error in test case %v", n)
+			body = bytes.NewBuffer(jsonObject)
+		}
+		if body == nil {
+			body = bytes.NewBuffer([]byte{})
+		}
+		req, err := http.NewRequest(http.MethodPost, server.URL+SIMULATE_URL, body)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			// Check received parameters
+			assert.Equal(t, test.request.ExternalID, testApi.ArgsIn[SimulatePoliciesMethod][1], "Error in test case %v", n)
+			assert.Equal(t, test.request.Policies, testApi.ArgsIn[SimulatePoliciesMethod][2], "Error in test case %v", n)
+			assert.Equal(t, test.request.Checks, testApi.ArgsIn[SimulatePoliciesMethod][3], "Error in test case %v", n)
+			simulatePoliciesResponse := SimulatePoliciesResponse{}
+			err = json.NewDecoder(res.Body).Decode(&simulatePoliciesResponse)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, simulatePoliciesResponse, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestGetRequestContext(t *testing.T) {
+	_, trustedProxies, _ := net.ParseCIDR("10.1.0.0/16")
+	testcases := map[string]struct {
+		remoteAddr     string
+		tls            bool
+		headers        map[string]string
+		trustedProxies []*net.IPNet
+		// Expected result
+		expectedSourceIP        string
+		expectedSecureTransport bool
+	}{
+		"OkCaseNoProxy": {
+			remoteAddr:       "1.2.3.4:1234",
+			expectedSourceIP: "1.2.3.4",
+		},
+		"OkCaseTLS": {
+			remoteAddr:              "1.2.3.4:1234",
+			tls:                     true,
+			expectedSourceIP:        "1.2.3.4",
+			expectedSecureTransport: true,
+		},
+		"OkCaseUntrustedProxy": {
+			remoteAddr:       "1.2.3.4:1234",
+			headers:          map[string]string{FORWARDED_FOR_HEADER: "10.0.0.1", FORWARDED_PROTO_HEADER: "https"},
+			trustedProxies:   []*net.IPNet{trustedProxies},
+			expectedSourceIP: "1.2.3.4",
+		},
+		"OkCaseTrustedProxy": {
+			remoteAddr:              "10.1.0.1:1234",
+			headers:                 map[string]string{FORWARDED_FOR_HEADER: "10.0.0.1, 1.2.3.4", FORWARDED_PROTO_HEADER: "https"},
+			trustedProxies:          []*net.IPNet{trustedProxies},
+			expectedSourceIP:        "1.2.3.4",
+			expectedSecureTransport: true,
+		},
+		"OkCaseTrustedProxyChain": {
+			remoteAddr:       "10.1.0.1:1234",
+			headers:          map[string]string{FORWARDED_FOR_HEADER: "10.0.0.1, 1.2.3.4, 10.1.0.2", FORWARDED_PROTO_HEADER: "https, http"},
+			trustedProxies:   []*net.IPNet{trustedProxies},
+			expectedSourceIP: "1.2.3.4",
+		},
+		"OkCaseAllTrustedProxies": {
+			remoteAddr:       "10.1.0.1:1234",
+			headers:          map[string]string{FORWARDED_FOR_HEADER: "10.1.0.2"},
+			trustedProxies:   []*net.IPNet{trustedProxies},
+			expectedSourceIP: "10.1.0.2",
+		},
+	}
+
+	for n, test := range testcases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = test.remoteAddr
+		if test.tls {
+			req.TLS = &tls.ConnectionState{}
+		}
+		for k, v := range test.headers {
+			req.Header.Set(k, v)
+		}
+
+		context := getRequestContext(req, test.trustedProxies)
+		assert.Equal(t, test.expectedSourceIP, context.SourceIP, "Error in test case %v", n)
+		assert.Equal(t, test.expectedSecureTransport, context.SecureTransport, "Error in test case %v", n)
+	}
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/Tecsisa/foulkon/api"
 	"github.com/julienschmidt/httprouter"
@@ -19,6 +20,14 @@ type UpdateGroupRequest struct {
 	Path string `json:"path,omitempty"`
 }
 
+type AddMemberRequest struct {
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+type AttachGroupPolicyRequest struct {
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
 // RESPONSES
 
 type ListGroupsResponse struct {
@@ -49,6 +58,13 @@ type ListAttachedGroupPoliciesResponse struct {
 	Total            int                 `json:"total"`
 }
 
+type ListChildGroupsResponse struct {
+	Groups []api.GroupChildren `json:"groups,omitempty"`
+	Limit  int                 `json:"limit"`
+	Offset int                 `json:"offset"`
+	Total  int                 `json:"total"`
+}
+
 // HANDLERS
 
 func (wh *WorkerHandler) HandleAddGroup(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
@@ -144,14 +160,15 @@ func (wh *WorkerHandler) HandleRemoveGroup(w http.ResponseWriter, r *http.Reques
 }
 
 func (wh *WorkerHandler) HandleAddMember(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-	// Process request
-	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	// Process request, body is optional
+	request := &AddMemberRequest{}
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, optionalRequest(r, request))
 	if apiErr != nil {
 		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
 		return
 	}
 	// Call group API to add member to group
-	err := wh.worker.GroupApi.AddMember(requestInfo, filterData.ExternalID, filterData.GroupName, filterData.Org)
+	err := wh.worker.GroupApi.AddMember(requestInfo, filterData.ExternalID, filterData.GroupName, filterData.Org, request.ExpiresAt)
 	wh.processHttpResponse(r, w, requestInfo, nil, err, http.StatusNoContent)
 }
 
@@ -186,14 +203,15 @@ func (wh *WorkerHandler) HandleListMembers(w http.ResponseWriter, r *http.Reques
 }
 
 func (wh *WorkerHandler) HandleAttachPolicyToGroup(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-	// Process request
-	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	// Process request, body is optional
+	request := &AttachGroupPolicyRequest{}
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, optionalRequest(r, request))
 	if apiErr != nil {
 		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
 		return
 	}
 	// Call group API to attach policy to group
-	err := wh.worker.GroupApi.AttachPolicyToGroup(requestInfo, filterData.Org, filterData.GroupName, filterData.PolicyName)
+	err := wh.worker.GroupApi.AttachPolicyToGroup(requestInfo, filterData.Org, filterData.GroupName, filterData.PolicyName, request.ExpiresAt)
 	wh.processHttpResponse(r, w, requestInfo, nil, err, http.StatusNoContent)
 }
 
@@ -227,3 +245,45 @@ func (wh *WorkerHandler) HandleListAttachedGroupPolicies(w http.ResponseWriter,
 	}
 	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
 }
+
+func (wh *WorkerHandler) HandleAddChildGroup(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+	// Call group API to add child group to group
+	err := wh.worker.GroupApi.AddChildGroup(requestInfo, filterData.Org, filterData.GroupName, ps.ByName(CHILD_GROUP_NAME))
+	wh.processHttpResponse(r, w, requestInfo, nil, err, http.StatusNoContent)
+}
+
+func (wh *WorkerHandler) HandleRemoveChildGroup(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+	// Call group API to remove child group from group
+	err := wh.worker.GroupApi.RemoveChildGroup(requestInfo, filterData.Org, filterData.GroupName, ps.ByName(CHILD_GROUP_NAME))
+	wh.processHttpResponse(r, w, requestInfo, nil, err, http.StatusNoContent)
+}
+
+func (wh *WorkerHandler) HandleListChildGroups(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+	// Call group API to list child groups of group
+	result, total, err := wh.worker.GroupApi.ListChildGroups(requestInfo, filterData)
+	response := &ListChildGroupsResponse{
+		Groups: result,
+		Offset: filterData.Offset,
+		Limit:  filterData.Limit,
+		Total:  total,
+	}
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
+}
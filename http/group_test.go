@@ -311,6 +311,7 @@ func TestWorkerHandler_HandleGetGroupByName(t *testing.T) {
 			assert.Nil(t, err, "Error in test case %v", n)
 			// Check result
 			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+			assert.Equal(t, api.EntityTag(test.expectedResponse.UpdateAt), res.Header.Get(ETAG_HEADER), "Error in test case %v", n)
 		case http.StatusInternalServerError: // Empty message so continue
 			continue
 		default:
@@ -913,8 +914,10 @@ func TestWorkerHandler_HandleRemoveGroup(t *testing.T) {
 }
 
 func TestWorkerHandler_HandleAddMember(t *testing.T) {
+	expiresAt := time.Date(2050, 1, 1, 0, 0, 0, 0, time.UTC)
 	testcases := map[string]struct {
 		// API method args
+		request      *AddMemberRequest
 		org          string
 		userID       string
 		groupName    string
@@ -932,6 +935,15 @@ func TestWorkerHandler_HandleAddMember(t *testing.T) {
 			groupName:          "group1",
 			expectedStatusCode: http.StatusNoContent,
 		},
+		"OkCaseWithExpiration": {
+			org:       "org1",
+			userID:    "user1",
+			groupName: "group1",
+			request: &AddMemberRequest{
+				ExpiresAt: &expiresAt,
+			},
+			expectedStatusCode: http.StatusNoContent,
+		},
 		"ErrorCaseInvalidRequest": {
 			org:                "org1",
 			userID:             "user1",
@@ -1036,8 +1048,18 @@ func TestWorkerHandler_HandleAddMember(t *testing.T) {
 
 		testApi.ArgsOut[AddMemberMethod][0] = test.addMemberErr
 
+		var body *bytes.Buffer
+		if test.request != nil {
+			jsonObject, err := json.Marshal(test.request)
+			assert.Nil(t, err, "Error in test case %v", n)
+			body = bytes.NewBuffer(jsonObject)
+		}
+		if body == nil {
+			body = bytes.NewBuffer([]byte{})
+		}
+
 		url := fmt.Sprintf(server.URL+API_VERSION_1+"/organizations/%v/groups/%v/users/%v", test.org, test.groupName, test.userID)
-		req, err := http.NewRequest(http.MethodPost, url, nil)
+		req, err := http.NewRequest(http.MethodPost, url, body)
 		assert.Nil(t, err, "Error in test case %v", n)
 
 		q := req.URL.Query()
@@ -1052,6 +1074,11 @@ func TestWorkerHandler_HandleAddMember(t *testing.T) {
 			assert.Equal(t, test.userID, testApi.ArgsIn[AddMemberMethod][1], "Error in test case %v", n)
 			assert.Equal(t, test.groupName, testApi.ArgsIn[AddMemberMethod][2], "Error in test case %v", n)
 			assert.Equal(t, test.org, testApi.ArgsIn[AddMemberMethod][3], "Error in test case %v", n)
+			if test.request != nil {
+				assert.Equal(t, test.request.ExpiresAt, testApi.ArgsIn[AddMemberMethod][4], "Error in test case %v", n)
+			} else {
+				assert.Nil(t, testApi.ArgsIn[AddMemberMethod][4], "Error in test case %v", n)
+			}
 		}
 
 		// check status code
@@ -1236,6 +1263,7 @@ func TestWorkerHandler_HandleRemoveMember(t *testing.T) {
 
 func TestWorkerHandler_HandleListMembers(t *testing.T) {
 	now := time.Now().UTC()
+	expiresAt := now.Add(time.Hour)
 	testcases := map[string]struct {
 		// API method args
 		filter       *api.Filter
@@ -1263,8 +1291,9 @@ func TestWorkerHandler_HandleListMembers(t *testing.T) {
 						CreateAt: now,
 					},
 					{
-						User:     "member2",
-						CreateAt: now,
+						User:      "member2",
+						CreateAt:  now,
+						ExpiresAt: &expiresAt,
 					},
 				},
 				Offset: 0,
@@ -1277,8 +1306,9 @@ func TestWorkerHandler_HandleListMembers(t *testing.T) {
 					CreateAt: now,
 				},
 				{
-					User:     "member2",
-					CreateAt: now,
+					User:      "member2",
+					CreateAt:  now,
+					ExpiresAt: &expiresAt,
 				},
 			},
 			totalGroupsResult: 2,
@@ -1400,8 +1430,10 @@ func TestWorkerHandler_HandleListMembers(t *testing.T) {
 }
 
 func TestWorkerHandler_HandleAttachPolicyToGroup(t *testing.T) {
+	expiresAt := time.Date(2050, 1, 1, 0, 0, 0, 0, time.UTC)
 	testcases := map[string]struct {
 		// API method args
+		request      *AttachGroupPolicyRequest
 		org          string
 		groupName    string
 		policyName   string
@@ -1419,6 +1451,15 @@ func TestWorkerHandler_HandleAttachPolicyToGroup(t *testing.T) {
 			policyName:         "policy1",
 			expectedStatusCode: http.StatusNoContent,
 		},
+		"OkCaseWithExpiration": {
+			org:        "org1",
+			groupName:  "group1",
+			policyName: "policy1",
+			request: &AttachGroupPolicyRequest{
+				ExpiresAt: &expiresAt,
+			},
+			expectedStatusCode: http.StatusNoContent,
+		},
 		"ErrorCaseInvalidRequest": {
 			org:                "org1",
 			groupName:          "group1",
@@ -1523,8 +1564,18 @@ func TestWorkerHandler_HandleAttachPolicyToGroup(t *testing.T) {
 
 		testApi.ArgsOut[AttachPolicyToGroupMethod][0] = test.attachGroupPolicyErr
 
+		var body *bytes.Buffer
+		if test.request != nil {
+			jsonObject, err := json.Marshal(test.request)
+			assert.Nil(t, err, "Error in test case %v", n)
+			body = bytes.NewBuffer(jsonObject)
+		}
+		if body == nil {
+			body = bytes.NewBuffer([]byte{})
+		}
+
 		url := fmt.Sprintf(server.URL+API_VERSION_1+"/organizations/%v/groups/%v/policies/%v", test.org, test.groupName, test.policyName)
-		req, err := http.NewRequest(http.MethodPost, url, nil)
+		req, err := http.NewRequest(http.MethodPost, url, body)
 		assert.Nil(t, err, "Error in test case %v", n)
 
 		q := req.URL.Query()
@@ -1539,6 +1590,11 @@ func TestWorkerHandler_HandleAttachPolicyToGroup(t *testing.T) {
 			assert.Equal(t, test.org, testApi.ArgsIn[AttachPolicyToGroupMethod][1], "Error in test case %v", n)
 			assert.Equal(t, test.groupName, testApi.ArgsIn[AttachPolicyToGroupMethod][2], "Error in test case %v", n)
 			assert.Equal(t, test.policyName, testApi.ArgsIn[AttachPolicyToGroupMethod][3], "Error in test case %v", n)
+			if test.request != nil {
+				assert.Equal(t, test.request.ExpiresAt, testApi.ArgsIn[AttachPolicyToGroupMethod][4], "Error in test case %v", n)
+			} else {
+				assert.Nil(t, testApi.ArgsIn[AttachPolicyToGroupMethod][4], "Error in test case %v", n)
+			}
 		}
 
 		// check status code
@@ -1880,3 +1936,387 @@ func TestWorkerHandler_HandleListAttachedGroupPolicies(t *testing.T) {
 		}
 	}
 }
+
+func TestWorkerHandler_HandleAddChildGroup(t *testing.T) {
+	testcases := map[string]struct {
+		// API method args
+		org            string
+		groupName      string
+		childGroupName string
+		offset         string
+		ignoreArgsIn   bool
+		// Expected result
+		expectedStatusCode int
+		expectedError      api.Error
+		// Manager Errors
+		addChildGroupErr error
+	}{
+		"OkCase": {
+			org:                "org1",
+			groupName:          "group1",
+			childGroupName:     "group2",
+			expectedStatusCode: http.StatusNoContent,
+		},
+		"ErrorCaseInvalidRequest": {
+			org:                "org1",
+			groupName:          "group1",
+			childGroupName:     "group2",
+			offset:             "-1",
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: Offset -1",
+			},
+		},
+		"ErrorCaseGroupNotFoundErr": {
+			org:                "org1",
+			groupName:          "group1",
+			childGroupName:     "group2",
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code:    api.GROUP_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Group Not Found",
+			},
+			addChildGroupErr: &api.Error{
+				Code:    api.GROUP_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Group Not Found",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			org:                "org1",
+			groupName:          "group1",
+			childGroupName:     "group2",
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			addChildGroupErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseGroupIsAlreadyChildErr": {
+			org:                "org1",
+			groupName:          "group1",
+			childGroupName:     "group2",
+			expectedStatusCode: http.StatusConflict,
+			expectedError: api.Error{
+				Code:    api.GROUP_IS_ALREADY_A_CHILD_OF_GROUP,
+				Message: "Group is already a child of group",
+			},
+			addChildGroupErr: &api.Error{
+				Code:    api.GROUP_IS_ALREADY_A_CHILD_OF_GROUP,
+				Message: "Group is already a child of group",
+			},
+		},
+		"ErrorCaseGroupHierarchyCycleErr": {
+			org:                "org1",
+			groupName:          "group1",
+			childGroupName:     "group2",
+			expectedStatusCode: http.StatusConflict,
+			expectedError: api.Error{
+				Code:    api.GROUP_HIERARCHY_CYCLE,
+				Message: "Cycle",
+			},
+			addChildGroupErr: &api.Error{
+				Code:    api.GROUP_HIERARCHY_CYCLE,
+				Message: "Cycle",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			org:                "org1",
+			groupName:          "group1",
+			childGroupName:     "group2",
+			expectedStatusCode: http.StatusInternalServerError,
+			addChildGroupErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[AddChildGroupMethod][0] = test.addChildGroupErr
+
+		url := fmt.Sprintf(server.URL+API_VERSION_1+"/organizations/%v/groups/%v/groups/%v", test.org, test.groupName, test.childGroupName)
+		req, err := http.NewRequest(http.MethodPost, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		q := req.URL.Query()
+		q.Add("Offset", test.offset)
+		req.URL.RawQuery = q.Encode()
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if !test.ignoreArgsIn {
+			// Check received parameters
+			assert.Equal(t, test.org, testApi.ArgsIn[AddChildGroupMethod][1], "Error in test case %v", n)
+			assert.Equal(t, test.groupName, testApi.ArgsIn[AddChildGroupMethod][2], "Error in test case %v", n)
+			assert.Equal(t, test.childGroupName, testApi.ArgsIn[AddChildGroupMethod][3], "Error in test case %v", n)
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusNoContent:
+			// No message expected
+			continue
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleRemoveChildGroup(t *testing.T) {
+	testcases := map[string]struct {
+		// API method args
+		org            string
+		groupName      string
+		childGroupName string
+		offset         string
+		ignoreArgsIn   bool
+		// Expected result
+		expectedStatusCode int
+		expectedError      api.Error
+		// Manager Errors
+		removeChildGroupErr error
+	}{
+		"OkCase": {
+			org:                "org1",
+			groupName:          "group1",
+			childGroupName:     "group2",
+			expectedStatusCode: http.StatusNoContent,
+		},
+		"ErrorCaseInvalidRequest": {
+			org:                "org1",
+			groupName:          "group1",
+			childGroupName:     "group2",
+			offset:             "-1",
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: Offset -1",
+			},
+		},
+		"ErrorCaseGroupIsNotChildErr": {
+			org:                "org1",
+			groupName:          "group1",
+			childGroupName:     "group2",
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code:    api.GROUP_IS_NOT_A_CHILD_OF_GROUP,
+				Message: "Group is not a child of group",
+			},
+			removeChildGroupErr: &api.Error{
+				Code:    api.GROUP_IS_NOT_A_CHILD_OF_GROUP,
+				Message: "Group is not a child of group",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			org:                "org1",
+			groupName:          "group1",
+			childGroupName:     "group2",
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			removeChildGroupErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			org:                "org1",
+			groupName:          "group1",
+			childGroupName:     "group2",
+			expectedStatusCode: http.StatusInternalServerError,
+			removeChildGroupErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[RemoveChildGroupMethod][0] = test.removeChildGroupErr
+
+		url := fmt.Sprintf(server.URL+API_VERSION_1+"/organizations/%v/groups/%v/groups/%v", test.org, test.groupName, test.childGroupName)
+		req, err := http.NewRequest(http.MethodDelete, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		q := req.URL.Query()
+		q.Add("Offset", test.offset)
+		req.URL.RawQuery = q.Encode()
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if !test.ignoreArgsIn {
+			// Check received parameters
+			assert.Equal(t, test.org, testApi.ArgsIn[RemoveChildGroupMethod][1], "Error in test case %v", n)
+			assert.Equal(t, test.groupName, testApi.ArgsIn[RemoveChildGroupMethod][2], "Error in test case %v", n)
+			assert.Equal(t, test.childGroupName, testApi.ArgsIn[RemoveChildGroupMethod][3], "Error in test case %v", n)
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusNoContent:
+			// No message expected
+			continue
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleListChildGroups(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API method args
+		filter       *api.Filter
+		ignoreArgsIn bool
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   ListChildGroupsResponse
+		expectedError      api.Error
+		// Manager Results
+		listChildGroupsResult []api.GroupChildren
+		totalGroupsResult     int
+		// Manager Errors
+		listChildGroupsErr error
+	}{
+		"OkCase": {
+			filter: &api.Filter{
+				Org:       "org1",
+				GroupName: "group1",
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: ListChildGroupsResponse{
+				Groups: []api.GroupChildren{
+					{
+						Group:    "group2",
+						CreateAt: now,
+					},
+				},
+				Offset: 0,
+				Limit:  0,
+				Total:  1,
+			},
+			listChildGroupsResult: []api.GroupChildren{
+				{
+					Group:    "group2",
+					CreateAt: now,
+				},
+			},
+			totalGroupsResult: 1,
+		},
+		"ErrorCaseInvalidFilterParams": {
+			filter: &api.Filter{
+				PathPrefix: "",
+				Offset:     -1,
+			},
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: Offset -1",
+			},
+		},
+		"ErrorCaseGroupNotFoundErr": {
+			filter: &api.Filter{
+				Org:       "org1",
+				GroupName: "group1",
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code:    api.GROUP_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Group Not Found",
+			},
+			listChildGroupsErr: &api.Error{
+				Code:    api.GROUP_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Group Not Found",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			filter:             testFilter,
+			expectedStatusCode: http.StatusInternalServerError,
+			listChildGroupsErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[ListChildGroupsMethod][0] = test.listChildGroupsResult
+		testApi.ArgsOut[ListChildGroupsMethod][1] = test.totalGroupsResult
+		testApi.ArgsOut[ListChildGroupsMethod][2] = test.listChildGroupsErr
+
+		url := fmt.Sprintf(server.URL+API_VERSION_1+"/organizations/%v/groups/%v/groups", test.filter.Org, test.filter.GroupName)
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		addQueryParams(test.filter, req)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if !test.ignoreArgsIn {
+			// Check received parameter
+			filterData, ok := testApi.ArgsIn[ListChildGroupsMethod][1].(*api.Filter)
+			if ok {
+				// Check result
+				assert.Equal(t, test.filter, filterData, "Error in test case %v", n)
+			}
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			listChildGroupsResponse := ListChildGroupsResponse{}
+			err = json.NewDecoder(res.Body).Decode(&listChildGroupsResponse)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, listChildGroupsResponse, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
@@ -2,7 +2,10 @@ package http
 
 import (
 	"encoding/json"
+	"net"
 	"net/http"
+	"strings"
+	"time"
 
 	"fmt"
 	"strconv"
@@ -16,9 +19,12 @@ const (
 	// Constants for values in url
 	USER_ID             = "userid"
 	GROUP_NAME          = "groupname"
+	CHILD_GROUP_NAME    = "childgroupname"
 	POLICY_NAME         = "policyname"
 	PROXY_RESOURCE_NAME = "proxyresourcename"
 	AUTH_PROVIDER_NAME  = "authprovidername"
+	WEBHOOK_NAME        = "webhookname"
+	UPSTREAM_POOL_NAME  = "upstreampoolname"
 	ORG_NAME            = "orgname"
 
 	// URI Path param prefix
@@ -32,9 +38,11 @@ const (
 	ORG_ROOT = "/organizations/:" + ORG_NAME
 
 	// User API urls
-	USER_ROOT_URL      = API_VERSION_1 + "/users"
-	USER_ID_URL        = USER_ROOT_URL + URI_PATH_PREFIX + USER_ID
-	USER_ID_GROUPS_URL = USER_ID_URL + "/groups"
+	USER_ROOT_URL           = API_VERSION_1 + "/users"
+	USER_ID_URL             = USER_ROOT_URL + URI_PATH_PREFIX + USER_ID
+	USER_ID_GROUPS_URL      = USER_ID_URL + "/groups"
+	USER_ID_POLICIES_URL    = USER_ID_URL + "/policies"
+	USER_ID_POLICIES_ID_URL = USER_ID_POLICIES_URL + URI_PATH_PREFIX + ORG_NAME + URI_PATH_PREFIX + POLICY_NAME
 
 	// Group organization API urls
 	GROUP_ORG_ROOT_URL       = API_VERSION_1 + ORG_ROOT + "/groups"
@@ -43,6 +51,8 @@ const (
 	GROUP_ID_USERS_ID_URL    = GROUP_ID_USERS_URL + URI_PATH_PREFIX + USER_ID
 	GROUP_ID_POLICIES_URL    = GROUP_ID_URL + "/policies"
 	GROUP_ID_POLICIES_ID_URL = GROUP_ID_POLICIES_URL + URI_PATH_PREFIX + POLICY_NAME
+	GROUP_ID_GROUPS_URL      = GROUP_ID_URL + "/groups"
+	GROUP_ID_GROUPS_ID_URL   = GROUP_ID_GROUPS_URL + URI_PATH_PREFIX + CHILD_GROUP_NAME
 
 	// Policy API urls
 	POLICY_ROOT_URL      = API_VERSION_1 + ORG_ROOT + "/policies"
@@ -53,8 +63,15 @@ const (
 	PROXY_RESOURCE_ROOT_URL = API_VERSION_1 + ORG_ROOT + "/proxy-resources"
 	PROXY_RESOURCE_ID_URL   = PROXY_RESOURCE_ROOT_URL + URI_PATH_PREFIX + PROXY_RESOURCE_NAME
 
+	// Upstream pool API urls
+	UPSTREAM_POOL_ROOT_URL = API_VERSION_1 + ORG_ROOT + "/upstream-pools"
+	UPSTREAM_POOL_ID_URL   = UPSTREAM_POOL_ROOT_URL + URI_PATH_PREFIX + UPSTREAM_POOL_NAME
+
 	// Authorization URLs
-	RESOURCE_URL = API_VERSION_1 + "/resource"
+	RESOURCE_URL          = API_VERSION_1 + "/resource"
+	RESOURCE_BATCH_URL    = RESOURCE_URL + "/batch"
+	AUTHORIZE_EXPLAIN_URL = API_VERSION_1 + "/authorize/explain"
+	SIMULATE_URL          = API_VERSION_1 + "/simulate"
 
 	// Admin URLs
 	ADMIN_ROOT = "/admin"
@@ -63,8 +80,31 @@ const (
 	OIDC_AUTH_ROOT_URL = API_VERSION_1 + ADMIN_ROOT + "/auth/oidc/providers"
 	OIDC_AUTH_ID_URL   = OIDC_AUTH_ROOT_URL + URI_PATH_PREFIX + AUTH_PROVIDER_NAME
 
+	// Admin audit log API URLs
+	AUDIT_ROOT_URL = API_VERSION_1 + ADMIN_ROOT + "/audit"
+
+	// Admin webhook API URLs
+	WEBHOOK_ROOT_URL            = API_VERSION_1 + ADMIN_ROOT + "/webhooks"
+	WEBHOOK_ID_URL              = WEBHOOK_ROOT_URL + URI_PATH_PREFIX + WEBHOOK_NAME
+	WEBHOOK_ID_DEAD_LETTERS_URL = WEBHOOK_ID_URL + "/dead-letters"
+
+	// Admin IAM state API URLs
+	EXPORT_STATE_URL = API_VERSION_1 + ADMIN_ROOT + "/export"
+	IMPORT_STATE_URL = API_VERSION_1 + ADMIN_ROOT + "/import"
+
+	// Admin proxy config API URL
+	PROXY_CONFIG_URL = API_VERSION_1 + ADMIN_ROOT + "/proxy-config"
+
 	// Foulkon configuration URL
 	ABOUT = "/about"
+
+	// Headers used to retrieve the original request context
+	FORWARDED_FOR_HEADER   = "X-Forwarded-For"
+	FORWARDED_PROTO_HEADER = "X-Forwarded-Proto"
+
+	// Headers used for optimistic concurrency control
+	ETAG_HEADER     = "ETag"
+	IF_MATCH_HEADER = "If-Match"
 )
 
 // PROXY
@@ -72,6 +112,9 @@ const (
 type ProxyHandler struct {
 	proxy  *foulkon.Proxy
 	client *http.Client
+	cache  *decisionCache
+	// Upstream pools by org and name
+	pools map[string]*upstreamPool
 }
 
 // WORKER
@@ -112,24 +155,33 @@ func (wh *WorkerHandler) processHttpResponse(r *http.Request, w http.ResponseWri
 		switch apiError.Code {
 		case api.USER_ALREADY_EXIST, api.GROUP_ALREADY_EXIST,
 			api.USER_IS_ALREADY_A_MEMBER_OF_GROUP,
+			api.GROUP_IS_ALREADY_A_CHILD_OF_GROUP, api.GROUP_HIERARCHY_CYCLE,
 			api.PROXY_RESOURCE_ALREADY_EXIST,
-			api.POLICY_IS_ALREADY_ATTACHED_TO_GROUP, api.POLICY_ALREADY_EXIST,
+			api.POLICY_IS_ALREADY_ATTACHED_TO_GROUP, api.POLICY_IS_ALREADY_ATTACHED_TO_USER, api.POLICY_ALREADY_EXIST,
 			api.PROXY_RESOURCES_ROUTES_CONFLICT,
-			api.AUTH_OIDC_PROVIDER_ALREADY_EXIST:
+			api.UPSTREAM_POOL_ALREADY_EXIST, api.UPSTREAM_POOL_IN_USE,
+			api.AUTH_OIDC_PROVIDER_ALREADY_EXIST,
+			api.WEBHOOK_ALREADY_EXIST:
 			// A conflict occurs
 			statusCode = http.StatusConflict
 		case api.UNAUTHORIZED_RESOURCES_ERROR:
 			// No authorization success
 			statusCode = http.StatusForbidden
 		case api.USER_BY_EXTERNAL_ID_NOT_FOUND, api.GROUP_BY_ORG_AND_NAME_NOT_FOUND,
-			api.USER_IS_NOT_A_MEMBER_OF_GROUP, api.POLICY_IS_NOT_ATTACHED_TO_GROUP,
+			api.USER_IS_NOT_A_MEMBER_OF_GROUP, api.GROUP_IS_NOT_A_CHILD_OF_GROUP, api.POLICY_IS_NOT_ATTACHED_TO_GROUP,
+			api.POLICY_IS_NOT_ATTACHED_TO_USER,
 			api.POLICY_BY_ORG_AND_NAME_NOT_FOUND, api.PROXY_RESOURCE_BY_ORG_AND_NAME_NOT_FOUND,
-			api.AUTH_OIDC_PROVIDER_BY_NAME_NOT_FOUND:
+			api.UPSTREAM_POOL_BY_ORG_AND_NAME_NOT_FOUND,
+			api.AUTH_OIDC_PROVIDER_BY_NAME_NOT_FOUND,
+			api.WEBHOOK_BY_NAME_NOT_FOUND:
 			// Resource or relation not found
 			statusCode = http.StatusNotFound
 		case api.INVALID_PARAMETER_ERROR, api.REGEX_NO_MATCH:
 			// Unexpected input in validation parameters
 			statusCode = http.StatusBadRequest
+		case api.PRECONDITION_FAILED:
+			// Resource was modified after the version requested
+			statusCode = http.StatusPreconditionFailed
 		default: // Unexpected API error
 			statusCode = http.StatusInternalServerError
 		}
@@ -137,6 +189,11 @@ func (wh *WorkerHandler) processHttpResponse(r *http.Request, w http.ResponseWri
 		return
 	}
 
+	// Identify the version of the resource returned
+	if etag := entityTag(response); etag != "" {
+		w.Header().Set(ETAG_HEADER, etag)
+	}
+
 	// Write response data if everything is ok
 	WriteHttpResponse(r, w, requestInfo.RequestID, requestInfo.Identifier, responseCode, response)
 }
@@ -148,6 +205,8 @@ func (wh *WorkerHandler) getRequestInfo(r *http.Request) api.RequestInfo {
 		Identifier: mc.UserId,
 		Admin:      mc.Admin,
 		RequestID:  mc.XRequestId,
+		Context:    getRequestContext(r, wh.worker.TrustedProxies),
+		IfMatch:    r.Header.Get(IF_MATCH_HEADER),
 	}
 }
 
@@ -168,6 +227,11 @@ func WorkerHandlerRouter(worker *foulkon.Worker) http.Handler {
 
 	router.GET(USER_ID_GROUPS_URL, workerHandler.HandleListGroupsByUser)
 
+	router.GET(USER_ID_POLICIES_URL, workerHandler.HandleListAttachedUserPolicies)
+
+	router.POST(USER_ID_POLICIES_ID_URL, workerHandler.HandleAttachPolicyToUser)
+	router.DELETE(USER_ID_POLICIES_ID_URL, workerHandler.HandleDetachPolicyFromUser)
+
 	// Group api
 	router.POST(GROUP_ORG_ROOT_URL, workerHandler.HandleAddGroup)
 	router.GET(GROUP_ORG_ROOT_URL, workerHandler.HandleListGroups)
@@ -186,6 +250,11 @@ func WorkerHandlerRouter(worker *foulkon.Worker) http.Handler {
 	router.POST(GROUP_ID_POLICIES_ID_URL, workerHandler.HandleAttachPolicyToGroup)
 	router.DELETE(GROUP_ID_POLICIES_ID_URL, workerHandler.HandleDetachPolicyToGroup)
 
+	router.GET(GROUP_ID_GROUPS_URL, workerHandler.HandleListChildGroups)
+
+	router.POST(GROUP_ID_GROUPS_ID_URL, workerHandler.HandleAddChildGroup)
+	router.DELETE(GROUP_ID_GROUPS_ID_URL, workerHandler.HandleRemoveChildGroup)
+
 	// Special endpoint without organization URI for groups
 	router.GET(API_VERSION_1+"/groups", workerHandler.HandleListAllGroups)
 
@@ -212,8 +281,24 @@ func WorkerHandlerRouter(worker *foulkon.Worker) http.Handler {
 	router.GET(PROXY_RESOURCE_ID_URL, workerHandler.HandleGetProxyResourceByName)
 	router.PUT(PROXY_RESOURCE_ID_URL, workerHandler.HandleUpdateProxyResource)
 
+	// Upstream pools api
+	router.GET(UPSTREAM_POOL_ROOT_URL, workerHandler.HandleListUpstreamPools)
+	router.POST(UPSTREAM_POOL_ROOT_URL, workerHandler.HandleAddUpstreamPool)
+
+	router.DELETE(UPSTREAM_POOL_ID_URL, workerHandler.HandleRemoveUpstreamPool)
+
+	router.GET(UPSTREAM_POOL_ID_URL, workerHandler.HandleGetUpstreamPoolByName)
+	router.PUT(UPSTREAM_POOL_ID_URL, workerHandler.HandleUpdateUpstreamPool)
+
 	// Resources authorized endpoint
 	router.POST(RESOURCE_URL, workerHandler.HandleGetAuthorizedExternalResources)
+	router.POST(RESOURCE_BATCH_URL, workerHandler.HandleGetAuthorizedExternalResourcesBatch)
+
+	// Authorization explanation endpoint
+	router.POST(AUTHORIZE_EXPLAIN_URL, workerHandler.HandleExplainAuthorization)
+
+	// Policy simulation endpoint
+	router.POST(SIMULATE_URL, workerHandler.HandleSimulatePolicies)
 
 	// OIDC authentication api
 	router.GET(OIDC_AUTH_ROOT_URL, workerHandler.HandleListOidcProviders)
@@ -224,6 +309,27 @@ func WorkerHandlerRouter(worker *foulkon.Worker) http.Handler {
 	router.GET(OIDC_AUTH_ID_URL, workerHandler.HandleGetOidcProviderByName)
 	router.PUT(OIDC_AUTH_ID_URL, workerHandler.HandleUpdateOidcProvider)
 
+	// Audit log api
+	router.GET(AUDIT_ROOT_URL, workerHandler.HandleListAuditEntries)
+
+	// Webhook api
+	router.GET(WEBHOOK_ROOT_URL, workerHandler.HandleListWebhooks)
+	router.POST(WEBHOOK_ROOT_URL, workerHandler.HandleAddWebhook)
+
+	router.DELETE(WEBHOOK_ID_URL, workerHandler.HandleRemoveWebhook)
+
+	router.GET(WEBHOOK_ID_URL, workerHandler.HandleGetWebhookByName)
+	router.PUT(WEBHOOK_ID_URL, workerHandler.HandleUpdateWebhook)
+
+	router.GET(WEBHOOK_ID_DEAD_LETTERS_URL, workerHandler.HandleListWebhookDeadLetters)
+
+	// IAM state api
+	router.GET(EXPORT_STATE_URL, workerHandler.HandleExportState)
+	router.POST(IMPORT_STATE_URL, workerHandler.HandleImportState)
+
+	// Proxy config api
+	router.GET(PROXY_CONFIG_URL, workerHandler.HandleExportProxyConfig)
+
 	// Current Foulkon configuration
 	router.GET(ABOUT, workerHandler.HandleGetCurrentConfig)
 
@@ -256,6 +362,99 @@ func WriteHttpResponse(r *http.Request, w http.ResponseWriter, requestId string,
 
 // Private Helper Methods
 
+// getRequestContext retrieves request information used to evaluate policy conditions. Source IP and protocol
+// are taken from X-Forwarded-For and X-Forwarded-Proto headers only if the request comes from a trusted proxy.
+// Source IP is then the last address in X-Forwarded-For that isn't a trusted proxy, because previous ones
+// could be sent by the client.
+func getRequestContext(r *http.Request, trustedProxies []*net.IPNet) api.RequestContext {
+	sourceIP := ""
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		sourceIP = host
+	}
+	secureTransport := r.TLS != nil
+	if isTrustedProxy(sourceIP, trustedProxies) {
+		if forwardedFor := r.Header.Get(FORWARDED_FOR_HEADER); forwardedFor != "" {
+			hops := strings.Split(forwardedFor, ",")
+			for i := len(hops) - 1; i >= 0; i-- {
+				sourceIP = strings.TrimSpace(hops[i])
+				if !isTrustedProxy(sourceIP, trustedProxies) {
+					break
+				}
+			}
+		}
+		if forwardedProto := r.Header.Get(FORWARDED_PROTO_HEADER); forwardedProto != "" {
+			protocols := strings.Split(forwardedProto, ",")
+			secureTransport = strings.EqualFold(strings.TrimSpace(protocols[len(protocols)-1]), "https")
+		}
+	}
+	return api.RequestContext{
+		SourceIP:        sourceIP,
+		CurrentTime:     time.Now().UTC(),
+		SecureTransport: secureTransport,
+		UserAgent:       r.UserAgent(),
+		Referer:         r.Referer(),
+		Headers:         r.Header,
+	}
+}
+
+// isTrustedProxy returns true if the address is in any of the trusted proxy networks
+func isTrustedProxy(address string, trustedProxies []*net.IPNet) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// entityTag returns the ETag of the resources that can be updated, or an empty string for other responses
+func entityTag(response interface{}) string {
+	switch resource := response.(type) {
+	case *api.User:
+		if resource != nil {
+			return api.EntityTag(resource.UpdateAt)
+		}
+	case *api.Group:
+		if resource != nil {
+			return api.EntityTag(resource.UpdateAt)
+		}
+	case *api.Policy:
+		if resource != nil {
+			return api.EntityTag(resource.UpdateAt)
+		}
+	case *api.ProxyResource:
+		if resource != nil {
+			return api.EntityTag(resource.UpdateAt)
+		}
+	case *api.UpstreamPool:
+		if resource != nil {
+			return api.EntityTag(resource.UpdateAt)
+		}
+	case *api.OidcProvider:
+		if resource != nil {
+			return api.EntityTag(resource.UpdateAt)
+		}
+	case *api.Webhook:
+		if resource != nil {
+			return api.EntityTag(resource.UpdateAt)
+		}
+	}
+	return ""
+}
+
+// optionalRequest returns the request to decode only if the http request has a body,
+// so endpoints with optional parameters in body also accept empty requests.
+func optionalRequest(r *http.Request, request interface{}) interface{} {
+	if r.ContentLength == 0 {
+		return nil
+	}
+	return request
+}
+
 func getFilterData(r *http.Request, ps httprouter.Params) (*api.Filter, error) {
 	var err error
 	// Retrieve Offset
@@ -297,6 +496,8 @@ func getFilterData(r *http.Request, ps httprouter.Params) (*api.Filter, error) {
 		GroupName:         ps.ByName(GROUP_NAME),
 		ProxyResourceName: ps.ByName(PROXY_RESOURCE_NAME),
 		AuthProviderName:  ps.ByName(AUTH_PROVIDER_NAME),
+		WebhookName:       ps.ByName(WEBHOOK_NAME),
+		UpstreamPoolName:  ps.ByName(UPSTREAM_POOL_NAME),
 		Offset:            offset,
 		Limit:             limit,
 		OrderBy:           r.URL.Query().Get("OrderBy"),
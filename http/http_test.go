@@ -24,12 +24,15 @@ import (
 
 const (
 	// USER API METHODS
-	AddUserMethod             = "AddUser"
-	GetUserByExternalIdMethod = "GetUserByExternalId"
-	ListUsersMethod           = "ListUsers"
-	UpdateUserMethod          = "UpdateUser"
-	RemoveUserMethod          = "RemoveUser"
-	ListGroupsByUserMethod    = "ListGroupsByUser"
+	AddUserMethod                  = "AddUser"
+	GetUserByExternalIdMethod      = "GetUserByExternalId"
+	ListUsersMethod                = "ListUsers"
+	UpdateUserMethod               = "UpdateUser"
+	RemoveUserMethod               = "RemoveUser"
+	ListGroupsByUserMethod         = "ListGroupsByUser"
+	AttachPolicyToUserMethod       = "AttachPolicyToUser"
+	DetachPolicyFromUserMethod     = "DetachPolicyFromUser"
+	ListAttachedUserPoliciesMethod = "ListAttachedUserPolicies"
 
 	// GROUP API METHODS
 	AddGroupMethod                  = "AddGroup"
@@ -43,6 +46,10 @@ const (
 	AttachPolicyToGroupMethod       = "AttachPolicyToGroup"
 	DetachPolicyToGroupMethod       = "DetachPolicyToGroup"
 	ListAttachedGroupPoliciesMethod = "ListAttachedGroupPolicies"
+	AddChildGroupMethod             = "AddChildGroup"
+	RemoveChildGroupMethod          = "RemoveChildGroup"
+	ListChildGroupsMethod           = "ListChildGroups"
+	RemoveExpiredRelationsMethod    = "RemoveExpiredRelations"
 
 	// POLICY API METHODS
 	AddPolicyMethod          = "AddPolicy"
@@ -53,11 +60,14 @@ const (
 	ListAttachedGroupsMethod = "ListAttachedGroups"
 
 	// AUTHZ API
-	GetAuthorizedUsersMethod             = "GetAuthorizedUsers"
-	GetAuthorizedGroupsMethod            = "GetAuthorizedGroups"
-	GetAuthorizedPoliciesMethod          = "GetAuthorizedPolicies"
-	GetAuthorizedExternalResourcesMethod = "GetAuthorizedExternalResources"
-	GetAuthorizedProxyResources          = "GetAuthorizedProxyResources"
+	GetAuthorizedUsersMethod                  = "GetAuthorizedUsers"
+	GetAuthorizedGroupsMethod                 = "GetAuthorizedGroups"
+	GetAuthorizedPoliciesMethod               = "GetAuthorizedPolicies"
+	GetAuthorizedExternalResourcesMethod      = "GetAuthorizedExternalResources"
+	GetAuthorizedExternalResourcesBatchMethod = "GetAuthorizedExternalResourcesBatch"
+	GetAuthorizedProxyResources               = "GetAuthorizedProxyResources"
+	ExplainAuthorizationMethod                = "ExplainAuthorization"
+	SimulatePoliciesMethod                    = "SimulatePolicies"
 
 	// PROXY API
 	AddProxyResourceMethod       = "AddProxyResource"
@@ -66,6 +76,16 @@ const (
 	UpdateProxyResourceMethod    = "UpdateProxyResource"
 	RemoveProxyResourceMethod    = "RemoveProxyResource"
 	ListProxyResourcesMethod     = "ListProxyResources"
+	GetUpstreamPoolsMethod       = "GetUpstreamPools"
+	GetProxyConfigMethod         = "GetProxyConfig"
+	ExportProxyConfigMethod      = "ExportProxyConfig"
+
+	// UPSTREAM POOL API
+	AddUpstreamPoolMethod       = "AddUpstreamPool"
+	GetUpstreamPoolByNameMethod = "GetUpstreamPoolByName"
+	ListUpstreamPoolsMethod     = "ListUpstreamPools"
+	UpdateUpstreamPoolMethod    = "UpdateUpstreamPool"
+	RemoveUpstreamPoolMethod    = "RemoveUpstreamPool"
 
 	// AUTH OIDC PROVIDER API
 	AddOidcProviderMethod       = "AddOidcProvider"
@@ -73,6 +93,21 @@ const (
 	ListOidcProvidersMethod     = "ListOidcProviders"
 	UpdateOidcProviderMethod    = "UpdateOidcProvider"
 	RemoveOidcProviderMethod    = "RemoveOidcProvider"
+
+	// AUDIT API
+	ListAuditEntriesMethod = "ListAuditEntries"
+
+	// WEBHOOK API
+	AddWebhookMethod             = "AddWebhook"
+	GetWebhookByNameMethod       = "GetWebhookByName"
+	ListWebhooksMethod           = "ListWebhooks"
+	UpdateWebhookMethod          = "UpdateWebhook"
+	RemoveWebhookMethod          = "RemoveWebhook"
+	ListWebhookDeadLettersMethod = "ListWebhookDeadLetters"
+
+	// STATE API
+	ExportStateMethod = "ExportState"
+	ImportStateMethod = "ImportState"
 )
 
 // Test server used to test handlers
@@ -197,6 +232,11 @@ func TestMain(m *testing.M) {
 		AuthzApi:          testApi,
 		ProxyApi:          testApi,
 		AuthOidcAPI:       testApi,
+		AuditAPI:          testApi,
+		UpstreamPoolAPI:   testApi,
+		WebhookAPI:        testApi,
+		StateAPI:          testApi,
+		AuthzCache:        api.NewAuthzCache(time.Minute, 100),
 		Config:            config,
 	}
 
@@ -230,16 +270,23 @@ func makeTestApi() *TestAPI {
 	testApi.ArgsIn[UpdateUserMethod] = make([]interface{}, 3)
 	testApi.ArgsIn[RemoveUserMethod] = make([]interface{}, 2)
 	testApi.ArgsIn[ListGroupsByUserMethod] = make([]interface{}, 2)
+	testApi.ArgsIn[AttachPolicyToUserMethod] = make([]interface{}, 4)
+	testApi.ArgsIn[DetachPolicyFromUserMethod] = make([]interface{}, 4)
+	testApi.ArgsIn[ListAttachedUserPoliciesMethod] = make([]interface{}, 2)
 
 	testApi.ArgsIn[AddGroupMethod] = make([]interface{}, 4)
 	testApi.ArgsIn[GetGroupByNameMethod] = make([]interface{}, 3)
 	testApi.ArgsIn[ListGroupsMethod] = make([]interface{}, 2)
 	testApi.ArgsIn[UpdateGroupMethod] = make([]interface{}, 5)
 	testApi.ArgsIn[RemoveGroupMethod] = make([]interface{}, 3)
-	testApi.ArgsIn[AddMemberMethod] = make([]interface{}, 4)
+	testApi.ArgsIn[AddMemberMethod] = make([]interface{}, 5)
 	testApi.ArgsIn[RemoveMemberMethod] = make([]interface{}, 4)
 	testApi.ArgsIn[ListMembersMethod] = make([]interface{}, 2)
-	testApi.ArgsIn[AttachPolicyToGroupMethod] = make([]interface{}, 4)
+	testApi.ArgsIn[AddChildGroupMethod] = make([]interface{}, 4)
+	testApi.ArgsIn[RemoveChildGroupMethod] = make([]interface{}, 4)
+	testApi.ArgsIn[ListChildGroupsMethod] = make([]interface{}, 2)
+	testApi.ArgsIn[RemoveExpiredRelationsMethod] = make([]interface{}, 0)
+	testApi.ArgsIn[AttachPolicyToGroupMethod] = make([]interface{}, 5)
 	testApi.ArgsIn[DetachPolicyToGroupMethod] = make([]interface{}, 4)
 	testApi.ArgsIn[ListAttachedGroupPoliciesMethod] = make([]interface{}, 2)
 
@@ -254,7 +301,10 @@ func makeTestApi() *TestAPI {
 	testApi.ArgsIn[GetAuthorizedGroupsMethod] = make([]interface{}, 4)
 	testApi.ArgsIn[GetAuthorizedPoliciesMethod] = make([]interface{}, 4)
 	testApi.ArgsIn[GetAuthorizedExternalResourcesMethod] = make([]interface{}, 3)
+	testApi.ArgsIn[GetAuthorizedExternalResourcesBatchMethod] = make([]interface{}, 2)
 	testApi.ArgsIn[GetAuthorizedProxyResources] = make([]interface{}, 4)
+	testApi.ArgsIn[ExplainAuthorizationMethod] = make([]interface{}, 4)
+	testApi.ArgsIn[SimulatePoliciesMethod] = make([]interface{}, 4)
 
 	testApi.ArgsIn[AddProxyResourceMethod] = make([]interface{}, 5)
 	testApi.ArgsIn[GetProxyResourceByNameMethod] = make([]interface{}, 3)
@@ -262,12 +312,29 @@ func makeTestApi() *TestAPI {
 	testApi.ArgsIn[UpdateProxyResourceMethod] = make([]interface{}, 6)
 	testApi.ArgsIn[RemoveProxyResourceMethod] = make([]interface{}, 3)
 	testApi.ArgsIn[ListProxyResourcesMethod] = make([]interface{}, 3)
+	testApi.ArgsIn[GetUpstreamPoolsMethod] = make([]interface{}, 0)
+	testApi.ArgsIn[ExportProxyConfigMethod] = make([]interface{}, 2)
+
+	testApi.ArgsIn[AddUpstreamPoolMethod] = make([]interface{}, 5)
+	testApi.ArgsIn[GetUpstreamPoolByNameMethod] = make([]interface{}, 3)
+	testApi.ArgsIn[ListUpstreamPoolsMethod] = make([]interface{}, 2)
+	testApi.ArgsIn[UpdateUpstreamPoolMethod] = make([]interface{}, 6)
+	testApi.ArgsIn[RemoveUpstreamPoolMethod] = make([]interface{}, 3)
 
 	testApi.ArgsIn[AddOidcProviderMethod] = make([]interface{}, 5)
 	testApi.ArgsIn[GetOidcProviderByNameMethod] = make([]interface{}, 2)
 	testApi.ArgsIn[ListOidcProvidersMethod] = make([]interface{}, 2)
+	testApi.ArgsIn[ListAuditEntriesMethod] = make([]interface{}, 2)
 	testApi.ArgsIn[UpdateOidcProviderMethod] = make([]interface{}, 6)
 	testApi.ArgsIn[RemoveOidcProviderMethod] = make([]interface{}, 2)
+	testApi.ArgsIn[AddWebhookMethod] = make([]interface{}, 6)
+	testApi.ArgsIn[GetWebhookByNameMethod] = make([]interface{}, 2)
+	testApi.ArgsIn[ListWebhooksMethod] = make([]interface{}, 2)
+	testApi.ArgsIn[UpdateWebhookMethod] = make([]interface{}, 7)
+	testApi.ArgsIn[RemoveWebhookMethod] = make([]interface{}, 2)
+	testApi.ArgsIn[ListWebhookDeadLettersMethod] = make([]interface{}, 2)
+	testApi.ArgsIn[ExportStateMethod] = make([]interface{}, 2)
+	testApi.ArgsIn[ImportStateMethod] = make([]interface{}, 4)
 
 	testApi.ArgsOut[AddUserMethod] = make([]interface{}, 2)
 	testApi.ArgsOut[GetUserByExternalIdMethod] = make([]interface{}, 2)
@@ -275,6 +342,9 @@ func makeTestApi() *TestAPI {
 	testApi.ArgsOut[UpdateUserMethod] = make([]interface{}, 2)
 	testApi.ArgsOut[RemoveUserMethod] = make([]interface{}, 1)
 	testApi.ArgsOut[ListGroupsByUserMethod] = make([]interface{}, 3)
+	testApi.ArgsOut[AttachPolicyToUserMethod] = make([]interface{}, 1)
+	testApi.ArgsOut[DetachPolicyFromUserMethod] = make([]interface{}, 1)
+	testApi.ArgsOut[ListAttachedUserPoliciesMethod] = make([]interface{}, 3)
 
 	testApi.ArgsOut[AddGroupMethod] = make([]interface{}, 2)
 	testApi.ArgsOut[GetGroupByNameMethod] = make([]interface{}, 2)
@@ -284,6 +354,10 @@ func makeTestApi() *TestAPI {
 	testApi.ArgsOut[AddMemberMethod] = make([]interface{}, 1)
 	testApi.ArgsOut[RemoveMemberMethod] = make([]interface{}, 1)
 	testApi.ArgsOut[ListMembersMethod] = make([]interface{}, 3)
+	testApi.ArgsOut[AddChildGroupMethod] = make([]interface{}, 1)
+	testApi.ArgsOut[RemoveChildGroupMethod] = make([]interface{}, 1)
+	testApi.ArgsOut[ListChildGroupsMethod] = make([]interface{}, 3)
+	testApi.ArgsOut[RemoveExpiredRelationsMethod] = make([]interface{}, 1)
 	testApi.ArgsOut[AttachPolicyToGroupMethod] = make([]interface{}, 1)
 	testApi.ArgsOut[DetachPolicyToGroupMethod] = make([]interface{}, 1)
 	testApi.ArgsOut[ListAttachedGroupPoliciesMethod] = make([]interface{}, 3)
@@ -299,7 +373,10 @@ func makeTestApi() *TestAPI {
 	testApi.ArgsOut[GetAuthorizedGroupsMethod] = make([]interface{}, 2)
 	testApi.ArgsOut[GetAuthorizedPoliciesMethod] = make([]interface{}, 2)
 	testApi.ArgsOut[GetAuthorizedExternalResourcesMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[GetAuthorizedExternalResourcesBatchMethod] = make([]interface{}, 2)
 	testApi.ArgsOut[GetAuthorizedProxyResources] = make([]interface{}, 2)
+	testApi.ArgsOut[ExplainAuthorizationMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[SimulatePoliciesMethod] = make([]interface{}, 2)
 
 	testApi.ArgsOut[AddProxyResourceMethod] = make([]interface{}, 2)
 	testApi.ArgsOut[GetProxyResourceByNameMethod] = make([]interface{}, 2)
@@ -307,12 +384,30 @@ func makeTestApi() *TestAPI {
 	testApi.ArgsOut[UpdateProxyResourceMethod] = make([]interface{}, 2)
 	testApi.ArgsOut[RemoveProxyResourceMethod] = make([]interface{}, 1)
 	testApi.ArgsOut[ListProxyResourcesMethod] = make([]interface{}, 3)
+	testApi.ArgsOut[GetUpstreamPoolsMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[GetProxyConfigMethod] = make([]interface{}, 1)
+	testApi.ArgsOut[ExportProxyConfigMethod] = make([]interface{}, 2)
+
+	testApi.ArgsOut[AddUpstreamPoolMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[GetUpstreamPoolByNameMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[ListUpstreamPoolsMethod] = make([]interface{}, 3)
+	testApi.ArgsOut[UpdateUpstreamPoolMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[RemoveUpstreamPoolMethod] = make([]interface{}, 1)
 
 	testApi.ArgsOut[AddOidcProviderMethod] = make([]interface{}, 2)
 	testApi.ArgsOut[GetOidcProviderByNameMethod] = make([]interface{}, 2)
 	testApi.ArgsOut[ListOidcProvidersMethod] = make([]interface{}, 3)
+	testApi.ArgsOut[ListAuditEntriesMethod] = make([]interface{}, 3)
 	testApi.ArgsOut[UpdateOidcProviderMethod] = make([]interface{}, 2)
 	testApi.ArgsOut[RemoveOidcProviderMethod] = make([]interface{}, 1)
+	testApi.ArgsOut[AddWebhookMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[GetWebhookByNameMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[ListWebhooksMethod] = make([]interface{}, 3)
+	testApi.ArgsOut[UpdateWebhookMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[RemoveWebhookMethod] = make([]interface{}, 1)
+	testApi.ArgsOut[ListWebhookDeadLettersMethod] = make([]interface{}, 3)
+	testApi.ArgsOut[ExportStateMethod] = make([]interface{}, 2)
+	testApi.ArgsOut[ImportStateMethod] = make([]interface{}, 2)
 
 	return testApi
 }
@@ -410,6 +505,48 @@ func (t TestAPI) ListGroupsByUser(authenticatedUser api.RequestInfo, filter *api
 	return groups, total, err
 }
 
+func (t TestAPI) AttachPolicyToUser(authenticatedUser api.RequestInfo, externalId string, org string, policyName string) error {
+	t.ArgsIn[AttachPolicyToUserMethod][0] = authenticatedUser
+	t.ArgsIn[AttachPolicyToUserMethod][1] = externalId
+	t.ArgsIn[AttachPolicyToUserMethod][2] = org
+	t.ArgsIn[AttachPolicyToUserMethod][3] = policyName
+	var err error
+	if t.ArgsOut[AttachPolicyToUserMethod][0] != nil {
+		err = t.ArgsOut[AttachPolicyToUserMethod][0].(error)
+	}
+	return err
+}
+
+func (t TestAPI) DetachPolicyFromUser(authenticatedUser api.RequestInfo, externalId string, org string, policyName string) error {
+	t.ArgsIn[DetachPolicyFromUserMethod][0] = authenticatedUser
+	t.ArgsIn[DetachPolicyFromUserMethod][1] = externalId
+	t.ArgsIn[DetachPolicyFromUserMethod][2] = org
+	t.ArgsIn[DetachPolicyFromUserMethod][3] = policyName
+	var err error
+	if t.ArgsOut[DetachPolicyFromUserMethod][0] != nil {
+		err = t.ArgsOut[DetachPolicyFromUserMethod][0].(error)
+	}
+	return err
+}
+
+func (t TestAPI) ListAttachedUserPolicies(authenticatedUser api.RequestInfo, filter *api.Filter) ([]api.UserPolicies, int, error) {
+	t.ArgsIn[ListAttachedUserPoliciesMethod][0] = authenticatedUser
+	t.ArgsIn[ListAttachedUserPoliciesMethod][1] = filter
+	var policies []api.UserPolicies
+	var total int
+	if t.ArgsOut[ListAttachedUserPoliciesMethod][1] != nil {
+		total = t.ArgsOut[ListAttachedUserPoliciesMethod][1].(int)
+	}
+	if t.ArgsOut[ListAttachedUserPoliciesMethod][0] != nil {
+		policies = t.ArgsOut[ListAttachedUserPoliciesMethod][0].([]api.UserPolicies)
+	}
+	var err error
+	if t.ArgsOut[ListAttachedUserPoliciesMethod][2] != nil {
+		err = t.ArgsOut[ListAttachedUserPoliciesMethod][2].(error)
+	}
+	return policies, total, err
+}
+
 // GROUP API
 
 func (t TestAPI) AddGroup(authenticatedUser api.RequestInfo, org string, name string, path string) (*api.Group, error) {
@@ -490,11 +627,12 @@ func (t TestAPI) RemoveGroup(authenticatedUser api.RequestInfo, org string, name
 	return err
 }
 
-func (t TestAPI) AddMember(authenticatedUser api.RequestInfo, userID string, groupName string, org string) error {
+func (t TestAPI) AddMember(authenticatedUser api.RequestInfo, userID string, groupName string, org string, expiresAt *time.Time) error {
 	t.ArgsIn[AddMemberMethod][0] = authenticatedUser
 	t.ArgsIn[AddMemberMethod][1] = userID
 	t.ArgsIn[AddMemberMethod][2] = groupName
 	t.ArgsIn[AddMemberMethod][3] = org
+	t.ArgsIn[AddMemberMethod][4] = expiresAt
 	var err error
 	if t.ArgsOut[AddMemberMethod][0] != nil {
 		err = t.ArgsOut[AddMemberMethod][0].(error)
@@ -533,11 +671,63 @@ func (t TestAPI) ListMembers(authenticatedUser api.RequestInfo, filter *api.Filt
 	return externalIDs, total, err
 }
 
-func (t TestAPI) AttachPolicyToGroup(authenticatedUser api.RequestInfo, org string, groupName string, policyName string) error {
+func (t TestAPI) AddChildGroup(authenticatedUser api.RequestInfo, org string, groupName string, childGroupName string) error {
+	t.ArgsIn[AddChildGroupMethod][0] = authenticatedUser
+	t.ArgsIn[AddChildGroupMethod][1] = org
+	t.ArgsIn[AddChildGroupMethod][2] = groupName
+	t.ArgsIn[AddChildGroupMethod][3] = childGroupName
+	var err error
+	if t.ArgsOut[AddChildGroupMethod][0] != nil {
+		err = t.ArgsOut[AddChildGroupMethod][0].(error)
+	}
+	return err
+}
+
+func (t TestAPI) RemoveChildGroup(authenticatedUser api.RequestInfo, org string, groupName string, childGroupName string) error {
+	t.ArgsIn[RemoveChildGroupMethod][0] = authenticatedUser
+	t.ArgsIn[RemoveChildGroupMethod][1] = org
+	t.ArgsIn[RemoveChildGroupMethod][2] = groupName
+	t.ArgsIn[RemoveChildGroupMethod][3] = childGroupName
+	var err error
+	if t.ArgsOut[RemoveChildGroupMethod][0] != nil {
+		err = t.ArgsOut[RemoveChildGroupMethod][0].(error)
+	}
+	return err
+}
+
+func (t TestAPI) ListChildGroups(authenticatedUser api.RequestInfo, filter *api.Filter) ([]api.GroupChildren, int, error) {
+	t.ArgsIn[ListChildGroupsMethod][0] = authenticatedUser
+	t.ArgsIn[ListChildGroupsMethod][1] = filter
+
+	var children []api.GroupChildren
+	var total int
+	if t.ArgsOut[ListChildGroupsMethod][1] != nil {
+		total = t.ArgsOut[ListChildGroupsMethod][1].(int)
+	}
+	if t.ArgsOut[ListChildGroupsMethod][0] != nil {
+		children = t.ArgsOut[ListChildGroupsMethod][0].([]api.GroupChildren)
+	}
+	var err error
+	if t.ArgsOut[ListChildGroupsMethod][2] != nil {
+		err = t.ArgsOut[ListChildGroupsMethod][2].(error)
+	}
+	return children, total, err
+}
+
+func (t TestAPI) RemoveExpiredRelations() error {
+	var err error
+	if t.ArgsOut[RemoveExpiredRelationsMethod][0] != nil {
+		err = t.ArgsOut[RemoveExpiredRelationsMethod][0].(error)
+	}
+	return err
+}
+
+func (t TestAPI) AttachPolicyToGroup(authenticatedUser api.RequestInfo, org string, groupName string, policyName string, expiresAt *time.Time) error {
 	t.ArgsIn[AttachPolicyToGroupMethod][0] = authenticatedUser
 	t.ArgsIn[AttachPolicyToGroupMethod][1] = org
 	t.ArgsIn[AttachPolicyToGroupMethod][2] = groupName
 	t.ArgsIn[AttachPolicyToGroupMethod][3] = policyName
+	t.ArgsIn[AttachPolicyToGroupMethod][4] = expiresAt
 	var err error
 	if t.ArgsOut[AttachPolicyToGroupMethod][0] != nil {
 		err = t.ArgsOut[AttachPolicyToGroupMethod][0].(error)
@@ -708,10 +898,56 @@ func (t TestAPI) GetAuthorizedExternalResources(authenticatedUser api.RequestInf
 	return resourcesToReturn, err
 }
 
+func (t TestAPI) GetAuthorizedExternalResourcesBatch(authenticatedUser api.RequestInfo, checks []api.AuthorizationCheck) ([]api.AuthorizationCheckResult, error) {
+	t.ArgsIn[GetAuthorizedExternalResourcesBatchMethod][0] = authenticatedUser
+	t.ArgsIn[GetAuthorizedExternalResourcesBatchMethod][1] = checks
+	var results []api.AuthorizationCheckResult
+	if t.ArgsOut[GetAuthorizedExternalResourcesBatchMethod][0] != nil {
+		results = t.ArgsOut[GetAuthorizedExternalResourcesBatchMethod][0].([]api.AuthorizationCheckResult)
+	}
+	var err error
+	if t.ArgsOut[GetAuthorizedExternalResourcesBatchMethod][1] != nil {
+		err = t.ArgsOut[GetAuthorizedExternalResourcesBatchMethod][1].(error)
+	}
+	return results, err
+}
+
 func (t TestAPI) GetAuthorizedProxyResources(authenticatedUser api.RequestInfo, resourceUrn string, action string, proxyResources []api.ProxyResource) ([]api.ProxyResource, error) {
 	return nil, nil
 }
 
+func (t TestAPI) ExplainAuthorization(authenticatedUser api.RequestInfo, externalID string, action string, resource string) (*api.AuthorizationExplanation, error) {
+	t.ArgsIn[ExplainAuthorizationMethod][0] = authenticatedUser
+	t.ArgsIn[ExplainAuthorizationMethod][1] = externalID
+	t.ArgsIn[ExplainAuthorizationMethod][2] = action
+	t.ArgsIn[ExplainAuthorizationMethod][3] = resource
+	var explanation *api.AuthorizationExplanation
+	if t.ArgsOut[ExplainAuthorizationMethod][0] != nil {
+		explanation = t.ArgsOut[ExplainAuthorizationMethod][0].(*api.AuthorizationExplanation)
+	}
+	var err error
+	if t.ArgsOut[ExplainAuthorizationMethod][1] != nil {
+		err = t.ArgsOut[ExplainAuthorizationMethod][1].(error)
+	}
+	return explanation, err
+}
+
+func (t TestAPI) SimulatePolicies(authenticatedUser api.RequestInfo, externalID string, drafts []api.DraftPolicy, checks []api.SimulationCheck) ([]api.SimulationResult, error) {
+	t.ArgsIn[SimulatePoliciesMethod][0] = authenticatedUser
+	t.ArgsIn[SimulatePoliciesMethod][1] = externalID
+	t.ArgsIn[SimulatePoliciesMethod][2] = drafts
+	t.ArgsIn[SimulatePoliciesMethod][3] = checks
+	var results []api.SimulationResult
+	if t.ArgsOut[SimulatePoliciesMethod][0] != nil {
+		results = t.ArgsOut[SimulatePoliciesMethod][0].([]api.SimulationResult)
+	}
+	var err error
+	if t.ArgsOut[SimulatePoliciesMethod][1] != nil {
+		err = t.ArgsOut[SimulatePoliciesMethod][1].(error)
+	}
+	return results, err
+}
+
 // PROXY API
 func (t TestAPI) AddProxyResource(authenticatedUser api.RequestInfo, name string, org string, path string, resource api.ResourceEntity) (*api.ProxyResource, error) {
 	t.ArgsIn[AddProxyResourceMethod][0] = authenticatedUser
@@ -809,6 +1045,144 @@ func (t TestAPI) RemoveProxyResource(authenticatedUser api.RequestInfo, org stri
 	return err
 }
 
+func (t TestAPI) GetUpstreamPools() ([]api.UpstreamPool, error) {
+	defer t.resourceLock.Unlock()
+	t.resourceLock.Lock()
+	var pools []api.UpstreamPool
+	if t.ArgsOut[GetUpstreamPoolsMethod][0] != nil {
+		pools = t.ArgsOut[GetUpstreamPoolsMethod][0].([]api.UpstreamPool)
+	}
+	var err error
+	if t.ArgsOut[GetUpstreamPoolsMethod][1] != nil {
+		err = t.ArgsOut[GetUpstreamPoolsMethod][1].(error)
+	}
+	return pools, err
+}
+
+// GetProxyConfig returns the resources and pools of their methods, with revision 1 unless other is set
+func (t TestAPI) GetProxyConfig(revision int64) (*api.ProxyConfig, error) {
+	t.resourceLock.Lock()
+	currentRevision := int64(1)
+	if t.ArgsOut[GetProxyConfigMethod][0] != nil {
+		currentRevision = t.ArgsOut[GetProxyConfigMethod][0].(int64)
+	}
+	t.resourceLock.Unlock()
+	if currentRevision == revision {
+		return nil, nil
+	}
+
+	resources, err := t.GetProxyResources()
+	if err != nil {
+		return nil, err
+	}
+	pools, err := t.GetUpstreamPools()
+	if err != nil {
+		return nil, err
+	}
+	return &api.ProxyConfig{
+		Revision:      currentRevision,
+		Resources:     resources,
+		UpstreamPools: pools,
+	}, nil
+}
+
+func (t TestAPI) ExportProxyConfig(requestInfo api.RequestInfo, revision int64) (*api.ProxyConfig, error) {
+	t.ArgsIn[ExportProxyConfigMethod][0] = requestInfo
+	t.ArgsIn[ExportProxyConfigMethod][1] = revision
+
+	var config *api.ProxyConfig
+	if t.ArgsOut[ExportProxyConfigMethod][0] != nil {
+		config = t.ArgsOut[ExportProxyConfigMethod][0].(*api.ProxyConfig)
+	}
+	var err error
+	if t.ArgsOut[ExportProxyConfigMethod][1] != nil {
+		err = t.ArgsOut[ExportProxyConfigMethod][1].(error)
+	}
+	return config, err
+}
+
+func (t TestAPI) AddUpstreamPool(requestInfo api.RequestInfo, name string, org string, path string, config api.UpstreamPoolConfig) (*api.UpstreamPool, error) {
+	t.ArgsIn[AddUpstreamPoolMethod][0] = requestInfo
+	t.ArgsIn[AddUpstreamPoolMethod][1] = name
+	t.ArgsIn[AddUpstreamPoolMethod][2] = org
+	t.ArgsIn[AddUpstreamPoolMethod][3] = path
+	t.ArgsIn[AddUpstreamPoolMethod][4] = config
+	var pool *api.UpstreamPool
+	if t.ArgsOut[AddUpstreamPoolMethod][0] != nil {
+		pool = t.ArgsOut[AddUpstreamPoolMethod][0].(*api.UpstreamPool)
+	}
+	var err error
+	if t.ArgsOut[AddUpstreamPoolMethod][1] != nil {
+		err = t.ArgsOut[AddUpstreamPoolMethod][1].(error)
+	}
+	return pool, err
+}
+
+func (t TestAPI) GetUpstreamPoolByName(requestInfo api.RequestInfo, org string, name string) (*api.UpstreamPool, error) {
+	t.ArgsIn[GetUpstreamPoolByNameMethod][0] = requestInfo
+	t.ArgsIn[GetUpstreamPoolByNameMethod][1] = org
+	t.ArgsIn[GetUpstreamPoolByNameMethod][2] = name
+	var pool *api.UpstreamPool
+	if t.ArgsOut[GetUpstreamPoolByNameMethod][0] != nil {
+		pool = t.ArgsOut[GetUpstreamPoolByNameMethod][0].(*api.UpstreamPool)
+	}
+	var err error
+	if t.ArgsOut[GetUpstreamPoolByNameMethod][1] != nil {
+		err = t.ArgsOut[GetUpstreamPoolByNameMethod][1].(error)
+	}
+	return pool, err
+}
+
+func (t TestAPI) ListUpstreamPools(requestInfo api.RequestInfo, filter *api.Filter) ([]api.UpstreamPoolIdentity, int, error) {
+	t.ArgsIn[ListUpstreamPoolsMethod][0] = requestInfo
+	t.ArgsIn[ListUpstreamPoolsMethod][1] = filter
+
+	var pools []api.UpstreamPoolIdentity
+	if t.ArgsOut[ListUpstreamPoolsMethod][0] != nil {
+		pools = t.ArgsOut[ListUpstreamPoolsMethod][0].([]api.UpstreamPoolIdentity)
+	}
+	var total int
+	if t.ArgsOut[ListUpstreamPoolsMethod][1] != nil {
+		total = t.ArgsOut[ListUpstreamPoolsMethod][1].(int)
+	}
+	var err error
+	if t.ArgsOut[ListUpstreamPoolsMethod][2] != nil {
+		err = t.ArgsOut[ListUpstreamPoolsMethod][2].(error)
+	}
+	return pools, total, err
+}
+
+func (t TestAPI) UpdateUpstreamPool(requestInfo api.RequestInfo, org string, name string, newName string, newPath string,
+	newConfig api.UpstreamPoolConfig) (*api.UpstreamPool, error) {
+	t.ArgsIn[UpdateUpstreamPoolMethod][0] = requestInfo
+	t.ArgsIn[UpdateUpstreamPoolMethod][1] = org
+	t.ArgsIn[UpdateUpstreamPoolMethod][2] = name
+	t.ArgsIn[UpdateUpstreamPoolMethod][3] = newName
+	t.ArgsIn[UpdateUpstreamPoolMethod][4] = newPath
+	t.ArgsIn[UpdateUpstreamPoolMethod][5] = newConfig
+
+	var pool *api.UpstreamPool
+	if t.ArgsOut[UpdateUpstreamPoolMethod][0] != nil {
+		pool = t.ArgsOut[UpdateUpstreamPoolMethod][0].(*api.UpstreamPool)
+	}
+	var err error
+	if t.ArgsOut[UpdateUpstreamPoolMethod][1] != nil {
+		err = t.ArgsOut[UpdateUpstreamPoolMethod][1].(error)
+	}
+	return pool, err
+}
+
+func (t TestAPI) RemoveUpstreamPool(requestInfo api.RequestInfo, org string, name string) error {
+	t.ArgsIn[RemoveUpstreamPoolMethod][0] = requestInfo
+	t.ArgsIn[RemoveUpstreamPoolMethod][1] = org
+	t.ArgsIn[RemoveUpstreamPoolMethod][2] = name
+	var err error
+	if t.ArgsOut[RemoveUpstreamPoolMethod][0] != nil {
+		err = t.ArgsOut[RemoveUpstreamPoolMethod][0].(error)
+	}
+	return err
+}
+
 func (t TestAPI) AddOidcProvider(requestInfo api.RequestInfo, name string, path string, issuerURL string, oidcClients []string) (*api.OidcProvider, error) {
 	t.ArgsIn[AddOidcProviderMethod][0] = requestInfo
 	t.ArgsIn[AddOidcProviderMethod][1] = name
@@ -890,6 +1264,161 @@ func (t TestAPI) RemoveOidcProvider(requestInfo api.RequestInfo, name string) er
 	return err
 }
 
+// AUDIT API
+func (t TestAPI) ListAuditEntries(requestInfo api.RequestInfo, filter *api.Filter) ([]api.AuditEntry, int, error) {
+	t.ArgsIn[ListAuditEntriesMethod][0] = requestInfo
+	t.ArgsIn[ListAuditEntriesMethod][1] = filter
+
+	var entries []api.AuditEntry
+	var total int
+	if t.ArgsOut[ListAuditEntriesMethod][1] != nil {
+		total = t.ArgsOut[ListAuditEntriesMethod][1].(int)
+	}
+	if t.ArgsOut[ListAuditEntriesMethod][0] != nil {
+		entries = t.ArgsOut[ListAuditEntriesMethod][0].([]api.AuditEntry)
+	}
+	var err error
+	if t.ArgsOut[ListAuditEntriesMethod][2] != nil {
+		err = t.ArgsOut[ListAuditEntriesMethod][2].(error)
+	}
+	return entries, total, err
+}
+
+// WEBHOOK API
+func (t TestAPI) AddWebhook(requestInfo api.RequestInfo, name string, path string, url string, secret string, events []string) (*api.Webhook, error) {
+	t.ArgsIn[AddWebhookMethod][0] = requestInfo
+	t.ArgsIn[AddWebhookMethod][1] = name
+	t.ArgsIn[AddWebhookMethod][2] = path
+	t.ArgsIn[AddWebhookMethod][3] = url
+	t.ArgsIn[AddWebhookMethod][4] = secret
+	t.ArgsIn[AddWebhookMethod][5] = events
+	var webhook *api.Webhook
+	if t.ArgsOut[AddWebhookMethod][0] != nil {
+		webhook = t.ArgsOut[AddWebhookMethod][0].(*api.Webhook)
+	}
+	var err error
+	if t.ArgsOut[AddWebhookMethod][1] != nil {
+		err = t.ArgsOut[AddWebhookMethod][1].(error)
+	}
+	return webhook, err
+}
+
+func (t TestAPI) GetWebhookByName(requestInfo api.RequestInfo, name string) (*api.Webhook, error) {
+	t.ArgsIn[GetWebhookByNameMethod][0] = requestInfo
+	t.ArgsIn[GetWebhookByNameMethod][1] = name
+	var webhook *api.Webhook
+	if t.ArgsOut[GetWebhookByNameMethod][0] != nil {
+		webhook = t.ArgsOut[GetWebhookByNameMethod][0].(*api.Webhook)
+	}
+	var err error
+	if t.ArgsOut[GetWebhookByNameMethod][1] != nil {
+		err = t.ArgsOut[GetWebhookByNameMethod][1].(error)
+	}
+	return webhook, err
+}
+
+func (t TestAPI) ListWebhooks(requestInfo api.RequestInfo, filter *api.Filter) ([]string, int, error) {
+	t.ArgsIn[ListWebhooksMethod][0] = requestInfo
+	t.ArgsIn[ListWebhooksMethod][1] = filter
+
+	var webhooks []string
+	if t.ArgsOut[ListWebhooksMethod][0] != nil {
+		webhooks = t.ArgsOut[ListWebhooksMethod][0].([]string)
+	}
+	var total int
+	if t.ArgsOut[ListWebhooksMethod][1] != nil {
+		total = t.ArgsOut[ListWebhooksMethod][1].(int)
+	}
+	var err error
+	if t.ArgsOut[ListWebhooksMethod][2] != nil {
+		err = t.ArgsOut[ListWebhooksMethod][2].(error)
+	}
+	return webhooks, total, err
+}
+
+func (t TestAPI) UpdateWebhook(requestInfo api.RequestInfo, name string, newName string, newPath string, newURL string,
+	newSecret string, newEvents []string) (*api.Webhook, error) {
+	t.ArgsIn[UpdateWebhookMethod][0] = requestInfo
+	t.ArgsIn[UpdateWebhookMethod][1] = name
+	t.ArgsIn[UpdateWebhookMethod][2] = newName
+	t.ArgsIn[UpdateWebhookMethod][3] = newPath
+	t.ArgsIn[UpdateWebhookMethod][4] = newURL
+	t.ArgsIn[UpdateWebhookMethod][5] = newSecret
+	t.ArgsIn[UpdateWebhookMethod][6] = newEvents
+
+	var webhook *api.Webhook
+	if t.ArgsOut[UpdateWebhookMethod][0] != nil {
+		webhook = t.ArgsOut[UpdateWebhookMethod][0].(*api.Webhook)
+	}
+	var err error
+	if t.ArgsOut[UpdateWebhookMethod][1] != nil {
+		err = t.ArgsOut[UpdateWebhookMethod][1].(error)
+	}
+	return webhook, err
+}
+
+func (t TestAPI) RemoveWebhook(requestInfo api.RequestInfo, name string) error {
+	t.ArgsIn[RemoveWebhookMethod][0] = requestInfo
+	t.ArgsIn[RemoveWebhookMethod][1] = name
+	var err error
+	if t.ArgsOut[RemoveWebhookMethod][0] != nil {
+		err = t.ArgsOut[RemoveWebhookMethod][0].(error)
+	}
+	return err
+}
+
+func (t TestAPI) ListWebhookDeadLetters(requestInfo api.RequestInfo, filter *api.Filter) ([]api.WebhookDeadLetter, int, error) {
+	t.ArgsIn[ListWebhookDeadLettersMethod][0] = requestInfo
+	t.ArgsIn[ListWebhookDeadLettersMethod][1] = filter
+
+	var deadLetters []api.WebhookDeadLetter
+	if t.ArgsOut[ListWebhookDeadLettersMethod][0] != nil {
+		deadLetters = t.ArgsOut[ListWebhookDeadLettersMethod][0].([]api.WebhookDeadLetter)
+	}
+	var total int
+	if t.ArgsOut[ListWebhookDeadLettersMethod][1] != nil {
+		total = t.ArgsOut[ListWebhookDeadLettersMethod][1].(int)
+	}
+	var err error
+	if t.ArgsOut[ListWebhookDeadLettersMethod][2] != nil {
+		err = t.ArgsOut[ListWebhookDeadLettersMethod][2].(error)
+	}
+	return deadLetters, total, err
+}
+
+// STATE API
+func (t TestAPI) ExportState(requestInfo api.RequestInfo, org string) (*api.State, error) {
+	t.ArgsIn[ExportStateMethod][0] = requestInfo
+	t.ArgsIn[ExportStateMethod][1] = org
+
+	var state *api.State
+	if t.ArgsOut[ExportStateMethod][0] != nil {
+		state = t.ArgsOut[ExportStateMethod][0].(*api.State)
+	}
+	var err error
+	if t.ArgsOut[ExportStateMethod][1] != nil {
+		err = t.ArgsOut[ExportStateMethod][1].(error)
+	}
+	return state, err
+}
+
+func (t TestAPI) ImportState(requestInfo api.RequestInfo, state api.State, mode string, dryRun bool) (*api.ImportResult, error) {
+	t.ArgsIn[ImportStateMethod][0] = requestInfo
+	t.ArgsIn[ImportStateMethod][1] = state
+	t.ArgsIn[ImportStateMethod][2] = mode
+	t.ArgsIn[ImportStateMethod][3] = dryRun
+
+	var result *api.ImportResult
+	if t.ArgsOut[ImportStateMethod][0] != nil {
+		result = t.ArgsOut[ImportStateMethod][0].(*api.ImportResult)
+	}
+	var err error
+	if t.ArgsOut[ImportStateMethod][1] != nil {
+		err = t.ArgsOut[ImportStateMethod][1].(error)
+	}
+	return result, err
+}
+
 // Private helper methods
 
 func addQueryParams(filter *api.Filter, r *http.Request) {
@@ -407,6 +407,7 @@ func TestWorkerHandler_HandleGetPolicyByName(t *testing.T) {
 			assert.Nil(t, err, "Error in test case %v", n)
 			// Check result
 			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+			assert.Equal(t, api.EntityTag(test.expectedResponse.UpdateAt), res.Header.Get(ETAG_HEADER), "Error in test case %v", n)
 		case http.StatusInternalServerError: // Empty message so continue
 			continue
 		default:
@@ -732,6 +733,7 @@ func TestWorkerHandler_HandleUpdatePolicy(t *testing.T) {
 	testcases := map[string]struct {
 		// API method args
 		org     string
+		ifMatch string
 		request *UpdatePolicyRequest
 		// Expected result
 		expectedStatusCode int
@@ -850,6 +852,21 @@ func TestWorkerHandler_HandleUpdatePolicy(t *testing.T) {
 				Message: "Policy already exist",
 			},
 		},
+		"ErrorCasePreconditionFailed": {
+			org:     "org1",
+			ifMatch: "\"1\"",
+			request: &UpdatePolicyRequest{
+				Name: "policy1",
+				Path: "path1",
+			},
+			updatePolicyErr: &api.Error{
+				Code: api.PRECONDITION_FAILED,
+			},
+			expectedStatusCode: http.StatusPreconditionFailed,
+			expectedError: api.Error{
+				Code: api.PRECONDITION_FAILED,
+			},
+		},
 		"ErrorCaseInvalidParameterError": {
 			org: "org1",
 			request: &UpdatePolicyRequest{
@@ -947,6 +964,7 @@ func TestWorkerHandler_HandleUpdatePolicy(t *testing.T) {
 		url := fmt.Sprintf(server.URL+API_VERSION_1+"/organizations/%v/policies/policy1", test.org)
 		req, err := http.NewRequest(http.MethodPut, url, body)
 		assert.Nil(t, err, "Error in test case %v", n)
+		req.Header.Set(IF_MATCH_HEADER, test.ifMatch)
 
 		res, err := client.Do(req)
 		assert.Nil(t, err, "Error in test case %v", n)
@@ -958,6 +976,7 @@ func TestWorkerHandler_HandleUpdatePolicy(t *testing.T) {
 			assert.Equal(t, test.request.Name, testApi.ArgsIn[UpdatePolicyMethod][3], "Error in test case %v", n)
 			assert.Equal(t, test.request.Path, testApi.ArgsIn[UpdatePolicyMethod][4], "Error in test case %v", n)
 			assert.Equal(t, test.request.Statements, testApi.ArgsIn[UpdatePolicyMethod][5], "Error in test case %v", n)
+			assert.Equal(t, test.ifMatch, testApi.ArgsIn[UpdatePolicyMethod][0].(api.RequestInfo).IfMatch, "Error in test case %v", n)
 		}
 
 		// check status code
@@ -970,6 +989,7 @@ func TestWorkerHandler_HandleUpdatePolicy(t *testing.T) {
 			assert.Nil(t, err, "Error in test case %v", n)
 			// Check result
 			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+			assert.Equal(t, api.EntityTag(test.expectedResponse.UpdateAt), res.Header.Get(ETAG_HEADER), "Error in test case %v", n)
 		case http.StatusInternalServerError: // Empty message so continue
 			continue
 		default:
@@ -988,6 +1008,7 @@ func TestWorkerHandler_HandleRemovePolicy(t *testing.T) {
 		org          string
 		policyName   string
 		offset       string
+		ifMatch      string
 		ignoreArgsIn bool
 		// Expected result
 		expectedStatusCode int
@@ -1026,6 +1047,18 @@ func TestWorkerHandler_HandleRemovePolicy(t *testing.T) {
 				Code: api.POLICY_BY_ORG_AND_NAME_NOT_FOUND,
 			},
 		},
+		"ErrorCasePreconditionFailed": {
+			org:        "org1",
+			policyName: "p1",
+			ifMatch:    "\"1\"",
+			deletePolicyErr: &api.Error{
+				Code: api.PRECONDITION_FAILED,
+			},
+			expectedStatusCode: http.StatusPreconditionFailed,
+			expectedError: api.Error{
+				Code: api.PRECONDITION_FAILED,
+			},
+		},
 		"ErrorCaseInvalidParam": {
 			org:        "org1",
 			policyName: "p1",
@@ -1070,6 +1103,7 @@ func TestWorkerHandler_HandleRemovePolicy(t *testing.T) {
 		url := fmt.Sprintf(server.URL+API_VERSION_1+"/organizations/%v/policies/%v", test.org, test.policyName)
 		req, err := http.NewRequest(http.MethodDelete, url, nil)
 		assert.Nil(t, err, "Error in test case %v", n)
+		req.Header.Set(IF_MATCH_HEADER, test.ifMatch)
 
 		q := req.URL.Query()
 		q.Add("Offset", test.offset)
@@ -1082,6 +1116,7 @@ func TestWorkerHandler_HandleRemovePolicy(t *testing.T) {
 			// Check received parameters
 			assert.Equal(t, test.org, testApi.ArgsIn[RemovePolicyMethod][1], "Error in test case %v", n)
 			assert.Equal(t, test.policyName, testApi.ArgsIn[RemovePolicyMethod][2], "Error in test case %v", n)
+			assert.Equal(t, test.ifMatch, testApi.ArgsIn[RemovePolicyMethod][0].(api.RequestInfo).IfMatch, "Error in test case %v", n)
 		}
 
 		// check status code
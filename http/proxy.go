@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/Tecsisa/foulkon/api"
 	"github.com/Tecsisa/foulkon/middleware"
@@ -24,6 +26,7 @@ const (
 	INTERNAL_SERVER_ERROR = "InternalServerError"
 	BAD_REQUEST           = "BadRequest"
 	FORBIDDEN_ERROR       = "ForbiddenError"
+	UPSTREAM_UNAVAILABLE  = "UpstreamUnavailableError"
 )
 
 // REQUESTS
@@ -65,7 +68,15 @@ func (ph *ProxyHandler) HandleRequest(proxyResource api.ProxyResource) httproute
 		for _, p := range parameters {
 			urn = strings.Replace(urn, p[0], ps.ByName(p[1]), -1)
 		}
-		if workerRequestID, err := ph.checkAuthorization(r, urn, proxyResource.Resource.Action); err == nil {
+		start := time.Now()
+		workerRequestID, user, cached, err := ph.authorize(r, urn, proxyResource.Resource.Action)
+		ph.recordDecision(requestID, workerRequestID, user, urn, proxyResource.Resource.Action, cached, err, start)
+		if err == nil {
+			// Requests to an upstream pool are balanced between its targets
+			if proxyResource.Resource.Upstream != "" {
+				ph.serveUpstreamPool(w, r, proxyResource, requestID, workerRequestID, cached)
+				return
+			}
 			destURL, err := url.Parse(proxyResource.Resource.Host)
 			if err != nil {
 				apiErr := getErrorMessage(INVALID_DEST_HOST_URL, fmt.Sprintf("Error creating destination host URL: %v", err.Error()))
@@ -74,7 +85,7 @@ func (ph *ProxyHandler) HandleRequest(proxyResource api.ProxyResource) httproute
 				return
 			}
 			// Log request
-			api.TransactionProxyLog(requestID, workerRequestID, r, "Request accepted")
+			logAcceptedRequest(requestID, workerRequestID, r, cached)
 			// Serve Request
 			reverseProxy := httputil.NewSingleHostReverseProxy(destURL)
 			logWritter := api.Log.Writer()
@@ -98,6 +109,9 @@ func (ph *ProxyHandler) HandleRequest(proxyResource api.ProxyResource) httproute
 				responseErr = getErrorMessage(INTERNAL_SERVER_ERROR, "Internal server error. Contact the administrator")
 			}
 			WriteHttpResponse(r, w, requestID, "", statusCode, responseErr)
+			if cached {
+				api.TransactionProxyLog(requestID, workerRequestID, r, "Request denied from cached decision")
+			}
 			api.TransactionProxyErrorLogWithStatus(requestID, workerRequestID, r, statusCode, apiError)
 			return
 		}
@@ -181,17 +195,81 @@ func (wh *WorkerHandler) HandleRemoveProxyResource(w http.ResponseWriter, r *htt
 	wh.processHttpResponse(r, w, requestInfo, nil, err, http.StatusNoContent)
 }
 
-func (ph *ProxyHandler) checkAuthorization(r *http.Request, urn string, action string) (string, error) {
+// serveUpstreamPool proxies an accepted request to the upstream pool of the proxy resource
+func (ph *ProxyHandler) serveUpstreamPool(w http.ResponseWriter, r *http.Request, proxyResource api.ProxyResource,
+	requestID string, workerRequestID string, cached bool) {
+	pool, ok := ph.pools[upstreamPoolKey(proxyResource.Org, proxyResource.Resource.Upstream)]
+	if !ok {
+		apiErr := getErrorMessage(UPSTREAM_UNAVAILABLE, fmt.Sprintf("Upstream pool with org %v and name %v not found",
+			proxyResource.Org, proxyResource.Resource.Upstream))
+		api.TransactionProxyErrorLogWithStatus(requestID, workerRequestID, r, http.StatusBadGateway, apiErr)
+		WriteHttpResponse(r, w, requestID, "", http.StatusBadGateway, getErrorMessage(UPSTREAM_UNAVAILABLE, "Upstream unavailable"))
+		return
+	}
+	logAcceptedRequest(requestID, workerRequestID, r, cached)
+	pool.ServeHTTP(w, r)
+}
+
+// authorize returns the cached decision for the request if there is one, or checks authorization
+// with the worker otherwise. It also returns true if the decision was cached.
+func (ph *ProxyHandler) authorize(r *http.Request, urn string, action string) (string, string, bool, error) {
+	key, cacheable := ph.cache.key(r, urn, action)
+	if cacheable {
+		if decision, ok := ph.cache.get(key); ok {
+			return decision.workerRequestID, decision.user, true, decision.err
+		}
+	}
+
+	workerRequestID, user, err := ph.checkAuthorization(r, urn, action)
+	if cacheable {
+		ph.cache.set(key, workerRequestID, user, err)
+	}
+	return workerRequestID, user, false, err
+}
+
+// recordDecision records the decision made for a request since start, if the worker allowed or forbade it.
+// The user is the one authenticated by the worker, empty if the request wasn't authenticated.
+func (ph *ProxyHandler) recordDecision(requestID string, workerRequestID string, user string, urn string, action string,
+	cached bool, err error, start time.Time) {
+	if ph.proxy.DecisionLog == nil {
+		return
+	}
+	allowedUrns := []string{urn}
+	if err != nil {
+		if apiError, ok := err.(*api.Error); !ok || apiError.Code != FORBIDDEN_ERROR {
+			return
+		}
+		allowedUrns = nil
+	}
+	ph.proxy.DecisionLog.Record(api.Decision{
+		Time:            start.UTC(),
+		Source:          api.DECISION_SOURCE_PROXY,
+		RequestID:       requestID,
+		WorkerRequestID: workerRequestID,
+		User:            user,
+		Action:          action,
+		RequestedUrns:   []string{urn},
+		AllowedUrns:     allowedUrns,
+		Allowed:         err == nil,
+		Cached:          cached,
+		Latency:         time.Since(start),
+	})
+}
+
+// checkAuthorization asks the worker to authorize the request, returning the worker request ID and the user
+// authenticated by the worker
+func (ph *ProxyHandler) checkAuthorization(r *http.Request, urn string, action string) (string, string, error) {
 	workerRequestID := "None"
+	user := ""
 	if !isFullUrn(urn) {
-		return workerRequestID,
+		return workerRequestID, user,
 			getErrorMessage(api.INVALID_PARAMETER_ERROR, fmt.Sprintf("Urn %v is a prefix, it would be a full urn resource", urn))
 	}
 	if err := api.AreValidResources([]string{urn}, api.RESOURCE_EXTERNAL); err != nil {
-		return workerRequestID, err
+		return workerRequestID, user, err
 	}
 	if err := api.AreValidActions([]string{action}); err != nil {
-		return workerRequestID, err
+		return workerRequestID, user, err
 	}
 
 	body, err := json.Marshal(AuthorizeResourcesRequest{
@@ -199,37 +277,51 @@ func (ph *ProxyHandler) checkAuthorization(r *http.Request, urn string, action s
 		Resources: []string{urn},
 	})
 	if err != nil {
-		return workerRequestID, getErrorMessage(api.UNKNOWN_API_ERROR, err.Error())
+		return workerRequestID, user, getErrorMessage(api.UNKNOWN_API_ERROR, err.Error())
 	}
 
 	req, err := http.NewRequest(http.MethodPost, ph.proxy.WorkerHost+RESOURCE_URL, bytes.NewBuffer(body))
 	if err != nil {
-		return workerRequestID, getErrorMessage(api.UNKNOWN_API_ERROR, err.Error())
+		return workerRequestID, user, getErrorMessage(api.UNKNOWN_API_ERROR, err.Error())
 	}
 	// Add all headers from original request
-	req.Header = r.Header
+	for k, v := range r.Header {
+		req.Header[k] = v
+	}
+	// Add original request context to evaluate policy conditions. Values sent by the client are replaced,
+	// so they can't be spoofed
+	req.Header.Del(FORWARDED_FOR_HEADER)
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		req.Header.Set(FORWARDED_FOR_HEADER, host)
+	}
+	if r.TLS != nil {
+		req.Header.Set(FORWARDED_PROTO_HEADER, "https")
+	} else {
+		req.Header.Set(FORWARDED_PROTO_HEADER, "http")
+	}
 	// Call worker to retrieve authorization
 	res, err := ph.client.Do(req)
 	if err != nil {
-		return workerRequestID, getErrorMessage(HOST_UNREACHABLE, err.Error())
+		return workerRequestID, user, getErrorMessage(HOST_UNREACHABLE, err.Error())
 	}
 
 	defer res.Body.Close()
 
 	workerRequestID = res.Header.Get(middleware.REQUEST_ID_HEADER)
+	user = res.Header.Get(middleware.USER_ID_HEADER)
 
 	switch res.StatusCode {
 	case http.StatusUnauthorized:
-		return workerRequestID, getErrorMessage(FORBIDDEN_ERROR, "Unauthenticated user")
+		return workerRequestID, user, getErrorMessage(FORBIDDEN_ERROR, "Unauthenticated user")
 	case http.StatusForbidden:
-		return workerRequestID, getErrorMessage(FORBIDDEN_ERROR, fmt.Sprintf("Restricted access to urn %v", urn))
+		return workerRequestID, user, getErrorMessage(FORBIDDEN_ERROR, fmt.Sprintf("Restricted access to urn %v", urn))
 	case http.StatusBadRequest:
-		return workerRequestID, getErrorMessage(BAD_REQUEST, "Invalid request")
+		return workerRequestID, user, getErrorMessage(BAD_REQUEST, "Invalid request")
 	case http.StatusOK:
 		authzResponse := AuthorizeResourcesResponse{}
 		err = json.NewDecoder(res.Body).Decode(&authzResponse)
 		if err != nil {
-			return workerRequestID, getErrorMessage(api.UNKNOWN_API_ERROR, fmt.Sprintf("Error parsing foulkon response %v", err.Error()))
+			return workerRequestID, user, getErrorMessage(api.UNKNOWN_API_ERROR, fmt.Sprintf("Error parsing foulkon response %v", err.Error()))
 		}
 
 		// Check urns allowed to find target urn
@@ -242,17 +334,25 @@ func (ph *ProxyHandler) checkAuthorization(r *http.Request, urn string, action s
 		}
 
 		if !allowed {
-			return workerRequestID,
+			return workerRequestID, user,
 				getErrorMessage(FORBIDDEN_ERROR, fmt.Sprintf("No access for urn %v received from server", urn))
 		}
 
-		return workerRequestID, nil
+		return workerRequestID, user, nil
 	default:
-		return workerRequestID,
+		return workerRequestID, user,
 			getErrorMessage(INTERNAL_SERVER_ERROR, fmt.Sprintf("There was a problem retrieving authorization, status code %v", res.StatusCode))
 	}
 }
 
+func logAcceptedRequest(requestID string, workerRequestID string, r *http.Request, cached bool) {
+	if cached {
+		api.TransactionProxyLog(requestID, workerRequestID, r, "Request accepted from cached decision")
+	} else {
+		api.TransactionProxyLog(requestID, workerRequestID, r, "Request accepted")
+	}
+}
+
 // Check parameters in URN to replace with URI parameters
 func getUrnParameters(urn string) [][]string {
 	match := rUrnParam.FindAllStringSubmatch(urn, -1)
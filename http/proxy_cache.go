@@ -0,0 +1,139 @@
+package http
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/foulkon"
+)
+
+// decisionCache is a size-bounded cache of the authorization decisions received from the worker. Decisions
+// are cached by authenticated identity, action, urn and the request context used to evaluate policy conditions,
+// with only the request headers configured as evaluated by them, during positiveTTL if the request was allowed
+// or negativeTTL if it was forbidden. A nil cache is a disabled cache.
+type decisionCache struct {
+	positiveTTL time.Duration
+	negativeTTL time.Duration
+	size        int
+	// Request header with the authenticated identity, in addition to Authorization header
+	identityHeader string
+	// Request headers evaluated by policy conditions, in canonical form
+	headers []string
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type cachedDecision struct {
+	key             string
+	workerRequestID string
+	// User authenticated by the worker
+	user string
+	// Error returned by the worker, nil if the request was allowed
+	err       error
+	expiresAt time.Time
+}
+
+// newDecisionCache returns the decision cache configured in proxy, or nil if it is disabled
+func newDecisionCache(proxy *foulkon.Proxy) *decisionCache {
+	if proxy.DecisionCacheSize <= 0 || (proxy.DecisionCachePositiveTTL <= 0 && proxy.DecisionCacheNegativeTTL <= 0) {
+		return nil
+	}
+	return &decisionCache{
+		positiveTTL:    proxy.DecisionCachePositiveTTL,
+		negativeTTL:    proxy.DecisionCacheNegativeTTL,
+		size:           proxy.DecisionCacheSize,
+		identityHeader: proxy.DecisionCacheIdentityHeader,
+		headers:        proxy.DecisionCacheHeaders,
+		entries:        make(map[string]*list.Element),
+		lru:            list.New(),
+	}
+}
+
+// key returns the cache key for a request, and false if the request has no identity to cache its decision
+func (c *decisionCache) key(r *http.Request, urn string, action string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	identity := []string{r.Header.Get("Authorization")}
+	if c.identityHeader != "" {
+		identity = append(identity, r.Header.Get(c.identityHeader))
+	}
+	if strings.Join(identity, "") == "" {
+		return "", false
+	}
+
+	// Other request headers don't change the decision unless policy conditions evaluate them
+	context := getRequestContext(r, nil)
+	values := append(identity, action, urn, context.SourceIP, strconv.FormatBool(context.SecureTransport),
+		context.UserAgent, context.Referer)
+	for _, name := range c.headers {
+		values = append(values, name+":"+strings.Join(r.Header[name], ","))
+	}
+	hash := sha256.Sum256([]byte(strings.Join(values, "\x00")))
+	return hex.EncodeToString(hash[:]), true
+}
+
+// get returns the decision cached for a key if it hasn't expired
+func (c *decisionCache) get(key string) (*cachedDecision, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	decision := element.Value.(*cachedDecision)
+	if !time.Now().UTC().Before(decision.expiresAt) {
+		c.lru.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	return decision, true
+}
+
+// set caches the decision received from the worker. Only allowed and forbidden requests are cached,
+// and the least recently used decision is evicted when the cache is full.
+func (c *decisionCache) set(key string, workerRequestID string, user string, err error) {
+	ttl := c.positiveTTL
+	if err != nil {
+		if apiError, ok := err.(*api.Error); !ok || apiError.Code != FORBIDDEN_ERROR {
+			return
+		}
+		ttl = c.negativeTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	decision := &cachedDecision{
+		key:             key,
+		workerRequestID: workerRequestID,
+		user:            user,
+		err:             err,
+		expiresAt:       time.Now().UTC().Add(ttl),
+	}
+	if element, ok := c.entries[key]; ok {
+		element.Value = decision
+		c.lru.MoveToFront(element)
+		return
+	}
+	if c.lru.Len() >= c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedDecision).key)
+	}
+	c.entries[key] = c.lru.PushFront(decision)
+}
@@ -0,0 +1,378 @@
+package http
+
+import (
+	"container/list"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/foulkon"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDecisionCache(t *testing.T) {
+	testcases := map[string]struct {
+		proxy *foulkon.Proxy
+		// Expected result
+		expectedNil bool
+	}{
+		"OkCase": {
+			proxy: &foulkon.Proxy{
+				DecisionCacheSize:        10,
+				DecisionCachePositiveTTL: time.Minute,
+			},
+		},
+		"OkCaseZeroSize": {
+			proxy: &foulkon.Proxy{
+				DecisionCachePositiveTTL: time.Minute,
+				DecisionCacheNegativeTTL: time.Minute,
+			},
+			expectedNil: true,
+		},
+		"OkCaseZeroTTLs": {
+			proxy: &foulkon.Proxy{
+				DecisionCacheSize: 10,
+			},
+			expectedNil: true,
+		},
+	}
+
+	for n, test := range testcases {
+		cache := newDecisionCache(test.proxy)
+		assert.Equal(t, test.expectedNil, cache == nil, "Error in test case %v", n)
+	}
+}
+
+func TestDecisionCache_Key(t *testing.T) {
+	testcases := map[string]struct {
+		identityHeader   string
+		conditionHeaders []string
+		// Client addresses and headers of both requests
+		remoteAddr      string
+		otherRemoteAddr string
+		headers         map[string]string
+		otherHeaders    map[string]string
+		// Expected result
+		expectedCacheable bool
+		expectedSameKey   bool
+	}{
+		"OkCaseSameIdentity": {
+			headers:           map[string]string{"Authorization": "Bearer token1"},
+			otherHeaders:      map[string]string{"Authorization": "Bearer token1"},
+			expectedCacheable: true,
+			expectedSameKey:   true,
+		},
+		"OkCaseDifferentIdentity": {
+			headers:           map[string]string{"Authorization": "Bearer token1"},
+			otherHeaders:      map[string]string{"Authorization": "Bearer token2"},
+			expectedCacheable: true,
+		},
+		"OkCaseDifferentIdentityHeader": {
+			identityHeader:    "X-Remote-User",
+			headers:           map[string]string{"X-Remote-User": "user1"},
+			otherHeaders:      map[string]string{"X-Remote-User": "user2"},
+			expectedCacheable: true,
+		},
+		"OkCaseDifferentSourceIP": {
+			remoteAddr:        "10.0.0.1:1234",
+			otherRemoteAddr:   "10.0.0.2:1234",
+			headers:           map[string]string{"Authorization": "Bearer token1"},
+			otherHeaders:      map[string]string{"Authorization": "Bearer token1"},
+			expectedCacheable: true,
+		},
+		"OkCaseDifferentUserAgent": {
+			headers:           map[string]string{"Authorization": "Bearer token1", "User-Agent": "agent1"},
+			otherHeaders:      map[string]string{"Authorization": "Bearer token1", "User-Agent": "agent2"},
+			expectedCacheable: true,
+		},
+		"OkCaseDifferentConditionHeader": {
+			conditionHeaders:  []string{"X-Tenant"},
+			headers:           map[string]string{"Authorization": "Bearer token1", "X-Tenant": "org1"},
+			otherHeaders:      map[string]string{"Authorization": "Bearer token1", "X-Tenant": "org2"},
+			expectedCacheable: true,
+		},
+		"OkCaseDifferentUnrelatedHeader": {
+			conditionHeaders:  []string{"X-Tenant"},
+			headers:           map[string]string{"Authorization": "Bearer token1", "X-Tenant": "org1", "X-Trace": "trace1"},
+			otherHeaders:      map[string]string{"Authorization": "Bearer token1", "X-Tenant": "org1", "X-Trace": "trace2"},
+			expectedCacheable: true,
+			expectedSameKey:   true,
+		},
+		"OkCaseNoIdentity": {
+			headers:      map[string]string{},
+			otherHeaders: map[string]string{},
+		},
+		"OkCaseIdentityHeaderNotConfigured": {
+			headers:      map[string]string{"X-Remote-User": "user1"},
+			otherHeaders: map[string]string{"X-Remote-User": "user2"},
+		},
+	}
+
+	for n, test := range testcases {
+		cache := newDecisionCache(&foulkon.Proxy{
+			DecisionCacheSize:           10,
+			DecisionCachePositiveTTL:    time.Minute,
+			DecisionCacheIdentityHeader: test.identityHeader,
+			DecisionCacheHeaders:        test.conditionHeaders,
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if test.remoteAddr != "" {
+			req.RemoteAddr = test.remoteAddr
+		}
+		for k, v := range test.headers {
+			req.Header.Set(k, v)
+		}
+		otherReq := httptest.NewRequest(http.MethodGet, "/", nil)
+		if test.otherRemoteAddr != "" {
+			otherReq.RemoteAddr = test.otherRemoteAddr
+		}
+		for k, v := range test.otherHeaders {
+			otherReq.Header.Set(k, v)
+		}
+
+		key, cacheable := cache.key(req, "urn:ews:example:instance1:resource/user", "example:user")
+		otherKey, _ := cache.key(otherReq, "urn:ews:example:instance1:resource/user", "example:user")
+		assert.Equal(t, test.expectedCacheable, cacheable, "Error in test case %v", n)
+		if cacheable {
+			assert.Equal(t, test.expectedSameKey, key == otherKey, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestDecisionCache(t *testing.T) {
+	forbidden := getErrorMessage(FORBIDDEN_ERROR, "")
+	testcases := map[string]struct {
+		size        int
+		positiveTTL time.Duration
+		negativeTTL time.Duration
+		// Operations over the cache before checking it
+		prepare func(cache *decisionCache)
+		key     string
+		// Expected result
+		expectedFound           bool
+		expectedWorkerRequestID string
+		expectedErr             error
+	}{
+		"OkCaseAllowed": {
+			size:        2,
+			positiveTTL: time.Minute,
+			prepare: func(cache *decisionCache) {
+				cache.set("key1", "request1", "user1", nil)
+			},
+			key:                     "key1",
+			expectedFound:           true,
+			expectedWorkerRequestID: "request1",
+		},
+		"OkCaseForbidden": {
+			size:        2,
+			negativeTTL: time.Minute,
+			prepare: func(cache *decisionCache) {
+				cache.set("key1", "request1", "user1", forbidden)
+			},
+			key:                     "key1",
+			expectedFound:           true,
+			expectedWorkerRequestID: "request1",
+			expectedErr:             forbidden,
+		},
+		"OkCaseAllowedNotCached": {
+			size:        2,
+			negativeTTL: time.Minute,
+			prepare: func(cache *decisionCache) {
+				cache.set("key1", "request1", "user1", nil)
+			},
+			key: "key1",
+		},
+		"OkCaseForbiddenNotCached": {
+			size:        2,
+			positiveTTL: time.Minute,
+			prepare: func(cache *decisionCache) {
+				cache.set("key1", "request1", "user1", forbidden)
+			},
+			key: "key1",
+		},
+		"OkCaseErrorNotCached": {
+			size:        2,
+			positiveTTL: time.Minute,
+			negativeTTL: time.Minute,
+			prepare: func(cache *decisionCache) {
+				cache.set("key1", "request1", "user1", getErrorMessage(HOST_UNREACHABLE, "unreachable"))
+			},
+			key: "key1",
+		},
+		"OkCaseExpired": {
+			size:        2,
+			positiveTTL: time.Nanosecond,
+			prepare: func(cache *decisionCache) {
+				cache.set("key1", "request1", "user1", nil)
+				time.Sleep(time.Millisecond)
+			},
+			key: "key1",
+		},
+		"OkCaseLeastRecentlyUsedEvicted": {
+			size:        2,
+			positiveTTL: time.Minute,
+			prepare: func(cache *decisionCache) {
+				cache.set("key1", "request1", "user1", nil)
+				cache.set("key2", "request2", "user1", nil)
+				cache.get("key1")
+				cache.set("key3", "request3", "user1", nil)
+			},
+			key: "key2",
+		},
+		"OkCaseRecentlyUsedKept": {
+			size:        2,
+			positiveTTL: time.Minute,
+			prepare: func(cache *decisionCache) {
+				cache.set("key1", "request1", "user1", nil)
+				cache.set("key2", "request2", "user1", nil)
+				cache.get("key1")
+				cache.set("key3", "request3", "user1", nil)
+			},
+			key:                     "key1",
+			expectedFound:           true,
+			expectedWorkerRequestID: "request1",
+		},
+	}
+
+	for n, test := range testcases {
+		cache := &decisionCache{
+			positiveTTL: test.positiveTTL,
+			negativeTTL: test.negativeTTL,
+			size:        test.size,
+			entries:     make(map[string]*list.Element),
+			lru:         list.New(),
+		}
+		test.prepare(cache)
+
+		decision, found := cache.get(test.key)
+		assert.Equal(t, test.expectedFound, found, "Error in test case %v", n)
+		if found {
+			assert.Equal(t, test.expectedWorkerRequestID, decision.workerRequestID, "Error in test case %v", n)
+			assert.Equal(t, test.expectedErr, decision.err, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestProxyHandler_HandleRequestWithCache(t *testing.T) {
+	proxyCore := &foulkon.Proxy{
+		WorkerHost:               server.URL,
+		DecisionCacheSize:        10,
+		DecisionCachePositiveTTL: time.Minute,
+		DecisionCacheNegativeTTL: time.Minute,
+		DecisionCacheHeaders:     []string{"X-Tenant"},
+	}
+	proxyHandler := ProxyHandler{proxy: proxyCore, client: http.DefaultClient, cache: newDecisionCache(proxyCore)}
+	router := httprouter.New()
+	router.Handle(http.MethodGet, USER_ID_URL, proxyHandler.HandleRequest(api.ProxyResource{
+		ID: "resource1",
+		Resource: api.ResourceEntity{
+			Host:   server.URL,
+			Path:   USER_ID_URL,
+			Method: http.MethodGet,
+			Urn:    "urn:ews:example:instance1:resource/{userid}",
+			Action: "example:user",
+		},
+	}))
+	cachedProxy := httptest.NewServer(router)
+	defer cachedProxy.Close()
+
+	// Requests are done in order, so decisions cached in previous requests are used
+	testcases := []struct {
+		name          string
+		authorization string
+		headers       map[string]string
+		// Worker decision
+		getAuthorizedExternalResourcesResult []string
+		getAuthorizedExternalResourcesErr    error
+		// Expected result
+		expectedStatusCode int
+	}{
+		{
+			name:                                 "OkCaseAllowed",
+			authorization:                        "Bearer token1",
+			getAuthorizedExternalResourcesResult: []string{"urn:ews:example:instance1:resource/user"},
+			expectedStatusCode:                   http.StatusOK,
+		},
+		{
+			name:          "OkCaseAllowedFromCache",
+			authorization: "Bearer token1",
+			getAuthorizedExternalResourcesErr: &api.Error{
+				Code: api.UNAUTHORIZED_RESOURCES_ERROR,
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:          "OkCaseAllowedFromCacheWithUnrelatedHeader",
+			authorization: "Bearer token1",
+			headers:       map[string]string{"X-Trace": "trace1"},
+			getAuthorizedExternalResourcesErr: &api.Error{
+				Code: api.UNAUTHORIZED_RESOURCES_ERROR,
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:          "ErrorCaseConditionHeaderNotCached",
+			authorization: "Bearer token1",
+			headers:       map[string]string{"X-Tenant": "org2"},
+			getAuthorizedExternalResourcesErr: &api.Error{
+				Code: api.UNAUTHORIZED_RESOURCES_ERROR,
+			},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:          "ErrorCaseForbidden",
+			authorization: "Bearer token2",
+			getAuthorizedExternalResourcesErr: &api.Error{
+				Code: api.UNAUTHORIZED_RESOURCES_ERROR,
+			},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:                                 "ErrorCaseForbiddenFromCache",
+			authorization:                        "Bearer token2",
+			getAuthorizedExternalResourcesResult: []string{"urn:ews:example:instance1:resource/user"},
+			expectedStatusCode:                   http.StatusForbidden,
+		},
+		{
+			name:                                 "OkCaseNoIdentity",
+			getAuthorizedExternalResourcesResult: []string{"urn:ews:example:instance1:resource/user"},
+			expectedStatusCode:                   http.StatusOK,
+		},
+		{
+			name: "ErrorCaseNoIdentityNotCached",
+			getAuthorizedExternalResourcesErr: &api.Error{
+				Code: api.UNAUTHORIZED_RESOURCES_ERROR,
+			},
+			expectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	client := http.DefaultClient
+
+	for _, test := range testcases {
+		testApi.ArgsOut[GetAuthorizedExternalResourcesMethod][0] = test.getAuthorizedExternalResourcesResult
+		testApi.ArgsOut[GetAuthorizedExternalResourcesMethod][1] = test.getAuthorizedExternalResourcesErr
+		testApi.ArgsOut[GetUserByExternalIdMethod][0] = &api.User{ID: "UserID"}
+		testApi.ArgsOut[GetUserByExternalIdMethod][1] = nil
+
+		req, err := http.NewRequest(http.MethodGet, cachedProxy.URL+USER_ROOT_URL+"/user", nil)
+		assert.Nil(t, err, "Error in test case %v", test.name)
+		if test.authorization != "" {
+			req.Header.Set("Authorization", test.authorization)
+		}
+		for k, v := range test.headers {
+			req.Header.Set(k, v)
+		}
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", test.name)
+		res.Body.Close()
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", test.name)
+	}
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// Interval between reads of the proxy config revision while a request waits for a change
+	PROXY_CONFIG_POLL_INTERVAL = time.Second
+
+	// Max time in seconds that a request waits for a change of the proxy config
+	PROXY_CONFIG_MAX_WAIT = 60
+)
+
+// HANDLERS
+
+// HandleExportProxyConfig returns the proxy config if its revision isn't the Revision parameter. Otherwise it waits
+// up to Wait seconds for a change, and responds with Not Modified status if there wasn't any.
+func (wh *WorkerHandler) HandleExportProxyConfig(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	requestInfo, _, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	var revision int64
+	var wait int
+	if apiErr == nil {
+		revision, wait, apiErr = getProxyConfigParams(r)
+	}
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+
+	timeout := time.NewTimer(time.Duration(wait) * time.Second)
+	defer timeout.Stop()
+	ticker := time.NewTicker(PROXY_CONFIG_POLL_INTERVAL)
+	defer ticker.Stop()
+	for {
+		// Call proxy API to export the proxy config
+		config, err := wh.worker.ProxyApi.ExportProxyConfig(requestInfo, revision)
+		if err != nil || config != nil {
+			wh.processHttpResponse(r, w, requestInfo, config, err, http.StatusOK)
+			return
+		}
+
+		select {
+		case <-ticker.C:
+		case <-timeout.C:
+			wh.processHttpResponse(r, w, requestInfo, nil, nil, http.StatusNotModified)
+			return
+		case <-r.Context().Done():
+			// Client went away
+			return
+		}
+	}
+}
+
+// Private helper methods
+
+// getProxyConfigParams retrieves the revision that the caller has and the seconds to wait for a change of it.
+// Wait is limited to PROXY_CONFIG_MAX_WAIT.
+func getProxyConfigParams(r *http.Request) (int64, int, *api.Error) {
+	var revision int64
+	if rev := r.URL.Query().Get("Revision"); len(rev) != 0 {
+		var err error
+		revision, err = strconv.ParseInt(rev, 10, 64)
+		if err != nil || revision < 0 {
+			return 0, 0, &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter: Revision %v", rev),
+			}
+		}
+	}
+
+	var wait int
+	if wt := r.URL.Query().Get("Wait"); len(wt) != 0 {
+		var err error
+		wait, err = strconv.Atoi(wt)
+		if err != nil || wait < 0 {
+			return 0, 0, &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter: Wait %v", wt),
+			}
+		}
+		if wait > PROXY_CONFIG_MAX_WAIT {
+			wait = PROXY_CONFIG_MAX_WAIT
+		}
+	}
+
+	return revision, wait, nil
+}
@@ -0,0 +1,140 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerHandler_HandleExportProxyConfig(t *testing.T) {
+	config := &api.ProxyConfig{
+		Revision: 2,
+		Resources: []api.ProxyResource{
+			{
+				ID:   "ID1",
+				Name: "resource1",
+				Org:  "org1",
+				Resource: api.ResourceEntity{
+					Host:   "http://localhost:8080",
+					Path:   "/path",
+					Method: "GET",
+					Urn:    "urn",
+					Action: "action",
+				},
+			},
+		},
+		UpstreamPools: []api.UpstreamPool{},
+	}
+	testcases := map[string]struct {
+		// API method args
+		queryParams  map[string]string
+		ignoreArgsIn bool
+		// Expected result
+		expectedRevision   int64
+		expectedStatusCode int
+		expectedResponse   *api.ProxyConfig
+		expectedError      api.Error
+		// Manager Results
+		exportProxyConfigResult *api.ProxyConfig
+		// Manager Errors
+		exportProxyConfigErr error
+	}{
+		"OkCase": {
+			queryParams:             map[string]string{"Revision": "1", "Wait": "30"},
+			expectedRevision:        1,
+			expectedStatusCode:      http.StatusOK,
+			expectedResponse:        config,
+			exportProxyConfigResult: config,
+		},
+		"OkCaseNotModified": {
+			queryParams:        map[string]string{"Revision": "2"},
+			expectedRevision:   2,
+			expectedStatusCode: http.StatusNotModified,
+		},
+		"ErrorCaseInvalidRevision": {
+			queryParams:        map[string]string{"Revision": "-1"},
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: Revision -1",
+			},
+		},
+		"ErrorCaseInvalidWait": {
+			queryParams:        map[string]string{"Wait": "30s"},
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: Wait 30s",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			exportProxyConfigErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			expectedStatusCode: http.StatusInternalServerError,
+			exportProxyConfigErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+		testApi.ArgsIn[ExportProxyConfigMethod][1] = nil
+		testApi.ArgsOut[ExportProxyConfigMethod][0] = test.exportProxyConfigResult
+		testApi.ArgsOut[ExportProxyConfigMethod][1] = test.exportProxyConfigErr
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+PROXY_CONFIG_URL, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		q := req.URL.Query()
+		for param, value := range test.queryParams {
+			q.Add(param, value)
+		}
+		req.URL.RawQuery = q.Encode()
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// Check received parameters
+		if test.ignoreArgsIn {
+			assert.Nil(t, testApi.ArgsIn[ExportProxyConfigMethod][1], "Error in test case %v", n)
+		} else {
+			assert.Equal(t, test.expectedRevision, testApi.ArgsIn[ExportProxyConfigMethod][1], "Error in test case %v", n)
+		}
+
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			exportProxyConfigResponse := &api.ProxyConfig{}
+			err = json.NewDecoder(res.Body).Decode(exportProxyConfigResponse)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, exportProxyConfigResponse, "Error in test case %v", n)
+		case http.StatusNotModified, http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
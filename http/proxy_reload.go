@@ -0,0 +1,282 @@
+package http
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/middleware"
+	"github.com/julienschmidt/httprouter"
+	"github.com/satori/go.uuid"
+)
+
+const (
+	// Proxy endpoints. Notifications are served before the proxy resources, and status in the admin address.
+	PROXY_ADMIN_ROOT_URL   = api.PROXY_RESERVED_PATH
+	PROXY_STATUS_URL       = PROXY_ADMIN_ROOT_URL + "/status"
+	PROXY_NOTIFICATION_URL = PROXY_ADMIN_ROOT_URL + "/notifications"
+
+	// Proxy reload triggers
+	RELOAD_TRIGGER_STARTUP      = "startup"
+	RELOAD_TRIGGER_TICKER       = "ticker"
+	RELOAD_TRIGGER_NOTIFICATION = "notification"
+	RELOAD_TRIGGER_WATCH        = "watch"
+	RELOAD_TRIGGER_CONFIG_FILE  = "config_file"
+
+	// Proxy reload results
+	RELOAD_RESULT_UPDATED = "updated"
+	RELOAD_RESULT_FAILED  = "failed"
+
+	// Number of reload events kept in the proxy status
+	RELOAD_EVENTS_SIZE = 20
+
+	// Max size of the notifications read
+	NOTIFICATION_MAX_BODY_SIZE = 1 << 20
+
+	// Proxy error codes
+	INVALID_SIGNATURE = "InvalidSignatureError"
+)
+
+// RESPONSES
+
+// ProxyReloadEvent is an update of the proxy routes, or a failed attempt to read the proxy resources
+type ProxyReloadEvent struct {
+	Time    time.Time `json:"time"`
+	Trigger string    `json:"trigger"`
+	Result  string    `json:"result"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// ProxyStatus has the routes served by the proxy and its last reload events, oldest first
+type ProxyStatus struct {
+	Revision      int64              `json:"revision"`
+	Resources     int                `json:"resources"`
+	UpstreamPools int                `json:"upstreamPools"`
+	LastCheck     time.Time          `json:"lastCheck"`
+	LastUpdate    time.Time          `json:"lastUpdate"`
+	Updates       int                `json:"updates"`
+	Failures      int                `json:"failures"`
+	Events        []ProxyReloadEvent `json:"events"`
+}
+
+// proxyRoutes is the handler of the proxy server. It serves the proxy endpoints under the reserved path, and
+// the rest of requests with the current router of the proxy resources. Routers are swapped atomically, so
+// requests in progress end with the router they started with.
+type proxyRoutes struct {
+	reserved *httprouter.Router
+	router   atomic.Value
+}
+
+// newProxyRoutes returns the handler of a proxy server without proxy resources
+func newProxyRoutes(reserved *httprouter.Router) *proxyRoutes {
+	routes := &proxyRoutes{reserved: reserved}
+	routes.swap(newProxyRouter(nil, nil))
+	return routes
+}
+
+func (pr *proxyRoutes) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == PROXY_ADMIN_ROOT_URL || strings.HasPrefix(r.URL.Path, PROXY_ADMIN_ROOT_URL+"/") {
+		pr.reserved.ServeHTTP(w, r)
+		return
+	}
+	pr.router.Load().(*proxyRouter).ServeHTTP(w, r)
+}
+
+// swap replaces the router of the proxy resources
+func (pr *proxyRoutes) swap(router *proxyRouter) {
+	pr.router.Store(router)
+}
+
+// proxyReloadStatus records the reloads of the proxy routes
+type proxyReloadStatus struct {
+	mutex  sync.Mutex
+	status ProxyStatus
+}
+
+// record adds a reload to the status. Reloads without changes only update the last check.
+func (rs *proxyReloadStatus) record(now time.Time, trigger string, updated bool, err error, revision int64, resources int, pools int) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	rs.status.LastCheck = now
+	rs.status.Revision = revision
+	rs.status.Resources = resources
+	rs.status.UpstreamPools = pools
+
+	event := ProxyReloadEvent{Time: now, Trigger: trigger}
+	switch {
+	case err != nil:
+		rs.status.Failures++
+		event.Result = RELOAD_RESULT_FAILED
+		event.Error = err.Error()
+	case updated:
+		rs.status.Updates++
+		rs.status.LastUpdate = now
+		event.Result = RELOAD_RESULT_UPDATED
+	default:
+		return
+	}
+
+	rs.status.Events = append(rs.status.Events, event)
+	if len(rs.status.Events) > RELOAD_EVENTS_SIZE {
+		rs.status.Events = rs.status.Events[len(rs.status.Events)-RELOAD_EVENTS_SIZE:]
+	}
+}
+
+// get returns a copy of the status
+func (rs *proxyReloadStatus) get() ProxyStatus {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	status := rs.status
+	status.Events = append([]ProxyReloadEvent{}, rs.status.Events...)
+	return status
+}
+
+// HANDLERS
+
+// HandleGetStatus returns the routes served by the proxy and its last reload events
+func (ps *ProxyServer) HandleGetStatus(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	requestID := uuid.NewV4().String()
+	w.Header().Set(middleware.REQUEST_ID_HEADER, requestID)
+	WriteHttpResponse(r, w, requestID, "", http.StatusOK, ps.status.get())
+}
+
+// HandleNotification reloads the proxy routes when a worker webhook notifies a change. The notification
+// must be signed with the secret of the webhook.
+func (ps *ProxyServer) HandleNotification(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	requestID := uuid.NewV4().String()
+	w.Header().Set(middleware.REQUEST_ID_HEADER, requestID)
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, NOTIFICATION_MAX_BODY_SIZE))
+	if err != nil {
+		apiErr := getErrorMessage(BAD_REQUEST, err.Error())
+		api.TransactionProxyErrorLogWithStatus(requestID, "", r, http.StatusBadRequest, apiErr)
+		WriteHttpResponse(r, w, requestID, "", http.StatusBadRequest, getErrorMessage(BAD_REQUEST, "Bad request"))
+		return
+	}
+
+	signature := api.WebhookSignature(ps.notifySecret, body)
+	if !hmac.Equal([]byte(signature), []byte(r.Header.Get(api.WEBHOOK_SIGNATURE_HEADER))) {
+		apiErr := getErrorMessage(INVALID_SIGNATURE, "Invalid notification signature")
+		api.TransactionProxyErrorLogWithStatus(requestID, "", r, http.StatusUnauthorized, apiErr)
+		WriteHttpResponse(r, w, requestID, "", http.StatusUnauthorized, apiErr)
+		return
+	}
+
+	api.TransactionProxyLog(requestID, "", r, "Reload notified by event "+r.Header.Get(api.WEBHOOK_EVENT_HEADER))
+	ps.Notify()
+	WriteHttpResponse(r, w, requestID, "", http.StatusAccepted, nil)
+}
+
+// Notify requests a reload of the proxy routes. Notifications received while a reload is pending are merged.
+func (ps *ProxyServer) Notify() {
+	select {
+	case ps.notifications <- struct{}{}:
+	default:
+	}
+}
+
+// reload updates the proxy routes if the proxy resources or upstream pools changed, and records the result
+func (ps *ProxyServer) reload(trigger string) error {
+	updated, err := ps.reloadFunc(ps)
+	ps.recordReload(trigger, updated, err)
+	return err
+}
+
+// loadConfigFile updates the proxy routes with the config saved in the config file, and records the result
+func (ps *ProxyServer) loadConfigFile() {
+	config, err := readProxyConfig(ps.configFile)
+	if err != nil {
+		api.Log.Errorf("Couldn't load proxy config from file %v: %v", ps.configFile, err)
+	} else {
+		api.Log.Infof("Loading proxy config from file %v", ps.configFile)
+		ps.updateConfig(config)
+	}
+	ps.recordReload(RELOAD_TRIGGER_CONFIG_FILE, err == nil, err)
+}
+
+func (ps *ProxyServer) recordReload(trigger string, updated bool, err error) {
+	ps.resourceLock.Lock()
+	revision, resources, pools := ps.revision, len(ps.currentResources), len(ps.currentPools)
+	ps.resourceLock.Unlock()
+	ps.status.record(time.Now().UTC(), trigger, updated, err, revision, resources, pools)
+}
+
+// watchReloads reloads the proxy routes on every tick and notification, until done is closed
+func (ps *ProxyServer) watchReloads(ticks <-chan time.Time, done <-chan struct{}) {
+	for {
+		select {
+		case <-ticks:
+			ps.reload(RELOAD_TRIGGER_TICKER)
+		case <-ps.notifications:
+			ps.reload(RELOAD_TRIGGER_NOTIFICATION)
+		case <-done:
+			return
+		}
+	}
+}
+
+// watchConfigChanges reloads the proxy routes whenever the source returns a new config, until done is closed.
+// The source waits for changes, and failed reloads are retried after the refresh time.
+func (ps *ProxyServer) watchConfigChanges(done <-chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		if err := ps.reload(RELOAD_TRIGGER_WATCH); err != nil {
+			select {
+			case <-time.After(ps.refreshTime):
+			case <-done:
+				return
+			}
+		}
+	}
+}
+
+// Private helper methods
+
+// readProxyConfig reads a proxy config saved with writeProxyConfig
+func readProxyConfig(file string) (*api.ProxyConfig, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	config := new(api.ProxyConfig)
+	if err := json.Unmarshal(b, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// writeProxyConfig saves a proxy config encoded with JSON. It is written in a temporary file and renamed,
+// so the file is never left half written.
+func writeProxyConfig(file string, config *api.ProxyConfig) error {
+	b, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
@@ -0,0 +1,410 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/foulkon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxyRoutes_ServeHTTP(t *testing.T) {
+	testAPI := makeTestApi()
+	testAPI.ArgsOut[GetProxyResourcesMethod][0] = []api.ProxyResource{
+		{
+			ID: "ID1",
+			Resource: api.ResourceEntity{
+				Host:   "http://localhost:1",
+				Path:   "/path1",
+				Method: "GET",
+				Urn:    "urn1",
+				Action: "action1",
+			},
+		},
+	}
+	srv := NewProxy(&foulkon.Proxy{ProxyApi: testAPI, RefreshTime: time.Minute})
+	ps := srv.(*ProxyServer)
+	routes := ps.Handler
+	assert.Nil(t, ps.adminServer, "Error in test")
+
+	// The handler of the server doesn't change when resources are reloaded
+	testAPI.ArgsOut[GetProxyResourcesMethod][0] = []api.ProxyResource{}
+	testAPI.ArgsOut[GetProxyConfigMethod][0] = int64(2)
+	ps.reload(RELOAD_TRIGGER_TICKER)
+	assert.Equal(t, routes, ps.Handler, "Error in test")
+
+	testcases := map[string]struct {
+		method             string
+		path               string
+		expectedStatusCode int
+	}{
+		"ErrorCaseStatusInAdminAddress": {
+			method:             http.MethodGet,
+			path:               PROXY_STATUS_URL,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		"ErrorCaseUnknownAdminEndpoint": {
+			method:             http.MethodGet,
+			path:               PROXY_ADMIN_ROOT_URL + "/unknown",
+			expectedStatusCode: http.StatusNotFound,
+		},
+		"ErrorCaseNotificationsDisabled": {
+			method:             http.MethodPost,
+			path:               PROXY_NOTIFICATION_URL,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		"ErrorCaseResourceRemoved": {
+			method:             http.MethodGet,
+			path:               "/path1",
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for n, test := range testcases {
+		req := httptest.NewRequest(test.method, test.path, nil)
+		w := httptest.NewRecorder()
+		routes.ServeHTTP(w, req)
+		assert.Equal(t, test.expectedStatusCode, w.Code, "Error in test case %v", n)
+	}
+}
+
+func TestProxyReloadStatus_record(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		updated bool
+		err     error
+
+		expectedStatus ProxyStatus
+	}{
+		"OkCaseUpdated": {
+			updated: true,
+			expectedStatus: ProxyStatus{
+				Revision:      3,
+				Resources:     2,
+				UpstreamPools: 1,
+				LastCheck:     now,
+				LastUpdate:    now,
+				Updates:       1,
+				Events: []ProxyReloadEvent{
+					{Time: now, Trigger: RELOAD_TRIGGER_TICKER, Result: RELOAD_RESULT_UPDATED},
+				},
+			},
+		},
+		"OkCaseUnchanged": {
+			expectedStatus: ProxyStatus{
+				Revision:      3,
+				Resources:     2,
+				UpstreamPools: 1,
+				LastCheck:     now,
+				Events:        []ProxyReloadEvent{},
+			},
+		},
+		"OkCaseFailed": {
+			err: errors.New("Unknown error"),
+			expectedStatus: ProxyStatus{
+				Revision:      3,
+				Resources:     2,
+				UpstreamPools: 1,
+				LastCheck:     now,
+				Failures:      1,
+				Events: []ProxyReloadEvent{
+					{Time: now, Trigger: RELOAD_TRIGGER_TICKER, Result: RELOAD_RESULT_FAILED, Error: "Unknown error"},
+				},
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		rs := &proxyReloadStatus{}
+		rs.record(now, RELOAD_TRIGGER_TICKER, test.updated, test.err, 3, 2, 1)
+		assert.Equal(t, test.expectedStatus, rs.get(), "Error in test case %v", n)
+	}
+
+	// Only the last events are kept
+	rs := &proxyReloadStatus{}
+	for i := 0; i < RELOAD_EVENTS_SIZE+5; i++ {
+		rs.record(now.Add(time.Duration(i)*time.Second), RELOAD_TRIGGER_NOTIFICATION, true, nil, int64(i), 0, 0)
+	}
+	status := rs.get()
+	assert.Equal(t, RELOAD_EVENTS_SIZE+5, status.Updates, "Error in test")
+	assert.Equal(t, RELOAD_EVENTS_SIZE, len(status.Events), "Error in test")
+	assert.Equal(t, now.Add(5*time.Second), status.Events[0].Time, "Error in test")
+	assert.Equal(t, status.LastUpdate, status.Events[RELOAD_EVENTS_SIZE-1].Time, "Error in test")
+}
+
+func TestProxyServer_HandleGetStatus(t *testing.T) {
+	testAPI := makeTestApi()
+	testAPI.ArgsOut[GetProxyResourcesMethod][0] = []api.ProxyResource{
+		{
+			ID: "ID1",
+			Resource: api.ResourceEntity{
+				Host:   "http://localhost:1",
+				Path:   "/path1",
+				Method: "GET",
+				Urn:    "urn1",
+				Action: "action1",
+			},
+		},
+	}
+	srv := NewProxy(&foulkon.Proxy{ProxyApi: testAPI, RefreshTime: time.Minute, AdminHost: "127.0.0.1", AdminPort: "0"})
+	ps := srv.(*ProxyServer)
+
+	// Failed reload keeps the current resources
+	testAPI.ArgsOut[GetProxyConfigMethod][0] = int64(2)
+	testAPI.ArgsOut[GetProxyResourcesMethod][1] = &api.Error{
+		Code:    api.UNKNOWN_API_ERROR,
+		Message: "Error",
+	}
+	ps.reload(RELOAD_TRIGGER_TICKER)
+
+	// Status is served in the admin address
+	req := httptest.NewRequest(http.MethodGet, PROXY_STATUS_URL, nil)
+	w := httptest.NewRecorder()
+	ps.adminServer.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "Error in test")
+
+	status := ProxyStatus{}
+	err := json.NewDecoder(w.Body).Decode(&status)
+	assert.Nil(t, err, "Error in test")
+	assert.Equal(t, int64(1), status.Revision, "Error in test")
+	assert.Equal(t, 1, status.Resources, "Error in test")
+	assert.Equal(t, 1, status.Updates, "Error in test")
+	assert.Equal(t, 1, status.Failures, "Error in test")
+	if assert.Equal(t, 2, len(status.Events), "Error in test") {
+		assert.Equal(t, RELOAD_TRIGGER_STARTUP, status.Events[0].Trigger, "Error in test")
+		assert.Equal(t, RELOAD_RESULT_UPDATED, status.Events[0].Result, "Error in test")
+		assert.Equal(t, RELOAD_TRIGGER_TICKER, status.Events[1].Trigger, "Error in test")
+		assert.Equal(t, RELOAD_RESULT_FAILED, status.Events[1].Result, "Error in test")
+		assert.Equal(t, "Code: UnknownApiError, Message: Error", status.Events[1].Error, "Error in test")
+	}
+}
+
+func TestProxyServer_HandleNotification(t *testing.T) {
+	body := []byte(`{"id":"EventID","type":"proxy_resource.created"}`)
+	testcases := map[string]struct {
+		signature string
+
+		expectedStatusCode   int
+		expectedResponse     *api.Error
+		expectedNotification bool
+	}{
+		"OkCase": {
+			signature:            api.WebhookSignature("secret", body),
+			expectedStatusCode:   http.StatusAccepted,
+			expectedNotification: true,
+		},
+		"ErrorCaseWithoutSignature": {
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedResponse: &api.Error{
+				Code:    INVALID_SIGNATURE,
+				Message: "Invalid notification signature",
+			},
+		},
+		"ErrorCaseOtherSecret": {
+			signature:          api.WebhookSignature("other", body),
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedResponse: &api.Error{
+				Code:    INVALID_SIGNATURE,
+				Message: "Invalid notification signature",
+			},
+		},
+	}
+
+	for n, test := range testcases {
+		testAPI := makeTestApi()
+		srv := NewProxy(&foulkon.Proxy{ProxyApi: testAPI, RefreshTime: time.Minute, NotifySecret: "secret"})
+		ps := srv.(*ProxyServer)
+
+		req := httptest.NewRequest(http.MethodPost, PROXY_NOTIFICATION_URL, bytes.NewReader(body))
+		req.Header.Set(api.WEBHOOK_EVENT_HEADER, "proxy_resource.created")
+		if test.signature != "" {
+			req.Header.Set(api.WEBHOOK_SIGNATURE_HEADER, test.signature)
+		}
+		w := httptest.NewRecorder()
+		ps.Handler.ServeHTTP(w, req)
+
+		assert.Equal(t, test.expectedStatusCode, w.Code, "Error in test case %v", n)
+		if test.expectedResponse != nil {
+			apiError := &api.Error{}
+			err := json.NewDecoder(w.Body).Decode(apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResponse, apiError, "Error in test case %v", n)
+		}
+		assert.Equal(t, test.expectedNotification, len(ps.notifications) == 1, "Error in test case %v", n)
+	}
+}
+
+func TestProxyServer_Notify(t *testing.T) {
+	testAPI := makeTestApi()
+	srv := NewProxy(&foulkon.Proxy{ProxyApi: testAPI, RefreshTime: time.Minute})
+	ps := srv.(*ProxyServer)
+
+	// Pending notifications are merged
+	ps.Notify()
+	ps.Notify()
+	assert.Equal(t, 1, len(ps.notifications), "Error in test")
+
+	testAPI.ArgsOut[GetProxyResourcesMethod][0] = []api.ProxyResource{
+		{
+			ID: "ID1",
+			Resource: api.ResourceEntity{
+				Host:   "http://localhost:1",
+				Path:   "/path1",
+				Method: "GET",
+				Urn:    "urn1",
+				Action: "action1",
+			},
+		},
+	}
+	testAPI.ArgsOut[GetProxyConfigMethod][0] = int64(2)
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		ps.watchReloads(nil, done)
+		close(finished)
+	}()
+
+	// Wait the reload of the notification
+	for i := 0; i < 100 && ps.status.get().Updates < 2; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	close(done)
+	<-finished
+
+	status := ps.status.get()
+	assert.Equal(t, 1, status.Resources, "Error in test")
+	assert.Equal(t, 0, len(ps.notifications), "Error in test")
+	if assert.Equal(t, 2, len(status.Events), "Error in test") {
+		assert.Equal(t, RELOAD_TRIGGER_STARTUP, status.Events[0].Trigger, "Error in test")
+		assert.Equal(t, RELOAD_TRIGGER_NOTIFICATION, status.Events[1].Trigger, "Error in test")
+		assert.Equal(t, RELOAD_RESULT_UPDATED, status.Events[1].Result, "Error in test")
+	}
+}
+
+func TestProxyServer_watchConfigChanges(t *testing.T) {
+	testAPI := makeTestApi()
+	srv := NewProxy(&foulkon.Proxy{
+		ProxyApi:     testAPI,
+		RefreshTime:  time.Minute,
+		NotifySecret: "secret",
+		ConfigSource: foulkon.CONFIG_SOURCE_WORKER,
+	})
+	ps := srv.(*ProxyServer)
+
+	// Notifications aren't needed if the config is watched
+	req := httptest.NewRequest(http.MethodPost, PROXY_NOTIFICATION_URL, nil)
+	w := httptest.NewRecorder()
+	ps.Handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code, "Error in test")
+
+	testAPI.ArgsOut[GetProxyResourcesMethod][0] = []api.ProxyResource{
+		{
+			ID: "ID1",
+			Resource: api.ResourceEntity{
+				Host:   "http://localhost:1",
+				Path:   "/path1",
+				Method: "GET",
+				Urn:    "urn1",
+				Action: "action1",
+			},
+		},
+	}
+	testAPI.ArgsOut[GetProxyConfigMethod][0] = int64(2)
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		ps.watchConfigChanges(done)
+		close(finished)
+	}()
+
+	// Wait the reload of the new revision
+	for i := 0; i < 100 && ps.status.get().Updates < 2; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	close(done)
+	<-finished
+
+	status := ps.status.get()
+	assert.Equal(t, int64(2), status.Revision, "Error in test")
+	assert.Equal(t, 1, status.Resources, "Error in test")
+	if assert.Equal(t, 2, len(status.Events), "Error in test") {
+		assert.Equal(t, RELOAD_TRIGGER_WATCH, status.Events[1].Trigger, "Error in test")
+		assert.Equal(t, RELOAD_RESULT_UPDATED, status.Events[1].Result, "Error in test")
+	}
+}
+
+func TestProxyServer_ConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proxy-config")
+	assert.Nil(t, err, "Error in test")
+	defer os.RemoveAll(dir)
+	configFile := filepath.Join(dir, "config.json")
+
+	resources := []api.ProxyResource{
+		{
+			ID: "ID1",
+			Resource: api.ResourceEntity{
+				Host:   "http://localhost:1",
+				Path:   "/path1",
+				Method: "GET",
+				Urn:    "urn1",
+				Action: "action1",
+			},
+		},
+	}
+	sourceErr := &api.Error{
+		Code:    api.UNKNOWN_API_ERROR,
+		Message: "Error",
+	}
+
+	// Source unavailable without config saved
+	testAPI := makeTestApi()
+	testAPI.ArgsOut[GetProxyResourcesMethod][1] = sourceErr
+	srv := NewProxy(&foulkon.Proxy{ProxyApi: testAPI, RefreshTime: time.Minute, ConfigFile: configFile})
+	status := srv.(*ProxyServer).status.get()
+	assert.Equal(t, 0, status.Updates, "Error in test")
+	if assert.Equal(t, 2, len(status.Events), "Error in test") {
+		assert.Equal(t, RELOAD_TRIGGER_STARTUP, status.Events[0].Trigger, "Error in test")
+		assert.Equal(t, RELOAD_RESULT_FAILED, status.Events[0].Result, "Error in test")
+		assert.Equal(t, RELOAD_TRIGGER_CONFIG_FILE, status.Events[1].Trigger, "Error in test")
+		assert.Equal(t, RELOAD_RESULT_FAILED, status.Events[1].Result, "Error in test")
+	}
+
+	// Config loaded is saved
+	testAPI = makeTestApi()
+	testAPI.ArgsOut[GetProxyResourcesMethod][0] = resources
+	NewProxy(&foulkon.Proxy{ProxyApi: testAPI, RefreshTime: time.Minute, ConfigFile: configFile})
+	config, err := readProxyConfig(configFile)
+	if assert.Nil(t, err, "Error in test") {
+		assert.Equal(t, &api.ProxyConfig{Revision: 1, Resources: resources}, config, "Error in test")
+	}
+
+	// Source unavailable with config saved
+	testAPI = makeTestApi()
+	testAPI.ArgsOut[GetProxyConfigMethod][0] = int64(2)
+	testAPI.ArgsOut[GetProxyResourcesMethod][1] = sourceErr
+	srv = NewProxy(&foulkon.Proxy{ProxyApi: testAPI, RefreshTime: time.Minute, ConfigFile: configFile})
+	ps := srv.(*ProxyServer)
+	assert.Equal(t, resources, ps.currentResources, "Error in test")
+	status = ps.status.get()
+	assert.Equal(t, int64(1), status.Revision, "Error in test")
+	assert.Equal(t, 1, status.Updates, "Error in test")
+	if assert.Equal(t, 2, len(status.Events), "Error in test") {
+		assert.Equal(t, RELOAD_TRIGGER_CONFIG_FILE, status.Events[1].Trigger, "Error in test")
+		assert.Equal(t, RELOAD_RESULT_UPDATED, status.Events[1].Result, "Error in test")
+	}
+
+	// The saved config isn't reloaded while the revision doesn't change
+	testAPI.ArgsOut[GetProxyConfigMethod][0] = int64(1)
+	err = ps.reload(RELOAD_TRIGGER_TICKER)
+	assert.Nil(t, err, "Error in test")
+	assert.Equal(t, 1, ps.status.get().Updates, "Error in test")
+}
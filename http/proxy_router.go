@@ -0,0 +1,145 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/julienschmidt/httprouter"
+)
+
+// proxyRouter routes the requests to the proxy resources. There is a router for every virtual host,
+// and a default router with the resources without host, used for requests to other hosts and for
+// requests that don't match any route of their host.
+type proxyRouter struct {
+	hosts         map[string]*httprouter.Router
+	defaultRouter *httprouter.Router
+}
+
+// proxyRoute has the resources with the same host, method and path, sorted from the most specific
+// header matchers to the least specific. Requests are handled by the first resource matched.
+type proxyRoute struct {
+	resources []api.ProxyResource
+	handlers  []httprouter.Handle
+	// notFound handles the requests that don't match any resource
+	notFound http.Handler
+}
+
+// newProxyRouter returns a router of the proxy resources
+func newProxyRouter(proxyResources []api.ProxyResource, ph *ProxyHandler) *proxyRouter {
+	pr := &proxyRouter{
+		hosts:         map[string]*httprouter.Router{},
+		defaultRouter: httprouter.New(),
+	}
+	routes := map[string]*proxyRoute{}
+	for _, resource := range proxyResources {
+		// Clean path
+		resource.Resource.Path = httprouter.CleanPath(resource.Resource.Path)
+
+		host := strings.ToLower(resource.Resource.MatchHost)
+		key := host + " " + resource.Resource.Method + " " + resource.Resource.Path
+		if route, ok := routes[key]; ok {
+			route.add(resource, ph)
+			continue
+		}
+
+		router := pr.defaultRouter
+		var notFound http.Handler = http.NotFoundHandler()
+		if host != "" {
+			router = pr.hosts[host]
+			if router == nil {
+				// Requests that don't match any route of the host are routed by the default router
+				router = httprouter.New()
+				router.NotFound = pr.defaultRouter.ServeHTTP
+				router.HandleMethodNotAllowed = false
+				pr.hosts[host] = router
+			}
+			notFound = pr.defaultRouter
+		}
+
+		route := &proxyRoute{notFound: notFound}
+		route.add(resource, ph)
+		if safeRouterAdderHandler(router, resource, route.handle) {
+			routes[key] = route
+		}
+	}
+	return pr
+}
+
+func (pr *proxyRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if router, ok := pr.hosts[requestHost(r)]; ok {
+		router.ServeHTTP(w, r)
+		return
+	}
+	pr.defaultRouter.ServeHTTP(w, r)
+}
+
+// add adds a resource to the route, after the resources with more header matchers
+func (route *proxyRoute) add(resource api.ProxyResource, ph *ProxyHandler) {
+	for _, other := range route.resources {
+		if sameHeaderMatchers(other.Resource.MatchHeaders, resource.Resource.MatchHeaders) {
+			api.Log.Errorf("There was a problem adding proxy resource with name %v and org %v: "+
+				"a resource with the same headers is already registered for path '%v'", resource.Name, resource.Org, resource.Resource.Path)
+			return
+		}
+	}
+	i := sort.Search(len(route.resources), func(i int) bool {
+		return len(route.resources[i].Resource.MatchHeaders) < len(resource.Resource.MatchHeaders)
+	})
+	route.resources = append(route.resources, api.ProxyResource{})
+	copy(route.resources[i+1:], route.resources[i:])
+	route.resources[i] = resource
+	route.handlers = append(route.handlers, nil)
+	copy(route.handlers[i+1:], route.handlers[i:])
+	route.handlers[i] = ph.HandleRequest(resource)
+}
+
+// handle handles the request with the first resource matched by its headers
+func (route *proxyRoute) handle(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	for i, resource := range route.resources {
+		if matchHeaders(r, resource.Resource.MatchHeaders) {
+			route.handlers[i](w, r, ps)
+			return
+		}
+	}
+	route.notFound.ServeHTTP(w, r)
+}
+
+// matchHeaders checks if the request has all headers. Only the first value of each header is matched, so
+// a request can't match two resources with different values of the same header.
+func matchHeaders(r *http.Request, headers []api.HeaderMatcher) bool {
+	for _, h := range headers {
+		if r.Header.Get(h.Name) != h.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// sameHeaderMatchers checks if both lists have the same header matchers, in any order
+func sameHeaderMatchers(a []api.HeaderMatcher, b []api.HeaderMatcher) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	values := map[string]string{}
+	for _, h := range a {
+		values[http.CanonicalHeaderKey(h.Name)] = h.Value
+	}
+	for _, h := range b {
+		if value, ok := values[http.CanonicalHeaderKey(h.Name)]; !ok || value != h.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// requestHost returns the host of the request in lower case, without port
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
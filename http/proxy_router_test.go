@@ -0,0 +1,170 @@
+package http
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/foulkon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxyRouter_ServeHTTP(t *testing.T) {
+	// Backends answer with the name of the resource
+	resources := []api.ProxyResource{}
+	backends := []*httptest.Server{}
+	addResource := func(name string, matchHost string, path string, headers []api.HeaderMatcher) {
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, name)
+		}))
+		backends = append(backends, backend)
+		resources = append(resources, api.ProxyResource{
+			Name: name,
+			Org:  "org1",
+			Resource: api.ResourceEntity{
+				Host:         backend.URL,
+				Path:         path,
+				Method:       http.MethodGet,
+				Urn:          "urn:ews:example:instance1:resource/get",
+				Action:       "example:get",
+				MatchHost:    matchHost,
+				MatchHeaders: headers,
+			},
+		})
+	}
+	addResource("default", "", "/items/:id", nil)
+	addResource("status", "", "/status", nil)
+	addResource("reports", "", "/reports", nil)
+	addResource("host", "api.example.com", "/items/:id", nil)
+	addResource("hostV2", "api.example.com", "/items/:id", []api.HeaderMatcher{{Name: "X-Version", Value: "2"}})
+	addResource("hostV2JSON", "api.example.com", "/items/:id",
+		[]api.HeaderMatcher{{Name: "X-Version", Value: "2"}, {Name: "Accept", Value: "application/json"}})
+	addResource("hostReportsV2", "api.example.com", "/reports", []api.HeaderMatcher{{Name: "X-Version", Value: "2"}})
+	// Same route and headers as hostV2, it isn't added
+	addResource("hostV2Repeated", "api.example.com", "/items/:id", []api.HeaderMatcher{{Name: "x-version", Value: "2"}})
+
+	proxyHandler := &ProxyHandler{proxy: &foulkon.Proxy{WorkerHost: server.URL, ProxyApi: testApi}, client: http.DefaultClient}
+	router := httptest.NewServer(newProxyRouter(resources, proxyHandler))
+	defer router.Close()
+	for _, backend := range backends {
+		defer backend.Close()
+	}
+
+	assert.Equal(t, "There was a problem adding proxy resource with name hostV2Repeated and org org1: "+
+		"a resource with the same headers is already registered for path '/items/:id'", hook.LastEntry().Message, "Error in test")
+
+	testcases := map[string]struct {
+		host    string
+		path    string
+		headers map[string]string
+		// Headers added after the previous ones, as later values of the same header
+		addedHeaders map[string]string
+		// Expected results
+		expectedStatusCode int
+		expectedResource   string
+	}{
+		"OkCaseOtherHost": {
+			host:               "www.example.com",
+			path:               "/items/1",
+			expectedStatusCode: http.StatusOK,
+			expectedResource:   "default",
+		},
+		"OkCaseHost": {
+			host:               "api.example.com:8000",
+			path:               "/items/1",
+			expectedStatusCode: http.StatusOK,
+			expectedResource:   "host",
+		},
+		"OkCaseHostUpperCase": {
+			host:               "API.example.com",
+			path:               "/items/1",
+			expectedStatusCode: http.StatusOK,
+			expectedResource:   "host",
+		},
+		"OkCaseHeader": {
+			host:               "api.example.com",
+			path:               "/items/1",
+			headers:            map[string]string{"X-Version": "2"},
+			expectedStatusCode: http.StatusOK,
+			expectedResource:   "hostV2",
+		},
+		"OkCaseMostSpecificHeaders": {
+			host:               "api.example.com",
+			path:               "/items/1",
+			headers:            map[string]string{"X-Version": "2", "Accept": "application/json"},
+			expectedStatusCode: http.StatusOK,
+			expectedResource:   "hostV2JSON",
+		},
+		"OkCaseHeaderWithOtherValue": {
+			host:               "api.example.com",
+			path:               "/items/1",
+			headers:            map[string]string{"X-Version": "3"},
+			expectedStatusCode: http.StatusOK,
+			expectedResource:   "host",
+		},
+		"OkCaseHeaderWithOtherFirstValue": {
+			host:               "api.example.com",
+			path:               "/items/1",
+			headers:            map[string]string{"X-Version": "3"},
+			addedHeaders:       map[string]string{"X-Version": "2"},
+			expectedStatusCode: http.StatusOK,
+			expectedResource:   "host",
+		},
+		"OkCasePathWithoutHostRoute": {
+			host:               "api.example.com",
+			path:               "/status",
+			expectedStatusCode: http.StatusOK,
+			expectedResource:   "status",
+		},
+		"OkCaseHeadersWithoutHostRoute": {
+			host:               "api.example.com",
+			path:               "/reports",
+			expectedStatusCode: http.StatusOK,
+			expectedResource:   "reports",
+		},
+		"OkCaseHeadersWithHostRoute": {
+			host:               "api.example.com",
+			path:               "/reports",
+			headers:            map[string]string{"X-Version": "2"},
+			expectedStatusCode: http.StatusOK,
+			expectedResource:   "hostReportsV2",
+		},
+		"ErrorCaseNotFound": {
+			host:               "api.example.com",
+			path:               "/unknown",
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+		testApi.ArgsOut[GetAuthorizedExternalResourcesMethod][0] = []string{"urn:ews:example:instance1:resource/get"}
+		testApi.ArgsOut[GetAuthorizedExternalResourcesMethod][1] = nil
+
+		req, err := http.NewRequest(http.MethodGet, router.URL+test.path, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+		req.Host = test.host
+		for name, value := range test.headers {
+			req.Header.Set(name, value)
+		}
+		for name, value := range test.addedHeaders {
+			req.Header.Add(name, value)
+		}
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// Check response
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+		if test.expectedResource != "" {
+			body, err := ioutil.ReadAll(res.Body)
+			assert.Nil(t, err, "Error in test case %v", n)
+			assert.Equal(t, test.expectedResource, string(body), "Error in test case %v", n)
+		}
+		res.Body.Close()
+	}
+}
@@ -3,6 +3,8 @@ package http
 import (
 	"encoding/json"
 	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,6 +12,9 @@ import (
 	"fmt"
 
 	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/foulkon"
+	"github.com/Tecsisa/foulkon/middleware"
+	"github.com/julienschmidt/httprouter"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -175,8 +180,13 @@ func TestProxyHandler_HandleRequest(t *testing.T) {
 		testApi.ArgsOut[GetUserByExternalIdMethod][0] = test.getUserByExternalIdResult
 		testApi.ArgsOut[GetUserByExternalIdMethod][1] = test.getUserByExternalIdErr
 
+		testApi.ArgsIn[GetAuthorizedExternalResourcesMethod][0] = nil
+
 		req, err := http.NewRequest(http.MethodGet, proxy.URL+test.resource, nil)
 		assert.Nil(t, err, "Error in test case %v", n)
+		// Spoofed request context
+		req.Header.Set(FORWARDED_FOR_HEADER, "10.0.0.1")
+		req.Header.Set(FORWARDED_PROTO_HEADER, "https")
 
 		if test.authStatusCode != 0 {
 			authConnector.statusCode = test.authStatusCode
@@ -185,6 +195,14 @@ func TestProxyHandler_HandleRequest(t *testing.T) {
 		res, err := client.Do(req)
 		assert.Nil(t, err, "Error in test case %v", n)
 
+		// Check request context sent to worker
+		if requestInfo, ok := testApi.ArgsIn[GetAuthorizedExternalResourcesMethod][0].(api.RequestInfo); ok {
+			assert.Equal(t, "127.0.0.1", requestInfo.Context.Headers.Get(FORWARDED_FOR_HEADER), "Error in test case %v", n)
+			assert.Equal(t, "http", requestInfo.Context.Headers.Get(FORWARDED_PROTO_HEADER), "Error in test case %v", n)
+			assert.Equal(t, "127.0.0.1", requestInfo.Context.SourceIP, "Error in test case %v", n)
+			assert.False(t, requestInfo.Context.SecureTransport, "Error in test case %v", n)
+		}
+
 		// check status code
 		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
 
@@ -210,6 +228,183 @@ func TestProxyHandler_HandleRequest(t *testing.T) {
 	}
 }
 
+// testDecisionSink stores the decisions written in memory
+type testDecisionSink struct {
+	mutex     sync.Mutex
+	decisions []api.Decision
+}
+
+func (s *testDecisionSink) Write(decision api.Decision) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.decisions = append(s.decisions, decision)
+	return nil
+}
+
+func (s *testDecisionSink) Close() error {
+	return nil
+}
+
+// pop returns the decisions written since the last call
+func (s *testDecisionSink) pop() []api.Decision {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	decisions := s.decisions
+	s.decisions = nil
+	return decisions
+}
+
+func TestProxyHandler_HandleRequestWithDecisionLog(t *testing.T) {
+	sink := &testDecisionSink{}
+	proxyCore := &foulkon.Proxy{
+		WorkerHost:                  server.URL,
+		DecisionCacheSize:           10,
+		DecisionCachePositiveTTL:    time.Minute,
+		DecisionCacheNegativeTTL:    time.Minute,
+		DecisionCacheIdentityHeader: "X-User",
+		DecisionLog:                 api.NewDecisionLog(sink, 1, 1),
+	}
+	proxyHandler := ProxyHandler{proxy: proxyCore, client: http.DefaultClient, cache: newDecisionCache(proxyCore)}
+	router := httprouter.New()
+	router.Handle(http.MethodGet, USER_ID_URL, proxyHandler.HandleRequest(api.ProxyResource{
+		ID: "resource1",
+		Resource: api.ResourceEntity{
+			Host:   server.URL,
+			Path:   USER_ID_URL,
+			Method: http.MethodGet,
+			Urn:    "urn:ews:example:instance1:resource/{userid}",
+			Action: "example:user",
+		},
+	}))
+	router.Handle(http.MethodGet, "/invalidUrn", proxyHandler.HandleRequest(api.ProxyResource{
+		ID: "invalidUrn",
+		Resource: api.ResourceEntity{
+			Host:   server.URL,
+			Path:   "/invalidUrn",
+			Method: http.MethodGet,
+			Urn:    "urn:ews:example:instance1:resource/*",
+			Action: "example:user",
+		},
+	}))
+	decisionProxy := httptest.NewServer(router)
+	defer decisionProxy.Close()
+
+	// Requests are done in order, so decisions cached in previous requests are used. Decisions are recorded
+	// with the user authenticated by the worker
+	testcases := []struct {
+		name     string
+		resource string
+		user     string
+		// Worker decision
+		getAuthorizedExternalResourcesResult []string
+		getAuthorizedExternalResourcesErr    error
+		// Expected result
+		expectedStatusCode int
+		expectedDecision   *api.Decision
+	}{
+		{
+			name:                                 "OkCaseAllowed",
+			resource:                             USER_ROOT_URL + "/user",
+			user:                                 "user1",
+			getAuthorizedExternalResourcesResult: []string{"urn:ews:example:instance1:resource/user"},
+			expectedStatusCode:                   http.StatusOK,
+			expectedDecision: &api.Decision{
+				Source:        api.DECISION_SOURCE_PROXY,
+				User:          "userID",
+				Action:        "example:user",
+				RequestedUrns: []string{"urn:ews:example:instance1:resource/user"},
+				AllowedUrns:   []string{"urn:ews:example:instance1:resource/user"},
+				Allowed:       true,
+			},
+		},
+		{
+			name:               "OkCaseAllowedFromCache",
+			resource:           USER_ROOT_URL + "/user",
+			user:               "user1",
+			expectedStatusCode: http.StatusOK,
+			expectedDecision: &api.Decision{
+				Source:        api.DECISION_SOURCE_PROXY,
+				User:          "userID",
+				Action:        "example:user",
+				RequestedUrns: []string{"urn:ews:example:instance1:resource/user"},
+				AllowedUrns:   []string{"urn:ews:example:instance1:resource/user"},
+				Allowed:       true,
+				Cached:        true,
+			},
+		},
+		{
+			name:     "ErrorCaseForbidden",
+			resource: USER_ROOT_URL + "/user",
+			user:     "user2",
+			getAuthorizedExternalResourcesErr: &api.Error{
+				Code: api.UNAUTHORIZED_RESOURCES_ERROR,
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedDecision: &api.Decision{
+				Source:        api.DECISION_SOURCE_PROXY,
+				User:          "userID",
+				Action:        "example:user",
+				RequestedUrns: []string{"urn:ews:example:instance1:resource/user"},
+				AllowedUrns:   []string{},
+			},
+		},
+		{
+			name:               "ErrorCaseInvalidParameterNotRecorded",
+			resource:           "/invalidUrn",
+			user:               "user1",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:     "ErrorCaseWorkerErrorNotRecorded",
+			resource: USER_ROOT_URL + "/user",
+			user:     "user3",
+			getAuthorizedExternalResourcesErr: &api.Error{
+				Code: api.UNKNOWN_API_ERROR,
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	client := http.DefaultClient
+
+	for _, test := range testcases {
+		testApi.ArgsOut[GetAuthorizedExternalResourcesMethod][0] = test.getAuthorizedExternalResourcesResult
+		testApi.ArgsOut[GetAuthorizedExternalResourcesMethod][1] = test.getAuthorizedExternalResourcesErr
+		testApi.ArgsOut[GetUserByExternalIdMethod][0] = &api.User{ID: "UserID"}
+		testApi.ArgsOut[GetUserByExternalIdMethod][1] = nil
+
+		req, err := http.NewRequest(http.MethodGet, decisionProxy.URL+test.resource, nil)
+		assert.Nil(t, err, "Error in test case %v", test.name)
+		req.Header.Set("Authorization", "Bearer "+test.user)
+		req.Header.Set("X-User", test.user)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", test.name)
+		res.Body.Close()
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", test.name)
+
+		// check recorded decision
+		decisions := sink.pop()
+		if test.expectedDecision == nil {
+			assert.Empty(t, decisions, "Error in test case %v", test.name)
+			continue
+		}
+		if assert.Len(t, decisions, 1, "Error in test case %v", test.name) {
+			decision := decisions[0]
+			assert.Equal(t, res.Header.Get(middleware.REQUEST_ID_HEADER), decision.RequestID, "Error in test case %v", test.name)
+			assert.NotEmpty(t, decision.WorkerRequestID, "Error in test case %v", test.name)
+			// Identifiers, time and latency change in each execution
+			decision.RequestID = ""
+			decision.WorkerRequestID = ""
+			decision.Time = time.Time{}
+			decision.Latency = 0
+			assert.Equal(t, *test.expectedDecision, decision, "Error in test case %v", test.name)
+		}
+	}
+}
+
 func TestWorkerHandler_HandleAddProxyResource(t *testing.T) {
 	now := time.Now().UTC()
 	testcases := map[string]struct {
@@ -845,6 +1040,7 @@ func TestWorkerHandler_HandleGetProxyResourceByName(t *testing.T) {
 			assert.Nil(t, err, "Error in test case %v", n)
 			// Check result
 			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+			assert.Equal(t, api.EntityTag(test.expectedResponse.UpdateAt), res.Header.Get(ETAG_HEADER), "Error in test case %v", n)
 		case http.StatusInternalServerError: // Empty message so continue
 			continue
 		default:
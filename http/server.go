@@ -13,10 +13,10 @@ import (
 	"github.com/Tecsisa/foulkon/api"
 	"github.com/Tecsisa/foulkon/foulkon"
 	"github.com/julienschmidt/httprouter"
-	"github.com/kylelemons/godebug/pretty"
 )
 
-type ReloadHandlerFunc func(watch *ProxyServer) bool
+// ReloadHandlerFunc updates the routes of the proxy server. It returns true if they changed.
+type ReloadHandlerFunc func(ps *ProxyServer) (bool, error)
 
 // ProxyServer struct with reload Handler extension
 type ProxyServer struct {
@@ -27,8 +27,26 @@ type ProxyServer struct {
 	reloadFunc   ReloadHandlerFunc
 	refreshTime  time.Duration
 
-	reloadServe      chan struct{}
+	// Routes are reloaded on every refresh and on notifications of the worker, signed with notifySecret
+	routes        *proxyRoutes
+	notifications chan struct{}
+	notifySecret  string
+	status        proxyReloadStatus
+
+	// Routes are reloaded as soon as the source returns a new config, instead of every refresh, if watchConfig
+	// is true. The last config loaded is saved in configFile, and loaded from it if the startup reload fails.
+	watchConfig bool
+	configFile  string
+
+	// Admin endpoints aren't authenticated, so they are served in their own address. Nil if it isn't set.
+	adminServer *http.Server
+
+	proxy            *foulkon.Proxy
+	cache            *decisionCache
+	revision         int64
 	currentResources []api.ProxyResource
+	currentPools     []api.UpstreamPool
+	pools            map[string]*upstreamPool
 	http.Server
 }
 
@@ -37,6 +55,9 @@ type WorkerServer struct {
 	certFile string
 	keyFile  string
 
+	sweeper         api.InternalGroupAPI
+	sweeperInterval time.Duration
+
 	http.Server
 }
 
@@ -48,6 +69,15 @@ type Server interface {
 
 // Run starts an HTTP WorkerServer
 func (ws *WorkerServer) Run() error {
+	// Remove expired group relations every sweeperInterval, until the server stops
+	if ws.sweeper != nil && ws.sweeperInterval > 0 {
+		done := make(chan struct{})
+		defer close(done)
+		ticker := time.NewTicker(ws.sweeperInterval)
+		defer ticker.Stop()
+		go ws.sweepExpiredRelations(ticker.C, done)
+	}
+
 	var err error
 	if ws.certFile != "" || ws.keyFile != "" {
 		err = ws.ListenAndServeTLS(ws.certFile, ws.keyFile)
@@ -58,6 +88,20 @@ func (ws *WorkerServer) Run() error {
 	return err
 }
 
+// sweepExpiredRelations removes expired group relations on every tick, until done is closed
+func (ws *WorkerServer) sweepExpiredRelations(ticks <-chan time.Time, done <-chan struct{}) {
+	for {
+		select {
+		case <-ticks:
+			if err := ws.sweeper.RemoveExpiredRelations(); err != nil {
+				api.Log.Errorf("Unexpected error removing expired group relations %v", err)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
 // Configuration an HTTP ProxyServer with a given address
 func (ps *ProxyServer) Configuration() error {
 	if ps.certFile != "" || ps.keyFile != "" {
@@ -91,44 +135,49 @@ func (ps *ProxyServer) Configuration() error {
 // Configuration an HTTP WorkerServer
 func (ws *WorkerServer) Configuration() error { return nil }
 
-// Run starts an HTTP ProxyServer
+// Run starts an HTTP ProxyServer. Routes are reloaded every refreshTime and when the worker notifies a change,
+// or when the worker returns a new config if they are watched, without stopping the listener.
 func (ps *ProxyServer) Run() error {
-	// Call reloadFunc every refreshTime
-	timer := time.NewTicker(ps.refreshTime)
-	// now wait for the other times when we needed to
-	go func() {
-		for range timer.C {
-			// change the handler
-			if ps.reloadFunc(ps) {
-				ps.reloadServe <- struct{}{} // reset the listening binding
-			}
-		}
-	}()
-
-	var err error
 	ln, err := net.Listen("tcp", ps.Addr)
 	if err != nil {
 		return err
 	}
+	if ps.certFile != "" || ps.keyFile != "" {
+		ln = tls.NewListener(ln, ps.TLSConfig)
+	}
 
-	for {
-		l := ln.(*net.TCPListener)
-		defer l.Close()
-		go func(l net.Listener) {
-			err = ps.Serve(l)
-		}(l)
+	if ps.adminServer != nil {
+		adminLn, err := net.Listen("tcp", ps.adminServer.Addr)
 		if err != nil {
+			ln.Close()
 			return err
 		}
-		<-ps.reloadServe
+		defer ps.adminServer.Close()
+		go func() {
+			if err := ps.adminServer.Serve(adminLn); err != http.ErrServerClosed {
+				api.Log.Errorf("Proxy admin server stopped: %v", err)
+			}
+		}()
 	}
+
+	done := make(chan struct{})
+	defer close(done)
+	if ps.watchConfig {
+		go ps.watchConfigChanges(done)
+	} else {
+		ticker := time.NewTicker(ps.refreshTime)
+		defer ticker.Stop()
+		go ps.watchReloads(ticker.C, done)
+	}
+
+	return ps.Serve(ln)
 }
 
 // NewProxy returns a new ProxyServer
 func NewProxy(proxy *foulkon.Proxy) Server {
 	// Initialization
 	ps := new(ProxyServer)
-	ps.reloadServe = make(chan struct{}, 1)
+	ps.notifications = make(chan struct{}, 1)
 	ps.TLSConfig = &tls.Config{}
 
 	// Set Proxy parameters
@@ -137,9 +186,36 @@ func NewProxy(proxy *foulkon.Proxy) Server {
 
 	ps.Addr = proxy.Host + ":" + proxy.Port
 	ps.refreshTime = proxy.RefreshTime
+	ps.notifySecret = proxy.NotifySecret
+	ps.watchConfig = proxy.ConfigSource == foulkon.CONFIG_SOURCE_WORKER
+	ps.configFile = proxy.ConfigFile
+	ps.proxy = proxy
+	// Decision cache is kept between reloads
+	ps.cache = newDecisionCache(proxy)
 	ps.reloadFunc = ps.RefreshResources(proxy)
 
-	ps.reloadFunc(ps)
+	// Notifications are signed, so they are served with the proxy resources. They aren't needed if the
+	// config is watched.
+	reserved := httprouter.New()
+	if ps.notifySecret != "" && !ps.watchConfig {
+		reserved.POST(PROXY_NOTIFICATION_URL, ps.HandleNotification)
+	}
+	ps.routes = newProxyRoutes(reserved)
+	ps.Handler = ps.routes
+
+	if proxy.AdminPort != "" {
+		admin := httprouter.New()
+		admin.GET(PROXY_STATUS_URL, ps.HandleGetStatus)
+		ps.adminServer = &http.Server{
+			Addr:    proxy.AdminHost + ":" + proxy.AdminPort,
+			Handler: admin,
+		}
+	}
+
+	// Use the last config saved if the source is unavailable
+	if err := ps.reload(RELOAD_TRIGGER_STARTUP); err != nil && ps.configFile != "" {
+		ps.loadConfigFile()
+	}
 
 	return ps
 }
@@ -150,6 +226,8 @@ func NewWorker(worker *foulkon.Worker, h http.Handler) Server {
 	ws.certFile = worker.CertFile
 	ws.keyFile = worker.KeyFile
 	ws.Addr = worker.Host + ":" + worker.Port
+	ws.sweeper = worker.InternalGroupApi
+	ws.sweeperInterval = worker.SweeperInterval
 
 	ws.Handler = h
 
@@ -157,52 +235,60 @@ func NewWorker(worker *foulkon.Worker, h http.Handler) Server {
 }
 
 // RefreshResources implements reloadFunc
-func (ps *ProxyServer) RefreshResources(proxy *foulkon.Proxy) func(s *ProxyServer) bool {
-	return func(srv *ProxyServer) bool {
-		proxyHandler := ProxyHandler{proxy: proxy, client: http.DefaultClient}
-
-		// Get proxy resources
-		newProxyResources, err := proxy.ProxyApi.GetProxyResources()
+func (ps *ProxyServer) RefreshResources(proxy *foulkon.Proxy) ReloadHandlerFunc {
+	return func(srv *ProxyServer) (bool, error) {
+		srv.resourceLock.Lock()
+		revision := srv.revision
+		srv.resourceLock.Unlock()
+
+		// Get proxy config if its revision changed
+		config, err := proxy.ProxyApi.GetProxyConfig(revision)
 		if err != nil {
-			api.Log.Errorf("Unexpected error reading proxy resources from database %v", err)
-			return false
+			api.Log.Errorf("Unexpected error reading proxy config %v", err)
+			return false, err
+		}
+		if config == nil {
+			return false, nil
 		}
 
-		if diff := pretty.Compare(srv.currentResources, newProxyResources); diff != "" {
-			router := httprouter.New()
-
-			defer srv.resourceLock.Unlock()
-			srv.resourceLock.Lock()
-
-			// writer lock
-			ps.currentResources = newProxyResources
-
-			api.Log.Info("Updating resources ...")
-			for _, pr := range newProxyResources {
-				// Clean path
-				pr.Resource.Path = httprouter.CleanPath(pr.Resource.Path)
-
-				// Attach resource
-				safeRouterAdderHandler(router, pr, &proxyHandler)
+		srv.updateConfig(config)
+		if srv.configFile != "" {
+			if err := writeProxyConfig(srv.configFile, config); err != nil {
+				api.Log.Errorf("Couldn't save proxy config in file %v: %v", srv.configFile, err)
 			}
-			// TODO: test when resources are empty
-			// If we had resources and those were deleted then handler must be
-			// created with empty router.
-			ps.Server.Handler = router
-			return true
 		}
-		return false
+		return true, nil
 	}
 }
 
-// Method to control when router has a resource already defined that collides with another
-func safeRouterAdderHandler(router *httprouter.Router, pr api.ProxyResource, ph *ProxyHandler) {
+// updateConfig swaps the routes of the proxy server for the ones of the config
+func (ps *ProxyServer) updateConfig(config *api.ProxyConfig) {
+	defer ps.resourceLock.Unlock()
+	ps.resourceLock.Lock()
+
+	// writer lock
+	ps.revision = config.Revision
+	ps.currentResources = config.Resources
+	ps.currentPools = config.UpstreamPools
+	ps.pools = refreshUpstreamPools(ps.pools, config.UpstreamPools, ps.proxy.ProxyFlushInterval)
+
+	api.Log.Infof("Updating resources to revision %v ...", config.Revision)
+	proxyHandler := ProxyHandler{proxy: ps.proxy, client: http.DefaultClient, cache: ps.cache, pools: ps.pools}
+	// If we had resources and those were deleted then handler is
+	// created with empty routers.
+	ps.routes.swap(newProxyRouter(config.Resources, &proxyHandler))
+}
+
+// Method to control when router has a resource already defined that collides with another. It returns false if
+// the route wasn't added.
+func safeRouterAdderHandler(router *httprouter.Router, pr api.ProxyResource, handle httprouter.Handle) (added bool) {
 	defer func() {
 		if r := recover(); r != nil {
 			api.Log.Errorf("There was a problem adding proxy resource with name %v and org %v: %v", pr.Name, pr.Org, r)
 		}
 	}()
-	router.Handle(pr.Resource.Method, pr.Resource.Path, ph.HandleRequest(pr))
+	router.Handle(pr.Resource.Method, pr.Resource.Path, handle)
+	return true
 }
 
 func strSliceContains(ss []string, s string) bool {
@@ -20,11 +20,14 @@ import (
 
 func TestNewWorker(t *testing.T) {
 	// Args
+	testApi := makeTestApi()
 	worker := &foulkon.Worker{
-		Host:     "host",
-		Port:     "port",
-		CertFile: "cert",
-		KeyFile:  "key",
+		Host:             "host",
+		Port:             "port",
+		CertFile:         "cert",
+		KeyFile:          "key",
+		InternalGroupApi: testApi,
+		SweeperInterval:  time.Minute,
 	}
 	handler := httprouter.New()
 	// Call func
@@ -36,6 +39,8 @@ func TestNewWorker(t *testing.T) {
 	assert.Equal(t, worker.CertFile, ws.certFile, "Error in test")
 	assert.Equal(t, worker.KeyFile, ws.keyFile, "Error in test")
 	assert.Equal(t, handler, ws.Handler, "Error in test")
+	assert.Equal(t, worker.InternalGroupApi, ws.sweeper, "Error in test")
+	assert.Equal(t, worker.SweeperInterval, ws.sweeperInterval, "Error in test")
 }
 
 func TestNewProxy(t *testing.T) {
@@ -128,6 +133,7 @@ func TestNewProxy(t *testing.T) {
 				ProxyApi:    testApi,
 			},
 			getProxyResourcesMethod: []api.ProxyResource{},
+			expectedResources:       []api.ProxyResource{},
 		},
 		"ErrorCaseGetProxyResources": {
 			proxy: &foulkon.Proxy{
@@ -142,7 +148,7 @@ func TestNewProxy(t *testing.T) {
 				Code:    INTERNAL_SERVER_ERROR,
 				Message: "Unknow error",
 			},
-			expectedError: "Unexpected error reading proxy resources from database Code: InternalServerError, Message: Unknow error",
+			expectedError: "Unexpected error reading proxy config Code: InternalServerError, Message: Unknow error",
 		},
 		"ErrorCaseDeployingRepeatedResourcePaths": {
 			proxy: &foulkon.Proxy{
@@ -399,6 +405,40 @@ func TestWorkerServer_Run(t *testing.T) {
 	}
 }
 
+// testSweeper counts the calls to remove expired relations
+type testSweeper struct {
+	calls chan struct{}
+}
+
+func (s *testSweeper) RemoveExpiredRelations() error {
+	s.calls <- struct{}{}
+	return nil
+}
+
+func TestWorkerServer_sweepExpiredRelations(t *testing.T) {
+	sweeper := &testSweeper{calls: make(chan struct{}, 1)}
+	ws := &WorkerServer{sweeper: sweeper}
+	ticks := make(chan time.Time)
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		ws.sweepExpiredRelations(ticks, done)
+		close(finished)
+	}()
+
+	ticks <- time.Now()
+	<-sweeper.calls
+
+	// Sweeper stops when done is closed
+	close(done)
+	<-finished
+	select {
+	case ticks <- time.Now():
+		assert.Fail(t, "Error in test: sweeper still running")
+	default:
+	}
+}
+
 func TestProxyServer_Run(t *testing.T) {
 	testcases := map[string]struct {
 		proxy *foulkon.Proxy
@@ -456,6 +496,7 @@ func TestProxyServer_Run(t *testing.T) {
 					},
 				},
 			}
+			testAPI.ArgsOut[GetProxyConfigMethod][0] = int64(2)
 
 			go func() {
 				srv.Run()
@@ -0,0 +1,156 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/julienschmidt/httprouter"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// State document formats
+	STATE_FORMAT_JSON = "json"
+	STATE_FORMAT_YAML = "yaml"
+
+	YAML_CONTENT_TYPE = "application/yaml"
+)
+
+// HANDLERS
+
+func (wh *WorkerHandler) HandleExportState(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	var format string
+	if apiErr == nil {
+		format, apiErr = getStateFormat(r, r.Header.Get("Accept"))
+	}
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+
+	// Call state API to export the state
+	response, err := wh.worker.StateAPI.ExportState(requestInfo, filterData.Org)
+	wh.processStateResponse(r, w, requestInfo, response, err, format)
+}
+
+func (wh *WorkerHandler) HandleImportState(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	requestInfo, _, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	var format string
+	if apiErr == nil {
+		format, apiErr = getStateFormat(r, r.Header.Get("Accept"))
+	}
+	state := api.State{}
+	if apiErr == nil {
+		apiErr = decodeState(r, &state)
+	}
+	mode := r.URL.Query().Get("Mode")
+	if len(mode) == 0 {
+		mode = api.IMPORT_MODE_CREATE
+	}
+	dryRun := false
+	if apiErr == nil && len(r.URL.Query().Get("DryRun")) > 0 {
+		var err error
+		if dryRun, err = strconv.ParseBool(r.URL.Query().Get("DryRun")); err != nil {
+			apiErr = &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: fmt.Sprintf("Invalid parameter: DryRun %v", r.URL.Query().Get("DryRun")),
+			}
+		}
+	}
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+
+	// Call state API to import the state
+	response, err := wh.worker.StateAPI.ImportState(requestInfo, state, mode, dryRun)
+	wh.processStateResponse(r, w, requestInfo, response, err, format)
+}
+
+// Private helper methods
+
+// processStateResponse writes the response like processHttpResponse, encoded with YAML if it is the format requested
+func (wh *WorkerHandler) processStateResponse(r *http.Request, w http.ResponseWriter, requestInfo api.RequestInfo,
+	response interface{}, err error, format string) {
+	if err != nil || format != STATE_FORMAT_YAML {
+		wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
+		return
+	}
+
+	b, err := yaml.Marshal(response)
+	if err != nil {
+		apiErr := &api.Error{
+			Code:    api.UNKNOWN_API_ERROR,
+			Message: err.Error(),
+		}
+		api.TransactionResponseErrorLog(requestInfo.RequestID, requestInfo.Identifier, r, http.StatusInternalServerError, apiErr)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("Content-Type", YAML_CONTENT_TYPE)
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// getStateFormat retrieves the format of the state documents from the Format query parameter, or
+// from the media type if it isn't passed. JSON is used by default.
+func getStateFormat(r *http.Request, mediaType string) (string, *api.Error) {
+	format := r.URL.Query().Get("Format")
+	switch format {
+	case STATE_FORMAT_JSON, STATE_FORMAT_YAML:
+		return format, nil
+	case "":
+		if isYAMLMediaType(mediaType) {
+			return STATE_FORMAT_YAML, nil
+		}
+		return STATE_FORMAT_JSON, nil
+	default:
+		return "", &api.Error{
+			Code:    api.INVALID_PARAMETER_ERROR,
+			Message: fmt.Sprintf("Invalid parameter: Format %v", format),
+		}
+	}
+}
+
+// decodeState decodes the state document of the request body, with YAML if it is its content type or JSON otherwise
+func decodeState(r *http.Request, state *api.State) *api.Error {
+	body, err := ioutil.ReadAll(r.Body)
+	if err == nil {
+		if isYAMLMediaType(r.Header.Get("Content-Type")) {
+			err = yaml.Unmarshal(body, state)
+		} else {
+			err = json.Unmarshal(body, state)
+		}
+	}
+	if err != nil {
+		return &api.Error{
+			Code:    api.INVALID_PARAMETER_ERROR,
+			Message: err.Error(),
+		}
+	}
+	return nil
+}
+
+// isYAMLMediaType checks if any of the media types of the header value is a YAML one
+func isYAMLMediaType(value string) bool {
+	for _, part := range strings.Split(value, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case YAML_CONTENT_TYPE, "application/x-yaml", "text/yaml", "text/x-yaml":
+			return true
+		}
+	}
+	return false
+}
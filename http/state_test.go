@@ -0,0 +1,349 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestWorkerHandler_HandleExportState(t *testing.T) {
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	state := &api.State{
+		Version: api.STATE_VERSION,
+		Org:     "org1",
+		Users: []api.StateUser{
+			{
+				ExternalID: "user1",
+				Path:       "/path/",
+				Policies:   []api.StatePolicyRef{{Org: "org1", Name: "policy1"}},
+			},
+		},
+		Groups: []api.StateGroup{
+			{
+				Org:      "org1",
+				Name:     "group1",
+				Path:     "/path/",
+				Members:  []api.StateMember{{ExternalID: "user1", ExpiresAt: &expiresAt}},
+				Policies: []api.StateGroupPolicy{{Name: "policy1"}},
+			},
+		},
+		Policies: []api.StatePolicy{
+			{
+				Org:  "org1",
+				Name: "policy1",
+				Path: "/path/",
+				Statements: []api.Statement{
+					{
+						Effect:    "allow",
+						Actions:   []string{api.USER_ACTION_GET_USER},
+						Resources: []string{api.GetUrnPrefix("", api.RESOURCE_USER, "/path/")},
+					},
+				},
+			},
+		},
+		ProxyResources: []api.StateProxyResource{},
+		UpstreamPools:  []api.StateUpstreamPool{},
+		OidcProviders:  []api.StateOidcProvider{},
+	}
+	testcases := map[string]struct {
+		// API method args
+		queryParams  map[string]string
+		accept       string
+		ignoreArgsIn bool
+		// Expected result
+		expectedOrg         string
+		expectedStatusCode  int
+		expectedContentType string
+		expectedResponse    *api.State
+		expectedError       api.Error
+		// Manager Results
+		exportStateResult *api.State
+		// Manager Errors
+		exportStateErr error
+	}{
+		"OkCaseJSON": {
+			queryParams:         map[string]string{"Org": "org1"},
+			expectedOrg:         "org1",
+			expectedStatusCode:  http.StatusOK,
+			expectedContentType: "application/json",
+			expectedResponse:    state,
+			exportStateResult:   state,
+		},
+		"OkCaseYAMLFormat": {
+			queryParams:         map[string]string{"Org": "org1", "Format": STATE_FORMAT_YAML},
+			expectedOrg:         "org1",
+			expectedStatusCode:  http.StatusOK,
+			expectedContentType: YAML_CONTENT_TYPE,
+			expectedResponse:    state,
+			exportStateResult:   state,
+		},
+		"OkCaseYAMLAccept": {
+			accept:              "text/yaml, application/json;q=0.5",
+			expectedOrg:         "",
+			expectedStatusCode:  http.StatusOK,
+			expectedContentType: YAML_CONTENT_TYPE,
+			expectedResponse:    state,
+			exportStateResult:   state,
+		},
+		"ErrorCaseInvalidFormat": {
+			queryParams:        map[string]string{"Format": "xml"},
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: Format xml",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			exportStateErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			expectedStatusCode: http.StatusInternalServerError,
+			exportStateErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+		testApi.ArgsIn[ExportStateMethod][1] = nil
+		testApi.ArgsOut[ExportStateMethod][0] = test.exportStateResult
+		testApi.ArgsOut[ExportStateMethod][1] = test.exportStateErr
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+EXPORT_STATE_URL, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+		if test.accept != "" {
+			req.Header.Set("Accept", test.accept)
+		}
+
+		q := req.URL.Query()
+		for param, value := range test.queryParams {
+			q.Add(param, value)
+		}
+		req.URL.RawQuery = q.Encode()
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// Check received parameters
+		if test.ignoreArgsIn {
+			assert.Nil(t, testApi.ArgsIn[ExportStateMethod][1], "Error in test case %v", n)
+		} else {
+			assert.Equal(t, test.expectedOrg, testApi.ArgsIn[ExportStateMethod][1], "Error in test case %v", n)
+		}
+
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			assert.Equal(t, test.expectedContentType, res.Header.Get("Content-Type"), "Error in test case %v", n)
+			body, err := ioutil.ReadAll(res.Body)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			if test.expectedContentType == YAML_CONTENT_TYPE {
+				expectedBody, err := yaml.Marshal(test.expectedResponse)
+				assert.Nil(t, err, "Error in test case %v", n)
+				assert.Equal(t, string(expectedBody), string(body), "Error in test case %v", n)
+			} else {
+				exportStateResponse := &api.State{}
+				err = json.Unmarshal(body, exportStateResponse)
+				assert.Nil(t, err, "Error in test case %v", n)
+				assert.Equal(t, test.expectedResponse, exportStateResponse, "Error in test case %v", n)
+			}
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleImportState(t *testing.T) {
+	state := api.State{
+		Version: api.STATE_VERSION,
+		Users: []api.StateUser{
+			{ExternalID: "user1", Path: "/path/"},
+		},
+		Groups: []api.StateGroup{
+			{
+				Org:     "org1",
+				Name:    "group1",
+				Path:    "/path/",
+				Members: []api.StateMember{{ExternalID: "user1"}},
+			},
+		},
+		Policies:       []api.StatePolicy{},
+		ProxyResources: []api.StateProxyResource{},
+		UpstreamPools:  []api.StateUpstreamPool{},
+		OidcProviders:  []api.StateOidcProvider{},
+	}
+	result := &api.ImportResult{
+		Mode:   api.IMPORT_MODE_UPSERT,
+		DryRun: true,
+		Changes: []api.StateChange{
+			{
+				Action:  api.GROUP_ACTION_ADD_MEMBER,
+				Urn:     api.CreateUrn("org1", api.RESOURCE_GROUP, "/path/", "group1"),
+				Related: api.CreateUrn("", api.RESOURCE_USER, "/path/", "user1"),
+			},
+		},
+	}
+	jsonBody, _ := json.Marshal(state)
+	yamlBody, _ := yaml.Marshal(state)
+	testcases := map[string]struct {
+		// API method args
+		queryParams  map[string]string
+		contentType  string
+		body         []byte
+		ignoreArgsIn bool
+		// Expected result
+		expectedState      api.State
+		expectedMode       string
+		expectedDryRun     bool
+		expectedStatusCode int
+		expectedResponse   *api.ImportResult
+		expectedError      api.Error
+		// Manager Results
+		importStateResult *api.ImportResult
+		// Manager Errors
+		importStateErr error
+	}{
+		"OkCaseJSON": {
+			queryParams:        map[string]string{"Mode": api.IMPORT_MODE_UPSERT, "DryRun": "true"},
+			body:               jsonBody,
+			expectedState:      state,
+			expectedMode:       api.IMPORT_MODE_UPSERT,
+			expectedDryRun:     true,
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   result,
+			importStateResult:  result,
+		},
+		"OkCaseYAMLDefaultMode": {
+			contentType:        "application/x-yaml",
+			body:               yamlBody,
+			expectedState:      state,
+			expectedMode:       api.IMPORT_MODE_CREATE,
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   result,
+			importStateResult:  result,
+		},
+		"ErrorCaseMalformedDocument": {
+			body:               []byte("{"),
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "unexpected end of JSON input",
+			},
+		},
+		"ErrorCaseInvalidDryRun": {
+			queryParams:        map[string]string{"DryRun": "maybe"},
+			body:               jsonBody,
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: DryRun maybe",
+			},
+		},
+		"ErrorCaseAlreadyExist": {
+			body:               jsonBody,
+			expectedState:      state,
+			expectedMode:       api.IMPORT_MODE_CREATE,
+			expectedStatusCode: http.StatusConflict,
+			expectedError: api.Error{
+				Code:    api.USER_ALREADY_EXIST,
+				Message: "Unable to create user, user with externalId user1 already exist",
+			},
+			importStateErr: &api.Error{
+				Code:    api.USER_ALREADY_EXIST,
+				Message: "Unable to create user, user with externalId user1 already exist",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			body:               jsonBody,
+			expectedState:      state,
+			expectedMode:       api.IMPORT_MODE_CREATE,
+			expectedStatusCode: http.StatusInternalServerError,
+			importStateErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+		testApi.ArgsIn[ImportStateMethod][1] = nil
+		testApi.ArgsIn[ImportStateMethod][2] = nil
+		testApi.ArgsIn[ImportStateMethod][3] = nil
+		testApi.ArgsOut[ImportStateMethod][0] = test.importStateResult
+		testApi.ArgsOut[ImportStateMethod][1] = test.importStateErr
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+IMPORT_STATE_URL, bytes.NewBuffer(test.body))
+		assert.Nil(t, err, "Error in test case %v", n)
+		if test.contentType != "" {
+			req.Header.Set("Content-Type", test.contentType)
+		}
+
+		q := req.URL.Query()
+		for param, value := range test.queryParams {
+			q.Add(param, value)
+		}
+		req.URL.RawQuery = q.Encode()
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// Check received parameters
+		if test.ignoreArgsIn {
+			assert.Nil(t, testApi.ArgsIn[ImportStateMethod][1], "Error in test case %v", n)
+		} else {
+			assert.Equal(t, test.expectedState, testApi.ArgsIn[ImportStateMethod][1], "Error in test case %v", n)
+			assert.Equal(t, test.expectedMode, testApi.ArgsIn[ImportStateMethod][2], "Error in test case %v", n)
+			assert.Equal(t, test.expectedDryRun, testApi.ArgsIn[ImportStateMethod][3], "Error in test case %v", n)
+		}
+
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			importStateResponse := &api.ImportResult{}
+			err = json.NewDecoder(res.Body).Decode(importStateResponse)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, importStateResponse, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/julienschmidt/httprouter"
+)
+
+// REQUESTS
+
+type CreateUpstreamPoolRequest struct {
+	Name   string                 `json:"name,omitempty"`
+	Path   string                 `json:"path,omitempty"`
+	Config api.UpstreamPoolConfig `json:"config,omitempty"`
+}
+
+type UpdateUpstreamPoolRequest struct {
+	Name   string                 `json:"name,omitempty"`
+	Path   string                 `json:"path,omitempty"`
+	Config api.UpstreamPoolConfig `json:"config,omitempty"`
+}
+
+// RESPONSES
+
+type ListUpstreamPoolsResponse struct {
+	Pools  []string `json:"pools,omitempty"`
+	Limit  int      `json:"limit"`
+	Offset int      `json:"offset"`
+	Total  int      `json:"total"`
+}
+
+// HANDLERS
+
+func (wh *WorkerHandler) HandleAddUpstreamPool(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	request := &CreateUpstreamPoolRequest{}
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, request)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+
+	// Call upstream pool API to create upstream pool
+	response, err := wh.worker.UpstreamPoolAPI.AddUpstreamPool(requestInfo, request.Name, filterData.Org, request.Path, request.Config)
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusCreated)
+}
+
+func (wh *WorkerHandler) HandleGetUpstreamPoolByName(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+
+	// Call upstream pool API to retrieve upstream pool
+	response, err := wh.worker.UpstreamPoolAPI.GetUpstreamPoolByName(requestInfo, filterData.Org, filterData.UpstreamPoolName)
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
+}
+
+func (wh *WorkerHandler) HandleListUpstreamPools(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+	// Call upstream pool API to list upstream pools
+	result, total, err := wh.worker.UpstreamPoolAPI.ListUpstreamPools(requestInfo, filterData)
+	pools := []string{}
+	for _, pool := range result {
+		pools = append(pools, pool.Name)
+	}
+	// Create response
+	response := &ListUpstreamPoolsResponse{
+		Pools:  pools,
+		Offset: filterData.Offset,
+		Limit:  filterData.Limit,
+		Total:  total,
+	}
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
+}
+
+func (wh *WorkerHandler) HandleUpdateUpstreamPool(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	request := &UpdateUpstreamPoolRequest{}
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, request)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+	// Call upstream pool API to update upstream pool
+	response, err := wh.worker.UpstreamPoolAPI.UpdateUpstreamPool(requestInfo, filterData.Org, filterData.UpstreamPoolName,
+		request.Name, request.Path, request.Config)
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
+}
+
+func (wh *WorkerHandler) HandleRemoveUpstreamPool(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+	// Call upstream pool API to remove upstream pool
+	err := wh.worker.UpstreamPoolAPI.RemoveUpstreamPool(requestInfo, filterData.Org, filterData.UpstreamPoolName)
+	wh.processHttpResponse(r, w, requestInfo, nil, err, http.StatusNoContent)
+}
@@ -0,0 +1,294 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/middleware"
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// upstreamPool balances the requests of the proxy resources that reference an upstream pool
+// between its available targets. All targets share the connections of the pool transport.
+type upstreamPool struct {
+	pool      api.UpstreamPool
+	targets   []*upstreamTarget
+	transport *http.Transport
+
+	// Round-robin counter, also used to break ties between least loaded targets
+	next uint32
+
+	stopOnce sync.Once
+	stopped  chan struct{}
+}
+
+// upstreamTarget is a target of an upstream pool. A target is available if it passes the active health
+// checks and it isn't ejected after consecutive failed requests.
+type upstreamTarget struct {
+	url   *url.URL
+	proxy *httputil.ReverseProxy
+
+	// Requests being proxied to the target, used by least-connections balancer
+	active int64
+
+	mutex sync.Mutex
+	// Active health checks state
+	healthy bool
+	checks  int
+	// Passive health checks state
+	failures     int
+	ejectedUntil time.Time
+}
+
+// newUpstreamPool returns the runtime of an upstream pool, running its active health checks if enabled
+func newUpstreamPool(pool api.UpstreamPool, flushInterval time.Duration) (*upstreamPool, error) {
+	config := pool.Config
+	up := &upstreamPool{
+		pool: pool,
+		transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			MaxIdleConns:          config.Transport.MaxIdleConns,
+			MaxIdleConnsPerHost:   config.Transport.MaxIdleConns,
+			IdleConnTimeout:       time.Duration(config.Transport.IdleConnTimeout) * time.Second,
+			ResponseHeaderTimeout: time.Duration(config.Transport.ResponseTimeout) * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+		stopped: make(chan struct{}),
+	}
+
+	for _, target := range config.Targets {
+		targetURL, err := url.Parse(target)
+		if err != nil {
+			up.stop()
+			return nil, err
+		}
+		t := &upstreamTarget{url: targetURL, healthy: true}
+		t.proxy = httputil.NewSingleHostReverseProxy(targetURL)
+		t.proxy.Transport = &targetTransport{pool: up, target: t}
+		t.proxy.FlushInterval = flushInterval
+		up.targets = append(up.targets, t)
+	}
+
+	if config.HealthCheck != nil {
+		go up.runHealthChecks(*config.HealthCheck)
+	}
+
+	return up, nil
+}
+
+// ServeHTTP proxies the request to a target of the pool, or answers with a bad gateway error if
+// none is available
+func (up *upstreamPool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	target := up.pick(time.Now())
+	if target == nil {
+		requestID := w.Header().Get(middleware.REQUEST_ID_HEADER)
+		apiErr := getErrorMessage(UPSTREAM_UNAVAILABLE,
+			fmt.Sprintf("There is no target available in upstream pool with org %v and name %v", up.pool.Org, up.pool.Name))
+		api.TransactionProxyErrorLogWithStatus(requestID, "", r, http.StatusBadGateway, apiErr)
+		WriteHttpResponse(r, w, requestID, "", http.StatusBadGateway, getErrorMessage(UPSTREAM_UNAVAILABLE, "Upstream unavailable"))
+		return
+	}
+
+	atomic.AddInt64(&target.active, 1)
+	defer atomic.AddInt64(&target.active, -1)
+
+	// The error log writer belongs to the request, so it isn't closed while the request is in progress
+	// even if the pool is stopped meanwhile
+	logWriter := api.Log.Writer()
+	defer logWriter.Close()
+	proxy := *target.proxy
+	proxy.ErrorLog = log.New(logWriter, "", 0)
+	proxy.ServeHTTP(w, r)
+}
+
+// pick selects an available target with the balancer of the pool, nil if there is none
+func (up *upstreamPool) pick(now time.Time) *upstreamTarget {
+	available := make([]*upstreamTarget, 0, len(up.targets))
+	for _, t := range up.targets {
+		if t.available(now) {
+			available = append(available, t)
+		}
+	}
+	if len(available) == 0 {
+		return nil
+	}
+
+	start := int(atomic.AddUint32(&up.next, 1)-1) % len(available)
+	if up.pool.Config.Balancer != api.UPSTREAM_BALANCER_LEAST_CONNECTIONS {
+		return available[start]
+	}
+	selected := available[start]
+	for i := 1; i < len(available); i++ {
+		t := available[(start+i)%len(available)]
+		if atomic.LoadInt64(&t.active) < atomic.LoadInt64(&selected.active) {
+			selected = t
+		}
+	}
+	return selected
+}
+
+// targetTransport sends the requests of a target with the pool transport and records their results. Errors
+// are answered with a bad gateway response, so the reverse proxy never handles them.
+type targetTransport struct {
+	pool   *upstreamPool
+	target *upstreamTarget
+}
+
+func (tt *targetTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	res, err := tt.pool.transport.RoundTrip(r)
+	if err == nil {
+		tt.pool.record(tt.target, res.StatusCode < http.StatusInternalServerError)
+		return res, nil
+	}
+
+	// Requests canceled by the client don't count as failures of the target
+	if r.Context().Err() == nil {
+		tt.pool.record(tt.target, false)
+	}
+	requestID := r.Header.Get(middleware.REQUEST_ID_HEADER)
+	apiErr := getErrorMessage(HOST_UNREACHABLE, fmt.Sprintf("Error proxying request to target %v: %v", tt.target.url, err.Error()))
+	api.TransactionProxyErrorLogWithStatus(requestID, "", r, http.StatusBadGateway, apiErr)
+	body, err := json.Marshal(getErrorMessage(HOST_UNREACHABLE, "Error proxying request to upstream"))
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", http.StatusBadGateway, http.StatusText(http.StatusBadGateway)),
+		StatusCode:    http.StatusBadGateway,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       r,
+	}, nil
+}
+
+// record updates the passive health of a target with the result of a request. The target is ejected
+// after the max failures in a row of the pool.
+func (up *upstreamPool) record(t *upstreamTarget, success bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if success {
+		t.failures = 0
+		return
+	}
+	t.failures++
+	if t.failures >= up.pool.Config.Ejection.MaxFailures {
+		t.failures = 0
+		t.ejectedUntil = time.Now().Add(time.Duration(up.pool.Config.Ejection.Duration) * time.Second)
+		api.Log.Warnf("Target %v of upstream pool with org %v and name %v ejected until %v",
+			t.url, up.pool.Org, up.pool.Name, t.ejectedUntil.Format(time.RFC3339))
+	}
+}
+
+// runHealthChecks requests the health check path of every target each interval, until the pool is stopped
+func (up *upstreamPool) runHealthChecks(hc api.UpstreamHealthCheck) {
+	client := &http.Client{
+		Transport: up.transport,
+		Timeout:   time.Duration(hc.Timeout) * time.Second,
+	}
+	ticker := time.NewTicker(time.Duration(hc.Interval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-up.stopped:
+			return
+		case <-ticker.C:
+			for _, t := range up.targets {
+				up.check(client, t, hc)
+			}
+		}
+	}
+}
+
+// check updates the active health of a target. The target changes its state after the threshold
+// of checks in a row with the other result.
+func (up *upstreamPool) check(client *http.Client, t *upstreamTarget, hc api.UpstreamHealthCheck) {
+	healthy := false
+	res, err := client.Get(t.url.String() + hc.Path)
+	if err == nil {
+		res.Body.Close()
+		healthy = res.StatusCode >= http.StatusOK && res.StatusCode < http.StatusBadRequest
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if healthy == t.healthy {
+		t.checks = 0
+		return
+	}
+	t.checks++
+	if t.checks >= hc.Threshold {
+		t.checks = 0
+		t.healthy = healthy
+		if healthy {
+			api.Log.Infof("Target %v of upstream pool with org %v and name %v is healthy", t.url, up.pool.Org, up.pool.Name)
+		} else {
+			api.Log.Warnf("Target %v of upstream pool with org %v and name %v is unhealthy", t.url, up.pool.Org, up.pool.Name)
+		}
+	}
+}
+
+// stop ends the health checks of the pool and closes its idle connections. Requests in progress aren't affected.
+func (up *upstreamPool) stop() {
+	up.stopOnce.Do(func() {
+		close(up.stopped)
+		up.transport.CloseIdleConnections()
+	})
+}
+
+// available checks if the target is healthy and it isn't ejected
+func (t *upstreamTarget) available(now time.Time) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.healthy && !now.Before(t.ejectedUntil)
+}
+
+// upstreamPoolKey identifies the upstream pool referenced by proxy resources of an org
+func upstreamPoolKey(org string, name string) string {
+	return org + "/" + name
+}
+
+// refreshUpstreamPools returns the runtime of the upstream pools. Pools with the same config are kept,
+// so their health and connections aren't lost, and the rest of current pools are stopped.
+func refreshUpstreamPools(current map[string]*upstreamPool, pools []api.UpstreamPool, flushInterval time.Duration) map[string]*upstreamPool {
+	refreshed := map[string]*upstreamPool{}
+	for _, pool := range pools {
+		key := upstreamPoolKey(pool.Org, pool.Name)
+		if up, ok := current[key]; ok && pretty.Compare(up.pool.Config, pool.Config) == "" {
+			refreshed[key] = up
+			continue
+		}
+		up, err := newUpstreamPool(pool, flushInterval)
+		if err != nil {
+			api.Log.Errorf("There was a problem creating upstream pool with name %v and org %v: %v", pool.Name, pool.Org, err)
+			continue
+		}
+		refreshed[key] = up
+	}
+	for key, up := range current {
+		if refreshed[key] != up {
+			up.stop()
+		}
+	}
+	return refreshed
+}
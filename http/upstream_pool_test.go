@@ -0,0 +1,405 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/Tecsisa/foulkon/foulkon"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpstreamPool_pick(t *testing.T) {
+	testcases := map[string]struct {
+		balancer string
+		// Active requests and state of every target
+		active  []int64
+		ejected []bool
+		healthy []bool
+		// Expected results
+		expectedTargets []int
+	}{
+		"OkCaseRoundRobin": {
+			balancer:        api.UPSTREAM_BALANCER_ROUND_ROBIN,
+			active:          []int64{0, 5, 0},
+			ejected:         []bool{false, false, false},
+			healthy:         []bool{true, true, true},
+			expectedTargets: []int{0, 1, 2, 0},
+		},
+		"OkCaseRoundRobinSkipsUnavailable": {
+			balancer:        api.UPSTREAM_BALANCER_ROUND_ROBIN,
+			active:          []int64{0, 0, 0},
+			ejected:         []bool{false, true, false},
+			healthy:         []bool{true, true, true},
+			expectedTargets: []int{0, 2, 0},
+		},
+		"OkCaseLeastConnections": {
+			balancer:        api.UPSTREAM_BALANCER_LEAST_CONNECTIONS,
+			active:          []int64{3, 1, 2},
+			ejected:         []bool{false, false, false},
+			healthy:         []bool{true, true, true},
+			expectedTargets: []int{1, 1, 1},
+		},
+		"OkCaseLeastConnectionsTies": {
+			balancer:        api.UPSTREAM_BALANCER_LEAST_CONNECTIONS,
+			active:          []int64{1, 1, 3},
+			ejected:         []bool{false, false, false},
+			healthy:         []bool{true, true, true},
+			expectedTargets: []int{0, 1, 0},
+		},
+		"OkCaseLeastConnectionsSkipsUnhealthy": {
+			balancer:        api.UPSTREAM_BALANCER_LEAST_CONNECTIONS,
+			active:          []int64{3, 1, 2},
+			ejected:         []bool{false, false, false},
+			healthy:         []bool{true, false, true},
+			expectedTargets: []int{2, 2},
+		},
+		"OkCaseNoTargetAvailable": {
+			balancer:        api.UPSTREAM_BALANCER_ROUND_ROBIN,
+			active:          []int64{0, 0},
+			ejected:         []bool{true, false},
+			healthy:         []bool{true, false},
+			expectedTargets: []int{-1},
+		},
+	}
+
+	now := time.Now()
+	for n, test := range testcases {
+		up := &upstreamPool{pool: api.UpstreamPool{Config: api.UpstreamPoolConfig{Balancer: test.balancer}}}
+		for i := range test.active {
+			target := &upstreamTarget{active: test.active[i], healthy: test.healthy[i]}
+			if test.ejected[i] {
+				target.ejectedUntil = now.Add(time.Minute)
+			}
+			up.targets = append(up.targets, target)
+		}
+
+		for i, expected := range test.expectedTargets {
+			target := up.pick(now)
+			if expected < 0 {
+				assert.Nil(t, target, "Error in test case %v, request %v", n, i)
+				continue
+			}
+			assert.True(t, up.targets[expected] == target, "Error in test case %v, request %v", n, i)
+		}
+	}
+}
+
+func TestUpstreamPool_record(t *testing.T) {
+	up := &upstreamPool{pool: api.UpstreamPool{Config: api.UpstreamPoolConfig{
+		Ejection: api.UpstreamEjection{MaxFailures: 2, Duration: 30},
+	}}}
+	target := &upstreamTarget{healthy: true}
+	up.targets = []*upstreamTarget{target}
+	now := time.Now()
+
+	// A success resets the consecutive failures
+	up.record(target, false)
+	up.record(target, true)
+	up.record(target, false)
+	assert.True(t, target.available(now), "Error recording requests")
+
+	// The target is ejected during the ejection duration
+	up.record(target, false)
+	assert.False(t, target.available(now), "Error recording requests")
+	assert.False(t, target.available(now.Add(29*time.Second)), "Error recording requests")
+	assert.True(t, target.available(now.Add(31*time.Second)), "Error recording requests")
+}
+
+func TestUpstreamPool_check(t *testing.T) {
+	status := http.StatusOK
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/health", r.URL.Path, "Error checking health")
+		w.WriteHeader(status)
+	}))
+	defer backend.Close()
+
+	pool := api.UpstreamPool{Config: api.UpstreamPoolConfig{
+		Targets:   []string{backend.URL},
+		Ejection:  api.UpstreamEjection{MaxFailures: 5, Duration: 30},
+		Transport: api.UpstreamTransport{MaxIdleConns: 10, IdleConnTimeout: 30},
+	}}
+	up, err := newUpstreamPool(pool, 0)
+	assert.Nil(t, err, "Error checking health")
+	defer up.stop()
+	hc := api.UpstreamHealthCheck{Path: "/health", Interval: 10, Timeout: 1, Threshold: 2}
+	client := &http.Client{Transport: up.transport}
+	target := up.targets[0]
+	now := time.Now()
+
+	// The target is unhealthy after the threshold of failed checks
+	status = http.StatusServiceUnavailable
+	up.check(client, target, hc)
+	assert.True(t, target.available(now), "Error checking health")
+	up.check(client, target, hc)
+	assert.False(t, target.available(now), "Error checking health")
+
+	// And healthy again after the threshold of successful checks
+	status = http.StatusOK
+	up.check(client, target, hc)
+	assert.False(t, target.available(now), "Error checking health")
+	up.check(client, target, hc)
+	assert.True(t, target.available(now), "Error checking health")
+}
+
+func TestUpstreamPool_ServeHTTP(t *testing.T) {
+	// Backends answer with their index
+	targets := []string{}
+	for i := 0; i < 2; i++ {
+		index := i
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, index)
+		}))
+		defer backend.Close()
+		targets = append(targets, backend.URL)
+	}
+	// Target refusing connections
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	targets = append(targets, down.URL)
+
+	up, err := newUpstreamPool(api.UpstreamPool{
+		Org:  "org1",
+		Name: "pool1",
+		Config: api.UpstreamPoolConfig{
+			Targets:   targets,
+			Balancer:  api.UPSTREAM_BALANCER_ROUND_ROBIN,
+			Ejection:  api.UpstreamEjection{MaxFailures: 1, Duration: 30},
+			Transport: api.UpstreamTransport{MaxIdleConns: 10, IdleConnTimeout: 30},
+		},
+	}, 0)
+	assert.Nil(t, err, "Error serving request")
+	defer up.stop()
+	router := httptest.NewServer(up)
+	defer router.Close()
+
+	get := func() (int, string) {
+		res, err := http.Get(router.URL + "/items/1")
+		assert.Nil(t, err, "Error serving request")
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		assert.Nil(t, err, "Error serving request")
+		return res.StatusCode, string(body)
+	}
+
+	// Requests are balanced between targets, and the failed target is ejected
+	for _, expected := range []string{"0", "1"} {
+		status, body := get()
+		assert.Equal(t, http.StatusOK, status, "Error serving request")
+		assert.Equal(t, expected, body, "Error serving request")
+	}
+	status, body := get()
+	assert.Equal(t, http.StatusBadGateway, status, "Error serving request")
+	apiError := api.Error{}
+	assert.Nil(t, json.Unmarshal([]byte(body), &apiError), "Error serving request")
+	assert.Equal(t, HOST_UNREACHABLE, apiError.Code, "Error serving request")
+	// The round-robin counter goes on between the available targets
+	for _, expected := range []string{"1", "0", "1"} {
+		status, body := get()
+		assert.Equal(t, http.StatusOK, status, "Error serving request")
+		assert.Equal(t, expected, body, "Error serving request")
+	}
+
+	// There is no target available if all of them are ejected
+	for _, target := range up.targets {
+		target.ejectedUntil = time.Now().Add(time.Minute)
+	}
+	status, body = get()
+	assert.Equal(t, http.StatusBadGateway, status, "Error serving request")
+	apiError = api.Error{}
+	assert.Nil(t, json.Unmarshal([]byte(body), &apiError), "Error serving request")
+	assert.Equal(t, UPSTREAM_UNAVAILABLE, apiError.Code, "Error serving request")
+}
+
+// lockedBuffer is a log output that can be read while the log writers write to it
+type lockedBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String()
+}
+
+func TestUpstreamPool_ServeHTTPAfterStop(t *testing.T) {
+	output := new(lockedBuffer)
+	logger := api.Log
+	api.Log = logrus.New()
+	api.Log.Out = output
+	defer func() { api.Log = logger }()
+
+	// Backend aborts the response body once the pool is stopped
+	release := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-release
+		panic(http.ErrAbortHandler)
+	}))
+	defer backend.Close()
+
+	up, err := newUpstreamPool(api.UpstreamPool{
+		Org:  "org1",
+		Name: "pool1",
+		Config: api.UpstreamPoolConfig{
+			Targets:   []string{backend.URL},
+			Ejection:  api.UpstreamEjection{MaxFailures: 5, Duration: 30},
+			Transport: api.UpstreamTransport{MaxIdleConns: 10, IdleConnTimeout: 30},
+		},
+	}, 0)
+	assert.Nil(t, err, "Error serving request")
+	router := httptest.NewServer(up)
+	defer router.Close()
+
+	res, err := http.Get(router.URL + "/items/1")
+	if !assert.Nil(t, err, "Error serving request") {
+		close(release)
+		return
+	}
+	defer res.Body.Close()
+	up.stop()
+	close(release)
+	ioutil.ReadAll(res.Body)
+
+	// The error of the request in progress is still logged
+	logged := false
+	for i := 0; i < 100 && !logged; i++ {
+		logged = strings.Contains(output.String(), "ReverseProxy read error")
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, logged, "Error serving request")
+}
+
+func TestRefreshUpstreamPools(t *testing.T) {
+	config := api.UpstreamPoolConfig{
+		Targets:   []string{"http://10.0.0.1:8080"},
+		Ejection:  api.UpstreamEjection{MaxFailures: 5, Duration: 30},
+		Transport: api.UpstreamTransport{MaxIdleConns: 10, IdleConnTimeout: 30},
+	}
+	newConfig := config
+	newConfig.Targets = []string{"http://10.0.0.2:8080"}
+
+	current := refreshUpstreamPools(nil, []api.UpstreamPool{
+		{Org: "org1", Name: "pool1", Config: config},
+		{Org: "org1", Name: "pool2", Config: config},
+		{Org: "org2", Name: "pool1", Config: config},
+	}, 0)
+	assert.Equal(t, 3, len(current), "Error refreshing upstream pools")
+
+	refreshed := refreshUpstreamPools(current, []api.UpstreamPool{
+		{Org: "org1", Name: "pool1", Config: config},
+		{Org: "org1", Name: "pool2", Config: newConfig},
+	}, 0)
+	defer func() {
+		for _, up := range refreshed {
+			up.stop()
+		}
+	}()
+
+	// Pools with the same config are kept, the rest are stopped
+	assert.Equal(t, 2, len(refreshed), "Error refreshing upstream pools")
+	assert.True(t, current["org1/pool1"] == refreshed["org1/pool1"], "Error refreshing upstream pools")
+	assert.False(t, current["org1/pool2"] == refreshed["org1/pool2"], "Error refreshing upstream pools")
+	assert.Equal(t, newConfig, refreshed["org1/pool2"].pool.Config, "Error refreshing upstream pools")
+	for key, stopped := range map[string]bool{"org1/pool1": false, "org1/pool2": true, "org2/pool1": true} {
+		select {
+		case <-current[key].stopped:
+			assert.True(t, stopped, "Error refreshing upstream pools, pool %v", key)
+		default:
+			assert.False(t, stopped, "Error refreshing upstream pools, pool %v", key)
+		}
+	}
+}
+
+func TestProxyHandler_serveUpstreamPool(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "pool1")
+	}))
+	defer backend.Close()
+
+	pools := refreshUpstreamPools(nil, []api.UpstreamPool{
+		{
+			Org:  "org1",
+			Name: "pool1",
+			Config: api.UpstreamPoolConfig{
+				Targets:   []string{backend.URL},
+				Ejection:  api.UpstreamEjection{MaxFailures: 5, Duration: 30},
+				Transport: api.UpstreamTransport{MaxIdleConns: 10, IdleConnTimeout: 30},
+			},
+		},
+	}, 0)
+	defer pools["org1/pool1"].stop()
+
+	resources := []api.ProxyResource{}
+	for _, upstream := range []string{"pool1", "unknown"} {
+		resources = append(resources, api.ProxyResource{
+			Name: upstream,
+			Org:  "org1",
+			Resource: api.ResourceEntity{
+				Upstream: upstream,
+				Path:     "/" + upstream,
+				Method:   http.MethodGet,
+				Urn:      "urn:ews:example:instance1:resource/get",
+				Action:   "example:get",
+			},
+		})
+	}
+	proxyHandler := &ProxyHandler{proxy: &foulkon.Proxy{WorkerHost: server.URL, ProxyApi: testApi}, client: http.DefaultClient, pools: pools}
+	router := httptest.NewServer(newProxyRouter(resources, proxyHandler))
+	defer router.Close()
+
+	testcases := map[string]struct {
+		path string
+		// Expected results
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		"OkCase": {
+			path:               "/pool1",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "pool1",
+		},
+		"ErrorCaseUpstreamPoolNotFound": {
+			path:               "/unknown",
+			expectedStatusCode: http.StatusBadGateway,
+		},
+	}
+
+	for n, test := range testcases {
+		testApi.ArgsOut[GetAuthorizedExternalResourcesMethod][0] = []string{"urn:ews:example:instance1:resource/get"}
+		testApi.ArgsOut[GetAuthorizedExternalResourcesMethod][1] = nil
+
+		res, err := http.Get(router.URL + test.path)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		// Check response
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+		body, err := ioutil.ReadAll(res.Body)
+		assert.Nil(t, err, "Error in test case %v", n)
+		if test.expectedBody != "" {
+			assert.Equal(t, test.expectedBody, string(body), "Error in test case %v", n)
+		} else {
+			apiError := api.Error{}
+			assert.Nil(t, json.Unmarshal(body, &apiError), "Error in test case %v", n)
+			assert.Equal(t, UPSTREAM_UNAVAILABLE, apiError.Code, "Error in test case %v", n)
+		}
+		res.Body.Close()
+	}
+}
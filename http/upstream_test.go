@@ -0,0 +1,734 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerHandler_HandleAddUpstreamPool(t *testing.T) {
+	now := time.Now().UTC()
+	config := api.UpstreamPoolConfig{
+		Targets:  []string{"http://10.0.0.1:8080", "http://10.0.0.2:8080"},
+		Balancer: api.UPSTREAM_BALANCER_LEAST_CONNECTIONS,
+	}
+	testcases := map[string]struct {
+		// API method args
+		org     string
+		request *CreateUpstreamPoolRequest
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   api.UpstreamPool
+		expectedError      api.Error
+		// Manager Results
+		addUpstreamPoolResult *api.UpstreamPool
+		// Manager Errors
+		addUpstreamPoolErr error
+	}{
+		"OkCase": {
+			org: "org1",
+			request: &CreateUpstreamPoolRequest{
+				Name:   "pool1",
+				Path:   "/path/",
+				Config: config,
+			},
+			addUpstreamPoolResult: &api.UpstreamPool{
+				ID:       "test1",
+				Name:     "pool1",
+				Org:      "org1",
+				Path:     "/path/",
+				Urn:      api.CreateUrn("org1", api.RESOURCE_UPSTREAM_POOL, "/path/", "pool1"),
+				Config:   config,
+				CreateAt: now,
+				UpdateAt: now,
+			},
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse: api.UpstreamPool{
+				ID:       "test1",
+				Name:     "pool1",
+				Org:      "org1",
+				Path:     "/path/",
+				Urn:      api.CreateUrn("org1", api.RESOURCE_UPSTREAM_POOL, "/path/", "pool1"),
+				Config:   config,
+				CreateAt: now,
+				UpdateAt: now,
+			},
+		},
+		"ErrorCaseMalformedRequest": {
+			org:                "org1",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "EOF",
+			},
+		},
+		"ErrorCaseUpstreamPoolAlreadyExists": {
+			org: "org1",
+			request: &CreateUpstreamPoolRequest{
+				Name:   "pool1",
+				Path:   "/path/",
+				Config: config,
+			},
+			expectedStatusCode: http.StatusConflict,
+			expectedError: api.Error{
+				Code:    api.UPSTREAM_POOL_ALREADY_EXIST,
+				Message: "Upstream pool already exist",
+			},
+			addUpstreamPoolErr: &api.Error{
+				Code:    api.UPSTREAM_POOL_ALREADY_EXIST,
+				Message: "Upstream pool already exist",
+			},
+		},
+		"ErrorCaseInvalidParameterError": {
+			org: "org1",
+			request: &CreateUpstreamPoolRequest{
+				Name: "pool1",
+				Path: "/path/",
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid Parameter",
+			},
+			addUpstreamPoolErr: &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid Parameter",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			org: "org1",
+			request: &CreateUpstreamPoolRequest{
+				Name:   "pool1",
+				Path:   "/path/",
+				Config: config,
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			addUpstreamPoolErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			org: "org1",
+			request: &CreateUpstreamPoolRequest{
+				Name:   "pool1",
+				Path:   "/path/",
+				Config: config,
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			addUpstreamPoolErr: &api.Error{
+				Code: api.UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[AddUpstreamPoolMethod][0] = test.addUpstreamPoolResult
+		testApi.ArgsOut[AddUpstreamPoolMethod][1] = test.addUpstreamPoolErr
+
+		var body *bytes.Buffer
+		if test.request != nil {
+			jsonObject, err := json.Marshal(test.request)
+			assert.Nil(t, err, "Error in test case %v", n)
+			body = bytes.NewBuffer(jsonObject)
+		}
+		if body == nil {
+			body = bytes.NewBuffer([]byte{})
+		}
+
+		url := fmt.Sprintf(server.URL+API_VERSION_1+"/organizations/%v/upstream-pools", test.org)
+		req, err := http.NewRequest(http.MethodPost, url, body)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if test.request != nil {
+			// Check received parameters
+			assert.Equal(t, test.request.Name, testApi.ArgsIn[AddUpstreamPoolMethod][1], "Error in test case %v", n)
+			assert.Equal(t, test.org, testApi.ArgsIn[AddUpstreamPoolMethod][2], "Error in test case %v", n)
+			assert.Equal(t, test.request.Path, testApi.ArgsIn[AddUpstreamPoolMethod][3], "Error in test case %v", n)
+			assert.Equal(t, test.request.Config, testApi.ArgsIn[AddUpstreamPoolMethod][4], "Error in test case %v", n)
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusCreated:
+			response := api.UpstreamPool{}
+			err = json.NewDecoder(res.Body).Decode(&response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleGetUpstreamPoolByName(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API method args
+		org          string
+		name         string
+		offset       string
+		ignoreArgsIn bool
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   api.UpstreamPool
+		expectedError      api.Error
+		// Manager Results
+		getUpstreamPoolByNameResult *api.UpstreamPool
+		// Manager Errors
+		getUpstreamPoolByNameErr error
+	}{
+		"OkCase": {
+			org:                "org1",
+			name:               "pool1",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: api.UpstreamPool{
+				ID:       "test1",
+				Name:     "pool1",
+				Org:      "org1",
+				Path:     "/path/",
+				Urn:      api.CreateUrn("org1", api.RESOURCE_UPSTREAM_POOL, "/path/", "pool1"),
+				Config:   api.UpstreamPoolConfig{Targets: []string{"http://10.0.0.1:8080"}},
+				CreateAt: now,
+				UpdateAt: now,
+			},
+			getUpstreamPoolByNameResult: &api.UpstreamPool{
+				ID:       "test1",
+				Name:     "pool1",
+				Org:      "org1",
+				Path:     "/path/",
+				Urn:      api.CreateUrn("org1", api.RESOURCE_UPSTREAM_POOL, "/path/", "pool1"),
+				Config:   api.UpstreamPoolConfig{Targets: []string{"http://10.0.0.1:8080"}},
+				CreateAt: now,
+				UpdateAt: now,
+			},
+		},
+		"ErrorCaseInvalidRequest": {
+			org:                "org1",
+			name:               "pool1",
+			offset:             "-1",
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: Offset -1",
+			},
+		},
+		"ErrorCaseUpstreamPoolNotFound": {
+			org:                "org1",
+			name:               "pool1",
+			expectedStatusCode: http.StatusNotFound,
+			getUpstreamPoolByNameErr: &api.Error{
+				Code:    api.UPSTREAM_POOL_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Upstream pool not found",
+			},
+			expectedError: api.Error{
+				Code:    api.UPSTREAM_POOL_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Upstream pool not found",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			org:                "org1",
+			name:               "pool1",
+			expectedStatusCode: http.StatusForbidden,
+			getUpstreamPoolByNameErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseInternalServerError": {
+			org:                "org1",
+			name:               "pool1",
+			expectedStatusCode: http.StatusInternalServerError,
+			getUpstreamPoolByNameErr: &api.Error{
+				Code: api.UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[GetUpstreamPoolByNameMethod][0] = test.getUpstreamPoolByNameResult
+		testApi.ArgsOut[GetUpstreamPoolByNameMethod][1] = test.getUpstreamPoolByNameErr
+
+		url := fmt.Sprintf(server.URL+API_VERSION_1+"/organizations/%v/upstream-pools/%v", test.org, test.name)
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		q := req.URL.Query()
+		q.Add("Offset", test.offset)
+		req.URL.RawQuery = q.Encode()
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if !test.ignoreArgsIn {
+			// Check received parameters
+			assert.Equal(t, test.org, testApi.ArgsIn[GetUpstreamPoolByNameMethod][1], "Error in test case %v", n)
+			assert.Equal(t, test.name, testApi.ArgsIn[GetUpstreamPoolByNameMethod][2], "Error in test case %v", n)
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			response := api.UpstreamPool{}
+			err = json.NewDecoder(res.Body).Decode(&response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+			assert.Equal(t, api.EntityTag(test.expectedResponse.UpdateAt), res.Header.Get(ETAG_HEADER), "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleListUpstreamPools(t *testing.T) {
+	testcases := map[string]struct {
+		// API method args
+		filter       *api.Filter
+		ignoreArgsIn bool
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   ListUpstreamPoolsResponse
+		expectedError      api.Error
+		// Manager Results
+		listUpstreamPoolsResult []api.UpstreamPoolIdentity
+		listUpstreamPoolsTotal  int
+		// Manager Errors
+		listUpstreamPoolsErr error
+	}{
+		"OkCase": {
+			filter: &api.Filter{
+				Org:        "org1",
+				PathPrefix: "/path/",
+				Offset:     0,
+				Limit:      0,
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: ListUpstreamPoolsResponse{
+				Pools:  []string{"pool1", "pool2"},
+				Offset: 0,
+				Limit:  0,
+				Total:  2,
+			},
+			listUpstreamPoolsResult: []api.UpstreamPoolIdentity{
+				{Org: "org1", Name: "pool1"},
+				{Org: "org1", Name: "pool2"},
+			},
+			listUpstreamPoolsTotal: 2,
+		},
+		"ErrorCaseInvalidFilterParams": {
+			filter: &api.Filter{
+				Org:   "org1",
+				Limit: -1,
+			},
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: Limit -1",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			filter: &api.Filter{
+				Org:        "org1",
+				PathPrefix: "/path/",
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			listUpstreamPoolsErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			filter: &api.Filter{
+				Org: "org1",
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			listUpstreamPoolsErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[ListUpstreamPoolsMethod][0] = test.listUpstreamPoolsResult
+		testApi.ArgsOut[ListUpstreamPoolsMethod][1] = test.listUpstreamPoolsTotal
+		testApi.ArgsOut[ListUpstreamPoolsMethod][2] = test.listUpstreamPoolsErr
+
+		url := fmt.Sprintf(server.URL+API_VERSION_1+"/organizations/%v/upstream-pools", test.filter.Org)
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		addQueryParams(test.filter, req)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if !test.ignoreArgsIn {
+			// Check received parameters
+			filterData, ok := testApi.ArgsIn[ListUpstreamPoolsMethod][1].(*api.Filter)
+			if ok {
+				// Check result
+				assert.Equal(t, test.filter, filterData, "Error in test case %v", n)
+			}
+		}
+
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			listUpstreamPoolsResponse := ListUpstreamPoolsResponse{}
+			err = json.NewDecoder(res.Body).Decode(&listUpstreamPoolsResponse)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, listUpstreamPoolsResponse, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleUpdateUpstreamPool(t *testing.T) {
+	now := time.Now().UTC()
+	config := api.UpstreamPoolConfig{
+		Targets: []string{"http://10.0.0.3:8080"},
+	}
+	testcases := map[string]struct {
+		// API method args
+		org     string
+		name    string
+		request *UpdateUpstreamPoolRequest
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   api.UpstreamPool
+		expectedError      api.Error
+		// Manager Results
+		updateUpstreamPoolResult *api.UpstreamPool
+		// Manager Errors
+		updateUpstreamPoolErr error
+	}{
+		"OkCase": {
+			org:  "org1",
+			name: "pool1",
+			request: &UpdateUpstreamPoolRequest{
+				Name:   "pool2",
+				Path:   "/new/",
+				Config: config,
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: api.UpstreamPool{
+				ID:       "test1",
+				Name:     "pool2",
+				Org:      "org1",
+				Path:     "/new/",
+				Urn:      api.CreateUrn("org1", api.RESOURCE_UPSTREAM_POOL, "/new/", "pool2"),
+				Config:   config,
+				CreateAt: now,
+				UpdateAt: now,
+			},
+			updateUpstreamPoolResult: &api.UpstreamPool{
+				ID:       "test1",
+				Name:     "pool2",
+				Org:      "org1",
+				Path:     "/new/",
+				Urn:      api.CreateUrn("org1", api.RESOURCE_UPSTREAM_POOL, "/new/", "pool2"),
+				Config:   config,
+				CreateAt: now,
+				UpdateAt: now,
+			},
+		},
+		"ErrorCaseMalformedRequest": {
+			org:                "org1",
+			name:               "pool1",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "EOF",
+			},
+		},
+		"ErrorCaseUpstreamPoolNotFound": {
+			org:  "org1",
+			name: "pool1",
+			request: &UpdateUpstreamPoolRequest{
+				Name:   "pool1",
+				Path:   "/path/",
+				Config: config,
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code:    api.UPSTREAM_POOL_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Upstream pool not found",
+			},
+			updateUpstreamPoolErr: &api.Error{
+				Code:    api.UPSTREAM_POOL_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Upstream pool not found",
+			},
+		},
+		"ErrorCaseUpstreamPoolInUse": {
+			org:  "org1",
+			name: "pool1",
+			request: &UpdateUpstreamPoolRequest{
+				Name:   "pool2",
+				Path:   "/path/",
+				Config: config,
+			},
+			expectedStatusCode: http.StatusConflict,
+			expectedError: api.Error{
+				Code:    api.UPSTREAM_POOL_IN_USE,
+				Message: "Upstream pool in use",
+			},
+			updateUpstreamPoolErr: &api.Error{
+				Code:    api.UPSTREAM_POOL_IN_USE,
+				Message: "Upstream pool in use",
+			},
+		},
+		"ErrorCasePreconditionFailed": {
+			org:  "org1",
+			name: "pool1",
+			request: &UpdateUpstreamPoolRequest{
+				Name:   "pool1",
+				Path:   "/path/",
+				Config: config,
+			},
+			expectedStatusCode: http.StatusPreconditionFailed,
+			expectedError: api.Error{
+				Code:    api.PRECONDITION_FAILED,
+				Message: "Precondition failed",
+			},
+			updateUpstreamPoolErr: &api.Error{
+				Code:    api.PRECONDITION_FAILED,
+				Message: "Precondition failed",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			org:  "org1",
+			name: "pool1",
+			request: &UpdateUpstreamPoolRequest{
+				Name:   "pool1",
+				Path:   "/path/",
+				Config: config,
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			updateUpstreamPoolErr: &api.Error{
+				Code: api.UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[UpdateUpstreamPoolMethod][0] = test.updateUpstreamPoolResult
+		testApi.ArgsOut[UpdateUpstreamPoolMethod][1] = test.updateUpstreamPoolErr
+
+		var body *bytes.Buffer
+		if test.request != nil {
+			jsonObject, err := json.Marshal(test.request)
+			assert.Nil(t, err, "Error in test case %v", n)
+			body = bytes.NewBuffer(jsonObject)
+		}
+		if body == nil {
+			body = bytes.NewBuffer([]byte{})
+		}
+
+		url := fmt.Sprintf(server.URL+API_VERSION_1+"/organizations/%v/upstream-pools/%v", test.org, test.name)
+		req, err := http.NewRequest(http.MethodPut, url, body)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if test.request != nil {
+			// Check received parameters
+			assert.Equal(t, test.org, testApi.ArgsIn[UpdateUpstreamPoolMethod][1], "Error in test case %v", n)
+			assert.Equal(t, test.name, testApi.ArgsIn[UpdateUpstreamPoolMethod][2], "Error in test case %v", n)
+			assert.Equal(t, test.request.Name, testApi.ArgsIn[UpdateUpstreamPoolMethod][3], "Error in test case %v", n)
+			assert.Equal(t, test.request.Path, testApi.ArgsIn[UpdateUpstreamPoolMethod][4], "Error in test case %v", n)
+			assert.Equal(t, test.request.Config, testApi.ArgsIn[UpdateUpstreamPoolMethod][5], "Error in test case %v", n)
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			response := api.UpstreamPool{}
+			err = json.NewDecoder(res.Body).Decode(&response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleRemoveUpstreamPool(t *testing.T) {
+	testcases := map[string]struct {
+		// API method args
+		org          string
+		name         string
+		offset       string
+		ignoreArgsIn bool
+		// Expected result
+		expectedStatusCode int
+		expectedError      api.Error
+		// Manager Errors
+		removeUpstreamPoolErr error
+	}{
+		"OkCase": {
+			org:                "org1",
+			name:               "pool1",
+			expectedStatusCode: http.StatusNoContent,
+		},
+		"ErrorCaseInvalidRequest": {
+			org:                "org1",
+			name:               "pool1",
+			offset:             "-1",
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: Offset -1",
+			},
+		},
+		"ErrorCaseUpstreamPoolNotFound": {
+			org:                "org1",
+			name:               "pool1",
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code:    api.UPSTREAM_POOL_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Upstream pool not found",
+			},
+			removeUpstreamPoolErr: &api.Error{
+				Code:    api.UPSTREAM_POOL_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Upstream pool not found",
+			},
+		},
+		"ErrorCaseUpstreamPoolInUse": {
+			org:                "org1",
+			name:               "pool1",
+			expectedStatusCode: http.StatusConflict,
+			expectedError: api.Error{
+				Code:    api.UPSTREAM_POOL_IN_USE,
+				Message: "Upstream pool in use",
+			},
+			removeUpstreamPoolErr: &api.Error{
+				Code:    api.UPSTREAM_POOL_IN_USE,
+				Message: "Upstream pool in use",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			org:                "org1",
+			name:               "pool1",
+			expectedStatusCode: http.StatusInternalServerError,
+			removeUpstreamPoolErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[RemoveUpstreamPoolMethod][0] = test.removeUpstreamPoolErr
+
+		url := fmt.Sprintf(server.URL+API_VERSION_1+"/organizations/%v/upstream-pools/%v", test.org, test.name)
+		req, err := http.NewRequest(http.MethodDelete, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		q := req.URL.Query()
+		q.Add("Offset", test.offset)
+		req.URL.RawQuery = q.Encode()
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if !test.ignoreArgsIn {
+			// Check received parameters
+			assert.Equal(t, test.org, testApi.ArgsIn[RemoveUpstreamPoolMethod][1], "Error in test case %v", n)
+			assert.Equal(t, test.name, testApi.ArgsIn[RemoveUpstreamPoolMethod][2], "Error in test case %v", n)
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusNoContent:
+			// No message expected
+			continue
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
@@ -34,6 +34,13 @@ type GetGroupsByUserIdResponse struct {
 	Total  int              `json:"total"`
 }
 
+type ListAttachedUserPoliciesResponse struct {
+	AttachedPolicies []api.UserPolicies `json:"policies,omitempty"`
+	Limit            int                `json:"limit"`
+	Offset           int                `json:"offset"`
+	Total            int                `json:"total"`
+}
+
 // HANDLERS
 
 func (wh *WorkerHandler) HandleAddUser(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
@@ -128,3 +135,46 @@ func (wh *WorkerHandler) HandleListGroupsByUser(w http.ResponseWriter, r *http.R
 	}
 	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
 }
+
+func (wh *WorkerHandler) HandleAttachPolicyToUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+	// Call user API to attach policy to user
+	err := wh.worker.UserApi.AttachPolicyToUser(requestInfo, filterData.ExternalID, filterData.Org, filterData.PolicyName)
+	wh.processHttpResponse(r, w, requestInfo, nil, err, http.StatusNoContent)
+}
+
+func (wh *WorkerHandler) HandleDetachPolicyFromUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+	// Call user API to detach policy from user
+	err := wh.worker.UserApi.DetachPolicyFromUser(requestInfo, filterData.ExternalID, filterData.Org, filterData.PolicyName)
+	wh.processHttpResponse(r, w, requestInfo, nil, err, http.StatusNoContent)
+}
+
+func (wh *WorkerHandler) HandleListAttachedUserPolicies(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Process request
+	requestInfo, filterData, apiErr := wh.processHttpRequest(r, w, ps, nil)
+	if apiErr != nil {
+		wh.processHttpResponse(r, w, requestInfo, nil, apiErr, http.StatusBadRequest)
+		return
+	}
+	// Call user API to list user policies
+	result, total, err := wh.worker.UserApi.ListAttachedUserPolicies(requestInfo, filterData)
+	// Create response
+	response := &ListAttachedUserPoliciesResponse{
+		AttachedPolicies: result,
+		Offset:           filterData.Offset,
+		Limit:            filterData.Limit,
+		Total:            total,
+	}
+	wh.processHttpResponse(r, w, requestInfo, response, err, http.StatusOK)
+}
@@ -293,6 +293,7 @@ func TestWorkerHandler_HandleGetUserByExternalID(t *testing.T) {
 			assert.Nil(t, err, "Error in test case %v", n)
 			// Check result
 			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+			assert.Equal(t, api.EntityTag(test.expectedResponse.UpdateAt), res.Header.Get(ETAG_HEADER), "Error in test case %v", n)
 		case http.StatusInternalServerError: // Empty message so continue
 			continue
 		default:
@@ -868,3 +869,467 @@ func TestWorkerHandler_HandleListGroupsByUser(t *testing.T) {
 		}
 	}
 }
+
+func TestWorkerHandler_HandleAttachPolicyToUser(t *testing.T) {
+	testcases := map[string]struct {
+		// API method args
+		externalID   string
+		org          string
+		policyName   string
+		offset       string
+		ignoreArgsIn bool
+		// Expected result
+		expectedStatusCode int
+		expectedError      api.Error
+		// Manager Errors
+		attachPolicyToUserErr error
+	}{
+		"OkCase": {
+			externalID:         "UserID",
+			org:                "org1",
+			policyName:         "policy1",
+			expectedStatusCode: http.StatusNoContent,
+		},
+		"ErrorCaseInvalidRequest": {
+			externalID:         "UserID",
+			org:                "org1",
+			policyName:         "policy1",
+			offset:             "-1",
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: Offset -1",
+			},
+		},
+		"ErrorCaseUserNotFoundErr": {
+			externalID:         "UserID",
+			org:                "org1",
+			policyName:         "policy1",
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code:    api.USER_BY_EXTERNAL_ID_NOT_FOUND,
+				Message: "User Not Found",
+			},
+			attachPolicyToUserErr: &api.Error{
+				Code:    api.USER_BY_EXTERNAL_ID_NOT_FOUND,
+				Message: "User Not Found",
+			},
+		},
+		"ErrorCasePolicyNotFoundErr": {
+			externalID:         "UserID",
+			org:                "org1",
+			policyName:         "policy1",
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code:    api.POLICY_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Policy Not Found",
+			},
+			attachPolicyToUserErr: &api.Error{
+				Code:    api.POLICY_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Policy Not Found",
+			},
+		},
+		"ErrorCaseInvalidParameterErr": {
+			externalID:         "UserID",
+			org:                "org1",
+			policyName:         "policy1",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid Parameter",
+			},
+			attachPolicyToUserErr: &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid Parameter",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			externalID:         "UserID",
+			org:                "org1",
+			policyName:         "policy1",
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			attachPolicyToUserErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCasePolicyIsAlreadyAttachedErr": {
+			externalID:         "UserID",
+			org:                "org1",
+			policyName:         "policy1",
+			expectedStatusCode: http.StatusConflict,
+			expectedError: api.Error{
+				Code:    api.POLICY_IS_ALREADY_ATTACHED_TO_USER,
+				Message: "Policy is already attached to user",
+			},
+			attachPolicyToUserErr: &api.Error{
+				Code:    api.POLICY_IS_ALREADY_ATTACHED_TO_USER,
+				Message: "Policy is already attached to user",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			externalID:         "UserID",
+			org:                "org1",
+			policyName:         "policy1",
+			expectedStatusCode: http.StatusInternalServerError,
+			attachPolicyToUserErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[AttachPolicyToUserMethod][0] = test.attachPolicyToUserErr
+
+		url := fmt.Sprintf(server.URL+API_VERSION_1+"/users/%v/policies/%v/%v", test.externalID, test.org, test.policyName)
+		req, err := http.NewRequest(http.MethodPost, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		q := req.URL.Query()
+		q.Add("Offset", test.offset)
+		req.URL.RawQuery = q.Encode()
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if !test.ignoreArgsIn {
+			// Check received parameters
+			assert.Equal(t, test.externalID, testApi.ArgsIn[AttachPolicyToUserMethod][1], "Error in test case %v", n)
+			assert.Equal(t, test.org, testApi.ArgsIn[AttachPolicyToUserMethod][2], "Error in test case %v", n)
+			assert.Equal(t, test.policyName, testApi.ArgsIn[AttachPolicyToUserMethod][3], "Error in test case %v", n)
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusNoContent:
+			// No message expected
+			continue
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleDetachPolicyFromUser(t *testing.T) {
+	testcases := map[string]struct {
+		// API method args
+		externalID   string
+		org          string
+		policyName   string
+		offset       string
+		ignoreArgsIn bool
+		// Expected result
+		expectedStatusCode int
+		expectedError      api.Error
+		// Manager Errors
+		detachPolicyFromUserErr error
+	}{
+		"OkCase": {
+			externalID:         "UserID",
+			org:                "org1",
+			policyName:         "policy1",
+			expectedStatusCode: http.StatusNoContent,
+		},
+		"ErrorCaseInvalidRequest": {
+			externalID:         "UserID",
+			org:                "org1",
+			policyName:         "policy1",
+			offset:             "-1",
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: Offset -1",
+			},
+		},
+		"ErrorCaseUserNotFoundErr": {
+			externalID:         "UserID",
+			org:                "org1",
+			policyName:         "policy1",
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code:    api.USER_BY_EXTERNAL_ID_NOT_FOUND,
+				Message: "User Not Found",
+			},
+			detachPolicyFromUserErr: &api.Error{
+				Code:    api.USER_BY_EXTERNAL_ID_NOT_FOUND,
+				Message: "User Not Found",
+			},
+		},
+		"ErrorCasePolicyNotFoundErr": {
+			externalID:         "UserID",
+			org:                "org1",
+			policyName:         "policy1",
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code:    api.POLICY_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Policy Not Found",
+			},
+			detachPolicyFromUserErr: &api.Error{
+				Code:    api.POLICY_BY_ORG_AND_NAME_NOT_FOUND,
+				Message: "Policy Not Found",
+			},
+		},
+		"ErrorCasePolicyIsNotAttachedErr": {
+			externalID:         "UserID",
+			org:                "org1",
+			policyName:         "policy1",
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code:    api.POLICY_IS_NOT_ATTACHED_TO_USER,
+				Message: "Policy is not attached to user",
+			},
+			detachPolicyFromUserErr: &api.Error{
+				Code:    api.POLICY_IS_NOT_ATTACHED_TO_USER,
+				Message: "Policy is not attached to user",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			externalID:         "UserID",
+			org:                "org1",
+			policyName:         "policy1",
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			detachPolicyFromUserErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			externalID:         "UserID",
+			org:                "org1",
+			policyName:         "policy1",
+			expectedStatusCode: http.StatusInternalServerError,
+			detachPolicyFromUserErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[DetachPolicyFromUserMethod][0] = test.detachPolicyFromUserErr
+
+		url := fmt.Sprintf(server.URL+API_VERSION_1+"/users/%v/policies/%v/%v", test.externalID, test.org, test.policyName)
+		req, err := http.NewRequest(http.MethodDelete, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		q := req.URL.Query()
+		q.Add("Offset", test.offset)
+		req.URL.RawQuery = q.Encode()
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if !test.ignoreArgsIn {
+			// Check received parameters
+			assert.Equal(t, test.externalID, testApi.ArgsIn[DetachPolicyFromUserMethod][1], "Error in test case %v", n)
+			assert.Equal(t, test.org, testApi.ArgsIn[DetachPolicyFromUserMethod][2], "Error in test case %v", n)
+			assert.Equal(t, test.policyName, testApi.ArgsIn[DetachPolicyFromUserMethod][3], "Error in test case %v", n)
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusNoContent:
+			// No message expected
+			continue
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleListAttachedUserPolicies(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API method args
+		filter       *api.Filter
+		ignoreArgsIn bool
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   ListAttachedUserPoliciesResponse
+		expectedError      api.Error
+		// Manager Results
+		getListAttachedUserPoliciesResult []api.UserPolicies
+		totalPoliciesResult               int
+		// Manager Errors
+		getListAttachedUserPoliciesErr error
+	}{
+		"OkCase": {
+			filter: &api.Filter{
+				ExternalID: "UserID",
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: ListAttachedUserPoliciesResponse{
+				AttachedPolicies: []api.UserPolicies{
+					{
+						Org:      "org1",
+						Policy:   "policy1",
+						CreateAt: now,
+					},
+					{
+						Org:      "org1",
+						Policy:   "policy2",
+						CreateAt: now,
+					},
+				},
+				Total: 2,
+			},
+			getListAttachedUserPoliciesResult: []api.UserPolicies{
+				{
+					Org:      "org1",
+					Policy:   "policy1",
+					CreateAt: now,
+				},
+				{
+					Org:      "org1",
+					Policy:   "policy2",
+					CreateAt: now,
+				},
+			},
+			totalPoliciesResult: 2,
+		},
+		"ErrorCaseInvalidFilterParams": {
+			filter: &api.Filter{
+				ExternalID: "UserID",
+				Offset:     -1,
+			},
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: Offset -1",
+			},
+		},
+		"ErrorCaseUserNotFoundErr": {
+			filter: &api.Filter{
+				ExternalID: "UserID",
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code:    api.USER_BY_EXTERNAL_ID_NOT_FOUND,
+				Message: "User Not Found",
+			},
+			getListAttachedUserPoliciesErr: &api.Error{
+				Code:    api.USER_BY_EXTERNAL_ID_NOT_FOUND,
+				Message: "User Not Found",
+			},
+		},
+		"ErrorCaseInvalidParameterErr": {
+			filter: &api.Filter{
+				ExternalID: "UserID",
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid Parameter",
+			},
+			getListAttachedUserPoliciesErr: &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid Parameter",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			filter: &api.Filter{
+				ExternalID: "UserID",
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			getListAttachedUserPoliciesErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			filter: &api.Filter{
+				ExternalID: "UserID",
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			getListAttachedUserPoliciesErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[ListAttachedUserPoliciesMethod][0] = test.getListAttachedUserPoliciesResult
+		testApi.ArgsOut[ListAttachedUserPoliciesMethod][1] = test.totalPoliciesResult
+		testApi.ArgsOut[ListAttachedUserPoliciesMethod][2] = test.getListAttachedUserPoliciesErr
+
+		url := fmt.Sprintf(server.URL+API_VERSION_1+"/users/%v/policies", test.filter.ExternalID)
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		addQueryParams(test.filter, req)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if !test.ignoreArgsIn {
+			// Check received parameter
+			filterData, ok := testApi.ArgsIn[ListAttachedUserPoliciesMethod][1].(*api.Filter)
+			if ok {
+				// Check result
+				assert.Equal(t, test.filter, filterData, "Error in test case %v", n)
+			}
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			getUserPoliciesResponse := ListAttachedUserPoliciesResponse{}
+			err = json.NewDecoder(res.Body).Decode(&getUserPoliciesResponse)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, getUserPoliciesResponse, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
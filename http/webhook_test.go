@@ -0,0 +1,847 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Tecsisa/foulkon/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerHandler_HandleAddWebhook(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API method args
+		request *CreateWebhookRequest
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   api.Webhook
+		expectedError      api.Error
+		// Manager Results
+		addWebhookResult *api.Webhook
+		// Manager Errors
+		addWebhookErr error
+	}{
+		"OkCase": {
+			request: &CreateWebhookRequest{
+				Name:   "test",
+				Path:   "/path/",
+				URL:    "https://hooks.test.com/foulkon",
+				Secret: "secret",
+				Events: []string{"user.*"},
+			},
+			addWebhookResult: &api.Webhook{
+				ID:       "test1",
+				Name:     "test",
+				Path:     "/path/",
+				CreateAt: now,
+				UpdateAt: now,
+				Urn:      api.CreateUrn("", api.RESOURCE_WEBHOOK, "/path/", "test"),
+				URL:      "https://hooks.test.com/foulkon",
+				Secret:   "secret",
+				Events:   []string{"user.*"},
+			},
+			expectedStatusCode: http.StatusCreated,
+			expectedResponse: api.Webhook{
+				ID:       "test1",
+				Name:     "test",
+				Path:     "/path/",
+				CreateAt: now,
+				UpdateAt: now,
+				Urn:      api.CreateUrn("", api.RESOURCE_WEBHOOK, "/path/", "test"),
+				URL:      "https://hooks.test.com/foulkon",
+				Events:   []string{"user.*"},
+			},
+		},
+		"ErrorCaseMalformedRequest": {
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "EOF",
+			},
+		},
+		"ErrorCaseWebhookAlreadyExists": {
+			request: &CreateWebhookRequest{
+				Name: "test",
+				Path: "/path/",
+			},
+			expectedStatusCode: http.StatusConflict,
+			expectedError: api.Error{
+				Code:    api.WEBHOOK_ALREADY_EXIST,
+				Message: "Webhook already exist",
+			},
+			addWebhookErr: &api.Error{
+				Code:    api.WEBHOOK_ALREADY_EXIST,
+				Message: "Webhook already exist",
+			},
+		},
+		"ErrorCaseInvalidParameterError": {
+			request: &CreateWebhookRequest{
+				Name: "test",
+				Path: "/path/",
+				URL:  "ftp://hooks.test.com",
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid Parameter",
+			},
+			addWebhookErr: &api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid Parameter",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			request: &CreateWebhookRequest{
+				Name: "test",
+				Path: "/path/",
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			addWebhookErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			request: &CreateWebhookRequest{
+				Name: "test",
+				Path: "/path/",
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			addWebhookErr: &api.Error{
+				Code: api.UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[AddWebhookMethod][0] = test.addWebhookResult
+		testApi.ArgsOut[AddWebhookMethod][1] = test.addWebhookErr
+
+		var body *bytes.Buffer
+		if test.request != nil {
+			jsonObject, err := json.Marshal(test.request)
+			assert.Nil(t, err, "Error in test case %v", n)
+			body = bytes.NewBuffer(jsonObject)
+		}
+		if body == nil {
+			body = bytes.NewBuffer([]byte{})
+		}
+
+		url := fmt.Sprintf(server.URL + WEBHOOK_ROOT_URL)
+		req, err := http.NewRequest(http.MethodPost, url, body)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if test.request != nil {
+			// Check received parameters
+			assert.Equal(t, test.request.Name, testApi.ArgsIn[AddWebhookMethod][1], "Error in test case %v", n)
+			assert.Equal(t, test.request.Path, testApi.ArgsIn[AddWebhookMethod][2], "Error in test case %v", n)
+			assert.Equal(t, test.request.URL, testApi.ArgsIn[AddWebhookMethod][3], "Error in test case %v", n)
+			assert.Equal(t, test.request.Secret, testApi.ArgsIn[AddWebhookMethod][4], "Error in test case %v", n)
+			assert.Equal(t, test.request.Events, testApi.ArgsIn[AddWebhookMethod][5], "Error in test case %v", n)
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusCreated:
+			response := api.Webhook{}
+			err = json.NewDecoder(res.Body).Decode(&response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result, secret is never returned
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleGetWebhookByName(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API method args
+		webhookName  string
+		offset       string
+		ignoreArgsIn bool
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   api.Webhook
+		expectedError      api.Error
+		// Manager Results
+		getWebhookByNameResult *api.Webhook
+		// Manager Errors
+		getWebhookByNameErr error
+	}{
+		"OkCase": {
+			webhookName:        "webhook1",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: api.Webhook{
+				ID:       "test1",
+				Name:     "webhook1",
+				Path:     "/path/",
+				CreateAt: now,
+				UpdateAt: now,
+				Urn:      api.CreateUrn("", api.RESOURCE_WEBHOOK, "/path/", "webhook1"),
+				URL:      "https://hooks.test.com/foulkon",
+				Events:   []string{"*"},
+			},
+			getWebhookByNameResult: &api.Webhook{
+				ID:       "test1",
+				Name:     "webhook1",
+				Path:     "/path/",
+				CreateAt: now,
+				UpdateAt: now,
+				Urn:      api.CreateUrn("", api.RESOURCE_WEBHOOK, "/path/", "webhook1"),
+				URL:      "https://hooks.test.com/foulkon",
+				Secret:   "secret",
+				Events:   []string{"*"},
+			},
+		},
+		"ErrorCaseInvalidRequest": {
+			webhookName:        "webhook1",
+			offset:             "-1",
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: Offset -1",
+			},
+		},
+		"ErrorCaseWebhookNotFound": {
+			webhookName:        "webhook1",
+			expectedStatusCode: http.StatusNotFound,
+			getWebhookByNameErr: &api.Error{
+				Code:    api.WEBHOOK_BY_NAME_NOT_FOUND,
+				Message: "Webhook not found",
+			},
+			expectedError: api.Error{
+				Code:    api.WEBHOOK_BY_NAME_NOT_FOUND,
+				Message: "Webhook not found",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			webhookName:        "webhook1",
+			expectedStatusCode: http.StatusForbidden,
+			getWebhookByNameErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseInternalServerError": {
+			webhookName:        "webhook1",
+			expectedStatusCode: http.StatusInternalServerError,
+			getWebhookByNameErr: &api.Error{
+				Code: api.UNKNOWN_API_ERROR,
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[GetWebhookByNameMethod][0] = test.getWebhookByNameResult
+		testApi.ArgsOut[GetWebhookByNameMethod][1] = test.getWebhookByNameErr
+
+		url := fmt.Sprintf(server.URL+WEBHOOK_ROOT_URL+"/%v", test.webhookName)
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		q := req.URL.Query()
+		q.Add("Offset", test.offset)
+		req.URL.RawQuery = q.Encode()
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if !test.ignoreArgsIn {
+			// Check received parameters
+			assert.Equal(t, test.webhookName, testApi.ArgsIn[GetWebhookByNameMethod][1], "Error in test case %v", n)
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			response := api.Webhook{}
+			err = json.NewDecoder(res.Body).Decode(&response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+			assert.Equal(t, api.EntityTag(test.expectedResponse.UpdateAt), res.Header.Get(ETAG_HEADER), "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleListWebhooks(t *testing.T) {
+	testcases := map[string]struct {
+		// API method args
+		filter       *api.Filter
+		ignoreArgsIn bool
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   ListWebhooksResponse
+		expectedError      api.Error
+		// Manager Results
+		listWebhooksResult []string
+		listWebhooksTotal  int
+		// Manager Errors
+		listWebhooksErr error
+	}{
+		"OkCase": {
+			filter: &api.Filter{
+				PathPrefix: "/path/",
+				Offset:     0,
+				Limit:      0,
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: ListWebhooksResponse{
+				Webhooks: []string{"webhook1"},
+				Offset:   0,
+				Limit:    0,
+				Total:    1,
+			},
+			listWebhooksResult: []string{
+				"webhook1",
+			},
+			listWebhooksTotal: 1,
+		},
+		"ErrorCaseInvalidFilterParams": {
+			filter: &api.Filter{
+				PathPrefix: "",
+				Limit:      -1,
+			},
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: Limit -1",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			filter: &api.Filter{
+				PathPrefix: "/path/",
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			listWebhooksErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			filter:             testFilter,
+			expectedStatusCode: http.StatusInternalServerError,
+			listWebhooksErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[ListWebhooksMethod][0] = test.listWebhooksResult
+		testApi.ArgsOut[ListWebhooksMethod][1] = test.listWebhooksTotal
+		testApi.ArgsOut[ListWebhooksMethod][2] = test.listWebhooksErr
+
+		url := fmt.Sprintf(server.URL + WEBHOOK_ROOT_URL)
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		addQueryParams(test.filter, req)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if !test.ignoreArgsIn {
+			// Check received parameters
+			filterData, ok := testApi.ArgsIn[ListWebhooksMethod][1].(*api.Filter)
+			if ok {
+				// Check result
+				assert.Equal(t, test.filter, filterData, "Error in test case %v", n)
+			}
+		}
+
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			listWebhooksResponse := ListWebhooksResponse{}
+			err = json.NewDecoder(res.Body).Decode(&listWebhooksResponse)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, listWebhooksResponse, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleUpdateWebhook(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API method args
+		webhookName string
+		request     *UpdateWebhookRequest
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   api.Webhook
+		expectedError      api.Error
+		// Manager Results
+		updateWebhookResult *api.Webhook
+		// Manager Errors
+		updateWebhookErr error
+	}{
+		"OkCase": {
+			webhookName: "webhook1",
+			request: &UpdateWebhookRequest{
+				Name:   "newName",
+				Path:   "/newPath/",
+				URL:    "https://hooks.test.com/new",
+				Events: []string{"group.*", "policy.*"},
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: api.Webhook{
+				ID:       "ID",
+				Name:     "newName",
+				Path:     "/newPath/",
+				Urn:      "urn",
+				CreateAt: now,
+				UpdateAt: now,
+				URL:      "https://hooks.test.com/new",
+				Events:   []string{"group.*", "policy.*"},
+			},
+			updateWebhookResult: &api.Webhook{
+				ID:       "ID",
+				Name:     "newName",
+				Path:     "/newPath/",
+				Urn:      "urn",
+				CreateAt: now,
+				UpdateAt: now,
+				URL:      "https://hooks.test.com/new",
+				Secret:   "secret",
+				Events:   []string{"group.*", "policy.*"},
+			},
+		},
+		"ErrorCaseMalformedRequest": {
+			webhookName:        "webhook1",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "EOF",
+			},
+		},
+		"ErrorCaseWebhookNotFound": {
+			webhookName: "webhook1",
+			request: &UpdateWebhookRequest{
+				Name: "newName",
+				Path: "/newPath/",
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code:    api.WEBHOOK_BY_NAME_NOT_FOUND,
+				Message: "Webhook not found",
+			},
+			updateWebhookErr: &api.Error{
+				Code:    api.WEBHOOK_BY_NAME_NOT_FOUND,
+				Message: "Webhook not found",
+			},
+		},
+		"ErrorCaseWebhookAlreadyExistError": {
+			webhookName: "webhook1",
+			request: &UpdateWebhookRequest{
+				Name: "newName",
+				Path: "/newPath/",
+			},
+			expectedStatusCode: http.StatusConflict,
+			expectedError: api.Error{
+				Code:    api.WEBHOOK_ALREADY_EXIST,
+				Message: "Webhook already exist",
+			},
+			updateWebhookErr: &api.Error{
+				Code:    api.WEBHOOK_ALREADY_EXIST,
+				Message: "Webhook already exist",
+			},
+		},
+		"ErrorCasePreconditionFailed": {
+			webhookName: "webhook1",
+			request: &UpdateWebhookRequest{
+				Name: "newName",
+				Path: "/newPath/",
+			},
+			expectedStatusCode: http.StatusPreconditionFailed,
+			expectedError: api.Error{
+				Code:    api.PRECONDITION_FAILED,
+				Message: "Precondition failed",
+			},
+			updateWebhookErr: &api.Error{
+				Code:    api.PRECONDITION_FAILED,
+				Message: "Precondition failed",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			webhookName: "webhook1",
+			request: &UpdateWebhookRequest{
+				Name: "newName",
+				Path: "/newPath/",
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			updateWebhookErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[UpdateWebhookMethod][0] = test.updateWebhookResult
+		testApi.ArgsOut[UpdateWebhookMethod][1] = test.updateWebhookErr
+
+		var body *bytes.Buffer
+		if test.request != nil {
+			jsonObject, err := json.Marshal(test.request)
+			assert.Nil(t, err, "Error in test case %v", n)
+			body = bytes.NewBuffer(jsonObject)
+		}
+		if body == nil {
+			body = bytes.NewBuffer([]byte{})
+		}
+
+		url := fmt.Sprintf(server.URL+WEBHOOK_ROOT_URL+"/%v", test.webhookName)
+		req, err := http.NewRequest(http.MethodPut, url, body)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if test.request != nil {
+			// Check received parameters
+			assert.Equal(t, test.webhookName, testApi.ArgsIn[UpdateWebhookMethod][1], "Error in test case %v", n)
+			assert.Equal(t, test.request.Name, testApi.ArgsIn[UpdateWebhookMethod][2], "Error in test case %v", n)
+			assert.Equal(t, test.request.Path, testApi.ArgsIn[UpdateWebhookMethod][3], "Error in test case %v", n)
+			assert.Equal(t, test.request.URL, testApi.ArgsIn[UpdateWebhookMethod][4], "Error in test case %v", n)
+			assert.Equal(t, test.request.Secret, testApi.ArgsIn[UpdateWebhookMethod][5], "Error in test case %v", n)
+			assert.Equal(t, test.request.Events, testApi.ArgsIn[UpdateWebhookMethod][6], "Error in test case %v", n)
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			response := api.Webhook{}
+			err = json.NewDecoder(res.Body).Decode(&response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleRemoveWebhook(t *testing.T) {
+	testcases := map[string]struct {
+		// API method args
+		name         string
+		offset       string
+		ignoreArgsIn bool
+		// Expected result
+		expectedStatusCode int
+		expectedError      api.Error
+		// Manager Errors
+		removeWebhookErr error
+	}{
+		"OkCase": {
+			name:               "webhook1",
+			expectedStatusCode: http.StatusNoContent,
+		},
+		"ErrorCaseInvalidRequest": {
+			name:               "webhook1",
+			offset:             "-1",
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: Offset -1",
+			},
+		},
+		"ErrorCaseWebhookNotFound": {
+			name:               "webhook1",
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code:    api.WEBHOOK_BY_NAME_NOT_FOUND,
+				Message: "Webhook not found",
+			},
+			removeWebhookErr: &api.Error{
+				Code:    api.WEBHOOK_BY_NAME_NOT_FOUND,
+				Message: "Webhook not found",
+			},
+		},
+		"ErrorCaseUnauthorizedError": {
+			name:               "webhook1",
+			expectedStatusCode: http.StatusForbidden,
+			expectedError: api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+			removeWebhookErr: &api.Error{
+				Code:    api.UNAUTHORIZED_RESOURCES_ERROR,
+				Message: "Unauthorized",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			name:               "webhook1",
+			expectedStatusCode: http.StatusInternalServerError,
+			removeWebhookErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[RemoveWebhookMethod][0] = test.removeWebhookErr
+
+		url := fmt.Sprintf(server.URL+WEBHOOK_ROOT_URL+"/%v", test.name)
+		req, err := http.NewRequest(http.MethodDelete, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		q := req.URL.Query()
+		q.Add("Offset", test.offset)
+		req.URL.RawQuery = q.Encode()
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if !test.ignoreArgsIn {
+			// Check received parameters
+			assert.Equal(t, test.name, testApi.ArgsIn[RemoveWebhookMethod][1], "Error in test case %v", n)
+		}
+
+		// check status code
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusNoContent:
+			// No message expected
+			continue
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
+
+func TestWorkerHandler_HandleListWebhookDeadLetters(t *testing.T) {
+	now := time.Now().UTC()
+	testcases := map[string]struct {
+		// API method args
+		webhookName  string
+		filter       *api.Filter
+		ignoreArgsIn bool
+		// Expected result
+		expectedStatusCode int
+		expectedResponse   ListWebhookDeadLettersResponse
+		expectedError      api.Error
+		// Manager Results
+		listDeadLettersResult []api.WebhookDeadLetter
+		listDeadLettersTotal  int
+		// Manager Errors
+		listDeadLettersErr error
+	}{
+		"OkCase": {
+			webhookName: "webhook1",
+			filter: &api.Filter{
+				Offset: 0,
+				Limit:  0,
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: ListWebhookDeadLettersResponse{
+				DeadLetters: []api.WebhookDeadLetter{
+					{
+						ID: "dl1",
+						Event: api.WebhookEvent{
+							ID:       "event1",
+							Type:     api.WEBHOOK_EVENT_USER_CREATED,
+							Urn:      api.CreateUrn("", api.RESOURCE_USER, "/path/", "user1"),
+							CreateAt: now,
+						},
+						Attempts:  5,
+						LastError: "Unexpected status code 500",
+						CreateAt:  now,
+					},
+				},
+				Offset: 0,
+				Limit:  0,
+				Total:  1,
+			},
+			listDeadLettersResult: []api.WebhookDeadLetter{
+				{
+					ID:        "dl1",
+					WebhookID: "webhookID",
+					Event: api.WebhookEvent{
+						ID:       "event1",
+						Type:     api.WEBHOOK_EVENT_USER_CREATED,
+						Urn:      api.CreateUrn("", api.RESOURCE_USER, "/path/", "user1"),
+						CreateAt: now,
+					},
+					Attempts:  5,
+					LastError: "Unexpected status code 500",
+					CreateAt:  now,
+				},
+			},
+			listDeadLettersTotal: 1,
+		},
+		"ErrorCaseInvalidFilterParams": {
+			webhookName: "webhook1",
+			filter: &api.Filter{
+				Limit: -1,
+			},
+			ignoreArgsIn:       true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError: api.Error{
+				Code:    api.INVALID_PARAMETER_ERROR,
+				Message: "Invalid parameter: Limit -1",
+			},
+		},
+		"ErrorCaseWebhookNotFound": {
+			webhookName: "webhook1",
+			filter: &api.Filter{
+				Offset: 0,
+				Limit:  0,
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedError: api.Error{
+				Code:    api.WEBHOOK_BY_NAME_NOT_FOUND,
+				Message: "Webhook not found",
+			},
+			listDeadLettersErr: &api.Error{
+				Code:    api.WEBHOOK_BY_NAME_NOT_FOUND,
+				Message: "Webhook not found",
+			},
+		},
+		"ErrorCaseUnknownApiError": {
+			webhookName: "webhook1",
+			filter: &api.Filter{
+				Offset: 0,
+				Limit:  0,
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			listDeadLettersErr: &api.Error{
+				Code:    api.UNKNOWN_API_ERROR,
+				Message: "Error",
+			},
+		},
+	}
+
+	client := http.DefaultClient
+
+	for n, test := range testcases {
+
+		testApi.ArgsOut[ListWebhookDeadLettersMethod][0] = test.listDeadLettersResult
+		testApi.ArgsOut[ListWebhookDeadLettersMethod][1] = test.listDeadLettersTotal
+		testApi.ArgsOut[ListWebhookDeadLettersMethod][2] = test.listDeadLettersErr
+
+		url := fmt.Sprintf(server.URL+WEBHOOK_ROOT_URL+"/%v/dead-letters", test.webhookName)
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		addQueryParams(test.filter, req)
+
+		res, err := client.Do(req)
+		assert.Nil(t, err, "Error in test case %v", n)
+
+		if !test.ignoreArgsIn {
+			// Check received parameters
+			filterData, ok := testApi.ArgsIn[ListWebhookDeadLettersMethod][1].(*api.Filter)
+			if ok {
+				assert.Equal(t, test.webhookName, filterData.WebhookName, "Error in test case %v", n)
+				assert.Equal(t, test.filter.Offset, filterData.Offset, "Error in test case %v", n)
+				assert.Equal(t, test.filter.Limit, filterData.Limit, "Error in test case %v", n)
+			}
+		}
+
+		assert.Equal(t, test.expectedStatusCode, res.StatusCode, "Error in test case %v", n)
+
+		switch res.StatusCode {
+		case http.StatusOK:
+			response := ListWebhookDeadLettersResponse{}
+			err = json.NewDecoder(res.Body).Decode(&response)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check result
+			assert.Equal(t, test.expectedResponse, response, "Error in test case %v", n)
+		case http.StatusInternalServerError: // Empty message so continue
+			continue
+		default:
+			apiError := api.Error{}
+			err = json.NewDecoder(res.Body).Decode(&apiError)
+			assert.Nil(t, err, "Error in test case %v", n)
+			// Check error
+			assert.Equal(t, test.expectedError, apiError, "Error in test case %v", n)
+		}
+	}
+}
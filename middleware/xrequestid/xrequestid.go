@@ -2,11 +2,15 @@ package xrequestid
 
 import (
 	"net/http"
+	"regexp"
 
 	"github.com/Tecsisa/foulkon/middleware"
 	"github.com/satori/go.uuid"
 )
 
+// Request IDs received from callers are kept if they match this regex, so they can't inject text in the logs
+var rRequestID = regexp.MustCompile(`^[\w\-.:]{1,128}$`)
+
 // XRequestId middleware system
 type XRequestIdMiddleware struct{}
 
@@ -17,7 +21,11 @@ func NewXRequestIdMiddleware() *XRequestIdMiddleware {
 
 func (r *XRequestIdMiddleware) Action(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		requestID := uuid.NewV4().String()
+		// Keep the request ID of the caller to correlate its logs with the worker ones
+		requestID := r.Header.Get(middleware.REQUEST_ID_HEADER)
+		if !rRequestID.MatchString(requestID) {
+			requestID = uuid.NewV4().String()
+		}
 		r.Header.Set(middleware.REQUEST_ID_HEADER, requestID)
 		w.Header().Add(middleware.REQUEST_ID_HEADER, requestID)
 		next.ServeHTTP(w, r)
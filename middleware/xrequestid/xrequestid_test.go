@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/Tecsisa/foulkon/middleware"
@@ -40,6 +41,45 @@ func TestXRequestIdMiddleware_Action(t *testing.T) {
 	assert.Nil(t, err, "Error in test")
 }
 
+func TestXRequestIdMiddleware_ActionWithCallerRequestID(t *testing.T) {
+	testcases := map[string]struct {
+		requestID string
+		// Expected result
+		kept bool
+	}{
+		"OkCaseKeepRequestID": {
+			requestID: "caller-request.1:2",
+			kept:      true,
+		},
+		"OkCaseInvalidRequestID": {
+			requestID: "request\nInjected log line",
+		},
+		"OkCaseTooLongRequestID": {
+			requestID: strings.Repeat("a", 129),
+		},
+	}
+
+	for n, test := range testcases {
+		testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+		mw := NewXRequestIdMiddleware()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(middleware.REQUEST_ID_HEADER, test.requestID)
+		w := httptest.NewRecorder()
+		mw.Action(testHandler).ServeHTTP(w, req)
+
+		requestID := req.Header.Get(middleware.REQUEST_ID_HEADER)
+		assert.Equal(t, requestID, w.Result().Header.Get(middleware.REQUEST_ID_HEADER), "Error in test case %v", n)
+		if test.kept {
+			assert.Equal(t, test.requestID, requestID, "Error in test case %v", n)
+		} else {
+			_, err := uuid.FromString(requestID)
+			assert.Nil(t, err, "Error in test case %v", n)
+		}
+	}
+}
+
 func TestXRequestIdMiddleware_GetInfo(t *testing.T) {
 	mw := NewXRequestIdMiddleware()
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
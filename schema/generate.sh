@@ -4,4 +4,9 @@ prmd doc user.json > ../doc/api/user.md
 prmd doc policy.json > ../doc/api/policy.md
 prmd doc proxy_resource.json > ../doc/api/proxy_resource.md
 prmd doc resource.json > ../doc/api/resource.md
-prmd doc oidc_provider.json > ../doc/api/oidc_provider.md
\ No newline at end of file
+prmd doc oidc_provider.json > ../doc/api/oidc_provider.md
+prmd doc audit.json > ../doc/api/audit.md
+prmd doc webhook.json > ../doc/api/webhook.md
+prmd doc upstream_pool.json > ../doc/api/upstream_pool.md
+prmd doc proxy_config.json > ../doc/api/proxy_config.md
+prmd doc state.json > ../doc/api/state.md
\ No newline at end of file
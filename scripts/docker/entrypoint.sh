@@ -1,10 +1,12 @@
 #!/bin/sh
 
-usage() { echo "Usage: worker|proxy" 1>&2; exit 1; }
+usage() { echo "Usage: worker|proxy|migrate up|down|status" 1>&2; exit 1; }
 if [ "$1" = 'worker' ]; then
     worker -config-file=/worker.toml
 elif [ "$1" = 'proxy' ]; then
     proxy -proxy-file=/proxy.toml
+elif [ "$1" = 'migrate' ]; then
+    foulkon migrate -config-file=/worker.toml $2
 else
 	usage
 fi
\ No newline at end of file
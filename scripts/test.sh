@@ -7,7 +7,7 @@ echo "" > coverage.txt
 
 echo "--> Running tests"
 echo -e '----> Running unit tests'
-for d in $(go list ./... | grep -v '/vendor/' | egrep -v '/database/|cmd/|auth/oidc|foulkon/foulkon'); do
+for d in $(go list ./... | grep -v '/vendor/' | egrep -v '/database/|cmd/proxy|cmd/worker|auth/oidc|foulkon/foulkon'); do
     go test -race -coverprofile=profile.out -covermode=atomic $d || exit 1
     if [ -f profile.out ]; then
         cat profile.out >> coverage.txt
@@ -16,6 +16,22 @@ for d in $(go list ./... | grep -v '/vendor/' | egrep -v '/database/|cmd/|auth/o
 done
 
 echo -e '\n----> Running connector tests'
+# Memory
+echo -e '--------> Running in-memory connector'
+go test ./database/memory ${GOTEST_FLAGS:--race -coverprofile=profile.out -covermode=atomic} || exit 1
+if [ -f profile.out ]; then
+    cat profile.out >> coverage.txt
+    rm profile.out
+fi
+
+# SQLite
+echo -e '--------> Running SQLite connector'
+go test ./database/sqlite ${GOTEST_FLAGS:--race -coverprofile=profile.out -covermode=atomic} || exit 1
+if [ -f profile.out ]; then
+    cat profile.out >> coverage.txt
+    rm profile.out
+fi
+
 # Postgres
 echo -e '--------> Running PostgreSQL connector'
 echo $(echo -e 'Starting PostgreSQL (Docker container) postgrestest with id ') \